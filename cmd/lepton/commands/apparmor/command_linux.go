@@ -34,6 +34,7 @@ func Command() *cobra.Command {
 
 	cmd.AddCommand(
 		inspectCommand(),
+		generateCommand(),
 		loadCommand(),
 		unloadCommand(),
 	)
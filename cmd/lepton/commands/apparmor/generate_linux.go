@@ -0,0 +1,57 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package apparmor
+
+import (
+	"github.com/spf13/cobra"
+
+	"go.farcloser.world/lepton/cmd/lepton/completion"
+	"go.farcloser.world/lepton/cmd/lepton/helpers"
+	"go.farcloser.world/lepton/leptonic/services/containerd"
+	"go.farcloser.world/lepton/pkg/cmd/apparmor"
+)
+
+func generateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "generate CONTAINER",
+		Short:             "Display the AppArmor profile generated from the configuration of a container.",
+		Args:              helpers.IsExactArgs(1),
+		RunE:              generateAction,
+		ValidArgsFunction: generateShellComplete,
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+	}
+}
+
+func generateAction(cmd *cobra.Command, args []string) error {
+	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	cli, ctx, cancel, err := containerd.NewClient(cmd.Context(), globalOptions.Namespace, globalOptions.Address)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	return apparmor.Generate(ctx, cli, cmd.OutOrStdout(), args[0])
+}
+
+func generateShellComplete(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	return completion.ContainerNames(cmd, nil)
+}
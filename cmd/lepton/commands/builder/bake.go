@@ -0,0 +1,124 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package builder
+
+import (
+	"github.com/spf13/cobra"
+
+	"go.farcloser.world/lepton/cmd/lepton/completion"
+	"go.farcloser.world/lepton/cmd/lepton/helpers"
+	"go.farcloser.world/lepton/leptonic/services/containerd"
+	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/cmd/builder"
+)
+
+func bakeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bake [flags] [TARGET...]",
+		Short: "Build many targets from compose or bake files, concurrently",
+		Long: `Build many targets from compose or bake files, concurrently. Needs buildkitd to be running.
+Targets are read from the build sections of compose files, or from JSON and HCL bake files, which support groups,
+inheritance, variables and matrix.
+If no file is specified, compose.yaml, docker-compose.yml, docker-bake.json, docker-bake.hcl, and their override
+variants are looked up in the current directory.
+If no target is specified, the "default" group is built.`,
+		RunE:          bakeAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	helpers.AddStringFlag(cmd, "buildkit-host", nil, "", "BUILDKIT_HOST", "BuildKit address")
+	cmd.Flags().String("builder", "", "Override the configured builder instance")
+	cmd.Flags().StringArrayP("file", "f", nil, "Compose or bake file")
+	cmd.Flags().Bool("no-cache", false, "Do not use cache when building the images")
+	cmd.Flags().Bool("pull", false, "Always attempt to pull all referenced images")
+	cmd.Flags().String("progress", "auto", "Set type of progress output (auto, plain, tty, rawjson, quiet)")
+	cmd.Flags().Bool("print", false, "Print the resolved targets in JSON, without building")
+
+	_ = cmd.RegisterFlagCompletionFunc("builder", completion.BuilderNames)
+
+	return cmd
+}
+
+func bakeOptions(cmd *cobra.Command, args []string) (*options.BuilderBake, error) {
+	files, err := cmd.Flags().GetStringArray("file")
+	if err != nil {
+		return nil, err
+	}
+
+	noCache, err := cmd.Flags().GetBool("no-cache")
+	if err != nil {
+		return nil, err
+	}
+
+	pull, err := cmd.Flags().GetBool("pull")
+	if err != nil {
+		return nil, err
+	}
+
+	progress, err := cmd.Flags().GetString("progress")
+	if err != nil {
+		return nil, err
+	}
+
+	printOnly, err := cmd.Flags().GetBool("print")
+	if err != nil {
+		return nil, err
+	}
+
+	return &options.BuilderBake{
+		Stdout:   cmd.OutOrStdout(),
+		Stderr:   cmd.OutOrStderr(),
+		Files:    files,
+		Targets:  args,
+		NoCache:  noCache,
+		Pull:     pull,
+		Progress: progress,
+		Print:    printOnly,
+	}, nil
+}
+
+func bakeAction(cmd *cobra.Command, args []string) error {
+	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	opts, err := bakeOptions(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	if !opts.Print {
+		inst, err := helpers.ProcessBuilderOption(cmd, globalOptions)
+		if err != nil {
+			return err
+		}
+
+		opts.BuildKitHost = inst.Host
+		opts.Platform = inst.Platforms
+	}
+
+	cli, ctx, cancel, err := containerd.NewClient(cmd.Context(), globalOptions.Namespace, globalOptions.Address)
+	if err != nil {
+		return err
+	}
+
+	defer cancel()
+
+	return builder.Bake(ctx, cli, globalOptions, opts)
+}
@@ -44,6 +44,7 @@ If Dockerfile is not present and -f is not specified, it will look for Container
 	}
 
 	helpers.AddStringFlag(cmd, "buildkit-host", nil, "", "BUILDKIT_HOST", "BuildKit address")
+	cmd.Flags().String("builder", "", "Override the configured builder instance")
 	cmd.Flags().StringArray("add-host", nil, "Add a custom host-to-IP mapping (format: \"host:ip\")")
 	cmd.Flags().StringArrayP("tag", "t", nil, "Name and optionally a tag in the 'name:tag' format")
 	cmd.Flags().StringP("file", "f", "", "Name of the Dockerfile")
@@ -52,7 +53,11 @@ If Dockerfile is not present and -f is not specified, it will look for Container
 	cmd.Flags().Bool("no-cache", false, "Do not use cache when building the image")
 	cmd.Flags().StringP("output", "o", "", "Output destination (format: type=local,dest=path)")
 	cmd.Flags().
-		String("progress", "auto", "Set type of progress output (auto, plain, tty). Use plain to show container output")
+		String(
+			"progress",
+			"auto",
+			"Set type of progress output (auto, plain, tty, rawjson, quiet). Use plain to show container output",
+		)
 	cmd.Flags().String("provenance", "", "Shorthand for \"--attest=type=provenance\"")
 	cmd.Flags().
 		Bool("pull", false, "On true, always attempt to pull latest image version from remote. Default uses buildkit's default.")
@@ -87,6 +92,7 @@ If Dockerfile is not present and -f is not specified, it will look for Container
 		},
 	)
 	_ = cmd.RegisterFlagCompletionFunc("platform", completion.Platforms)
+	_ = cmd.RegisterFlagCompletionFunc("builder", completion.BuilderNames)
 
 	return cmd
 }
@@ -276,11 +282,16 @@ func buildAction(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	opts.BuildKitHost, err = helpers.ProcessBuildkitHostOption(cmd, globalOptions.Namespace)
+	inst, err := helpers.ProcessBuilderOption(cmd, globalOptions)
 	if err != nil {
 		return err
 	}
 
+	opts.BuildKitHost = inst.Host
+	if len(opts.Platform) == 0 {
+		opts.Platform = inst.Platforms
+	}
+
 	cli, ctx, cancel, err := containerd.NewClient(cmd.Context(), globalOptions.Namespace, globalOptions.Address)
 	if err != nil {
 		return err
@@ -35,7 +35,14 @@ func Command() *cobra.Command {
 	cmd.AddCommand(
 		BuildCommand(),
 		pruneCommand(),
+		duCommand(),
+		bakeCommand(),
 		debugCommand(),
+		createCommand(),
+		listCommand(),
+		inspectCommand(),
+		useCommand(),
+		removeCommand(),
 	)
 
 	return cmd
@@ -0,0 +1,98 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package builder
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"go.farcloser.world/lepton/cmd/lepton/completion"
+	"go.farcloser.world/lepton/cmd/lepton/helpers"
+	"go.farcloser.world/lepton/leptonic/utils"
+	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/cmd/builder"
+	"go.farcloser.world/lepton/pkg/strutil"
+)
+
+func createCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "create [flags] NAME",
+		Short:         "Create a named builder instance",
+		Args:          helpers.IsExactArgs(1),
+		RunE:          createAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	cmd.Flags().String("buildkit-host", "", "BuildKit address of the builder")
+	cmd.Flags().StringSlice("platform", []string{}, "Default target platforms when building with this builder")
+	cmd.Flags().StringArray("worker-label", nil, "Require the BuildKit worker to have this label (key=value)")
+	cmd.Flags().Bool("use", false, "Set the new builder as the default")
+
+	_ = cmd.RegisterFlagCompletionFunc("platform", completion.Platforms)
+
+	return cmd
+}
+
+func createOptions(cmd *cobra.Command, args []string) (*options.BuilderCreate, error) {
+	buildkitHost, err := cmd.Flags().GetString("buildkit-host")
+	if err != nil {
+		return nil, err
+	}
+
+	if buildkitHost == "" {
+		return nil, errors.New("--buildkit-host needs to be specified")
+	}
+
+	platform, err := cmd.Flags().GetStringSlice("platform")
+	if err != nil {
+		return nil, err
+	}
+
+	workerLabels, err := cmd.Flags().GetStringArray("worker-label")
+	if err != nil {
+		return nil, err
+	}
+
+	use, err := cmd.Flags().GetBool("use")
+	if err != nil {
+		return nil, err
+	}
+
+	return &options.BuilderCreate{
+		Name:         args[0],
+		BuildKitHost: buildkitHost,
+		Platforms:    strutil.DedupeStrSlice(platform),
+		WorkerLabels: utils.StringSlice2KVMap(workerLabels, "="),
+		Use:          use,
+	}, nil
+}
+
+func createAction(cmd *cobra.Command, args []string) error {
+	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	opts, err := createOptions(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	return builder.Create(cmd.Context(), cmd.OutOrStdout(), globalOptions, opts)
+}
@@ -0,0 +1,88 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package builder
+
+import (
+	"github.com/spf13/cobra"
+
+	"go.farcloser.world/lepton/cmd/lepton/helpers"
+	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/cmd/builder"
+	"go.farcloser.world/lepton/pkg/formatter"
+)
+
+func duCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "du",
+		Args:          cobra.NoArgs,
+		Short:         "Show BuildKit build cache disk usage",
+		RunE:          duAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	helpers.AddStringFlag(cmd, "buildkit-host", nil, "", "BUILDKIT_HOST", "BuildKit address")
+	cmd.Flags().BoolP("verbose", "v", false, "Show all the details of each build cache record")
+	cmd.Flags().String("format", "",
+		"Format the output using the given Go template, e.g, 'table {{.FormattedID}}\\t{{.FormattedSize}}', "+
+			"or one of 'table', 'wide', 'json', 'jsonl', 'yaml', 'csv'")
+
+	_ = cmd.RegisterFlagCompletionFunc(
+		"format",
+		func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return formatter.Formats, cobra.ShellCompDirectiveNoFileComp
+		},
+	)
+
+	return cmd
+}
+
+func duOptions(cmd *cobra.Command, _ []string) (*options.BuilderDiskUsage, error) {
+	verbose, err := cmd.Flags().GetBool("verbose")
+	if err != nil {
+		return nil, err
+	}
+
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return nil, err
+	}
+
+	return &options.BuilderDiskUsage{
+		Verbose: verbose,
+		Format:  format,
+	}, nil
+}
+
+func duAction(cmd *cobra.Command, args []string) error {
+	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	opts, err := duOptions(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	opts.BuildKitHost, err = helpers.ProcessBuildkitHostOption(cmd, globalOptions)
+	if err != nil {
+		return err
+	}
+
+	return builder.DiskUsage(cmd.Context(), cmd.OutOrStdout(), globalOptions, opts)
+}
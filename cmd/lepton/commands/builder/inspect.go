@@ -0,0 +1,68 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package builder
+
+import (
+	"github.com/spf13/cobra"
+
+	"go.farcloser.world/lepton/cmd/lepton/completion"
+	"go.farcloser.world/lepton/cmd/lepton/helpers"
+	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/cmd/builder"
+)
+
+func inspectCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "inspect [flags] NAME [NAME...]",
+		Short:             "Display detailed information on one or more builder instances",
+		Args:              cobra.MinimumNArgs(1),
+		RunE:              inspectAction,
+		ValidArgsFunction: completion.BuilderNames,
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+	}
+
+	cmd.Flags().StringP("format", "f", "", "Format the output using the given Go template, e.g, '{{json .}}'")
+
+	return cmd
+}
+
+func inspectOptions(cmd *cobra.Command, args []string) (*options.BuilderInspect, error) {
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return nil, err
+	}
+
+	return &options.BuilderInspect{
+		NamesList: args,
+		Format:    format,
+	}, nil
+}
+
+func inspectAction(cmd *cobra.Command, args []string) error {
+	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	opts, err := inspectOptions(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	return builder.Inspect(cmd.Context(), cmd.OutOrStdout(), globalOptions, opts)
+}
@@ -0,0 +1,83 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package builder
+
+import (
+	"github.com/spf13/cobra"
+
+	"go.farcloser.world/lepton/cmd/lepton/helpers"
+	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/cmd/builder"
+	"go.farcloser.world/lepton/pkg/formatter"
+)
+
+func listCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "list",
+		Aliases:       []string{"ls"},
+		Short:         "List builder instances",
+		Args:          cobra.NoArgs,
+		RunE:          listAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	cmd.Flags().BoolP("quiet", "q", false, "Only display names")
+	cmd.Flags().String("format", "",
+		"Format the output using the given Go template, e.g, 'table {{.Name}}\\t{{.Status}}', "+
+			"or one of 'table', 'wide', 'json', 'jsonl', 'yaml', 'csv'")
+
+	_ = cmd.RegisterFlagCompletionFunc(
+		"format",
+		func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return formatter.Formats, cobra.ShellCompDirectiveNoFileComp
+		},
+	)
+
+	return cmd
+}
+
+func listOptions(cmd *cobra.Command) (*options.BuilderList, error) {
+	quiet, err := cmd.Flags().GetBool("quiet")
+	if err != nil {
+		return nil, err
+	}
+
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return nil, err
+	}
+
+	return &options.BuilderList{
+		Quiet:  quiet,
+		Format: format,
+	}, nil
+}
+
+func listAction(cmd *cobra.Command, _ []string) error {
+	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	opts, err := listOptions(cmd)
+	if err != nil {
+		return err
+	}
+
+	return builder.List(cmd.Context(), cmd.OutOrStdout(), globalOptions, opts)
+}
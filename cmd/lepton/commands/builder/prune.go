@@ -21,6 +21,8 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"go.farcloser.world/core/units"
+
 	"go.farcloser.world/lepton/cmd/lepton/helpers"
 	"go.farcloser.world/lepton/pkg/api/options"
 	"go.farcloser.world/lepton/pkg/cmd/builder"
@@ -39,6 +41,9 @@ func pruneCommand() *cobra.Command {
 	helpers.AddStringFlag(cmd, "buildkit-host", nil, "", "BUILDKIT_HOST", "BuildKit address")
 	cmd.Flags().BoolP("all", "a", false, "Remove all unused build cache, not just dangling ones")
 	cmd.Flags().BoolP("force", "f", false, "Do not prompt for confirmation")
+	cmd.Flags().StringArray("filter", nil, "Filter the build cache to prune (e.g. 'until=24h', 'type=regular')")
+	cmd.Flags().String("keep-storage", "", "Amount of disk space to keep for the build cache (e.g. '10GB')")
+	cmd.Flags().Duration("keep-duration", 0, "Keep the build cache used more recently than this duration (e.g. '48h')")
 
 	return cmd
 }
@@ -54,6 +59,28 @@ func pruneOptions(cmd *cobra.Command, _ []string) (*options.BuilderPrune, error)
 		return nil, err
 	}
 
+	filters, err := cmd.Flags().GetStringArray("filter")
+	if err != nil {
+		return nil, err
+	}
+
+	keepDuration, err := cmd.Flags().GetDuration("keep-duration")
+	if err != nil {
+		return nil, err
+	}
+
+	keepStorageStr, err := cmd.Flags().GetString("keep-storage")
+	if err != nil {
+		return nil, err
+	}
+
+	var keepStorage int64
+	if keepStorageStr != "" {
+		if keepStorage, err = units.RAMInBytes(keepStorageStr); err != nil {
+			return nil, fmt.Errorf("invalid --keep-storage %q: %w", keepStorageStr, err)
+		}
+	}
+
 	if !force {
 		var msg string
 
@@ -69,9 +96,12 @@ func pruneOptions(cmd *cobra.Command, _ []string) (*options.BuilderPrune, error)
 	}
 
 	return &options.BuilderPrune{
-		Stderr: cmd.OutOrStderr(),
-		All:    all,
-		Force:  force,
+		Stderr:       cmd.OutOrStderr(),
+		All:          all,
+		Force:        force,
+		Filters:      filters,
+		KeepDuration: keepDuration,
+		KeepStorage:  keepStorage,
 	}, nil
 }
 
@@ -86,7 +116,7 @@ func pruneAction(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	opts.BuildKitHost, err = helpers.ProcessBuildkitHostOption(cmd, globalOptions.Namespace)
+	opts.BuildKitHost, err = helpers.ProcessBuildkitHostOption(cmd, globalOptions)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,49 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package builder
+
+import (
+	"github.com/spf13/cobra"
+
+	"go.farcloser.world/lepton/cmd/lepton/completion"
+	"go.farcloser.world/lepton/cmd/lepton/helpers"
+	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/cmd/builder"
+)
+
+func useCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "use NAME",
+		Short:             "Set the default builder instance (\"default\" reverts to the implicit builder)",
+		Args:              helpers.IsExactArgs(1),
+		RunE:              useAction,
+		ValidArgsFunction: completion.BuilderNames,
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+	}
+
+	return cmd
+}
+
+func useAction(cmd *cobra.Command, args []string) error {
+	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	return builder.Use(cmd.Context(), globalOptions, &options.BuilderUse{Name: args[0]})
+}
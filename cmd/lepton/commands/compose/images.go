@@ -21,7 +21,6 @@ import (
 	"fmt"
 	"os"
 	"strings"
-	"text/tabwriter"
 
 	"github.com/containerd/containerd/v2/client"
 	"github.com/containerd/containerd/v2/core/snapshots"
@@ -47,9 +46,18 @@ func imagesCommand() *cobra.Command {
 		SilenceErrors: true,
 	}
 
-	cmd.Flags().String("format", "", "Format the output. Supported values: [json]")
+	cmd.Flags().String("format", "",
+		"Format the output using the given Go template, e.g, 'table {{.ContainerName}}\\t{{.Size}}', "+
+			"or one of 'table', 'wide', 'json', 'jsonl', 'yaml', 'csv'")
 	cmd.Flags().BoolP("quiet", "q", false, "Only show numeric image IDs")
 
+	_ = cmd.RegisterFlagCompletionFunc(
+		"format",
+		func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return formatter.Formats, cobra.ShellCompDirectiveNoFileComp
+		},
+	)
+
 	return cmd
 }
 
@@ -67,9 +75,6 @@ func imagesAction(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	if format != formatter.FormatJSON && format != "" {
-		return fmt.Errorf("unsupported format %s, supported formats are: [json]", format)
-	}
 
 	cli, ctx, cancel, err := containerd.NewClient(cmd.Context(), globalOptions.Namespace, globalOptions.Address)
 	if err != nil {
@@ -81,7 +86,7 @@ func imagesAction(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	c, err := compose.New(cli, globalOptions, options, cmd.OutOrStdout(), cmd.ErrOrStderr())
+	c, err := compose.New(ctx, cli, globalOptions, options, cmd.OutOrStdout(), cmd.ErrOrStderr())
 	if err != nil {
 		return err
 	}
@@ -133,14 +138,6 @@ func printComposeImages(
 	sn snapshots.Snapshotter,
 	format string,
 ) error {
-	type composeImagePrintable struct {
-		ContainerName string
-		Repository    string
-		Tag           string
-		ImageID       string
-		Size          string
-	}
-
 	imagePrintables := make([]composeImagePrintable, len(containers))
 	eg, ctx := errgroup.WithContext(ctx)
 	for i, c := range containers {
@@ -170,10 +167,6 @@ func printComposeImages(
 			if tag == "" {
 				tag = "<none>"
 			}
-			if format != formatter.FormatJSON {
-				imageID = strings.Split(imageID, ":")[1][:12]
-			}
-
 			// no race condition since each goroutine accesses different `i`
 			imagePrintables[i] = composeImagePrintable{
 				ContainerName: containerName,
@@ -191,28 +184,30 @@ func printComposeImages(
 		return err
 	}
 
-	if format == formatter.FormatJSON {
-		outJSON, err := formatter.ToJSON(imagePrintables, "", "")
-		if err != nil {
-			return err
-		}
-		_, err = fmt.Fprint(cmd.OutOrStdout(), outJSON)
-		return err
-	}
+	return formatter.Render(cmd.OutOrStdout(), format, imagePrintables, []formatter.Column{
+		{Header: "CONTAINER", Field: "ContainerName"},
+		{Header: "REPOSITORY", Field: "Repository"},
+		{Header: "TAG", Field: "Tag"},
+		{Header: "IMAGE ID", Field: "ShortImageID"},
+		{Header: "SIZE", Field: "Size"},
+	})
+}
 
-	w := tabwriter.NewWriter(cmd.OutOrStdout(), 4, 8, 4, ' ', 0)
-	fmt.Fprintln(w, "Container\tRepository\tTag\tImage Id\tSize")
-	for _, p := range imagePrintables {
-		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
-			p.ContainerName,
-			p.Repository,
-			p.Tag,
-			p.ImageID,
-			p.Size,
-		); err != nil {
-			return err
-		}
+// composeImagePrintable is an image used by a container of the project.
+type composeImagePrintable struct {
+	ContainerName string
+	Repository    string
+	Tag           string
+	ImageID       string
+	Size          string
+}
+
+// ShortImageID returns the truncated image ID, as displayed in tables.
+func (p composeImagePrintable) ShortImageID() string {
+	_, id, _ := strings.Cut(p.ImageID, ":")
+	if len(id) > 12 {
+		return id[:12]
 	}
 
-	return w.Flush()
+	return id
 }
@@ -162,8 +162,8 @@ volumes:
 		}
 	}
 
-	// check other formats are not supported
-	base.ComposeCmd("-f", comp.YAMLFullPath(), "images", "--format", "yaml").AssertFail()
+	// check other formats are rendered as well
+	base.ComposeCmd("-f", comp.YAMLFullPath(), "images", "--format", "yaml").AssertOutContains("ContainerName: db")
 	// check all services are up (can be marshalled and unmarshalled)
 	base.ComposeCmd("-f", comp.YAMLFullPath(), "images", "--format", formatter.FormatJSON).
 		AssertOutWithFunc(assertHandler("all", 2, `"ContainerName":"wordpress"`, `"ContainerName":"db"`))
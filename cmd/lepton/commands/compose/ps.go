@@ -20,7 +20,6 @@ import (
 	"context"
 	"fmt"
 	"strings"
-	"text/tabwriter"
 	"time"
 
 	"github.com/containerd/containerd/v2/client"
@@ -49,7 +48,9 @@ func psCommand() *cobra.Command {
 		SilenceErrors: true,
 	}
 
-	cmd.Flags().String("format", formatter.FormatTable, "Format the output. Supported values: [table|json]")
+	cmd.Flags().String("format", formatter.FormatTable,
+		"Format the output using the given Go template, e.g, 'table {{.Name}}\\t{{.State}}', "+
+			"or one of 'table', 'wide', 'json', 'jsonl', 'yaml', 'csv'")
 	cmd.Flags().String("filter", "", "Filter matches containers based on given conditions")
 	cmd.Flags().
 		StringArray("status", []string{}, "Filter services by status. Values: [paused | restarting | removing | running | dead | created | exited]")
@@ -85,9 +86,6 @@ func psAction(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	if format != formatter.FormatJSON && format != formatter.FormatTable {
-		return fmt.Errorf("unsupported format %s, supported formats are: [table|json]", format)
-	}
 	status, err := cmd.Flags().GetStringArray("status")
 	if err != nil {
 		return err
@@ -133,7 +131,7 @@ func psAction(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	c, err := compose.New(cli, globalOptions, options, cmd.OutOrStdout(), cmd.ErrOrStderr())
+	c, err := compose.New(ctx, cli, globalOptions, options, cmd.OutOrStdout(), cmd.ErrOrStderr())
 	if err != nil {
 		return err
 	}
@@ -181,16 +179,20 @@ func psAction(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	// Tables display the formatted ports, other formats the docker-compatible publishers
+	tabular := format == formatter.FormatNone || format == formatter.FormatTable ||
+		format == formatter.FormatWide || format == formatter.FormatCSV ||
+		strings.HasPrefix(format, formatter.FormatTable+" ")
 	containersPrintable := make([]ContainerPrintable, len(containers))
 	eg, ctx := errgroup.WithContext(ctx)
 	for i, container := range containers {
 		eg.Go(func() error {
 			var p ContainerPrintable
 			var err error
-			if format == formatter.FormatJSON {
-				p, err = composeContainerPrintableJSON(ctx, container)
-			} else {
+			if tabular {
 				p, err = composeContainerPrintableTab(ctx, container)
+			} else {
+				p, err = composeContainerPrintableJSON(ctx, container)
 			}
 			if err != nil {
 				return err
@@ -210,31 +212,15 @@ func psAction(cmd *cobra.Command, args []string) error {
 		}
 		return nil
 	}
-	if format == formatter.FormatJSON {
-		outJSON, err := formatter.ToJSON(containersPrintable, "", "")
-		if err != nil {
-			return err
-		}
-		_, err = fmt.Fprint(cmd.OutOrStdout(), outJSON)
-		return err
-	}
-
-	w := tabwriter.NewWriter(cmd.OutOrStdout(), 4, 8, 4, ' ', 0)
-	fmt.Fprintln(w, "NAME\tIMAGE\tCOMMAND\tSERVICE\tSTATUS\tPORTS")
-	for _, p := range containersPrintable {
-		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
-			p.Name,
-			p.Image,
-			p.Command,
-			p.Service,
-			p.State,
-			p.Ports,
-		); err != nil {
-			return err
-		}
-	}
 
-	return w.Flush()
+	return formatter.Render(cmd.OutOrStdout(), format, containersPrintable, []formatter.Column{
+		{Header: "NAME", Field: "Name"},
+		{Header: "IMAGE", Field: "Image"},
+		{Header: "COMMAND", Field: "Command"},
+		{Header: "SERVICE", Field: "Service"},
+		{Header: "STATUS", Field: "State"},
+		{Header: "PORTS", Field: "Ports"},
+	})
 }
 
 // composeContainerPrintableTab constructs ContainerPrintable with fields
@@ -195,8 +195,9 @@ volumes:
 		}
 	}
 
-	// check other formats are not supported
-	base.ComposeCmd("-f", comp.YAMLFullPath(), "ps", "--format", "yaml").AssertFail()
+	// check yaml output has the same fields
+	base.ComposeCmd("-f", comp.YAMLFullPath(), "ps", "--format", "yaml").
+		AssertOutContainsAll("Service: wordpress", "Service: db")
 	// check all services are up (can be marshalled and unmarshalled) and check Image field exists
 	base.ComposeCmd("-f", comp.YAMLFullPath(), "ps", "--format", formatter.FormatJSON).
 		AssertOutWithFunc(assertHandler(
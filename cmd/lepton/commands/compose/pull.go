@@ -35,6 +35,7 @@ func pullCommand() *cobra.Command {
 	}
 
 	cmd.Flags().BoolP("quiet", "q", false, "Pull without printing progress information")
+	helpers.AddProgressFlag(cmd)
 
 	return cmd
 }
@@ -54,7 +55,7 @@ func pullAction(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	c, err := compose.New(cli, globalOptions, options, cmd.OutOrStdout(), cmd.ErrOrStderr())
+	c, err := compose.New(ctx, cli, globalOptions, options, cmd.OutOrStdout(), cmd.ErrOrStderr())
 	if err != nil {
 		return err
 	}
@@ -63,8 +64,13 @@ func pullAction(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	progress, err := helpers.ProcessProgressOption(cmd)
+	if err != nil {
+		return err
+	}
 	po := composer.PullOptions{
-		Quiet: quiet,
+		Quiet:    quiet,
+		Progress: progress,
 	}
 	return c.Pull(ctx, po, args)
 }
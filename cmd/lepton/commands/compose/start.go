@@ -28,6 +28,7 @@ import (
 
 	"go.farcloser.world/lepton/cmd/lepton/helpers"
 	"go.farcloser.world/lepton/leptonic/services/containerd"
+	"go.farcloser.world/lepton/pkg/api/options"
 	"go.farcloser.world/lepton/pkg/cmd/compose"
 	"go.farcloser.world/lepton/pkg/containerutil"
 	"go.farcloser.world/lepton/pkg/labels"
@@ -59,7 +60,7 @@ func startAction(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	c, err := compose.New(cli, globalOptions, options, cmd.OutOrStdout(), cmd.ErrOrStderr())
+	c, err := compose.New(ctx, cli, globalOptions, options, cmd.OutOrStdout(), cmd.ErrOrStderr())
 	if err != nil {
 		return err
 	}
@@ -84,7 +85,7 @@ func startAction(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("service %q has no container to start", svcName)
 		}
 
-		if err := startContainers(ctx, cli, containers); err != nil {
+		if err := startContainers(ctx, cli, globalOptions, containers); err != nil {
 			return err
 		}
 	}
@@ -92,7 +93,12 @@ func startAction(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func startContainers(ctx context.Context, cli *client.Client, containers []client.Container) error {
+func startContainers(
+	ctx context.Context,
+	cli *client.Client,
+	globalOptions *options.Global,
+	containers []client.Container,
+) error {
 	eg, ctx := errgroup.WithContext(ctx)
 	for _, c := range containers {
 		eg.Go(func() error {
@@ -109,7 +115,7 @@ func startContainers(ctx context.Context, cli *client.Client, containers []clien
 			}
 
 			// in compose, always disable attach
-			if err := containerutil.Start(ctx, c, false, cli, ""); err != nil {
+			if err := containerutil.Start(ctx, c, false, cli, "", globalOptions); err != nil {
 				return err
 			}
 			info, err := c.Info(ctx, client.WithoutRefreshedMetadata)
@@ -144,7 +144,7 @@ func upAction(cmd *cobra.Command, services []string) error {
 		return err
 	}
 	options.Services = services
-	c, err := compose.New(cli, globalOptions, options, cmd.OutOrStdout(), cmd.ErrOrStderr())
+	c, err := compose.New(ctx, cli, globalOptions, options, cmd.OutOrStdout(), cmd.ErrOrStderr())
 	if err != nil {
 		return err
 	}
@@ -32,9 +32,11 @@ import (
 
 func copyCommand() *cobra.Command {
 	longHelp := `
-This command requires 'tar' to be installed on the host (not in the container).
-Using GNU tar is recommended.
-The path of the 'tar' binary can be specified with an environment variable '$TAR'.
+Use '-' as the source to read a tar archive from stdin and extract it into a directory of the container.
+Use '-' as the destination to write a tar archive of the container source to stdout.
+
+Files copied into the container are owned by the container root user, unless '--archive' is specified, in which
+case their ownership is preserved.
 
 WARNING: 'nerdctl cp' is designed only for use with trusted, cooperating containers.
 Using 'nerdctl cp' with untrusted or malicious containers is unsupported and may not provide protection against unexpected behavior.
@@ -45,7 +47,7 @@ Using 'nerdctl cp' with untrusted or malicious containers is unsupported and may
 	cmd := &cobra.Command{
 		Use:               usage,
 		Args:              helpers.IsExactArgs(2),
-		Short:             "Copy files/folders between a running container and the local filesystem.",
+		Short:             "Copy files/folders between a container and the local filesystem.",
 		Long:              longHelp,
 		RunE:              copyAction,
 		ValidArgsFunction: copyShellComplete,
@@ -54,6 +56,7 @@ Using 'nerdctl cp' with untrusted or malicious containers is unsupported and may
 	}
 
 	cmd.Flags().BoolP("follow-link", "L", false, "Always follow symbolic link in SRC_PATH.")
+	cmd.Flags().BoolP("archive", "a", false, "Archive mode (copy all uid/gid information)")
 
 	return cmd
 }
@@ -87,6 +90,10 @@ func copyOptions(cmd *cobra.Command, args []string) (options.ContainerCp, error)
 	if err != nil {
 		return options.ContainerCp{}, err
 	}
+	archive, err := cmd.Flags().GetBool("archive")
+	if err != nil {
+		return options.ContainerCp{}, err
+	}
 
 	srcSpec, err := parseCpFileSpec(args[0])
 	if err != nil {
@@ -104,12 +111,6 @@ func copyOptions(cmd *cobra.Command, args []string) (options.ContainerCp, error)
 	if srcSpec.Container == nil && destSpec.Container == nil {
 		return options.ContainerCp{}, errors.New("one of src or dest must be a container file specification")
 	}
-	if srcSpec.Path == "-" {
-		return options.ContainerCp{}, errors.New("support for reading a tar archive from stdin is not implemented yet")
-	}
-	if destSpec.Path == "-" {
-		return options.ContainerCp{}, errors.New("support for writing a tar archive to stdout is not implemented yet")
-	}
 
 	container2host := srcSpec.Container != nil
 	var containerReq string
@@ -125,6 +126,9 @@ func copyOptions(cmd *cobra.Command, args []string) (options.ContainerCp, error)
 		DestPath:       destSpec.Path,
 		SrcPath:        srcSpec.Path,
 		FollowSymLink:  flagL,
+		Archive:        archive,
+		Stdin:          cmd.InOrStdin(),
+		Stdout:         cmd.OutOrStdout(),
 	}, nil
 }
 
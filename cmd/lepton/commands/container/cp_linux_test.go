@@ -827,7 +827,8 @@ func cpTestHelper(t *testing.T, tg *testgroup) {
 	var srcUID, destUID int
 	if copyToContainer {
 		srcUID = os.Geteuid()
-		destUID = srcUID
+		// Without --archive, files copied into a container are owned by the container root
+		destUID = 0
 	} else {
 		srcUID = 42
 		destUID = os.Geteuid()
@@ -1007,7 +1008,7 @@ func cpTestHelper(t *testing.T, tg *testgroup) {
 					cmd = base.Cmd("cp", containerStopped+":"+sourceSpec, destinationSpec)
 				}
 
-				if rootlessutil.IsRootless() && nerdtest.IsNotDocker() {
+				if !copyToContainer && rootlessutil.IsRootless() && nerdtest.IsNotDocker() {
 					cmd.Assert(
 						icmd.Expected{
 							ExitCode: 1,
@@ -446,6 +446,10 @@ func createAction(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load networking flags: %w", err)
 	}
 
+	if err = applyNamespaceDefaults(ctx, cmd, cli, createOpt, &netFlags); err != nil {
+		return err
+	}
+
 	netManager, err := containerutil.NewNetworkingOptionsManager(createOpt.GOptions, netFlags, cli)
 	if err != nil {
 		return err
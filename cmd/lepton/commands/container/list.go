@@ -17,12 +17,9 @@
 package container
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"io"
-	"text/tabwriter"
-	"text/template"
 
 	"github.com/spf13/cobra"
 
@@ -49,18 +46,16 @@ func PsCommand() *cobra.Command {
 	cmd.Flags().Bool("no-trunc", false, "Don't truncate output")
 	cmd.Flags().BoolP("quiet", "q", false, "Only display container IDs")
 	cmd.Flags().BoolP("size", "s", false, "Display total file sizes")
-	cmd.Flags().String("format", "", "Format the output using the given Go template, e.g, '{{json .}}', 'wide'")
+	cmd.Flags().String("format", "",
+		"Format the output using the given Go template, e.g, '{{json .}}', 'table {{.ID}}\\t{{.Names}}', "+
+			"or one of 'table', 'wide', 'json', 'jsonl', 'yaml', 'csv'")
 	cmd.Flags().
 		StringSliceP("filter", "f", nil, "Filter matches containers based on given conditions. When specifying the condition 'status', it filters all containers")
 
 	_ = cmd.RegisterFlagCompletionFunc(
 		"format",
 		func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-			return []string{
-				formatter.FormatJSON,
-				formatter.FormatTable,
-				formatter.FormatWide,
-			}, cobra.ShellCompDirectiveNoFileComp
+			return formatter.Formats, cobra.ShellCompDirectiveNoFileComp
 		},
 	)
 
@@ -118,18 +113,18 @@ func psOptions(cmd *cobra.Command, _ []string) (options.ContainerList, Formattin
 	}
 
 	return options.ContainerList{
-			GOptions: globalOptions,
-			All:      all,
-			LastN:    lastN,
-			Truncate: trunc,
-			Size:     size || (format == formatter.FormatWide && !quiet),
-			Filters:  filters,
-		}, FormattingAndPrintingOptions{
-			Stdout: cmd.OutOrStdout(),
-			Quiet:  quiet,
-			Format: format,
-			Size:   size,
-		}, nil
+		GOptions: globalOptions,
+		All:      all,
+		LastN:    lastN,
+		Truncate: trunc,
+		Size:     size || ((format == formatter.FormatWide || format == formatter.FormatCSV) && !quiet),
+		Filters:  filters,
+	}, FormattingAndPrintingOptions{
+		Stdout: cmd.OutOrStdout(),
+		Quiet:  quiet,
+		Format: format,
+		Size:   size,
+	}, nil
 }
 
 func psAction(cmd *cobra.Command, args []string) error {
@@ -164,78 +159,39 @@ type FormattingAndPrintingOptions struct {
 }
 
 func formatAndPrintContainerInfo(containers []container.ListItem, options FormattingAndPrintingOptions) error {
-	w := options.Stdout
-	var (
-		wide bool
-		tmpl *template.Template
-	)
 	switch options.Format {
-	case formatter.FormatNone, formatter.FormatTable:
-		w = tabwriter.NewWriter(w, 4, 8, 4, ' ', 0)
-		if !options.Quiet {
-			printHeader := "CONTAINER ID\tIMAGE\tCOMMAND\tCREATED\tSTATUS\tPORTS\tNAMES"
-			if options.Size {
-				printHeader += "\tSIZE"
+	case formatter.FormatNone, formatter.FormatTable, formatter.FormatWide:
+		if options.Quiet {
+			for _, c := range containers {
+				if _, err := fmt.Fprintln(options.Stdout, c.ID); err != nil {
+					return err
+				}
 			}
-			fmt.Fprintln(w, printHeader)
-		}
-	case formatter.FormatWide:
-		w = tabwriter.NewWriter(w, 4, 8, 4, ' ', 0)
-		if !options.Quiet {
-			fmt.Fprintln(w, "CONTAINER ID\tIMAGE\tCOMMAND\tCREATED\tSTATUS\tPORTS\tNAMES\tRUNTIME\tPLATFORM\tSIZE")
-			wide = true
+			return nil
 		}
 	default:
 		if options.Quiet {
 			return errors.New("format and quiet must not be specified together")
 		}
-		var err error
-		tmpl, err = formatter.ParseTemplate(options.Format)
-		if err != nil {
-			return err
-		}
 	}
 
-	for _, c := range containers {
-		if tmpl != nil {
-			var b bytes.Buffer
-			if err := tmpl.Execute(&b, &c); err != nil {
-				return err
-			}
-			if _, err := fmt.Fprintln(w, b.String()); err != nil {
-				return err
-			}
-		} else if options.Quiet {
-			if _, err := fmt.Fprintln(w, c.ID); err != nil {
-				return err
-			}
-		} else {
-			format := "%s\t%s\t%s\t%s\t%s\t%s\t%s"
-			args := []interface{}{
-				c.ID,
-				c.Image,
-				c.Command,
-				formatter.TimeSinceInHuman(c.CreatedAt),
-				c.Status,
-				c.Ports,
-				c.Names,
-			}
-			if wide {
-				format += "\t%s\t%s\t%s\n"
-				args = append(args, c.Runtime, c.Platform, c.Size)
-			} else if options.Size {
-				format += "\t%s\n"
-				args = append(args, c.Size)
-			} else {
-				format += "\n"
-			}
-			if _, err := fmt.Fprintf(w, format, args...); err != nil {
-				return err
-			}
-		}
+	columns := []formatter.Column{
+		{Header: "CONTAINER ID", Field: "ID"},
+		{Header: "IMAGE", Field: "Image"},
+		{Header: "COMMAND", Field: "Command"},
+		{Header: "CREATED", Field: "RunningFor"},
+		{Header: "STATUS", Field: "Status"},
+		{Header: "PORTS", Field: "Ports"},
+		{Header: "NAMES", Field: "Names"},
+		{Header: "RUNTIME", Field: "Runtime", Wide: true},
+		{Header: "PLATFORM", Field: "Platform", Wide: true},
+		{Header: "SIZE", Field: "Size", Wide: !options.Size},
 	}
-	if f, ok := w.(formatter.Flusher); ok {
-		return f.Flush()
+
+	items := make([]*container.ListItem, len(containers))
+	for i := range containers {
+		items[i] = &containers[i]
 	}
-	return nil
+
+	return formatter.Render(options.Stdout, options.Format, items, columns)
 }
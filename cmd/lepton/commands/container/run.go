@@ -24,12 +24,15 @@ import (
 
 	"github.com/containerd/console"
 	"github.com/containerd/log"
+	"github.com/containerd/platforms"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 
 	"go.farcloser.world/containers/security/cgroups"
 
 	"go.farcloser.world/lepton/cmd/lepton/completion"
+	"go.farcloser.world/lepton/leptonic/emulation"
+	"go.farcloser.world/lepton/leptonic/errs"
 	"go.farcloser.world/lepton/pkg/annotations"
 	"go.farcloser.world/lepton/pkg/api/options"
 	"go.farcloser.world/lepton/pkg/clientutil"
@@ -38,11 +41,14 @@ import (
 	"go.farcloser.world/lepton/pkg/containerutil"
 	"go.farcloser.world/lepton/pkg/defaults"
 	"go.farcloser.world/lepton/pkg/errutil"
+	"go.farcloser.world/lepton/pkg/eventutil"
 	"go.farcloser.world/lepton/pkg/labels"
 	"go.farcloser.world/lepton/pkg/logging"
+	"go.farcloser.world/lepton/pkg/mountutil/volumestore"
 	"go.farcloser.world/lepton/pkg/netutil"
 	"go.farcloser.world/lepton/pkg/signalutil"
 	"go.farcloser.world/lepton/pkg/taskutil"
+	"go.farcloser.world/lepton/pkg/version"
 )
 
 const (
@@ -145,6 +151,8 @@ func setCreateFlags(cmd *cobra.Command) {
 	cmd.Flags().StringSliceP("publish", "p", nil, "Publish a container's port(s) to the host")
 	cmd.Flags().String("ip", "", "IPv4 address to assign to the container")
 	cmd.Flags().String("ip6", "", "IPv6 address to assign to the container")
+	cmd.Flags().Bool("ip-sticky", false, "Request the addresses first allocated to the container again on restart")
+	cmd.Flags().StringSlice("network-alias", nil, "Add network-scoped alias for the container, as [NETWORK:]ALIAS")
 	cmd.Flags().StringP("hostname", "h", "", "Container host name")
 	cmd.Flags().String("domainname", "", "Container domain name")
 	cmd.Flags().String("mac-address", "", "MAC address to assign to the container")
@@ -215,8 +223,8 @@ func setCreateFlags(cmd *cobra.Command) {
 		"security-opt",
 		func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			return []string{
-				"seccomp=", "seccomp=" + defaults.SeccompProfileName, "seccomp=unconfined",
-				"apparmor=", "apparmor=" + defaults.AppArmorProfileName, "apparmor=unconfined",
+				"seccomp=", "seccomp=" + defaults.SeccompProfileName, "seccomp=unconfined", "seccomp=trace",
+				"apparmor=", "apparmor=" + defaults.AppArmorProfileName, "apparmor=unconfined", "apparmor=generated",
 				"no-new-privileges",
 				"systempaths=unconfined",
 				"privileged-without-host-devices",
@@ -411,6 +419,10 @@ func runAction(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if err = checkPlatformEmulation(createOpt.Platform); err != nil {
+		return err
+	}
+
 	cli, ctx, cancel, err := clientutil.NewClientWithPlatform(
 		cmd.Context(),
 		createOpt.GOptions.Namespace,
@@ -435,6 +447,10 @@ func runAction(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load networking flags: %w", err)
 	}
 
+	if err = applyNamespaceDefaults(ctx, cmd, cli, createOpt, &netFlags); err != nil {
+		return err
+	}
+
 	netManager, err := containerutil.NewNetworkingOptionsManager(createOpt.GOptions, netFlags, cli)
 	if err != nil {
 		return err
@@ -483,15 +499,22 @@ func runAction(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	logURI := lab[labels.LogURI]
+	// Volumes with options must be mounted before the task is created
+	if err := volumestore.MountContainerVolumes(id, lab); err != nil {
+		return err
+	}
 	detachC := make(chan struct{})
 	task, err := taskutil.NewTask(ctx, cli, c, createOpt.Attach, createOpt.Interactive, createOpt.TTY, createOpt.Detach,
 		con, logURI, createOpt.DetachKeys, createOpt.GOptions.Namespace, detachC)
 	if err != nil {
+		containerutil.ReleaseContainerVolumes(ctx, id, lab)
 		return err
 	}
 	if err := task.Start(ctx); err != nil {
+		containerutil.ReleaseContainerVolumes(ctx, id, lab)
 		return err
 	}
+	eventutil.Record(ctx, createOpt.GOptions, eventutil.ContainerEvent(ctx, c, eventutil.ActionStart))
 
 	if createOpt.Detach {
 		fmt.Fprintln(createOpt.Stdout, id)
@@ -550,3 +573,32 @@ func runShellComplete(cmd *cobra.Command, args []string, toComplete string) ([]s
 	}
 	return nil, cobra.ShellCompDirectiveNoFileComp
 }
+
+// checkPlatformEmulation fails early if the platform cannot run on this host, rather than letting the container
+// fail with "exec format error".
+func checkPlatformEmulation(platform string) error {
+	if platform == "" || runtime.GOOS != "linux" {
+		return nil
+	}
+
+	parsed, err := platforms.Parse(platform)
+	if err != nil {
+		return err
+	}
+
+	// Errors (e.g. unknown architectures) are reported as warnings when creating the client
+	if canExec, err := emulation.CanExecProbably(parsed); err != nil || canExec {
+		return nil //nolint:nilerr
+	}
+
+	return fmt.Errorf(
+		"%w: platform %q cannot run on this host (%s), as no emulator is registered for it: "+
+			"run `%s system emulation install --platform %s` as root (see also `%s system emulation ls`)",
+		errs.ErrFailedPrecondition,
+		platform,
+		platforms.DefaultString(),
+		version.RootName,
+		platforms.Format(parsed),
+		version.RootName,
+	)
+}
@@ -718,3 +718,40 @@ func TestBindMountWhenHostFolderDoesNotExist(t *testing.T) {
 	_, err = os.Stat(hp)
 	assert.ErrorIs(t, err, os.ErrNotExist)
 }
+
+func TestRunMountImage(t *testing.T) {
+	t.Parallel()
+
+	base := testutil.NewBase(t)
+	base.Cmd("run", "--rm",
+		"--mount", "type=image,source="+testutil.AlpineImage+",target=/img",
+		testutil.AlpineImage, "test", "-f", "/img/etc/alpine-release").AssertOK()
+	base.Cmd("run", "--rm",
+		"--mount", "type=image,source="+testutil.AlpineImage+",target=/img,image-subpath=etc",
+		testutil.AlpineImage, "test", "-f", "/img/alpine-release").AssertOK()
+	// Image mounts are read-only
+	base.Cmd("run", "--rm",
+		"--mount", "type=image,source="+testutil.AlpineImage+",target=/img",
+		testutil.AlpineImage, "touch", "/img/foo").AssertFail()
+	base.Cmd("run", "--rm",
+		"--mount", "type=image,source="+testutil.AlpineImage+",target=/img,image-subpath=does-not-exist",
+		testutil.AlpineImage).AssertFail()
+}
+
+func TestRunMountVolumeSubpath(t *testing.T) {
+	t.Parallel()
+
+	base := testutil.NewBase(t)
+	volName := testutil.Identifier(t)
+	defer base.Cmd("volume", "rm", "-f", volName).Run()
+	base.Cmd("volume", "create", volName).AssertOK()
+
+	base.Cmd("run", "--rm", "-v", volName+":/data",
+		testutil.AlpineImage, "sh", "-euxc", "mkdir -p /data/sub && echo -n str1 > /data/sub/file1").AssertOK()
+	base.Cmd("run", "--rm",
+		"--mount", "type=volume,source="+volName+",target=/mnt,volume-subpath=sub",
+		testutil.AlpineImage, "cat", "/mnt/file1").AssertOutExactly("str1")
+	base.Cmd("run", "--rm",
+		"--mount", "type=volume,source="+volName+",target=/mnt,volume-subpath=../sub",
+		testutil.AlpineImage).AssertFail()
+}
@@ -0,0 +1,77 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package container
+
+import (
+	"context"
+	"os"
+
+	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/spf13/cobra"
+
+	"go.farcloser.world/lepton/leptonic/services/namespace"
+	"go.farcloser.world/lepton/pkg/api/options"
+)
+
+// applyNamespaceDefaults sets the defaults of the namespace for all settings that were not explicitly specified.
+// Explicit flags (and environment variables) take precedence over namespace defaults, which take precedence over
+// the configuration file.
+func applyNamespaceDefaults(
+	ctx context.Context,
+	cmd *cobra.Command,
+	cli *containerd.Client,
+	createOpt *options.ContainerCreate,
+	netFlags *options.ContainerNetwork,
+) error {
+	defaults, err := namespace.GetDefaults(ctx, cli, createOpt.GOptions.Namespace)
+	if err != nil {
+		return err
+	}
+
+	if defaults.Network != "" && !flagChanged(cmd, "network", "net") {
+		netFlags.NetworkSlice = []string{defaults.Network}
+	}
+
+	if defaults.Snapshotter != "" && !flagChanged(cmd, "snapshotter", "storage-driver") &&
+		os.Getenv("CONTAINERD_SNAPSHOTTER") == "" {
+		createOpt.GOptions.Snapshotter = defaults.Snapshotter
+	}
+
+	// Log options only make sense along with their driver
+	if defaults.LogDriver != "" && !flagChanged(cmd, "log-driver") {
+		createOpt.LogDriver = defaults.LogDriver
+		if !flagChanged(cmd, "log-opt") {
+			createOpt.LogOpt = defaults.LogOpts
+		}
+	}
+
+	if defaults.Runtime != "" && !flagChanged(cmd, "runtime") {
+		createOpt.Runtime = defaults.Runtime
+	}
+
+	return nil
+}
+
+func flagChanged(cmd *cobra.Command, names ...string) bool {
+	for _, name := range names {
+		if flag := cmd.Flags().Lookup(name); flag != nil && flag.Changed {
+			return true
+		}
+	}
+
+	return false
+}
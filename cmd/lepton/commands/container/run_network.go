@@ -85,6 +85,20 @@ func loadNetworkFlags(cmd *cobra.Command) (options.ContainerNetwork, error) {
 	}
 	netOpts.IP6Address = ip6Address
 
+	// --ip-sticky
+	ipSticky, err := cmd.Flags().GetBool("ip-sticky")
+	if err != nil {
+		return netOpts, err
+	}
+	netOpts.IPSticky = ipSticky
+
+	// --network-alias=[<network>:]<alias> ...
+	networkAliases, err := cmd.Flags().GetStringSlice("network-alias")
+	if err != nil {
+		return netOpts, err
+	}
+	netOpts.NetworkAliases = strutil.DedupeStrSlice(networkAliases)
+
 	// -h/--hostname=<container hostname>
 	hostName, err := cmd.Flags().GetString("hostname")
 	if err != nil {
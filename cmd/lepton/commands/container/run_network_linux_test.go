@@ -1014,6 +1014,36 @@ func TestRunContainerWithStaticIP6(t *testing.T) {
 	}
 }
 
+func TestRunPortIPv6(t *testing.T) {
+	if rootlessutil.IsRootless() {
+		t.Skip("IPv6 port publishing is not tested in rootless mode yet")
+	}
+	networkName := "test-network-" + testutil.Identifier(t)
+	containerName := testutil.Identifier(t)
+	base := testutil.NewBaseWithIPv6Compatible(t)
+	// The IPv6 subnet is allocated from the IPv6 pool
+	base.Cmd("network", "create", networkName, "--ipv6").AssertOK()
+	t.Cleanup(func() {
+		base.Cmd("rm", "-f", containerName).Run()
+		base.Cmd("network", "rm", networkName).Run()
+	})
+
+	base.Cmd("run", "-d", "--name", containerName, "--network", networkName,
+		"-p", "[::]:8086:80", testutil.NginxAlpineImage).AssertOK()
+	base.Cmd("port", containerName, "80").AssertOutExactly("[::]:8086\n")
+
+	// IPv6 loopback cannot be DNAT-ed, so connect through the address of the host on the network, i.e., the gateway
+	route := base.Cmd("exec", containerName, "ip", "-6", "route", "show", "default").Out()
+	fields := strings.Fields(route)
+	assert.Assert(t, len(fields) > 2 && fields[1] == "via", route)
+	resp, err := nettestutil.HTTPGet("http://"+net.JoinHostPort(fields[2], "8086"), 30, false)
+	assert.NilError(t, err)
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(string(respBody), testutil.NginxAlpineIndexHTMLSnippet))
+}
+
 func TestNoneNetworkHostName(t *testing.T) {
 	nerdtest.Setup()
 	testCase := &test.Case{
@@ -25,6 +25,7 @@ import (
 	"go.farcloser.world/lepton/leptonic/services/containerd"
 	"go.farcloser.world/lepton/pkg/api/options"
 	"go.farcloser.world/lepton/pkg/cmd/container"
+	"go.farcloser.world/lepton/pkg/formatter"
 )
 
 func StatsCommand() *cobra.Command {
@@ -38,10 +39,26 @@ func StatsCommand() *cobra.Command {
 	}
 
 	cmd.Flags().BoolP("all", "a", false, "Show all containers (default shows just running)")
-	cmd.Flags().String("format", "", "Pretty-print images using a Go template, e.g, '{{json .}}'")
+	cmd.Flags().String(
+		"format",
+		"",
+		"Pretty-print images using a Go template, e.g, '{{json .}}', or 'openmetrics' or 'prometheus' (one-shot)",
+	)
 	cmd.Flags().Bool("no-stream", false, "Disable streaming stats and only pull the first result")
 	cmd.Flags().Bool("no-trunc", false, "Do not truncate output")
 
+	_ = cmd.RegisterFlagCompletionFunc(
+		"format",
+		func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return []string{
+				formatter.FormatJSON,
+				formatter.FormatTable,
+				formatter.FormatOpenMetrics,
+				formatter.FormatPrometheus,
+			}, cobra.ShellCompDirectiveNoFileComp
+		},
+	)
+
 	return cmd
 }
 
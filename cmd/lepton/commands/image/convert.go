@@ -66,6 +66,7 @@ func convertCommand() *cobra.Command {
 	cmd.Flags().Bool("oci", false, "Convert Docker media types to OCI media types")
 	cmd.Flags().StringSlice("platform", []string{}, "Convert content for a specific platform")
 	cmd.Flags().Bool("all-platforms", false, "Convert content for all platforms")
+	helpers.AddProgressFlag(cmd)
 
 	_ = cmd.RegisterFlagCompletionFunc("platform", completion.Platforms)
 
@@ -128,10 +129,16 @@ func convertOptions(cmd *cobra.Command, args []string) (*options.ImageConvert, e
 		return nil, err
 	}
 
+	progress, err := helpers.ProcessProgressOption(cmd)
+	if err != nil {
+		return nil, err
+	}
+
 	return &options.ImageConvert{
 		SourceRef:                   args[0],
 		DestinationRef:              args[1],
 		Format:                      format,
+		Progress:                    progress,
 		Zstd:                        zstd,
 		ZstdCompressionLevel:        zstdCompressionLevel,
 		ZstdChunked:                 zstdchunked,
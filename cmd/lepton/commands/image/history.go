@@ -17,19 +17,13 @@
 package image
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
-	"io"
-	"os"
 	"strconv"
-	"text/tabwriter"
-	"text/template"
 	"time"
 
 	"github.com/containerd/containerd/v2/client"
-	"github.com/containerd/log"
 	"github.com/spf13/cobra"
 
 	"go.farcloser.world/containers/specs"
@@ -54,7 +48,9 @@ func HistoryCommand() *cobra.Command {
 		SilenceErrors:     true,
 	}
 
-	cmd.Flags().StringP("format", "f", "", "Format the output using the given Go template, e.g, '{{json .}}'")
+	cmd.Flags().StringP("format", "f", "",
+		"Format the output using the given Go template, e.g, 'table {{.Snapshot}}\\t{{.Size}}', "+
+			"or one of 'table', 'wide', 'json', 'jsonl', 'yaml', 'csv'")
 	cmd.Flags().BoolP("quiet", "q", false, "Only show numeric IDs")
 	cmd.Flags().BoolP("human", "H", true, "Print sizes and dates in human readable format (default true)")
 	cmd.Flags().Bool("no-trunc", false, "Don't truncate output")
@@ -62,7 +58,7 @@ func HistoryCommand() *cobra.Command {
 	_ = cmd.RegisterFlagCompletionFunc(
 		"format",
 		func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-			return []string{formatter.FormatJSON}, cobra.ShellCompDirectiveNoFileComp
+			return formatter.Formats, cobra.ShellCompDirectiveNoFileComp
 		},
 	)
 
@@ -158,12 +154,6 @@ func historyAction(cmd *cobra.Command, args []string) error {
 	return walker.WalkAll(ctx, args, true)
 }
 
-type historyPrinter struct {
-	w                     io.Writer
-	quiet, noTrunc, human bool
-	tmpl                  *template.Template
-}
-
 func printHistory(cmd *cobra.Command, historys []historyPrintable) error {
 	quiet, err := cmd.Flags().GetBool("quiet")
 	if err != nil {
@@ -177,66 +167,54 @@ func printHistory(cmd *cobra.Command, historys []historyPrintable) error {
 	if err != nil {
 		return err
 	}
-
-	var w io.Writer
-	w = os.Stdout
-
 	format, err := cmd.Flags().GetString("format")
 	if err != nil {
 		return err
 	}
 
-	var tmpl *template.Template
-	switch format {
-	case formatter.FormatNone, formatter.FormatTable:
-		w = tabwriter.NewWriter(w, 4, 8, 4, ' ', 0)
-		if !quiet {
-			fmt.Fprintln(w, "SNAPSHOT\tCREATED\tCREATED BY\tSIZE\tCOMMENT") //nolint:dupword
-		}
-	default:
-		quiet = false
-		var err error
-		tmpl, err = formatter.ParseTemplate(format)
-		if err != nil {
-			return err
-		}
-	}
+	// Quiet has no effect with a format
+	quiet = quiet && (format == formatter.FormatNone || format == formatter.FormatTable)
 
-	printer := &historyPrinter{
-		w:       w,
-		quiet:   quiet,
-		noTrunc: noTrunc,
-		human:   human,
-		tmpl:    tmpl,
+	printables := make([]historyPrintable, 0, len(historys))
+	for index := len(historys) - 1; index >= 0; index-- {
+		printables = append(printables, formatHistory(historys[index], quiet, noTrunc, human))
 	}
 
-	for index := len(historys) - 1; index >= 0; index-- {
-		if err := printer.printHistory(historys[index]); err != nil {
-			log.L.Warn(err)
+	if quiet {
+		for _, printable := range printables {
+			if _, err = fmt.Fprintln(cmd.OutOrStdout(), printable.Snapshot); err != nil {
+				return err
+			}
 		}
-	}
 
-	if f, ok := w.(formatter.Flusher); ok {
-		return f.Flush()
+		return nil
 	}
-	return nil
+
+	return formatter.Render(cmd.OutOrStdout(), format, printables, []formatter.Column{
+		{Header: "SNAPSHOT", Field: "Snapshot"},
+		{Header: "CREATED", Field: "CreatedSince"},
+		{Header: "CREATED BY", Field: "CreatedBy"},
+		{Header: "SIZE", Field: "Size"},
+		{Header: "COMMENT", Field: "Comment"},
+	})
 }
 
-func (x *historyPrinter) printHistory(printable historyPrintable) error {
+// formatHistory truncates, and formats the date and size of a history entry for display.
+func formatHistory(printable historyPrintable, quiet, noTrunc, human bool) historyPrintable {
 	// Truncate long values unless --no-trunc is passed
-	if !x.noTrunc {
+	if !noTrunc {
 		if len(printable.CreatedBy) > 45 {
 			printable.CreatedBy = printable.CreatedBy[0:44] + "…"
 		}
 		// Do not truncate snapshot id if quiet is being passed
-		if !x.quiet && len(printable.Snapshot) > 45 {
+		if !quiet && len(printable.Snapshot) > 45 {
 			printable.Snapshot = printable.Snapshot[0:44] + "…"
 		}
 	}
 
 	// Format date and size for display based on --human preference
 	printable.CreatedAt = printable.creationTime.Local().Format(time.RFC3339) //nolint:gosmopolitan
-	if x.human {
+	if human {
 		printable.CreatedSince = formatter.TimeSinceInHuman(*printable.creationTime)
 		printable.Size = units.HumanSize(float64(printable.size))
 	} else {
@@ -244,30 +222,7 @@ func (x *historyPrinter) printHistory(printable historyPrintable) error {
 		printable.Size = strconv.FormatInt(printable.size, 10)
 	}
 
-	if x.tmpl != nil {
-		var b bytes.Buffer
-		if err := x.tmpl.Execute(&b, printable); err != nil {
-			return err
-		}
-		if _, err := fmt.Fprintln(x.w, b.String()); err != nil {
-			return err
-		}
-	} else if x.quiet {
-		if _, err := fmt.Fprintln(x.w, printable.Snapshot); err != nil {
-			return err
-		}
-	} else {
-		if _, err := fmt.Fprintf(x.w, "%s\t%s\t%s\t%s\t%s\n",
-			printable.Snapshot,
-			printable.CreatedSince,
-			printable.CreatedBy,
-			printable.Size,
-			printable.Comment,
-		); err != nil {
-			return err
-		}
-	}
-	return nil
+	return printable
 }
 
 func historyShellComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -19,8 +19,6 @@ package image_test
 import (
 	"encoding/json"
 	"errors"
-	"io"
-	"strings"
 	"testing"
 	"time"
 
@@ -44,16 +42,9 @@ type historyObj struct {
 }
 
 func decode(stdout string) ([]historyObj, error) {
-	dec := json.NewDecoder(strings.NewReader(stdout))
 	object := []historyObj{}
-	for {
-		var v historyObj
-		if err := dec.Decode(&v); err == io.EOF {
-			break
-		} else if err != nil {
-			return nil, errors.New("failed to decode history object")
-		}
-		object = append(object, v)
+	if err := json.Unmarshal([]byte(stdout), &object); err != nil {
+		return nil, errors.New("failed to decode history objects")
 	}
 
 	return object, nil
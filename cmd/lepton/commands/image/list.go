@@ -57,7 +57,9 @@ Properties:
 	cmd.Flags().BoolP("quiet", "q", false, "Only show numeric IDs")
 	cmd.Flags().Bool("no-trunc", false, "Don't truncate output")
 	// Alias "-f" is reserved for "--filter"
-	cmd.Flags().String("format", "", "Format the output using the given Go template, e.g, '{{json .}}', 'wide'")
+	cmd.Flags().String("format", "",
+		"Format the output using the given Go template, e.g, 'table {{.ID}}\\t{{.Repository}}', "+
+			"or one of 'table', 'wide', 'json', 'jsonl', 'yaml', 'csv'")
 	cmd.Flags().StringSliceP("filter", "f", []string{}, "Filter output based on conditions provided")
 	cmd.Flags().Bool("digests", false, "Show digests (compatible with Docker, unlike ID)")
 	cmd.Flags().Bool("names", false, "Show image names")
@@ -66,11 +68,7 @@ Properties:
 	_ = cmd.RegisterFlagCompletionFunc(
 		"format",
 		func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-			return []string{
-				formatter.FormatJSON,
-				formatter.FormatTable,
-				formatter.FormatWide,
-			}, cobra.ShellCompDirectiveNoFileComp
+			return formatter.Formats, cobra.ShellCompDirectiveNoFileComp
 		},
 	)
 
@@ -39,6 +39,7 @@ func LoadCommand() *cobra.Command {
 
 	cmd.Flags().StringP("input", "i", "", "Read from tar archive file, instead of STDIN")
 	cmd.Flags().BoolP("quiet", "q", false, "Suppress the load output")
+	helpers.AddProgressFlag(cmd)
 	cmd.Flags().StringSlice("platform", []string{}, "Import content for a specific platform")
 	cmd.Flags().Bool("all-platforms", false, "Import content for all platforms")
 
@@ -68,6 +69,10 @@ func loadOptions(cmd *cobra.Command) (options.ImageLoad, error) {
 	if err != nil {
 		return options.ImageLoad{}, err
 	}
+	progress, err := helpers.ProcessProgressOption(cmd)
+	if err != nil {
+		return options.ImageLoad{}, err
+	}
 	return options.ImageLoad{
 		GOptions:     globalOptions,
 		Input:        input,
@@ -76,6 +81,7 @@ func loadOptions(cmd *cobra.Command) (options.ImageLoad, error) {
 		Stdout:       cmd.OutOrStdout(),
 		Stdin:        cmd.InOrStdin(),
 		Quiet:        quiet,
+		Progress:     progress,
 	}, nil
 }
 
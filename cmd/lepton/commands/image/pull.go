@@ -55,6 +55,7 @@ func PullCommand() *cobra.Command {
 	cmd.Flags().
 		String("soci-index-digest", "", "Specify a particular index digest for SOCI. If left empty, SOCI will automatically use the index determined by the selection policy.")
 	cmd.Flags().BoolP("quiet", "q", false, "Suppress verbose output")
+	helpers.AddProgressFlag(cmd)
 
 	_ = cmd.RegisterFlagCompletionFunc(
 		"unpack",
@@ -106,6 +107,11 @@ func pullOptions(cmd *cobra.Command, args []string) (options.ImagePull, error) {
 		return options.ImagePull{}, err
 	}
 
+	progress, err := helpers.ProcessProgressOption(cmd)
+	if err != nil {
+		return options.ImagePull{}, err
+	}
+
 	sociIndexDigest, err := cmd.Flags().GetString("soci-index-digest")
 	if err != nil {
 		return options.ImagePull{}, err
@@ -122,6 +128,7 @@ func pullOptions(cmd *cobra.Command, args []string) (options.ImagePull, error) {
 		Unpack:          unpack,
 		Mode:            "always",
 		Quiet:           quiet,
+		Progress:        progress,
 		RFlags: options.RemoteSnapshotterFlags{
 			SociIndexDigest: sociIndexDigest,
 		},
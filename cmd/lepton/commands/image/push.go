@@ -51,6 +51,7 @@ func PushCommand() *cobra.Command {
 	cmd.Flags().
 		Int64("soci-min-layer-size", -1, "Minimum layer size to build zTOC for. Smaller layers won't have zTOC and not lazy pulled. Default is 10 MiB.")
 	cmd.Flags().BoolP("quiet", "q", false, "Suppress verbose output")
+	helpers.AddProgressFlag(cmd)
 	cmd.Flags().Bool(allowNonDistFlag, false, "Allow pushing images with non-distributable blobs")
 
 	_ = cmd.RegisterFlagCompletionFunc("platform", completion.Platforms)
@@ -81,6 +82,10 @@ func pushOptions(cmd *cobra.Command, args []string) (options.ImagePush, error) {
 	if err != nil {
 		return options.ImagePush{}, err
 	}
+	progress, err := helpers.ProcessProgressOption(cmd)
+	if err != nil {
+		return options.ImagePush{}, err
+	}
 	allowNonDist, err := cmd.Flags().GetBool(allowNonDistFlag)
 	if err != nil {
 		return options.ImagePush{}, err
@@ -100,6 +105,7 @@ func pushOptions(cmd *cobra.Command, args []string) (options.ImagePush, error) {
 		Platforms:                      platform,
 		AllPlatforms:                   allPlatforms,
 		Quiet:                          quiet,
+		Progress:                       progress,
 		AllowNondistributableArtifacts: allowNonDist,
 		Stdout:                         cmd.OutOrStdout(),
 	}, nil
@@ -37,7 +37,9 @@ func listCommand() *cobra.Command {
 	}
 
 	cmd.Flags().BoolP("quiet", "q", false, "Only display names")
-	cmd.Flags().String("format", "", "Format the output using the given Go template, e.g, '{{json .}}'")
+	cmd.Flags().String("format", "",
+		"Format the output using the given Go template, e.g, 'table {{.Name}}\\t{{.Containers}}', "+
+			"or one of 'table', 'wide', 'json', 'jsonl', 'yaml', 'csv'")
 
 	return cmd
 }
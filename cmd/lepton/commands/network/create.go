@@ -109,7 +109,7 @@ func createAction(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	return network.Create(cmd.OutOrStdout(), globalOptions, &options.NetworkCreate{
+	return network.Create(cmd.Context(), cmd.OutOrStdout(), globalOptions, &options.NetworkCreate{
 		Name:        name,
 		Driver:      driver,
 		Options:     utils.KeyValueStringsToMap(opts),
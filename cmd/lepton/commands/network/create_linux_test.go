@@ -163,6 +163,40 @@ func TestNetworkCreate(t *testing.T) {
 				}
 			},
 		},
+		{
+			Description: "with ipv6, without subnet",
+			Require:     nerdtest.OnlyIPv6,
+			Setup: func(data test.Data, helpers test.Helpers) {
+				helpers.Ensure("network", "create", data.Identifier(), "--ipv6")
+			},
+			Cleanup: func(data test.Data, helpers test.Helpers) {
+				helpers.Anyhow("network", "rm", data.Identifier())
+			},
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				return helpers.Command(
+					"run",
+					"--rm",
+					"--net",
+					data.Identifier(),
+					testutil.CommonImage,
+					"sh",
+					"-euxc",
+					"ip addr show dev eth0; ip -6 route",
+				)
+			},
+			Expected: func(data test.Data, helpers test.Helpers) *test.Expected {
+				return &test.Expected{
+					ExitCode: 0,
+					Output: func(stdout, info string, t *testing.T) {
+						// The default pool is a random ULA prefix
+						_, pool, _ := net.ParseCIDR("fd00::/8")
+						ip := ipv6helper.FindIPv6(stdout)
+						assert.Assert(t, pool.Contains(ip), info)
+						assert.Assert(t, strings.Contains(stdout, "default via"), info)
+					},
+				}
+			},
+		},
 	}
 
 	testCase.Run(t)
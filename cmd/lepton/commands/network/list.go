@@ -38,16 +38,14 @@ func listCommand() *cobra.Command {
 
 	cmd.Flags().BoolP("quiet", "q", false, "Only display network IDs")
 	cmd.Flags().StringSliceP("filter", "f", []string{}, "Provide filter values (e.g. \"name=default\")")
-	cmd.Flags().String("format", "", "Format the output using the given Go template, e.g, '{{json .}}'")
+	cmd.Flags().String("format", "",
+		"Format the output using the given Go template, e.g, 'table {{.ID}}\\t{{.Name}}', "+
+			"or one of 'table', 'wide', 'json', 'jsonl', 'yaml', 'csv'")
 
 	_ = cmd.RegisterFlagCompletionFunc(
 		"format",
 		func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-			return []string{
-				formatter.FormatJSON,
-				formatter.FormatTable,
-				formatter.FormatWide,
-			}, cobra.ShellCompDirectiveNoFileComp
+			return formatter.Formats, cobra.ShellCompDirectiveNoFileComp
 		},
 	)
 
@@ -26,7 +26,7 @@ func Command() *cobra.Command {
 	cmd := &cobra.Command{
 		Annotations:   map[string]string{helpers.Category: helpers.Management},
 		Use:           "registry",
-		Short:         "Manage registry credentials",
+		Short:         "Manage registry credentials and mirrors",
 		RunE:          helpers.UnknownSubcommandAction,
 		SilenceUsage:  true,
 		SilenceErrors: true,
@@ -35,6 +35,7 @@ func Command() *cobra.Command {
 	cmd.AddCommand(
 		LoginCommand(),
 		LogoutCommand(),
+		mirrorCommand(),
 	)
 
 	return cmd
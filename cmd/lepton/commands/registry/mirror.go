@@ -0,0 +1,177 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package registry
+
+import (
+	"github.com/spf13/cobra"
+
+	"go.farcloser.world/lepton/cmd/lepton/helpers"
+	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/cmd/registry"
+	"go.farcloser.world/lepton/pkg/formatter"
+)
+
+func mirrorCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "mirror",
+		Short:         "Manage registry mirrors (hosts.toml files of the first --hosts-dir)",
+		RunE:          helpers.UnknownSubcommandAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	cmd.AddCommand(
+		mirrorAddCommand(),
+		mirrorListCommand(),
+		mirrorRemoveCommand(),
+	)
+
+	return cmd
+}
+
+func mirrorAddCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "add [flags] REGISTRY MIRROR",
+		Short:         "Add a mirror to pull from before falling back to a registry",
+		Example:       "  mirror add docker.io https://mirror.example.com",
+		Args:          helpers.IsExactArgs(2),
+		RunE:          mirrorAddAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	cmd.Flags().Int("priority", 0, "Position of the mirror, 1 being tried first (default: after the other mirrors)")
+	cmd.Flags().Bool("skip-verify", false, "Skip verifying the TLS certificate of the mirror")
+	cmd.Flags().String("ca", "", "CA certificate file of the mirror")
+
+	return cmd
+}
+
+func mirrorAddOptions(cmd *cobra.Command) (*options.RegistryMirrorAdd, error) {
+	priority, err := cmd.Flags().GetInt("priority")
+	if err != nil {
+		return nil, err
+	}
+
+	skipVerify, err := cmd.Flags().GetBool("skip-verify")
+	if err != nil {
+		return nil, err
+	}
+
+	ca, err := cmd.Flags().GetString("ca")
+	if err != nil {
+		return nil, err
+	}
+
+	return &options.RegistryMirrorAdd{
+		Priority:   priority,
+		SkipVerify: skipVerify,
+		CA:         ca,
+	}, nil
+}
+
+func mirrorAddAction(cmd *cobra.Command, args []string) error {
+	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	opts, err := mirrorAddOptions(cmd)
+	if err != nil {
+		return err
+	}
+
+	return registry.MirrorAdd(cmd.OutOrStdout(), globalOptions, args[0], args[1], opts)
+}
+
+func mirrorListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "ls",
+		Aliases:       []string{"list"},
+		Short:         "List registry mirrors, from hosts.toml files and the configuration",
+		Args:          cobra.NoArgs,
+		RunE:          mirrorListAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	cmd.Flags().BoolP("quiet", "q", false, "Only display mirror urls")
+	cmd.Flags().String("format", "",
+		"Format the output using the given Go template, e.g, 'table {{.Registry}}\\t{{.URL}}', "+
+			"or one of 'table', 'wide', 'json', 'jsonl', 'yaml', 'csv'")
+
+	_ = cmd.RegisterFlagCompletionFunc(
+		"format",
+		func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return formatter.Formats, cobra.ShellCompDirectiveNoFileComp
+		},
+	)
+
+	return cmd
+}
+
+func mirrorListOptions(cmd *cobra.Command) (*options.RegistryMirrorList, error) {
+	quiet, err := cmd.Flags().GetBool("quiet")
+	if err != nil {
+		return nil, err
+	}
+
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return nil, err
+	}
+
+	return &options.RegistryMirrorList{
+		Quiet:  quiet,
+		Format: format,
+	}, nil
+}
+
+func mirrorListAction(cmd *cobra.Command, _ []string) error {
+	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	opts, err := mirrorListOptions(cmd)
+	if err != nil {
+		return err
+	}
+
+	return registry.MirrorList(cmd.OutOrStdout(), globalOptions, opts)
+}
+
+func mirrorRemoveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:           "rm [flags] REGISTRY MIRROR [MIRROR...]",
+		Aliases:       []string{"remove"},
+		Short:         "Remove mirrors of a registry",
+		Args:          cobra.MinimumNArgs(2),
+		RunE:          mirrorRemoveAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+}
+
+func mirrorRemoveAction(cmd *cobra.Command, args []string) error {
+	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	return registry.MirrorRemove(cmd.OutOrStdout(), globalOptions, args[0], args[1:])
+}
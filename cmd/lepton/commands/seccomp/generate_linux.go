@@ -0,0 +1,67 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package seccomp
+
+import (
+	"github.com/spf13/cobra"
+
+	"go.farcloser.world/lepton/cmd/lepton/completion"
+	"go.farcloser.world/lepton/cmd/lepton/helpers"
+	"go.farcloser.world/lepton/leptonic/services/containerd"
+	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/cmd/seccomp"
+)
+
+func generateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "generate [flags] CONTAINER",
+		Short:             "Generate a minimal seccomp profile from a container run with --security-opt seccomp=trace",
+		Args:              helpers.IsExactArgs(1),
+		RunE:              generateAction,
+		ValidArgsFunction: generateShellComplete,
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+	}
+
+	cmd.Flags().String("name", "", "Store the profile under this name, instead of printing it")
+
+	return cmd
+}
+
+func generateAction(cmd *cobra.Command, args []string) error {
+	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	name, err := cmd.Flags().GetString("name")
+	if err != nil {
+		return err
+	}
+
+	cli, ctx, cancel, err := containerd.NewClient(cmd.Context(), globalOptions.Namespace, globalOptions.Address)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	return seccomp.Generate(ctx, cli, cmd.OutOrStdout(), globalOptions, args[0], &options.SeccompGenerate{Name: name})
+}
+
+func generateShellComplete(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	return completion.ContainerNames(cmd, nil)
+}
@@ -0,0 +1,54 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package seccomp
+
+import (
+	"github.com/spf13/cobra"
+
+	"go.farcloser.world/lepton/cmd/lepton/helpers"
+	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/cmd/seccomp"
+)
+
+func loadCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "load [flags] FILE",
+		Short:         "Validate and store a seccomp profile, to be used by name. FILE can be - for stdin.",
+		Args:          helpers.IsExactArgs(1),
+		RunE:          loadAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	cmd.Flags().String("name", "", "Name of the profile (default: the name of the file, without extension)")
+
+	return cmd
+}
+
+func loadAction(cmd *cobra.Command, args []string) error {
+	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	name, err := cmd.Flags().GetString("name")
+	if err != nil {
+		return err
+	}
+
+	return seccomp.Load(cmd.OutOrStdout(), cmd.InOrStdin(), globalOptions, args[0], &options.SeccompLoad{Name: name})
+}
@@ -0,0 +1,47 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package seccomp
+
+import (
+	"github.com/spf13/cobra"
+
+	"go.farcloser.world/lepton/cmd/lepton/completion"
+	"go.farcloser.world/lepton/cmd/lepton/helpers"
+	"go.farcloser.world/lepton/pkg/cmd/seccomp"
+)
+
+func removeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "rm NAME [NAME...]",
+		Aliases:           []string{"remove"},
+		Short:             "Remove one or more stored seccomp profiles",
+		Args:              cobra.MinimumNArgs(1),
+		RunE:              removeAction,
+		ValidArgsFunction: completion.SeccompProfileNames,
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+	}
+}
+
+func removeAction(cmd *cobra.Command, args []string) error {
+	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	return seccomp.Remove(cmd.OutOrStdout(), globalOptions, args)
+}
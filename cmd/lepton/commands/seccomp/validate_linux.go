@@ -0,0 +1,39 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package seccomp
+
+import (
+	"github.com/spf13/cobra"
+
+	"go.farcloser.world/lepton/cmd/lepton/helpers"
+	"go.farcloser.world/lepton/pkg/cmd/seccomp"
+)
+
+func validateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:           "validate FILE",
+		Short:         "Check that a file is a valid seccomp profile. FILE can be - for stdin.",
+		Args:          helpers.IsExactArgs(1),
+		RunE:          validateAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+}
+
+func validateAction(cmd *cobra.Command, args []string) error {
+	return seccomp.Validate(cmd.InOrStdin(), args[0])
+}
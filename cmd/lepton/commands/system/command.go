@@ -35,7 +35,9 @@ func Command() *cobra.Command {
 	cmd.AddCommand(
 		EventsCommand(),
 		InfoCommand(),
+		metricsCommand(),
 		pruneCommand(),
+		emulationCommand(),
 	)
 
 	return cmd
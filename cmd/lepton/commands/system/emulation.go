@@ -0,0 +1,163 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package system
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"go.farcloser.world/lepton/cmd/lepton/completion"
+	"go.farcloser.world/lepton/cmd/lepton/helpers"
+	"go.farcloser.world/lepton/leptonic/errs"
+	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/cmd/system"
+	"go.farcloser.world/lepton/pkg/formatter"
+)
+
+func emulationCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "emulation",
+		Short:         "Manage the emulators (binfmt_misc handlers) running foreign platforms",
+		RunE:          helpers.UnknownSubcommandAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	cmd.AddCommand(
+		emulationListCommand(),
+		emulationInstallCommand(),
+		emulationUninstallCommand(),
+	)
+
+	return cmd
+}
+
+func emulationListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "ls",
+		Aliases:       []string{"list"},
+		Short:         "List the binfmt_misc handlers, and the platforms they enable",
+		Args:          cobra.NoArgs,
+		RunE:          emulationListAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	cmd.Flags().BoolP("quiet", "q", false, "Only display handler names")
+	cmd.Flags().String("format", "",
+		"Format the output using the given Go template, e.g, 'table {{.Name}}\\t{{.FormattedPlatforms}}', "+
+			"or one of 'table', 'wide', 'json', 'jsonl', 'yaml', 'csv'")
+
+	_ = cmd.RegisterFlagCompletionFunc(
+		"format",
+		func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return formatter.Formats, cobra.ShellCompDirectiveNoFileComp
+		},
+	)
+
+	return cmd
+}
+
+func emulationListAction(cmd *cobra.Command, _ []string) error {
+	quiet, err := cmd.Flags().GetBool("quiet")
+	if err != nil {
+		return err
+	}
+
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return err
+	}
+
+	return system.EmulationList(cmd.OutOrStdout(), &options.SystemEmulationList{
+		Quiet:  quiet,
+		Format: format,
+	})
+}
+
+func emulationInstallCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "install --platform PLATFORM [--platform PLATFORM...]",
+		Short:         "Register static qemu binaries from --qemu-dir to run foreign platforms. Requires root.",
+		Args:          cobra.NoArgs,
+		RunE:          emulationInstallAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	cmd.Flags().StringSlice("platform", nil, "Platform to run through emulation, e.g. 'linux/arm64'")
+	_ = cmd.RegisterFlagCompletionFunc("platform", completion.Platforms)
+
+	return cmd
+}
+
+func emulationInstallAction(cmd *cobra.Command, _ []string) error {
+	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	platforms, err := emulationPlatforms(cmd)
+	if err != nil {
+		return err
+	}
+
+	return system.EmulationInstall(cmd.OutOrStdout(), globalOptions, &options.SystemEmulationInstall{
+		Platforms: platforms,
+	})
+}
+
+func emulationUninstallCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "uninstall --platform PLATFORM [--platform PLATFORM...]",
+		Short:         "Unregister the binfmt_misc handlers running foreign platforms. Requires root.",
+		Args:          cobra.NoArgs,
+		RunE:          emulationUninstallAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	cmd.Flags().StringSlice("platform", nil, "Platform to stop running through emulation, e.g. 'linux/arm64'")
+	_ = cmd.RegisterFlagCompletionFunc("platform", completion.Platforms)
+
+	return cmd
+}
+
+func emulationUninstallAction(cmd *cobra.Command, _ []string) error {
+	platforms, err := emulationPlatforms(cmd)
+	if err != nil {
+		return err
+	}
+
+	return system.EmulationUninstall(cmd.OutOrStdout(), &options.SystemEmulationUninstall{
+		Platforms: platforms,
+	})
+}
+
+func emulationPlatforms(cmd *cobra.Command) ([]string, error) {
+	platforms, err := cmd.Flags().GetStringSlice("platform")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(platforms) == 0 {
+		return nil, errors.Join(errs.ErrInvalidArgument, errors.New("at least one --platform is required"))
+	}
+
+	return platforms, nil
+}
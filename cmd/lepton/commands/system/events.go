@@ -39,6 +39,10 @@ func EventsCommand() *cobra.Command {
 
 	cmd.Flags().String("format", "", "Format the output using the given Go template, e.g, '{{json .}}'")
 	cmd.Flags().StringSliceP("filter", "f", []string{}, "Filter matches containers based on given conditions")
+	cmd.Flags().
+		String("since", "", "Replay recorded events since timestamp (e.g. 2013-01-02T13:23:37Z) or relative (e.g. 42m)")
+	cmd.Flags().
+		String("until", "", "Stream events until timestamp (e.g. 2013-01-02T13:23:37Z) or relative (e.g. 42m)")
 
 	_ = cmd.RegisterFlagCompletionFunc(
 		"format",
@@ -47,6 +51,23 @@ func EventsCommand() *cobra.Command {
 		},
 	)
 
+	cmd.AddCommand(eventsRecordCommand())
+
+	return cmd
+}
+
+func eventsRecordCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "record",
+		Args:  cobra.NoArgs,
+		Short: "Record container die and oom events into the events journal",
+		Long: `Record container die and oom events into the events journal, until interrupted.
+Other events are recorded by the commands that trigger them, when the events journal is enabled.`,
+		RunE:          eventsRecordAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
 	return cmd
 }
 
@@ -61,9 +82,21 @@ func eventsOptions(cmd *cobra.Command, _ []string) (*options.SystemEvents, error
 		return nil, err
 	}
 
+	since, err := cmd.Flags().GetString("since")
+	if err != nil {
+		return nil, err
+	}
+
+	until, err := cmd.Flags().GetString("until")
+	if err != nil {
+		return nil, err
+	}
+
 	return &options.SystemEvents{
 		Format:  format,
 		Filters: filters,
+		Since:   since,
+		Until:   until,
 	}, nil
 }
 
@@ -87,3 +120,19 @@ func eventsAction(cmd *cobra.Command, args []string) error {
 
 	return system.Events(ctx, cli, cmd.OutOrStdout(), globalOptions, opts)
 }
+
+func eventsRecordAction(cmd *cobra.Command, _ []string) error {
+	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	cli, ctx, cancel, err := containerd.NewClient(cmd.Context(), globalOptions.Namespace, globalOptions.Address)
+	if err != nil {
+		return err
+	}
+
+	defer cancel()
+
+	return system.EventsRecord(ctx, cli, globalOptions)
+}
@@ -0,0 +1,87 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package system
+
+import (
+	"github.com/spf13/cobra"
+
+	"go.farcloser.world/lepton/cmd/lepton/helpers"
+	"go.farcloser.world/lepton/leptonic/services/containerd"
+	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/cmd/system"
+)
+
+func metricsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "metrics",
+		Short:         "Expose containers and engine metrics",
+		RunE:          helpers.UnknownSubcommandAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	cmd.AddCommand(metricsServeCommand())
+
+	return cmd
+}
+
+func metricsServeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "serve",
+		Args:          cobra.NoArgs,
+		Short:         "Serve metrics in the OpenMetrics format over http, on /metrics",
+		RunE:          metricsServeAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	cmd.Flags().String("addr", "127.0.0.1:9323", "Address to listen on")
+
+	return cmd
+}
+
+func metricsServeOptions(cmd *cobra.Command, _ []string) (options.SystemMetricsServe, error) {
+	addr, err := cmd.Flags().GetString("addr")
+	if err != nil {
+		return options.SystemMetricsServe{}, err
+	}
+
+	return options.SystemMetricsServe{
+		Addr: addr,
+	}, nil
+}
+
+func metricsServeAction(cmd *cobra.Command, args []string) error {
+	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	opts, err := metricsServeOptions(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	cli, ctx, cancel, err := containerd.NewClient(cmd.Context(), globalOptions.Namespace, globalOptions.Address)
+	if err != nil {
+		return err
+	}
+
+	defer cancel()
+
+	return system.MetricsServe(ctx, cli, globalOptions, opts)
+}
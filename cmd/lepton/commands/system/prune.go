@@ -102,7 +102,7 @@ func pruneAction(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	buildkitHost, err := helpers.ProcessBuildkitHostOption(cmd, globalOptions.Namespace)
+	buildkitHost, err := helpers.ProcessBuildkitHostOption(cmd, globalOptions)
 	if err != nil {
 		log.L.WithError(err).Warn("BuildKit is not running. Build caches will not be pruned.")
 		buildkitHost = ""
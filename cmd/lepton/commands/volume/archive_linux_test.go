@@ -0,0 +1,220 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package volume_test
+
+import (
+	"archive/tar"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/tigron/expect"
+	"go.farcloser.world/tigron/require"
+	"go.farcloser.world/tigron/test"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+	"go.farcloser.world/lepton/pkg/testutil"
+	"go.farcloser.world/lepton/pkg/testutil/nerdtest"
+)
+
+func TestVolumeExportImport(t *testing.T) {
+	testCase := nerdtest.Setup()
+
+	testCase.Require = require.Not(nerdtest.Docker)
+
+	testCase.Setup = func(data test.Data, helpers test.Helpers) {
+		data.Set("source", data.Identifier("source"))
+		helpers.Ensure("volume", "create", "--label", "foo=bar", data.Get("source"))
+		helpers.Ensure("run", "--rm", "-v", data.Get("source")+":/volume", testutil.CommonImage,
+			"sh", "-euxc", "echo hello > /volume/file && mkdir /volume/dir && chown 1234:5678 /volume/dir")
+	}
+
+	testCase.Cleanup = func(data test.Data, helpers test.Helpers) {
+		helpers.Anyhow("volume", "rm", data.Get("source"))
+	}
+
+	runUser := func(data test.Data, helpers test.Helpers) {
+		helpers.Ensure("run", "-d", "--name", data.Identifier(), "-v", data.Get("source")+":/volume",
+			testutil.CommonImage, "sleep", nerdtest.Infinity)
+	}
+
+	removeUser := func(data test.Data, helpers test.Helpers) {
+		helpers.Anyhow("rm", "-f", data.Identifier())
+	}
+
+	// Subtests running a container using the source volume prevent the others from exporting it
+	testCase.SubTests = []*test.Case{
+		{
+			Description: "export to a zstd archive, then import into a new volume",
+			NoParallel:  true,
+			Cleanup: func(data test.Data, helpers test.Helpers) {
+				helpers.Anyhow("volume", "rm", data.Identifier())
+			},
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				archive := filepath.Join(data.TempDir(), "volume.tar.zst")
+				helpers.Ensure("volume", "export", "-o", archive, data.Get("source"))
+				helpers.Ensure("volume", "import", data.Identifier(), archive)
+
+				return helpers.Command("run", "--rm", "-v", data.Identifier()+":/volume",
+					testutil.CommonImage, "sh", "-euc", "cat /volume/file; stat -c %u:%g /volume/dir")
+			},
+			Expected: func(data test.Data, helpers test.Helpers) *test.Expected {
+				return &test.Expected{
+					Output: expect.All(
+						expect.Equals("hello\n1234:5678\n"),
+						func(stdout, info string, t *testing.T) {
+							helpers.Command("volume", "inspect", "--format", "{{.Labels.foo}}", data.Identifier()).
+								Run(&test.Expected{Output: expect.Equals("bar\n")})
+						},
+					),
+				}
+			},
+		},
+		{
+			Description: "create --from clones labels and content",
+			NoParallel:  true,
+			Cleanup: func(data test.Data, helpers test.Helpers) {
+				helpers.Anyhow("volume", "rm", data.Identifier())
+			},
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				helpers.Ensure("volume", "create", "--from", data.Get("source"), data.Identifier())
+
+				return helpers.Command("run", "--rm", "-v", data.Identifier()+":/volume",
+					testutil.CommonImage, "cat", "/volume/file")
+			},
+			Expected: test.Expects(expect.ExitCodeSuccess, nil, expect.Equals("hello\n")),
+		},
+		{
+			Description: "export of a volume used by a running container requires --pause-users",
+			NoParallel:  true,
+			Setup:       runUser,
+			Cleanup:     removeUser,
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				return helpers.Command("volume", "export", "-o", filepath.Join(data.TempDir(), "volume.tar"),
+					data.Get("source"))
+			},
+			Expected: test.Expects(1, []error{errs.ErrFailedPrecondition}, nil),
+		},
+		{
+			Description: "export with --pause-users resumes the containers",
+			NoParallel:  true,
+			Setup:       runUser,
+			Cleanup:     removeUser,
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				helpers.Ensure("volume", "export", "--pause-users", "-o",
+					filepath.Join(data.TempDir(), "volume.tar"), data.Get("source"))
+
+				return helpers.Command("inspect", "--format", "{{.State.Status}}", data.Identifier())
+			},
+			Expected: test.Expects(expect.ExitCodeSuccess, nil, expect.Equals("running\n")),
+		},
+		{
+			Description: "archived mount options are not applied, --opt is",
+			NoParallel:  true,
+			Require:     nerdtest.Rootful,
+			Setup: func(data test.Data, helpers test.Helpers) {
+				helpers.Ensure("volume", "create", "--opt", "type=tmpfs", "--opt", "device=tmpfs",
+					"--opt", "o=size=10m", data.Identifier("options"))
+			},
+			Cleanup: func(data test.Data, helpers test.Helpers) {
+				helpers.Anyhow("volume", "rm", data.Identifier("options"), data.Identifier("plain"), data.Identifier())
+			},
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				archive := filepath.Join(data.TempDir(), "volume.tar")
+				helpers.Ensure("volume", "export", "-o", archive, data.Identifier("options"))
+				helpers.Ensure("volume", "import", data.Identifier("plain"), archive)
+				helpers.Command("volume", "inspect", "--format", "{{len .Options}}", data.Identifier("plain")).
+					Run(&test.Expected{Output: expect.Equals("0\n")})
+				helpers.Ensure("volume", "import", "--opt", "type=tmpfs", "--opt", "device=tmpfs",
+					"--opt", "o=size=20m", data.Identifier(), archive)
+
+				return helpers.Command("volume", "inspect", "--format", "{{.Options.type}} {{.Options.o}}",
+					data.Identifier())
+			},
+			Expected: test.Expects(expect.ExitCodeSuccess, nil, expect.Equals("tmpfs size=20m\n")),
+		},
+		{
+			Description: "an archive cannot bind mount a host directory on the imported volume",
+			Require:     nerdtest.Rootful,
+			Cleanup: func(data test.Data, helpers test.Helpers) {
+				helpers.Anyhow("volume", "rm", data.Identifier())
+			},
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				target := data.TempDir()
+				archive := filepath.Join(data.TempDir(), "bind.tar")
+				writeArchive(helpers, archive, [][2]string{
+					{"volume.json", `{"options":{"type":"none","o":"bind","device":"` + target + `"}}`},
+					{"_data/file", "written"},
+				})
+
+				helpers.Ensure("volume", "import", data.Identifier(), archive)
+				_, err := os.Stat(filepath.Join(target, "file"))
+				assert.Assert(helpers.T(), errors.Is(err, os.ErrNotExist))
+
+				return helpers.Command("volume", "inspect", "--format", "{{len .Options}}", data.Identifier())
+			},
+			Expected: test.Expects(expect.ExitCodeSuccess, nil, expect.Equals("0\n")),
+		},
+		{
+			Description: "a failed import does not leave the created volume behind",
+			Cleanup: func(data test.Data, helpers test.Helpers) {
+				helpers.Anyhow("volume", "rm", data.Identifier())
+			},
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				archive := filepath.Join(data.TempDir(), "escape.tar")
+				// An entry outside of the data directory
+				writeArchive(helpers, archive, [][2]string{{"volume.json", "{}"}, {"_data/../escape", "escape"}})
+
+				helpers.Fail("volume", "import", data.Identifier(), archive)
+
+				return helpers.Command("volume", "inspect", data.Identifier())
+			},
+			Expected: test.Expects(1, []error{errs.ErrNotFound}, nil),
+		},
+		{
+			Description: "importing something else than a volume archive fails",
+			Command: func(data test.Data, helpers test.Helpers) test.TestableCommand {
+				return helpers.Command("volume", "import", data.Identifier(), "/etc/hostname")
+			},
+			Expected: test.Expects(1, nil, nil),
+		},
+	}
+
+	testCase.Run(t)
+}
+
+// writeArchive writes a tar archive of regular files, given as name and content pairs, in order.
+func writeArchive(helpers test.Helpers, archive string, entries [][2]string) {
+	f, err := os.Create(archive)
+	assert.NilError(helpers.T(), err)
+	tw := tar.NewWriter(f)
+	for _, entry := range entries {
+		assert.NilError(helpers.T(), tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     entry[0],
+			Mode:     0o644,
+			Size:     int64(len(entry[1])),
+		}))
+		_, err = tw.Write([]byte(entry[1]))
+		assert.NilError(helpers.T(), err)
+	}
+	assert.NilError(helpers.T(), tw.Close())
+	assert.NilError(helpers.T(), f.Close())
+}
@@ -38,6 +38,8 @@ func Command() *cobra.Command {
 		createCommand(),
 		removeCommand(),
 		pruneCommand(),
+		exportCommand(),
+		importCommand(),
 	)
 
 	return cmd
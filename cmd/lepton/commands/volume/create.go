@@ -18,11 +18,15 @@ package volume
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 
+	"go.farcloser.world/lepton/cmd/lepton/completion"
 	"go.farcloser.world/lepton/cmd/lepton/helpers"
 	"go.farcloser.world/lepton/leptonic/errs"
+	"go.farcloser.world/lepton/leptonic/services/containerd"
 	"go.farcloser.world/lepton/pkg/api/options"
 	"go.farcloser.world/lepton/pkg/cmd/volume"
 	"go.farcloser.world/lepton/pkg/utils"
@@ -39,6 +43,11 @@ func createCommand() *cobra.Command {
 	}
 
 	cmd.Flags().StringArray("label", nil, "Set a label on the volume")
+	cmd.Flags().StringArrayP("opt", "o", nil, "Set driver specific options (type, device, o)")
+	cmd.Flags().String("from", "", "Clone an existing volume, labels and content")
+	cmd.Flags().Bool("pause-users", false, "Pause the running containers using the cloned volume during the copy")
+
+	_ = cmd.RegisterFlagCompletionFunc("from", completion.VolumeNames)
 
 	return cmd
 }
@@ -60,17 +69,54 @@ func createOptions(cmd *cobra.Command, args []string) (*options.VolumeCreate, er
 		lbls = utils.KeyValueStringsToMap(labels)
 	}
 
+	opts, err := volumeOptions(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := cmd.Flags().GetString("from")
+	if err != nil {
+		return nil, err
+	}
+
+	pauseUsers, err := cmd.Flags().GetBool("pause-users")
+	if err != nil {
+		return nil, err
+	}
+
 	name := ""
 	if len(args) > 0 {
 		name = args[0]
 	}
 
 	return &options.VolumeCreate{
-		Name:   name,
-		Labels: lbls,
+		Name:       name,
+		Labels:     lbls,
+		Options:    opts,
+		From:       from,
+		PauseUsers: pauseUsers,
 	}, nil
 }
 
+// volumeOptions parses the --opt flags.
+func volumeOptions(cmd *cobra.Command) (map[string]string, error) {
+	optStrings, err := cmd.Flags().GetStringArray("opt")
+	if err != nil {
+		return nil, err
+	}
+
+	opts := map[string]string{}
+	for _, opt := range optStrings {
+		key, value, ok := strings.Cut(opt, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%w: invalid volume option %q (expected key=value)", errs.ErrInvalidArgument, opt)
+		}
+		opts[key] = value
+	}
+
+	return opts, nil
+}
+
 func createAction(cmd *cobra.Command, args []string) error {
 	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
 	if err != nil {
@@ -82,5 +128,16 @@ func createAction(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	return volume.Create(cmd.Context(), cmd.OutOrStdout(), globalOptions, opts)
+	if opts.From == "" {
+		return volume.Create(cmd.Context(), cmd.OutOrStdout(), globalOptions, opts)
+	}
+
+	cli, ctx, cancel, err := containerd.NewClient(cmd.Context(), globalOptions.Namespace, globalOptions.Address)
+	if err != nil {
+		return err
+	}
+
+	defer cancel()
+
+	return volume.Clone(ctx, cli, cmd.OutOrStdout(), globalOptions, opts)
 }
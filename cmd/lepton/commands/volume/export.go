@@ -0,0 +1,132 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package volume
+
+import (
+	"errors"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"go.farcloser.world/core/term"
+
+	"go.farcloser.world/lepton/cmd/lepton/completion"
+	"go.farcloser.world/lepton/cmd/lepton/helpers"
+	"go.farcloser.world/lepton/leptonic/services/containerd"
+	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/cmd/volume"
+)
+
+func exportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "export [flags] VOLUME",
+		Short:             "Export a volume, labels and content, to a tar archive (streamed to STDOUT by default)",
+		Args:              helpers.IsExactArgs(1),
+		RunE:              exportAction,
+		ValidArgsFunction: exportShellComplete,
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+	}
+
+	cmd.Flags().StringP("output", "o", "", "Write to a file, instead of STDOUT")
+	cmd.Flags().String("compression", "",
+		"Compression of the archive (none, gzip, zstd). Inferred from the output file extension by default")
+	cmd.Flags().Bool("pause-users", false, "Pause the running containers using the volume during the export")
+
+	_ = cmd.RegisterFlagCompletionFunc("compression",
+		func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+			return volume.Compressions, cobra.ShellCompDirectiveNoFileComp
+		},
+	)
+
+	return cmd
+}
+
+func exportOptions(cmd *cobra.Command, _ []string) (*options.VolumeExport, error) {
+	outputPath, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return nil, err
+	}
+
+	compression, err := cmd.Flags().GetString("compression")
+	if err != nil {
+		return nil, err
+	}
+
+	if compression == "" {
+		compression = volume.CompressionFromPath(outputPath)
+	}
+
+	pauseUsers, err := cmd.Flags().GetBool("pause-users")
+	if err != nil {
+		return nil, err
+	}
+
+	return &options.VolumeExport{
+		Compression: compression,
+		PauseUsers:  pauseUsers,
+	}, nil
+}
+
+func exportAction(cmd *cobra.Command, args []string) error {
+	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	opts, err := exportOptions(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	output := cmd.OutOrStdout()
+	outputPath, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return err
+	} else if outputPath != "" {
+		f, err := os.OpenFile(outputPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+		if err != nil {
+			return err
+		}
+		output = f
+		defer f.Close()
+	} else if out, ok := output.(*os.File); ok && term.IsTerminal(out.Fd()) {
+		return errors.New("cowardly refusing to export to a terminal. Use the -o flag or redirect")
+	}
+	opts.Stdout = output
+
+	cli, ctx, cancel, err := containerd.NewClient(cmd.Context(), globalOptions.Namespace, globalOptions.Address)
+	if err != nil {
+		return err
+	}
+
+	defer cancel()
+
+	if err = volume.Export(ctx, cli, globalOptions, args[0], opts); err != nil && outputPath != "" {
+		os.Remove(outputPath)
+	}
+
+	return err
+}
+
+func exportShellComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return completion.VolumeNames(cmd, args, toComplete)
+}
@@ -0,0 +1,106 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package volume
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"go.farcloser.world/lepton/cmd/lepton/completion"
+	"go.farcloser.world/lepton/cmd/lepton/helpers"
+	"go.farcloser.world/lepton/leptonic/services/containerd"
+	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/cmd/volume"
+)
+
+func importCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import [flags] VOLUME [FILE|-]",
+		Short: "Import a volume archive created by `volume export` (read from STDIN by default)",
+		Long: "The volume is created with the archived labels if it does not exist. " +
+			"Otherwise, the archived content is extracted over the existing one.\n" +
+			"Of the archived options, only the size is restored: other options must be set with --opt.\n" +
+			"The compression of the archive (gzip, zstd) is detected.",
+		Args:              cobra.RangeArgs(1, 2),
+		RunE:              importAction,
+		ValidArgsFunction: importShellComplete,
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+	}
+
+	cmd.Flags().Bool("pause-users", false, "Pause the running containers using the volume during the import")
+	cmd.Flags().StringArrayP("opt", "o", nil, "Set driver specific options of the created volume (type, device, o)")
+
+	return cmd
+}
+
+func importOptions(cmd *cobra.Command, _ []string) (*options.VolumeImport, error) {
+	pauseUsers, err := cmd.Flags().GetBool("pause-users")
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := volumeOptions(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &options.VolumeImport{
+		Stdin:      cmd.InOrStdin(),
+		PauseUsers: pauseUsers,
+		Options:    opts,
+	}, nil
+}
+
+func importAction(cmd *cobra.Command, args []string) error {
+	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	opts, err := importOptions(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	if len(args) > 1 && args[1] != "-" {
+		f, err := os.Open(args[1])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		opts.Stdin = f
+	}
+
+	cli, ctx, cancel, err := containerd.NewClient(cmd.Context(), globalOptions.Namespace, globalOptions.Address)
+	if err != nil {
+		return err
+	}
+
+	defer cancel()
+
+	return volume.Import(ctx, cli, cmd.OutOrStdout(), globalOptions, args[0], opts)
+}
+
+func importShellComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return completion.VolumeNames(cmd, args, toComplete)
+	}
+
+	return nil, cobra.ShellCompDirectiveDefault
+}
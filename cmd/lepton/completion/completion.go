@@ -24,9 +24,12 @@ import (
 	"github.com/spf13/cobra"
 
 	"go.farcloser.world/lepton/cmd/lepton/helpers"
+	"go.farcloser.world/lepton/leptonic/buildkit"
 	"go.farcloser.world/lepton/leptonic/services/containerd"
 	"go.farcloser.world/lepton/leptonic/services/image"
 	"go.farcloser.world/lepton/leptonic/services/namespace"
+	"go.farcloser.world/lepton/pkg/cmd/builder"
+	"go.farcloser.world/lepton/pkg/cmd/seccomp"
 	"go.farcloser.world/lepton/pkg/cmd/volume"
 	"go.farcloser.world/lepton/pkg/labels"
 	"go.farcloser.world/lepton/pkg/netutil"
@@ -57,6 +60,54 @@ func VolumeNames(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.Shel
 	return candidates, cobra.ShellCompDirectiveNoFileComp
 }
 
+func BuilderNames(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	instStore, err := builder.Store(globalOptions.Namespace, globalOptions.DataRoot, globalOptions.Address)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	instances, err := instStore.List()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	candidates := []string{buildkit.DefaultInstanceName}
+	for _, inst := range instances {
+		candidates = append(candidates, inst.Name)
+	}
+
+	return candidates, cobra.ShellCompDirectiveNoFileComp
+}
+
+func SeccompProfileNames(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	profiles, err := seccomp.Store(globalOptions.DataRoot, globalOptions.Address)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	list, err := profiles.List()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	candidates := []string{}
+	for _, profile := range list {
+		candidates = append(candidates, profile.Name)
+	}
+
+	return candidates, cobra.ShellCompDirectiveNoFileComp
+}
+
 func ImageNames(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
 	globalOptions, err := helpers.ProcessRootCmdFlags(cmd)
 	if err != nil {
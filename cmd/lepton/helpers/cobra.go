@@ -21,10 +21,13 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/containerd/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+
+	"go.farcloser.world/lepton/pkg/imgutil/jobs"
 )
 
 // UnknownSubcommandAction is needed to let `system non-existent-command` fail
@@ -271,3 +274,39 @@ func AddPersistentBoolFlag(
 		}
 	}
 }
+
+// ApplyFormatDefaults sets the default value of the `--format` flag of commands, from the `[formats]` section of the
+// config.
+// Keys are command paths relative to the root command, e.g. "ps", "volume ls" or "compose ps". Aliases are accepted.
+func ApplyFormatDefaults(rootCmd *cobra.Command, formats map[string]string) error {
+	for key, format := range formats {
+		cmd, rest, err := rootCmd.Find(strings.Fields(key))
+		if err != nil || len(rest) > 0 || cmd == rootCmd {
+			return fmt.Errorf("invalid formats entry %q in config: unknown command", key)
+		}
+		flag := cmd.Flags().Lookup("format")
+		if flag == nil {
+			return fmt.Errorf(
+				"invalid formats entry %q in config: %q does not have a --format flag",
+				key,
+				cmd.CommandPath(),
+			)
+		}
+		if err = flag.Value.Set(format); err != nil {
+			return fmt.Errorf("invalid formats entry %q in config: %w", key, err)
+		}
+		flag.DefValue = format
+	}
+	return nil
+}
+
+// AddProgressFlag adds the --progress flag of commands transferring content
+func AddProgressFlag(cmd *cobra.Command) {
+	cmd.Flags().String("progress", jobs.ProgressAuto, "Set type of progress output (auto, tty, plain, json)")
+	_ = cmd.RegisterFlagCompletionFunc(
+		"progress",
+		func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return jobs.ProgressModes, cobra.ShellCompDirectiveNoFileComp
+		},
+	)
+}
@@ -26,6 +26,9 @@ import (
 
 	"go.farcloser.world/lepton/leptonic/buildkit"
 	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/clientutil"
+	"go.farcloser.world/lepton/pkg/config"
+	"go.farcloser.world/lepton/pkg/imgutil/jobs"
 )
 
 func ProcessImageVerifyOptions(cmd *cobra.Command, _ []string) (opt options.ImageVerify, err error) {
@@ -56,22 +59,91 @@ func ProcessImageVerifyOptions(cmd *cobra.Command, _ []string) (opt options.Imag
 	return
 }
 
-func ProcessBuildkitHostOption(cmd *cobra.Command, namespace string) (string, error) {
+// ProcessProgressOption returns the validated value of the --progress flag (see AddProgressFlag)
+func ProcessProgressOption(cmd *cobra.Command) (string, error) {
+	progress, err := cmd.Flags().GetString("progress")
+	if err != nil {
+		return "", err
+	}
+
+	return progress, jobs.ValidateProgress(progress)
+}
+
+// ProcessBuildkitHostOption returns the address of the builder to use, as resolved by ProcessBuilderOption.
+func ProcessBuildkitHostOption(cmd *cobra.Command, globalOptions *options.Global) (string, error) {
+	inst, err := ProcessBuilderOption(cmd, globalOptions)
+	if err != nil {
+		return "", err
+	}
+
+	return inst.Host, nil
+}
+
+// ProcessBuilderOption resolves the builder to use.
+// An explicit --buildkit-host (or BUILDKIT_HOST) wins, then --builder if the command has it, then the builder
+// selected with `builder use`, and finally the implicit builder of the namespace.
+func ProcessBuilderOption(cmd *cobra.Command, globalOptions *options.Global) (*buildkit.Instance, error) {
 	if cmd.Flags().Changed("buildkit-host") || os.Getenv("BUILDKIT_HOST") != "" {
 		// If address is explicitly specified, use it.
 		buildkitHost, err := cmd.Flags().GetString("buildkit-host")
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 
 		if err = buildkit.PingBKDaemon(buildkitHost); err != nil {
-			return "", err
+			return nil, err
 		}
 
-		return buildkitHost, nil
+		return &buildkit.Instance{Host: buildkitHost}, nil
 	}
 
-	return buildkit.GetBuildkitHost(namespace)
+	var name string
+	if cmd.Flags().Lookup("builder") != nil {
+		var err error
+		if name, err = cmd.Flags().GetString("builder"); err != nil {
+			return nil, err
+		}
+	}
+
+	dataStore, err := clientutil.DataStore(globalOptions.DataRoot, globalOptions.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	instStore, err := buildkit.NewInstanceStore(dataStore, globalOptions.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		if name, err = instStore.Current(); err != nil {
+			return nil, err
+		}
+	}
+
+	if name == buildkit.DefaultInstanceName {
+		buildkitHost, err := buildkit.GetBuildkitHost(globalOptions.Namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		return &buildkit.Instance{Name: buildkit.DefaultInstanceName, Host: buildkitHost}, nil
+	}
+
+	inst, err := instStore.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = buildkit.PingBKDaemon(inst.Host); err != nil {
+		return nil, fmt.Errorf("builder %q is not reachable: %w", inst.Name, err)
+	}
+
+	if err = inst.CheckWorkerLabels(cmd.Context()); err != nil {
+		return nil, err
+	}
+
+	return inst, nil
 }
 
 func ProcessRootCmdFlags(cmd *cobra.Command) (*options.Global, error) {
@@ -145,27 +217,85 @@ func ProcessRootCmdFlags(cmd *cobra.Command) (*options.Global, error) {
 		return nil, err
 	}
 
+	bridgeSubnet, err := cmd.Flags().GetString("bridge-subnet")
+	if err != nil {
+		return nil, err
+	}
+
+	bridgeIPv6, err := cmd.Flags().GetBool("bridge-ipv6")
+	if err != nil {
+		return nil, err
+	}
+
+	ipv6Pool, err := cmd.Flags().GetString("ipv6-pool")
+	if err != nil {
+		return nil, err
+	}
+
 	kubeHideDupe, err := cmd.Flags().GetBool("kube-hide-dupe")
 	if err != nil {
 		return nil, err
 	}
 
+	eventsJournal, err := cmd.Flags().GetBool("events-journal")
+	if err != nil {
+		return nil, err
+	}
+
+	qemuDir, err := cmd.Flags().GetString("qemu-dir")
+	if err != nil {
+		return nil, err
+	}
+
+	hooksDir, err := cmd.Flags().GetString("hooks-dir")
+	if err != nil {
+		return nil, err
+	}
+
+	registryMirrors, err := cmd.Flags().GetStringArray("registry-mirror")
+	if err != nil {
+		return nil, err
+	}
+
+	registry, err := config.ParseRegistryMirrors(registryMirrors)
+	if err != nil {
+		return nil, err
+	}
+
+	addressPools, err := cmd.Flags().GetStringArray("default-address-pool")
+	if err != nil {
+		return nil, err
+	}
+
+	defaultAddressPools, err := config.ParseAddressPools(addressPools)
+	if err != nil {
+		return nil, err
+	}
+
 	return &options.Global{
-		Debug:            debug,
-		DebugFull:        debugFull,
-		Address:          address,
-		Namespace:        namespace,
-		Snapshotter:      snapshotter,
-		CNIPath:          cniPath,
-		CNINetConfPath:   cniConfigPath,
-		DataRoot:         dataRoot,
-		CgroupManager:    cgroups.Manager(cgroupManager),
-		InsecureRegistry: insecureRegistry,
-		HostsDir:         hostsDir,
-		Experimental:     experimental,
-		HostGatewayIP:    hostGatewayIP,
-		BridgeIP:         bridgeIP,
-		KubeHideDupe:     kubeHideDupe,
+		Debug:               debug,
+		DebugFull:           debugFull,
+		Address:             address,
+		Namespace:           namespace,
+		Snapshotter:         snapshotter,
+		CNIPath:             cniPath,
+		CNINetConfPath:      cniConfigPath,
+		DataRoot:            dataRoot,
+		CgroupManager:       cgroups.Manager(cgroupManager),
+		InsecureRegistry:    insecureRegistry,
+		HostsDir:            hostsDir,
+		Experimental:        experimental,
+		HostGatewayIP:       hostGatewayIP,
+		BridgeIP:            bridgeIP,
+		BridgeSubnet:        bridgeSubnet,
+		BridgeIPv6:          bridgeIPv6,
+		IPv6Pool:            ipv6Pool,
+		KubeHideDupe:        kubeHideDupe,
+		EventsJournal:       eventsJournal,
+		QemuDir:             qemuDir,
+		HooksDir:            hooksDir,
+		Registry:            registry,
+		DefaultAddressPools: defaultAddressPools,
 	}, nil
 }
 
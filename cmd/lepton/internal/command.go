@@ -33,5 +33,7 @@ func Command() *cobra.Command {
 		ociHookCommand(),
 	)
 
+	addCpCommand(cmd)
+
 	return cmd
 }
@@ -0,0 +1,99 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"go.farcloser.world/lepton/cmd/lepton/helpers"
+	"go.farcloser.world/lepton/pkg/tarutil"
+)
+
+// cp pack and unpack are run by `cp` in the user namespace of a container, in rootless mode
+func addCpCommand(cmd *cobra.Command) {
+	cpCmd := &cobra.Command{
+		Use:           "cp",
+		Short:         "Archive files from, or extract files into a container user namespace",
+		RunE:          helpers.UnknownSubcommandAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	packCmd := &cobra.Command{
+		Use:           "pack SRC_PATH",
+		Short:         "Write a tar archive of SRC_PATH to stdout",
+		Args:          helpers.IsExactArgs(1),
+		RunE:          internalCpPackAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	packCmd.Flags().String("name", ".", "Name of SRC_PATH in the archive")
+	packCmd.Flags().Bool("follow-link", false, "Follow symbolic links")
+
+	unpackCmd := &cobra.Command{
+		Use:           "unpack DEST_PATH",
+		Short:         "Extract a tar archive read from stdin into DEST_PATH",
+		Args:          helpers.IsExactArgs(1),
+		RunE:          internalCpUnpackAction,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	unpackCmd.Flags().String("chown", "", "Owner of the extracted files (UID:GID), instead of the archived one")
+
+	cpCmd.AddCommand(packCmd, unpackCmd)
+	cmd.AddCommand(cpCmd)
+}
+
+func internalCpPackAction(cmd *cobra.Command, args []string) error {
+	name, err := cmd.Flags().GetString("name")
+	if err != nil {
+		return err
+	}
+
+	followLink, err := cmd.Flags().GetBool("follow-link")
+	if err != nil {
+		return err
+	}
+
+	return tarutil.Pack(os.Stdout, args[0], tarutil.PackOptions{
+		Name:          name,
+		FollowSymlink: followLink,
+	})
+}
+
+func internalCpUnpackAction(cmd *cobra.Command, args []string) error {
+	chown, err := cmd.Flags().GetString("chown")
+	if err != nil {
+		return err
+	}
+
+	var opts tarutil.UnpackOptions
+	if chown != "" {
+		var owner tarutil.Owner
+		if _, err = fmt.Sscanf(chown, "%d:%d", &owner.UID, &owner.GID); err != nil {
+			return fmt.Errorf("invalid owner %q: %w", chown, err)
+		}
+		opts.Chown = &owner
+	}
+
+	return tarutil.Unpack(os.Stdin, args[0], opts)
+}
@@ -62,5 +62,8 @@ func internalOCIHookAction(cmd *cobra.Command, args []string) error {
 		globalOptions.CNIPath,
 		globalOptions.CNINetConfPath,
 		globalOptions.BridgeIP,
+		globalOptions.BridgeSubnet,
+		globalOptions.BridgeIPv6,
+		globalOptions.IPv6Pool,
 	)
 }
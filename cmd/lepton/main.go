@@ -144,14 +144,14 @@ func xmain() error {
 	return app.Execute()
 }
 
-func initRootCmdFlags(rootCmd *cobra.Command, tomlPath string) (*pflag.FlagSet, error) {
+func initRootCmdFlags(rootCmd *cobra.Command, tomlPath string) (*config.Config, *pflag.FlagSet, error) {
 	cfg := config.New()
 	if r, err := os.Open(tomlPath); err == nil {
 		log.L.Debugf("Loading config from %q", tomlPath)
 		defer r.Close()
 		dec := toml.NewDecoder(r).DisallowUnknownFields() // set Strict to detect typo
 		if err := dec.Decode(cfg); err != nil {
-			return nil, fmt.Errorf(
+			return nil, nil, fmt.Errorf(
 				"failed to load config (not daemon config) from %q (Hint: don't mix up daemon's `config.toml` with `%s.toml`): %w",
 				tomlPath,
 				version.RootName,
@@ -162,7 +162,7 @@ func initRootCmdFlags(rootCmd *cobra.Command, tomlPath string) (*pflag.FlagSet,
 	} else {
 		log.L.WithError(err).Debugf("Not loading config from %q", tomlPath)
 		if !errors.Is(err, os.ErrNotExist) {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 	aliasToBeInherited := pflag.NewFlagSet(rootCmd.Name(), pflag.ExitOnError)
@@ -271,9 +271,34 @@ func initRootCmdFlags(rootCmd *cobra.Command, tomlPath string) (*pflag.FlagSet,
 		version.EnvPrefix+"_BRIDGE_IP",
 		"IP address for the default bridge network",
 	)
+	rootCmd.PersistentFlags().
+		String("bridge-subnet", cfg.BridgeSubnet, "Subnet of the default bridge network, e.g. 10.1.100.0/24")
+	rootCmd.PersistentFlags().
+		Bool("bridge-ipv6", cfg.BridgeIPv6, "Enable IPv6 on the default bridge network, when creating it")
+	rootCmd.PersistentFlags().String(
+		"ipv6-pool",
+		cfg.IPv6Pool,
+		"ULA prefix IPv6 subnets are allocated from, a random /48 generated once per data root if empty",
+	)
 	rootCmd.PersistentFlags().
 		Bool("kube-hide-dupe", cfg.KubeHideDupe, "Deduplicate images for Kubernetes with namespace k8s.io")
-	return aliasToBeInherited, nil
+	rootCmd.PersistentFlags().
+		Bool("events-journal", cfg.EventsJournal, "Persist events to a journal, for replay with `events --since`")
+	rootCmd.PersistentFlags().
+		String("qemu-dir", cfg.QemuDir, "Directory of the static qemu binaries used by `system emulation install`")
+	rootCmd.PersistentFlags().
+		String("hooks-dir", cfg.HooksDir, "Directory of the user-provided OCI hooks, in hooks.d format")
+	rootCmd.PersistentFlags().StringArray(
+		"registry-mirror",
+		config.FormatRegistryMirrors(cfg.Registry),
+		"Mirror to pull from before falling back to a registry, as REGISTRY=MIRROR, e.g. docker.io=https://mirror.io",
+	)
+	rootCmd.PersistentFlags().StringArray(
+		"default-address-pool",
+		config.FormatAddressPools(cfg.DefaultAddressPools),
+		"Pool to allocate the subnets of new networks from, as base=BASE,size=SIZE, e.g. base=10.200.0.0/16,size=24",
+	)
+	return cfg, aliasToBeInherited, nil
 }
 
 func newApp() (*cobra.Command, error) {
@@ -298,7 +323,7 @@ Config file ($%s_TOML): %s
 	}
 
 	rootCmd.SetUsageFunc(usage)
-	aliasToBeInherited, err := initRootCmdFlags(rootCmd, tomlPath)
+	cfg, aliasToBeInherited, err := initRootCmdFlags(rootCmd, tomlPath)
 	if err != nil {
 		return nil, err
 	}
@@ -421,8 +446,13 @@ Config file ($%s_TOML): %s
 		compose.Command(),
 	)
 	addApparmorCommand(rootCmd)
+	addSeccompCommand(rootCmd)
 	container.AddCopyCommand(rootCmd)
 
+	if err = helpers.ApplyFormatDefaults(rootCmd, cfg.Formats); err != nil {
+		return nil, err
+	}
+
 	// add aliasToBeInherited to subCommand(s) InheritedFlags
 	for _, subCmd := range rootCmd.Commands() {
 		subCmd.InheritedFlags().AddFlagSet(aliasToBeInherited)
@@ -20,6 +20,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"go.farcloser.world/lepton/cmd/lepton/commands/apparmor"
+	"go.farcloser.world/lepton/cmd/lepton/commands/seccomp"
 	"go.farcloser.world/lepton/pkg/rootlessutil"
 	"go.farcloser.world/lepton/pkg/strutil"
 )
@@ -50,6 +51,11 @@ func appNeedsRootlessParentMain(cmd *cobra.Command, _ []string) bool {
 		if len(commands) >= 3 && commands[2] == "cp" {
 			return false
 		}
+	// system emulation: false, because binfmt_misc handlers are registered for the whole host
+	case "system":
+		if len(commands) >= 3 && commands[2] == "emulation" {
+			return false
+		}
 	}
 
 	return true
@@ -58,3 +64,7 @@ func appNeedsRootlessParentMain(cmd *cobra.Command, _ []string) bool {
 func addApparmorCommand(rootCmd *cobra.Command) {
 	rootCmd.AddCommand(apparmor.Command())
 }
+
+func addSeccompCommand(rootCmd *cobra.Command) {
+	rootCmd.AddCommand(seccomp.Command())
+}
@@ -27,3 +27,7 @@ func appNeedsRootlessParentMain(cmd *cobra.Command, args []string) bool {
 func addApparmorCommand(rootCmd *cobra.Command) {
 	// NOP
 }
+
+func addSeccompCommand(rootCmd *cobra.Command) {
+	// NOP
+}
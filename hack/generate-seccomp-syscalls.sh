@@ -0,0 +1,60 @@
+#!/usr/bin/env bash
+
+#   Copyright Farcloser.
+
+#   Licensed under the Apache License, Version 2.0 (the "License");
+#   you may not use this file except in compliance with the License.
+#   You may obtain a copy of the License at
+
+#       http://www.apache.org/licenses/LICENSE-2.0
+
+#   Unless required by applicable law or agreed to in writing, software
+#   distributed under the License is distributed on an "AS IS" BASIS,
+#   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+#   See the License for the specific language governing permissions and
+#   limitations under the License.
+
+# Generates the syscall number to name tables used to turn seccomp audit records into profiles.
+# The tables are extracted from golang.org/x/sys/unix.
+
+set -o errexit -o errtrace -o functrace -o nounset -o pipefail
+root="$(cd "$(dirname "${BASH_SOURCE[0]:-$PWD}")" 2>/dev/null 1>&2 && pwd)"
+readonly root
+
+readonly output="$root"/../leptonic/services/seccomp/zsyscalls_linux.go
+
+# GOARCH, AUDIT_ARCH_* value, specs.Arch constant
+readonly arches=(
+  "amd64 c000003e ArchX86_64"
+  "386 40000003 ArchX86"
+  "arm64 c00000b7 ArchAARCH64"
+  "arm 40000028 ArchARM"
+  "ppc64le c0000015 ArchPPC64LE"
+  "s390x 80000016 ArchS390X"
+  "riscv64 c00000f3 ArchRISCV64"
+)
+
+unix="$(cd "$root"/.. && go list -m -f '{{.Dir}}' golang.org/x/sys)"/unix
+readonly unix
+
+{
+  cat "$root"/headers/go.txt
+  printf '\n// Code generated by hack/generate-seccomp-syscalls.sh; DO NOT EDIT.\n\n'
+  printf 'package seccomp\n\nimport "go.farcloser.world/containers/specs"\n\n'
+  printf '// syscallTables maps audit architectures (AUDIT_ARCH_*) to seccomp architectures and syscall names.\n'
+  printf 'var syscallTables = map[string]syscallTable{\n'
+  for line in "${arches[@]}"; do
+    read -r goarch audit arch <<<"$line"
+    printf '\t"%s": {\n\t\tarch: specs.%s,\n\t\tnames: map[int]string{\n' "$audit" "$arch"
+    # libseccomp names fstatat "newfstatat" on every architecture
+    awk '/^\tSYS_[A-Z0-9_]+ += [0-9]+$/ {
+      name = tolower(substr($1, 5)); nr = $3
+      if (name == "fstatat") name = "newfstatat"
+      if (!(nr in seen)) { seen[nr] = 1; printf "\t\t\t%s: \"%s\",\n", nr, name }
+    }' "$unix"/zsysnum_linux_"$goarch".go
+    printf '\t\t},\n\t},\n'
+  done
+  printf '}\n'
+} > "$output"
+
+gofmt -w "$output"
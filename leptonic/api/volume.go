@@ -19,6 +19,8 @@ package api
 type Volume struct {
 	Name       string            `json:"name"`
 	Labels     map[string]string `json:"labels,omitempty"`
+	Options    map[string]string `json:"options,omitempty"`
 	Mountpoint string            `json:"mountpoint"`
 	Size       int64             `json:"size,omitempty"`
+	Limit      int64             `json:"limit,omitempty"`
 }
@@ -25,7 +25,7 @@ package buildkit
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -36,8 +36,11 @@ import (
 	"runtime"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/containerd/log"
+	"github.com/containerd/platforms"
+	"github.com/moby/buildkit/client"
 
 	"go.farcloser.world/lepton/pkg/rootlessutil"
 )
@@ -48,17 +51,34 @@ const (
 	ContainerfileName     string = "Containerfile"
 
 	TempDockerfileName string = "docker-build-tempdockerfile-"
+
+	pingTimeout = 5 * time.Second
 )
 
+// BuildctlBinary returns the path of the buildctl binary.
+// Builds do not need it, as they are driven through the BuildKit client API.
 func BuildctlBinary() (string, error) {
 	return exec.LookPath("buildctl")
 }
 
-func BuildctlBaseArgs(buildkitHost string) []string {
-	return []string{"--addr=" + buildkitHost}
+// NewClient returns a BuildKit client for buildkitHost.
+// The connection is established lazily, on the first call.
+func NewClient(ctx context.Context, buildkitHost string) (*client.Client, error) {
+	return client.New(ctx, buildkitHost)
 }
 
 func GetBuildkitHost(namespace string) (string, error) {
+	buildkitHost, err := FindBuildkitHost(namespace)
+	if err != nil {
+		log.L.WithError(err).Error(getHint())
+	}
+
+	return buildkitHost, err
+}
+
+// FindBuildkitHost returns the first reachable well-known BuildKit address of the namespace.
+// Unlike GetBuildkitHost, it does not log hints on failure.
+func FindBuildkitHost(namespace string) (string, error) {
 	paths, err := getBuildkitHostCandidates(namespace)
 	if err != nil {
 		return "", err
@@ -67,7 +87,7 @@ func GetBuildkitHost(namespace string) (string, error) {
 	var errs []error //nolint:prealloc
 	for _, buildkitHost := range paths {
 		log.L.Debugf("Choosing the buildkit host %q, candidates=%v", buildkitHost, paths)
-		_, err := pingBKDaemon(buildkitHost)
+		err := pingBKDaemon(buildkitHost)
 		if err == nil {
 			log.L.Debugf("Chosen buildkit host %q", buildkitHost)
 			return buildkitHost, nil
@@ -75,47 +95,57 @@ func GetBuildkitHost(namespace string) (string, error) {
 		errs = append(errs, fmt.Errorf("failed to ping to host %s: %w", buildkitHost, err))
 	}
 	allErr := errors.Join(errs...)
-	log.L.WithError(allErr).Error(getHint())
 	return "", fmt.Errorf("no buildkit host is available, tried %d candidates: %w", len(paths), allErr)
 }
 
-func GetWorkerLabels(buildkitHost string) (labels map[string]string, _ error) {
-	buildctlBinary, err := BuildctlBinary()
+// GetWorkerLabels returns the labels of the first worker of buildkitHost.
+func GetWorkerLabels(ctx context.Context, buildkitHost string) (labels map[string]string, _ error) {
+	bkClient, err := NewClient(ctx, buildkitHost)
 	if err != nil {
 		return nil, err
 	}
-	args := BuildctlBaseArgs(buildkitHost)
-	args = append(args, "debug", "workers", "--format", "{{json .}}")
-	buildctlCheckCmd := exec.Command(buildctlBinary, args...)
-	buildctlCheckCmd.Env = os.Environ()
-	out, err := buildctlCheckCmd.Output()
+	defer bkClient.Close()
+
+	workers, err := bkClient.ListWorkers(ctx)
 	if err != nil {
 		return nil, err
 	}
-	var workers []json.RawMessage
-	if err := json.Unmarshal(out, &workers); err != nil {
-		return nil, err
-	}
 	if len(workers) == 0 {
 		return nil, errors.New("no worker available")
 	}
-	metadata := map[string]json.RawMessage{}
-	if err := json.Unmarshal(workers[0], &metadata); err != nil {
-		return nil, err
-	}
-	labelsRaw, ok := metadata["labels"]
-	if !ok {
+	if workers[0].Labels == nil {
 		return nil, errors.New("worker doesn't have labels")
 	}
-	labels = map[string]string{}
-	if err := json.Unmarshal(labelsRaw, &labels); err != nil {
+	return workers[0].Labels, nil
+}
+
+// GetWorkerPlatforms returns the platforms supported by the workers of buildkitHost, formatted and deduplicated.
+func GetWorkerPlatforms(ctx context.Context, buildkitHost string) ([]string, error) {
+	bkClient, err := NewClient(ctx, buildkitHost)
+	if err != nil {
+		return nil, err
+	}
+	defer bkClient.Close()
+
+	workers, err := bkClient.ListWorkers(ctx)
+	if err != nil {
 		return nil, err
 	}
-	return labels, nil
+
+	var res []string
+	for _, w := range workers {
+		for _, p := range w.Platforms {
+			if formatted := platforms.Format(p); !slices.Contains(res, formatted) {
+				res = append(res, formatted)
+			}
+		}
+	}
+
+	return res, nil
 }
 
 func getHint() string {
-	hint := "`buildctl` needs to be installed and `buildkitd` needs to be running, see https://github.com/moby/buildkit"
+	hint := "`buildkitd` needs to be running, see https://github.com/moby/buildkit"
 	if rootlessutil.IsRootless() {
 		hint += " , and `containerd-rootless-setuptool.sh install-buildkit` for OCI worker or `containerd-rootless-setuptool.sh install-buildkit-containerd` for containerd worker"
 	}
@@ -123,32 +153,30 @@ func getHint() string {
 }
 
 func PingBKDaemon(buildkitHost string) error {
-	if out, err := pingBKDaemon(buildkitHost); err != nil {
-		if out != "" {
-			log.L.Error(out)
-		}
+	if err := pingBKDaemon(buildkitHost); err != nil {
 		return fmt.Errorf(getHint()+": %w", err)
 	}
 	return nil
 }
 
-func pingBKDaemon(buildkitHost string) (output string, _ error) {
+func pingBKDaemon(buildkitHost string) error {
 	supportedOses := []string{"linux", "windows"}
 	if !slices.Contains(supportedOses, runtime.GOOS) {
-		return "", fmt.Errorf("only %s are supported", strings.Join(supportedOses, ", "))
+		return fmt.Errorf("only %s are supported", strings.Join(supportedOses, ", "))
 	}
-	buildctlBinary, err := BuildctlBinary()
+
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+
+	bkClient, err := NewClient(ctx, buildkitHost)
 	if err != nil {
-		return "", err
+		return err
 	}
-	args := BuildctlBaseArgs(buildkitHost)
-	args = append(args, "debug", "workers")
-	buildctlCheckCmd := exec.Command(buildctlBinary, args...)
-	buildctlCheckCmd.Env = os.Environ()
-	if out, err := buildctlCheckCmd.CombinedOutput(); err != nil {
-		return string(out), err
-	}
-	return "", nil
+	defer bkClient.Close()
+
+	_, err = bkClient.ListWorkers(ctx)
+
+	return err
 }
 
 // WriteTempDockerfile is from https://github.com/docker/cli/blob/v20.10.9/cli/command/image/build/context.go#L118
@@ -0,0 +1,334 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package buildkit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/containerd/platforms"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+	"go.farcloser.world/lepton/leptonic/identifiers"
+	"go.farcloser.world/lepton/leptonic/store"
+)
+
+const (
+	buildersDirBasename = "builders"
+	instancesGroup      = "instances"
+	currentKey          = "current"
+
+	// DefaultInstanceName designates the implicit builder, found by probing the well-known BuildKit sockets
+	// of the namespace.
+	// It cannot be created nor removed.
+	DefaultInstanceName = "default"
+)
+
+// ErrInstanceStore will wrap all errors here
+var ErrInstanceStore = errors.New("builder-store error")
+
+// Instance is a named builder definition.
+type Instance struct {
+	Name string `json:"name"`
+	// Host is the BuildKit address
+	Host string `json:"host"`
+	// Platforms are used by default when building with this instance and no platform is specified
+	Platforms []string `json:"platforms,omitempty"`
+	// WorkerLabels are constraints that the labels of the BuildKit worker must satisfy
+	WorkerLabels map[string]string `json:"workerLabels,omitempty"`
+	CreatedAt    time.Time         `json:"createdAt"`
+}
+
+// Validate checks that the instance definition is well-formed.
+func (inst *Instance) Validate() error {
+	if err := identifiers.Validate(inst.Name); err != nil {
+		return errors.Join(errs.ErrInvalidArgument, err)
+	}
+
+	if inst.Name == DefaultInstanceName {
+		return fmt.Errorf("%w: builder name %q is reserved", errs.ErrInvalidArgument, DefaultInstanceName)
+	}
+
+	if inst.Host == "" {
+		return fmt.Errorf("%w: builder %q needs a BuildKit address", errs.ErrInvalidArgument, inst.Name)
+	}
+
+	for _, p := range inst.Platforms {
+		if _, err := platforms.Parse(p); err != nil {
+			return errors.Join(errs.ErrInvalidArgument, err)
+		}
+	}
+
+	return nil
+}
+
+// CheckWorkerLabels verifies that the worker of the instance satisfies its label constraints.
+func (inst *Instance) CheckWorkerLabels(ctx context.Context) error {
+	if len(inst.WorkerLabels) == 0 {
+		return nil
+	}
+
+	labels, err := GetWorkerLabels(ctx, inst.Host)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range inst.WorkerLabels {
+		if actual, ok := labels[k]; !ok || actual != v {
+			return fmt.Errorf("%w: worker of builder %q does not satisfy label constraint %s=%s (got %q)",
+				errs.ErrFailedPrecondition, inst.Name, k, v, actual)
+		}
+	}
+
+	return nil
+}
+
+// InstanceStore persists builder definitions for a namespace, along with the one currently in use.
+type InstanceStore interface {
+	// Create saves a new builder definition. It errors if a builder with the same name already exists.
+	Create(inst *Instance) error
+	// Get returns the definition of the builder `name`.
+	Get(name string) (*Instance, error)
+	// List returns all builder definitions, sorted by name.
+	List() ([]*Instance, error)
+	// Remove deletes the builder `name`. If it was in use, the default builder is used again.
+	Remove(name string) error
+	// Use selects the builder `name` as the default. DefaultInstanceName resets to the implicit builder.
+	Use(name string) error
+	// Current returns the name of the builder in use.
+	Current() (string, error)
+}
+
+// NewInstanceStore returns the InstanceStore of a namespace.
+func NewInstanceStore(dataStore, namespace string) (InstanceStore, error) {
+	if namespace == "" {
+		return nil, errors.Join(ErrInstanceStore, errs.ErrInvalidArgument)
+	}
+
+	st, err := store.New(filepath.Join(dataStore, buildersDirBasename, namespace), false, 0, 0)
+	if err != nil {
+		return nil, errors.Join(ErrInstanceStore, err)
+	}
+
+	return &instanceStore{
+		safeStore: st,
+	}, nil
+}
+
+type instanceStore struct {
+	safeStore store.Store
+}
+
+func (x *instanceStore) Create(inst *Instance) (err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(ErrInstanceStore, err)
+		}
+	}()
+
+	if err = inst.Validate(); err != nil {
+		return err
+	}
+
+	if inst.CreatedAt.IsZero() {
+		inst.CreatedAt = time.Now().UTC()
+	}
+
+	data, err := json.Marshal(inst)
+	if err != nil {
+		return err
+	}
+
+	return x.safeStore.WithLock(func() error {
+		exists, err := x.safeStore.Exists(instancesGroup, inst.Name)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			return fmt.Errorf("%w: builder %q already exists", errs.ErrInvalidArgument, inst.Name)
+		}
+
+		return x.safeStore.Set(data, instancesGroup, inst.Name)
+	})
+}
+
+func (x *instanceStore) Get(name string) (inst *Instance, err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(ErrInstanceStore, err)
+		}
+	}()
+
+	if err = identifiers.Validate(name); err != nil {
+		return nil, errors.Join(errs.ErrInvalidArgument, err)
+	}
+
+	err = x.safeStore.WithLock(func() error {
+		inst, err = x.get(name)
+		return err
+	})
+
+	return inst, err
+}
+
+func (x *instanceStore) List() (list []*Instance, err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(ErrInstanceStore, err)
+		}
+	}()
+
+	err = x.safeStore.WithLock(func() error {
+		names, err := x.safeStore.List(instancesGroup)
+		if err != nil {
+			if errors.Is(err, errs.ErrNotFound) {
+				return nil
+			}
+
+			return err
+		}
+
+		for _, name := range names {
+			inst, err := x.get(name)
+			if err != nil {
+				return err
+			}
+
+			list = append(list, inst)
+		}
+
+		return nil
+	})
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list, err
+}
+
+func (x *instanceStore) Remove(name string) (err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(ErrInstanceStore, err)
+		}
+	}()
+
+	if name == DefaultInstanceName {
+		return fmt.Errorf("%w: the default builder cannot be removed", errs.ErrInvalidArgument)
+	}
+
+	if err = identifiers.Validate(name); err != nil {
+		return errors.Join(errs.ErrInvalidArgument, err)
+	}
+
+	return x.safeStore.WithLock(func() error {
+		if _, err := x.get(name); err != nil {
+			return err
+		}
+
+		current, err := x.current()
+		if err != nil {
+			return err
+		}
+
+		if current == name {
+			if err = x.safeStore.Delete(currentKey); err != nil {
+				return err
+			}
+		}
+
+		return x.safeStore.Delete(instancesGroup, name)
+	})
+}
+
+func (x *instanceStore) Use(name string) (err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(ErrInstanceStore, err)
+		}
+	}()
+
+	return x.safeStore.WithLock(func() error {
+		if name == DefaultInstanceName {
+			exists, err := x.safeStore.Exists(currentKey)
+			if err != nil || !exists {
+				return err
+			}
+
+			return x.safeStore.Delete(currentKey)
+		}
+
+		if _, err := x.get(name); err != nil {
+			return err
+		}
+
+		return x.safeStore.Set([]byte(name), currentKey)
+	})
+}
+
+func (x *instanceStore) Current() (name string, err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(ErrInstanceStore, err)
+		}
+	}()
+
+	err = x.safeStore.WithLock(func() error {
+		name, err = x.current()
+		return err
+	})
+
+	return name, err
+}
+
+func (x *instanceStore) current() (string, error) {
+	data, err := x.safeStore.Get(currentKey)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return DefaultInstanceName, nil
+		}
+
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func (x *instanceStore) get(name string) (*Instance, error) {
+	data, err := x.safeStore.Get(instancesGroup, name)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return nil, fmt.Errorf("%w: no such builder %q", errs.ErrNotFound, name)
+		}
+
+		return nil, err
+	}
+
+	inst := &Instance{}
+	if err = json.Unmarshal(data, inst); err != nil {
+		return nil, err
+	}
+
+	return inst, nil
+}
@@ -0,0 +1,102 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package buildkit_test
+
+import (
+	"errors"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/lepton/leptonic/buildkit"
+	"go.farcloser.world/lepton/leptonic/errs"
+)
+
+func TestInstanceStore(t *testing.T) {
+	st, err := buildkit.NewInstanceStore(t.TempDir(), "test")
+	assert.NilError(t, err)
+
+	current, err := st.Current()
+	assert.NilError(t, err)
+	assert.Equal(t, current, buildkit.DefaultInstanceName)
+
+	list, err := st.List()
+	assert.NilError(t, err)
+	assert.Equal(t, len(list), 0)
+
+	err = st.Create(&buildkit.Instance{Name: buildkit.DefaultInstanceName, Host: "unix:///run/buildkit.sock"})
+	assert.Assert(t, errors.Is(err, errs.ErrInvalidArgument))
+
+	err = st.Create(&buildkit.Instance{Name: "remote"})
+	assert.Assert(t, errors.Is(err, errs.ErrInvalidArgument))
+
+	err = st.Create(&buildkit.Instance{
+		Name:      "remote",
+		Host:      "tcp://10.0.0.1:1234",
+		Platforms: []string{"foo/bar/baz/qux"},
+	})
+	assert.Assert(t, errors.Is(err, errs.ErrInvalidArgument))
+
+	err = st.Create(&buildkit.Instance{
+		Name:         "remote",
+		Host:         "tcp://10.0.0.1:1234",
+		Platforms:    []string{"linux/arm64"},
+		WorkerLabels: map[string]string{"org.mobyproject.buildkit.worker.executor": "containerd"},
+	})
+	assert.NilError(t, err)
+
+	err = st.Create(&buildkit.Instance{Name: "remote", Host: "tcp://10.0.0.2:1234"})
+	assert.Assert(t, errors.Is(err, errs.ErrInvalidArgument))
+
+	inst, err := st.Get("remote")
+	assert.NilError(t, err)
+	assert.Equal(t, inst.Host, "tcp://10.0.0.1:1234")
+	assert.DeepEqual(t, inst.Platforms, []string{"linux/arm64"})
+	assert.Assert(t, !inst.CreatedAt.IsZero())
+
+	_, err = st.Get("missing")
+	assert.Assert(t, errors.Is(err, errs.ErrNotFound))
+
+	err = st.Use("missing")
+	assert.Assert(t, errors.Is(err, errs.ErrNotFound))
+
+	assert.NilError(t, st.Use("remote"))
+	current, err = st.Current()
+	assert.NilError(t, err)
+	assert.Equal(t, current, "remote")
+
+	assert.NilError(t, st.Create(&buildkit.Instance{Name: "local", Host: "unix:///run/buildkit.sock"}))
+	list, err = st.List()
+	assert.NilError(t, err)
+	assert.Equal(t, len(list), 2)
+	assert.Equal(t, list[0].Name, "local")
+
+	err = st.Remove(buildkit.DefaultInstanceName)
+	assert.Assert(t, errors.Is(err, errs.ErrInvalidArgument))
+
+	// Removing the builder in use falls back to the default one
+	assert.NilError(t, st.Remove("remote"))
+	current, err = st.Current()
+	assert.NilError(t, err)
+	assert.Equal(t, current, buildkit.DefaultInstanceName)
+
+	assert.NilError(t, st.Use("local"))
+	assert.NilError(t, st.Use(buildkit.DefaultInstanceName))
+	current, err = st.Current()
+	assert.NilError(t, err)
+	assert.Equal(t, current, buildkit.DefaultInstanceName)
+}
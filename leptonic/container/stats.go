@@ -0,0 +1,75 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package container
+
+// Pressure is the pressure stall information of a resource, over 10, 60 and 300 seconds windows (in percent),
+// along with the total stall time (in microseconds).
+// See https://docs.kernel.org/accounting/psi.html
+type Pressure struct {
+	Avg10  float64
+	Avg60  float64
+	Avg300 float64
+	Total  uint64
+}
+
+// ResourcePressure holds the "some" and "full" pressure of a resource.
+// Full is always nil for CPU on kernels older than 5.13.
+type ResourcePressure struct {
+	Some *Pressure
+	Full *Pressure
+}
+
+// PSI is the pressure stall information of the container cgroup (cgroup v2 only).
+type PSI struct {
+	CPU    ResourcePressure
+	Memory ResourcePressure
+	IO     ResourcePressure
+}
+
+// MemoryBreakdown details the memory usage of the container (in bytes), and the memory events it went through.
+type MemoryBreakdown struct {
+	Anon   uint64
+	File   uint64
+	Kernel uint64
+	Shmem  uint64
+	Swap   uint64
+	// OOMEvents is the number of times the cgroup hit its memory limit and the OOM killer was invoked.
+	// It is not available on cgroup v1, which has no memory.events, and stays 0 there.
+	OOMEvents uint64
+	// OOMKills is the number of processes killed by the OOM killer
+	OOMKills uint64
+}
+
+// NetworkInterface holds the counters of a network interface of the container.
+type NetworkInterface struct {
+	RxBytes   uint64
+	RxPackets uint64
+	RxErrors  uint64
+	RxDropped uint64
+	TxBytes   uint64
+	TxPackets uint64
+	TxErrors  uint64
+	TxDropped uint64
+}
+
+// Stats holds the detailed resources usage of a container, beyond the summary returned by the stats library.
+type Stats struct {
+	// PSI is nil on cgroup v1, or when the kernel does not support pressure stall information
+	PSI      *PSI
+	Memory   MemoryBreakdown
+	Networks map[string]NetworkInterface
+}
@@ -0,0 +1,330 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package container
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	v1 "github.com/containerd/cgroups/v3/cgroup1/stats"
+	v2 "github.com/containerd/cgroups/v3/cgroup2/stats"
+
+	"go.farcloser.world/containers/stats"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+)
+
+// clockTicks is USER_HZ, which is 100 on all supported architectures
+const clockTicks = 100
+
+// DecodeMetrics computes the stats summary and the detailed stats of a container, from its task metrics.
+// Both cgroup v1 and v2 metrics are supported.
+// previous holds the prior reading (used to compute CPU usage) and is updated.
+// pid is the pid of the container task, used to read the network counters of the container network namespace.
+func DecodeMetrics(previous *stats.ContainerStats, metrics any, pid int) (stats.Entry, *Stats, error) {
+	networks, err := readNetDev(fmt.Sprintf("/proc/%d/net/dev", pid))
+	if err != nil {
+		return stats.Entry{}, nil, err
+	}
+
+	var (
+		entry    stats.Entry
+		detailed *Stats
+	)
+
+	switch typed := metrics.(type) {
+	case *v1.Metrics:
+		entry, err = cgroup1Entry(previous, typed, networks)
+		detailed = cgroup1Stats(typed)
+	case *v2.Metrics:
+		entry, err = stats.SetCgroup2StatsFields(previous, typed, pid)
+		detailed = cgroup2Stats(typed)
+	default:
+		return stats.Entry{}, nil, errors.Join(
+			errs.ErrInvalidArgument,
+			fmt.Errorf("unsupported metrics type %T", metrics),
+		)
+	}
+
+	if err != nil {
+		return entry, nil, err
+	}
+
+	detailed.Networks = networks
+
+	return entry, detailed, nil
+}
+
+func cgroup2Stats(metrics *v2.Metrics) *Stats {
+	mem := metrics.GetMemory()
+	res := &Stats{
+		Memory: MemoryBreakdown{
+			Anon:      mem.GetAnon(),
+			File:      mem.GetFile(),
+			Kernel:    mem.GetKernelStack() + mem.GetSlab(),
+			Shmem:     mem.GetShmem(),
+			Swap:      mem.GetSwapUsage(),
+			OOMEvents: metrics.GetMemoryEvents().GetOom(),
+			OOMKills:  metrics.GetMemoryEvents().GetOomKill(),
+		},
+	}
+
+	cpuPSI, memPSI, ioPSI := metrics.GetCPU().GetPSI(), mem.GetPSI(), metrics.GetIo().GetPSI()
+	if cpuPSI != nil || memPSI != nil || ioPSI != nil {
+		res.PSI = &PSI{
+			CPU:    resourcePressure(cpuPSI),
+			Memory: resourcePressure(memPSI),
+			IO:     resourcePressure(ioPSI),
+		}
+	}
+
+	return res
+}
+
+func resourcePressure(psi *v2.PSIStats) ResourcePressure {
+	return ResourcePressure{
+		Some: pressure(psi.GetSome()),
+		Full: pressure(psi.GetFull()),
+	}
+}
+
+func pressure(data *v2.PSIData) *Pressure {
+	if data == nil {
+		return nil
+	}
+
+	return &Pressure{
+		Avg10:  data.GetAvg10(),
+		Avg60:  data.GetAvg60(),
+		Avg300: data.GetAvg300(),
+		Total:  data.GetTotal(),
+	}
+}
+
+func cgroup1Stats(metrics *v1.Metrics) *Stats {
+	mem := metrics.GetMemory()
+
+	// memory.memsw accounts for both memory and swap
+	var swap uint64
+	if memsw, usage := mem.GetSwap().GetUsage(), mem.GetUsage().GetUsage(); memsw > usage {
+		swap = memsw - usage
+	}
+
+	return &Stats{
+		Memory: MemoryBreakdown{
+			Anon:     mem.GetTotalRSS(),
+			File:     mem.GetTotalCache(),
+			Kernel:   mem.GetKernel().GetUsage(),
+			Swap:     swap,
+			OOMKills: metrics.GetMemoryOomControl().GetOomKill(),
+		},
+	}
+}
+
+func cgroup1Entry(
+	previous *stats.ContainerStats,
+	metrics *v1.Metrics,
+	networks map[string]NetworkInterface,
+) (stats.Entry, error) {
+	systemUsage, err := systemCPUUsage()
+	if err != nil {
+		return stats.Entry{}, err
+	}
+
+	// A container is never accounted by both cgroup versions, so the cgroup v2 fields are reused to hold the prior
+	// cgroup v1 reading
+	var (
+		cpuUsage    = metrics.GetCPU().GetUsage()
+		cpuDelta    = float64(cpuUsage.GetTotal()) - float64(previous.Cgroup2CPU)
+		systemDelta = float64(systemUsage) - float64(previous.Cgroup2System)
+		cpuPercent  = 0.0
+	)
+
+	if systemDelta > 0.0 && cpuDelta > 0.0 {
+		cpuPercent = (cpuDelta / systemDelta) * float64(len(cpuUsage.GetPerCPU())) * 100.0
+	}
+
+	previous.Cgroup2CPU = cpuUsage.GetTotal()
+	previous.Cgroup2System = systemUsage
+
+	mem := metrics.GetMemory()
+	memUsage := float64(mem.GetUsage().GetUsage())
+	if inactive := float64(mem.GetTotalInactiveFile()); inactive < memUsage {
+		memUsage -= inactive
+	}
+
+	// An unlimited cgroup v1 reports a page-aligned max int64 - use the host memory instead
+	memLimit := float64(mem.GetUsage().GetLimit())
+	if hostMem, err := hostMemory(); err == nil && memLimit > hostMem {
+		memLimit = hostMem
+	}
+
+	memPercent := 0.0
+	if memLimit != 0 {
+		memPercent = memUsage / memLimit * 100.0
+	}
+
+	var blkRead, blkWrite uint64
+	for _, entry := range metrics.GetBlkio().GetIoServiceBytesRecursive() {
+		switch strings.ToLower(entry.GetOp()) {
+		case "read":
+			blkRead += entry.GetValue()
+		case "write":
+			blkWrite += entry.GetValue()
+		}
+	}
+
+	var netRx, netTx uint64
+	for name, netif := range networks {
+		if name == "lo" {
+			continue
+		}
+		netRx += netif.RxBytes
+		netTx += netif.TxBytes
+	}
+
+	return stats.Entry{
+		CPUPercentage:    cpuPercent,
+		Memory:           memUsage,
+		MemoryLimit:      memLimit,
+		MemoryPercentage: memPercent,
+		NetworkRx:        float64(netRx),
+		NetworkTx:        float64(netTx),
+		BlockRead:        float64(blkRead),
+		BlockWrite:       float64(blkWrite),
+		PidsCurrent:      metrics.GetPids().GetCurrent(),
+	}, nil
+}
+
+// systemCPUUsage returns the host cumulative CPU time in nanoseconds, from the first line of /proc/stat.
+func systemCPUUsage() (uint64, error) {
+	file, err := os.Open("/proc/stat")
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] != "cpu" {
+			continue
+		}
+
+		if len(fields) < 8 {
+			return 0, fmt.Errorf("invalid number of cpu fields in /proc/stat: %q", scanner.Text())
+		}
+
+		var totalClockTicks uint64
+		// user, nice, system, idle, iowait, irq, softirq
+		for _, field := range fields[1:8] {
+			value, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("unable to parse /proc/stat: %w", err)
+			}
+			totalClockTicks += value
+		}
+
+		return totalClockTicks * (1e9 / clockTicks), nil
+	}
+
+	if err = scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, errors.New("no cpu line found in /proc/stat")
+}
+
+// hostMemory returns the host total memory in bytes, from /proc/meminfo.
+func hostMemory() (float64, error) {
+	file, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			memKb, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0, err
+			}
+
+			return float64(memKb * 1024), nil
+		}
+	}
+
+	return 0, errors.New("no MemTotal found in /proc/meminfo")
+}
+
+// readNetDev reads per-interface counters from a /proc/PID/net/dev file, which reflects the network namespace of PID.
+func readNetDev(path string) (map[string]NetworkInterface, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseNetDev(file)
+}
+
+func parseNetDev(reader io.Reader) (map[string]NetworkInterface, error) {
+	res := map[string]NetworkInterface{}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		name, counters, found := strings.Cut(scanner.Text(), ":")
+		// The two header lines do not have a colon
+		if !found {
+			continue
+		}
+
+		fields := strings.Fields(counters)
+		if len(fields) < 16 {
+			return nil, fmt.Errorf("invalid net/dev line: %q", scanner.Text())
+		}
+
+		var err error
+		values := make([]uint64, 16)
+		for i := range values {
+			if values[i], err = strconv.ParseUint(fields[i], 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid net/dev line: %q: %w", scanner.Text(), err)
+			}
+		}
+
+		// Receive: bytes packets errs drop fifo frame compressed multicast
+		// Transmit: bytes packets errs drop fifo colls carrier compressed
+		res[strings.TrimSpace(name)] = NetworkInterface{
+			RxBytes:   values[0],
+			RxPackets: values[1],
+			RxErrors:  values[2],
+			RxDropped: values[3],
+			TxBytes:   values[8],
+			TxPackets: values[9],
+			TxErrors:  values[10],
+			TxDropped: values[11],
+		}
+	}
+
+	return res, scanner.Err()
+}
@@ -0,0 +1,53 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package container
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseNetDev(t *testing.T) {
+	t.Parallel()
+
+	//nolint:lll
+	netDev := `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo:     120       2    0    0    0     0          0         0      120       2    0    0    0     0       0          0
+  eth0:  123456     100    1    2    0     0          0         0    65432      80    3    4    0     0       0          0
+`
+
+	res, err := parseNetDev(strings.NewReader(netDev))
+	assert.NilError(t, err)
+	assert.Equal(t, len(res), 2)
+	assert.DeepEqual(t, res["eth0"], NetworkInterface{
+		RxBytes:   123456,
+		RxPackets: 100,
+		RxErrors:  1,
+		RxDropped: 2,
+		TxBytes:   65432,
+		TxPackets: 80,
+		TxErrors:  3,
+		TxDropped: 4,
+	})
+	assert.Equal(t, res["lo"].RxBytes, uint64(120))
+
+	_, err = parseNetDev(strings.NewReader("eth0: 1 2 3\n"))
+	assert.ErrorContains(t, err, "invalid net/dev line")
+}
@@ -19,7 +19,6 @@ package emulation
 import (
 	"errors"
 	"fmt"
-	"os"
 	"runtime"
 
 	"github.com/containerd/platforms"
@@ -52,24 +51,19 @@ func canExecProbably(p specs.Platform) (bool, error) {
 		return false, nil
 	}
 
-	qemuArch, err := ociArch2qemuArch(p.Architecture)
-	if err != nil {
+	if _, err := ociArch2qemuArch(p.Architecture); err != nil {
 		return false, errors.Join(errs.ErrInvalidArgument, err)
 	}
 
-	candidates := []string{
-		"/proc/sys/fs/binfmt_misc/qemu-" + qemuArch,
-		"/proc/sys/fs/binfmt_misc/buildkit-qemu-" + qemuArch,
-	}
-
-	// Rosetta 2 for Linux on ARM Mac
-	// https://developer.apple.com/documentation/virtualization/running_intel_binaries_in_linux_vms_with_rosetta
-	if runtime.GOARCH == "arm64" && p.Architecture == "amd64" {
-		candidates = append(candidates, "/proc/sys/fs/binfmt_misc/rosetta")
+	registered, err := Handlers()
+	if err != nil {
+		// Without binfmt_misc, nothing can be emulated
+		return false, nil //nolint:nilerr
 	}
 
-	for _, cand := range candidates {
-		if _, err = os.Stat(cand); err == nil {
+	matcher := platforms.OnlyStrict(p)
+	for _, handler := range registered {
+		if handler.Enabled && enables(handler, matcher) {
 			return true, nil
 		}
 	}
@@ -0,0 +1,343 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package emulation
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/containerd/platforms"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+)
+
+// BinfmtMiscPath is where the kernel exposes binfmt_misc handlers.
+const BinfmtMiscPath = "/proc/sys/fs/binfmt_misc"
+
+const (
+	binfmtRegister = "register"
+	binfmtStatus   = "status"
+	// Handlers are registered with the "fix binary" flag, so that the interpreter is opened at registration time,
+	// and does not need to be present inside containers.
+	binfmtFlags = "F"
+	// Writing this to a handler file unregisters it.
+	binfmtUnregister = "-1"
+	qemuPrefix       = "qemu-"
+	rosettaHandler   = "rosetta"
+)
+
+var (
+	ErrBinfmtMiscUnavailable = errors.New("binfmt_misc is not available (is it mounted on " + BinfmtMiscPath + "?)")
+	ErrNativePlatform        = errors.New("platform is native, and does not need emulation")
+	ErrInterpreterNotFound   = errors.New("qemu interpreter not found")
+	ErrNoHandler             = errors.New("no emulation handler is registered for platform")
+)
+
+// Handler is a binfmt_misc handler.
+type Handler struct {
+	Name        string
+	Enabled     bool
+	Interpreter string
+	Flags       string
+	// Platforms lists the OCI platforms (as in "linux/arm64") this handler enables.
+	Platforms []string
+}
+
+// qemuMagic holds the ELF header magic and mask matching the binaries of a qemu architecture.
+// These are the values of qemu scripts/qemu-binfmt-conf.sh.
+type qemuMagic struct {
+	magic string
+	mask  string
+}
+
+var qemuMagics = map[string]qemuMagic{
+	"x86_64": {
+		magic: `\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\x3e\x00`,
+		mask:  `\xff\xff\xff\xff\xff\xfe\xfe\x00\xff\xff\xff\xff\xff\xff\xff\xff\xfe\xff\xff\xff`,
+	},
+	"i386": {
+		magic: `\x7fELF\x01\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\x03\x00`,
+		mask:  `\xff\xff\xff\xff\xff\xfe\xfe\x00\xff\xff\xff\xff\xff\xff\xff\xff\xfe\xff\xff\xff`,
+	},
+	"aarch64": {
+		magic: `\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\xb7\x00`,
+		mask:  `\xff\xff\xff\xff\xff\xff\xff\x00\xff\xff\xff\xff\xff\xff\xff\xff\xfe\xff\xff\xff`,
+	},
+	"arm": {
+		magic: `\x7fELF\x01\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\x28\x00`,
+		mask:  `\xff\xff\xff\xff\xff\xff\xff\x00\xff\xff\xff\xff\xff\xff\xff\xff\xfe\xff\xff\xff`,
+	},
+	"ppc64le": {
+		magic: `\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\x15\x00`,
+		mask:  `\xff\xff\xff\xff\xff\xff\xff\xfc\xff\xff\xff\xff\xff\xff\xff\xff\xfe\xff\xff\x00`,
+	},
+	"s390x": {
+		magic: `\x7fELF\x02\x02\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\x16`,
+		mask:  `\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xfe\xff\xff`,
+	},
+	"riscv64": {
+		magic: `\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\xf3\x00`,
+		mask:  `\xff\xff\xff\xff\xff\xff\xff\x00\xff\xff\xff\xff\xff\xff\xff\xff\xfe\xff\xff\xff`,
+	},
+	"mips64": {
+		magic: `\x7fELF\x02\x02\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\x08`,
+		mask:  `\xff\xff\xff\xff\xff\xff\xff\x00\xff\xff\xff\xff\xff\xff\xff\xff\xff\xfe\xff\xff`,
+	},
+	"mips64el": {
+		magic: `\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\x08\x00`,
+		mask:  `\xff\xff\xff\xff\xff\xff\xff\x00\xff\xff\xff\xff\xff\xff\xff\xff\xfe\xff\xff\xff`,
+	},
+	"loongarch64": {
+		magic: `\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00\x02\x01`,
+		mask:  `\xff\xff\xff\xff\xff\xff\xff\x00\xff\xff\xff\xff\xff\xff\xff\xff\xfe\xff\xff\xff`,
+	},
+}
+
+// qemuPlatforms maps qemu architectures to the OCI platforms their emulator can run.
+var qemuPlatforms = map[string][]string{
+	"x86_64":      {"linux/amd64"},
+	"i386":        {"linux/386"},
+	"aarch64":     {"linux/arm64"},
+	"arm":         {"linux/arm/v5", "linux/arm/v6", "linux/arm/v7"},
+	"ppc64le":     {"linux/ppc64le"},
+	"s390x":       {"linux/s390x"},
+	"riscv64":     {"linux/riscv64"},
+	"mips64":      {"linux/mips64"},
+	"mips64el":    {"linux/mips64le"},
+	"loongarch64": {"linux/loong64"},
+}
+
+// Handlers returns the binfmt_misc handlers registered on the host, sorted by name.
+func Handlers() ([]*Handler, error) {
+	return handlers(BinfmtMiscPath)
+}
+
+// Install registers a handler for the platform, with the static qemu binary found in qemuDir.
+// The handler is named "qemu-<arch>", and replaces any existing handler by that name.
+func Install(platform specs.Platform, qemuDir string) (*Handler, error) {
+	return install(BinfmtMiscPath, platform, qemuDir)
+}
+
+// Uninstall unregisters all the handlers enabling the platform, and returns their names.
+func Uninstall(platform specs.Platform) ([]string, error) {
+	return uninstall(BinfmtMiscPath, platform)
+}
+
+func handlers(root string) ([]*Handler, error) {
+	if runtime.GOOS != "linux" {
+		return []*Handler{}, nil
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, errors.Join(ErrBinfmtMiscUnavailable, err)
+	}
+
+	res := []*Handler{}
+	for _, entry := range entries {
+		if entry.IsDir() || entry.Name() == binfmtRegister || entry.Name() == binfmtStatus {
+			continue
+		}
+
+		handler, err := readHandler(filepath.Join(root, entry.Name()))
+		if err != nil {
+			// Handlers may be unregistered concurrently
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		res = append(res, handler)
+	}
+
+	slices.SortFunc(res, func(a, b *Handler) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return res, nil
+}
+
+func readHandler(path string) (*Handler, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := &Handler{
+		Name: filepath.Base(path),
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "enabled":
+			handler.Enabled = true
+		case strings.HasPrefix(line, "interpreter "):
+			handler.Interpreter = strings.TrimPrefix(line, "interpreter ")
+		case strings.HasPrefix(line, "flags:"):
+			handler.Flags = strings.TrimSpace(strings.TrimPrefix(line, "flags:"))
+		}
+	}
+
+	handler.Platforms = handlerPlatforms(handler)
+
+	return handler, scanner.Err()
+}
+
+// handlerPlatforms guesses the platforms enabled by a handler, from its name or the name of its interpreter.
+func handlerPlatforms(handler *Handler) []string {
+	if handler.Name == rosettaHandler {
+		if runtime.GOARCH == "arm64" {
+			return []string{"linux/amd64"}
+		}
+
+		return []string{}
+	}
+
+	for _, candidate := range []string{handler.Name, filepath.Base(handler.Interpreter)} {
+		candidate = strings.TrimSuffix(candidate, "-static")
+		if index := strings.Index(candidate, qemuPrefix); index >= 0 {
+			if res, ok := qemuPlatforms[candidate[index+len(qemuPrefix):]]; ok {
+				return res
+			}
+		}
+	}
+
+	return []string{}
+}
+
+func install(root string, platform specs.Platform, qemuDir string) (*Handler, error) {
+	if platforms.Default().Match(platform) {
+		return nil, fmt.Errorf("%w: %s", ErrNativePlatform, platforms.Format(platform))
+	}
+
+	qemuArch, err := ociArch2qemuArch(platform.Architecture)
+	if err != nil {
+		return nil, errors.Join(errs.ErrInvalidArgument, err)
+	}
+
+	magic, ok := qemuMagics[qemuArch]
+	if !ok {
+		return nil, fmt.Errorf("%w: %w: %q", errs.ErrInvalidArgument, ErrUnknownOCIArchitecture, platform.Architecture)
+	}
+
+	interpreter := ""
+	for _, candidate := range []string{qemuPrefix + qemuArch + "-static", qemuPrefix + qemuArch} {
+		if _, err = os.Stat(filepath.Join(qemuDir, candidate)); err == nil {
+			interpreter = filepath.Join(qemuDir, candidate)
+
+			break
+		}
+	}
+
+	if interpreter == "" {
+		return nil, fmt.Errorf("%w: %w: neither %s-static nor %s in %s", errs.ErrNotFound, ErrInterpreterNotFound,
+			qemuPrefix+qemuArch, qemuPrefix+qemuArch, qemuDir)
+	}
+
+	name := qemuPrefix + qemuArch
+	if _, err = os.Stat(filepath.Join(root, binfmtRegister)); err != nil {
+		return nil, errors.Join(ErrBinfmtMiscUnavailable, err)
+	}
+
+	// The kernel refuses to register a handler under an existing name
+	if err = unregister(root, name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	registration := fmt.Sprintf(":%s:M::%s:%s:%s:%s", name, magic.magic, magic.mask, interpreter, binfmtFlags)
+	if err = writeBinfmt(filepath.Join(root, binfmtRegister), registration); err != nil {
+		return nil, fmt.Errorf("%w: failed to register %s: %w", errs.ErrSystemFailure, name, err)
+	}
+
+	handler := &Handler{
+		Name:        name,
+		Enabled:     true,
+		Interpreter: interpreter,
+		Flags:       binfmtFlags,
+	}
+	handler.Platforms = handlerPlatforms(handler)
+
+	return handler, nil
+}
+
+func uninstall(root string, platform specs.Platform) ([]string, error) {
+	registered, err := handlers(root)
+	if err != nil {
+		return nil, err
+	}
+
+	matcher := platforms.OnlyStrict(platform)
+	removed := []string{}
+	for _, handler := range registered {
+		if !enables(handler, matcher) {
+			continue
+		}
+
+		if err = unregister(root, handler.Name); err != nil {
+			return removed, fmt.Errorf("%w: failed to unregister %s: %w", errs.ErrSystemFailure, handler.Name, err)
+		}
+
+		removed = append(removed, handler.Name)
+	}
+
+	if len(removed) == 0 {
+		return nil, fmt.Errorf("%w: %w %s", errs.ErrNotFound, ErrNoHandler, platforms.Format(platform))
+	}
+
+	return removed, nil
+}
+
+// unregister removes a handler. It fails with os.ErrNotExist if the handler is not registered.
+func unregister(root, name string) error {
+	return writeBinfmt(filepath.Join(root, name), binfmtUnregister)
+}
+
+// writeBinfmt writes to an existing binfmt_misc file.
+// Files cannot be created there: opening a missing handler with O_CREATE fails with EACCES, instead of ENOENT.
+func writeBinfmt(path, data string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.WriteString(data)
+
+	return errors.Join(err, file.Close())
+}
+
+func enables(handler *Handler, matcher platforms.Matcher) bool {
+	for _, p := range handler.Platforms {
+		parsed, err := platforms.Parse(p)
+		if err == nil && matcher.Match(parsed) {
+			return true
+		}
+	}
+
+	return false
+}
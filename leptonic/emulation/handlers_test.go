@@ -0,0 +1,149 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package emulation
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/containerd/platforms"
+	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+)
+
+func writeHandler(t *testing.T, root, name, content string) {
+	t.Helper()
+
+	assert.NilError(t, os.WriteFile(filepath.Join(root, name), []byte(content), 0o600))
+}
+
+func TestHandlers(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("binfmt_misc is linux only")
+	}
+
+	t.Parallel()
+
+	root := t.TempDir()
+	writeHandler(t, root, binfmtRegister, "")
+	writeHandler(t, root, binfmtStatus, "enabled\n")
+	writeHandler(t, root, "qemu-aarch64",
+		"enabled\ninterpreter /usr/bin/qemu-aarch64-static\nflags: F\noffset 0\nmagic 7f454c46\nmask ffffffff\n")
+	writeHandler(t, root, "buildkit-qemu-arm", "disabled\ninterpreter /usr/bin/buildkit-qemu-arm\nflags: OCF\n")
+	writeHandler(t, root, "s390x-handler", "enabled\ninterpreter /opt/qemu/qemu-s390x\nflags: \n")
+	writeHandler(t, root, "python3", "enabled\ninterpreter /usr/bin/python3\nflags: \nextension .py\n")
+
+	registered, err := handlers(root)
+	assert.NilError(t, err)
+	assert.Equal(t, len(registered), 4)
+
+	assert.DeepEqual(t, *registered[0], Handler{
+		Name:        "buildkit-qemu-arm",
+		Interpreter: "/usr/bin/buildkit-qemu-arm",
+		Flags:       "OCF",
+		Platforms:   []string{"linux/arm/v5", "linux/arm/v6", "linux/arm/v7"},
+	})
+	assert.DeepEqual(t, registered[1].Platforms, []string{})
+	assert.DeepEqual(t, *registered[2], Handler{
+		Name:        "qemu-aarch64",
+		Enabled:     true,
+		Interpreter: "/usr/bin/qemu-aarch64-static",
+		Flags:       "F",
+		Platforms:   []string{"linux/arm64"},
+	})
+	assert.DeepEqual(t, registered[3].Platforms, []string{"linux/s390x"})
+
+	_, err = uninstall(root, platforms.MustParse("linux/riscv64"))
+	assert.ErrorIs(t, err, ErrNoHandler)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+
+	// linux/arm64 must not match the 32 bits arm handler
+	removed, err := uninstall(root, platforms.MustParse("linux/arm64"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, removed, []string{"qemu-aarch64"})
+
+	removed, err = uninstall(root, platforms.MustParse("linux/arm/v7"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, removed, []string{"buildkit-qemu-arm"})
+}
+
+func TestInstallErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := install(t.TempDir(), platforms.DefaultSpec(), t.TempDir())
+	assert.ErrorIs(t, err, ErrNativePlatform)
+
+	foreign := platforms.MustParse("linux/s390x")
+	if runtime.GOARCH == "s390x" {
+		foreign = platforms.MustParse("linux/riscv64")
+	}
+
+	_, err = install(t.TempDir(), foreign, t.TempDir())
+	assert.ErrorIs(t, err, ErrInterpreterNotFound)
+
+	_, err = install(t.TempDir(), platforms.MustParse("linux/sparc"), t.TempDir())
+	assert.ErrorIs(t, err, ErrUnknownOCIArchitecture)
+
+	qemuDir := t.TempDir()
+	writeHandler(t, qemuDir, "qemu-s390x-static", "")
+	writeHandler(t, qemuDir, "qemu-riscv64", "")
+
+	_, err = install(t.TempDir(), foreign, qemuDir)
+	assert.ErrorIs(t, err, ErrBinfmtMiscUnavailable)
+}
+
+func TestInstall(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("binfmt_misc is linux only")
+	}
+
+	t.Parallel()
+
+	foreign, qemuArch := platforms.MustParse("linux/s390x"), "s390x"
+	if runtime.GOARCH == "s390x" {
+		foreign, qemuArch = platforms.MustParse("linux/riscv64"), "riscv64"
+	}
+
+	qemuDir := t.TempDir()
+	writeHandler(t, qemuDir, "qemu-"+qemuArch+"-static", "")
+
+	root := t.TempDir()
+	writeHandler(t, root, binfmtRegister, "")
+
+	handler, err := install(root, foreign, qemuDir)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *handler, Handler{
+		Name:        "qemu-" + qemuArch,
+		Enabled:     true,
+		Interpreter: filepath.Join(qemuDir, "qemu-"+qemuArch+"-static"),
+		Flags:       "F",
+		Platforms:   []string{platforms.Format(foreign)},
+	})
+
+	registration, err := os.ReadFile(filepath.Join(root, binfmtRegister))
+	assert.NilError(t, err)
+	assert.Equal(t, string(registration), fmt.Sprintf(":qemu-%s:M::%s:%s:%s:F",
+		qemuArch, qemuMagics[qemuArch].magic, qemuMagics[qemuArch].mask, handler.Interpreter))
+
+	// Unregistering the missing handler must not have created it, as binfmt_misc would refuse that
+	_, err = os.Stat(filepath.Join(root, handler.Name))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
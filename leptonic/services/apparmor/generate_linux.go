@@ -0,0 +1,275 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package apparmor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/containerd/containerd/v2/core/containers"
+	"github.com/containerd/containerd/v2/pkg/oci"
+	"github.com/containerd/log"
+
+	"go.farcloser.world/containers/security/apparmor"
+	"go.farcloser.world/containers/specs"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+	"go.farcloser.world/lepton/pkg/labels"
+	"go.farcloser.world/lepton/pkg/version"
+)
+
+// Generated is the security-opt value requesting a profile generated from the container configuration.
+const Generated = "generated"
+
+const (
+	networkNone       = "none"
+	capSysAdmin       = "CAP_SYS_ADMIN"
+	apparmorParserBin = "apparmor_parser"
+)
+
+// Mounts under these destinations are covered by the generic rules of the template.
+var systemMountPrefixes = []string{"/proc", "/sys", "/dev"}
+
+const generatedTemplate = `#include <tunables/global>
+
+profile {{.Name}} flags=(attach_disconnected,mediate_deleted) {
+  #include <abstractions/base>
+
+  network{{if .NetworkNone}} unix{{end}},
+{{- range .Capabilities}}
+  capability {{.}},
+{{- end}}
+{{if .ReadonlyRootfs}}
+  # The root filesystem is read-only: only the writable mounts can be written to.
+  /** rmlkix,
+  /dev/** rwlk,
+  @{PROC}/** rw,
+  /sys/** rw,
+{{- range .Writable}}
+  {{.}} rwlk,
+{{- end}}
+{{- else}}
+  file,
+{{- end}}
+{{- range .Readonly}}
+  deny {{.}} wl,
+{{- end}}
+
+  {{if .Mount}}mount,
+  remount,{{else}}deny mount,{{end}}
+  umount,
+
+  # Host (privileged) processes may send signals to container processes.
+  signal (receive) peer=unconfined,
+  # Container processes may send signals amongst themselves.
+  signal (send,receive) peer={{.Name}},
+
+  deny @{PROC}/* w,   # deny write for all files directly in /proc (not in a subdir)
+  # deny write to files not in /proc/<number>/** or /proc/sys/**
+  deny @{PROC}/{[^1-9],[^1-9][^0-9],[^1-9s][^0-9y][^0-9s],[^1-9][^0-9][^0-9][^0-9/]*}/** w,
+  deny @{PROC}/sys/[^k]** w,  # deny /proc/sys except /proc/sys/k* (effectively /proc/sys/kernel)
+  deny @{PROC}/sys/kernel/{?,??,[^s][^h][^m]**} w,  # deny everything except shm* in /proc/sys/kernel/
+  deny @{PROC}/sysrq-trigger rwklx,
+  deny @{PROC}/kcore rwklx,
+
+  deny /sys/[^f]*/** wklx,
+  deny /sys/f[^s]*/** wklx,
+  deny /sys/fs/[^c]*/** wklx,
+  deny /sys/fs/c[^g]*/** wklx,
+  deny /sys/fs/cg[^r]*/** wklx,
+  deny /sys/firmware/** rwklx,
+  deny /sys/devices/virtual/powercap/** rwklx,
+  deny /sys/kernel/security/** rwklx,
+
+  # suppress ptrace denials when using 'ps' inside a container
+  ptrace (trace,read,tracedby,readby) peer={{.Name}},
+}
+`
+
+var generatedTmpl = template.Must(template.New("apparmor").Parse(generatedTemplate))
+
+type generatedProfile struct {
+	Name           string
+	NetworkNone    bool
+	Capabilities   []string
+	ReadonlyRootfs bool
+	Writable       []string
+	Readonly       []string
+	Mount          bool
+}
+
+// GeneratedProfileName returns the name of the profile generated for the container with the given id.
+// The full id is used, so that profiles of distinct containers never collide.
+func GeneratedProfileName(id string) string {
+	return version.RootName + "-generated-" + id
+}
+
+// Generate renders an AppArmor profile named `name` for the container described by spec.
+// The profile grants the capabilities of the bounding set, allows mounting only with CAP_SYS_ADMIN, restricts
+// networking to unix sockets for containers without network, and denies writes to read-only mounts.
+// If the root filesystem is read-only, writes are further restricted to the writable mounts.
+func Generate(name string, spec *specs.Spec) (string, error) {
+	if spec == nil || spec.Process == nil {
+		return "", errWrap(errors.New("cannot generate a profile from an incomplete spec"), ErrServiceAppArmor,
+			errs.ErrInvalidArgument)
+	}
+
+	data := generatedProfile{
+		Name:           name,
+		NetworkNone:    isNetworkNone(spec),
+		ReadonlyRootfs: spec.Root != nil && spec.Root.Readonly,
+	}
+
+	if spec.Process.Capabilities != nil {
+		for _, capability := range spec.Process.Capabilities.Bounding {
+			data.Capabilities = append(data.Capabilities,
+				strings.ToLower(strings.TrimPrefix(capability, "CAP_")))
+			if capability == capSysAdmin {
+				data.Mount = true
+			}
+		}
+		slices.Sort(data.Capabilities)
+		data.Capabilities = slices.Compact(data.Capabilities)
+	}
+
+	for _, mount := range spec.Mounts {
+		destination := path.Clean(mount.Destination)
+		if isSystemMount(destination) {
+			continue
+		}
+
+		if slices.Contains(mount.Options, "ro") {
+			data.Readonly = append(data.Readonly, pathRule(destination))
+		} else {
+			data.Writable = append(data.Writable, pathRule(destination))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := generatedTmpl.Execute(&buf, data); err != nil {
+		return "", errWrap(err, ErrServiceAppArmor, errs.ErrSystemFailure)
+	}
+
+	return buf.String(), nil
+}
+
+// WithGeneratedProfile returns a SpecOpts generating, loading and applying a profile for the container.
+// Since the profile is derived from the spec, this must be applied after every other option.
+func WithGeneratedProfile() oci.SpecOpts {
+	return func(_ context.Context, _ oci.Client, c *containers.Container, s *oci.Spec) error {
+		if !apparmor.Enabled() {
+			return errWrap(ErrUnsupported, ErrServiceAppArmor)
+		}
+
+		if !apparmor.CanLoadProfile() {
+			return errWrap(ErrCannotLoadOrUnload, ErrServiceAppArmor)
+		}
+
+		if err := LoadGenerated(c.ID, s); err != nil {
+			return err
+		}
+
+		s.Process.ApparmorProfile = GeneratedProfileName(c.ID)
+
+		return nil
+	}
+}
+
+// LoadGenerated generates the profile of the container with the given id from its spec, and loads it.
+func LoadGenerated(id string, spec *specs.Spec) error {
+	profile, err := Generate(GeneratedProfileName(id), spec)
+	if err != nil {
+		return err
+	}
+
+	return loadProfile(profile)
+}
+
+// IsGenerated tells whether spec uses the profile generated for the container with the given id.
+func IsGenerated(id string, spec *specs.Spec) bool {
+	return spec != nil && spec.Process != nil && spec.Process.ApparmorProfile == GeneratedProfileName(id)
+}
+
+// UnloadGenerated unloads the profile generated for the container with the given id, if spec uses it.
+func UnloadGenerated(id string, spec *specs.Spec) error {
+	if !IsGenerated(id, spec) {
+		return nil
+	}
+
+	return Unload(GeneratedProfileName(id))
+}
+
+func loadProfile(profile string) error {
+	var stderr bytes.Buffer
+
+	// -K: do not cache, -r: replace a profile with the same name
+	cmd := exec.Command(apparmorParserBin, "-Kr")
+	cmd.Stdin = strings.NewReader(profile)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return errWrap(fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String())), ErrServiceAppArmor,
+			errs.ErrSystemFailure)
+	}
+
+	return nil
+}
+
+func isNetworkNone(spec *specs.Spec) bool {
+	var networks []string
+	if err := json.Unmarshal([]byte(spec.Annotations[labels.Networks]), &networks); err != nil {
+		log.L.WithError(err).Debug("unable to determine the container networks")
+
+		return false
+	}
+
+	return len(networks) == 1 && networks[0] == networkNone
+}
+
+func isSystemMount(destination string) bool {
+	for _, prefix := range systemMountPrefixes {
+		if destination == prefix || strings.HasPrefix(destination, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pathRule returns a quoted rule matching a directory or file and everything below it.
+func pathRule(destination string) string {
+	var escaped strings.Builder
+	for _, r := range destination {
+		if strings.ContainsRune(`*?[]{}^"\`, r) {
+			escaped.WriteRune('\\')
+		}
+		escaped.WriteRune(r)
+	}
+
+	if destination == "/" {
+		return `"/**"`
+	}
+
+	return `"` + escaped.String() + `{,/**}"`
+}
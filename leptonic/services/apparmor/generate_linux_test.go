@@ -0,0 +1,96 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package apparmor_test
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/containers/specs"
+
+	"go.farcloser.world/lepton/leptonic/services/apparmor"
+	"go.farcloser.world/lepton/pkg/labels"
+)
+
+func TestGeneratedProfileName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, apparmor.GeneratedProfileName("0123456789abcdef"), "lepton-generated-0123456789abcdef")
+	assert.Equal(t, apparmor.GeneratedProfileName("0123"), "lepton-generated-0123")
+}
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	_, err := apparmor.Generate("test", &specs.Spec{})
+	assert.ErrorContains(t, err, "incomplete spec")
+
+	spec := &specs.Spec{
+		Process: &specs.Process{
+			Capabilities: &specs.LinuxCapabilities{
+				Bounding: []string{"CAP_NET_RAW", "CAP_CHOWN"},
+			},
+		},
+		Root: &specs.Root{Readonly: true},
+		Mounts: []specs.Mount{
+			{Destination: "/proc", Type: "proc"},
+			{Destination: "/dev/shm", Type: "tmpfs"},
+			{Destination: "/data", Type: "bind", Options: []string{"rbind", "rw"}},
+			{Destination: "/etc/config dir/", Type: "bind", Options: []string{"rbind", "ro"}},
+			{Destination: "/weird[1]", Type: "bind"},
+		},
+		Annotations: map[string]string{
+			labels.Networks: `["none"]`,
+		},
+	}
+
+	profile, err := apparmor.Generate("test", spec)
+	assert.NilError(t, err)
+
+	for _, expected := range []string{
+		"profile test flags=(attach_disconnected,mediate_deleted) {",
+		"  network unix,\n",
+		"  capability chown,\n  capability net_raw,\n",
+		"  /** rmlkix,\n",
+		"  \"/data{,/**}\" rwlk,\n",
+		"  \"/weird\\[1\\]{,/**}\" rwlk,\n",
+		"  deny \"/etc/config dir{,/**}\" wl,\n",
+		"  deny mount,\n",
+		"  signal (send,receive) peer=test,\n",
+	} {
+		assert.Assert(t, strings.Contains(profile, expected), "missing %q in:\n%s", expected, profile)
+	}
+
+	for _, unexpected := range []string{"/proc{", "/dev/shm", "\n  file,\n", "\n  network,\n"} {
+		assert.Assert(t, !strings.Contains(profile, unexpected), "unexpected %q in:\n%s", unexpected, profile)
+	}
+
+	spec.Process.Capabilities.Bounding = append(spec.Process.Capabilities.Bounding, "CAP_SYS_ADMIN")
+	spec.Root.Readonly = false
+	spec.Annotations[labels.Networks] = `["bridge"]`
+
+	profile, err = apparmor.Generate("test", spec)
+	assert.NilError(t, err)
+
+	for _, expected := range []string{"\n  network,\n", "\n  file,\n", "\n  mount,\n", "capability sys_admin,\n"} {
+		assert.Assert(t, strings.Contains(profile, expected), "missing %q in:\n%s", expected, profile)
+	}
+
+	assert.Assert(t, !strings.Contains(profile, "rwlk,"), "unexpected writable rules in:\n%s", profile)
+}
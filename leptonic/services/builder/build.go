@@ -23,26 +23,37 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 
 	"github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/core/content"
 	"github.com/containerd/containerd/v2/core/images"
-	"github.com/containerd/containerd/v2/core/images/archive"
+	"github.com/containerd/containerd/v2/plugins/content/local"
 	"github.com/containerd/errdefs"
 	"github.com/containerd/log"
 	"github.com/containerd/platforms"
+	"github.com/docker/cli/cli/config"
+	bkclient "github.com/moby/buildkit/client"
+	bkbuild "github.com/moby/buildkit/cmd/buildctl/build"
+	"github.com/moby/buildkit/exporter/containerimage/exptypes"
+	"github.com/moby/buildkit/session/auth/authprovider"
+	"github.com/moby/buildkit/session/sshforward/sshprovider"
+	"github.com/moby/buildkit/util/progress/progressui"
+	"github.com/opencontainers/go-digest"
+	"github.com/tonistiigi/fsutil"
+	"golang.org/x/sync/errgroup"
 
 	"go.farcloser.world/containers/reference"
 	"go.farcloser.world/containers/specs"
 
 	"go.farcloser.world/lepton/leptonic/buildkit"
 	"go.farcloser.world/lepton/pkg/api/options"
-	"go.farcloser.world/lepton/pkg/clientutil"
 	"go.farcloser.world/lepton/pkg/containerutil"
-	"go.farcloser.world/lepton/pkg/platformutil"
 	"go.farcloser.world/lepton/pkg/strutil"
 )
 
@@ -61,67 +72,178 @@ func (p platformParser) DefaultSpec() platforms.Platform {
 	return platforms.DefaultSpec()
 }
 
+// Build builds an image with the dockerfile frontend, through the BuildKit client API.
+// Interrupting the build cancels the solve on the BuildKit side.
 func Build(ctx context.Context, cli *client.Client, globalOptions *options.Global, opts *options.BuilderBuild) error {
-	buildctlBinary, err := buildkit.BuildctlBinary()
-	if err != nil {
-		return err
+	mode := progressui.DisplayMode(opts.Progress)
+	if opts.Quiet {
+		mode = progressui.QuietMode
 	}
 
-	buildctlArgs, needsLoading, metaFile, tags, cleanup, err := generateBuildctlArgs(ctx, cli, globalOptions, opts)
-	if err != nil {
-		return err
-	}
+	return BuildAll(ctx, cli, globalOptions, []*options.BuilderBuild{opts}, opts.Stderr, mode)
+}
 
-	if cleanup != nil {
-		defer cleanup()
+// BuildAll runs builds concurrently against their BuildKit hosts, displaying their combined progress on progressOut.
+// Builds sharing a host and a context directory reuse the files already uploaded to BuildKit.
+// If one build fails, the others are cancelled.
+func BuildAll(
+	ctx context.Context,
+	cli *client.Client,
+	globalOptions *options.Global,
+	builds []*options.BuilderBuild,
+	progressOut io.Writer,
+	mode progressui.DisplayMode,
+) error {
+	if len(builds) == 0 {
+		return nil
 	}
 
-	buildctlCmd := exec.Command(buildctlBinary, buildctlArgs...)
-	buildctlCmd.Env = os.Environ()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	var buildctlStdout io.Reader
-	if needsLoading {
-		buildctlStdout, err = buildctlCmd.StdoutPipe()
+	// Each host gets a single client, shared by the builds running on it
+	bkClients := map[string]*bkclient.Client{}
+	defer func() {
+		for _, bkClient := range bkClients {
+			bkClient.Close()
+		}
+	}()
+
+	for _, opts := range builds {
+		if _, ok := bkClients[opts.BuildKitHost]; ok {
+			continue
+		}
+
+		bkClient, err := buildkit.NewClient(ctx, opts.BuildKitHost)
 		if err != nil {
 			return err
 		}
-	} else {
-		buildctlCmd.Stdout = opts.Stdout
-	}
 
-	if !opts.Quiet {
-		buildctlCmd.Stderr = opts.Stderr
+		bkClients[opts.BuildKitHost] = bkClient
 	}
 
-	if err := buildctlCmd.Start(); err != nil {
+	// Blobs streamed to the content store must not be garbage collected before their image gets created
+	ctx, done, err := cli.WithLease(ctx)
+	if err != nil {
 		return err
 	}
+	defer done(context.WithoutCancel(ctx))
+
+	jobs := make([]*buildJob, 0, len(builds))
+	defer func() {
+		for _, job := range jobs {
+			job.cleanup()
+		}
+	}()
+
+	for _, opts := range builds {
+		solveOpt, output, cleanup, err := generateSolveOpt(ctx, cli, globalOptions, opts)
+		if cleanup != nil {
+			jobs = append(jobs, &buildJob{opts: opts, solveOpt: solveOpt, output: output, cleanup: cleanup})
+		}
 
-	if needsLoading {
-		platMC, err := platformutil.NewMatchComparer(false, opts.Platform)
 		if err != nil {
 			return err
 		}
-		if err = loadImage(ctx, buildctlStdout, globalOptions.Namespace, globalOptions.Address, globalOptions.Snapshotter, opts.Stdout, platMC, opts.Quiet); err != nil {
-			return err
+
+		if output.load {
+			if err = attachContentStore(ctx, cli, globalOptions.Namespace, solveOpt, output); err != nil {
+				return err
+			}
 		}
 	}
 
-	if err = buildctlCmd.Wait(); err != nil {
+	display, err := progressui.NewDisplay(progressOut, mode)
+	if err != nil {
 		return err
 	}
 
-	if opts.IidFile != "" {
-		id, err := getDigestFromMetaFile(metaFile)
-		if err != nil {
+	// Statuses of all the solves are funneled to a single display
+	statusCh := make(chan *bkclient.SolveStatus)
+	var forwarders sync.WaitGroup
+	eg, egCtx := errgroup.WithContext(ctx)
+	for _, job := range jobs {
+		jobCh := make(chan *bkclient.SolveStatus)
+		forwarders.Add(1)
+		go func() {
+			defer forwarders.Done()
+			for status := range jobCh {
+				statusCh <- status
+			}
+		}()
+
+		eg.Go(func() error {
+			// The status channel is closed by Solve
+			resp, err := bkClients[job.opts.BuildKitHost].Solve(egCtx, nil, *job.solveOpt, jobCh)
+			if err != nil {
+				return err
+			}
+
+			return finish(egCtx, cli, globalOptions, job, resp)
+		})
+	}
+
+	go func() {
+		forwarders.Wait()
+		close(statusCh)
+	}()
+
+	displayErr := make(chan error, 1)
+	go func() {
+		// The display must not stop before all the solves are done
+		_, err := display.UpdateFrom(context.WithoutCancel(egCtx), statusCh)
+		// Keep draining, so that solves never block on a failed display
+		for range statusCh {
+		}
+		displayErr <- err
+	}()
+
+	err = eg.Wait()
+	if dErr := <-displayErr; err == nil {
+		err = dErr
+	}
+
+	return err
+}
+
+// buildJob is a build, ready to be solved.
+type buildJob struct {
+	opts     *options.BuilderBuild
+	solveOpt *bkclient.SolveOpt
+	output   *buildOutput
+	cleanup  func()
+}
+
+// finish loads and tags the image built by job, and writes its digest out.
+func finish(
+	ctx context.Context,
+	cli *client.Client,
+	globalOptions *options.Global,
+	job *buildJob,
+	resp *bkclient.SolveResponse,
+) error {
+	opts, output := job.opts, job.output
+	if output.load {
+		if err := loadImage(ctx, cli, globalOptions.Snapshotter, opts, output, resp); err != nil {
 			return err
 		}
-		if err := os.WriteFile(opts.IidFile, []byte(id), 0o644); err != nil {
+	}
+
+	digest := resp.ExporterResponse[exptypes.ExporterImageDigestKey]
+	if opts.IidFile != "" {
+		if digest == "" {
+			return errors.New("failed to find the image digest in the build response")
+		}
+		if err := os.WriteFile(opts.IidFile, []byte(digest), 0o644); err != nil {
 			return err
 		}
 	}
 
-	if len(tags) > 1 {
+	if opts.Quiet && digest != "" {
+		fmt.Fprintln(opts.Stdout, digest)
+	}
+
+	if tags := output.tags; len(tags) > 1 {
 		imageService := cli.ImageService()
 		image, err := imageService.Get(ctx, tags[0])
 		if err != nil {
@@ -148,92 +270,50 @@ func Build(ctx context.Context, cli *client.Client, globalOptions *options.Globa
 	return nil
 }
 
-// TODO: This struct and `loadImage` are duplicated with the code in `cmd/load.go`, remove it after `load.go` has been
-// refactor
-type readCounter struct {
-	io.Reader
-	N int
-}
-
-func loadImage(
-	ctx context.Context,
-	in io.Reader,
-	namespace, address, snapshotter string,
-	output io.Writer,
-	platMC platforms.MatchComparer,
-	quiet bool,
-) error {
-	// In addition to passing WithImagePlatform() to cli.Import(), we also need to pass WithDefaultPlatform() to
-	// NewClient().
-	// Otherwise unpacking may fail.
-	cli, ctx, cancel, err := clientutil.NewClientWithOpt(ctx, namespace, address, client.WithDefaultPlatform(platMC))
-	if err != nil {
-		return err
-	}
-	defer func() {
-		cancel()
-		cli.Close()
-	}()
-
-	r := &readCounter{Reader: in}
-
-	imgs, err := cli.Import(
-		ctx,
-		r,
-		client.WithDigestRef(archive.DigestTranslator(snapshotter)),
-		client.WithSkipDigestRef(func(name string) bool { return name != "" }),
-		client.WithImportPlatform(platMC),
-	)
-	if err != nil {
-		if r.N == 0 {
-			// Avoid confusing "unrecognized image format"
-			return errors.New("no image was built")
-		}
-		if errors.Is(err, images.ErrEmptyWalk) {
-			err = fmt.Errorf("%w (Hint: set `--platform=PLATFORM` or `--all-platforms`)", err)
-		}
-		return err
+// sharedKey lets builds of the same context directory reuse the files already transferred to BuildKit.
+func sharedKey(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
 	}
 
-	for _, img := range imgs {
-		image := client.NewImageWithPlatform(cli, img, platMC)
-
-		// TODO: Show unpack status
-		if !quiet {
-			fmt.Fprintf(output, "unpacking %s (%s)...\n", img.Name, img.Target.Digest)
-		}
-
-		err = image.Unpack(ctx, snapshotter)
-		if err != nil {
-			return err
-		}
-
-		if quiet {
-			fmt.Fprintln(output, img.Target.Digest)
-		} else {
-			fmt.Fprintf(output, "Loaded image: %s\n", img.Name)
-		}
-	}
+	return digest.FromString(dir).Encoded()
+}
 
-	return nil
+// buildOutput describes where the result of a build goes.
+type buildOutput struct {
+	// load is true if the image has to be loaded into containerd once built
+	load bool
+	// tags are the normalized names of the image
+	tags []string
+	// exportDir is a temporary OCI layout the image gets exported to, when it cannot be streamed to containerd
+	exportDir string
 }
 
-func generateBuildctlArgs(
+func generateSolveOpt(
 	ctx context.Context,
 	cli *client.Client,
 	globalOptions *options.Global,
 	opts *options.BuilderBuild,
 ) (
-	buildctlArgs []string, needsLoading bool, metaFile string, tags []string, cleanup func(), err error,
+	solveOpt *bkclient.SolveOpt, out *buildOutput, cleanup func(), err error,
 ) {
+	out = &buildOutput{}
+	var cleanups []func()
+	cleanup = func() {
+		for _, fn := range cleanups {
+			fn()
+		}
+	}
+
 	output := opts.Output
 	if output == "" {
 		info, err := cli.Server(ctx)
 		if err != nil {
-			return nil, false, "", nil, nil, err
+			return nil, nil, cleanup, err
 		}
 
 		sharable, err := isImageSharable(
+			ctx,
 			opts.BuildKitHost,
 			globalOptions.Namespace,
 			info.UUID,
@@ -241,7 +321,7 @@ func generateBuildctlArgs(
 			opts.Platform,
 		)
 		if err != nil {
-			return nil, false, "", nil, nil, err
+			return nil, nil, cleanup, err
 		}
 
 		if sharable {
@@ -251,54 +331,50 @@ func generateBuildctlArgs(
 			if len(opts.Platform) > 1 {
 				// For avoiding `error: failed to solve: docker exporter does not currently support exporting manifest
 				// lists`
-				// TODO: consider using type=oci for single-opts.Platform build too
 				output = "type=oci"
 			}
-			needsLoading = true
-		}
-	} else {
-		if !strings.Contains(output, "type=") {
-			// should accept --output <DIR> as an alias of --output
-			// type=local,dest=<DIR>
-			output = "type=local,dest=" + output
-		}
-
-		if strings.Contains(output, "type=docker") || strings.Contains(output, "type=oci") {
-			if !strings.Contains(output, "dest=") {
-				needsLoading = true
-			}
 		}
+	} else if !strings.Contains(output, "type=") {
+		// should accept --output <DIR> as an alias of --output
+		// type=local,dest=<DIR>
+		output = "type=local,dest=" + output
 	}
 
-	if tags = strutil.DedupeStrSlice(opts.Tag); len(tags) > 0 {
-		ref := tags[0]
-		parsedReference, err := reference.Parse(ref)
-		if err != nil {
-			return nil, false, "", nil, nil, err
-		}
-		output += ",name=" + parsedReference.String()
-
-		// pick the first tag and add it to output
-		for idx, tag := range tags {
-			parsedReference, err = reference.Parse(tag)
+	if tags := strutil.DedupeStrSlice(opts.Tag); len(tags) > 0 {
+		for _, tag := range tags {
+			parsedReference, err := reference.Parse(tag)
 			if err != nil {
-				return nil, false, "", nil, nil, err
+				return nil, nil, cleanup, err
 			}
-			tags[idx] = parsedReference.String()
+			out.tags = append(out.tags, parsedReference.String())
 		}
-	} else if len(tags) == 0 {
+
+		// pick the first tag and add it to output
+		output += ",name=" + out.tags[0]
+	} else {
 		output += ",dangling-name-prefix=<none>"
 	}
 
-	buildctlArgs = buildkit.BuildctlBaseArgs(opts.BuildKitHost)
+	export, load, err := parseOutput(output, opts.Stdout)
+	if err != nil {
+		return nil, nil, cleanup, err
+	}
+	out.load = load
+
+	solveOpt = &bkclient.SolveOpt{
+		Exports:       []bkclient.ExportEntry{export},
+		LocalMounts:   map[string]fsutil.FS{},
+		OCIStores:     map[string]content.Store{},
+		Frontend:      "dockerfile.v0",
+		FrontendAttrs: map[string]string{},
+		SharedKey:     sharedKey(opts.BuildContext),
+	}
 
-	buildctlArgs = append(buildctlArgs, []string{
-		"build",
-		"--progress=" + opts.Progress,
-		"--frontend=dockerfile.v0",
-		"--local=context=" + opts.BuildContext,
-		"--output=" + output,
-	}...)
+	contextFS, err := fsutil.NewFS(opts.BuildContext)
+	if err != nil {
+		return nil, nil, cleanup, err
+	}
+	solveOpt.LocalMounts["context"] = contextFS
 
 	dir := opts.BuildContext
 	file := buildkit.DefaultDockerfileName
@@ -308,11 +384,12 @@ func generateBuildctlArgs(
 			var err error
 			dir, err = buildkit.WriteTempDockerfile(opts.Stdin)
 			if err != nil {
-				return nil, false, "", nil, nil, err
-			}
-			cleanup = func() {
-				os.RemoveAll(dir)
+				return nil, nil, cleanup, err
 			}
+			tmpDir := dir
+			cleanups = append(cleanups, func() {
+				os.RemoveAll(tmpDir)
+			})
 		} else {
 			dir, file = filepath.Split(opts.File)
 		}
@@ -324,12 +401,19 @@ func generateBuildctlArgs(
 
 	dir, file, err = buildkit.File(dir, file)
 	if err != nil {
-		return nil, false, "", nil, nil, err
+		return nil, nil, cleanup, err
 	}
 
+	dockerfileFS, err := fsutil.NewFS(dir)
+	if err != nil {
+		return nil, nil, cleanup, err
+	}
+	solveOpt.LocalMounts["dockerfile"] = dockerfileFS
+	solveOpt.FrontendAttrs["filename"] = file
+
 	buildCtx, err := parseContextNames(opts.ExtendedBuildContext)
 	if err != nil {
-		return nil, false, "", nil, nil, err
+		return nil, nil, cleanup, err
 	}
 
 	for k, v := range buildCtx {
@@ -337,68 +421,72 @@ func generateBuildctlArgs(
 		isDockerImage := strings.HasPrefix(v, "docker-image://") || strings.HasPrefix(v, "target:")
 
 		if isURL || isDockerImage {
-			buildctlArgs = append(buildctlArgs, fmt.Sprintf("--opt=context:%s=%s", k, v))
+			solveOpt.FrontendAttrs["context:"+k] = v
 			continue
 		}
 
 		if isOCILayout := strings.HasPrefix(v, "oci-layout://"); isOCILayout {
-			args, err := parseBuildContextFromOCILayout(k, v)
+			store, attr, err := parseBuildContextFromOCILayout(k, v)
 			if err != nil {
-				return nil, false, "", nil, nil, err
+				return nil, nil, cleanup, err
 			}
 
-			buildctlArgs = append(buildctlArgs, args...)
+			solveOpt.OCIStores[ociLayoutStoreKey] = store
+			solveOpt.FrontendAttrs["context:"+k] = attr
 			continue
 		}
 
 		path, err := filepath.Abs(v)
 		if err != nil {
-			return nil, false, "", nil, nil, err
+			return nil, nil, cleanup, err
+		}
+		localFS, err := fsutil.NewFS(path)
+		if err != nil {
+			return nil, nil, cleanup, err
 		}
-		buildctlArgs = append(buildctlArgs, fmt.Sprintf("--local=%s=%s", k, path))
-		buildctlArgs = append(buildctlArgs, fmt.Sprintf("--opt=context:%s=local:%s", k, k))
+		solveOpt.LocalMounts[k] = localFS
+		solveOpt.FrontendAttrs["context:"+k] = "local:" + k
 	}
 
-	buildctlArgs = append(buildctlArgs, "--local=dockerfile="+dir)
-	buildctlArgs = append(buildctlArgs, "--opt=filename="+file)
-
 	if opts.Target != "" {
-		buildctlArgs = append(buildctlArgs, "--opt=target="+opts.Target)
+		solveOpt.FrontendAttrs["target"] = opts.Target
 	}
 
 	if len(opts.Platform) > 0 {
-		buildctlArgs = append(buildctlArgs, "--opt=platform="+strings.Join(opts.Platform, ","))
+		solveOpt.FrontendAttrs["platform"] = strings.Join(opts.Platform, ",")
 	}
 
 	seenBuildArgs := make(map[string]struct{})
 	for _, ba := range strutil.DedupeStrSlice(opts.BuildArgs) {
-		arr := strings.Split(ba, "=")
-		seenBuildArgs[arr[0]] = struct{}{}
-		if len(arr) == 1 && len(arr[0]) > 0 {
+		key, value, found := strings.Cut(ba, "=")
+		seenBuildArgs[key] = struct{}{}
+		if !found && len(key) > 0 {
 			// Avoid masking default build arg value from Dockerfile if environment variable is not set
 			// https://github.com/moby/moby/issues/24101
-			val, ok := os.LookupEnv(arr[0])
+			val, ok := os.LookupEnv(key)
 			if ok {
-				buildctlArgs = append(buildctlArgs, fmt.Sprintf("--opt=build-arg:%s=%s", ba, val))
+				solveOpt.FrontendAttrs["build-arg:"+key] = val
 			}
-		} else if len(arr) > 1 && len(arr[0]) > 0 {
-			buildctlArgs = append(buildctlArgs, "--opt=build-arg:"+ba)
+		} else if found && len(key) > 0 {
+			solveOpt.FrontendAttrs["build-arg:"+key] = value
 
 			// Support `--build-arg BUILDKIT_INLINE_CACHE=1` for compatibility with `docker buildx build`
 			// https://github.com/docker/buildx/blob/v0.6.3/docs/reference/buildx_build.md#-export-build-cache-to-an-external-cache-destination---cache-to
-			if strings.HasPrefix(ba, "BUILDKIT_INLINE_CACHE=") {
-				bic := strings.TrimPrefix(ba, "BUILDKIT_INLINE_CACHE=")
-				bicParsed, err := strconv.ParseBool(bic)
+			if key == "BUILDKIT_INLINE_CACHE" {
+				bicParsed, err := strconv.ParseBool(value)
 				if err == nil {
 					if bicParsed {
-						buildctlArgs = append(buildctlArgs, "--export-cache=type=inline")
+						solveOpt.CacheExports = append(
+							solveOpt.CacheExports,
+							bkclient.CacheOptionsEntry{Type: "inline"},
+						)
 					}
 				} else {
-					log.L.WithError(err).Warnf("invalid BUILDKIT_INLINE_CACHE: %q", bic)
+					log.L.WithError(err).Warnf("invalid BUILDKIT_INLINE_CACHE: %q", value)
 				}
 			}
 		} else {
-			return nil, false, "", nil, nil, fmt.Errorf("invalid build arg %q", ba)
+			return nil, nil, cleanup, fmt.Errorf("invalid build arg %q", ba)
 		}
 	}
 
@@ -406,87 +494,91 @@ func generateBuildctlArgs(
 	// https://github.com/docker/buildx/pull/1482
 	if v := os.Getenv("SOURCE_DATE_EPOCH"); v != "" {
 		if _, ok := seenBuildArgs["SOURCE_DATE_EPOCH"]; !ok {
-			buildctlArgs = append(buildctlArgs, "--opt=build-arg:SOURCE_DATE_EPOCH="+v)
+			solveOpt.FrontendAttrs["build-arg:SOURCE_DATE_EPOCH"] = v
 		}
 	}
 
 	for _, l := range strutil.DedupeStrSlice(opts.Label) {
-		buildctlArgs = append(buildctlArgs, "--opt=label:"+l)
+		key, value, _ := strings.Cut(l, "=")
+		solveOpt.FrontendAttrs["label:"+key] = value
 	}
 
 	if opts.NoCache {
-		buildctlArgs = append(buildctlArgs, "--no-cache")
+		solveOpt.FrontendAttrs["no-cache"] = ""
 	}
 
 	if opts.Pull != nil {
 		switch *opts.Pull {
 		case true:
-			buildctlArgs = append(buildctlArgs, "--opt=image-resolve-mode=pull")
+			solveOpt.FrontendAttrs["image-resolve-mode"] = "pull"
 		case false:
-			buildctlArgs = append(buildctlArgs, "--opt=image-resolve-mode=local")
+			solveOpt.FrontendAttrs["image-resolve-mode"] = "local"
 		}
 	}
 
-	for _, s := range strutil.DedupeStrSlice(opts.Secret) {
-		buildctlArgs = append(buildctlArgs, "--secret="+s)
-	}
+	authProvider := authprovider.NewDockerAuthProvider(authprovider.DockerAuthProviderConfig{
+		ConfigFile: config.LoadDefaultConfigFile(opts.Stderr),
+	})
+	solveOpt.Session = append(solveOpt.Session, authProvider)
 
-	for _, s := range strutil.DedupeStrSlice(opts.Allow) {
-		buildctlArgs = append(buildctlArgs, "--allow="+s)
+	if secrets := strutil.DedupeStrSlice(opts.Secret); len(secrets) > 0 {
+		secretProvider, err := bkbuild.ParseSecret(secrets)
+		if err != nil {
+			return nil, nil, cleanup, err
+		}
+		solveOpt.Session = append(solveOpt.Session, secretProvider)
 	}
 
+	solveOpt.AllowedEntitlements = append(solveOpt.AllowedEntitlements, strutil.DedupeStrSlice(opts.Allow)...)
+
 	for _, s := range strutil.DedupeStrSlice(opts.Attest) {
 		optAttestType, optAttestAttrs, _ := strings.Cut(s, ",")
 		if strings.HasPrefix(optAttestType, "type=") {
 			optAttestType := strings.TrimPrefix(optAttestType, "type=")
-			buildctlArgs = append(buildctlArgs, fmt.Sprintf("--opt=attest:%s=%s", optAttestType, optAttestAttrs))
+			solveOpt.FrontendAttrs["attest:"+optAttestType] = optAttestAttrs
 		} else {
-			return nil, false, "", nil, nil, errors.New("attestation type not specified")
+			return nil, nil, cleanup, errors.New("attestation type not specified")
 		}
 	}
 
-	for _, s := range strutil.DedupeStrSlice(opts.SSH) {
-		buildctlArgs = append(buildctlArgs, "--ssh="+s)
+	if ssh := strutil.DedupeStrSlice(opts.SSH); len(ssh) > 0 {
+		agentConfigs, err := bkbuild.ParseSSH(ssh)
+		if err != nil {
+			return nil, nil, cleanup, err
+		}
+		sshProvider, err := sshprovider.NewSSHAgentProvider(agentConfigs)
+		if err != nil {
+			return nil, nil, cleanup, err
+		}
+		solveOpt.Session = append(solveOpt.Session, sshProvider)
 	}
 
-	for _, s := range strutil.DedupeStrSlice(opts.CacheFrom) {
-		if !strings.Contains(s, "type=") {
-			s = "type=registry,ref=" + s
-		}
-		buildctlArgs = append(buildctlArgs, "--import-cache="+s)
+	cacheImports, err := bkbuild.ParseImportCache(cacheOptions(opts.CacheFrom))
+	if err != nil {
+		return nil, nil, cleanup, err
 	}
+	solveOpt.CacheImports = cacheImports
 
-	for _, s := range strutil.DedupeStrSlice(opts.CacheTo) {
-		if !strings.Contains(s, "type=") {
-			s = "type=registry,ref=" + s
-		}
-		buildctlArgs = append(buildctlArgs, "--export-cache="+s)
+	cacheExports, err := bkbuild.ParseExportCache(cacheOptions(opts.CacheTo))
+	if err != nil {
+		return nil, nil, cleanup, err
 	}
+	solveOpt.CacheExports = append(solveOpt.CacheExports, cacheExports...)
 
 	if !opts.Rm {
 		log.L.Warn("ignoring deprecated flag: '--rm=false'")
 	}
 
-	if opts.IidFile != "" {
-		file, err := os.CreateTemp("", "buildkit-meta-*")
-		if err != nil {
-			return nil, false, "", nil, cleanup, err
-		}
-		defer file.Close()
-		metaFile = file.Name()
-		buildctlArgs = append(buildctlArgs, "--metadata-file="+metaFile)
-	}
-
 	if opts.NetworkMode != "" {
 		switch opts.NetworkMode {
 		case "none":
-			buildctlArgs = append(buildctlArgs, "--opt=force-network-mode="+opts.NetworkMode)
+			solveOpt.FrontendAttrs["force-network-mode"] = opts.NetworkMode
 		case "host":
-			buildctlArgs = append(
-				buildctlArgs,
-				"--opt=force-network-mode="+opts.NetworkMode,
-				"--allow=network.host",
-				"--allow=security.insecure",
+			solveOpt.FrontendAttrs["force-network-mode"] = opts.NetworkMode
+			solveOpt.AllowedEntitlements = append(
+				solveOpt.AllowedEntitlements,
+				"network.host",
+				"security.insecure",
 			)
 		case "", "default":
 		default:
@@ -496,41 +588,91 @@ func generateBuildctlArgs(
 	if len(opts.ExtraHosts) > 0 {
 		extraHosts, err := containerutil.ParseExtraHosts(opts.ExtraHosts, globalOptions.HostGatewayIP, "=")
 		if err != nil {
-			return nil, false, "", nil, nil, err
+			return nil, nil, cleanup, err
 		}
-		buildctlArgs = append(buildctlArgs, "--opt=add-hosts="+strings.Join(extraHosts, ","))
+		solveOpt.FrontendAttrs["add-hosts"] = strings.Join(extraHosts, ",")
 	}
 
-	return buildctlArgs, needsLoading, metaFile, tags, cleanup, nil
+	return solveOpt, out, cleanup, nil
 }
 
-func getDigestFromMetaFile(path string) (string, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return "", err
+// parseOutput converts an --output value into an export entry.
+// Docker and OCI images without a destination are to be loaded into containerd: they are returned as such, with load
+// set to true.
+func parseOutput(output string, stdout io.Writer) (entry bkclient.ExportEntry, load bool, err error) {
+	entry.Attrs = map[string]string{}
+	var dest string
+	for _, field := range strings.Split(output, ",") {
+		key, value, _ := strings.Cut(field, "=")
+		switch key {
+		case "type":
+			entry.Type = value
+		case "dest":
+			dest = value
+		default:
+			entry.Attrs[key] = value
+		}
 	}
 
-	defer os.Remove(path)
+	switch entry.Type {
+	case bkclient.ExporterLocal:
+		if dest == "" {
+			return entry, false, fmt.Errorf("output directory is required for %s exporter", entry.Type)
+		}
+		entry.OutputDir = dest
+	case bkclient.ExporterTar:
+		entry.Output = outputFile(dest, stdout)
+	case bkclient.ExporterOCI, bkclient.ExporterDocker:
+		if dest == "" {
+			entry.Attrs["tar"] = "false"
+
+			return entry, true, nil
+		}
 
-	metadata := map[string]json.RawMessage{}
-	if err := json.Unmarshal(data, &metadata); err != nil {
-		log.L.WithError(err).Errorf("failed to unmarshal metadata file %s", path)
-		return "", err
+		if tar, err := strconv.ParseBool(entry.Attrs["tar"]); err == nil && !tar {
+			entry.OutputDir = dest
+		} else {
+			entry.Output = outputFile(dest, stdout)
+		}
+	default:
+		if dest != "" {
+			return entry, false, fmt.Errorf("output %s is not supported by %s exporter", dest, entry.Type)
+		}
 	}
 
-	digestRaw, ok := metadata["containerimage.digest"]
-	if !ok {
-		return "", errors.New("failed to find containerimage.digest in metadata file")
+	return entry, false, nil
+}
+
+// outputFile returns a function opening dest, or stdout if dest is empty or "-"
+func outputFile(dest string, stdout io.Writer) func(map[string]string) (io.WriteCloser, error) {
+	return func(map[string]string) (io.WriteCloser, error) {
+		if dest == "" || dest == "-" {
+			return nopWriteCloser{stdout}, nil
+		}
+
+		return os.Create(dest)
 	}
+}
 
-	var digest string
-	if err := json.Unmarshal(digestRaw, &digest); err != nil {
-		log.L.WithError(err).Errorf("failed to unmarshal digset")
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
 
-		return "", err
+// cacheOptions defaults cache values without a type to registry references
+func cacheOptions(values []string) []string {
+	res := []string{}
+	for _, s := range strutil.DedupeStrSlice(values) {
+		if !strings.Contains(s, "type=") {
+			s = "type=registry,ref=" + s
+		}
+		res = append(res, s)
 	}
 
-	return digest, nil
+	return res
 }
 
 func isMatchingRuntimePlatform(platform string, parser PlatformParser) bool {
@@ -557,8 +699,12 @@ func isBuildPlatformDefault(platform []string, parser PlatformParser) bool {
 	return false
 }
 
-func isImageSharable(buildkitHost, namespace, uuid, snapshotter string, platform []string) (bool, error) {
-	labels, err := buildkit.GetWorkerLabels(buildkitHost)
+func isImageSharable(
+	ctx context.Context,
+	buildkitHost, namespace, uuid, snapshotter string,
+	platform []string,
+) (bool, error) {
+	labels, err := buildkit.GetWorkerLabels(ctx, buildkitHost)
 	if err != nil {
 		return false, err
 	}
@@ -616,20 +762,23 @@ var (
 	ErrOCILayoutEmptyDigest    = errors.New("OCI layout cannot have empty digest")
 )
 
-func parseBuildContextFromOCILayout(name, path string) ([]string, error) {
+// ociLayoutStoreKey is the session key of the content store holding OCI layout contexts
+const ociLayoutStoreKey = "parent-image-key"
+
+func parseBuildContextFromOCILayout(name, path string) (content.Store, string, error) {
 	path, found := strings.CutPrefix(path, "oci-layout://")
 	if !found {
-		return []string{}, ErrOCILayoutPrefixNotFound
+		return nil, "", ErrOCILayoutPrefixNotFound
 	}
 
 	abspath, err := filepath.Abs(path)
 	if err != nil {
-		return []string{}, err
+		return nil, "", err
 	}
 
 	ociIndex, err := readOCIIndexFromPath(abspath)
 	if err != nil {
-		return []string{}, err
+		return nil, "", err
 	}
 
 	var digest string
@@ -640,13 +789,15 @@ func parseBuildContextFromOCILayout(name, path string) ([]string, error) {
 	}
 
 	if digest == "" {
-		return []string{}, ErrOCILayoutEmptyDigest
+		return nil, "", ErrOCILayoutEmptyDigest
+	}
+
+	store, err := local.NewStore(abspath)
+	if err != nil {
+		return nil, "", fmt.Errorf("oci-layout context %s at %s failed to initialize: %w", name, abspath, err)
 	}
 
-	return []string{
-		"--oci-layout=parent-image-key=" + abspath,
-		fmt.Sprintf("--opt=context:%s=oci-layout:parent-image-key@%s", name, digest),
-	}, nil
+	return store, fmt.Sprintf("oci-layout:%s@%s", ociLayoutStoreKey, digest), nil
 }
 
 func readOCIIndexFromPath(path string) (*specs.Index, error) {
@@ -0,0 +1,254 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/containerd/v2/core/leases"
+	"github.com/containerd/containerd/v2/pkg/namespaces"
+	"github.com/containerd/containerd/v2/plugins/content/local"
+	"github.com/containerd/errdefs"
+	bkclient "github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/exporter/containerimage/exptypes"
+	sessioncontent "github.com/moby/buildkit/session/content"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/platformutil"
+)
+
+// attachContentStore exposes the containerd content store to the build session, so that BuildKit streams the image
+// straight into it.
+// A session holds a single set of content stores, which then also has to provide the OCI layout contexts.
+func attachContentStore(
+	ctx context.Context,
+	cli *client.Client,
+	namespace string,
+	solveOpt *bkclient.SolveOpt,
+	out *buildOutput,
+) error {
+	// Local caches come with content stores set up by the BuildKit client, which cannot be merged with ours.
+	// The image is then exported to a temporary OCI layout, and copied to containerd afterwards.
+	if usesLocalCache(solveOpt) {
+		dir, err := os.MkdirTemp("", "buildkit-export-")
+		if err != nil {
+			return err
+		}
+
+		out.exportDir = dir
+		solveOpt.Exports[0].OutputDir = dir
+
+		return nil
+	}
+
+	leaseID, _ := leases.FromContext(ctx)
+	stores := map[string]content.Store{
+		"export": &namespacedStore{Store: cli.ContentStore(), namespace: namespace, lease: leaseID},
+	}
+	for key, store := range solveOpt.OCIStores {
+		stores["oci:"+key] = store
+	}
+
+	solveOpt.OCIStores = nil
+	solveOpt.Session = append(solveOpt.Session, sessioncontent.NewAttachable(stores))
+
+	return nil
+}
+
+func usesLocalCache(solveOpt *bkclient.SolveOpt) bool {
+	for _, entry := range append(append([]bkclient.CacheOptionsEntry{}, solveOpt.CacheImports...),
+		solveOpt.CacheExports...) {
+		if entry.Type == "local" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// loadImage creates the image exported by the build, and unpacks it.
+func loadImage(
+	ctx context.Context,
+	cli *client.Client,
+	snapshotter string,
+	opts *options.BuilderBuild,
+	out *buildOutput,
+	resp *bkclient.SolveResponse,
+) error {
+	encoded, ok := resp.ExporterResponse[exptypes.ExporterImageDescriptorKey]
+	if !ok {
+		return errors.New("no image was built")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return err
+	}
+
+	var desc specs.Descriptor
+	if err = json.Unmarshal(raw, &desc); err != nil {
+		return err
+	}
+
+	if out.exportDir != "" {
+		defer os.RemoveAll(out.exportDir)
+
+		if err = copyLayout(ctx, out.exportDir, cli.ContentStore(), desc); err != nil {
+			return err
+		}
+	}
+
+	img := images.Image{
+		Name:   "<none>@" + desc.Digest.String(),
+		Target: desc,
+	}
+	if len(out.tags) > 0 {
+		img.Name = out.tags[0]
+	}
+
+	imageService := cli.ImageService()
+	if _, err = imageService.Create(ctx, img); err != nil {
+		if !errors.Is(err, errdefs.ErrAlreadyExists) {
+			return err
+		}
+
+		if _, err = imageService.Update(ctx, img); err != nil {
+			return err
+		}
+	}
+
+	platMC, err := platformutil.NewMatchComparer(false, opts.Platform)
+	if err != nil {
+		return err
+	}
+
+	// TODO: Show unpack status
+	if !opts.Quiet {
+		fmt.Fprintf(opts.Stdout, "unpacking %s (%s)...\n", img.Name, desc.Digest)
+	}
+
+	if err = client.NewImageWithPlatform(cli, img, platMC).Unpack(ctx, snapshotter); err != nil {
+		if errors.Is(err, images.ErrEmptyWalk) {
+			err = fmt.Errorf("%w (Hint: set `--platform=PLATFORM` or `--all-platforms`)", err)
+		}
+
+		return err
+	}
+
+	if !opts.Quiet {
+		fmt.Fprintf(opts.Stdout, "Loaded image: %s\n", img.Name)
+	}
+
+	return nil
+}
+
+// copyLayout copies the content of an image from the OCI layout at dir into the content store.
+func copyLayout(ctx context.Context, dir string, store content.Ingester, desc specs.Descriptor) error {
+	layout, err := local.NewStore(dir)
+	if err != nil {
+		return err
+	}
+
+	copyHandler := images.HandlerFunc(func(ctx context.Context, desc specs.Descriptor) ([]specs.Descriptor, error) {
+		ra, err := layout.ReaderAt(ctx, desc)
+		if err != nil {
+			return nil, err
+		}
+		defer ra.Close()
+
+		return nil, content.WriteBlob(ctx, store, desc.Digest.String(), content.NewReader(ra), desc)
+	})
+
+	return images.Walk(ctx, images.Handlers(copyHandler, images.ChildrenHandler(layout)), desc)
+}
+
+// namespacedStore is a containerd content store, for calls coming from the build session, which carry neither the
+// namespace nor the lease.
+type namespacedStore struct {
+	content.Store
+	namespace string
+	lease     string
+}
+
+func (st *namespacedStore) context(ctx context.Context) context.Context {
+	ctx = namespaces.WithNamespace(ctx, st.namespace)
+	if st.lease != "" {
+		ctx = leases.WithLease(ctx, st.lease)
+	}
+
+	return ctx
+}
+
+func (st *namespacedStore) Info(ctx context.Context, dgst digest.Digest) (content.Info, error) {
+	return st.Store.Info(st.context(ctx), dgst)
+}
+
+func (st *namespacedStore) Update(ctx context.Context, info content.Info, fieldpaths ...string) (content.Info, error) {
+	return st.Store.Update(st.context(ctx), info, fieldpaths...)
+}
+
+func (st *namespacedStore) Walk(ctx context.Context, fn content.WalkFunc, filters ...string) error {
+	return st.Store.Walk(st.context(ctx), fn, filters...)
+}
+
+func (st *namespacedStore) Delete(ctx context.Context, dgst digest.Digest) error {
+	return st.Store.Delete(st.context(ctx), dgst)
+}
+
+func (st *namespacedStore) ReaderAt(ctx context.Context, desc specs.Descriptor) (content.ReaderAt, error) {
+	return st.Store.ReaderAt(st.context(ctx), desc)
+}
+
+func (st *namespacedStore) Status(ctx context.Context, ref string) (content.Status, error) {
+	return st.Store.Status(st.context(ctx), ref)
+}
+
+func (st *namespacedStore) ListStatuses(ctx context.Context, filters ...string) ([]content.Status, error) {
+	return st.Store.ListStatuses(st.context(ctx), filters...)
+}
+
+func (st *namespacedStore) Abort(ctx context.Context, ref string) error {
+	return st.Store.Abort(st.context(ctx), ref)
+}
+
+func (st *namespacedStore) Writer(ctx context.Context, opts ...content.WriterOpt) (content.Writer, error) {
+	writer, err := st.Store.Writer(st.context(ctx), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &namespacedWriter{Writer: writer, store: st}, nil
+}
+
+type namespacedWriter struct {
+	content.Writer
+	store *namespacedStore
+}
+
+func (w *namespacedWriter) Commit(ctx context.Context, size int64, expected digest.Digest, opts ...content.Opt) error {
+	return w.Writer.Commit(w.store.context(ctx), size, expected, opts...)
+}
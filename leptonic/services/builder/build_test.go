@@ -18,6 +18,7 @@ package builder
 
 import (
 	"fmt"
+	"io"
 	"path/filepath"
 	"reflect"
 	"runtime"
@@ -218,12 +219,12 @@ func TestIsBuildPlatformDefault(t *testing.T) {
 	}
 }
 
-func TestParseBuildctlArgsForOCILayout(t *testing.T) {
+func TestParseBuildContextFromOCILayout(t *testing.T) {
 	tests := []struct {
 		name          string
 		ociLayoutName string
 		ociLayoutPath string
-		expectedArgs  []string
+		expectedAttr  string
 		errorIsNil    bool
 		expectedErr   string
 	}{
@@ -231,14 +232,12 @@ func TestParseBuildctlArgsForOCILayout(t *testing.T) {
 			name:          "PrefixNotFoundError",
 			ociLayoutName: "unit-test",
 			ociLayoutPath: "/tmp/oci-layout/",
-			expectedArgs:  []string{},
 			expectedErr:   ErrOCILayoutPrefixNotFound.Error(),
 		},
 		{
 			name:          "DirectoryNotFoundError",
 			ociLayoutName: "unit-test",
 			ociLayoutPath: "oci-layout:///tmp/oci-layout",
-			expectedArgs:  []string{},
 			expectedErr:   "open /tmp/oci-layout/index.json: no such file or directory",
 		},
 	}
@@ -254,14 +253,75 @@ func TestParseBuildctlArgsForOCILayout(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			args, err := parseBuildContextFromOCILayout(test.ociLayoutName, test.ociLayoutPath)
+			_, attr, err := parseBuildContextFromOCILayout(test.ociLayoutName, test.ociLayoutPath)
 			if test.errorIsNil {
 				assert.NilError(t, err)
 			} else {
 				assert.Error(t, err, test.expectedErr)
 			}
-			assert.Equal(t, len(args), len(test.expectedArgs))
-			assert.DeepEqual(t, args, test.expectedArgs)
+			assert.Equal(t, attr, test.expectedAttr)
+		})
+	}
+}
+
+func TestParseOutput(t *testing.T) {
+	tests := []struct {
+		name       string
+		output     string
+		exportType string
+		attrs      map[string]string
+		outputDir  string
+		hasOutput  bool
+		load       bool
+		expectErr  bool
+	}{
+		{
+			name:       "ImageToLoad",
+			output:     "type=docker,name=example.com/image:tag",
+			exportType: "docker",
+			attrs:      map[string]string{"name": "example.com/image:tag", "tar": "false"},
+			load:       true,
+		},
+		{
+			name:       "SharedImage",
+			output:     "type=image,unpack=true,dangling-name-prefix=<none>",
+			exportType: "image",
+			attrs:      map[string]string{"unpack": "true", "dangling-name-prefix": "<none>"},
+		},
+		{
+			name:       "LocalDirectory",
+			output:     "type=local,dest=/tmp/out",
+			exportType: "local",
+			attrs:      map[string]string{},
+			outputDir:  "/tmp/out",
+		},
+		{
+			name:       "OCIArchive",
+			output:     "type=oci,dest=-",
+			exportType: "oci",
+			attrs:      map[string]string{},
+			hasOutput:  true,
+		},
+		{
+			name:      "LocalWithoutDestination",
+			output:    "type=local",
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entry, load, err := parseOutput(test.output, io.Discard)
+			if test.expectErr {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, entry.Type, test.exportType)
+			assert.DeepEqual(t, entry.Attrs, test.attrs)
+			assert.Equal(t, entry.OutputDir, test.outputDir)
+			assert.Equal(t, entry.Output != nil, test.hasOutput)
+			assert.Equal(t, load, test.load)
 		})
 	}
 }
@@ -18,67 +18,144 @@ package builder
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
-	"io"
-	"os/exec"
+	"fmt"
+	"strings"
+	"time"
+
+	bkclient "github.com/moby/buildkit/client"
+	"golang.org/x/sync/errgroup"
 
 	"go.farcloser.world/lepton/leptonic/buildkit"
+	"go.farcloser.world/lepton/leptonic/errs"
+	leptontime "go.farcloser.world/lepton/leptonic/time"
 )
 
-var (
-	ErrServiceBuilder = errors.New("builder error")
-
-	ErrStdoutPiper  = errors.New("stdout piper error")
-	ErrStartFailed  = errors.New("failed to start builder")
-	ErrWaitFailed   = errors.New("failed to wait for builder")
-	ErrDecodeFailed = errors.New("failed to decode result")
-)
+var ErrServiceBuilder = errors.New("builder error")
+
+// PruneOptions control which build cache records are pruned.
+type PruneOptions struct {
+	// All will remove all unused build cache, not just dangling ones
+	All bool
+	// Filters are BuildKit filters, e.g. `type==regular` (see ParseFilters)
+	Filters []string
+	// KeepDuration keeps the records used more recently than this
+	KeepDuration time.Duration
+	// KeepStorage is the amount of storage (in bytes) to keep for the build cache
+	KeepStorage int64
+}
 
-// Prune will prune all build cache.
-func Prune(ctx context.Context, errout io.Writer, buildkitHost string, all bool) ([]*buildkit.UsageInfo, error) {
-	buildctlBinary, err := buildkit.BuildctlBinary()
+// Prune will prune the build cache of buildkitHost, and returns the records that were removed.
+func Prune(ctx context.Context, buildkitHost string, opts *PruneOptions) ([]*buildkit.UsageInfo, error) {
+	bkClient, err := buildkit.NewClient(ctx, buildkitHost)
 	if err != nil {
 		return nil, errors.Join(ErrServiceBuilder, err)
 	}
+	defer bkClient.Close()
+
+	pruneOpts := []bkclient.PruneOption{
+		bkclient.WithKeepOpt(opts.KeepDuration, opts.KeepStorage, 0, 0),
+	}
+
+	if opts.All {
+		pruneOpts = append(pruneOpts, bkclient.PruneAll)
+	}
 
-	buildctlArgs := buildkit.BuildctlBaseArgs(buildkitHost)
-	buildctlArgs = append(buildctlArgs, "prune", "--format={{json .}}")
+	if len(opts.Filters) > 0 {
+		pruneOpts = append(pruneOpts, bkclient.WithFilter(opts.Filters))
+	}
 
-	if all {
-		buildctlArgs = append(buildctlArgs, "--all")
+	result := make([]*buildkit.UsageInfo, 0)
+	ch := make(chan bkclient.UsageInfo)
+
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		defer close(ch)
+		return bkClient.Prune(ctx, ch, pruneOpts...)
+	})
+	eg.Go(func() error {
+		for info := range ch {
+			result = append(result, usageInfo(&info))
+		}
+
+		return nil
+	})
+
+	if err = eg.Wait(); err != nil {
+		return nil, errors.Join(ErrServiceBuilder, err)
 	}
 
-	buildctlCmd := exec.Command(buildctlBinary, buildctlArgs...)
-	buildctlCmd.Stderr = errout
+	return result, nil
+}
 
-	stdout, err := buildctlCmd.StdoutPipe()
+// DiskUsage returns the build cache records of buildkitHost matching the BuildKit filters.
+func DiskUsage(ctx context.Context, buildkitHost string, filters []string) ([]*buildkit.UsageInfo, error) {
+	bkClient, err := buildkit.NewClient(ctx, buildkitHost)
 	if err != nil {
-		return nil, errors.Join(ErrServiceBuilder, ErrStdoutPiper, err)
+		return nil, errors.Join(ErrServiceBuilder, err)
 	}
+	defer bkClient.Close()
 
-	defer stdout.Close()
+	var duOpts []bkclient.DiskUsageOption
+	if len(filters) > 0 {
+		duOpts = append(duOpts, bkclient.WithFilter(filters))
+	}
 
-	if err = buildctlCmd.Start(); err != nil {
-		return nil, errors.Join(ErrServiceBuilder, ErrStartFailed, err)
+	records, err := bkClient.DiskUsage(ctx, duOpts...)
+	if err != nil {
+		return nil, errors.Join(ErrServiceBuilder, err)
 	}
 
-	dec := json.NewDecoder(stdout)
-	result := make([]*buildkit.UsageInfo, 0)
+	result := make([]*buildkit.UsageInfo, 0, len(records))
+	for _, info := range records {
+		result = append(result, usageInfo(info))
+	}
 
-	for {
-		v := &buildkit.UsageInfo{}
-		if err := dec.Decode(v); err == io.EOF {
-			break
-		} else if err != nil {
-			return nil, errors.Join(ErrServiceBuilder, ErrDecodeFailed, err)
+	return result, nil
+}
+
+// ParseFilters converts docker style filters (`type=<value>`, `until=<duration or timestamp>`) into BuildKit
+// filters, and the keep duration implied by `until`.
+func ParseFilters(filters []string, reference time.Time) (bkFilters []string, until time.Duration, err error) {
+	for _, filter := range filters {
+		key, value, ok := strings.Cut(filter, "=")
+		if !ok || value == "" {
+			return nil, 0, fmt.Errorf("%w: invalid filter %q", errs.ErrInvalidArgument, filter)
 		}
-		result = append(result, v)
-	}
 
-	if err = buildctlCmd.Wait(); err != nil {
-		return nil, errors.Join(ErrServiceBuilder, ErrWaitFailed, err)
+		switch key {
+		case "type":
+			bkFilters = append(bkFilters, "type=="+value)
+		case "until":
+			ts, err := leptontime.ParseTimestamp(value, reference)
+			if err != nil {
+				return nil, 0, fmt.Errorf("%w: invalid until filter %q", errs.ErrInvalidArgument, value)
+			}
+
+			until = reference.Sub(ts)
+			if until < 0 {
+				return nil, 0, fmt.Errorf("%w: until filter %q is in the future", errs.ErrInvalidArgument, value)
+			}
+		default:
+			return nil, 0, fmt.Errorf("%w: unsupported filter %q", errs.ErrInvalidArgument, key)
+		}
 	}
 
-	return result, err
+	return bkFilters, until, nil
+}
+
+func usageInfo(info *bkclient.UsageInfo) *buildkit.UsageInfo {
+	return &buildkit.UsageInfo{
+		ID:          info.ID,
+		Mutable:     info.Mutable,
+		InUse:       info.InUse,
+		Size:        info.Size,
+		CreatedAt:   info.CreatedAt,
+		LastUsedAt:  info.LastUsedAt,
+		UsageCount:  info.UsageCount,
+		Parents:     info.Parents,
+		Description: info.Description,
+		RecordType:  buildkit.UsageRecordType(info.RecordType),
+		Shared:      info.Shared,
+	}
 }
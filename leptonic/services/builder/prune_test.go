@@ -0,0 +1,52 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package builder
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+)
+
+func TestParseFilters(t *testing.T) {
+	t.Parallel()
+
+	reference := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	filters, until, err := ParseFilters(nil, reference)
+	assert.NilError(t, err)
+	assert.Equal(t, len(filters), 0)
+	assert.Equal(t, until, time.Duration(0))
+
+	filters, until, err = ParseFilters([]string{"type=regular", "until=24h", "type=exec.cachemount"}, reference)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, filters, []string{"type==regular", "type==exec.cachemount"})
+	assert.Equal(t, until, 24*time.Hour)
+
+	_, until, err = ParseFilters([]string{"until=2025-03-01T10:00:00Z"}, reference)
+	assert.NilError(t, err)
+	assert.Equal(t, until, 2*time.Hour)
+
+	for _, invalid := range []string{"type", "type=", "until=tomorrow", "until=2025-03-02T10:00:00Z", "id=foo"} {
+		_, _, err = ParseFilters([]string{invalid}, reference)
+		assert.Assert(t, errors.Is(err, errs.ErrInvalidArgument), invalid)
+	}
+}
@@ -0,0 +1,212 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package namespace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/containerd/v2/client"
+	"github.com/docker/go-units"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+	"go.farcloser.world/lepton/leptonic/services/helpers"
+	"go.farcloser.world/lepton/pkg/version"
+)
+
+// Well-known namespace labels, holding the defaults and quotas of a namespace.
+var (
+	// LabelDefaultNetwork is the network used when none is specified.
+	LabelDefaultNetwork = version.RootName + "/default.network"
+	// LabelDefaultSnapshotter is the snapshotter used when none is specified.
+	LabelDefaultSnapshotter = version.RootName + "/default.snapshotter"
+	// LabelDefaultLogDriver is the log driver used when none is specified.
+	LabelDefaultLogDriver = version.RootName + "/default.log-driver"
+	// LabelDefaultLogOpts is a comma separated list of key=value options for the default log driver.
+	LabelDefaultLogOpts = version.RootName + "/default.log-opts"
+	// LabelDefaultRuntime is the runtime used when none is specified.
+	LabelDefaultRuntime = version.RootName + "/default.runtime"
+	// LabelDefaultRegistryMirrors is a comma separated list of registry=mirror-url pairs, by order of preference.
+	LabelDefaultRegistryMirrors = version.RootName + "/default.registry-mirrors"
+
+	// LabelQuotaContainers is the maximum number of containers.
+	LabelQuotaContainers = version.RootName + "/quota.containers"
+	// LabelQuotaMemory is the maximum total memory limit of all containers (eg: 16g).
+	LabelQuotaMemory = version.RootName + "/quota.memory"
+	// LabelQuotaContent is the maximum size of the content store (eg: 100g).
+	LabelQuotaContent = version.RootName + "/quota.content"
+)
+
+// ErrQuotaExceeded is returned when an operation would exceed one of the namespace quotas.
+var ErrQuotaExceeded = errors.New("namespace quota exceeded")
+
+// Defaults holds the settings applied to containers and images of a namespace, when not explicitly specified.
+type Defaults struct {
+	Network     string
+	Snapshotter string
+	LogDriver   string
+	LogOpts     []string
+	Runtime     string
+	// RegistryMirrors maps a registry host (eg: docker.io) to its mirrors urls, by order of preference
+	RegistryMirrors map[string][]string
+}
+
+// Quotas holds the limits of a namespace. Zero means unlimited.
+type Quotas struct {
+	Containers int
+	Memory     int64
+	Content    int64
+}
+
+// ParseDefaults extracts the defaults from the labels of a namespace.
+func ParseDefaults(labels map[string]string) (*Defaults, error) {
+	res := &Defaults{
+		Network:     labels[LabelDefaultNetwork],
+		Snapshotter: labels[LabelDefaultSnapshotter],
+		LogDriver:   labels[LabelDefaultLogDriver],
+		Runtime:     labels[LabelDefaultRuntime],
+	}
+
+	for _, opt := range splitList(labels[LabelDefaultLogOpts]) {
+		if !strings.Contains(opt, "=") {
+			return nil, invalidLabel(LabelDefaultLogOpts, fmt.Errorf("expected key=value, got %q", opt))
+		}
+
+		res.LogOpts = append(res.LogOpts, opt)
+	}
+
+	for _, pair := range splitList(labels[LabelDefaultRegistryMirrors]) {
+		registry, mirror, found := strings.Cut(pair, "=")
+		if !found || registry == "" {
+			return nil, invalidLabel(LabelDefaultRegistryMirrors, fmt.Errorf("expected registry=url, got %q", pair))
+		}
+
+		if u, err := url.Parse(mirror); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, invalidLabel(LabelDefaultRegistryMirrors, fmt.Errorf("invalid mirror url %q", mirror))
+		}
+
+		if res.RegistryMirrors == nil {
+			res.RegistryMirrors = map[string][]string{}
+		}
+
+		res.RegistryMirrors[registry] = append(res.RegistryMirrors[registry], mirror)
+	}
+
+	return res, nil
+}
+
+// ParseQuotas extracts the quotas from the labels of a namespace.
+func ParseQuotas(labels map[string]string) (*Quotas, error) {
+	res := &Quotas{}
+
+	if value := labels[LabelQuotaContainers]; value != "" {
+		containers, err := strconv.Atoi(value)
+		if err != nil || containers < 0 {
+			return nil, invalidLabel(LabelQuotaContainers, fmt.Errorf("expected a positive number, got %q", value))
+		}
+
+		res.Containers = containers
+	}
+
+	for key, target := range map[string]*int64{LabelQuotaMemory: &res.Memory, LabelQuotaContent: &res.Content} {
+		if value := labels[key]; value != "" {
+			size, err := units.RAMInBytes(value)
+			if err != nil || size < 0 {
+				return nil, invalidLabel(key, fmt.Errorf("expected a size, got %q", value))
+			}
+
+			*target = size
+		}
+	}
+
+	return res, nil
+}
+
+// GetDefaults returns the defaults of a namespace.
+// A namespace that does not exist has no defaults.
+func GetDefaults(ctx context.Context, cli *client.Client, name string) (*Defaults, error) {
+	labels, err := getLabels(ctx, cli, name)
+	if err != nil {
+		return nil, errWrap(err)
+	}
+
+	res, err := ParseDefaults(labels)
+	if err != nil {
+		return nil, errWrap(err)
+	}
+
+	return res, nil
+}
+
+// GetQuotas returns the quotas of a namespace.
+// A namespace that does not exist has no quotas.
+func GetQuotas(ctx context.Context, cli *client.Client, name string) (*Quotas, error) {
+	labels, err := getLabels(ctx, cli, name)
+	if err != nil {
+		return nil, errWrap(err)
+	}
+
+	res, err := ParseQuotas(labels)
+	if err != nil {
+		return nil, errWrap(err)
+	}
+
+	return res, nil
+}
+
+func getLabels(ctx context.Context, cli *client.Client, name string) (map[string]string, error) {
+	if err := validate(name); err != nil {
+		return nil, err
+	}
+
+	labels, err := cli.NamespaceService().Labels(NamespacedContext(ctx, name), name)
+	if err != nil {
+		return nil, helpers.ErrConvert(err)
+	}
+
+	return labels, nil
+}
+
+// validateLabels verifies the values of the well-known labels, if present.
+func validateLabels(labels map[string]string) error {
+	if _, err := ParseDefaults(labels); err != nil {
+		return err
+	}
+
+	_, err := ParseQuotas(labels)
+
+	return err
+}
+
+func splitList(value string) []string {
+	var res []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			res = append(res, item)
+		}
+	}
+
+	return res
+}
+
+func invalidLabel(key string, err error) error {
+	return errors.Join(errs.ErrInvalidArgument, fmt.Errorf("invalid value for label %q: %w", key, err))
+}
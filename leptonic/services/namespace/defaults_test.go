@@ -0,0 +1,176 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package namespace
+
+import (
+	"errors"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+)
+
+func TestParseDefaults(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		labels  map[string]string
+		want    *Defaults
+		wantErr bool
+	}{
+		{
+			name:   "no labels",
+			labels: nil,
+			want:   &Defaults{},
+		},
+		{
+			name: "all defaults",
+			labels: map[string]string{
+				LabelDefaultNetwork:     "backend",
+				LabelDefaultSnapshotter: "stargz",
+				LabelDefaultLogDriver:   "json-file",
+				LabelDefaultLogOpts:     "max-size=10m, max-file=3",
+				LabelDefaultRuntime:     "io.containerd.runc.v2",
+				LabelDefaultRegistryMirrors: "docker.io=https://mirror.example.com," +
+					"docker.io=http://10.0.0.1:5000,ghcr.io=https://ghcr.example.com",
+				"unrelated": "label",
+			},
+			want: &Defaults{
+				Network:     "backend",
+				Snapshotter: "stargz",
+				LogDriver:   "json-file",
+				LogOpts:     []string{"max-size=10m", "max-file=3"},
+				Runtime:     "io.containerd.runc.v2",
+				RegistryMirrors: map[string][]string{
+					"docker.io": {"https://mirror.example.com", "http://10.0.0.1:5000"},
+					"ghcr.io":   {"https://ghcr.example.com"},
+				},
+			},
+		},
+		{
+			name:   "empty lists",
+			labels: map[string]string{LabelDefaultLogOpts: " , ", LabelDefaultRegistryMirrors: ""},
+			want:   &Defaults{},
+		},
+		{
+			name:    "log opt without value",
+			labels:  map[string]string{LabelDefaultLogOpts: "max-size"},
+			wantErr: true,
+		},
+		{
+			name:    "mirror without registry",
+			labels:  map[string]string{LabelDefaultRegistryMirrors: "=https://mirror.example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "mirror without url",
+			labels:  map[string]string{LabelDefaultRegistryMirrors: "docker.io"},
+			wantErr: true,
+		},
+		{
+			name:    "mirror without scheme",
+			labels:  map[string]string{LabelDefaultRegistryMirrors: "docker.io=mirror.example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "mirror with unsupported scheme",
+			labels:  map[string]string{LabelDefaultRegistryMirrors: "docker.io=ftp://mirror.example.com"},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseDefaults(tc.labels)
+			if tc.wantErr {
+				assert.Assert(t, errors.Is(err, errs.ErrInvalidArgument), "got %v", err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, got, tc.want)
+		})
+	}
+}
+
+func TestParseQuotas(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		labels  map[string]string
+		want    *Quotas
+		wantErr bool
+	}{
+		{
+			name:   "no labels",
+			labels: nil,
+			want:   &Quotas{},
+		},
+		{
+			name: "all quotas",
+			labels: map[string]string{
+				LabelQuotaContainers: "50",
+				LabelQuotaMemory:     "16g",
+				LabelQuotaContent:    "100m",
+			},
+			want: &Quotas{Containers: 50, Memory: 16 << 30, Content: 100 << 20},
+		},
+		{
+			name:   "zero means unlimited",
+			labels: map[string]string{LabelQuotaContainers: "0", LabelQuotaMemory: "0"},
+			want:   &Quotas{},
+		},
+		{
+			name:    "negative containers",
+			labels:  map[string]string{LabelQuotaContainers: "-1"},
+			wantErr: true,
+		},
+		{
+			name:    "containers not a number",
+			labels:  map[string]string{LabelQuotaContainers: "many"},
+			wantErr: true,
+		},
+		{
+			name:    "memory not a size",
+			labels:  map[string]string{LabelQuotaMemory: "lots"},
+			wantErr: true,
+		},
+		{
+			name:    "negative content",
+			labels:  map[string]string{LabelQuotaContent: "-1g"},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseQuotas(tc.labels)
+			if tc.wantErr {
+				assert.Assert(t, errors.Is(err, errs.ErrInvalidArgument), "got %v", err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, got, tc.want)
+		})
+	}
+}
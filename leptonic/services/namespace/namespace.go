@@ -78,6 +78,10 @@ func Create(ctx context.Context, cli *client.Client, name string, labels map[str
 		return errWrap(err)
 	}
 
+	if err := validateLabels(labels); err != nil {
+		return errWrap(err)
+	}
+
 	service := cli.NamespaceService()
 
 	if err := service.Create(ctx, name, labels); err != nil {
@@ -96,6 +100,10 @@ func Update(ctx context.Context, cli *client.Client, name string, labels map[str
 		return []error{errWrap(errs.ErrInvalidArgument)}
 	}
 
+	if err := validateLabels(labels); err != nil {
+		return []error{errWrap(err)}
+	}
+
 	service := cli.NamespaceService()
 	resultErrors := []error{}
 
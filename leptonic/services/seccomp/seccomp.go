@@ -0,0 +1,386 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package seccomp manages the seccomp profiles stored in the data root, and turns the syscalls traced from containers
+// into profiles.
+package seccomp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"go.farcloser.world/containers/specs"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+	"go.farcloser.world/lepton/leptonic/identifiers"
+	"go.farcloser.world/lepton/leptonic/store"
+	"go.farcloser.world/lepton/pkg/defaults"
+)
+
+const (
+	// Unconfined disables seccomp
+	Unconfined = "unconfined"
+	// Trace runs the container with a profile that allows and logs every syscall. See Generate.
+	Trace = "trace"
+
+	profilesDirBasename = "seccomp"
+	profilesGroup       = "profiles"
+)
+
+// ErrServiceSeccomp will wrap all errors here
+var ErrServiceSeccomp = errors.New("seccomp service error")
+
+var (
+	validActions = []specs.LinuxSeccompAction{
+		specs.ActKill,
+		specs.ActKillProcess,
+		specs.ActKillThread,
+		specs.ActTrap,
+		specs.ActErrno,
+		specs.ActTrace,
+		specs.ActAllow,
+		specs.ActLog,
+		specs.ActNotify,
+	}
+	validOperators = []specs.LinuxSeccompOperator{
+		specs.OpNotEqual,
+		specs.OpLessThan,
+		specs.OpLessEqual,
+		specs.OpEqualTo,
+		specs.OpGreaterEqual,
+		specs.OpGreaterThan,
+		specs.OpMaskedEqual,
+	}
+	knownArches = []specs.Arch{
+		specs.ArchX86,
+		specs.ArchX86_64,
+		specs.ArchX32,
+		specs.ArchARM,
+		specs.ArchAARCH64,
+		specs.ArchMIPS,
+		specs.ArchMIPS64,
+		specs.ArchMIPS64N32,
+		specs.ArchMIPSEL,
+		specs.ArchMIPSEL64,
+		specs.ArchMIPSEL64N32,
+		specs.ArchPPC,
+		specs.ArchPPC64,
+		specs.ArchPPC64LE,
+		specs.ArchS390,
+		specs.ArchS390X,
+		specs.ArchPARISC,
+		specs.ArchPARISC64,
+		specs.ArchRISCV64,
+		specs.ArchLOONGARCH64,
+	}
+)
+
+// Profile summarizes a stored seccomp profile.
+type Profile struct {
+	Name          string                   `json:"name"`
+	DefaultAction specs.LinuxSeccompAction `json:"defaultAction"`
+	Architectures []specs.Arch             `json:"architectures,omitempty"`
+	// Syscalls is the number of syscalls with an explicit rule
+	Syscalls int `json:"syscalls"`
+}
+
+// Validate parses an OCI seccomp profile (the `linux.seccomp` object of the runtime spec), and checks that it is
+// well-formed.
+func Validate(data []byte) (*specs.LinuxSeccomp, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	profile := &specs.LinuxSeccomp{}
+	if err := dec.Decode(profile); err != nil {
+		return nil, errors.Join(ErrServiceSeccomp, errs.ErrInvalidArgument, err)
+	}
+
+	if err := validate(profile); err != nil {
+		return nil, errors.Join(ErrServiceSeccomp, errs.ErrInvalidArgument, err)
+	}
+
+	return profile, nil
+}
+
+func validate(profile *specs.LinuxSeccomp) error {
+	if profile.DefaultAction == "" {
+		return errors.New("defaultAction is required")
+	}
+
+	if !slices.Contains(validActions, profile.DefaultAction) {
+		return fmt.Errorf("invalid defaultAction %q", profile.DefaultAction)
+	}
+
+	for _, arch := range profile.Architectures {
+		if !slices.Contains(knownArches, arch) {
+			return fmt.Errorf("unknown architecture %q", arch)
+		}
+	}
+
+	for i, syscall := range profile.Syscalls {
+		if len(syscall.Names) == 0 {
+			return fmt.Errorf("syscalls[%d]: names is required", i)
+		}
+
+		if !slices.Contains(validActions, syscall.Action) {
+			return fmt.Errorf("syscalls[%d]: invalid action %q", i, syscall.Action)
+		}
+
+		for j, arg := range syscall.Args {
+			if !slices.Contains(validOperators, arg.Op) {
+				return fmt.Errorf("syscalls[%d].args[%d]: invalid operator %q", i, j, arg.Op)
+			}
+		}
+	}
+
+	if profile.ListenerPath != "" && !filepath.IsAbs(profile.ListenerPath) {
+		return fmt.Errorf("listenerPath %q must be absolute", profile.ListenerPath)
+	}
+
+	return nil
+}
+
+// ProfileStore persists seccomp profiles in the data root, so that containers can reference them by name.
+type ProfileStore interface {
+	// Load validates and saves a profile under `name`, replacing any existing profile with the same name.
+	Load(name string, data []byte) error
+	// Get returns the profile `name`.
+	Get(name string) (*specs.LinuxSeccomp, error)
+	// Location returns the path of the profile file, for passing it along to the runtime.
+	Location(name string) (string, error)
+	// Exists checks whether a profile `name` is stored.
+	Exists(name string) (bool, error)
+	// List returns a summary of all the stored profiles, sorted by name.
+	List() ([]*Profile, error)
+	// Remove deletes the profile `name`.
+	Remove(name string) error
+}
+
+// NewProfileStore returns the ProfileStore of a data store.
+// Profiles are not namespaced, just like the seccomp filters they end up being loaded into.
+func NewProfileStore(dataStore string) (ProfileStore, error) {
+	st, err := store.New(filepath.Join(dataStore, profilesDirBasename), false, 0, 0)
+	if err != nil {
+		return nil, errors.Join(ErrServiceSeccomp, err)
+	}
+
+	return &profileStore{
+		safeStore: st,
+	}, nil
+}
+
+type profileStore struct {
+	safeStore store.Store
+}
+
+func (x *profileStore) Load(name string, data []byte) (err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(ErrServiceSeccomp, err)
+		}
+	}()
+
+	if err = validateName(name); err != nil {
+		return err
+	}
+
+	profile, err := Validate(data)
+	if err != nil {
+		return err
+	}
+
+	// Store a normalized version
+	data, err = json.MarshalIndent(profile, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	return x.safeStore.WithLock(func() error {
+		return x.safeStore.Set(data, profilesGroup, name)
+	})
+}
+
+func (x *profileStore) Get(name string) (profile *specs.LinuxSeccomp, err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(ErrServiceSeccomp, err)
+		}
+	}()
+
+	if err = validateName(name); err != nil {
+		return nil, err
+	}
+
+	err = x.safeStore.WithLock(func() error {
+		profile, err = x.get(name)
+		return err
+	})
+
+	return profile, err
+}
+
+func (x *profileStore) Location(name string) (location string, err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(ErrServiceSeccomp, err)
+		}
+	}()
+
+	if err = validateName(name); err != nil {
+		return "", err
+	}
+
+	err = x.safeStore.WithLock(func() error {
+		exists, err := x.safeStore.Exists(profilesGroup, name)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return fmt.Errorf("%w: no such seccomp profile %q", errs.ErrNotFound, name)
+		}
+
+		location, err = x.safeStore.Location(profilesGroup, name)
+		return err
+	})
+
+	return location, err
+}
+
+func (x *profileStore) Exists(name string) (exists bool, err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(ErrServiceSeccomp, err)
+		}
+	}()
+
+	if err = validateName(name); err != nil {
+		return false, err
+	}
+
+	err = x.safeStore.WithLock(func() error {
+		exists, err = x.safeStore.Exists(profilesGroup, name)
+		return err
+	})
+
+	return exists, err
+}
+
+func (x *profileStore) List() (list []*Profile, err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(ErrServiceSeccomp, err)
+		}
+	}()
+
+	err = x.safeStore.WithLock(func() error {
+		names, err := x.safeStore.List(profilesGroup)
+		if err != nil {
+			if errors.Is(err, errs.ErrNotFound) {
+				return nil
+			}
+
+			return err
+		}
+
+		for _, name := range names {
+			profile, err := x.get(name)
+			if err != nil {
+				return err
+			}
+
+			summary := &Profile{
+				Name:          name,
+				DefaultAction: profile.DefaultAction,
+				Architectures: profile.Architectures,
+			}
+			for _, syscall := range profile.Syscalls {
+				summary.Syscalls += len(syscall.Names)
+			}
+
+			list = append(list, summary)
+		}
+
+		return nil
+	})
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list, err
+}
+
+func (x *profileStore) Remove(name string) (err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(ErrServiceSeccomp, err)
+		}
+	}()
+
+	if err = validateName(name); err != nil {
+		return err
+	}
+
+	return x.safeStore.WithLock(func() error {
+		exists, err := x.safeStore.Exists(profilesGroup, name)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return fmt.Errorf("%w: no such seccomp profile %q", errs.ErrNotFound, name)
+		}
+
+		return x.safeStore.Delete(profilesGroup, name)
+	})
+}
+
+func (x *profileStore) get(name string) (*specs.LinuxSeccomp, error) {
+	data, err := x.safeStore.Get(profilesGroup, name)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return nil, fmt.Errorf("%w: no such seccomp profile %q", errs.ErrNotFound, name)
+		}
+
+		return nil, err
+	}
+
+	profile := &specs.LinuxSeccomp{}
+	if err = json.Unmarshal(data, profile); err != nil {
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+func validateName(name string) error {
+	if err := identifiers.Validate(name); err != nil {
+		return errors.Join(errs.ErrInvalidArgument, err)
+	}
+
+	// These designate a mode or the default profile, not a stored profile
+	if name == Unconfined || name == Trace || name == defaults.SeccompProfileName {
+		return fmt.Errorf("%w: seccomp profile name %q is reserved", errs.ErrInvalidArgument, name)
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package seccomp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/containerd/containerd/v2/core/containers"
+	"github.com/containerd/containerd/v2/pkg/namespaces"
+	"github.com/containerd/containerd/v2/pkg/oci"
+
+	"go.farcloser.world/containers/security/seccomp"
+	"go.farcloser.world/containers/specs"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+)
+
+// Default returns the built-in profile, as applied to a container with the default capabilities.
+func Default(ctx context.Context, namespace string) (*specs.LinuxSeccomp, error) {
+	spec, err := oci.GenerateSpec(namespaces.WithNamespace(ctx, namespace), nil, &containers.Container{ID: "default"})
+	if err != nil {
+		return nil, errors.Join(ErrServiceSeccomp, err)
+	}
+
+	seccomp.LoadDefaultProfile(spec)
+
+	return spec.Linux.Seccomp, nil
+}
+
+// IsTraced tells whether a container spec was generated with the Trace profile.
+func IsTraced(spec *specs.Spec) bool {
+	return spec.Linux != nil && spec.Linux.Seccomp != nil &&
+		spec.Linux.Seccomp.DefaultAction == specs.ActLog && len(spec.Linux.Seccomp.Syscalls) == 0
+}
+
+// GetSpecOptions returns the options applying the profile designated by `securityOpt`:
+// Unconfined, Trace, the name of a profile of the store, or the path of a profile file.
+func GetSpecOptions(securityOpt string, profiles ProfileStore) (oci.SpecOpts, error) {
+	switch securityOpt {
+	case "":
+		return nil, errors.Join(
+			ErrServiceSeccomp,
+			errs.ErrInvalidArgument,
+			errors.New("security-opt \"seccomp\" can't be set to the empty string"),
+		)
+	case Unconfined:
+		return nil, nil
+	case Trace:
+		return withTraceProfile, nil
+	}
+
+	location := securityOpt
+	if _, err := os.Stat(securityOpt); err != nil && !strings.ContainsRune(securityOpt, os.PathSeparator) {
+		exists, err := profiles.Exists(securityOpt)
+		if err != nil {
+			return nil, err
+		}
+
+		if !exists {
+			return nil, fmt.Errorf("%w: %w: %q is neither a stored seccomp profile nor a file",
+				ErrServiceSeccomp, errs.ErrNotFound, securityOpt)
+		}
+
+		if location, err = profiles.Location(securityOpt); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(_ context.Context, _ oci.Client, _ *containers.Container, s *specs.Spec) error {
+		return seccomp.LoadProfile(s, location)
+	}, nil
+}
+
+// withTraceProfile allows every syscall, but has the kernel log them, for the architectures of the default profile.
+func withTraceProfile(_ context.Context, _ oci.Client, _ *containers.Container, s *specs.Spec) error {
+	seccomp.LoadDefaultProfile(s)
+	s.Linux.Seccomp = &specs.LinuxSeccomp{
+		DefaultAction: specs.ActLog,
+		Architectures: s.Linux.Seccomp.Architectures,
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package seccomp_test
+
+import (
+	"errors"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+	"go.farcloser.world/lepton/leptonic/services/seccomp"
+	"go.farcloser.world/lepton/pkg/defaults"
+)
+
+const testProfile = `{
+	"defaultAction": "SCMP_ACT_ERRNO",
+	"architectures": ["SCMP_ARCH_X86_64"],
+	"syscalls": [
+		{"names": ["read", "write", "exit_group"], "action": "SCMP_ACT_ALLOW"},
+		{"names": ["personality"], "action": "SCMP_ACT_ALLOW", "args": [{"index": 0, "value": 0, "op": "SCMP_CMP_EQ"}]}
+	]
+}`
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	profile, err := seccomp.Validate([]byte(testProfile))
+	assert.NilError(t, err)
+	assert.Equal(t, len(profile.Syscalls), 2)
+
+	invalid := []string{
+		`not json`,
+		`{}`,
+		`{"defaultAction": "SCMP_ACT_NOPE"}`,
+		`{"defaultAction": "SCMP_ACT_ERRNO", "unknownField": true}`,
+		`{"defaultAction": "SCMP_ACT_ERRNO", "architectures": ["SCMP_ARCH_Z80"]}`,
+		`{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"action": "SCMP_ACT_ALLOW"}]}`,
+		`{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"names": ["read"], "action": "ALLOW"}]}`,
+		`{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": [{"names": ["read"], "action": "SCMP_ACT_ALLOW",
+			"args": [{"index": 0, "value": 0, "op": "SCMP_CMP_NOPE"}]}]}`,
+	}
+	for _, data := range invalid {
+		_, err = seccomp.Validate([]byte(data))
+		assert.Assert(t, errors.Is(err, errs.ErrInvalidArgument), data)
+	}
+}
+
+func TestProfileStore(t *testing.T) {
+	t.Parallel()
+
+	st, err := seccomp.NewProfileStore(t.TempDir())
+	assert.NilError(t, err)
+
+	list, err := st.List()
+	assert.NilError(t, err)
+	assert.Equal(t, len(list), 0)
+
+	err = st.Load("strict", []byte(`{}`))
+	assert.Assert(t, errors.Is(err, errs.ErrInvalidArgument))
+
+	err = st.Load(seccomp.Trace, []byte(testProfile))
+	assert.Assert(t, errors.Is(err, errs.ErrInvalidArgument))
+
+	err = st.Load(defaults.SeccompProfileName, []byte(testProfile))
+	assert.Assert(t, errors.Is(err, errs.ErrInvalidArgument))
+
+	assert.NilError(t, st.Load("strict", []byte(testProfile)))
+
+	profile, err := st.Get("strict")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, profile.Syscalls[0].Names, []string{"read", "write", "exit_group"})
+
+	location, err := st.Location("strict")
+	assert.NilError(t, err)
+	assert.Assert(t, location != "")
+
+	exists, err := st.Exists("strict")
+	assert.NilError(t, err)
+	assert.Assert(t, exists)
+
+	_, err = st.Get("missing")
+	assert.Assert(t, errors.Is(err, errs.ErrNotFound))
+
+	_, err = st.Location("missing")
+	assert.Assert(t, errors.Is(err, errs.ErrNotFound))
+
+	list, err = st.List()
+	assert.NilError(t, err)
+	assert.Equal(t, len(list), 1)
+	assert.Equal(t, list[0].Name, "strict")
+	assert.Equal(t, list[0].Syscalls, 4)
+
+	assert.NilError(t, st.Remove("strict"))
+	err = st.Remove("strict")
+	assert.Assert(t, errors.Is(err, errs.ErrNotFound))
+}
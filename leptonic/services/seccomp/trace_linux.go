@@ -0,0 +1,224 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package seccomp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/containerd/log"
+	"golang.org/x/sys/unix"
+
+	"go.farcloser.world/containers/specs"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+)
+
+const (
+	// auditLogPath is where auditd writes records, when it is running
+	auditLogPath = "/var/log/audit/audit.log"
+	// kmsgPath gets the audit records otherwise (rate-limited)
+	kmsgPath = "/dev/kmsg"
+
+	// retLog is the code of the records for syscalls allowed by SCMP_ACT_LOG (SECCOMP_RET_LOG)
+	retLog = 0x7ffc0000
+)
+
+// Matches both the audit.log format ("type=SECCOMP msg=audit(...)") and the kernel log format ("audit: type=1326
+// audit(...)")
+var recordRegexp = regexp.MustCompile(
+	`audit\((\d+)\.(\d+):\d+\):.*\barch=([0-9a-f]+)\s+syscall=(\d+)\b.*\bcode=0x([0-9a-f]+)`,
+)
+
+type syscallTable struct {
+	arch  specs.Arch
+	names map[int]string
+}
+
+// syscallSet collects the syscall numbers seen, per audit architecture.
+type syscallSet map[string]map[int]struct{}
+
+func (set syscallSet) add(arch string, nr int) {
+	if set[arch] == nil {
+		set[arch] = map[int]struct{}{}
+	}
+
+	set[arch][nr] = struct{}{}
+}
+
+// Generate returns a minimal profile, allowing only the syscalls logged between `since` and `until` by containers
+// running with the Trace profile.
+// Records are read from the audit log if auditd is running, and from the kernel log otherwise. The kernel rate-limits
+// the latter, so auditd gives more reliable results.
+// Note that records do not identify containers: the traces of all the containers running with the Trace profile in
+// that period are merged.
+func Generate(since, until time.Time) (*specs.LinuxSeccomp, error) {
+	set := syscallSet{}
+
+	var readErrs []error
+	read := 0
+	if f, err := os.Open(auditLogPath); err == nil {
+		err = parseRecords(f, since, until, set)
+		_ = f.Close()
+		if err != nil {
+			readErrs = append(readErrs, err)
+		} else {
+			read++
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		readErrs = append(readErrs, err)
+	}
+
+	if err := readKmsg(since, until, set); err != nil {
+		readErrs = append(readErrs, err)
+	} else {
+		read++
+	}
+
+	if read == 0 {
+		return nil, errors.Join(append([]error{ErrServiceSeccomp, errs.ErrSystemFailure}, readErrs...)...)
+	}
+
+	if len(set) == 0 {
+		return nil, fmt.Errorf("%w: %w: no traced syscalls found in the audit or kernel logs",
+			ErrServiceSeccomp, errs.ErrNotFound)
+	}
+
+	return buildProfile(set), nil
+}
+
+// readKmsg reads all the records currently in the kernel ring buffer, without waiting for new ones.
+func readKmsg(since, until time.Time, set syscallSet) error {
+	fd, err := unix.Open(kmsgPath, unix.O_RDONLY|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", kmsgPath, err)
+	}
+	defer unix.Close(fd)
+
+	buf := make([]byte, 8192)
+	for {
+		n, err := unix.Read(fd, buf)
+		switch {
+		case errors.Is(err, unix.EAGAIN):
+			return nil
+		case errors.Is(err, unix.EPIPE):
+			// Records were overwritten while reading, carry on with the next ones
+			continue
+		case err != nil:
+			return fmt.Errorf("failed to read %s: %w", kmsgPath, err)
+		}
+
+		parseRecord(string(buf[:n]), since, until, set)
+	}
+}
+
+func parseRecords(r io.Reader, since, until time.Time, set syscallSet) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parseRecord(scanner.Text(), since, until, set)
+	}
+
+	return scanner.Err()
+}
+
+func parseRecord(line string, since, until time.Time, set syscallSet) {
+	m := recordRegexp.FindStringSubmatch(line)
+	if m == nil {
+		return
+	}
+
+	code, err := strconv.ParseUint(m[5], 16, 32)
+	if err != nil || code != retLog {
+		return
+	}
+
+	sec, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return
+	}
+
+	msec, err := strconv.ParseInt(m[2], 10, 64)
+	if err != nil {
+		return
+	}
+
+	stamp := time.Unix(sec, msec*int64(time.Millisecond))
+	if stamp.Before(since) || stamp.After(until) {
+		return
+	}
+
+	nr, err := strconv.Atoi(m[4])
+	if err != nil {
+		return
+	}
+
+	set.add(m[3], nr)
+}
+
+func buildProfile(set syscallSet) *specs.LinuxSeccomp {
+	errnoRet := uint(unix.EPERM)
+	profile := &specs.LinuxSeccomp{
+		DefaultAction:   specs.ActErrno,
+		DefaultErrnoRet: &errnoRet,
+	}
+
+	var names []string
+	for auditArch, numbers := range set {
+		table, ok := syscallTables[auditArch]
+		if !ok {
+			log.L.Warnf("ignoring %d syscalls of unsupported audit architecture %s", len(numbers), auditArch)
+			continue
+		}
+
+		profile.Architectures = append(profile.Architectures, table.arch)
+		for nr := range numbers {
+			name, ok := table.names[nr]
+			if !ok {
+				log.L.Warnf("ignoring unknown syscall %d for architecture %s", nr, table.arch)
+				continue
+			}
+
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+
+	sort.Slice(profile.Architectures, func(i, j int) bool {
+		return profile.Architectures[i] < profile.Architectures[j]
+	})
+	sort.Strings(names)
+
+	if len(names) > 0 {
+		profile.Syscalls = []specs.LinuxSyscall{
+			{
+				Names:  names,
+				Action: specs.ActAllow,
+			},
+		}
+	}
+
+	return profile
+}
@@ -0,0 +1,69 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package seccomp
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/containers/specs"
+)
+
+func TestParseRecords(t *testing.T) {
+	t.Parallel()
+
+	records := strings.Join([]string{
+		// audit.log, before the window
+		`type=SECCOMP msg=audit(1700000000.123:40): auid=4294967295 uid=0 gid=0 ses=4294967295 pid=1234 ` +
+			`comm="sh" exe="/bin/busybox" sig=0 arch=c000003e syscall=0 compat=0 ip=0x7f code=0x7ffc0000`,
+		// audit.log
+		`type=SECCOMP msg=audit(1700000100.123:45): auid=4294967295 uid=0 gid=0 ses=4294967295 pid=1234 ` +
+			`comm="sh" exe="/bin/busybox" sig=0 arch=c000003e syscall=59 compat=0 ip=0x7f code=0x7ffc0000`,
+		// kernel log
+		`6,1234,5678901234,-;audit: type=1326 audit(1700000100.456:46): auid=4294967295 uid=0 gid=0 ` +
+			`ses=4294967295 pid=1235 comm="sh" exe="/bin/busybox" sig=0 arch=c000003e syscall=231 compat=0 ` +
+			`ip=0x7f code=0x7ffc0000`,
+		// compat syscall
+		`type=SECCOMP msg=audit(1700000100.500:47): pid=1236 comm="a.out" exe="/a.out" sig=0 arch=40000003 ` +
+			`syscall=4 compat=1 ip=0x7f code=0x7ffc0000`,
+		// killed, not logged
+		`type=SECCOMP msg=audit(1700000100.600:48): pid=1237 comm="sh" exe="/bin/busybox" sig=31 ` +
+			`arch=c000003e syscall=1 compat=0 ip=0x7f code=0x80000000`,
+		`type=SYSCALL msg=audit(1700000100.700:49): arch=c000003e syscall=2 success=yes exit=3`,
+		// after the window
+		`type=SECCOMP msg=audit(1700000300.000:50): pid=1234 comm="sh" exe="/bin/busybox" sig=0 arch=c000003e ` +
+			`syscall=3 compat=0 ip=0x7f code=0x7ffc0000`,
+	}, "\n")
+
+	set := syscallSet{}
+	err := parseRecords(strings.NewReader(records), time.Unix(1700000050, 0), time.Unix(1700000200, 0), set)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, set, syscallSet{
+		"c000003e": {59: {}, 231: {}},
+		"40000003": {4: {}},
+	})
+
+	profile := buildProfile(set)
+	assert.Equal(t, profile.DefaultAction, specs.ActErrno)
+	assert.DeepEqual(t, profile.Architectures, []specs.Arch{specs.ArchX86, specs.ArchX86_64})
+	assert.Equal(t, len(profile.Syscalls), 1)
+	assert.DeepEqual(t, profile.Syscalls[0].Names, []string{"execve", "exit_group", "write"})
+	assert.Equal(t, profile.Syscalls[0].Action, specs.ActAllow)
+}
@@ -130,3 +130,18 @@ func parseTimestamp(value string) (sec, nsec int64, err error) {
 	nsec = int64(float64(nsec) * math.Pow(float64(10), float64(9-len(n))))
 	return sec, nsec, nil
 }
+
+// ParseTimestamp parses value like GetTimestamp does, and returns the corresponding time.
+func ParseTimestamp(value string, reference time.Time) (time.Time, error) {
+	ts, err := GetTimestamp(value, reference)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	sec, nsec, err := parseTimestamp(ts)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q", errs.ErrInvalidArgument, value)
+	}
+
+	return time.Unix(sec, nsec), nil
+}
@@ -79,3 +79,26 @@ func TestGetTimestamp(t *testing.T) {
 		}
 	}
 }
+
+func TestParseTimestamp(t *testing.T) {
+	now := time.Now().In(time.UTC)
+	cases := []struct {
+		in          string
+		expected    time.Time
+		expectedErr bool
+	}{
+		{"2006-01-02T15:04:05.999999999Z", time.Date(2006, 1, 2, 15, 4, 5, 999999999, time.UTC), false},
+		{"1136073600.000000001", time.Unix(1136073600, 1), false},
+		{"1m", time.Unix(now.Add(-1*time.Minute).Unix(), 0), false},
+		{"invalid", time.Time{}, true},
+	}
+
+	for _, c := range cases {
+		o, err := ParseTimestamp(c.in, now)
+		if !o.Equal(c.expected) ||
+			(err == nil && c.expectedErr) ||
+			(err != nil && !c.expectedErr) {
+			t.Errorf("wrong value for '%s'. expected:'%s' got:'%s' with error: `%s`", c.in, c.expected, o, err)
+		}
+	}
+}
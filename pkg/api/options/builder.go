@@ -18,6 +18,7 @@ package options
 
 import (
 	"io"
+	"time"
 )
 
 // BuilderBuild specifies options for `(image/builder) build`.
@@ -39,7 +40,7 @@ type BuilderBuild struct {
 	NoCache bool
 	// Output is the output destination
 	Output string
-	// Progress Set type of progress output (auto, plain, tty). Use plain to show container output
+	// Progress Set type of progress output (auto, plain, tty, rawjson, quiet). Use plain to show container output
 	Progress string
 	// Secret file to expose to the build: id=mysecret,src=/local/secret
 	Secret []string
@@ -84,4 +85,80 @@ type BuilderPrune struct {
 	All bool
 	// Force will not prompt for confirmation.
 	Force bool
+	// Filters restrict the pruned records, e.g. `type=regular` or `until=24h`
+	Filters []string
+	// KeepDuration keeps the build cache records used more recently than this
+	KeepDuration time.Duration
+	// KeepStorage is the amount of storage (in bytes) to keep for the build cache
+	KeepStorage int64
+}
+
+// BuilderDiskUsage specifies options for `builder du`.
+type BuilderDiskUsage struct {
+	// BuildKitHost is the buildkit host
+	BuildKitHost string
+	// Verbose shows all the details of each record
+	Verbose bool
+	// Format the output using the given Go template, e.g, '{{json .}}', or json
+	Format string
+}
+
+// BuilderCreate specifies options for `builder create`.
+type BuilderCreate struct {
+	Name string
+	// BuildKitHost is the buildkit host of the builder
+	BuildKitHost string
+	// Platforms are used by default when building with this builder
+	Platforms []string
+	// WorkerLabels are constraints that the labels of the builder worker must satisfy
+	WorkerLabels map[string]string
+	// Use will select the builder as the default once created
+	Use bool
+}
+
+// BuilderList specifies options for `builder ls`.
+type BuilderList struct {
+	// Quiet only shows names
+	Quiet bool
+	// Format the output using the given Go template, e.g, '{{json .}}'
+	Format string
+}
+
+// BuilderInspect specifies options for `builder inspect`.
+type BuilderInspect struct {
+	NamesList []string
+	// Format the output using the given Go template, e.g, '{{json .}}'
+	Format string
+}
+
+// BuilderUse specifies options for `builder use`.
+type BuilderUse struct {
+	Name string
+}
+
+// BuilderRemove specifies options for `builder rm`.
+type BuilderRemove struct {
+	NamesList []string
+}
+
+// BuilderBake specifies options for `builder bake`.
+type BuilderBake struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	// BuildKitHost is the buildkit host
+	BuildKitHost string
+	// Files are the compose or bake files to read
+	Files []string
+	// Targets are the targets or groups to build
+	Targets []string
+	// NoCache disables cache for all targets
+	NoCache bool
+	// Pull always attempts to pull the base images of all targets
+	Pull bool
+	// Platform is used for the targets that do not set platforms
+	Platform []string
+	// Progress is the type of the combined progress output
+	Progress string
+	// Print shows the resolved definition instead of building
+	Print bool
 }
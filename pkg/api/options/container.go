@@ -452,6 +452,13 @@ type ContainerCp struct {
 	SrcPath string
 	// Follow symbolic links in SRC_PATH
 	FollowSymLink bool
+	// Archive preserves the ownership of copied files (mapped into the container user namespace).
+	// Otherwise, files copied into the container are owned by the container root user.
+	Archive bool
+	// Stdin is read as a tar archive when SrcPath is "-"
+	Stdin io.Reader
+	// Stdout receives a tar archive when DestPath is "-"
+	Stdout io.Writer
 }
 
 // ContainerStats specifies options for `stats`.
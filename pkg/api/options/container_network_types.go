@@ -30,6 +30,10 @@ type ContainerNetwork struct {
 	IPAddress string
 	// IP6Address set specific static IP6 address(es) to use
 	IP6Address string
+	// IPSticky requests the addresses first allocated to the container again when it is restarted
+	IPSticky bool
+	// NetworkAliases are the network-scoped aliases of the container, as "ALIAS" or "NETWORK:ALIAS"
+	NetworkAliases []string
 	// Hostname set container host name
 	Hostname string
 	// Domainname specifies the container's domain name
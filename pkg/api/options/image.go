@@ -66,6 +66,8 @@ type ImageConvert struct {
 
 	// Format the output using the given Go template, e.g, 'json'
 	Format string
+	// Progress output mode of fetching missing content (auto, tty, plain, json)
+	Progress string
 
 	// #region zstd flags
 	// Zstd convert legacy tar(.gz) layers to zstd. Should be used in conjunction with '--oci'
@@ -132,6 +134,8 @@ type ImagePush struct {
 
 	// Suppress verbose output
 	Quiet bool
+	// Progress output mode (auto, tty, plain, json)
+	Progress string
 	// AllowNondistributableArtifacts allow pushing non-distributable artifacts
 	AllowNondistributableArtifacts bool
 }
@@ -160,6 +164,8 @@ type ImagePull struct {
 	Mode string
 	// Suppress verbose output
 	Quiet bool
+	// Progress output mode (auto, tty, plain, json)
+	Progress string
 	// Flags to pass into remote snapshotters
 	RFlags RemoteSnapshotterFlags
 }
@@ -265,4 +271,6 @@ type ImageLoad struct {
 	AllPlatforms bool
 	// Quiet suppresses the load output.
 	Quiet bool
+	// Progress output mode (auto, tty, plain, json)
+	Progress string
 }
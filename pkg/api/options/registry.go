@@ -0,0 +1,35 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package options
+
+// RegistryMirrorAdd specifies options for `registry mirror add`.
+type RegistryMirrorAdd struct {
+	// Position of the mirror, starting at 1 for the mirror tried first, 0 appending it after the others
+	Priority int
+	// Skip verifying the TLS certificate of the mirror
+	SkipVerify bool
+	// CA certificate file of the mirror
+	CA string
+}
+
+// RegistryMirrorList specifies options for `registry mirror ls`.
+type RegistryMirrorList struct {
+	// Only display mirror urls
+	Quiet bool
+	// Format the output using the given go template
+	Format string
+}
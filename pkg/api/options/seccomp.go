@@ -0,0 +1,43 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package options
+
+// SeccompList specifies options for `seccomp ls`.
+type SeccompList struct {
+	// Only display profile names
+	Quiet bool
+	// Format the output using the given go template
+	Format string
+}
+
+// SeccompInspect specifies options for `seccomp inspect`.
+type SeccompInspect struct {
+	// Format the output using the given go template
+	Format string
+}
+
+// SeccompLoad specifies options for `seccomp load`.
+type SeccompLoad struct {
+	// Name of the profile, defaults to the base name of the file without its extension
+	Name string
+}
+
+// SeccompGenerate specifies options for `seccomp generate`.
+type SeccompGenerate struct {
+	// Store the generated profile under this name, instead of printing it
+	Name string
+}
@@ -35,6 +35,10 @@ type SystemEvents struct {
 	Format string
 	// Filter events based on given conditions
 	Filters []string
+	// Since replays recorded events created since the given timestamp
+	Since string
+	// Until stops streaming events once the given timestamp is reached
+	Until string
 }
 
 // SystemPrune specifies options for `system prune`.
@@ -49,3 +53,29 @@ type SystemPrune struct {
 	// NetworkDriversToKeep the network drivers which need to keep
 	NetworkDriversToKeep []string
 }
+
+// SystemMetricsServe specifies options for `system metrics serve`.
+type SystemMetricsServe struct {
+	// Addr is the address to listen on, e.g. "127.0.0.1:9323"
+	Addr string
+}
+
+// SystemEmulationList specifies options for `system emulation ls`.
+type SystemEmulationList struct {
+	// Quiet only displays handler names
+	Quiet bool
+	// Format the output using the given Go template, e.g, '{{json .}}'
+	Format string
+}
+
+// SystemEmulationInstall specifies options for `system emulation install`.
+type SystemEmulationInstall struct {
+	// Platforms to register an emulator for, e.g. "linux/arm64"
+	Platforms []string
+}
+
+// SystemEmulationUninstall specifies options for `system emulation uninstall`.
+type SystemEmulationUninstall struct {
+	// Platforms to unregister the emulators of, e.g. "linux/arm64"
+	Platforms []string
+}
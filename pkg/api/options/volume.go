@@ -16,10 +16,18 @@
 
 package options
 
+import "io"
+
 // VolumeCreate specifies options for `volume create`.
 type VolumeCreate struct {
 	Name   string
 	Labels map[string]string
+	// Options are the `local` driver options (type, device, o) describing a filesystem to mount on the volume
+	Options map[string]string
+	// From is the name of a volume to clone, labels and content included
+	From string
+	// PauseUsers pauses the running containers using the cloned volume, instead of refusing to clone it
+	PauseUsers bool
 }
 
 // VolumeInspect specifies options for `volume inspect`.
@@ -49,6 +57,26 @@ type VolumePrune struct {
 	All bool
 }
 
+// VolumeExport specifies options for `volume export`.
+type VolumeExport struct {
+	// Stdout is where the archive is written
+	Stdout io.Writer
+	// Compression of the archive (none, gzip, zstd)
+	Compression string
+	// PauseUsers pauses the running containers using the volume, instead of refusing to export it
+	PauseUsers bool
+}
+
+// VolumeImport specifies options for `volume import`.
+type VolumeImport struct {
+	// Stdin is where the archive is read from. Compression is detected.
+	Stdin io.Reader
+	// PauseUsers pauses the running containers using the volume, instead of refusing to import into it
+	PauseUsers bool
+	// Options of the volume created by the import, instead of the archived size
+	Options map[string]string
+}
+
 // VolumeRemove specifies options for `volume rm`.
 type VolumeRemove struct {
 	NamesList []string
@@ -0,0 +1,331 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package bake loads build definitions made of many targets, from compose files or from JSON and HCL bake files,
+// and resolves them into individual builds.
+// Bake files support target groups, inheritance, variables, and matrix expansion of targets.
+package bake
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+)
+
+// DefaultGroup is built when no target is specified.
+const DefaultGroup = "default"
+
+// ErrBake will wrap all errors here
+var ErrBake = errors.New("bake error")
+
+// DefaultFiles are the files looked up in the current directory when none is specified.
+// All the existing ones are loaded, later files overriding earlier ones.
+var DefaultFiles = []string{
+	"compose.yaml",
+	"compose.yml",
+	"docker-compose.yml",
+	"docker-compose.yaml",
+	"docker-bake.json",
+	"docker-bake.override.json",
+	"docker-bake.hcl",
+	"docker-bake.override.hcl",
+}
+
+var targetNameRe = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// Definition is a set of targets and groups.
+type Definition struct {
+	Groups  map[string]*Group  `json:"group,omitempty"`
+	Targets map[string]*Target `json:"target"`
+
+	// matrix maps a matrix target name to the names of the targets it expands to
+	matrix map[string][]string
+}
+
+// Group is a named list of targets (or other groups) built together.
+type Group struct {
+	Targets []string `json:"targets" hcl:"targets"`
+}
+
+// Target describes a single build.
+// Unset fields are nil, so that they can be inherited.
+type Target struct {
+	Name string `json:"-"`
+
+	Inherits   []string          `json:"inherits,omitempty"   hcl:"inherits,optional"`
+	Context    *string           `json:"context,omitempty"    hcl:"context,optional"`
+	Dockerfile *string           `json:"dockerfile,omitempty" hcl:"dockerfile,optional"`
+	Target     *string           `json:"target,omitempty"     hcl:"target,optional"`
+	Args       map[string]string `json:"args,omitempty"       hcl:"args,optional"`
+	Labels     map[string]string `json:"labels,omitempty"     hcl:"labels,optional"`
+	Contexts   map[string]string `json:"contexts,omitempty"   hcl:"contexts,optional"`
+	Tags       []string          `json:"tags,omitempty"       hcl:"tags,optional"`
+	Platforms  []string          `json:"platforms,omitempty"  hcl:"platforms,optional"`
+	CacheFrom  []string          `json:"cache-from,omitempty" hcl:"cache-from,optional"`
+	CacheTo    []string          `json:"cache-to,omitempty"   hcl:"cache-to,optional"`
+	Secrets    []string          `json:"secret,omitempty"     hcl:"secret,optional"`
+	SSH        []string          `json:"ssh,omitempty"        hcl:"ssh,optional"`
+	Outputs    []string          `json:"output,omitempty"     hcl:"output,optional"`
+	Network    *string           `json:"network,omitempty"    hcl:"network,optional"`
+	Pull       *bool             `json:"pull,omitempty"       hcl:"pull,optional"`
+	NoCache    *bool             `json:"no-cache,omitempty"   hcl:"no-cache,optional"`
+}
+
+// Load reads and merges the definitions in files.
+// Files named *.json or *.hcl are bake files, anything else is read as a compose file.
+// If files is empty, DefaultFiles are looked up in the current directory.
+func Load(files []string) (def *Definition, err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(ErrBake, err)
+		}
+	}()
+
+	if len(files) == 0 {
+		for _, file := range DefaultFiles {
+			if _, err := os.Stat(file); err == nil {
+				files = append(files, file)
+			}
+		}
+
+		if len(files) == 0 {
+			return nil, fmt.Errorf("%w: no compose or bake file found", errs.ErrNotFound)
+		}
+	}
+
+	var composeFiles, bakeFiles []string
+	for _, file := range files {
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".json", ".hcl":
+			bakeFiles = append(bakeFiles, file)
+		default:
+			composeFiles = append(composeFiles, file)
+		}
+	}
+
+	def = &Definition{
+		Groups:  map[string]*Group{},
+		Targets: map[string]*Target{},
+		matrix:  map[string][]string{},
+	}
+
+	if len(composeFiles) > 0 {
+		if err = loadCompose(def, composeFiles); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(bakeFiles) > 0 {
+		if err = loadHCL(def, bakeFiles); err != nil {
+			return nil, err
+		}
+	}
+
+	return def, nil
+}
+
+// Resolve returns the targets to build for names, which may be targets, groups, or matrix targets.
+// Inheritance is applied, and the returned targets are ready to be built.
+// If names is empty, DefaultGroup (or a target with that name) is built.
+func (def *Definition) Resolve(names []string) (targets []*Target, err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(ErrBake, err)
+		}
+	}()
+
+	if len(names) == 0 {
+		names = []string{DefaultGroup}
+	}
+
+	var expanded []string
+	for _, name := range names {
+		if expanded, err = def.expand(name, expanded, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range expanded {
+		target, err := def.inherit(name, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		target.Inherits = nil
+		targets = append(targets, target)
+	}
+
+	return targets, nil
+}
+
+// expand appends the names of the targets designated by name to res, recursing into groups.
+func (def *Definition) expand(name string, res, visiting []string) ([]string, error) {
+	if slices.Contains(visiting, name) {
+		return nil, fmt.Errorf("%w: group %q is part of a cycle", errs.ErrInvalidArgument, name)
+	}
+
+	if group, ok := def.Groups[name]; ok {
+		var err error
+		for _, member := range group.Targets {
+			if res, err = def.expand(member, res, append(visiting, name)); err != nil {
+				return nil, err
+			}
+		}
+
+		return res, nil
+	}
+
+	members := []string{name}
+	if generated, ok := def.matrix[name]; ok {
+		members = generated
+	} else if _, ok := def.Targets[name]; !ok {
+		return nil, fmt.Errorf("%w: no such target or group %q", errs.ErrNotFound, name)
+	}
+
+	for _, member := range members {
+		if !slices.Contains(res, member) {
+			res = append(res, member)
+		}
+	}
+
+	return res, nil
+}
+
+// inherit returns a copy of the target name, with the fields of the targets it inherits from filled in.
+func (def *Definition) inherit(name string, visiting []string) (*Target, error) {
+	if slices.Contains(visiting, name) {
+		return nil, fmt.Errorf("%w: target %q inherits from itself", errs.ErrInvalidArgument, name)
+	}
+
+	target, ok := def.Targets[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: no such target %q", errs.ErrNotFound, name)
+	}
+
+	res := &Target{Name: name}
+	for _, parentName := range target.Inherits {
+		parent, err := def.inherit(parentName, append(visiting, name))
+		if err != nil {
+			return nil, err
+		}
+
+		res.merge(parent)
+	}
+
+	res.merge(target)
+
+	return res, nil
+}
+
+// addTarget adds target to the definition, merging it over an existing target of the same name.
+func (def *Definition) addTarget(target *Target) error {
+	if !targetNameRe.MatchString(target.Name) {
+		return fmt.Errorf("%w: invalid target name %q", errs.ErrInvalidArgument, target.Name)
+	}
+
+	if existing, ok := def.Targets[target.Name]; ok {
+		existing.merge(target)
+		return nil
+	}
+
+	def.Targets[target.Name] = target
+
+	return nil
+}
+
+// merge sets the fields of other over the ones of target. Maps are merged key by key.
+func (target *Target) merge(other *Target) {
+	if other.Inherits != nil {
+		target.Inherits = other.Inherits
+	}
+
+	if other.Context != nil {
+		target.Context = other.Context
+	}
+
+	if other.Dockerfile != nil {
+		target.Dockerfile = other.Dockerfile
+	}
+
+	if other.Target != nil {
+		target.Target = other.Target
+	}
+
+	target.Args = mergeMap(target.Args, other.Args)
+	target.Labels = mergeMap(target.Labels, other.Labels)
+	target.Contexts = mergeMap(target.Contexts, other.Contexts)
+
+	if other.Tags != nil {
+		target.Tags = other.Tags
+	}
+
+	if other.Platforms != nil {
+		target.Platforms = other.Platforms
+	}
+
+	if other.CacheFrom != nil {
+		target.CacheFrom = other.CacheFrom
+	}
+
+	if other.CacheTo != nil {
+		target.CacheTo = other.CacheTo
+	}
+
+	if other.Secrets != nil {
+		target.Secrets = other.Secrets
+	}
+
+	if other.SSH != nil {
+		target.SSH = other.SSH
+	}
+
+	if other.Outputs != nil {
+		target.Outputs = other.Outputs
+	}
+
+	if other.Network != nil {
+		target.Network = other.Network
+	}
+
+	if other.Pull != nil {
+		target.Pull = other.Pull
+	}
+
+	if other.NoCache != nil {
+		target.NoCache = other.NoCache
+	}
+}
+
+func mergeMap(dst, src map[string]string) map[string]string {
+	if src == nil {
+		return dst
+	}
+
+	if dst == nil {
+		dst = map[string]string{}
+	}
+
+	maps.Copy(dst, src)
+
+	return dst
+}
@@ -0,0 +1,230 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bake
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	assert.NilError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func targetNames(targets []*Target) []string {
+	names := []string{}
+	for _, target := range targets {
+		names = append(names, target.Name)
+	}
+
+	return names
+}
+
+func TestLoadHCL(t *testing.T) {
+	t.Setenv("BAKE_TEST_TAG", "v1")
+
+	file := writeFile(t, t.TempDir(), "docker-bake.hcl", `
+variable "BAKE_TEST_TAG" {
+  default = "latest"
+}
+
+variable "REGISTRY" {
+  default = "example.com/${lower(ORG)}"
+}
+
+variable "ORG" {
+  default = "Acme"
+}
+
+group "default" {
+  targets = ["app", "workers"]
+}
+
+group "all" {
+  targets = ["default", "db"]
+}
+
+target "base" {
+  dockerfile = "Dockerfile.base"
+  args = {
+    GO_VERSION = "1.24"
+  }
+  platforms = ["linux/amd64"]
+}
+
+target "app" {
+  inherits = ["base"]
+  context = "app"
+  tags = ["${REGISTRY}/app:${BAKE_TEST_TAG}"]
+  args = {
+    MODE = "release"
+  }
+}
+
+target "workers" {
+  inherits = ["base"]
+  name = "worker-${kind}-${arch}"
+  matrix = {
+    kind = ["cpu", "gpu"]
+    arch = ["amd64", "arm64"]
+  }
+  platforms = ["linux/${arch}"]
+  args = {
+    KIND = kind
+  }
+}
+
+target "db" {
+  context = "db"
+}
+`)
+
+	def, err := Load([]string{file})
+	assert.NilError(t, err)
+
+	targets, err := def.Resolve(nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, targetNames(targets), []string{
+		"app", "worker-cpu-amd64", "worker-gpu-amd64", "worker-cpu-arm64", "worker-gpu-arm64",
+	})
+
+	app := targets[0]
+	assert.Equal(t, *app.Context, "app")
+	assert.Equal(t, *app.Dockerfile, "Dockerfile.base")
+	assert.DeepEqual(t, app.Tags, []string{"example.com/acme/app:v1"})
+	assert.DeepEqual(t, app.Args, map[string]string{"GO_VERSION": "1.24", "MODE": "release"})
+	assert.DeepEqual(t, app.Platforms, []string{"linux/amd64"})
+	assert.Assert(t, app.Inherits == nil)
+
+	worker := targets[4]
+	assert.DeepEqual(t, worker.Platforms, []string{"linux/arm64"})
+	assert.DeepEqual(t, worker.Args, map[string]string{"GO_VERSION": "1.24", "KIND": "gpu"})
+
+	targets, err = def.Resolve([]string{"all", "app"})
+	assert.NilError(t, err)
+	assert.Equal(t, len(targets), 6)
+	assert.Equal(t, targets[5].Name, "db")
+
+	targets, err = def.Resolve([]string{"worker-gpu-arm64"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, targetNames(targets), []string{"worker-gpu-arm64"})
+
+	_, err = def.Resolve([]string{"missing"})
+	assert.Assert(t, errors.Is(err, errs.ErrNotFound))
+}
+
+func TestLoadJSON(t *testing.T) {
+	dir := t.TempDir()
+	file := writeFile(t, dir, "docker-bake.json", `{
+  "variable": {
+    "TAG": {"default": "dev"}
+  },
+  "target": {
+    "default": {
+      "context": ".",
+      "tags": ["app:${TAG}"],
+      "no-cache": true
+    }
+  }
+}`)
+	override := writeFile(t, dir, "docker-bake.override.hcl", `
+target "default" {
+  tags = ["app:override"]
+}
+`)
+
+	def, err := Load([]string{file, override})
+	assert.NilError(t, err)
+
+	targets, err := def.Resolve(nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(targets), 1)
+	assert.DeepEqual(t, targets[0].Tags, []string{"app:override"})
+	assert.Equal(t, *targets[0].NoCache, true)
+}
+
+func TestResolveCycles(t *testing.T) {
+	file := writeFile(t, t.TempDir(), "docker-bake.hcl", `
+group "a" {
+  targets = ["b"]
+}
+
+group "b" {
+  targets = ["a"]
+}
+
+target "x" {
+  inherits = ["y"]
+}
+
+target "y" {
+  inherits = ["x"]
+}
+`)
+
+	def, err := Load([]string{file})
+	assert.NilError(t, err)
+
+	_, err = def.Resolve([]string{"a"})
+	assert.Assert(t, errors.Is(err, errs.ErrInvalidArgument))
+
+	_, err = def.Resolve([]string{"x"})
+	assert.Assert(t, errors.Is(err, errs.ErrInvalidArgument))
+}
+
+func TestLoadCompose(t *testing.T) {
+	dir := t.TempDir()
+	file := writeFile(t, dir, "compose.yaml", `
+name: bake
+services:
+  web:
+    image: example.com/web:1
+    build:
+      context: ./web
+      args:
+        FOO: bar
+      target: prod
+  api:
+    build: ./api
+  db:
+    image: postgres
+`)
+
+	def, err := Load([]string{file})
+	assert.NilError(t, err)
+
+	targets, err := def.Resolve(nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, targetNames(targets), []string{"api", "web"})
+
+	assert.Equal(t, *targets[0].Context, filepath.Join(dir, "api"))
+	assert.DeepEqual(t, targets[0].Tags, []string{"bake-api"})
+	assert.DeepEqual(t, targets[1].Tags, []string{"example.com/web:1"})
+	assert.DeepEqual(t, targets[1].Args, map[string]string{"FOO": "bar"})
+	assert.Equal(t, *targets[1].Target, "prod")
+}
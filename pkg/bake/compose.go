@@ -0,0 +1,164 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bake
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	composecli "github.com/compose-spec/compose-go/v2/cli"
+	"github.com/compose-spec/compose-go/v2/types"
+
+	"go.farcloser.world/lepton/pkg/composer/serviceparser"
+)
+
+// loadCompose adds a target for each service of the compose project that has a `build` section.
+// The services are also the members of the default group.
+func loadCompose(def *Definition, files []string) error {
+	projectOptions, err := composecli.NewProjectOptions(files,
+		composecli.WithOsEnv,
+		composecli.WithDotEnv,
+		composecli.WithConfigFileEnv,
+	)
+	if err != nil {
+		return err
+	}
+
+	project, err := projectOptions.LoadProject(context.TODO())
+	if err != nil {
+		return err
+	}
+
+	group := &Group{}
+	for _, name := range slices.Sorted(maps.Keys(project.Services)) {
+		svc := project.Services[name]
+		if svc.Build == nil {
+			continue
+		}
+
+		target, err := composeTarget(project, &svc)
+		if err != nil {
+			return err
+		}
+
+		if err = def.addTarget(target); err != nil {
+			return err
+		}
+
+		group.Targets = append(group.Targets, target.Name)
+	}
+
+	if len(group.Targets) > 0 {
+		def.Groups[DefaultGroup] = group
+	}
+
+	return nil
+}
+
+func composeTarget(project *types.Project, svc *types.ServiceConfig) (*Target, error) {
+	build := svc.Build
+
+	image := svc.Image
+	if image == "" {
+		image = serviceparser.DefaultImageName(project.Name, svc.Name)
+	}
+
+	target := &Target{
+		Name:      svc.Name,
+		Context:   stringPtr(build.Context),
+		Tags:      append([]string{image}, build.Tags...),
+		Platforms: build.Platforms,
+		CacheFrom: build.CacheFrom,
+		CacheTo:   build.CacheTo,
+		Labels:    build.Labels,
+		Contexts:  build.AdditionalContexts,
+	}
+
+	if build.Dockerfile != "" {
+		target.Dockerfile = stringPtr(build.Dockerfile)
+	}
+
+	if build.Target != "" {
+		target.Target = stringPtr(build.Target)
+	}
+
+	if build.Network != "" {
+		target.Network = stringPtr(build.Network)
+	}
+
+	if build.Pull {
+		target.Pull = &build.Pull
+	}
+
+	if build.NoCache {
+		target.NoCache = &build.NoCache
+	}
+
+	if len(target.Platforms) == 0 && svc.Platform != "" {
+		target.Platforms = []string{svc.Platform}
+	}
+
+	for key, value := range build.Args {
+		if value == nil {
+			continue
+		}
+
+		if target.Args == nil {
+			target.Args = map[string]string{}
+		}
+
+		target.Args[key] = *value
+	}
+
+	for _, key := range build.SSH {
+		ssh := key.ID
+		if key.Path != "" {
+			ssh += "=" + key.Path
+		}
+
+		target.SSH = append(target.SSH, ssh)
+	}
+
+	for _, ref := range build.Secrets {
+		secret, ok := project.Secrets[ref.Source]
+		if !ok {
+			return nil, fmt.Errorf("build of service %q: secret %q is undefined", svc.Name, ref.Source)
+		}
+
+		id := ref.Source
+		if ref.Target != "" {
+			id = ref.Target
+		}
+
+		switch {
+		case secret.File != "":
+			target.Secrets = append(target.Secrets, "id="+id+",src="+secret.File)
+		case secret.Environment != "":
+			target.Secrets = append(target.Secrets, "id="+id+",env="+secret.Environment)
+		default:
+			return nil, fmt.Errorf("build of service %q: secret %q has no file nor environment", svc.Name, ref.Source)
+		}
+	}
+
+	return target, nil
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,309 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package bake
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+)
+
+// bakeFile is the top level schema of bake files. The same schema is used for the JSON syntax.
+type bakeFile struct {
+	Variables []*variableBlock `hcl:"variable,block"`
+	Groups    []*groupBlock    `hcl:"group,block"`
+	Targets   []*targetBlock   `hcl:"target,block"`
+}
+
+type variableBlock struct {
+	Name    string         `hcl:"name,label"`
+	Default hcl.Expression `hcl:"default,optional"`
+}
+
+type groupBlock struct {
+	Name string   `hcl:"name,label"`
+	Body hcl.Body `hcl:",remain"`
+}
+
+type targetBlock struct {
+	Label string `hcl:"name,label"`
+	// Matrix is a map of lists. A target is generated for every combination of values.
+	Matrix hcl.Expression `hcl:"matrix,optional"`
+	// Name of the generated targets. Required with a matrix, it can reference the matrix keys.
+	Name hcl.Expression `hcl:"name,optional"`
+	Body hcl.Body       `hcl:",remain"`
+}
+
+// functions are available to expressions of bake files.
+var functions = map[string]function.Function{
+	"coalesce":   stdlib.CoalesceFunc,
+	"concat":     stdlib.ConcatFunc,
+	"format":     stdlib.FormatFunc,
+	"join":       stdlib.JoinFunc,
+	"lower":      stdlib.LowerFunc,
+	"replace":    stdlib.ReplaceFunc,
+	"split":      stdlib.SplitFunc,
+	"trimprefix": stdlib.TrimPrefixFunc,
+	"trimsuffix": stdlib.TrimSuffixFunc,
+	"upper":      stdlib.UpperFunc,
+}
+
+// loadHCL adds the groups and targets of bake files to def.
+// Variables are shared across all files. Their default value can be overridden by the environment variable of the
+// same name.
+func loadHCL(def *Definition, files []string) error {
+	parser := hclparse.NewParser()
+
+	var contents []*bakeFile
+	for _, file := range files {
+		var hclFile *hcl.File
+		var diags hcl.Diagnostics
+		if strings.ToLower(filepath.Ext(file)) == ".json" {
+			hclFile, diags = parser.ParseJSONFile(file)
+		} else {
+			hclFile, diags = parser.ParseHCLFile(file)
+		}
+
+		if diags.HasErrors() {
+			return errors.Join(errs.ErrInvalidArgument, diags)
+		}
+
+		content := &bakeFile{}
+		if diags = gohcl.DecodeBody(hclFile.Body, nil, content); diags.HasErrors() {
+			return errors.Join(errs.ErrInvalidArgument, diags)
+		}
+
+		contents = append(contents, content)
+	}
+
+	var variables []*variableBlock
+	for _, content := range contents {
+		variables = append(variables, content.Variables...)
+	}
+
+	evalCtx, err := evalVariables(variables)
+	if err != nil {
+		return err
+	}
+
+	for _, content := range contents {
+		for _, block := range content.Groups {
+			group := &Group{}
+			if diags := gohcl.DecodeBody(block.Body, evalCtx, group); diags.HasErrors() {
+				return errors.Join(errs.ErrInvalidArgument, diags)
+			}
+
+			def.Groups[block.Name] = group
+		}
+
+		for _, block := range content.Targets {
+			if err = decodeTarget(def, block, evalCtx); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// evalVariables returns the evaluation context of bake files.
+// Variables may reference each other, so they are evaluated until no more can be.
+func evalVariables(variables []*variableBlock) (*hcl.EvalContext, error) {
+	evalCtx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{},
+		Functions: functions,
+	}
+
+	pending := slices.Clone(variables)
+	for len(pending) > 0 {
+		var next []*variableBlock
+		for _, variable := range pending {
+			if !isResolvable(variable.Default, evalCtx) {
+				next = append(next, variable)
+				continue
+			}
+
+			value, err := evalVariable(variable, evalCtx)
+			if err != nil {
+				return nil, err
+			}
+
+			evalCtx.Variables[variable.Name] = value
+		}
+
+		if len(next) == len(pending) {
+			return nil, fmt.Errorf("%w: variable %q references an undefined variable, or a cycle",
+				errs.ErrInvalidArgument, next[0].Name)
+		}
+
+		pending = next
+	}
+
+	return evalCtx, nil
+}
+
+func evalVariable(variable *variableBlock, evalCtx *hcl.EvalContext) (cty.Value, error) {
+	value, diags := variable.Default.Value(evalCtx)
+	if diags.HasErrors() {
+		return cty.NilVal, errors.Join(errs.ErrInvalidArgument, diags)
+	}
+
+	env, ok := os.LookupEnv(variable.Name)
+	if !ok {
+		if value.IsNull() {
+			return cty.StringVal(""), nil
+		}
+
+		return value, nil
+	}
+
+	override := cty.StringVal(env)
+	if value.IsNull() || value.Type() == cty.String {
+		return override, nil
+	}
+
+	// Environment values are converted to the type of the default, e.g. "true" to a bool
+	converted, err := convert.Convert(override, value.Type())
+	if err != nil {
+		return cty.NilVal, fmt.Errorf("%w: variable %q: %w", errs.ErrInvalidArgument, variable.Name, err)
+	}
+
+	return converted, nil
+}
+
+func isResolvable(expr hcl.Expression, evalCtx *hcl.EvalContext) bool {
+	for _, traversal := range expr.Variables() {
+		if _, ok := evalCtx.Variables[traversal.RootName()]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// decodeTarget adds the target(s) of block to def, expanding its matrix if it has one.
+func decodeTarget(def *Definition, block *targetBlock, evalCtx *hcl.EvalContext) error {
+	matrix, diags := block.Matrix.Value(evalCtx)
+	if diags.HasErrors() {
+		return errors.Join(errs.ErrInvalidArgument, diags)
+	}
+
+	if matrix.IsNull() {
+		target := &Target{Name: block.Label}
+		if diags = gohcl.DecodeBody(block.Body, evalCtx, target); diags.HasErrors() {
+			return errors.Join(errs.ErrInvalidArgument, diags)
+		}
+
+		return def.addTarget(target)
+	}
+
+	combinations, err := expandMatrix(block.Label, matrix)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(combinations))
+	for _, combination := range combinations {
+		childCtx := evalCtx.NewChild()
+		childCtx.Variables = combination
+
+		name, diags := block.Name.Value(childCtx)
+		if diags.HasErrors() {
+			return errors.Join(errs.ErrInvalidArgument, diags)
+		}
+
+		if name.IsNull() || name.Type() != cty.String {
+			return fmt.Errorf("%w: matrix target %q needs a name, unique for each combination",
+				errs.ErrInvalidArgument, block.Label)
+		}
+
+		target := &Target{Name: name.AsString()}
+		if diags = gohcl.DecodeBody(block.Body, childCtx, target); diags.HasErrors() {
+			return errors.Join(errs.ErrInvalidArgument, diags)
+		}
+
+		if slices.Contains(names, target.Name) {
+			return fmt.Errorf("%w: matrix target %q generates %q more than once",
+				errs.ErrInvalidArgument, block.Label, target.Name)
+		}
+
+		if err = def.addTarget(target); err != nil {
+			return err
+		}
+
+		names = append(names, target.Name)
+	}
+
+	def.matrix[block.Label] = names
+
+	return nil
+}
+
+// expandMatrix returns all the combinations of the values of matrix, a map (or object) of lists.
+// Keys are iterated in lexical order, so that the expansion is stable.
+func expandMatrix(label string, matrix cty.Value) ([]map[string]cty.Value, error) {
+	if !matrix.Type().IsObjectType() && !matrix.Type().IsMapType() {
+		return nil, fmt.Errorf("%w: matrix of target %q must be a map of lists", errs.ErrInvalidArgument, label)
+	}
+
+	combinations := []map[string]cty.Value{{}}
+
+	matrixMap := matrix.AsValueMap()
+	keys := make([]string, 0, len(matrixMap))
+	for key := range matrixMap {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		values := matrixMap[key]
+		if !values.CanIterateElements() || values.Type().IsMapType() || values.Type().IsObjectType() {
+			return nil, fmt.Errorf("%w: matrix key %q of target %q must be a list", errs.ErrInvalidArgument, key, label)
+		}
+
+		var next []map[string]cty.Value
+		for _, combination := range combinations {
+			for _, value := range values.AsValueSlice() {
+				extended := make(map[string]cty.Value, len(combination)+1)
+				for k, v := range combination {
+					extended[k] = v
+				}
+
+				extended[key] = value
+				next = append(next, extended)
+			}
+		}
+
+		combinations = next
+	}
+
+	return combinations, nil
+}
@@ -33,6 +33,7 @@ import (
 
 	"go.farcloser.world/lepton/leptonic/emulation"
 	"go.farcloser.world/lepton/leptonic/services/containerd"
+	"go.farcloser.world/lepton/pkg/version"
 )
 
 func NewClient(
@@ -65,9 +66,12 @@ func NewClientWithPlatform(
 
 		if canExec, canExecErr := emulation.CanExecProbably(platformParsed); !canExec {
 			warn := fmt.Sprintf(
-				"Platform %q seems incompatible with the host platform %q. If you see \"exec format error\", see https://github.com/farcloser/lepton/blob/main/docs/multi-platform.md",
+				"Platform %q seems incompatible with the host platform %q. If you see \"exec format error\", "+
+					"register an emulator with `%s system emulation install --platform %s`",
 				platform,
 				platforms.DefaultString(),
+				version.RootName,
+				platform,
 			)
 			if canExecErr != nil {
 				log.L.WithError(canExecErr).Warn(warn)
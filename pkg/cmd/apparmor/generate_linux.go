@@ -0,0 +1,61 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package apparmor
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	containerd "github.com/containerd/containerd/v2/client"
+
+	"go.farcloser.world/lepton/leptonic/services/apparmor"
+	"go.farcloser.world/lepton/pkg/idutil/containerwalker"
+)
+
+// Generate prints the AppArmor profile generated from the configuration of a container.
+// The container does not need to have been run with --security-opt apparmor=generated.
+func Generate(ctx context.Context, client *containerd.Client, output io.Writer, req string) error {
+	var profile string
+	walker := &containerwalker.ContainerWalker{
+		Client: client,
+		OnFound: func(ctx context.Context, found containerwalker.Found) error {
+			if found.MatchCount > 1 {
+				return fmt.Errorf("multiple IDs found with provided prefix: %s", found.Req)
+			}
+
+			spec, err := found.Container.Spec(ctx)
+			if err != nil {
+				return err
+			}
+
+			profile, err = apparmor.Generate(apparmor.GeneratedProfileName(found.Container.ID()), spec)
+
+			return err
+		},
+	}
+
+	if n, err := walker.Walk(ctx, req); err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("no such container %s", req)
+	}
+
+	_, err := fmt.Fprint(output, profile)
+
+	return err
+}
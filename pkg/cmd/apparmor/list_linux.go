@@ -20,10 +20,8 @@ package apparmor
 // and cannot be loaded currently anyhow.
 /*
 import (
-	"bytes"
 	"fmt"
 	"io"
-	"text/tabwriter"
 
 	"go.farcloser.world/lepton/leptonic/services/apparmor"
 	"go.farcloser.world/lepton/pkg/api/options"
@@ -36,55 +34,19 @@ func List(output io.Writer, options *options.AppArmorList) error {
 		return err
 	}
 
-	switch options.Format {
-	case formatter.FormatNone, formatter.FormatTable, formatter.FormatWide:
-		output = tabwriter.NewWriter(output, 4, 8, 4, ' ', 0)
-		if !options.Quiet {
-			if _, err = fmt.Fprintln(output, "NAME\tMODE"); err != nil {
-				return err
-			}
-		}
-
+	if options.Quiet {
 		for _, profile := range profiles {
-			if options.Quiet {
-				_, _ = fmt.Fprintln(output, profile.Name)
-			} else {
-				_, _ = fmt.Fprintf(output, "%s\t%s\n", profile.Name, profile.Mode)
-			}
-		}
-
-		if f, ok := output.(formatter.Flusher); ok {
-			return f.Flush()
-		}
-
-	case formatter.FormatJSON:
-		toPrint, err := formatter.ToJSON(profiles, "", "   ")
-		if err != nil {
-			return err
-		}
-
-		if _, err = fmt.Fprint(output, toPrint); err != nil {
-			return err
-		}
-
-	default:
-		tmpl, err := formatter.ParseTemplate(options.Format)
-		if err != nil {
-			return err
-		}
-
-		for _, f := range profiles {
-			var buff bytes.Buffer
-			if err := tmpl.Execute(&buff, f); err != nil {
-				return err
-			}
-
-			if _, err = fmt.Fprintln(output, buff.String()); err != nil {
+			if _, err = fmt.Fprintln(output, profile.Name); err != nil {
 				return err
 			}
 		}
+
+		return nil
 	}
 
-	return nil
+	return formatter.Render(output, options.Format, profiles, []formatter.Column{
+		{Header: "NAME", Field: "Name"},
+		{Header: "MODE", Field: "Mode"},
+	})
 }
 */
@@ -0,0 +1,160 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+
+	"github.com/containerd/containerd/v2/client"
+	"github.com/moby/buildkit/util/progress/progressui"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+	"go.farcloser.world/lepton/leptonic/services/builder"
+	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/bake"
+	"go.farcloser.world/lepton/pkg/formatter"
+)
+
+// Bake builds the targets of compose or bake files concurrently.
+func Bake(ctx context.Context, cli *client.Client, globalOptions *options.Global, opts *options.BuilderBake) error {
+	def, err := bake.Load(opts.Files)
+	if err != nil {
+		return err
+	}
+
+	targets, err := def.Resolve(opts.Targets)
+	if err != nil {
+		return err
+	}
+
+	if opts.Print {
+		return printBake(opts, targets)
+	}
+
+	builds := make([]*options.BuilderBuild, 0, len(targets))
+	for _, target := range targets {
+		build, err := bakeBuild(target, opts)
+		if err != nil {
+			return err
+		}
+
+		builds = append(builds, build)
+	}
+
+	return builder.BuildAll(ctx, cli, globalOptions, builds, opts.Stderr, progressui.DisplayMode(opts.Progress))
+}
+
+// printBake shows the resolved targets, in the JSON bake file format.
+func printBake(opts *options.BuilderBake, targets []*bake.Target) error {
+	resolved := &bake.Definition{
+		Groups:  map[string]*bake.Group{bake.DefaultGroup: {}},
+		Targets: map[string]*bake.Target{},
+	}
+
+	for _, target := range targets {
+		resolved.Groups[bake.DefaultGroup].Targets = append(resolved.Groups[bake.DefaultGroup].Targets, target.Name)
+		resolved.Targets[target.Name] = target
+	}
+
+	toPrint, err := formatter.ToJSON(resolved, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprint(opts.Stdout, toPrint)
+
+	return err
+}
+
+// bakeBuild converts a resolved target into build options.
+func bakeBuild(target *bake.Target, opts *options.BuilderBake) (*options.BuilderBuild, error) {
+	build := &options.BuilderBuild{
+		Stdout:       opts.Stdout,
+		Stderr:       opts.Stderr,
+		BuildKitHost: opts.BuildKitHost,
+		BuildContext: ".",
+		Tag:          target.Tags,
+		Platform:     target.Platforms,
+		Secret:       target.Secrets,
+		SSH:          target.SSH,
+		CacheFrom:    target.CacheFrom,
+		CacheTo:      target.CacheTo,
+		NoCache:      opts.NoCache,
+		Progress:     opts.Progress,
+		Rm:           true,
+	}
+
+	if target.Context != nil {
+		build.BuildContext = *target.Context
+	}
+
+	// The Dockerfile of a target is relative to its context
+	if target.Dockerfile != nil {
+		build.File = *target.Dockerfile
+		if !filepath.IsAbs(build.File) {
+			build.File = filepath.Join(build.BuildContext, build.File)
+		}
+	}
+
+	if target.Target != nil {
+		build.Target = *target.Target
+	}
+
+	if target.Network != nil {
+		build.NetworkMode = *target.Network
+	}
+
+	if target.NoCache != nil && *target.NoCache {
+		build.NoCache = true
+	}
+
+	if opts.Pull {
+		build.Pull = &opts.Pull
+	} else if target.Pull != nil {
+		build.Pull = target.Pull
+	}
+
+	if len(build.Platform) == 0 {
+		build.Platform = opts.Platform
+	}
+
+	switch len(target.Outputs) {
+	case 0:
+	case 1:
+		build.Output = target.Outputs[0]
+	default:
+		return nil, fmt.Errorf("%w: target %q has more than one output", errs.ErrInvalidArgument, target.Name)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(target.Args)) {
+		build.BuildArgs = append(build.BuildArgs, key+"="+target.Args[key])
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(target.Labels)) {
+		build.Label = append(build.Label, key+"="+target.Labels[key])
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(target.Contexts)) {
+		build.ExtendedBuildContext = append(build.ExtendedBuildContext, key+"="+target.Contexts[key])
+	}
+
+	return build, nil
+}
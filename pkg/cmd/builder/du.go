@@ -0,0 +1,173 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"go.farcloser.world/core/units"
+
+	"go.farcloser.world/lepton/leptonic/buildkit"
+	"go.farcloser.world/lepton/leptonic/services/builder"
+	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/formatter"
+)
+
+// usageRecord adds the formatted columns of the build cache records.
+type usageRecord struct {
+	*buildkit.UsageInfo
+}
+
+// FormattedID marks the mutable records with "*".
+func (r usageRecord) FormattedID() string {
+	if r.Mutable {
+		return r.ID + "*"
+	}
+
+	return r.ID
+}
+
+func (r usageRecord) Reclaimable() bool {
+	return !r.InUse
+}
+
+func (r usageRecord) FormattedSize() string {
+	return units.BytesSize(float64(r.Size))
+}
+
+func (r usageRecord) LastUsed() string {
+	return lastUsed(r.UsageInfo)
+}
+
+func (r usageRecord) FormattedParents() string {
+	return strings.Join(r.Parents, ", ")
+}
+
+// DiskUsage shows the build cache records, and how much space they use.
+func DiskUsage(ctx context.Context, output io.Writer, _ *options.Global, opts *options.BuilderDiskUsage) error {
+	records, err := builder.DiskUsage(ctx, opts.BuildKitHost, nil)
+	if err != nil {
+		return err
+	}
+
+	isTable := opts.Format == formatter.FormatNone || opts.Format == formatter.FormatTable ||
+		opts.Format == formatter.FormatWide
+	if isTable && opts.Verbose {
+		if err = printVerboseUsage(output, records); err != nil {
+			return err
+		}
+
+		return printUsageSummary(output, records)
+	}
+
+	items := make([]usageRecord, len(records))
+	for i, record := range records {
+		items[i] = usageRecord{record}
+	}
+
+	err = formatter.Render(output, opts.Format, items, []formatter.Column{
+		{Header: "ID", Field: "FormattedID"},
+		{Header: "TYPE", Field: "RecordType"},
+		{Header: "RECLAIMABLE", Field: "Reclaimable"},
+		{Header: "SHARED", Field: "Shared"},
+		{Header: "SIZE", Field: "FormattedSize"},
+		{Header: "LAST USED", Field: "LastUsed"},
+		{Header: "USAGE COUNT", Field: "UsageCount", Wide: true},
+		{Header: "PARENTS", Field: "FormattedParents", Wide: true},
+		{Header: "DESCRIPTION", Field: "Description", Wide: true},
+	})
+	if err != nil || !isTable {
+		return err
+	}
+
+	return printUsageSummary(output, records)
+}
+
+func printVerboseUsage(output io.Writer, records []*buildkit.UsageInfo) error {
+	for _, record := range records {
+		w := tabwriter.NewWriter(output, 4, 8, 4, ' ', 0)
+		lines := []string{
+			"ID:\t" + record.ID,
+			"Parents:\t" + strings.Join(record.Parents, ", "),
+			"Created at:\t" + record.CreatedAt.String(),
+			fmt.Sprintf("Mutable:\t%t", record.Mutable),
+			fmt.Sprintf("Reclaimable:\t%t", !record.InUse),
+			fmt.Sprintf("Shared:\t%t", record.Shared),
+			"Size:\t" + units.BytesSize(float64(record.Size)),
+			"Description:\t" + record.Description,
+			fmt.Sprintf("Usage count:\t%d", record.UsageCount),
+			"Last used:\t" + lastUsed(record),
+			"Type:\t" + string(record.RecordType),
+		}
+
+		for _, line := range lines {
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintln(output); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func printUsageSummary(output io.Writer, records []*buildkit.UsageInfo) error {
+	var shared, private, reclaimable, total int64
+	for _, record := range records {
+		if record.Shared {
+			shared += record.Size
+		} else {
+			private += record.Size
+		}
+
+		if !record.InUse {
+			reclaimable += record.Size
+		}
+
+		total += record.Size
+	}
+
+	w := tabwriter.NewWriter(output, 4, 8, 4, ' ', 0)
+	if shared > 0 {
+		_, _ = fmt.Fprintf(w, "Shared:\t%s\n", units.BytesSize(float64(shared)))
+		_, _ = fmt.Fprintf(w, "Private:\t%s\n", units.BytesSize(float64(private)))
+	}
+
+	_, _ = fmt.Fprintf(w, "Reclaimable:\t%s\n", units.BytesSize(float64(reclaimable)))
+	_, _ = fmt.Fprintf(w, "Total:\t%s\n", units.BytesSize(float64(total)))
+
+	return w.Flush()
+}
+
+func lastUsed(record *buildkit.UsageInfo) string {
+	if record.LastUsedAt == nil {
+		return ""
+	}
+
+	return formatter.TimeSinceInHuman(*record.LastUsedAt)
+}
@@ -0,0 +1,284 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/containerd/log"
+
+	"go.farcloser.world/lepton/leptonic/buildkit"
+	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/clientutil"
+	"go.farcloser.world/lepton/pkg/formatter"
+)
+
+const (
+	statusRunning     = "running"
+	statusUnreachable = "unreachable"
+)
+
+// Store returns the builder instances store of a namespace.
+func Store(ns, dataRoot, address string) (buildkit.InstanceStore, error) {
+	dataStore, err := clientutil.DataStore(dataRoot, address)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildkit.NewInstanceStore(dataStore, ns)
+}
+
+type builderOutput struct {
+	buildkit.Instance
+	Current         bool     `json:"current"`
+	Status          string   `json:"status"`
+	WorkerPlatforms []string `json:"workerPlatforms,omitempty"`
+}
+
+// Create saves a new builder definition.
+func Create(ctx context.Context, output io.Writer, globalOptions *options.Global, opts *options.BuilderCreate) error {
+	instStore, err := Store(globalOptions.Namespace, globalOptions.DataRoot, globalOptions.Address)
+	if err != nil {
+		return err
+	}
+
+	if err = buildkit.PingBKDaemon(opts.BuildKitHost); err != nil {
+		log.G(ctx).WithError(err).Warnf("builder %q is not reachable at the moment", opts.Name)
+	}
+
+	err = instStore.Create(&buildkit.Instance{
+		Name:         opts.Name,
+		Host:         opts.BuildKitHost,
+		Platforms:    opts.Platforms,
+		WorkerLabels: opts.WorkerLabels,
+	})
+	if err != nil {
+		return err
+	}
+
+	if opts.Use {
+		if err = instStore.Use(opts.Name); err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintln(output, opts.Name)
+
+	return err
+}
+
+// List shows all builders of the namespace, including the default one, with their status and platforms.
+func List(ctx context.Context, output io.Writer, globalOptions *options.Global, opts *options.BuilderList) error {
+	instStore, err := Store(globalOptions.Namespace, globalOptions.DataRoot, globalOptions.Address)
+	if err != nil {
+		return err
+	}
+
+	instances, err := instStore.List()
+	if err != nil {
+		return err
+	}
+
+	if opts.Quiet {
+		names := append([]string{buildkit.DefaultInstanceName}, instanceNames(instances)...)
+		_, err = fmt.Fprintln(output, strings.Join(names, "\n"))
+		return err
+	}
+
+	current, err := instStore.Current()
+	if err != nil {
+		return err
+	}
+
+	result := []*builderOutput{
+		describe(ctx, globalOptions.Namespace, &buildkit.Instance{Name: buildkit.DefaultInstanceName}),
+	}
+	for _, inst := range instances {
+		result = append(result, describe(ctx, globalOptions.Namespace, inst))
+	}
+
+	for _, entry := range result {
+		entry.Current = entry.Name == current
+	}
+
+	return formatter.Render(output, opts.Format, result, []formatter.Column{
+		{Header: "NAME", Field: "FormattedName"},
+		{Header: "HOST", Field: "Host"},
+		{Header: "STATUS", Field: "Status"},
+		{Header: "PLATFORMS", Field: "FormattedPlatforms"},
+	})
+}
+
+// Inspect shows the definition and status of the named builders.
+func Inspect(ctx context.Context, output io.Writer, globalOptions *options.Global, opts *options.BuilderInspect) error {
+	instStore, err := Store(globalOptions.Namespace, globalOptions.DataRoot, globalOptions.Address)
+	if err != nil {
+		return err
+	}
+
+	current, err := instStore.Current()
+	if err != nil {
+		return err
+	}
+
+	result := []interface{}{}
+	warns := []error{}
+
+	for _, name := range opts.NamesList {
+		inst := &buildkit.Instance{Name: buildkit.DefaultInstanceName}
+		if name != buildkit.DefaultInstanceName {
+			if inst, err = instStore.Get(name); err != nil {
+				warns = append(warns, err)
+				continue
+			}
+		}
+
+		entry := describe(ctx, globalOptions.Namespace, inst)
+		entry.Current = entry.Name == current
+		result = append(result, entry)
+	}
+
+	if err = formatter.FormatSlice(opts.Format, output, result); err != nil {
+		return err
+	}
+
+	for _, warn := range warns {
+		log.G(ctx).Warn(warn)
+	}
+
+	if len(warns) != 0 {
+		return errors.New("some builders could not be inspected")
+	}
+
+	return nil
+}
+
+// Use selects the builder to use by default for the namespace.
+func Use(_ context.Context, globalOptions *options.Global, opts *options.BuilderUse) error {
+	instStore, err := Store(globalOptions.Namespace, globalOptions.DataRoot, globalOptions.Address)
+	if err != nil {
+		return err
+	}
+
+	return instStore.Use(opts.Name)
+}
+
+// Remove deletes builder definitions.
+func Remove(ctx context.Context, output io.Writer, globalOptions *options.Global, opts *options.BuilderRemove) error {
+	instStore, err := Store(globalOptions.Namespace, globalOptions.DataRoot, globalOptions.Address)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, name := range opts.NamesList {
+		if err = instStore.Remove(name); err != nil {
+			log.G(ctx).WithError(err).Errorf("failed to remove builder %q", name)
+			errs = append(errs, err)
+			continue
+		}
+
+		if _, err = fmt.Fprintln(output, name); err != nil {
+			return err
+		}
+	}
+
+	if len(errs) != 0 {
+		return fmt.Errorf("%d errors:\n%w", len(errs), errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// describe probes a builder for reachability and supported platforms.
+// The default builder address is resolved from the well-known BuildKit sockets of the namespace.
+func describe(ctx context.Context, namespace string, inst *buildkit.Instance) *builderOutput {
+	entry := &builderOutput{
+		Instance: *inst,
+		Status:   statusUnreachable,
+	}
+
+	if entry.Name == buildkit.DefaultInstanceName {
+		host, err := buildkit.FindBuildkitHost(namespace)
+		if err != nil {
+			log.G(ctx).WithError(err).Debug("no default builder")
+			return entry
+		}
+
+		entry.Host = host
+	}
+
+	if err := buildkit.PingBKDaemon(entry.Host); err != nil {
+		log.G(ctx).WithError(err).Debugf("builder %q is unreachable", entry.Name)
+		return entry
+	}
+
+	entry.Status = statusRunning
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	platforms, err := buildkit.GetWorkerPlatforms(ctx, entry.Host)
+	if err != nil {
+		log.G(ctx).WithError(err).Warnf("failed to list the platforms of builder %q", entry.Name)
+	}
+
+	entry.WorkerPlatforms = platforms
+
+	return entry
+}
+
+// FormattedName marks the current builder with "*".
+func (entry *builderOutput) FormattedName() string {
+	if entry.Current {
+		return entry.Name + " *"
+	}
+
+	return entry.Name
+}
+
+// FormattedPlatforms lists the default platforms of the builder first, marked with "*", followed by the other
+// platforms supported by its workers.
+func (entry *builderOutput) FormattedPlatforms() string {
+	res := make([]string, 0, len(entry.Platforms)+len(entry.WorkerPlatforms))
+	for _, p := range entry.Platforms {
+		res = append(res, p+"*")
+	}
+
+	for _, p := range entry.WorkerPlatforms {
+		if !slices.Contains(entry.Platforms, p) {
+			res = append(res, p)
+		}
+	}
+
+	return strings.Join(res, ", ")
+}
+
+func instanceNames(instances []*buildkit.Instance) []string {
+	names := make([]string, 0, len(instances))
+	for _, inst := range instances {
+		names = append(names, inst.Name)
+	}
+
+	return names
+}
@@ -20,16 +20,37 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"time"
 
 	"go.farcloser.world/core/units"
 
+	"go.farcloser.world/lepton/leptonic/errs"
 	"go.farcloser.world/lepton/leptonic/services/builder"
 	"go.farcloser.world/lepton/pkg/api/options"
 )
 
-// Prune will prune all build cache.
+// Prune will prune the build cache, within the limits set by the filters and keep options.
 func Prune(ctx context.Context, output io.Writer, _ *options.Global, opts *options.BuilderPrune) error {
-	result, err := builder.Prune(ctx, opts.Stderr, opts.BuildKitHost, opts.All)
+	filters, until, err := builder.ParseFilters(opts.Filters, time.Now())
+	if err != nil {
+		return err
+	}
+
+	keepDuration := opts.KeepDuration
+	if until != 0 {
+		if keepDuration != 0 {
+			return fmt.Errorf("%w: --filter until and --keep-duration cannot be used together", errs.ErrInvalidArgument)
+		}
+
+		keepDuration = until
+	}
+
+	result, err := builder.Prune(ctx, opts.BuildKitHost, &builder.PruneOptions{
+		All:          opts.All,
+		Filters:      filters,
+		KeepDuration: keepDuration,
+		KeepStorage:  opts.KeepStorage,
+	})
 	if err != nil {
 		return err
 	}
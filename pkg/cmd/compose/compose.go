@@ -28,7 +28,9 @@ import (
 	"go.farcloser.world/containers/reference"
 	"go.farcloser.world/containers/specs"
 
+	"go.farcloser.world/lepton/leptonic/services/namespace"
 	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/clientutil"
 	"go.farcloser.world/lepton/pkg/cmd/volume"
 	"go.farcloser.world/lepton/pkg/composer"
 	"go.farcloser.world/lepton/pkg/composer/serviceparser"
@@ -40,6 +42,7 @@ import (
 
 // New returns a new *composer.Composer.
 func New(
+	ctx context.Context,
 	client *containerd.Client,
 	globalOptions *options.Global,
 	opts *composer.Options,
@@ -49,11 +52,17 @@ func New(
 		return nil, err
 	}
 
+	dataStore, err := clientutil.DataStore(globalOptions.DataRoot, globalOptions.Address)
+	if err != nil {
+		return nil, err
+	}
+
 	cniEnv, err := netutil.NewCNIEnv(
 		globalOptions.CNIPath,
 		globalOptions.CNINetConfPath,
 		netutil.WithNamespace(globalOptions.Namespace),
-		netutil.WithDefaultNetwork(globalOptions.BridgeIP),
+		netutil.WithIPv6Pool(globalOptions.IPv6Pool, dataStore),
+		netutil.WithDefaultNetwork(globalOptions.BridgeIP, globalOptions.BridgeSubnet, globalOptions.BridgeIPv6),
 	)
 	if err != nil {
 		return nil, err
@@ -84,6 +93,12 @@ func New(
 		return false, nil
 	}
 
+	defaults, err := namespace.GetDefaults(ctx, client, globalOptions.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	opts.DefaultNetwork = defaults.Network
+
 	volStore, err := volume.Store(globalOptions.Namespace, globalOptions.DataRoot, globalOptions.Address)
 	if err != nil {
 		return nil, err
@@ -49,6 +49,7 @@ import (
 	"go.farcloser.world/lepton/pkg/cmd/volume"
 	"go.farcloser.world/lepton/pkg/containerutil"
 	"go.farcloser.world/lepton/pkg/dnsutil/hostsstore"
+	"go.farcloser.world/lepton/pkg/eventutil"
 	"go.farcloser.world/lepton/pkg/flagutil"
 	"go.farcloser.world/lepton/pkg/imgutil"
 	"go.farcloser.world/lepton/pkg/imgutil/load"
@@ -58,7 +59,9 @@ import (
 	"go.farcloser.world/lepton/pkg/logging"
 	"go.farcloser.world/lepton/pkg/maputil"
 	"go.farcloser.world/lepton/pkg/mountutil"
+	"go.farcloser.world/lepton/pkg/mountutil/volumestore"
 	"go.farcloser.world/lepton/pkg/namestore"
+	"go.farcloser.world/lepton/pkg/ocihook/hooksd"
 	"go.farcloser.world/lepton/pkg/platformutil"
 	"go.farcloser.world/lepton/pkg/rootlessutil"
 	"go.farcloser.world/lepton/pkg/strutil"
@@ -73,6 +76,10 @@ func Create(
 	netManager containerutil.NetworkOptionsManager,
 	opts *options.ContainerCreate,
 ) (containerd.Container, func(), error) {
+	if err := checkNamespaceQuotas(ctx, client, opts); err != nil {
+		return nil, nil, err
+	}
+
 	// Acquire an exclusive lock on the volume store until we are done to avoid being raced by any other
 	// volume operations (or any other operation involving volume manipulation)
 	volStore, err := volume.Store(opts.GOptions.Namespace, opts.GOptions.DataRoot, opts.GOptions.Address)
@@ -225,10 +232,15 @@ func Create(
 		specOpts = append(specOpts, oci.WithTTY)
 	}
 
-	var mountOpts []oci.SpecOpts
-	mountOpts, internalLabels.anonVolumes, internalLabels.mountPoints, err = generateMountOpts(
+	var (
+		mountOpts  []oci.SpecOpts
+		mountCOpts []containerd.NewContainerOpts
+	)
+	mountOpts, mountCOpts, internalLabels.anonVolumes, internalLabels.mountPoints, err = generateMountOpts(
 		ctx,
 		client,
+		id,
+		internalLabels.stateDir,
 		ensuredImage,
 		volStore,
 		opts,
@@ -237,6 +249,7 @@ func Create(
 		return nil, generateRemoveStateDirFunc(ctx, id, internalLabels), err
 	}
 	specOpts = append(specOpts, mountOpts...)
+	cOpts = append(cOpts, mountCOpts...)
 
 	// Always set internalLabels.logURI
 	// to support restart the container that run with "-it", like
@@ -261,6 +274,22 @@ func Create(
 		internalLabels.logConfig.Driver = "json-file"
 	}
 
+	var (
+		volumeNames    []string
+		volumeSubpaths []volumestore.Subpath
+	)
+	for _, mp := range internalLabels.mountPoints {
+		if mp.Type == "volume" && mp.Name != "" {
+			volumeNames = append(volumeNames, mp.Name)
+			if mp.Subpath != "" {
+				volumeSubpaths = append(volumeSubpaths, volumestore.Subpath{Name: mp.Name, Subpath: mp.Subpath})
+			}
+		}
+	}
+	if err = volumestore.CheckRestartPolicy(volStore, volumeNames, volumeSubpaths, opts.Restart); err != nil {
+		return nil, generateRemoveStateDirFunc(ctx, id, internalLabels), err
+	}
+
 	restartOpts, err := generateRestartOpts(ctx, client, opts.Restart, logConfig.LogURI, opts.InRun)
 	if err != nil {
 		return nil, generateRemoveStateDirFunc(ctx, id, internalLabels), err
@@ -310,6 +339,13 @@ func Create(
 	specOpts = append(specOpts, oci.WithEnv(envs))
 
 	internalLabels.loadNetOpts(netLabelOpts)
+	internalLabels.networkAliases, err = containerutil.ParseNetworkAliases(
+		netLabelOpts.NetworkAliases,
+		netLabelOpts.NetworkSlice,
+	)
+	if err != nil {
+		return nil, generateRemoveOrphanedDirsFunc(ctx, id, dataStore, internalLabels), err
+	}
 
 	// NOTE: OCI hooks are currently not supported on Windows so we skip setting them altogether.
 	// The OCI hooks we define (whose logic can be found in pkg/ocihook) primarily
@@ -383,6 +419,14 @@ func Create(
 	internalLabels.rm = containerutil.EncodeContainerRmOptLabel(opts.Rm)
 
 	internalLabels.blkioWeight = opts.BlkioWeight
+	internalLabels.cgroupParent = opts.CgroupParent
+	internalLabels.binds = opts.Volume
+	internalLabels.volumesFrom = opts.VolumesFrom
+	internalLabels.capAdd = opts.CapAdd
+	internalLabels.capDrop = opts.CapDrop
+	internalLabels.privileged = opts.Privileged
+	internalLabels.securityOpt = opts.SecurityOpt
+	internalLabels.init = opts.InitProcessFlag || opts.InitBinary != nil
 
 	// TODO: abolish internal labels and only use annotations
 	ilOpt, err := withInternalLabels(internalLabels)
@@ -393,6 +437,16 @@ func Create(
 
 	specOpts = append(specOpts, propagateInternalContainerdLabelsToOCIAnnotations(),
 		oci.WithAnnotations(utils.KeyValueStringsToMap(opts.Annotations)))
+	specOpts = append(specOpts, setPlatformLastOptions(opts)...)
+
+	// User hooks are matched against the annotations, process and mounts, so they must be injected last.
+	if runtime.GOOS != "windows" {
+		userHooks, err := hooksd.Load(opts.GOptions.HooksDir)
+		if err != nil {
+			return nil, generateRemoveOrphanedDirsFunc(ctx, id, dataStore, internalLabels), err
+		}
+		specOpts = append(specOpts, withUserHooks(userHooks))
+	}
 
 	var s specs.Spec
 	spec := containerd.WithSpec(&s, specOpts...)
@@ -424,9 +478,12 @@ func Create(
 			containerNameStore,
 			netManager,
 			internalLabels,
+			&s,
 		), returnedError
 	}
 
+	eventutil.Record(ctx, opts.GOptions, eventutil.ContainerEvent(ctx, c, eventutil.ActionCreate))
+
 	return c, nil, nil
 }
 
@@ -624,6 +681,14 @@ func withOCIHook(cmd string, args []string) (oci.SpecOpts, error) {
 	}, nil
 }
 
+// withUserHooks injects the hooks from the hooks directory that match the container.
+func withUserHooks(hooks []*hooksd.Hook) oci.SpecOpts {
+	return func(_ context.Context, _ oci.Client, _ *containers.Container, s *specs.Spec) error {
+		hooksd.Inject(s, hooks)
+		return nil
+	}
+}
+
 func withContainerLabels(
 	label, labelFile []string,
 	ensuredImage *imgutil.EnsuredImage,
@@ -715,6 +780,8 @@ type internalLabels struct {
 	networks             []string
 	ipAddress            string
 	ip6Address           string
+	ipSticky             bool
+	networkAliases       map[string][]string
 	ports                []cni.PortMapping
 	macAddress           string
 	dnsServers           []string
@@ -741,6 +808,16 @@ type internalLabels struct {
 
 	// label for device mapping set by the --device flag
 	deviceMapping []dockercompat.DeviceMapping
+
+	// flags only kept for inspect
+	cgroupParent string
+	binds        []string
+	volumesFrom  []string
+	capAdd       []string
+	capDrop      []string
+	privileged   bool
+	securityOpt  []string
+	init         bool
 }
 
 // WithInternalLabels sets the internal labels for a container.
@@ -800,6 +877,18 @@ func withInternalLabels(internalLabels internalLabels) (containerd.NewContainerO
 		m[labels.IP6Address] = internalLabels.ip6Address
 	}
 
+	if internalLabels.ipSticky {
+		m[labels.IPSticky] = strconv.FormatBool(internalLabels.ipSticky)
+	}
+
+	if len(internalLabels.networkAliases) > 0 {
+		networkAliasesJSON, err := json.Marshal(internalLabels.networkAliases)
+		if err != nil {
+			return nil, err
+		}
+		m[labels.NetworkAliases] = string(networkAliasesJSON)
+	}
+
 	m[labels.Platform], err = platformutil.NormalizeString(internalLabels.platform)
 	if err != nil {
 		return nil, err
@@ -854,6 +943,17 @@ func withInternalLabels(internalLabels internalLabels) (containerd.NewContainerO
 		hostConfigLabel.Devices = append(hostConfigLabel.Devices, internalLabels.deviceMapping...)
 	}
 
+	hostConfigLabel.CgroupParent = internalLabels.cgroupParent
+	hostConfigLabel.Binds = internalLabels.binds
+	hostConfigLabel.VolumesFrom = internalLabels.volumesFrom
+	hostConfigLabel.CapAdd = internalLabels.capAdd
+	hostConfigLabel.CapDrop = internalLabels.capDrop
+	hostConfigLabel.Privileged = internalLabels.privileged
+	hostConfigLabel.SecurityOpt = internalLabels.securityOpt
+	if internalLabels.init {
+		hostConfigLabel.Init = &internalLabels.init
+	}
+
 	hostConfigJSON, err := json.Marshal(hostConfigLabel)
 	if err != nil {
 		return nil, err
@@ -876,6 +976,7 @@ func (il *internalLabels) loadNetOpts(opts options.ContainerNetwork) {
 	il.ports = opts.PortMappings
 	il.ipAddress = opts.IPAddress
 	il.ip6Address = opts.IP6Address
+	il.ipSticky = opts.IPSticky
 	il.networks = opts.NetworkSlice
 	il.macAddress = opts.MACAddress
 	il.dnsServers = opts.DNSServers
@@ -1042,6 +1143,7 @@ func generateGcFunc(
 	containerNameStore namestore.NameStore,
 	netManager containerutil.NetworkOptionsManager,
 	internalLabels internalLabels,
+	spec *specs.Spec,
 ) func() {
 	return func() {
 		if containerErr == nil {
@@ -1073,6 +1175,11 @@ func generateGcFunc(
 			log.G(ctx).WithError(rmErr).Warnf("failed to remove container %q state dir %q", id, internalLabels.stateDir)
 		}
 
+		// The generated profiles are loaded as the spec is built, before the container is created
+		if err := unloadGeneratedSecurityProfiles(id, spec); err != nil {
+			log.G(ctx).WithError(err).Warnf("failed to unload security profiles generated for container %q", id)
+		}
+
 		if name != "" {
 			var errE error
 			if containerNameStore, errE = namestore.New(dataStore, ns); errE != nil {
@@ -0,0 +1,114 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/docker/go-units"
+
+	"go.farcloser.world/lepton/leptonic/services/namespace"
+	"go.farcloser.world/lepton/pkg/api/options"
+)
+
+// checkNamespaceQuotas verifies that creating a container with opts does not exceed the quotas of its namespace.
+func checkNamespaceQuotas(ctx context.Context, client *containerd.Client, opts *options.ContainerCreate) error {
+	quotas, err := namespace.GetQuotas(ctx, client, opts.GOptions.Namespace)
+	if err != nil {
+		return err
+	}
+
+	if quotas.Containers == 0 && quotas.Memory == 0 {
+		return nil
+	}
+
+	containers, err := client.Containers(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = checkContainersQuota(opts.GOptions.Namespace, quotas.Containers, len(containers)); err != nil {
+		return err
+	}
+
+	if quotas.Memory == 0 || opts.Memory == "" {
+		return checkMemoryQuota(opts.GOptions.Namespace, quotas.Memory, 0, opts.Memory)
+	}
+
+	var used int64
+	for _, c := range containers {
+		spec, err := c.Spec(ctx)
+		if err != nil {
+			return err
+		}
+
+		if spec.Linux != nil && spec.Linux.Resources != nil && spec.Linux.Resources.Memory != nil &&
+			spec.Linux.Resources.Memory.Limit != nil && *spec.Linux.Resources.Memory.Limit > 0 {
+			used += *spec.Linux.Resources.Memory.Limit
+		}
+	}
+
+	return checkMemoryQuota(opts.GOptions.Namespace, quotas.Memory, used, opts.Memory)
+}
+
+// checkContainersQuota verifies that a namespace with `count` containers can have one more.
+func checkContainersQuota(ns string, quota, count int) error {
+	if quota > 0 && count >= quota {
+		return errors.Join(namespace.ErrQuotaExceeded, fmt.Errorf(
+			"namespace %q is limited to %d containers (%s), remove some containers first",
+			ns, quota, namespace.LabelQuotaContainers,
+		))
+	}
+
+	return nil
+}
+
+// checkMemoryQuota verifies that a container with a memory limit of `memory` fits in the memory quota of a namespace,
+// whose containers already have `used` bytes.
+func checkMemoryQuota(ns string, quota, used int64, memory string) error {
+	if quota == 0 {
+		return nil
+	}
+
+	if memory == "" {
+		return errors.Join(namespace.ErrQuotaExceeded, fmt.Errorf(
+			"namespace %q has a memory quota of %s (%s), containers must specify --memory",
+			ns, units.BytesSize(float64(quota)), namespace.LabelQuotaMemory,
+		))
+	}
+
+	requested, err := units.RAMInBytes(memory)
+	if err != nil {
+		return fmt.Errorf("failed to parse memory bytes %q: %w", memory, err)
+	}
+
+	if used+requested > quota {
+		return errors.Join(namespace.ErrQuotaExceeded, fmt.Errorf(
+			"namespace %q has a memory quota of %s (%s), %s are already allocated and %s were requested",
+			ns,
+			units.BytesSize(float64(quota)),
+			namespace.LabelQuotaMemory,
+			units.BytesSize(float64(used)),
+			units.BytesSize(float64(requested)),
+		))
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package container
+
+import (
+	"errors"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/lepton/leptonic/services/namespace"
+)
+
+func TestCheckContainersQuota(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		quota    int
+		count    int
+		exceeded bool
+	}{
+		{name: "unlimited", quota: 0, count: 1000},
+		{name: "empty namespace", quota: 1, count: 0},
+		{name: "below quota", quota: 3, count: 2},
+		{name: "quota reached", quota: 3, count: 3, exceeded: true},
+		{name: "quota lowered below count", quota: 3, count: 5, exceeded: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := checkContainersQuota("test", tc.quota, tc.count)
+			if tc.exceeded {
+				assert.Assert(t, errors.Is(err, namespace.ErrQuotaExceeded), "got %v", err)
+				return
+			}
+			assert.NilError(t, err)
+		})
+	}
+}
+
+func TestCheckMemoryQuota(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		quota    int64
+		used     int64
+		memory   string
+		exceeded bool
+		wantErr  bool
+	}{
+		{name: "unlimited", quota: 0, used: 1 << 40, memory: ""},
+		{name: "no limit requested", quota: 1 << 30, memory: "", exceeded: true},
+		{name: "fits", quota: 1 << 30, used: 512 << 20, memory: "256m"},
+		{name: "fills the quota", quota: 1 << 30, used: 512 << 20, memory: "512m"},
+		{name: "exceeds the quota", quota: 1 << 30, used: 512 << 20, memory: "513m", exceeded: true},
+		{name: "invalid limit", quota: 1 << 30, memory: "lots", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := checkMemoryQuota("test", tc.quota, tc.used, tc.memory)
+			switch {
+			case tc.exceeded:
+				assert.Assert(t, errors.Is(err, namespace.ErrQuotaExceeded), "got %v", err)
+			case tc.wantErr:
+				assert.Assert(t, err != nil)
+				assert.Assert(t, !errors.Is(err, namespace.ErrQuotaExceeded), "got %v", err)
+			default:
+				assert.NilError(t, err)
+			}
+		})
+	}
+}
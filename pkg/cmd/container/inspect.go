@@ -19,19 +19,37 @@ package container
 import (
 	"context"
 	"fmt"
+	"os/exec"
 	"time"
 
 	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/core/content"
 	"github.com/containerd/containerd/v2/core/snapshots"
+	"github.com/containerd/containerd/v2/pkg/shim"
 	"github.com/containerd/log"
+	"github.com/containerd/typeurl/v2"
+	types100 "github.com/containernetworking/cni/pkg/types/100"
+
+	"go.farcloser.world/containers/specs"
 
 	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/clientutil"
 	"go.farcloser.world/lepton/pkg/containerdutil"
 	"go.farcloser.world/lepton/pkg/containerinspector"
+	"go.farcloser.world/lepton/pkg/dnsutil/hostsstore"
+	"go.farcloser.world/lepton/pkg/eventutil"
 	"go.farcloser.world/lepton/pkg/formatter"
+	"go.farcloser.world/lepton/pkg/healthcheck"
 	"go.farcloser.world/lepton/pkg/idutil/containerwalker"
 	"go.farcloser.world/lepton/pkg/imgutil"
 	"go.farcloser.world/lepton/pkg/inspecttypes/dockercompat"
+	"go.farcloser.world/lepton/pkg/inspecttypes/native"
+	"go.farcloser.world/lepton/pkg/labels"
+	"go.farcloser.world/lepton/pkg/logging"
+	"go.farcloser.world/lepton/pkg/namestore"
+	"go.farcloser.world/lepton/pkg/netutil"
+	"go.farcloser.world/lepton/pkg/ocihook/state"
+	"go.farcloser.world/lepton/pkg/portutil"
 )
 
 // Inspect prints detailed information for each container in `containers`.
@@ -44,8 +62,15 @@ func Inspect(
 	f := &containerInspector{
 		mode:        options.Mode,
 		size:        options.Size,
+		client:      client,
 		snapshotter: containerdutil.SnapshotService(client, options.GOptions.Snapshotter),
 	}
+	switch f.mode {
+	case "native":
+		f.loadNativeSources(ctx, options.GOptions)
+	case "dockercompat":
+		f.loadDockerCompatSources(ctx, options.GOptions)
+	}
 
 	walker := &containerwalker.ContainerWalker{
 		Client:  client,
@@ -65,8 +90,75 @@ func Inspect(
 type containerInspector struct {
 	mode        string
 	size        bool
+	client      *containerd.Client
 	snapshotter snapshots.Snapshotter
 	entries     []interface{}
+	hostsStore  hostsstore.Store
+	// only for native
+	dataStore string
+	nameStore namestore.NameStore
+	// only for dockercompat
+	networkIDs map[string]string
+	events     []eventutil.Event
+}
+
+// loadNativeSources prepares the stores native inspection reads from.
+// This is best-effort: what cannot be loaded is simply not reported.
+func (x *containerInspector) loadNativeSources(ctx context.Context, globalOptions *options.Global) {
+	var err error
+	if x.dataStore, err = clientutil.DataStore(globalOptions.DataRoot, globalOptions.Address); err != nil {
+		log.G(ctx).WithError(err).Debug("failed to get the data store")
+		return
+	}
+
+	if x.hostsStore, err = hostsstore.New(x.dataStore, globalOptions.Namespace); err != nil {
+		log.G(ctx).WithError(err).Debug("failed to open the hosts store")
+	}
+
+	if x.nameStore, err = namestore.New(x.dataStore, globalOptions.Namespace); err != nil {
+		log.G(ctx).WithError(err).Debug("failed to open the name store")
+	}
+}
+
+// loadDockerCompatSources prepares what dockercompat needs beyond the containers themselves.
+// This is best-effort: what cannot be loaded is simply not reported.
+func (x *containerInspector) loadDockerCompatSources(ctx context.Context, globalOptions *options.Global) {
+	dataStore, err := clientutil.DataStore(globalOptions.DataRoot, globalOptions.Address)
+	if err != nil {
+		log.G(ctx).WithError(err).Debug("failed to get the data store")
+		return
+	}
+
+	if x.hostsStore, err = hostsstore.New(dataStore, globalOptions.Namespace); err != nil {
+		log.G(ctx).WithError(err).Debug("failed to open the hosts store")
+	}
+
+	if jn, err := eventutil.NewJournal(dataStore, globalOptions.Namespace, eventutil.DefaultJournalSize); err != nil {
+		log.G(ctx).WithError(err).Debug("failed to open the events journal")
+	} else if x.events, err = jn.Read(); err != nil {
+		log.G(ctx).WithError(err).Debug("failed to read the events journal")
+	}
+
+	cniEnv, err := netutil.NewCNIEnv(
+		globalOptions.CNIPath,
+		globalOptions.CNINetConfPath,
+		netutil.WithNamespace(globalOptions.Namespace),
+	)
+	if err != nil {
+		log.G(ctx).WithError(err).Debug("failed to load the networks")
+		return
+	}
+	networks, err := cniEnv.NetworkMap()
+	if err != nil {
+		log.G(ctx).WithError(err).Debug("failed to list the networks")
+		return
+	}
+	x.networkIDs = make(map[string]string, len(networks))
+	for name, network := range networks {
+		if network.CliID != nil {
+			x.networkIDs[name] = *network.CliID
+		}
+	}
 }
 
 func (x *containerInspector) Handler(ctx context.Context, found containerwalker.Found) error {
@@ -79,12 +171,14 @@ func (x *containerInspector) Handler(ctx context.Context, found containerwalker.
 	}
 	switch x.mode {
 	case "native":
+		x.completeNative(ctx, n)
 		x.entries = append(x.entries, n)
 	case "dockercompat":
 		d, err := dockercompat.ContainerFromNative(n)
 		if err != nil {
 			return err
 		}
+		x.completeDockerCompat(ctx, found.Container, n, d)
 		if x.size {
 			resourceUsage, allResourceUsage, err := imgutil.ResourceUsage(ctx, x.snapshotter, d.ID)
 			if err == nil {
@@ -99,3 +193,205 @@ func (x *containerInspector) Handler(ctx context.Context, found containerwalker.
 	}
 	return nil
 }
+
+// completeNative adds what containerd does not know about, or does not expose along with the container: the cgroup,
+// the resolved runtime, the snapshot, the OCI hooks state, the logging configuration and the name ownership.
+func (x *containerInspector) completeNative(ctx context.Context, n *native.Container) {
+	if n.Process != nil && n.Process.Pid > 0 {
+		cg, err := containerinspector.InspectCgroup(n.Process.Pid)
+		if err != nil {
+			log.G(ctx).WithError(err).Debugf("failed to inspect the cgroup of container %q", n.ID)
+		}
+		n.Cgroup = cg
+	} else if sp, ok := n.Spec.(*specs.Spec); ok && sp.Linux != nil && sp.Linux.CgroupsPath != "" {
+		n.Cgroup = &native.Cgroup{Path: sp.Linux.CgroupsPath}
+	}
+
+	n.ResolvedRuntime = &native.Runtime{
+		Name: n.Runtime.Name,
+		Shim: shim.BinaryName(n.Runtime.Name),
+	}
+	if n.ResolvedRuntime.Shim != "" {
+		if shimPath, err := exec.LookPath(n.ResolvedRuntime.Shim); err == nil {
+			n.ResolvedRuntime.ShimPath = shimPath
+		}
+	}
+	if n.Runtime.Options != nil {
+		if opts, err := typeurl.UnmarshalAny(n.Runtime.Options); err != nil {
+			log.G(ctx).WithError(err).Debugf("failed to decode the runtime options of container %q", n.ID)
+		} else {
+			n.ResolvedRuntime.Options = opts
+		}
+	}
+
+	if n.SnapshotKey != "" {
+		n.Snapshot = &native.Snapshot{
+			Snapshotter: n.Snapshotter,
+			Key:         n.SnapshotKey,
+		}
+		snapshotter := x.client.SnapshotService(n.Snapshotter)
+		if mounts, err := snapshotter.Mounts(ctx, n.SnapshotKey); err != nil {
+			log.G(ctx).WithError(err).Debugf("failed to get the snapshot mounts of container %q", n.ID)
+		} else {
+			n.Snapshot.Mounts = mounts
+		}
+		if usage, err := snapshotter.Usage(ctx, n.SnapshotKey); err != nil {
+			log.G(ctx).WithError(err).Debugf("failed to get the snapshot usage of container %q", n.ID)
+		} else {
+			n.Snapshot.Usage = &usage
+		}
+	}
+
+	n.State = x.nativeState(ctx, n)
+
+	ns := n.Labels[labels.Namespace]
+	if x.dataStore != "" && ns != "" {
+		if logConfig, err := logging.LoadLogConfig(x.dataStore, ns, n.ID); err != nil {
+			log.G(ctx).WithError(err).Debugf("failed to load the log config of container %q", n.ID)
+		} else {
+			n.LogConfig = &native.LogConfig{
+				Driver:  logConfig.Driver,
+				Opts:    logConfig.Opts,
+				Address: logConfig.Address,
+			}
+		}
+	}
+
+	if name := n.Labels[labels.Name]; name != "" && x.nameStore != nil {
+		n.NameOwnership = &native.NameOwner{Name: name}
+		if owner, err := x.nameStore.Owner(name); err != nil {
+			log.G(ctx).WithError(err).Debugf("failed to get the owner of name %q", name)
+		} else {
+			n.NameOwnership.Owner = owner
+		}
+	}
+}
+
+// nativeState gathers what the OCI hooks recorded: the lifecycle state, the allocated IPs and hosts file (only while
+// the container is running), and the published ports.
+func (x *containerInspector) nativeState(ctx context.Context, n *native.Container) *native.State {
+	st := &native.State{}
+	if stateDir := n.Labels[labels.StateDir]; stateDir != "" {
+		if lf, err := state.New(stateDir); err != nil {
+			log.G(ctx).WithError(err).Debugf("failed to open the state of container %q", n.ID)
+		} else if err = lf.Load(); err != nil {
+			log.G(ctx).WithError(err).Debugf("failed to load the state of container %q", n.ID)
+		} else {
+			st.StartedAt = lf.StartedAt
+			st.CreateError = lf.CreateError
+		}
+	}
+
+	if ports, err := portutil.ParsePortsLabel(n.Labels); err != nil {
+		log.G(ctx).WithError(err).Debugf("failed to parse the ports of container %q", n.ID)
+	} else if len(ports) > 0 {
+		st.Ports = ports
+	}
+
+	if x.hostsStore == nil {
+		return st
+	}
+	if meta, err := x.hostsStore.Get(n.ID); err == nil {
+		st.IPs = map[string][]string{}
+		for network, result := range meta.Networks {
+			if result == nil {
+				continue
+			}
+			for _, ipConfig := range result.IPs {
+				st.IPs[network] = append(st.IPs[network], ipConfig.Address.String())
+			}
+		}
+	}
+	if hostsPath, err := x.hostsStore.HostsPath(n.ID); err == nil {
+		st.HostsPath = hostsPath
+	}
+
+	return st
+}
+
+// completeDockerCompat adds what is not known from the container itself: the image configuration, the networks as
+// set up by CNI, whether the container was killed by the OOM killer, and its health.
+func (x *containerInspector) completeDockerCompat(
+	ctx context.Context,
+	container containerd.Container,
+	n *native.Container,
+	d *dockercompat.Container,
+) {
+	if imageConfig, err := readImageConfig(ctx, container); err != nil {
+		log.G(ctx).WithError(err).Debugf("failed to read the image config of container %q", d.ID)
+	} else if err = dockercompat.ApplyImageConfig(d, imageConfig); err != nil {
+		log.G(ctx).WithError(err).Debugf("failed to apply the image config of container %q", d.ID)
+	}
+
+	var results map[string]*types100.Result
+	if x.hostsStore != nil {
+		// Metadata only exists for running containers
+		if meta, err := x.hostsStore.Get(d.ID); err == nil {
+			results = meta.Networks
+		}
+	}
+	dockercompat.ApplyNetworkResults(d, results, x.networkIDs)
+
+	if d.State == nil {
+		return
+	}
+	d.State.OOMKilled = oomKilled(ctx, d, x.events)
+	if stateDir := n.Labels[labels.StateDir]; stateDir != "" {
+		var pid uint32
+		if d.State.Running || d.State.Paused {
+			pid = uint32(d.State.Pid)
+		}
+		if health, err := healthcheck.Load(stateDir, pid); err != nil {
+			log.G(ctx).WithError(err).Debugf("failed to load the health of container %q", d.ID)
+		} else {
+			dockercompat.ApplyHealth(d, health)
+		}
+	}
+}
+
+func readImageConfig(ctx context.Context, container containerd.Container) ([]byte, error) {
+	img, err := container.Image(ctx)
+	if err != nil {
+		return nil, err
+	}
+	desc, err := img.Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return content.ReadBlob(ctx, img.ContentStore(), desc)
+}
+
+// oomKilled tells whether a process of the container was killed by the OOM killer since it was last started.
+// While the task runs, the memory cgroup counts the kills. Once it has exited, the cgroup is gone: only the OOM events
+// recorded in the journal since the container was started are then known.
+func oomKilled(ctx context.Context, d *dockercompat.Container, events []eventutil.Event) bool {
+	if d.State.Running || d.State.Paused {
+		cg, err := containerinspector.InspectCgroup(d.State.Pid)
+		if err != nil || cg == nil {
+			log.G(ctx).WithError(err).Debugf("failed to inspect the cgroup of container %q", d.ID)
+			return false
+		}
+		kills, err := containerinspector.OOMKills(cg)
+		if err != nil {
+			log.G(ctx).WithError(err).Debugf("failed to read the OOM kills of container %q", d.ID)
+		}
+		return kills > 0
+	}
+
+	startedAt, err := time.Parse(time.RFC3339Nano, d.State.StartedAt)
+	if err != nil {
+		return false
+	}
+	return recordedOOM(events, d.ID, startedAt)
+}
+
+// recordedOOM tells whether events hold an OOM event for the container id that happened after startedAt.
+func recordedOOM(events []eventutil.Event, id string, startedAt time.Time) bool {
+	for _, event := range events {
+		if event.Type == eventutil.TypeContainer && event.Action == eventutil.ActionOOM && event.ID == id &&
+			!event.Time.Before(startedAt) {
+			return true
+		}
+	}
+	return false
+}
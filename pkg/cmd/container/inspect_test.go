@@ -0,0 +1,170 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package container
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/containerd/containerd/v2/core/containers"
+	"github.com/containerd/go-cni"
+	types100 "github.com/containernetworking/cni/pkg/types/100"
+	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/lepton/pkg/dnsutil/hostsstore"
+	"go.farcloser.world/lepton/pkg/eventutil"
+	"go.farcloser.world/lepton/pkg/inspecttypes/native"
+	"go.farcloser.world/lepton/pkg/labels"
+	"go.farcloser.world/lepton/pkg/ocihook/state"
+)
+
+func TestNativeState(t *testing.T) {
+	t.Parallel()
+
+	const id = "0123456789abcdef"
+	startedAt := time.Date(2025, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+	stateDir := t.TempDir()
+	lf, err := state.New(stateDir)
+	assert.NilError(t, err)
+	assert.NilError(t, lf.Transform(func(lf *state.Store) error {
+		lf.StartedAt = startedAt
+		lf.CreateError = true
+		return nil
+	}))
+
+	hostsStore, err := hostsstore.New(t.TempDir(), "test")
+	assert.NilError(t, err)
+	hostsPath, err := hostsStore.AllocHostsFile(id, nil)
+	assert.NilError(t, err)
+	assert.NilError(t, hostsStore.Acquire(hostsstore.Meta{
+		ID: id,
+		Networks: map[string]*types100.Result{
+			"bridge": {
+				IPs: []*types100.IPConfig{
+					{Address: net.IPNet{IP: net.ParseIP("10.4.0.2"), Mask: net.CIDRMask(24, 32)}},
+				},
+			},
+		},
+	}))
+
+	ports := `[{"HostPort":8080,"ContainerPort":80,"Protocol":"tcp","HostIP":"0.0.0.0"}]`
+
+	testCases := []struct {
+		name       string
+		labels     map[string]string
+		hostsStore hostsstore.Store
+		want       *native.State
+	}{
+		{
+			name: "running",
+			labels: map[string]string{
+				labels.StateDir: stateDir,
+				labels.Ports:    ports,
+			},
+			hostsStore: hostsStore,
+			want: &native.State{
+				StartedAt:   startedAt,
+				CreateError: true,
+				IPs:         map[string][]string{"bridge": {"10.4.0.2/24"}},
+				Ports:       []cni.PortMapping{{HostPort: 8080, ContainerPort: 80, Protocol: "tcp", HostIP: "0.0.0.0"}},
+				HostsPath:   hostsPath,
+			},
+		},
+		{
+			name:   "no hosts store",
+			labels: map[string]string{labels.StateDir: stateDir},
+			want: &native.State{
+				StartedAt:   startedAt,
+				CreateError: true,
+			},
+		},
+		{
+			name:   "never started",
+			labels: map[string]string{labels.StateDir: t.TempDir()},
+			want:   &native.State{},
+		},
+		{
+			name:   "invalid ports",
+			labels: map[string]string{labels.Ports: "{"},
+			want:   &native.State{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			x := &containerInspector{hostsStore: tc.hostsStore}
+			n := &native.Container{Container: containers.Container{ID: id, Labels: tc.labels}}
+			assert.DeepEqual(t, x.nativeState(context.Background(), n), tc.want)
+		})
+	}
+}
+
+func TestRecordedOOM(t *testing.T) {
+	t.Parallel()
+
+	const id = "0123456789abcdef"
+	startedAt := time.Date(2025, time.March, 10, 12, 0, 0, 0, time.UTC)
+	oom := func(id string, at time.Time) eventutil.Event {
+		return eventutil.Event{Time: at, Type: eventutil.TypeContainer, Action: eventutil.ActionOOM, ID: id}
+	}
+
+	testCases := []struct {
+		name     string
+		events   []eventutil.Event
+		expected bool
+	}{
+		{
+			name: "no events",
+		},
+		{
+			name:     "oom since the start",
+			events:   []eventutil.Event{oom(id, startedAt.Add(time.Minute))},
+			expected: true,
+		},
+		{
+			name:   "oom before the last start",
+			events: []eventutil.Event{oom(id, startedAt.Add(-time.Minute))},
+		},
+		{
+			name:   "oom of another container",
+			events: []eventutil.Event{oom("fedcba9876543210", startedAt.Add(time.Minute))},
+		},
+		{
+			name: "die without oom",
+			events: []eventutil.Event{{
+				Time:       startedAt.Add(time.Minute),
+				Type:       eventutil.TypeContainer,
+				Action:     eventutil.ActionDie,
+				ID:         id,
+				Attributes: map[string]string{eventutil.AttributeExitCode: "137"},
+			}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, recordedOOM(tc.events, id, startedAt), tc.expected)
+		})
+	}
+}
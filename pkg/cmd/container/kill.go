@@ -32,6 +32,7 @@ import (
 	"github.com/moby/sys/signal"
 
 	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/clientutil"
 	"go.farcloser.world/lepton/pkg/containerutil"
 	"go.farcloser.world/lepton/pkg/idutil/containerwalker"
 	"go.farcloser.world/lepton/pkg/labels"
@@ -153,11 +154,16 @@ func cleanupNetwork(ctx context.Context, container containerd.Container, globalO
 		case nettype.Host, nettype.None, nettype.Container, nettype.Namespace:
 			// NOP
 		case nettype.CNI:
+			dataStore, err := clientutil.DataStore(globalOpts.DataRoot, globalOpts.Address)
+			if err != nil {
+				return err
+			}
 			e, err := netutil.NewCNIEnv(
 				globalOpts.CNIPath,
 				globalOpts.CNINetConfPath,
 				netutil.WithNamespace(globalOpts.Namespace),
-				netutil.WithDefaultNetwork(globalOpts.BridgeIP),
+				netutil.WithIPv6Pool(globalOpts.IPv6Pool, dataStore),
+				netutil.WithDefaultNetwork(globalOpts.BridgeIP, globalOpts.BridgeSubnet, globalOpts.BridgeIPv6),
 			)
 			if err != nil {
 				return err
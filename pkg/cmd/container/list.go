@@ -134,13 +134,18 @@ type ListItem struct {
 	Labels    string
 	LabelsMap map[string]string `json:"-"`
 
-	// TODO: "LocalVolumes", "Mounts", "Networks", "RunningFor", "State"
+	// TODO: "LocalVolumes", "Mounts", "Networks", "State"
 }
 
 func (x *ListItem) Label(s string) string {
 	return x.LabelsMap[s]
 }
 
+// RunningFor returns the time elapsed since the container was created, e.g. "3 hours ago".
+func (x *ListItem) RunningFor() string {
+	return formatter.TimeSinceInHuman(x.CreatedAt)
+}
+
 func prepareContainers(
 	ctx context.Context,
 	client *containerd.Client,
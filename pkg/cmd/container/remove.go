@@ -35,11 +35,13 @@ import (
 	"go.farcloser.world/lepton/pkg/clientutil"
 	"go.farcloser.world/lepton/pkg/containerutil"
 	"go.farcloser.world/lepton/pkg/dnsutil/hostsstore"
+	"go.farcloser.world/lepton/pkg/eventutil"
 	"go.farcloser.world/lepton/pkg/idutil/containerwalker"
 	"go.farcloser.world/lepton/pkg/ipcutil"
 	"go.farcloser.world/lepton/pkg/labels"
 	"go.farcloser.world/lepton/pkg/mountutil/volumestore"
 	"go.farcloser.world/lepton/pkg/namestore"
+	"go.farcloser.world/lepton/pkg/netutil/reservationstore"
 )
 
 // StatusError represents an error that container is in a status unexpected
@@ -148,6 +150,9 @@ func RemoveContainer(
 		// Release the lock
 		retErr = errors.Join(lf.Release(), retErr)
 		// Note: technically, this is racy...
+		if retErr == nil {
+			retErr = volumestore.UnpinContainerSubpaths(containerLabels)
+		}
 		if retErr == nil {
 			retErr = os.RemoveAll(containerLabels[labels.StateDir])
 		}
@@ -178,6 +183,7 @@ func RemoveContainer(
 	// Get the container id and name
 	id := c.ID()
 	name := containerLabels[labels.Name]
+	destroyEvent := eventutil.ContainerEvent(ctx, c, eventutil.ActionDestroy)
 
 	// This will evaluate retErr to decide if we proceed with removal or not
 	defer func() {
@@ -227,6 +233,12 @@ func RemoveContainer(
 		}
 
 		// Container has been removed successfully. Now we just finish the cleanup on our side.
+		eventutil.Record(ctx, globalOptions, destroyEvent)
+
+		// Unload generated security profiles - soft failure
+		if err = unloadGeneratedSecurityProfiles(id, spec); err != nil {
+			log.G(ctx).WithError(err).Warnf("failed to unload security profiles generated for container %q", id)
+		}
 
 		// Cleanup IPC - soft failure
 		if err = ipcutil.CleanUp(ipc); err != nil {
@@ -249,6 +261,14 @@ func RemoveContainer(
 			log.G(ctx).WithError(err).Warnf("failed to remove hosts file for container %q", id)
 		}
 
+		rs, err := reservationstore.New(dataStore, containerNamespace)
+		if err != nil {
+			log.G(ctx).WithError(err).Warnf("failed to instantiate reservationstore for %q", containerNamespace)
+		} else if err = rs.Release(id); err != nil {
+			// Release the sticky addresses - soft failure
+			log.G(ctx).WithError(err).Warnf("failed to release the reserved addresses of container %q", id)
+		}
+
 		// Volume removal is not handled by the poststop hook lifecycle because it depends on removeAnonVolumes option
 		// Note that the anonymous volume list has been obtained earlier, without locking the volume store.
 		// Technically, a concurrent operation MAY have deleted these anonymous volumes already at this point, which
@@ -43,7 +43,7 @@ func Restart(
 			if err := containerutil.Stop(ctx, found.Container, options.Timeout, options.Signal); err != nil {
 				return err
 			}
-			if err := containerutil.Start(ctx, found.Container, false, client, ""); err != nil {
+			if err := containerutil.Start(ctx, found.Container, false, client, "", options.GOption); err != nil {
 				return err
 			}
 			_, err := fmt.Fprintln(options.Stdout, found.Req)
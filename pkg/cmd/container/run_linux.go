@@ -33,6 +33,7 @@ import (
 
 	"go.farcloser.world/lepton/pkg/api/options"
 	"go.farcloser.world/lepton/pkg/bypass4netnsutil"
+	"go.farcloser.world/lepton/pkg/clientutil"
 	"go.farcloser.world/lepton/pkg/containerutil"
 	"go.farcloser.world/lepton/pkg/idutil/containerwalker"
 	"go.farcloser.world/lepton/pkg/ipcutil"
@@ -41,6 +42,11 @@ import (
 	"go.farcloser.world/lepton/pkg/utils"
 )
 
+// setPlatformLastOptions returns the options derived from the complete spec, which must be applied last.
+func setPlatformLastOptions(options *options.ContainerCreate) []oci.SpecOpts {
+	return generateLastSecurityOpts(options.SecurityOpt)
+}
+
 // WithoutRunMount returns a SpecOpts that unmounts the default tmpfs on "/run"
 func WithoutRunMount() func(ctx context.Context, client oci.Client, c *containers.Container, s *oci.Spec) error {
 	return oci.WithoutRunMount
@@ -82,7 +88,11 @@ func setPlatformOptions(
 		strutil.DedupeStrSlice(options.CapDrop))
 	opts = append(opts, capOpts...)
 	securityOptsMaps := utils.KeyValueStringsToMap(strutil.DedupeStrSlice(options.SecurityOpt))
-	secOpts, err := generateSecurityOpts(options.Privileged, securityOptsMaps)
+	dataStore, err := clientutil.DataStore(options.GOptions.DataRoot, options.GOptions.Address)
+	if err != nil {
+		return nil, err
+	}
+	secOpts, err := generateSecurityOpts(options.Privileged, securityOptsMaps, dataStore)
 	if err != nil {
 		return nil, err
 	}
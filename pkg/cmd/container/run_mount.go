@@ -24,6 +24,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -135,15 +136,19 @@ func parseMountFlags(
 func generateMountOpts(
 	ctx context.Context,
 	client *containerd.Client,
+	id string,
+	stateDir string,
 	ensuredImage *imgutil.EnsuredImage,
 	volStore volumestore.VolumeService,
 	options *options.ContainerCreate,
-) ([]oci.SpecOpts, []string, []*mountutil.Processed, error) {
+) ([]oci.SpecOpts, []containerd.NewContainerOpts, []string, []*mountutil.Processed, error) {
 	var (
 		opts        []oci.SpecOpts
+		cOpts       []containerd.NewContainerOpts
 		anonVolumes []string
 		userMounts  []specs.Mount
 		mountPoints []*mountutil.Processed
+		subpaths    []volumestore.Subpath
 	)
 	mounted := make(map[string]struct{})
 	var imageVolumes map[string]struct{}
@@ -152,19 +157,19 @@ func generateMountOpts(
 		imageVolumes = ensuredImage.ImageConfig.Volumes
 
 		if err := ensuredImage.Image.Unpack(ctx, options.GOptions.Snapshotter); err != nil {
-			return nil, nil, nil, fmt.Errorf("error unpacking image: %w", err)
+			return nil, nil, nil, nil, fmt.Errorf("error unpacking image: %w", err)
 		}
 
 		diffIDs, err := ensuredImage.Image.RootFS(ctx)
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, err
 		}
 		chainID := specs.ChainID(diffIDs).String()
 
 		s := client.SnapshotService(options.GOptions.Snapshotter)
 		tempDir, err = os.MkdirTemp("", "initialC")
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, err
 		}
 		// We use Remove here instead of RemoveAll.
 		// The RemoveAll will delete the temp dir and all children it contains.
@@ -175,14 +180,14 @@ func generateMountOpts(
 		// Note(gsamfira): should we make this shorter?
 		ctx, done, err := client.WithLease(ctx, leases.WithRandomID(), leases.WithExpiration(1*time.Hour))
 		if err != nil {
-			return nil, nil, nil, fmt.Errorf("failed to create lease: %w", err)
+			return nil, nil, nil, nil, fmt.Errorf("failed to create lease: %w", err)
 		}
 		defer done(ctx)
 
 		var mounts []mount.Mount
 		mounts, err = s.View(ctx, tempDir, chainID)
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, err
 		}
 
 		// windows has additional steps for mounting see
@@ -203,45 +208,64 @@ func generateMountOpts(
 					// For https://github.com/containerd/nerdctl/issues/2056
 					unpriv, err := mountutil.UnprivilegedMountFlags(m.Source)
 					if err != nil {
-						return nil, nil, nil, err
+						return nil, nil, nil, nil, err
 					}
 					m.Options = strutil.DedupeStrSlice(append(m.Options, unpriv...))
 				}
 				if err := m.Mount(tempDir); err != nil {
 					if rmErr := s.Remove(ctx, tempDir); rmErr != nil && !errdefs.IsNotFound(rmErr) {
-						return nil, nil, nil, rmErr
+						return nil, nil, nil, nil, rmErr
 					}
-					return nil, nil, nil, fmt.Errorf("failed to mount %+v on %q: %w", m, tempDir, err)
+					return nil, nil, nil, nil, fmt.Errorf("failed to mount %+v on %q: %w", m, tempDir, err)
 				}
 			}
 		} else {
 			defer unmounter(tempDir)
 			if err := mount.All(mounts, tempDir); err != nil {
 				if err := s.Remove(ctx, tempDir); err != nil && !errdefs.IsNotFound(err) {
-					return nil, nil, nil, err
+					return nil, nil, nil, nil, err
 				}
-				return nil, nil, nil, err
+				return nil, nil, nil, nil, err
 			}
 		}
 	}
 
 	if parsed, err := parseMountFlags(volStore, options); err != nil {
-		return nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	} else if len(parsed) > 0 {
 		ociMounts := make([]specs.Mount, len(parsed))
 		for i, x := range parsed {
+			if x.Type == mountutil.Image {
+				cOpt, err := mountImage(ctx, client, id, i, x, options)
+				if err != nil {
+					return nil, nil, nil, nil, err
+				}
+				cOpts = append(cOpts, cOpt)
+			}
+
 			ociMounts[i] = x.Mount
 			mounted[filepath.Clean(x.Mount.Destination)] = struct{}{}
 
+			// The volume directory is pinned on the subpath source when the container starts
+			if x.Type == "volume" && x.Subpath != "" {
+				subpath := volumestore.Subpath{
+					Name:    x.Name,
+					Subpath: x.Subpath,
+					Source:  volumestore.SubpathSource(stateDir, len(subpaths)),
+				}
+				ociMounts[i].Source = subpath.Source
+				subpaths = append(subpaths, subpath)
+			}
+
 			target, err := securejoin.SecureJoin(tempDir, x.Mount.Destination)
 			if err != nil {
-				return nil, nil, nil, err
+				return nil, nil, nil, nil, err
 			}
 
-			// Copying content in AnonymousVolume and namedVolume
-			if x.Type == "volume" {
+			// Copying content in AnonymousVolume and namedVolume, unless only a directory of the volume is mounted
+			if x.Type == "volume" && x.Subpath == "" {
 				if err := copyExistingContents(target, x.Mount.Source); err != nil {
-					return nil, nil, nil, err
+					return nil, nil, nil, nil, err
 				}
 			}
 			if x.AnonymousVolume != "" {
@@ -260,7 +284,7 @@ func generateMountOpts(
 		imgVol := filepath.Clean(imgVolRaw)
 		switch imgVol {
 		case "/", "/dev", "/sys", "proc":
-			return nil, nil, nil, fmt.Errorf("invalid VOLUME: %q", imgVolRaw)
+			return nil, nil, nil, nil, fmt.Errorf("invalid VOLUME: %q", imgVolRaw)
 		}
 		if _, ok := mounted[imgVol]; ok {
 			continue
@@ -271,17 +295,17 @@ func generateMountOpts(
 			anonVolName, imgVolRaw)
 		anonVol, err := volStore.CreateWithoutLock(anonVolName, nil)
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, err
 		}
 
 		target, err := securejoin.SecureJoin(tempDir, imgVol)
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, err
 		}
 
 		// copying up initial contents of the mount point directory
 		if err := copyExistingContents(target, anonVol.Mountpoint); err != nil {
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, err
 		}
 
 		m := specs.Mount{
@@ -305,7 +329,7 @@ func generateMountOpts(
 
 	containers, err := client.Containers(ctx)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 
 	vfSet := strutil.SliceToSet(options.VolumesFrom)
@@ -321,7 +345,7 @@ func generateMountOpts(
 				log.G(ctx).Debugf("container %q is gone - ignoring", c.ID())
 				continue
 			}
-			return nil, nil, nil, err
+			return nil, nil, nil, nil, err
 		}
 		_, idMatch := vfSet[c.ID()]
 		nameMatch := false
@@ -333,28 +357,69 @@ func generateMountOpts(
 			if av, found := ls[labels.AnonymousVolumes]; found {
 				err = json.Unmarshal([]byte(av), &vfAnonVolumes)
 				if err != nil {
-					return nil, nil, nil, err
+					return nil, nil, nil, nil, err
 				}
 			}
 			if m, found := ls[labels.Mounts]; found {
 				err = json.Unmarshal([]byte(m), &vfMountPoints)
 				if err != nil {
-					return nil, nil, nil, err
+					return nil, nil, nil, nil, err
+				}
+			}
+
+			var vfSubpaths []volumestore.Subpath
+			if sp, found := ls[labels.VolumeSubpaths]; found {
+				err = json.Unmarshal([]byte(sp), &vfSubpaths)
+				if err != nil {
+					return nil, nil, nil, nil, err
 				}
 			}
 
 			ps := processeds(vfMountPoints)
 			s, err := c.Spec(ctx)
 			if err != nil {
-				return nil, nil, nil, err
+				return nil, nil, nil, nil, err
 			}
-			opts = append(opts, withMounts(s.Mounts))
+
+			// Volume subpaths are pinned for the new container on its own subpath sources
+			vfMounts := slices.Clone(s.Mounts)
+			for j := range vfMounts {
+				k := slices.IndexFunc(vfSubpaths, func(sp volumestore.Subpath) bool {
+					return sp.Source == vfMounts[j].Source
+				})
+				if k < 0 {
+					continue
+				}
+
+				subpath := vfSubpaths[k]
+				subpath.Source = volumestore.SubpathSource(stateDir, len(subpaths))
+				vfMounts[j].Source = subpath.Source
+				subpaths = append(subpaths, subpath)
+
+				for _, p := range ps {
+					if p.Type == "volume" && p.Mount.Destination == vfMounts[j].Destination {
+						p.Subpath = subpath.Subpath
+					}
+				}
+			}
+
+			opts = append(opts, withMounts(vfMounts))
 			anonVolumes = append(anonVolumes, vfAnonVolumes...)
 			mountPoints = append(mountPoints, ps...)
 		}
 	}
 
-	return opts, anonVolumes, mountPoints, nil
+	if len(subpaths) > 0 {
+		subpathsJSON, err := json.Marshal(subpaths)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		cOpts = append(cOpts, containerd.WithAdditionalContainerLabels(map[string]string{
+			labels.VolumeSubpaths: string(subpathsJSON),
+		}))
+	}
+
+	return opts, cOpts, anonVolumes, mountPoints, nil
 }
 
 // copyExistingContents copies from the source to the destination and
@@ -0,0 +1,93 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package container
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/core/leases"
+	"github.com/containerd/log"
+
+	"go.farcloser.world/containers/specs"
+
+	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/cmd/image"
+	"go.farcloser.world/lepton/pkg/mountutil"
+)
+
+// mountImage resolves an image mount to a read-only view of the snapshot of the image, pulled if necessary.
+// The view is referenced by the container, so that it is garbage collected by containerd once the container is removed.
+func mountImage(
+	ctx context.Context,
+	client *containerd.Client,
+	id string,
+	index int,
+	x *mountutil.Processed,
+	options *options.ContainerCreate,
+) (containerd.NewContainerOpts, error) {
+	pullOpt := options.ImagePullOpt
+	pullOpt.Mode = options.Pull
+	pullOpt.Unpack = nil
+
+	ensured, err := image.EnsureImage(ctx, client, x.Name, pullOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = ensured.Image.Unpack(ctx, ensured.Snapshotter); err != nil {
+		return nil, fmt.Errorf("error unpacking image %q: %w", x.Name, err)
+	}
+
+	diffIDs, err := ensured.Image.RootFS(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Until the container exists and references it, the view is protected by a lease, left to expire
+	ctx, _, err = client.WithLease(ctx, leases.WithRandomID(), leases.WithExpiration(1*time.Hour))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lease: %w", err)
+	}
+
+	snapshotter := client.SnapshotService(ensured.Snapshotter)
+	key := fmt.Sprintf("%s-image-mount-%d", id, index)
+
+	mounts, err := snapshotter.View(ctx, key, specs.ChainID(diffIDs).String())
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := imageSnapshotMount(mounts, x.Subpath)
+	if err != nil {
+		if rmErr := snapshotter.Remove(ctx, key); rmErr != nil {
+			log.G(ctx).WithError(rmErr).Warnf("failed removing snapshot %q", key)
+		}
+
+		return nil, fmt.Errorf("failed mounting image %q: %w", x.Name, err)
+	}
+
+	x.Mount.Type = m.Type
+	x.Mount.Source = m.Source
+	x.Mount.Options = m.Options
+
+	return containerd.WithAdditionalContainerLabels(map[string]string{
+		fmt.Sprintf("containerd.io/gc.ref.snapshot.%s/image-mount-%d", ensured.Snapshotter, index): key,
+	}), nil
+}
@@ -0,0 +1,164 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package container
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/containerd/containerd/v2/core/mount"
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/moby/sys/userns"
+	"golang.org/x/sys/unix"
+
+	"go.farcloser.world/containers/specs"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+	"go.farcloser.world/lepton/pkg/mountutil"
+	"go.farcloser.world/lepton/pkg/strutil"
+)
+
+// imageSnapshotMount converts the mounts of a snapshot view into a single read-only mount of the OCI spec,
+// restricted to subpath if set.
+// Only the bind mounts (single layer, or native snapshotter) and overlay mounts of snapshotters are supported.
+func imageSnapshotMount(mounts []mount.Mount, subpath string) (specs.Mount, error) {
+	if len(mounts) != 1 {
+		return specs.Mount{}, fmt.Errorf("%w: unexpected snapshot mounts %+v", errs.ErrFailedPrecondition, mounts)
+	}
+
+	m := mounts[0]
+	switch m.Type {
+	case "bind":
+		source, err := securejoin.SecureJoin(m.Source, subpath)
+		if err != nil {
+			return specs.Mount{}, err
+		}
+
+		if fi, err := os.Stat(source); err != nil || !fi.IsDir() {
+			return specs.Mount{}, fmt.Errorf("%w: %q is not a directory of the image", errs.ErrNotFound, subpath)
+		}
+
+		return bindImageMount(source)
+	case "overlay":
+		var lowers, overlayOpts []string
+		for _, opt := range m.Options {
+			switch {
+			case strings.HasPrefix(opt, "lowerdir="):
+				lowers = strings.Split(strings.TrimPrefix(opt, "lowerdir="), ":")
+			case strings.HasPrefix(opt, "upperdir="), strings.HasPrefix(opt, "workdir="), opt == "ro", opt == "rw":
+			default:
+				overlayOpts = append(overlayOpts, opt)
+			}
+		}
+
+		if subpath != "" {
+			var err error
+			if lowers, err = overlaySubpath(lowers, subpath); err != nil {
+				return specs.Mount{}, err
+			}
+		}
+
+		// Without an upper directory, overlay requires at least two lower directories
+		if len(lowers) == 1 {
+			return bindImageMount(lowers[0])
+		}
+
+		return specs.Mount{
+			Type:    "overlay",
+			Source:  "overlay",
+			Options: append(overlayOpts, "ro", "lowerdir="+strings.Join(lowers, ":")),
+		}, nil
+	default:
+		return specs.Mount{}, fmt.Errorf("%w: image mounts are not supported with %q snapshot mounts",
+			errs.ErrFailedPrecondition, m.Type)
+	}
+}
+
+func bindImageMount(source string) (specs.Mount, error) {
+	options := []string{"ro", "rbind", mountutil.DefaultPropagationMode}
+	if userns.RunningInUserNS() {
+		unpriv, err := mountutil.UnprivilegedMountFlags(source)
+		if err != nil {
+			return specs.Mount{}, err
+		}
+		options = strutil.DedupeStrSlice(append(options, unpriv...))
+	}
+
+	return specs.Mount{
+		Type:    "bind",
+		Source:  source,
+		Options: options,
+	}, nil
+}
+
+// overlaySubpath returns the directories of the overlay layers (topmost first) that make up subpath.
+// Walking down from the topmost layer, a whiteout (or any non-directory) on the path hides the layers below,
+// and so does an opaque directory.
+func overlaySubpath(lowers []string, subpath string) ([]string, error) {
+	components := strings.Split(subpath, string(os.PathSeparator))
+
+	var dirs []string
+
+layers:
+	for _, layer := range lowers {
+		dir := layer
+		opaque := false
+		for _, component := range components {
+			dir = filepath.Join(dir, component)
+			fi, err := os.Lstat(dir)
+			if errors.Is(err, os.ErrNotExist) {
+				if opaque {
+					break layers
+				}
+				continue layers
+			} else if err != nil {
+				return nil, err
+			}
+
+			if !fi.IsDir() {
+				break layers
+			}
+
+			opaque = opaque || isOpaqueDir(dir)
+		}
+
+		dirs = append(dirs, dir)
+		if opaque {
+			break
+		}
+	}
+
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("%w: %q is not a directory of the image", errs.ErrNotFound, subpath)
+	}
+
+	return dirs, nil
+}
+
+func isOpaqueDir(dir string) bool {
+	buf := make([]byte, 1)
+	for _, attr := range []string{"trusted.overlay.opaque", "user.overlay.opaque"} {
+		if n, err := unix.Lgetxattr(dir, attr, buf); err == nil && n == 1 && buf[0] == 'y' {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,31 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package container
+
+import (
+	"fmt"
+
+	"github.com/containerd/containerd/v2/core/mount"
+
+	"go.farcloser.world/containers/specs"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+)
+
+func imageSnapshotMount(_ []mount.Mount, _ string) (specs.Mount, error) {
+	return specs.Mount{}, fmt.Errorf("%w: image mounts are not supported on windows", errs.ErrFailedPrecondition)
+}
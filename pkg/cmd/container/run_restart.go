@@ -25,6 +25,7 @@ import (
 	containerd "github.com/containerd/containerd/v2/client"
 	"github.com/containerd/containerd/v2/core/runtime/restart"
 
+	"go.farcloser.world/lepton/pkg/mountutil/volumestore"
 	"go.farcloser.world/lepton/pkg/strutil"
 )
 
@@ -101,6 +102,9 @@ func UpdateContainerRestartPolicyLabel(
 	if err != nil {
 		return err
 	}
+	if err = volumestore.CheckContainerRestartPolicy(lables, restartFlag); err != nil {
+		return err
+	}
 	_, statusLabelExist := lables[restart.StatusLabel]
 	if !statusLabelExist {
 		task, err := container.Task(ctx, nil)
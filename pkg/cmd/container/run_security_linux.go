@@ -31,9 +31,11 @@ import (
 	"go.farcloser.world/containers/specs"
 
 	"go.farcloser.world/lepton/leptonic/services/apparmor"
+	seccompservice "go.farcloser.world/lepton/leptonic/services/seccomp"
 	"go.farcloser.world/lepton/pkg/defaults"
 	"go.farcloser.world/lepton/pkg/maputil"
 	"go.farcloser.world/lepton/pkg/strutil"
+	"go.farcloser.world/lepton/pkg/utils"
 )
 
 var privilegedOpts = []oci.SpecOpts{
@@ -59,13 +61,11 @@ func seccompWithDefaultProfile() oci.SpecOpts {
 	}
 }
 
-func seccompWithProfile(profile string) oci.SpecOpts {
-	return func(_ context.Context, _ oci.Client, _ *containers.Container, s *specs.Spec) (err error) {
-		return seccomp.LoadProfile(s, profile)
-	}
-}
-
-func generateSecurityOpts(privileged bool, securityOptsMap map[string]string) ([]oci.SpecOpts, error) {
+func generateSecurityOpts(
+	privileged bool,
+	securityOptsMap map[string]string,
+	dataStore string,
+) ([]oci.SpecOpts, error) {
 	for k := range securityOptsMap {
 		switch k {
 		case "seccomp", "apparmor", "no-new-privileges", "systempaths", "privileged-without-host-devices":
@@ -75,12 +75,18 @@ func generateSecurityOpts(privileged bool, securityOptsMap map[string]string) ([
 	}
 	var opts []oci.SpecOpts
 	if seccompProfile, ok := securityOptsMap["seccomp"]; ok && seccompProfile != defaults.SeccompProfileName {
-		if seccompProfile == "" {
-			return nil, errors.New("invalid security-opt \"seccomp\"")
+		profiles, err := seccompservice.NewProfileStore(dataStore)
+		if err != nil {
+			return nil, err
+		}
+
+		seccompOpts, err := seccompservice.GetSpecOptions(seccompProfile, profiles)
+		if err != nil {
+			return nil, err
 		}
 
-		if seccompProfile != "unconfined" {
-			opts = append(opts, seccompWithProfile(seccompProfile))
+		if seccompOpts != nil {
+			opts = append(opts, seccompOpts)
 		}
 	} else {
 		opts = append(opts, seccompWithDefaultProfile())
@@ -93,16 +99,19 @@ func generateSecurityOpts(privileged bool, securityOptsMap map[string]string) ([
 		explicitProfile = true
 	}
 
-	appArmorSpecs, err := apparmor.GetSpecOptions(profile)
-	// If we failed with an explicit --security-opt, hard error
-	if errors.Is(err, apparmor.ErrUnsupported) || errors.Is(err, apparmor.ErrCannotApply) {
-		if explicitProfile {
+	// Generated profiles depend on the complete spec, and are applied by generateLastSecurityOpts
+	if profile != apparmor.Generated {
+		appArmorSpecs, err := apparmor.GetSpecOptions(profile)
+		// If we failed with an explicit --security-opt, hard error
+		if errors.Is(err, apparmor.ErrUnsupported) || errors.Is(err, apparmor.ErrCannotApply) {
+			if explicitProfile {
+				return nil, err
+			}
+		} else if err != nil {
 			return nil, err
+		} else if appArmorSpecs != nil {
+			opts = append(opts, appArmorSpecs)
 		}
-	} else if err != nil {
-		return nil, err
-	} else if appArmorSpecs != nil {
-		opts = append(opts, appArmorSpecs)
 	}
 
 	nnp, err := maputil.MapBoolValueAsOpt(securityOptsMap, "no-new-privileges")
@@ -143,6 +152,22 @@ func generateSecurityOpts(privileged bool, securityOptsMap map[string]string) ([
 	return opts, nil
 }
 
+// generateLastSecurityOpts returns the security options that are derived from the complete spec, and must hence be
+// applied after every other option.
+func generateLastSecurityOpts(securityOpt []string) []oci.SpecOpts {
+	securityOptsMap := utils.KeyValueStringsToMap(strutil.DedupeStrSlice(securityOpt))
+	if securityOptsMap["apparmor"] == apparmor.Generated {
+		return []oci.SpecOpts{apparmor.WithGeneratedProfile()}
+	}
+
+	return nil
+}
+
+// unloadGeneratedSecurityProfiles unloads the security profiles that were generated for the container.
+func unloadGeneratedSecurityProfiles(id string, spec *specs.Spec) error {
+	return apparmor.UnloadGenerated(id, spec)
+}
+
 func canonicalizeCapName(s string) string {
 	if s == "" {
 		return ""
@@ -127,6 +127,14 @@ func setPlatformOptions(
 	return opts, nil
 }
 
+func setPlatformLastOptions(_ *options.ContainerCreate) []oci.SpecOpts {
+	return nil
+}
+
+func unloadGeneratedSecurityProfiles(_ string, _ *specs.Spec) error {
+	return nil
+}
+
 func WithWindowsProcessIsolated() oci.SpecOpts {
 	return func(_ context.Context, _ oci.Client, _ *containers.Container, s *specs.Spec) error {
 		if s.Windows == nil {
@@ -41,7 +41,14 @@ func Start(ctx context.Context, client *containerd.Client, reqs []string, option
 			if found.MatchCount > 1 {
 				return fmt.Errorf("multiple IDs found with provided prefix: %s", found.Req)
 			}
-			if err := containerutil.Start(ctx, found.Container, options.Attach, client, options.DetachKeys); err != nil {
+			if err := containerutil.Start(
+				ctx,
+				found.Container,
+				options.Attach,
+				client,
+				options.DetachKeys,
+				options.GOptions,
+			); err != nil {
 				return err
 			}
 			if !options.Attach {
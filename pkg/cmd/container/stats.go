@@ -37,24 +37,52 @@ import (
 	"go.farcloser.world/containers/security/cgroups"
 	stats2 "go.farcloser.world/containers/stats"
 
+	"go.farcloser.world/lepton/leptonic/container"
 	"go.farcloser.world/lepton/pkg/api/options"
 	"go.farcloser.world/lepton/pkg/clientutil"
 	"go.farcloser.world/lepton/pkg/containerutil"
 	"go.farcloser.world/lepton/pkg/eventutil"
 	"go.farcloser.world/lepton/pkg/formatter"
 	"go.farcloser.world/lepton/pkg/idutil/containerwalker"
+	"go.farcloser.world/lepton/pkg/metricsutil"
 	"go.farcloser.world/lepton/pkg/rootlessutil"
 	"go.farcloser.world/lepton/pkg/statsutil"
 )
 
+// containerStats pairs the stats summary of a container with its detailed stats.
+type containerStats struct {
+	*stats2.Stats
+
+	mu       sync.Mutex
+	detailed *container.Stats
+}
+
+func newContainerStats(id string) *containerStats {
+	return &containerStats{
+		Stats: stats2.NewStats(id),
+	}
+}
+
+func (cs *containerStats) setDetailed(detailed *container.Stats) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.detailed = detailed
+}
+
+func (cs *containerStats) getDetailed() *container.Stats {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return cs.detailed
+}
+
 type statsStruct struct {
 	mu sync.Mutex
-	cs []*stats2.Stats
+	cs []*containerStats
 }
 
 // add is from
 // https://github.com/docker/cli/blob/3fb4fb83dfb5db0c0753a8316f21aea54dab32c5/cli/command/container/stats_helpers.go#L26-L34
-func (s *statsStruct) add(cs *stats2.Stats) bool {
+func (s *statsStruct) add(cs *containerStats) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if _, exists := s.isKnownContainer(cs.ID); !exists {
@@ -98,6 +126,10 @@ func Stats(
 		)
 	}
 
+	if options.Format == formatter.FormatOpenMetrics || options.Format == formatter.FormatPrometheus {
+		return statsMetrics(ctx, client, containerIDs, options)
+	}
+
 	showAll := len(containerIDs) == 0
 	closeChan := make(chan error)
 
@@ -151,7 +183,7 @@ func Stats(
 					continue
 				}
 			}
-			s := stats2.NewStats(c.ID())
+			s := newContainerStats(c.ID())
 			if cStats.add(s) {
 				waitFirst.Add(1)
 				go collect(ctx, options.GOptions, s, waitFirst, c.ID(), !options.NoStream)
@@ -182,7 +214,7 @@ func Stats(
 					return
 				}
 			}
-			s := stats2.NewStats(datacc.ID)
+			s := newContainerStats(datacc.ID)
 			if cStats.add(s) {
 				waitFirst.Add(1)
 				go collect(ctx, options.GOptions, s, waitFirst, datacc.ID, !options.NoStream)
@@ -225,7 +257,7 @@ func Stats(
 		walker := &containerwalker.ContainerWalker{
 			Client: client,
 			OnFound: func(ctx context.Context, found containerwalker.Found) error {
-				s := stats2.NewStats(found.Container.ID())
+				s := newContainerStats(found.Container.ID())
 				if cStats.add(s) {
 					waitFirst.Add(1)
 					go collect(ctx, options.GOptions, s, waitFirst, found.Container.ID(), !options.NoStream)
@@ -258,13 +290,14 @@ func Stats(
 	firstTick := true
 	for range ticker.C {
 		cleanScreen()
-		ccstats := []stats2.Entry{}
+		ccstats := []statsutil.FormattedStatsEntry{}
 		cStats.mu.Lock()
 		for _, c := range cStats.cs {
 			if err := c.GetError(); err != nil {
 				fmt.Fprintf(options.Stderr, "unable to get stat entry: %s\n", err)
 			}
-			ccstats = append(ccstats, c.GetStatistics())
+			entry := c.GetStatistics()
+			ccstats = append(ccstats, statsutil.RenderEntry(&entry, c.getDetailed()))
 		}
 		cStats.mu.Unlock()
 
@@ -275,15 +308,14 @@ func Stats(
 			}
 		}
 
-		for _, c := range ccstats {
-			if c.ID == "" {
+		for _, rc := range ccstats {
+			if rc.Entry.ID == "" {
 				continue
 			}
-			rc := statsutil.RenderEntry(&c)
 			if !firstTick {
 				if tmpl != nil {
 					var b bytes.Buffer
-					if err := tmpl.Execute(&b, rc); err != nil {
+					if err := tmpl.Execute(&b, &rc); err != nil {
 						break
 					}
 					if _, err = fmt.Fprintln(options.Stdout, b.String()); err != nil {
@@ -334,7 +366,7 @@ func Stats(
 func collect(
 	ctx context.Context,
 	globalOptions *options.Global,
-	s *stats2.Stats,
+	s *containerStats,
 	waitFirst *sync.WaitGroup,
 	id string,
 	_noStream bool,
@@ -361,7 +393,7 @@ func collect(
 		cancel()
 		client.Close()
 	}()
-	container, err := client.LoadContainer(ctx, id)
+	ctr, err := client.LoadContainer(ctx, id)
 	if err != nil {
 		s.SetError(err)
 		return
@@ -371,14 +403,14 @@ func collect(
 		previousStats := new(stats2.ContainerStats)
 		firstSet := true
 		for {
-			labels, err := container.Labels(ctx)
+			labels, err := ctr.Labels(ctx)
 			if err != nil {
 				u <- err
 				continue
 			}
 
 			// when (firstSet == true), we only set container stats without rendering stat entry
-			statsEntry, err := setContainerStatsAndRenderStatsEntry(ctx, container, previousStats)
+			statsEntry, detailed, err := setContainerStatsAndRenderStatsEntry(ctx, ctr, previousStats)
 			if err != nil {
 				u <- err
 				continue
@@ -390,6 +422,7 @@ func collect(
 				firstSet = false
 			} else {
 				s.SetStatistics(statsEntry)
+				s.setDetailed(detailed)
 			}
 			u <- nil
 			// sleep to create distant CPU readings
@@ -422,3 +455,121 @@ func collect(
 		}
 	}
 }
+
+// statsMetrics outputs a single stats reading of the requested containers in the OpenMetrics or the Prometheus text
+// format.
+func statsMetrics(
+	ctx context.Context,
+	client *containerd.Client,
+	containerIDs []string,
+	options options.ContainerStats,
+) error {
+	var containers []containerd.Container
+	if len(containerIDs) == 0 {
+		var err error
+		containers, err = client.Containers(ctx)
+		if err != nil {
+			return err
+		}
+	} else {
+		walker := &containerwalker.ContainerWalker{
+			Client: client,
+			OnFound: func(ctx context.Context, found containerwalker.Found) error {
+				containers = append(containers, found.Container)
+				return nil
+			},
+		}
+
+		if err := walker.WalkAll(ctx, containerIDs, false); err != nil {
+			return err
+		}
+	}
+
+	// Like for the table output, the first reading is only used to create distant CPU readings.
+	previous := map[string]*stats2.ContainerStats{}
+	sampleContainers(ctx, containers, options.GOptions.Namespace, previous)
+	timer := time.NewTimer(500 * time.Millisecond)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	samples := sampleContainers(ctx, containers, options.GOptions.Namespace, previous)
+
+	if options.Format == formatter.FormatPrometheus {
+		return metricsutil.WriteText(options.Stdout, metricsutil.ContainerFamilies(samples))
+	}
+
+	return metricsutil.Write(options.Stdout, metricsutil.ContainerFamilies(samples))
+}
+
+// Metrics takes a stats reading of all containers in the namespace, for export as metrics.
+// Containers that are not running are skipped.
+// previous holds the prior reading of each container (used to compute CPU usage), and is updated in place.
+func Metrics(
+	ctx context.Context,
+	client *containerd.Client,
+	namespace string,
+	previous map[string]*stats2.ContainerStats,
+) ([]metricsutil.ContainerSample, error) {
+	containers, err := client.Containers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	samples := sampleContainers(ctx, containers, namespace, previous)
+
+	// Forget about containers that are gone
+	existing := make(map[string]struct{}, len(containers))
+	for _, c := range containers {
+		existing[c.ID()] = struct{}{}
+	}
+	for id := range previous {
+		if _, ok := existing[id]; !ok {
+			delete(previous, id)
+		}
+	}
+
+	return samples, nil
+}
+
+func sampleContainers(
+	ctx context.Context,
+	containers []containerd.Container,
+	namespace string,
+	previous map[string]*stats2.ContainerStats,
+) []metricsutil.ContainerSample {
+	samples := []metricsutil.ContainerSample{}
+	for _, c := range containers {
+		previousStats, ok := previous[c.ID()]
+		if !ok {
+			previousStats = new(stats2.ContainerStats)
+			previous[c.ID()] = previousStats
+		}
+
+		labels, err := c.Labels(ctx)
+		if err != nil {
+			log.G(ctx).WithError(err).Debugf("failed to read labels for container %s", c.ID())
+			continue
+		}
+
+		entry, _, err := setContainerStatsAndRenderStatsEntry(ctx, c, previousStats)
+		if err != nil {
+			// Containers without a task are simply not running
+			if !errdefs.IsNotFound(err) {
+				log.G(ctx).WithError(err).Debugf("failed to get stats for container %s", c.ID())
+			}
+			continue
+		}
+
+		entry.Name = containerutil.GetContainerName(labels)
+		samples = append(samples, metricsutil.ContainerSample{
+			Namespace: namespace,
+			Labels:    labels,
+			Entry:     entry,
+		})
+	}
+
+	return samples
+}
@@ -24,33 +24,35 @@ import (
 	"github.com/containerd/typeurl/v2"
 
 	"go.farcloser.world/containers/stats"
+
+	"go.farcloser.world/lepton/leptonic/container"
 )
 
 func setContainerStatsAndRenderStatsEntry(
 	ctx context.Context,
-	container client.Container,
+	ctr client.Container,
 	previousStats *stats.ContainerStats,
-) (statsEntry stats.Entry, err error) {
-	task, err := container.Task(ctx, nil)
+) (statsEntry stats.Entry, detailed *container.Stats, err error) {
+	task, err := ctr.Task(ctx, nil)
 	if err != nil {
-		return statsEntry, err
+		return statsEntry, nil, err
 	}
 
 	pid := int(task.Pid())
 
 	metric, err := task.Metrics(ctx)
 	if err != nil {
-		return statsEntry, err
+		return statsEntry, nil, err
 	}
 	anydata, err := typeurl.UnmarshalAny(metric.Data)
 	if err != nil {
-		return statsEntry, err
+		return statsEntry, nil, err
 	}
 
-	statsEntry, err = stats.SetCgroup2StatsFields(previousStats, anydata, pid)
+	statsEntry, detailed, err = container.DecodeMetrics(previousStats, anydata, pid)
 
 	previousStats.Time = time.Now()
-	statsEntry.ID = container.ID()
+	statsEntry.ID = ctr.ID()
 
-	return statsEntry, err
+	return statsEntry, detailed, err
 }
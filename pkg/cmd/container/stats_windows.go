@@ -22,12 +22,14 @@ import (
 	"github.com/containerd/containerd/v2/client"
 
 	"go.farcloser.world/containers/stats"
+
+	"go.farcloser.world/lepton/leptonic/container"
 )
 
 func setContainerStatsAndRenderStatsEntry(
 	ctx context.Context,
-	container client.Container,
+	ctr client.Container,
 	previousStats *stats.ContainerStats,
-) (statsEntry stats.Entry, err error) {
-	return stats.Entry{}, nil
+) (statsEntry stats.Entry, detailed *container.Stats, err error) {
+	return stats.Entry{}, nil, nil
 }
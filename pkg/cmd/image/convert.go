@@ -73,7 +73,7 @@ func Convert(
 	convertOpts = append(convertOpts, converter.WithPlatform(platMC))
 
 	// Ensure all the layers are here: https://github.com/containerd/nerdctl/issues/3425
-	err = EnsureAllContent(ctx, client, srcRef, platMC, globalOptions)
+	err = EnsureAllContent(ctx, client, srcRef, platMC, globalOptions, opts.Progress)
 	if err != nil {
 		return err
 	}
@@ -33,6 +33,7 @@ import (
 	"go.farcloser.world/lepton/pkg/api/options"
 	"go.farcloser.world/lepton/pkg/containerdutil"
 	"go.farcloser.world/lepton/pkg/errutil"
+	"go.farcloser.world/lepton/pkg/imgutil"
 	"go.farcloser.world/lepton/pkg/imgutil/dockerconfigresolver"
 	"go.farcloser.world/lepton/pkg/imgutil/fetch"
 	"go.farcloser.world/lepton/pkg/platformutil"
@@ -44,6 +45,7 @@ func EnsureAllContent(
 	srcName string,
 	platMC platforms.MatchComparer,
 	options *options.Global,
+	progress string,
 ) error {
 	// Get the image from the srcName
 	imageService := client.ImageService()
@@ -59,7 +61,7 @@ func EnsureAllContent(
 	// Iterate through the list
 	for _, i := range imagesList {
 		if platMC.Match(i.platform) {
-			err = ensureOne(ctx, client, srcName, img.Target, i.platform, options)
+			err = ensureOne(ctx, client, srcName, img.Target, i.platform, options, progress)
 			if err != nil {
 				return err
 			}
@@ -76,6 +78,7 @@ func ensureOne(
 	target specs.Descriptor,
 	platform specs.Platform,
 	options *options.Global,
+	progress string,
 ) error {
 	parsedReference, err := reference.Parse(rawRef)
 	if err != nil {
@@ -90,8 +93,20 @@ func ensureOne(
 	}
 
 	if len(missing) > 0 {
+		var incoming int64
+		for _, desc := range missing {
+			incoming += desc.Size
+		}
+		if err = imgutil.CheckContentQuota(ctx, client, options.Namespace, incoming); err != nil {
+			return err
+		}
+
 		// Get a resolver
-		var dOpts []dockerconfigresolver.Opt
+		dOpts, err := imgutil.RegistryMirrorOpts(ctx, client, options)
+		if err != nil {
+			return err
+		}
+
 		if options.InsecureRegistry {
 			log.G(ctx).Warnf("skipping verifying HTTPS certs for %q", parsedReference.Domain)
 			dOpts = append(dOpts, dockerconfigresolver.WithSkipVerifyCerts(true))
@@ -106,6 +121,7 @@ func ensureOne(
 			RemoteOpts:     []containerd.RemoteOpt{},
 			Platforms:      pltf,
 			ProgressOutput: os.Stderr,
+			Progress:       progress,
 		}
 
 		err = fetch.Fetch(ctx, client, rawRef, config)
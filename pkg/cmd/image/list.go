@@ -17,16 +17,12 @@
 package image
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"sort"
 	"strings"
-	"text/tabwriter"
-	"text/template"
 	"time"
 
 	containerd "github.com/containerd/containerd/v2/client"
@@ -174,68 +170,64 @@ func printImages(
 	} else {
 		finalImageList = imageList
 	}
-	digestsFlag := options.Digests
-	if options.Format == formatter.FormatWide {
-		digestsFlag = true
-	}
-	var tmpl *template.Template
 	switch options.Format {
 	case formatter.FormatNone, formatter.FormatTable, formatter.FormatWide:
-		w = tabwriter.NewWriter(w, 4, 8, 4, ' ', 0)
-		if !options.Quiet {
-			printHeader := ""
-			if options.Names {
-				printHeader += "NAME\t"
-			} else {
-				printHeader += "REPOSITORY\tTAG\t"
-			}
-			if digestsFlag {
-				printHeader += "DIGEST\t"
-			}
-			printHeader += "IMAGE ID\tCREATED\tPLATFORM\tSIZE\tBLOB SIZE"
-			fmt.Fprintln(w, printHeader)
-		}
 	default:
 		if options.Quiet {
 			return errors.New("format and quiet must not be specified together")
 		}
-		var err error
-		tmpl, err = formatter.ParseTemplate(options.Format)
-		if err != nil {
-			return err
-		}
 	}
 
 	printer := &imagePrinter{
-		w:           w,
-		quiet:       options.Quiet,
 		noTrunc:     options.NoTrunc,
-		digestsFlag: digestsFlag,
 		namesFlag:   options.Names,
-		tmpl:        tmpl,
 		client:      client,
 		provider:    containerdutil.NewProvider(client),
 		snapshotter: containerdutil.SnapshotService(client, options.GOptions.Snapshotter),
 	}
 
 	for _, img := range finalImageList {
-		if err := printer.printImage(ctx, img); err != nil {
+		if err := printer.addImage(ctx, img); err != nil {
 			log.G(ctx).Warn(err)
 		}
 	}
-	if f, ok := w.(formatter.Flusher); ok {
-		return f.Flush()
+
+	if options.Quiet {
+		for _, p := range printer.items {
+			if _, err := fmt.Fprintln(w, p.ID); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
-	return nil
+
+	var columns []formatter.Column
+	if options.Names {
+		columns = append(columns, formatter.Column{Header: "NAME", Field: "Name"})
+	} else {
+		columns = append(columns,
+			formatter.Column{Header: "REPOSITORY", Field: "Repository"},
+			formatter.Column{Header: "TAG", Field: "Tag"},
+		)
+	}
+	columns = append(columns,
+		formatter.Column{Header: "DIGEST", Field: "Digest", Wide: !options.Digests},
+		formatter.Column{Header: "IMAGE ID", Field: "ID"},
+		formatter.Column{Header: "CREATED", Field: "CreatedSince"},
+		formatter.Column{Header: "PLATFORM", Field: "Platform"},
+		formatter.Column{Header: "SIZE", Field: "Size"},
+		formatter.Column{Header: "BLOB SIZE", Field: "BlobSize"},
+	)
+
+	return formatter.Render(w, options.Format, printer.items, columns)
 }
 
 type imagePrinter struct {
-	w                                      io.Writer
-	quiet, noTrunc, digestsFlag, namesFlag bool
-	tmpl                                   *template.Template
-	client                                 *containerd.Client
-	provider                               content.Provider
-	snapshotter                            snapshots.Snapshotter
+	noTrunc, namesFlag bool
+	client             *containerd.Client
+	provider           content.Provider
+	snapshotter        snapshots.Snapshotter
+	items              []imagePrintable
 }
 
 type imageStruct struct {
@@ -356,14 +348,14 @@ func read(
 	return nil, fmt.Errorf("unknown media type: %s", desc.MediaType)
 }
 
-func (x *imagePrinter) printImage(ctx context.Context, img images.Image) error {
+func (x *imagePrinter) addImage(ctx context.Context, img images.Image) error {
 	candidateImages, err := read(ctx, x.provider, x.snapshotter, img.Target)
 	if err != nil {
 		return err
 	}
 
 	for platform, desc := range candidateImages {
-		if err := x.printImageSinglePlatform(*desc.config, img, desc.blobSize, desc.size, desc.platform); err != nil {
+		if err := x.addImageSinglePlatform(*desc.config, img, desc.blobSize, desc.size, desc.platform); err != nil {
 			log.G(ctx).WithError(err).Debugf("failed to get platform %q of image %q", platform, img.Name)
 		}
 	}
@@ -371,7 +363,7 @@ func (x *imagePrinter) printImage(ctx context.Context, img images.Image) error {
 	return nil
 }
 
-func (x *imagePrinter) printImageSinglePlatform(
+func (x *imagePrinter) addImageSinglePlatform(
 	desc specs.Descriptor,
 	img images.Image,
 	blobSize int64,
@@ -410,38 +402,6 @@ func (x *imagePrinter) printImageSinglePlatform(
 		// p.Digest does not need to be truncated
 		p.ID = strings.Split(p.ID, ":")[1][:12]
 	}
-	if x.tmpl != nil {
-		var b bytes.Buffer
-		if err := x.tmpl.Execute(&b, p); err != nil {
-			return err
-		}
-		if _, err := fmt.Fprintln(x.w, b.String()); err != nil {
-			return err
-		}
-	} else if x.quiet {
-		if _, err := fmt.Fprintln(x.w, p.ID); err != nil {
-			return err
-		}
-	} else {
-		format := ""
-		args := []interface{}{}
-		if x.namesFlag {
-			format += "%s\t"
-			args = append(args, p.Name)
-		} else {
-			format += "%s\t%s\t"
-			args = append(args, p.Repository, p.Tag)
-		}
-		if x.digestsFlag {
-			format += "%s\t"
-			args = append(args, p.Digest)
-		}
-
-		format += "%s\t%s\t%s\t%s\t%s\n"
-		args = append(args, p.ID, p.CreatedSince, p.Platform, p.Size, p.BlobSize)
-		if _, err := fmt.Fprintf(x.w, format, args...); err != nil {
-			return err
-		}
-	}
+	x.items = append(x.items, p)
 	return nil
 }
@@ -34,9 +34,11 @@ import (
 
 	"go.farcloser.world/lepton/pkg/api/options"
 	"go.farcloser.world/lepton/pkg/errutil"
+	"go.farcloser.world/lepton/pkg/eventutil"
 	converterutil "go.farcloser.world/lepton/pkg/imgutil/converter"
 	"go.farcloser.world/lepton/pkg/imgutil/dockerconfigresolver"
 	"go.farcloser.world/lepton/pkg/imgutil/push"
+	"go.farcloser.world/lepton/pkg/metricsutil"
 	"go.farcloser.world/lepton/pkg/platformutil"
 	"go.farcloser.world/lepton/pkg/signutil"
 	"go.farcloser.world/lepton/pkg/snapshotterutil"
@@ -61,7 +63,7 @@ func Push(ctx context.Context, client *containerd.Client, rawRef string, options
 		// Push fails with "400 Bad Request" when the manifest is multi-platform, but we do not locally have
 		// multi-platform blobs.
 		// So we create a tmp reduced-platform image to avoid the error.
-		err = EnsureAllContent(ctx, client, ref, platMC, options.GOptions)
+		err = EnsureAllContent(ctx, client, ref, platMC, options.GOptions, options.Progress)
 		if err != nil {
 			return err
 		}
@@ -78,7 +80,7 @@ func Push(ctx context.Context, client *containerd.Client, rawRef string, options
 			)
 		}
 
-		err = EnsureAllContent(ctx, client, ref, platMC, options.GOptions)
+		err = EnsureAllContent(ctx, client, ref, platMC, options.GOptions, options.Progress)
 		if err != nil {
 			return err
 		}
@@ -107,6 +109,7 @@ func Push(ctx context.Context, client *containerd.Client, rawRef string, options
 			platMC,
 			options.AllowNondistributableArtifacts,
 			options.Quiet,
+			options.Progress,
 		)
 	}
 
@@ -150,6 +153,12 @@ func Push(ctx context.Context, client *containerd.Client, rawRef string, options
 			Info("Hint: you may want to try --insecure-registry to allow plain HTTP (if you are in a trusted network)")
 		return err
 	}
+	metricsutil.Increment(ctx, options.GOptions, metricsutil.ImagePushes)
+	eventutil.Record(ctx, options.GOptions, eventutil.Event{
+		Type:   eventutil.TypeImage,
+		Action: eventutil.ActionPush,
+		ID:     pushRef,
+	})
 
 	img, err := client.ImageService().Get(ctx, pushRef)
 	if err != nil {
@@ -29,6 +29,7 @@ import (
 
 	"go.farcloser.world/lepton/pkg/api/options"
 	"go.farcloser.world/lepton/pkg/containerutil"
+	"go.farcloser.world/lepton/pkg/eventutil"
 	"go.farcloser.world/lepton/pkg/idutil/imagewalker"
 )
 
@@ -113,6 +114,7 @@ func Remove(ctx context.Context, client *containerd.Client, args []string, optio
 			if err := is.Delete(ctx, found.Image.Name, delOpts...); err != nil {
 				return err
 			}
+			recordDelete(ctx, options.GOptions, found.Image.Name)
 			fmt.Fprintf(options.Stdout, "Untagged: %s@%s\n", found.Image.Name, found.Image.Target.Digest)
 			for _, digest := range digests {
 				fmt.Fprintf(options.Stdout, "Deleted: %s\n", digest)
@@ -168,6 +170,7 @@ func Remove(ctx context.Context, client *containerd.Client, args []string, optio
 			if err := is.Delete(ctx, found.Image.Name, delOpts...); err != nil {
 				return false, err
 			}
+			recordDelete(ctx, options.GOptions, found.Image.Name)
 			fmt.Fprintf(options.Stdout, "Untagged: %s@%s\n", found.Image.Name, found.Image.Target.Digest)
 			for _, digest := range digests {
 				fmt.Fprintf(options.Stdout, "Deleted: %s\n", digest)
@@ -205,3 +208,11 @@ func Remove(ctx context.Context, client *containerd.Client, args []string, optio
 	}
 	return nil
 }
+
+func recordDelete(ctx context.Context, globalOptions *options.Global, name string) {
+	eventutil.Record(ctx, globalOptions, eventutil.Event{
+		Type:   eventutil.TypeImage,
+		Action: eventutil.ActionDelete,
+		ID:     name,
+	})
+}
@@ -56,7 +56,7 @@ func Save(
 			}
 
 			// Ensure all the layers are here: https://github.com/containerd/nerdctl/issues/3425
-			err = EnsureAllContent(ctx, client, found.Image.Name, platMC, options.GOptions)
+			err = EnsureAllContent(ctx, client, found.Image.Name, platMC, options.GOptions, "")
 			if err != nil {
 				return err
 			}
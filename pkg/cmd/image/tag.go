@@ -28,6 +28,7 @@ import (
 	"go.farcloser.world/containers/reference"
 
 	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/eventutil"
 	"go.farcloser.world/lepton/pkg/idutil/imagewalker"
 	"go.farcloser.world/lepton/pkg/platformutil"
 )
@@ -69,7 +70,7 @@ func Tag(ctx context.Context, client *containerd.Client, options options.ImageTa
 		return err
 	}
 
-	err = EnsureAllContent(ctx, client, srcName, platMC, options.GOptions)
+	err = EnsureAllContent(ctx, client, srcName, platMC, options.GOptions, "")
 	if err != nil {
 		log.G(ctx).Warn("Unable to fetch missing layers before committing. " +
 			"If you try to save or push this image, it might fail. See https://github.com/containerd/nerdctl/issues/3439.")
@@ -93,5 +94,13 @@ func Tag(ctx context.Context, client *containerd.Client, options options.ImageTa
 			return err
 		}
 	}
+
+	eventutil.Record(ctx, options.GOptions, eventutil.Event{
+		Type:       eventutil.TypeImage,
+		Action:     eventutil.ActionTag,
+		ID:         img.Name,
+		Attributes: map[string]string{eventutil.AttributeImage: srcName},
+	})
+
 	return nil
 }
@@ -20,8 +20,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"strings"
-	"text/tabwriter"
 
 	"github.com/containerd/containerd/v2/client"
 	"github.com/containerd/log"
@@ -41,6 +39,11 @@ type namespaceListOutput struct {
 	Labels     map[string]string `json:"labels,omitempty"`
 }
 
+// FormattedLabels returns the labels as a comma separated list of key=value.
+func (n *namespaceListOutput) FormattedLabels() string {
+	return formatter.FormatLabels(n.Labels)
+}
+
 func List(
 	ctx context.Context,
 	client *client.Client,
@@ -107,43 +110,12 @@ func List(
 		result = append(result, entry)
 	}
 
-	switch opts.Format {
-	case formatter.FormatNone, formatter.FormatTable, formatter.FormatWide:
-	default:
-		tmpl, err := formatter.ParseTemplate(opts.Format)
-		if err != nil {
-			return err
-		}
-		return tmpl.Execute(output, result)
-	}
-
-	w := tabwriter.NewWriter(output, 4, 8, 4, ' ', 0)
-
 	// no "NETWORKS", because networks are global objects
-	_, err = fmt.Fprintln(w, "NAME\tCONTAINERS\tIMAGES\tVOLUMES\tLABELS")
-	if err != nil {
-		log.G(ctx).WithError(err)
-	}
-
-	for _, ns := range result {
-		labels := []string{}
-		for k, v := range ns.Labels {
-			labels = append(labels, strings.Join([]string{k, v}, "="))
-		}
-		_, err = fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%v\t\n", ns.Name, ns.Containers,
-			ns.Images, ns.Volumes, strings.Join(labels, ","))
-		if err != nil {
-			log.G(ctx).WithError(err)
-		}
-	}
-
-	// for _, ns := range nsList {
-	// 	_, err = fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%v\t\n", ns, numContainers, numImages, numVolumes,
-	// strings.Join(labelStrings, ","))
-	//	if err != nil {
-	//		log.G(ctx).WithError(err)
-	//	}
-	//}
-
-	return w.Flush()
+	return formatter.Render(output, opts.Format, result, []formatter.Column{
+		{Header: "NAME", Field: "Name"},
+		{Header: "CONTAINERS", Field: "Containers"},
+		{Header: "IMAGES", Field: "Images"},
+		{Header: "VOLUMES", Field: "Volumes"},
+		{Header: "LABELS", Field: "FormattedLabels"},
+	})
 }
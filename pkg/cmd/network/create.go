@@ -17,6 +17,7 @@
 package network
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -25,10 +26,18 @@ import (
 
 	"go.farcloser.world/lepton/leptonic/identifiers"
 	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/clientutil"
+	"go.farcloser.world/lepton/pkg/config"
+	"go.farcloser.world/lepton/pkg/eventutil"
 	"go.farcloser.world/lepton/pkg/netutil"
 )
 
-func Create(output io.Writer, globalOption *options.Global, options *options.NetworkCreate) error {
+func Create(
+	ctx context.Context,
+	output io.Writer,
+	globalOption *options.Global,
+	options *options.NetworkCreate,
+) error {
 	if err := identifiers.Validate(options.Name); err != nil {
 		return fmt.Errorf("invalid network name: %w", err)
 	}
@@ -40,10 +49,17 @@ func Create(output io.Writer, globalOption *options.Global, options *options.Net
 		options.Subnets = []string{""}
 	}
 
+	dataStore, err := clientutil.DataStore(globalOption.DataRoot, globalOption.Address)
+	if err != nil {
+		return err
+	}
+
 	e, err := netutil.NewCNIEnv(
 		globalOption.CNIPath,
 		globalOption.CNINetConfPath,
 		netutil.WithNamespace(globalOption.Namespace),
+		netutil.WithIPv6Pool(globalOption.IPv6Pool, dataStore),
+		netutil.WithAddressPools(addressPools(globalOption.DefaultAddressPools)),
 	)
 	if err != nil {
 		return err
@@ -55,6 +71,21 @@ func Create(output io.Writer, globalOption *options.Global, options *options.Net
 		}
 		return err
 	}
+	eventutil.Record(ctx, globalOption, eventutil.Event{
+		Type:       eventutil.TypeNetwork,
+		Action:     eventutil.ActionCreate,
+		ID:         *net.CliID,
+		Attributes: map[string]string{eventutil.AttributeName: options.Name},
+	})
+
 	_, err = fmt.Fprintln(output, *net.CliID)
 	return err
 }
+
+func addressPools(pools []config.AddressPool) []netutil.AddressPool {
+	res := make([]netutil.AddressPool, len(pools))
+	for i, pool := range pools {
+		res[i] = netutil.AddressPool{Base: pool.Base, Size: pool.Size}
+	}
+	return res
+}
@@ -17,13 +17,10 @@
 package network
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"strings"
-	"text/tabwriter"
-	"text/template"
 
 	"go.farcloser.world/lepton/pkg/api/options"
 	"go.farcloser.world/lepton/pkg/formatter"
@@ -38,28 +35,23 @@ type networkPrintable struct {
 	file string
 }
 
+// File returns the path of the configuration file of the network, if any.
+func (p networkPrintable) File() string {
+	return p.file
+}
+
 func List(ctx context.Context, globalOptions *options.Global, options *options.NetworkList) error {
 	quiet := options.Quiet
 	format := options.Format
 	w := options.Stdout
 	filters := options.Filters
-	var tmpl *template.Template
 
 	switch format {
 	case formatter.FormatNone, formatter.FormatTable, formatter.FormatWide:
-		w = tabwriter.NewWriter(w, 4, 8, 4, ' ', 0)
-		if !quiet {
-			fmt.Fprintln(w, "NETWORK ID\tNAME\tFILE")
-		}
 	default:
 		if quiet {
 			return errors.New("format and quiet must not be specified together")
 		}
-		var err error
-		tmpl, err = formatter.ParseTemplate(format)
-		if err != nil {
-			return err
-		}
 	}
 
 	e, err := netutil.NewCNIEnv(
@@ -116,27 +108,20 @@ func List(ctx context.Context, globalOptions *options.Global, options *options.N
 		}...)
 	}
 
-	for _, p := range pp {
-		if tmpl != nil {
-			var b bytes.Buffer
-			if err := tmpl.Execute(&b, p); err != nil {
-				return err
-			}
-			if _, err = fmt.Fprintln(w, b.String()); err != nil {
-				return err
-			}
-		} else if quiet {
+	if quiet {
+		for _, p := range pp {
 			if p.ID != "" {
 				fmt.Fprintln(w, p.ID)
 			}
-		} else {
-			fmt.Fprintf(w, "%s\t%s\t%s\n", p.ID, p.Name, p.file)
 		}
+		return nil
 	}
-	if f, ok := w.(formatter.Flusher); ok {
-		return f.Flush()
-	}
-	return nil
+
+	return formatter.Render(w, format, pp, []formatter.Column{
+		{Header: "NETWORK ID", Field: "ID"},
+		{Header: "NAME", Field: "Name"},
+		{Header: "FILE", Field: "File"},
+	})
 }
 
 func getNetworkFilterFuncs(filters []string) ([]func(*map[string]string) bool, []func(string) bool) {
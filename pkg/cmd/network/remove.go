@@ -25,6 +25,7 @@ import (
 	"github.com/containerd/log"
 
 	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/eventutil"
 	"go.farcloser.world/lepton/pkg/netutil"
 )
 
@@ -76,6 +77,12 @@ func Remove(ctx context.Context, cli *client.Client, globalOptions *options.Glob
 			errs = append(errs, err)
 		} else {
 			result = append(result, req)
+			eventutil.Record(ctx, globalOptions, eventutil.Event{
+				Type:       eventutil.TypeNetwork,
+				Action:     eventutil.ActionDestroy,
+				ID:         *network.CliID,
+				Attributes: map[string]string{eventutil.AttributeName: network.Name},
+			})
 		}
 	}
 	for _, unErr := range errs {
@@ -0,0 +1,144 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/formatter"
+	"go.farcloser.world/lepton/pkg/imgutil/dockerconfigresolver"
+)
+
+// configSource is the source of the mirrors declared in the cli configuration (or with --registry-mirror).
+const configSource = "config"
+
+type mirror struct {
+	*dockerconfigresolver.Mirror
+}
+
+func (m mirror) FormattedCapabilities() string {
+	return strings.Join(m.Capabilities, ",")
+}
+
+// MirrorAdd adds a mirror of a registry to the hosts.toml file of the first --hosts-dir.
+func MirrorAdd(
+	output io.Writer,
+	globalOptions *options.Global,
+	registry, mirrorURL string,
+	opts *options.RegistryMirrorAdd,
+) error {
+	hostsDir, err := firstHostsDir(globalOptions)
+	if err != nil {
+		return err
+	}
+
+	added, err := dockerconfigresolver.AddMirror(hostsDir, registry, mirrorURL, opts.Priority, opts.SkipVerify, opts.CA)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(output, added.URL)
+
+	return err
+}
+
+// MirrorRemove removes mirrors of a registry from the hosts.toml file of the first --hosts-dir.
+func MirrorRemove(output io.Writer, globalOptions *options.Global, registry string, mirrorURLs []string) error {
+	hostsDir, err := firstHostsDir(globalOptions)
+	if err != nil {
+		return err
+	}
+
+	var errList []error
+	for _, mirrorURL := range mirrorURLs {
+		if err = dockerconfigresolver.RemoveMirror(hostsDir, registry, mirrorURL); err != nil {
+			errList = append(errList, err)
+			continue
+		}
+
+		if _, err = fmt.Fprintln(output, mirrorURL); err != nil {
+			return err
+		}
+	}
+
+	return errors.Join(errList...)
+}
+
+// MirrorList lists the mirrors declared in the hosts.toml files of --hosts-dir, then in the cli configuration.
+func MirrorList(output io.Writer, globalOptions *options.Global, opts *options.RegistryMirrorList) error {
+	mirrors, err := dockerconfigresolver.ListMirrors(globalOptions.HostsDir)
+	if err != nil {
+		return err
+	}
+
+	registries := make([]string, 0, len(globalOptions.Registry))
+	for registry := range globalOptions.Registry {
+		registries = append(registries, registry)
+	}
+	sort.Strings(registries)
+
+	for _, registry := range registries {
+		for i, mirrorURL := range globalOptions.Registry[registry].Mirrors {
+			mirrors = append(mirrors, &dockerconfigresolver.Mirror{
+				Registry:     registry,
+				URL:          mirrorURL,
+				Priority:     i + 1,
+				Capabilities: []string{"pull", "resolve"},
+				Source:       configSource,
+			})
+		}
+	}
+
+	if opts.Quiet {
+		for _, m := range mirrors {
+			if _, err = fmt.Fprintln(output, m.URL); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	items := make([]mirror, len(mirrors))
+	for i, m := range mirrors {
+		items[i] = mirror{m}
+	}
+
+	return formatter.Render(output, opts.Format, items, []formatter.Column{
+		{Header: "REGISTRY", Field: "Registry"},
+		{Header: "MIRROR", Field: "URL"},
+		{Header: "PRIORITY", Field: "Priority"},
+		{Header: "CAPABILITIES", Field: "FormattedCapabilities"},
+		{Header: "SKIP VERIFY", Field: "SkipVerify", Wide: true},
+		{Header: "CA", Field: "CA", Wide: true},
+		{Header: "SOURCE", Field: "Source", Wide: true},
+	})
+}
+
+func firstHostsDir(globalOptions *options.Global) (string, error) {
+	if len(globalOptions.HostsDir) == 0 {
+		return "", fmt.Errorf("%w: no hosts directory configured (--hosts-dir)", errs.ErrFailedPrecondition)
+	}
+
+	return globalOptions.HostsDir[0], nil
+}
@@ -0,0 +1,266 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package seccomp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	containerd "github.com/containerd/containerd/v2/client"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+	"go.farcloser.world/lepton/leptonic/services/seccomp"
+	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/formatter"
+	"go.farcloser.world/lepton/pkg/idutil/containerwalker"
+)
+
+// List shows the stored profiles.
+func List(output io.Writer, globalOptions *options.Global, opts *options.SeccompList) error {
+	profiles, err := Store(globalOptions.DataRoot, globalOptions.Address)
+	if err != nil {
+		return err
+	}
+
+	list, err := profiles.List()
+	if err != nil {
+		return err
+	}
+
+	if opts.Quiet {
+		for _, profile := range list {
+			if _, err = fmt.Fprintln(output, profile.Name); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return formatter.Render(output, opts.Format, list, []formatter.Column{
+		{Header: "NAME", Field: "Name"},
+		{Header: "DEFAULT ACTION", Field: "DefaultAction"},
+		{Header: "SYSCALLS", Field: "Syscalls"},
+		{Header: "ARCHITECTURES", Field: "Architectures", Wide: true},
+	})
+}
+
+// Inspect prints the stored profiles `names`.
+func Inspect(output io.Writer, globalOptions *options.Global, names []string, opts *options.SeccompInspect) error {
+	profiles, err := Store(globalOptions.DataRoot, globalOptions.Address)
+	if err != nil {
+		return err
+	}
+
+	var (
+		result  []interface{}
+		errList []error
+	)
+	for _, name := range names {
+		profile, err := profiles.Get(name)
+		if err != nil {
+			errList = append(errList, err)
+			continue
+		}
+
+		result = append(result, profile)
+	}
+
+	if len(result) > 0 {
+		if err = formatter.FormatSlice(opts.Format, output, result); err != nil {
+			errList = append(errList, err)
+		}
+	}
+
+	return errors.Join(errList...)
+}
+
+// Validate checks the profile file `path` ("-" for stdin).
+func Validate(input io.Reader, path string) error {
+	data, err := readProfile(input, path)
+	if err != nil {
+		return err
+	}
+
+	_, err = seccomp.Validate(data)
+
+	return err
+}
+
+// Load validates the profile file `path` ("-" for stdin) and stores it.
+func Load(
+	output io.Writer,
+	input io.Reader,
+	globalOptions *options.Global,
+	path string,
+	opts *options.SeccompLoad,
+) error {
+	name := opts.Name
+	if name == "" {
+		if path == "-" {
+			return fmt.Errorf("%w: a name is required when reading the profile from stdin", errs.ErrInvalidArgument)
+		}
+
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	data, err := readProfile(input, path)
+	if err != nil {
+		return err
+	}
+
+	profiles, err := Store(globalOptions.DataRoot, globalOptions.Address)
+	if err != nil {
+		return err
+	}
+
+	if err = profiles.Load(name, data); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(output, name)
+
+	return err
+}
+
+// Remove deletes the stored profiles `names`.
+func Remove(output io.Writer, globalOptions *options.Global, names []string) error {
+	profiles, err := Store(globalOptions.DataRoot, globalOptions.Address)
+	if err != nil {
+		return err
+	}
+
+	var errList []error
+	for _, name := range names {
+		if err = profiles.Remove(name); err != nil {
+			errList = append(errList, err)
+			continue
+		}
+
+		_, _ = fmt.Fprintln(output, name)
+	}
+
+	return errors.Join(errList...)
+}
+
+// Default prints the built-in profile.
+func Default(ctx context.Context, output io.Writer, globalOptions *options.Global) error {
+	profile, err := seccomp.Default(ctx, globalOptions.Namespace)
+	if err != nil {
+		return err
+	}
+
+	return writeProfile(output, profile)
+}
+
+// Generate turns the syscalls traced from a container run with `--security-opt seccomp=trace` into a profile.
+func Generate(
+	ctx context.Context,
+	client *containerd.Client,
+	output io.Writer,
+	globalOptions *options.Global,
+	req string,
+	opts *options.SeccompGenerate,
+) error {
+	var since time.Time
+	walker := &containerwalker.ContainerWalker{
+		Client: client,
+		OnFound: func(ctx context.Context, found containerwalker.Found) error {
+			if found.MatchCount > 1 {
+				return fmt.Errorf("multiple IDs found with provided prefix: %s", found.Req)
+			}
+
+			spec, err := found.Container.Spec(ctx)
+			if err != nil {
+				return err
+			}
+
+			if !seccomp.IsTraced(spec) {
+				return fmt.Errorf("%w: container %s was not run with --security-opt seccomp=%s",
+					errs.ErrFailedPrecondition, found.Req, seccomp.Trace)
+			}
+
+			info, err := found.Container.Info(ctx, containerd.WithoutRefreshedMetadata)
+			if err != nil {
+				return err
+			}
+
+			since = info.CreatedAt
+
+			return nil
+		},
+	}
+
+	if n, err := walker.Walk(ctx, req); err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("no such container %s", req)
+	}
+
+	profile, err := seccomp.Generate(since, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if opts.Name == "" {
+		return writeProfile(output, profile)
+	}
+
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return err
+	}
+
+	profiles, err := Store(globalOptions.DataRoot, globalOptions.Address)
+	if err != nil {
+		return err
+	}
+
+	if err = profiles.Load(opts.Name, data); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(output, opts.Name)
+
+	return err
+}
+
+func readProfile(input io.Reader, path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(input)
+	}
+
+	return os.ReadFile(path)
+}
+
+func writeProfile(output io.Writer, profile any) error {
+	res, err := formatter.ToJSON(profile, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprint(output, res)
+
+	return err
+}
@@ -0,0 +1,32 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package seccomp
+
+import (
+	"go.farcloser.world/lepton/leptonic/services/seccomp"
+	"go.farcloser.world/lepton/pkg/clientutil"
+)
+
+// Store returns the seccomp profiles store.
+func Store(dataRoot, address string) (seccomp.ProfileStore, error) {
+	dataStore, err := clientutil.DataStore(dataRoot, address)
+	if err != nil {
+		return nil, err
+	}
+
+	return seccomp.NewProfileStore(dataStore)
+}
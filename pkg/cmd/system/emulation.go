@@ -0,0 +1,122 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package system
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/containerd/platforms"
+
+	"go.farcloser.world/lepton/leptonic/emulation"
+	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/formatter"
+)
+
+type emulationHandler struct {
+	*emulation.Handler
+}
+
+func (h emulationHandler) FormattedPlatforms() string {
+	return strings.Join(h.Platforms, ",")
+}
+
+// EmulationList lists the binfmt_misc handlers registered on the host, and the platforms they enable.
+func EmulationList(output io.Writer, opts *options.SystemEmulationList) error {
+	handlers, err := emulation.Handlers()
+	if err != nil {
+		return err
+	}
+
+	if opts.Quiet {
+		for _, handler := range handlers {
+			if _, err = fmt.Fprintln(output, handler.Name); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	items := make([]emulationHandler, len(handlers))
+	for i, handler := range handlers {
+		items[i] = emulationHandler{handler}
+	}
+
+	return formatter.Render(output, opts.Format, items, []formatter.Column{
+		{Header: "NAME", Field: "Name"},
+		{Header: "ENABLED", Field: "Enabled"},
+		{Header: "PLATFORMS", Field: "FormattedPlatforms"},
+		{Header: "INTERPRETER", Field: "Interpreter", Wide: true},
+		{Header: "FLAGS", Field: "Flags", Wide: true},
+	})
+}
+
+// EmulationInstall registers a static qemu binary from the directory set by --qemu-dir for each platform.
+func EmulationInstall(output io.Writer, globalOptions *options.Global, opts *options.SystemEmulationInstall) error {
+	var errList []error
+	for _, platform := range opts.Platforms {
+		parsed, err := platforms.Parse(platform)
+		if err != nil {
+			errList = append(errList, err)
+
+			continue
+		}
+
+		handler, err := emulation.Install(parsed, globalOptions.QemuDir)
+		if err != nil {
+			errList = append(errList, fmt.Errorf("%s: %w", platform, err))
+
+			continue
+		}
+
+		if _, err = fmt.Fprintf(output, "%s: registered %s (%s)\n", platform, handler.Name,
+			handler.Interpreter); err != nil {
+			return err
+		}
+	}
+
+	return errors.Join(errList...)
+}
+
+// EmulationUninstall unregisters the handlers enabling each platform.
+func EmulationUninstall(output io.Writer, opts *options.SystemEmulationUninstall) error {
+	var errList []error
+	for _, platform := range opts.Platforms {
+		parsed, err := platforms.Parse(platform)
+		if err != nil {
+			errList = append(errList, err)
+
+			continue
+		}
+
+		removed, err := emulation.Uninstall(parsed)
+		for _, name := range removed {
+			if _, writeErr := fmt.Fprintf(output, "%s: unregistered %s\n", platform, name); writeErr != nil {
+				return writeErr
+			}
+		}
+
+		if err != nil {
+			errList = append(errList, fmt.Errorf("%s: %w", platform, err))
+		}
+	}
+
+	return errors.Join(errList...)
+}
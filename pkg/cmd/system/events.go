@@ -23,20 +23,29 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 	"text/template"
 	"time"
 
-	_ "github.com/containerd/containerd/api/events" // Register grpc event types
+	eventstypes "github.com/containerd/containerd/api/events"
 	containerd "github.com/containerd/containerd/v2/client"
 	"github.com/containerd/containerd/v2/core/events"
 	"github.com/containerd/log"
 	"github.com/containerd/typeurl/v2"
 
+	"go.farcloser.world/lepton/leptonic/errs"
+	timetypes "go.farcloser.world/lepton/leptonic/time"
 	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/clientutil"
+	"go.farcloser.world/lepton/pkg/containerutil"
+	"go.farcloser.world/lepton/pkg/eventutil"
 	"go.farcloser.world/lepton/pkg/formatter"
 )
 
+// ErrEventsJournalDisabled is returned when recording events while the journal is not enabled.
+var ErrEventsJournalDisabled = errors.New("the events journal is disabled (see --events-journal)")
+
 // EventOut contains information about an event.
 type EventOut struct {
 	Timestamp time.Time
@@ -45,6 +54,9 @@ type EventOut struct {
 	Topic     string
 	Status    Status
 	Event     string
+	// Type is the type of object the event is about (container, image, network, volume...)
+	Type       string
+	Attributes map[string]string
 }
 
 type Status string
@@ -54,10 +66,23 @@ const (
 	UNKNOWN Status = "unknown"
 )
 
-var statuses = [...]Status{START, UNKNOWN}
+var statuses = [...]Status{
+	START,
+	UNKNOWN,
+	eventutil.ActionCreate,
+	eventutil.ActionDie,
+	eventutil.ActionOOM,
+	eventutil.ActionDestroy,
+	eventutil.ActionPull,
+	eventutil.ActionPush,
+	eventutil.ActionTag,
+	eventutil.ActionDelete,
+	eventutil.ActionHealthStatus,
+}
 
 func isStatus(status string) bool {
-	status = strings.ToLower(status)
+	// Health events carry the new health status, as in "health_status: healthy"
+	status, _, _ = strings.Cut(strings.ToLower(status), ":")
 
 	for _, supportedStatus := range statuses {
 		if string(supportedStatus) == status {
@@ -76,6 +101,20 @@ func TopicToStatus(topic string) Status {
 	return UNKNOWN
 }
 
+// topicToType returns the type of object a containerd event topic is about, e.g., "container" for "/tasks/exit"
+func topicToType(topic string) string {
+	switch {
+	case strings.HasPrefix(topic, "/containers/"), strings.HasPrefix(topic, "/tasks/"):
+		return string(eventutil.TypeContainer)
+	case strings.HasPrefix(topic, "/images/"):
+		return string(eventutil.TypeImage)
+	}
+
+	kind, _, _ := strings.Cut(strings.TrimPrefix(topic, "/"), "/")
+
+	return strings.TrimSuffix(kind, "s")
+}
+
 // EventFilter for filtering events
 type EventFilter func(*EventOut) bool
 
@@ -89,7 +128,33 @@ func generateEventFilter(filter, filterValue string) (func(e *EventOut) bool, er
 				return false
 			}
 
-			return strings.EqualFold(string(e.Status), filterValue)
+			action, _, _ := strings.Cut(string(e.Status), ":")
+
+			return strings.EqualFold(string(e.Status), filterValue) || strings.EqualFold(action, filterValue)
+		}, nil
+	case "TYPE":
+		return func(e *EventOut) bool {
+			return strings.EqualFold(e.Type, filterValue)
+		}, nil
+	case "CONTAINER":
+		return func(e *EventOut) bool {
+			return e.Type == string(eventutil.TypeContainer) &&
+				(strings.HasPrefix(e.ID, filterValue) || e.Attributes[eventutil.AttributeName] == filterValue)
+		}, nil
+	case "IMAGE":
+		// Also matches events about containers created from the image
+		return func(e *EventOut) bool {
+			return (e.Type == string(eventutil.TypeImage) && e.ID == filterValue) ||
+				e.Attributes[eventutil.AttributeImage] == filterValue
+		}, nil
+	case "NETWORK":
+		return func(e *EventOut) bool {
+			return e.Type == string(eventutil.TypeNetwork) &&
+				(e.ID == filterValue || e.Attributes[eventutil.AttributeName] == filterValue)
+		}, nil
+	case "VOLUME":
+		return func(e *EventOut) bool {
+			return e.Type == string(eventutil.TypeVolume) && e.ID == filterValue
 		}, nil
 	}
 
@@ -146,15 +211,15 @@ func generateEventFilters(filters []string) (map[string][]EventFilter, error) {
 }
 
 // Events is from https://github.com/containerd/containerd/blob/v1.4.3/cmd/ctr/commands/events/events.go
+// When opts.Since or opts.Until are set, recorded events are first replayed from the journal.
+// Live events are then streamed, unless opts.Until is in the past.
 func Events(
 	ctx context.Context,
 	client *containerd.Client,
 	output io.Writer,
-	_ *options.Global,
+	globalOptions *options.Global,
 	opts *options.SystemEvents,
 ) error {
-	eventsClient := client.EventService()
-	eventsCh, errCh := eventsClient.Subscribe(ctx)
 	var tmpl *template.Template
 	switch opts.Format {
 	case formatter.FormatNone:
@@ -172,63 +237,302 @@ func Events(
 	if err != nil {
 		return err
 	}
+
+	now := time.Now()
+	var since, until time.Time
+	if opts.Since != "" {
+		if since, err = timetypes.ParseTimestamp(opts.Since, now); err != nil {
+			return fmt.Errorf("invalid value for \"since\": %w", err)
+		}
+	}
+	if opts.Until != "" {
+		if until, err = timetypes.ParseTimestamp(opts.Until, now); err != nil {
+			return fmt.Errorf("invalid value for \"until\": %w", err)
+		}
+	}
+
+	emit := func(eOut *EventOut) error {
+		if !applyFilters(eOut, filterMap) {
+			return nil
+		}
+		if tmpl != nil {
+			var b bytes.Buffer
+			if err := tmpl.Execute(&b, eOut); err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintln(output, b.String()+"\n"); err != nil {
+				return err
+			}
+		} else {
+			if _, err := fmt.Fprintln(
+				output,
+				eOut.Timestamp,
+				eOut.Namespace,
+				eOut.Topic,
+				eOut.Event,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	streamLive := until.IsZero() || until.After(now)
+
+	// Subscribe before replaying, so that no event is missed in-between
+	var (
+		eventsCh <-chan *events.Envelope
+		errCh    <-chan error
+	)
+	if streamLive {
+		eventsClient := client.EventService()
+		eventsCh, errCh = eventsClient.Subscribe(ctx)
+	}
+
+	if !since.IsZero() || !until.IsZero() {
+		if err = replayEvents(ctx, globalOptions, since, until, emit); err != nil {
+			return err
+		}
+	}
+
+	if !streamLive {
+		return nil
+	}
+
+	var untilCh <-chan time.Time
+	if !until.IsZero() {
+		timer := time.NewTimer(until.Sub(now))
+		defer timer.Stop()
+		untilCh = timer.C
+	}
+
 	for {
 		var e *events.Envelope
 		select {
 		case e = <-eventsCh:
 		case err := <-errCh:
 			return err
+		case <-untilCh:
+			return nil
 		}
-		if e != nil {
-			var out []byte
-			var id string
-			if e.Event != nil {
-				v, err := typeurl.UnmarshalAny(e.Event)
-				if err != nil {
-					log.G(ctx).WithError(err).Warn("cannot unmarshal an event from Any")
-					continue
-				}
-				out, err = json.Marshal(v)
-				if err != nil {
-					log.G(ctx).WithError(err).Warn("cannot marshal Any into JSON")
-					continue
+		if e == nil {
+			continue
+		}
+
+		eOut, err := liveEventOut(e)
+		if err != nil {
+			log.G(ctx).WithError(err).Warn("cannot decode an event")
+			continue
+		}
+		if eOut.Type == string(eventutil.TypeContainer) && eOut.ID != "" {
+			if info, err := client.ContainerService().Get(ctx, eOut.ID); err == nil {
+				if eOut.Attributes == nil {
+					eOut.Attributes = map[string]string{}
 				}
+				eOut.Attributes[eventutil.AttributeName] = containerutil.GetContainerName(info.Labels)
+				eOut.Attributes[eventutil.AttributeImage] = info.Image
 			}
-			var data map[string]interface{}
-			err := json.Unmarshal(out, &data)
-			if err != nil {
-				log.G(ctx).WithError(err).Warn("cannot marshal Any into JSON")
-			} else {
-				_, ok := data["container_id"]
-				if ok {
-					id = data["container_id"].(string)
-				}
+		}
+		if err = emit(eOut); err != nil {
+			return err
+		}
+	}
+}
+
+// liveEventOut converts a containerd event, giving it the type and action the journal records for the same event
+// (e.g., "die" for "/tasks/exit"), so that filters match live and replayed events alike.
+// Events without a journal counterpart have an unknown action.
+func liveEventOut(e *events.Envelope) (*EventOut, error) {
+	eOut := &EventOut{
+		Timestamp: e.Timestamp,
+		Namespace: e.Namespace,
+		Topic:     e.Topic,
+		Status:    UNKNOWN,
+		Type:      topicToType(e.Topic),
+	}
+	if e.Event == nil {
+		return eOut, nil
+	}
+
+	v, err := typeurl.UnmarshalAny(e.Event)
+	if err != nil {
+		return nil, err
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	eOut.Event = string(out)
+
+	switch typed := v.(type) {
+	case *eventstypes.TaskStart:
+		eOut.ID, eOut.Status = typed.ContainerID, START
+	case *eventstypes.TaskExit:
+		eOut.ID = typed.ContainerID
+		// Exits of exec processes have no counterpart
+		if typed.ID == typed.ContainerID {
+			eOut.Status = eventutil.ActionDie
+			eOut.Attributes = map[string]string{
+				eventutil.AttributeExitCode: strconv.FormatUint(uint64(typed.ExitStatus), 10),
 			}
+		}
+	case *eventstypes.TaskOOM:
+		eOut.ID, eOut.Status = typed.ContainerID, eventutil.ActionOOM
+	case *eventstypes.ContainerCreate:
+		eOut.ID, eOut.Status = typed.ID, eventutil.ActionCreate
+		eOut.Attributes = map[string]string{eventutil.AttributeImage: typed.Image}
+	case *eventstypes.ContainerDelete:
+		eOut.ID, eOut.Status = typed.ID, eventutil.ActionDestroy
+	case *eventstypes.ImageCreate:
+		// Both pulls and tags create images: only the commands triggering them can tell
+		eOut.ID = typed.Name
+	case *eventstypes.ImageDelete:
+		eOut.ID, eOut.Status = typed.Name, eventutil.ActionDelete
+	default:
+		var data map[string]interface{}
+		if err = json.Unmarshal(out, &data); err == nil {
+			if id, ok := data["container_id"].(string); ok {
+				eOut.ID = id
+			}
+		}
+	}
 
-			eOut := EventOut{e.Timestamp, id, e.Namespace, e.Topic, TopicToStatus(e.Topic), string(out)}
-			match := applyFilters(&eOut, filterMap)
-			if match {
-				if tmpl != nil {
-					var b bytes.Buffer
-					if err := tmpl.Execute(&b, eOut); err != nil {
-						return err
-					}
-					if _, err := fmt.Fprintln(output, b.String()+"\n"); err != nil {
-						return err
-					}
-				} else {
-					if _, err := fmt.Fprintln(
-						output,
-						e.Timestamp,
-						e.Namespace,
-						e.Topic,
-						string(out),
-					); err != nil {
-						return err
-					}
-				}
+	return eOut, nil
+}
+
+// journalEventOut converts an event recorded in the journal.
+func journalEventOut(event *eventutil.Event) (*EventOut, error) {
+	out, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	action, _, _ := strings.Cut(event.Action, ":")
+
+	return &EventOut{
+		Timestamp:  event.Time,
+		ID:         event.ID,
+		Namespace:  event.Namespace,
+		Topic:      fmt.Sprintf("/%s/%s", event.Type, action),
+		Status:     Status(event.Action),
+		Event:      string(out),
+		Type:       string(event.Type),
+		Attributes: event.Attributes,
+	}, nil
+}
+
+// replayEvents emits the events recorded in the journal between since and until (when not zero)
+func replayEvents(
+	ctx context.Context,
+	globalOptions *options.Global,
+	since, until time.Time,
+	emit func(*EventOut) error,
+) error {
+	if !globalOptions.EventsJournal {
+		log.G(ctx).Warn(ErrEventsJournalDisabled.Error() + ": only previously recorded events will be replayed")
+	}
+
+	dataStore, err := clientutil.DataStore(globalOptions.DataRoot, globalOptions.Address)
+	if err != nil {
+		return err
+	}
+
+	journal, err := eventutil.NewJournal(dataStore, globalOptions.Namespace, eventutil.DefaultJournalSize)
+	if err != nil {
+		return err
+	}
+
+	recorded, err := journal.Read()
+	if err != nil {
+		return err
+	}
+
+	for _, event := range recorded {
+		if (!since.IsZero() && event.Time.Before(since)) || (!until.IsZero() && event.Time.After(until)) {
+			continue
+		}
+
+		eOut, err := journalEventOut(&event)
+		if err != nil {
+			return err
+		}
+		if err = emit(eOut); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// EventsRecord records container exit and OOM events into the journal, until ctx is done.
+// Other events are recorded by the commands that trigger them, but these can only be observed from containerd.
+func EventsRecord(ctx context.Context, client *containerd.Client, globalOptions *options.Global) error {
+	if !globalOptions.EventsJournal {
+		return errors.Join(errs.ErrFailedPrecondition, ErrEventsJournalDisabled)
+	}
+
+	eventsCh, errCh := client.EventService().Subscribe(
+		ctx,
+		fmt.Sprintf(`namespace==%q,topic=="/tasks/exit"`, globalOptions.Namespace),
+		fmt.Sprintf(`namespace==%q,topic=="/tasks/oom"`, globalOptions.Namespace),
+	)
+
+	log.G(ctx).Infof("recording events for namespace %q", globalOptions.Namespace)
+
+	for {
+		var (
+			e  *events.Envelope
+			ok bool
+		)
+		select {
+		case e, ok = <-eventsCh:
+			if !ok {
+				return nil
 			}
+		case err := <-errCh:
+			return err
+		}
+
+		if e.Event == nil {
+			continue
+		}
+
+		v, err := typeurl.UnmarshalAny(e.Event)
+		if err != nil {
+			log.G(ctx).WithError(err).Warn("cannot unmarshal an event from Any")
+			continue
+		}
 
+		event := eventutil.Event{
+			Time:      e.Timestamp,
+			Namespace: e.Namespace,
+			Type:      eventutil.TypeContainer,
 		}
+
+		switch typed := v.(type) {
+		case *eventstypes.TaskExit:
+			// Ignore exec processes exits
+			if typed.ID != typed.ContainerID {
+				continue
+			}
+			event.Action = eventutil.ActionDie
+			event.ID = typed.ContainerID
+			event.Attributes = map[string]string{
+				eventutil.AttributeExitCode: strconv.FormatUint(uint64(typed.ExitStatus), 10),
+			}
+		case *eventstypes.TaskOOM:
+			event.Action = eventutil.ActionOOM
+			event.ID = typed.ContainerID
+			event.Attributes = map[string]string{}
+		default:
+			continue
+		}
+
+		if info, err := client.ContainerService().Get(ctx, event.ID); err == nil {
+			event.Attributes[eventutil.AttributeName] = containerutil.GetContainerName(info.Labels)
+			event.Attributes[eventutil.AttributeImage] = info.Image
+		}
+
+		eventutil.Record(ctx, globalOptions, event)
 	}
 }
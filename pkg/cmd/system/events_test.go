@@ -0,0 +1,117 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package system
+
+import (
+	"testing"
+	"time"
+
+	eventstypes "github.com/containerd/containerd/api/events"
+	"github.com/containerd/containerd/v2/core/events"
+	"github.com/containerd/typeurl/v2"
+	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/lepton/pkg/eventutil"
+)
+
+func TestEventFiltersMatchLiveAndReplayedEvents(t *testing.T) {
+	t.Parallel()
+
+	const id = "0123456789ab"
+	now := time.Now()
+
+	testCases := []struct {
+		name      string
+		topic     string
+		event     any
+		action    string
+		matching  [][]string
+		different [][]string
+	}{
+		{
+			name:      "die",
+			topic:     "/tasks/exit",
+			event:     &eventstypes.TaskExit{ContainerID: id, ID: id, ExitStatus: 137},
+			action:    eventutil.ActionDie,
+			matching:  [][]string{{"event=die"}, {"type=container", "status=die"}, {"container=" + id[:6]}},
+			different: [][]string{{"event=start"}, {"type=image"}},
+		},
+		{
+			name:      "oom",
+			topic:     "/tasks/oom",
+			event:     &eventstypes.TaskOOM{ContainerID: id},
+			action:    eventutil.ActionOOM,
+			matching:  [][]string{{"event=oom"}, {"event=die", "event=oom"}},
+			different: [][]string{{"event=die"}},
+		},
+		{
+			name:      "start",
+			topic:     "/tasks/start",
+			event:     &eventstypes.TaskStart{ContainerID: id, Pid: 42},
+			action:    eventutil.ActionStart,
+			matching:  [][]string{{"event=start"}, {"container=" + id}},
+			different: [][]string{{"event=create"}},
+		},
+		{
+			name:      "destroy",
+			topic:     "/containers/delete",
+			event:     &eventstypes.ContainerDelete{ID: id},
+			action:    eventutil.ActionDestroy,
+			matching:  [][]string{{"event=destroy"}, {"type=container"}},
+			different: [][]string{{"event=delete"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			payload, err := typeurl.MarshalAny(tc.event)
+			assert.NilError(t, err)
+			live, err := liveEventOut(&events.Envelope{
+				Timestamp: now,
+				Namespace: "default",
+				Topic:     tc.topic,
+				Event:     payload,
+			})
+			assert.NilError(t, err)
+
+			replayed, err := journalEventOut(&eventutil.Event{
+				Time:      now,
+				Namespace: "default",
+				Type:      eventutil.TypeContainer,
+				Action:    tc.action,
+				ID:        id,
+			})
+			assert.NilError(t, err)
+
+			for _, filters := range tc.matching {
+				filterMap, err := generateEventFilters(filters)
+				assert.NilError(t, err)
+				assert.Assert(t, applyFilters(live, filterMap), "live event does not match %v", filters)
+				assert.Assert(t, applyFilters(replayed, filterMap), "replayed event does not match %v", filters)
+			}
+
+			for _, filters := range tc.different {
+				filterMap, err := generateEventFilters(filters)
+				assert.NilError(t, err)
+				assert.Assert(t, !applyFilters(live, filterMap), "live event matches %v", filters)
+				assert.Assert(t, !applyFilters(replayed, filterMap), "replayed event matches %v", filters)
+			}
+		})
+	}
+}
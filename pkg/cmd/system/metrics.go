@@ -0,0 +1,171 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package system
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/log"
+
+	"go.farcloser.world/containers/stats"
+
+	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/clientutil"
+	"go.farcloser.world/lepton/pkg/cmd/container"
+	"go.farcloser.world/lepton/pkg/cmd/volume"
+	"go.farcloser.world/lepton/pkg/metricsutil"
+)
+
+// MetricsServe exposes containers and engine metrics over http, in the OpenMetrics text format, or the Prometheus text
+// format for scrapers that do not accept OpenMetrics, until ctx is done.
+// Metrics are collected on every scrape, for the namespace in globalOptions.
+func MetricsServe(
+	ctx context.Context,
+	client *containerd.Client,
+	globalOptions *options.Global,
+	opts options.SystemMetricsServe,
+) error {
+	collector := &metricsCollector{
+		client:        client,
+		globalOptions: globalOptions,
+		previous:      map[string]*stats.ContainerStats{},
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", collector)
+
+	listener, err := net.Listen("tcp", opts.Addr)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	log.G(ctx).Infof("serving metrics on http://%s/metrics", listener.Addr())
+
+	if err = server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+type metricsCollector struct {
+	client        *containerd.Client
+	globalOptions *options.Global
+
+	// mu serializes scrapes, as previous is shared between them to compute CPU usage
+	mu       sync.Mutex
+	previous map[string]*stats.ContainerStats
+}
+
+func (mc *metricsCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	families, err := mc.collect(r.Context())
+	if err != nil {
+		log.G(r.Context()).WithError(err).Error("failed to collect metrics")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Prometheus asks for OpenMetrics when it supports it, other scrapers only know about the text format
+	write := metricsutil.WriteText
+	contentType := metricsutil.TextContentType
+	if strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text") {
+		write, contentType = metricsutil.Write, metricsutil.ContentType
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	if err = write(w, families); err != nil {
+		log.G(r.Context()).WithError(err).Debug("failed to write metrics")
+	}
+}
+
+func (mc *metricsCollector) collect(ctx context.Context) ([]*metricsutil.Family, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	namespace := mc.globalOptions.Namespace
+	samples, err := container.Metrics(ctx, mc.client, namespace, mc.previous)
+	if err != nil {
+		return nil, err
+	}
+
+	containers, err := mc.client.ContainerService().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := mc.client.ImageService().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	volStore, err := volume.Store(namespace, mc.globalOptions.DataRoot, mc.globalOptions.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	volumes, err := volStore.Count()
+	if err != nil {
+		return nil, err
+	}
+
+	dataStore, err := clientutil.DataStore(mc.globalOptions.DataRoot, mc.globalOptions.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	counterStore, err := metricsutil.NewCounterStore(dataStore, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	counters, err := counterStore.List()
+	if err != nil {
+		return nil, err
+	}
+
+	families := metricsutil.EngineFamilies(metricsutil.EngineState{
+		Namespace:  namespace,
+		Containers: len(containers),
+		Images:     len(images),
+		Volumes:    volumes,
+		Counters:   counters,
+	})
+
+	return append(families, metricsutil.ContainerFamilies(samples)...), nil
+}
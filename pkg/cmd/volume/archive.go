@@ -0,0 +1,34 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package volume
+
+import "strings"
+
+// Compressions lists the supported compressions of volume archives.
+var Compressions = []string{"none", "gzip", "zstd"}
+
+// CompressionFromPath infers the compression of a volume archive from its file name.
+func CompressionFromPath(location string) string {
+	switch {
+	case strings.HasSuffix(location, ".zst"), strings.HasSuffix(location, ".zstd"):
+		return "zstd"
+	case strings.HasSuffix(location, ".gz"), strings.HasSuffix(location, ".tgz"):
+		return "gzip"
+	default:
+		return "none"
+	}
+}
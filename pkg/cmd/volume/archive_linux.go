@@ -0,0 +1,583 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package volume
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/pkg/archive/compression"
+	"github.com/containerd/errdefs"
+	"github.com/containerd/log"
+
+	"go.farcloser.world/lepton/leptonic/api"
+	"go.farcloser.world/lepton/leptonic/errs"
+	"go.farcloser.world/lepton/leptonic/utils"
+	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/containerutil"
+	"go.farcloser.world/lepton/pkg/eventutil"
+	"go.farcloser.world/lepton/pkg/inspecttypes/dockercompat"
+	"go.farcloser.world/lepton/pkg/labels"
+	"go.farcloser.world/lepton/pkg/mountutil"
+	"go.farcloser.world/lepton/pkg/mountutil/volumestore"
+	"go.farcloser.world/lepton/pkg/tarutil"
+)
+
+// A volume archive is a tar archive holding a metadata entry, followed by the content of the volume.
+const (
+	archiveMetadata = "volume.json"
+	archiveData     = "_data"
+	// maxMetadataSize bounds the size of the metadata entry
+	maxMetadataSize = 1 << 20
+)
+
+type archiveMetadataJSON struct {
+	Labels  map[string]string `json:"labels,omitempty"`
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// Export writes an archive of a volume, labels, options and content, to opts.Stdout.
+func Export(
+	ctx context.Context,
+	client *containerd.Client,
+	globalOptions *options.Global,
+	name string,
+	opts *options.VolumeExport,
+) error {
+	comp, err := parseCompression(opts.Compression)
+	if err != nil {
+		return err
+	}
+
+	volStore, err := Store(globalOptions.Namespace, globalOptions.DataRoot, globalOptions.Address)
+	if err != nil {
+		return err
+	}
+
+	vol, err := volStore.Get(name, false)
+	if err != nil {
+		return err
+	}
+
+	resume, err := quiesceUsers(ctx, client, name, opts.PauseUsers)
+	if err != nil {
+		return err
+	}
+	defer resume()
+
+	release, err := mountForTransfer(ctx, volStore, name)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	writer, err := compression.CompressStream(opts.Stdout, comp)
+	if err != nil {
+		return err
+	}
+
+	if err = writeArchive(writer, vol); err != nil {
+		_ = writer.Close()
+		return err
+	}
+
+	return writer.Close()
+}
+
+// Import extracts a volume archive read from opts.Stdin into a volume, creating it with the archived labels and
+// opts.Options if it does not exist.
+// Of the archived options, only the size is restored: the archive is not trusted to decide what gets mounted on the
+// volume, and written to (eg: "type=none,o=bind,device=/etc").
+func Import(
+	ctx context.Context,
+	client *containerd.Client,
+	output io.Writer,
+	globalOptions *options.Global,
+	name string,
+	opts *options.VolumeImport,
+) (err error) {
+	reader, err := compression.DecompressStream(opts.Stdin)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	tr := tar.NewReader(reader)
+	metadata, err := readMetadata(tr)
+	if err != nil {
+		return err
+	}
+
+	volStore, err := Store(globalOptions.Namespace, globalOptions.DataRoot, globalOptions.Address)
+	if err != nil {
+		return err
+	}
+
+	exists, err := volStore.Exists(name)
+	if err != nil {
+		return err
+	}
+
+	if exists && len(opts.Options) > 0 {
+		return fmt.Errorf("%w: volume %q already exists, options only apply to a volume created by the import",
+			errs.ErrInvalidArgument, name)
+	}
+
+	if !exists {
+		if metadata.Labels == nil {
+			metadata.Labels = map[string]string{}
+		}
+		// The volume is named now
+		delete(metadata.Labels, labels.AnonymousVolumes)
+
+		volOptions := opts.Options
+		if len(volOptions) == 0 {
+			volOptions = archivedOptions(ctx, metadata.Options)
+		}
+
+		if _, err = volStore.Create(name, metadata.Labels, volOptions); err != nil {
+			return err
+		}
+
+		// Do not leave a partial import behind
+		defer func() {
+			if err != nil {
+				_, _, removeErr := volStore.Remove(func() ([]string, []error, error) {
+					return []string{name}, nil, nil
+				})
+				if removeErr != nil {
+					log.G(ctx).WithError(removeErr).Errorf("failed removing volume %q", name)
+				}
+			}
+		}()
+	}
+
+	resume, err := quiesceUsers(ctx, client, name, opts.PauseUsers)
+	if err != nil {
+		return err
+	}
+	defer resume()
+
+	release, err := mountForTransfer(ctx, volStore, name)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	vol, err := volStore.Get(name, false)
+	if err != nil {
+		return err
+	}
+
+	if err = unpackData(tr, vol.Mountpoint); err != nil {
+		return err
+	}
+
+	if !exists {
+		eventutil.Record(ctx, globalOptions, eventutil.Event{
+			Type:   eventutil.TypeVolume,
+			Action: eventutil.ActionCreate,
+			ID:     name,
+		})
+	}
+
+	_, err = fmt.Fprintln(output, name)
+
+	return err
+}
+
+// archivedOptions returns the options of an archive that can be restored, that is the size only.
+func archivedOptions(ctx context.Context, archived map[string]string) map[string]string {
+	var res map[string]string
+	for key, value := range archived {
+		if key == volumestore.OptionSize {
+			res = map[string]string{key: value}
+			continue
+		}
+
+		log.G(ctx).Warnf("ignoring archived volume option %q, set the options of the volume with --opt instead", key)
+	}
+
+	return res
+}
+
+// Clone creates a volume with the labels and content of opts.From.
+func Clone(
+	ctx context.Context,
+	client *containerd.Client,
+	output io.Writer,
+	globalOptions *options.Global,
+	opts *options.VolumeCreate,
+) (err error) {
+	volStore, err := Store(globalOptions.Namespace, globalOptions.DataRoot, globalOptions.Address)
+	if err != nil {
+		return err
+	}
+
+	src, err := volStore.Get(opts.From, false)
+	if err != nil {
+		return err
+	}
+
+	if opts.Name != "" {
+		if exists, err := volStore.Exists(opts.Name); err != nil {
+			return err
+		} else if exists {
+			return fmt.Errorf("%w: volume %q already exists", errs.ErrFailedPrecondition, opts.Name)
+		}
+	}
+
+	lbls := map[string]string{}
+	for k, v := range src.Labels {
+		lbls[k] = v
+	}
+	// Whether the clone is anonymous depends on its own name only
+	delete(lbls, labels.AnonymousVolumes)
+	for k, v := range opts.Labels {
+		lbls[k] = v
+	}
+
+	dst, err := volStore.Create(opts.Name, lbls, opts.Options)
+	if err != nil {
+		return err
+	}
+
+	// Do not leave a partial copy behind
+	defer func() {
+		if err != nil {
+			_, _, removeErr := volStore.Remove(func() ([]string, []error, error) {
+				return []string{dst.Name}, nil, nil
+			})
+			if removeErr != nil {
+				log.G(ctx).WithError(removeErr).Errorf("failed removing volume %q", dst.Name)
+			}
+		}
+	}()
+
+	resume, err := quiesceUsers(ctx, client, src.Name, opts.PauseUsers)
+	if err != nil {
+		return err
+	}
+	defer resume()
+
+	for _, name := range []string{src.Name, dst.Name} {
+		release, err := mountForTransfer(ctx, volStore, name)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+
+	reader, writer := io.Pipe()
+	packed := make(chan error, 1)
+	go func() {
+		packErr := tarutil.Pack(writer, src.Mountpoint, tarutil.PackOptions{Name: archiveData})
+		_ = writer.CloseWithError(packErr)
+		packed <- packErr
+	}()
+
+	err = unpackData(tar.NewReader(reader), dst.Mountpoint)
+	_ = reader.CloseWithError(err)
+
+	// A failure to pack is also seen by unpack, which has more context
+	if packErr := <-packed; err == nil {
+		err = packErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	eventutil.Record(ctx, globalOptions, eventutil.Event{
+		Type:   eventutil.TypeVolume,
+		Action: eventutil.ActionCreate,
+		ID:     dst.Name,
+	})
+
+	_, err = fmt.Fprintln(output, dst.Name)
+
+	return err
+}
+
+// mountForTransfer mounts a volume with options for the duration of an export, import or clone.
+func mountForTransfer(ctx context.Context, volStore volumestore.VolumeService, name string) (func(), error) {
+	user := "transfer-" + utils.GenerateID(utils.ID32)
+	if err := volStore.Mount(name, user); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		if err := volStore.Unmount(name, user); err != nil {
+			log.G(ctx).WithError(err).Errorf("failed releasing volume %q", name)
+		}
+	}, nil
+}
+
+func writeArchive(w io.Writer, vol *api.Volume) error {
+	metadata, err := json.Marshal(&archiveMetadataJSON{Labels: vol.Labels, Options: vol.Options})
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	err = tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     archiveMetadata,
+		Mode:     0o644,
+		Size:     int64(len(metadata)),
+		Format:   tar.FormatPAX,
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err = tw.Write(metadata); err != nil {
+		return err
+	}
+
+	// The content follows in the same archive: flush, but do not close (which would write the end of archive marker)
+	if err = tw.Flush(); err != nil {
+		return err
+	}
+
+	return tarutil.Pack(w, vol.Mountpoint, tarutil.PackOptions{Name: archiveData})
+}
+
+func readMetadata(tr *tar.Reader) (*archiveMetadataJSON, error) {
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, errors.Join(tarutil.ErrInvalidArchive, err)
+	}
+
+	if hdr.Name != archiveMetadata || hdr.Typeflag != tar.TypeReg {
+		return nil, fmt.Errorf("%w: not a volume archive (first entry is %q)", tarutil.ErrInvalidArchive, hdr.Name)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(tr, maxMetadataSize))
+	if err != nil {
+		return nil, errors.Join(tarutil.ErrInvalidArchive, err)
+	}
+
+	var metadata archiveMetadataJSON
+	if err = json.Unmarshal(content, &metadata); err != nil {
+		return nil, errors.Join(tarutil.ErrInvalidArchive, err)
+	}
+
+	return &metadata, nil
+}
+
+// unpackData extracts the data entries read from tr into a volume mountpoint, including the ownership and mode of the
+// data directory itself.
+func unpackData(tr *tar.Reader, mountpoint string) error {
+	var root *tar.Header
+
+	reader, writer := io.Pipe()
+	stripped := make(chan error, 1)
+	go func() {
+		stripErr := stripDataPrefix(writer, tr, &root)
+		_ = writer.CloseWithError(stripErr)
+		stripped <- stripErr
+	}()
+
+	err := tarutil.Unpack(reader, mountpoint, tarutil.UnpackOptions{})
+	_ = reader.CloseWithError(err)
+
+	if stripErr := <-stripped; err == nil {
+		err = stripErr
+	}
+
+	if err != nil || root == nil {
+		return err
+	}
+
+	if err = os.Lchown(mountpoint, root.Uid, root.Gid); err != nil {
+		return err
+	}
+
+	if err = os.Chmod(mountpoint, root.FileInfo().Mode()); err != nil {
+		return err
+	}
+
+	return os.Chtimes(mountpoint, root.AccessTime, root.ModTime)
+}
+
+// stripDataPrefix rewrites the data entries of a volume archive relative to the data directory.
+// Anything outside the data directory is rejected. The header of the data directory itself is returned in root.
+func stripDataPrefix(w io.Writer, tr *tar.Reader, root **tar.Header) error {
+	tw := tar.NewWriter(w)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return errors.Join(tarutil.ErrInvalidArchive, err)
+		}
+
+		if hdr.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+
+		name, ok := relativeToData(hdr.Name)
+		if !ok {
+			return fmt.Errorf("%w: unexpected entry %q", tarutil.ErrInvalidArchive, hdr.Name)
+		}
+
+		if name == "" {
+			*root = hdr
+			continue
+		}
+
+		hdr.Name = name
+		if hdr.Typeflag == tar.TypeDir {
+			hdr.Name += "/"
+		}
+
+		if hdr.Typeflag == tar.TypeLink {
+			if hdr.Linkname, ok = relativeToData(hdr.Linkname); !ok || hdr.Linkname == "" {
+				return fmt.Errorf("%w: unexpected link target %q", tarutil.ErrInvalidArchive, hdr.Linkname)
+			}
+		}
+
+		if err = tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if _, err = io.Copy(tw, tr); err != nil {
+			return fmt.Errorf("failed to copy %q: %w", hdr.Name, err)
+		}
+	}
+
+	return tw.Close()
+}
+
+func relativeToData(name string) (string, bool) {
+	name = path.Clean(name)
+	if name == archiveData {
+		return "", true
+	}
+
+	return strings.CutPrefix(name, archiveData+"/")
+}
+
+func parseCompression(name string) (compression.Compression, error) {
+	switch name {
+	case "", "none":
+		return compression.Uncompressed, nil
+	case "gzip":
+		return compression.Gzip, nil
+	case "zstd":
+		return compression.Zstd, nil
+	default:
+		return compression.Uncompressed, fmt.Errorf("%w: unknown compression %q (supported: %s)",
+			errs.ErrInvalidArgument, name, strings.Join(Compressions, ", "))
+	}
+}
+
+// quiesceUsers ensures no running container is writing to the volume while its content is read or written.
+// Running containers using the volume are paused if pause is set, and are an error otherwise.
+// The returned function resumes the paused containers.
+func quiesceUsers(ctx context.Context, client *containerd.Client, name string, pause bool) (func(), error) {
+	running, err := runningUsers(ctx, client, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(running) > 0 && !pause {
+		return nil, fmt.Errorf("%w: volume %q is used by running containers %s (stop them, or use --pause-users)",
+			errs.ErrFailedPrecondition, name, strings.Join(running, ", "))
+	}
+
+	var paused []string
+	resume := func() {
+		for _, id := range paused {
+			if err := containerutil.Unpause(ctx, client, id); err != nil {
+				log.G(ctx).WithError(err).Errorf("failed resuming container %s", id)
+			}
+		}
+	}
+
+	for _, id := range running {
+		log.G(ctx).Debugf("pausing container %s using volume %q", id, name)
+		if err = containerutil.Pause(ctx, client, id); err != nil {
+			resume()
+			return nil, err
+		}
+
+		paused = append(paused, id)
+	}
+
+	return resume, nil
+}
+
+// runningUsers returns the IDs of the running containers using the volume.
+func runningUsers(ctx context.Context, client *containerd.Client, name string) ([]string, error) {
+	containers, err := client.Containers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var running []string
+	for _, c := range containers {
+		l, err := c.Labels(ctx)
+		if err != nil {
+			if errors.Is(err, errdefs.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+
+		var mounts []dockercompat.MountPoint
+		if err = json.Unmarshal([]byte(l[labels.Mounts]), &mounts); err != nil {
+			continue
+		}
+
+		uses := false
+		for _, m := range mounts {
+			uses = uses || (m.Type == mountutil.Volume && m.Name == name)
+		}
+
+		if !uses {
+			continue
+		}
+
+		task, err := c.Task(ctx, nil)
+		if err != nil {
+			continue
+		}
+
+		status, err := task.Status(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if status.Status == containerd.Running {
+			running = append(running, c.ID())
+		}
+	}
+
+	return running, nil
+}
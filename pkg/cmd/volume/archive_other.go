@@ -0,0 +1,49 @@
+//go:build !linux
+
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package volume
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	containerd "github.com/containerd/containerd/v2/client"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+	"go.farcloser.world/lepton/pkg/api/options"
+)
+
+func Export(_ context.Context, _ *containerd.Client, _ *options.Global, _ string, _ *options.VolumeExport) error {
+	return fmt.Errorf("%w: volume export is only supported on linux", errs.ErrFailedPrecondition)
+}
+
+func Import(
+	_ context.Context,
+	_ *containerd.Client,
+	_ io.Writer,
+	_ *options.Global,
+	_ string,
+	_ *options.VolumeImport,
+) error {
+	return fmt.Errorf("%w: volume import is only supported on linux", errs.ErrFailedPrecondition)
+}
+
+func Clone(_ context.Context, _ *containerd.Client, _ io.Writer, _ *options.Global, _ *options.VolumeCreate) error {
+	return fmt.Errorf("%w: volume cloning is only supported on linux", errs.ErrFailedPrecondition)
+}
@@ -21,20 +21,33 @@ import (
 	"fmt"
 	"io"
 
+	"go.farcloser.world/lepton/leptonic/errs"
 	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/eventutil"
+	"go.farcloser.world/lepton/pkg/rootlessutil"
 )
 
 func Create(ctx context.Context, output io.Writer, globalOptions *options.Global, opts *options.VolumeCreate) error {
+	if len(opts.Options) > 0 && rootlessutil.IsRootless() {
+		return fmt.Errorf("%w: volume options are not supported in rootless mode", errs.ErrFailedPrecondition)
+	}
+
 	volStore, err := Store(globalOptions.Namespace, globalOptions.DataRoot, globalOptions.Address)
 	if err != nil {
 		return err
 	}
 
-	vol, err := volStore.Create(opts.Name, opts.Labels)
+	vol, err := volStore.Create(opts.Name, opts.Labels, opts.Options)
 	if err != nil {
 		return err
 	}
 
+	eventutil.Record(ctx, globalOptions, eventutil.Event{
+		Type:   eventutil.TypeVolume,
+		Action: eventutil.ActionCreate,
+		ID:     vol.Name,
+	})
+
 	_, err = fmt.Fprintln(output, vol.Name)
 
 	return err
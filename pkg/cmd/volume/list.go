@@ -17,15 +17,13 @@
 package volume
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"sort"
 	"strconv"
 	"strings"
-	"text/tabwriter"
-	"text/template"
 
 	"github.com/containerd/containerd/v2/pkg/progress"
 	"github.com/containerd/log"
@@ -59,6 +57,9 @@ func List(ctx context.Context, output io.Writer, globalOptions *options.Global,
 		log.L.Warn("should use --filter=size and --size together")
 		opts.Size = true
 	}
+	if !opts.Quiet && (opts.Format == formatter.FormatWide || opts.Format == formatter.FormatCSV) {
+		opts.Size = true
+	}
 
 	vols, err := Volumes(
 		globalOptions.Namespace,
@@ -93,28 +94,15 @@ func removeSizeFilters(filters []string) []string {
 }
 
 func lsPrintOutput(w io.Writer, vols map[string]api.Volume, options *options.VolumeList) error {
-	var tmpl *template.Template
 	switch options.Format {
 	case formatter.FormatNone, formatter.FormatTable, formatter.FormatWide:
-		w = tabwriter.NewWriter(w, 4, 8, 4, ' ', 0)
-		if !options.Quiet {
-			if options.Size {
-				fmt.Fprintln(w, "VOLUME NAME\tDIRECTORY\tSIZE")
-			} else {
-				fmt.Fprintln(w, "VOLUME NAME\tDIRECTORY")
-			}
-		}
 	default:
 		if options.Quiet {
 			return errors.New("format and quiet must not be specified together")
 		}
-		var err error
-		tmpl, err = formatter.ParseTemplate(options.Format)
-		if err != nil {
-			return err
-		}
 	}
 
+	pp := make([]volumePrintable, 0, len(vols))
 	for _, v := range vols {
 		p := volumePrintable{
 			Driver:     "local",
@@ -129,26 +117,26 @@ func lsPrintOutput(w io.Writer, vols map[string]api.Volume, options *options.Vol
 		if options.Size {
 			p.Size = progress.Bytes(v.Size).String()
 		}
-		if tmpl != nil {
-			var b bytes.Buffer
-			if err := tmpl.Execute(&b, p); err != nil {
-				return err
-			}
-			if _, err := fmt.Fprintln(w, b.String()); err != nil {
-				return err
-			}
-		} else if options.Quiet {
+		pp = append(pp, p)
+	}
+	sort.Slice(pp, func(i, j int) bool {
+		return pp[i].Name < pp[j].Name
+	})
+
+	if options.Quiet {
+		for _, p := range pp {
 			fmt.Fprintln(w, p.Name)
-		} else if options.Size {
-			fmt.Fprintf(w, "%s\t%s\t%s\n", p.Name, p.Mountpoint, p.Size)
-		} else {
-			fmt.Fprintf(w, "%s\t%s\n", p.Name, p.Mountpoint)
 		}
+		return nil
 	}
-	if f, ok := w.(formatter.Flusher); ok {
-		return f.Flush()
-	}
-	return nil
+
+	return formatter.Render(w, options.Format, pp, []formatter.Column{
+		{Header: "VOLUME NAME", Field: "Name"},
+		{Header: "DIRECTORY", Field: "Mountpoint"},
+		{Header: "DRIVER", Field: "Driver", Wide: true},
+		{Header: "LABELS", Field: "Labels", Wide: true},
+		{Header: "SIZE", Field: "Size", Wide: !options.Size},
+	})
 }
 
 // Volumes returns volumes that match the given filters.
@@ -28,6 +28,7 @@ import (
 	"github.com/containerd/log"
 
 	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/eventutil"
 	"go.farcloser.world/lepton/pkg/inspecttypes/dockercompat"
 	"go.farcloser.world/lepton/pkg/labels"
 	"go.farcloser.world/lepton/pkg/mountutil"
@@ -77,6 +78,11 @@ func Remove(
 	}
 	// Otherwise, output on stdout whatever was successful
 	for _, name := range removedNames {
+		eventutil.Record(ctx, globalOptions, eventutil.Event{
+			Type:   eventutil.TypeVolume,
+			Action: eventutil.ActionDestroy,
+			ID:     name,
+		})
 		fmt.Fprintln(output, name)
 	}
 	// Log the rest
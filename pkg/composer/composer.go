@@ -49,8 +49,10 @@ type Options struct {
 	VolumeExists     func(string) (bool, error)
 	ImageExists      func(ctx context.Context, imageName string) (bool, error)
 	EnsureImage      func(ctx context.Context, imageName, pullMode, platform string, ps *serviceparser.Service, quiet bool) error
-	DebugPrintFull   bool // full debug print, may leak secret env var to logs
-	Experimental     bool // enable experimental features
+	// DefaultNetwork, if set, replaces the implicit default network of the project (eg: from namespace defaults)
+	DefaultNetwork string
+	DebugPrintFull bool // full debug print, may leak secret env var to logs
+	Experimental   bool // enable experimental features
 }
 
 func New(o *Options, client *containerd.Client) (*Composer, error) {
@@ -107,6 +109,14 @@ func New(o *Options, client *containerd.Client) (*Composer, error) {
 		return nil, err
 	}
 
+	if o.DefaultNetwork != "" {
+		if net, ok := project.Networks["default"]; ok && isImplicitDefaultNetwork(project, net) {
+			net.Name = o.DefaultNetwork
+			net.External = true
+			project.Networks["default"] = net
+		}
+	}
+
 	if o.DebugPrintFull {
 		projectJSON, err := json.MarshalIndent(project, "", "    ")
 		if err != nil {
@@ -187,3 +197,14 @@ func (c *Composer) ServiceNames(svcs ...string) ([]string, error) {
 	}
 	return names, nil
 }
+
+// isImplicitDefaultNetwork returns true if net is the default network added by compose, without any configuration.
+func isImplicitDefaultNetwork(project *compose.Project, net compose.NetworkConfig) bool {
+	return net.Name == project.Name+"_default" &&
+		!bool(net.External) &&
+		net.Driver == "" &&
+		len(net.DriverOpts) == 0 &&
+		net.Ipam.Driver == "" &&
+		len(net.Ipam.Config) == 0 &&
+		len(net.Labels) == 0
+}
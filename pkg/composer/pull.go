@@ -29,6 +29,8 @@ import (
 
 type PullOptions struct {
 	Quiet bool
+	// Progress output mode of the pulls
+	Progress string
 }
 
 func (c *Composer) Pull(ctx context.Context, po PullOptions, services []string) error {
@@ -57,6 +59,9 @@ func (c *Composer) pullServiceImage(
 	if po.Quiet {
 		args = append(args, "--quiet")
 	}
+	if po.Progress != "" {
+		args = append(args, "--progress="+po.Progress)
+	}
 	if verifier, ok := ps.Unparsed.Extensions[serviceparser.ComposeVerify]; ok {
 		args = append(args, "--verify="+verifier.(string))
 	}
@@ -602,9 +602,17 @@ func newContainer(project *types.Project, parsed *Service, i int) (*Container, e
 			if value != nil && value.Ipv4Address != "" {
 				c.RunArgs = append(c.RunArgs, "--ip="+value.Ipv4Address)
 			}
+			if value != nil && value.Ipv6Address != "" {
+				c.RunArgs = append(c.RunArgs, "--ip6="+value.Ipv6Address)
+			}
 			if value != nil && value.MacAddress != "" {
 				c.RunArgs = append(c.RunArgs, "--mac-address="+value.MacAddress)
 			}
+			if value != nil {
+				for _, alias := range value.Aliases {
+					c.RunArgs = append(c.RunArgs, "--network-alias="+net.fullName+":"+alias)
+				}
+			}
 		}
 	}
 
@@ -700,6 +708,16 @@ func newContainer(project *types.Project, parsed *Service, i int) (*Container, e
 	}
 
 	for _, v := range svc.Volumes {
+		// Image mounts and volume subpaths can only be expressed with --mount
+		if v.Type == types.VolumeTypeImage || (v.Volume != nil && v.Volume.Subpath != "") {
+			mStr, err := serviceVolumeConfigToFlagMount(v, project)
+			if err != nil {
+				return nil, err
+			}
+			c.RunArgs = append(c.RunArgs, "--mount="+mStr)
+			continue
+		}
+
 		vStr, mkdir, err := serviceVolumeConfigToFlagV(v, project)
 		if err != nil {
 			return nil, err
@@ -855,6 +873,53 @@ func serviceVolumeConfigToFlagV(
 	return s, mkdir, nil
 }
 
+func serviceVolumeConfigToFlagMount(c types.ServiceVolumeConfig, project *types.Project) (string, error) {
+	if unknown := reflectutil.UnknownNonEmptyFields(&c,
+		"Type",
+		"Source",
+		"Target",
+		"ReadOnly",
+		"Volume",
+		"Image",
+	); len(unknown) > 0 {
+		log.L.Warnf("Ignoring: volume: %+v", unknown)
+	}
+
+	if c.Source == "" {
+		return "", fmt.Errorf("%s volume source is missing", c.Type)
+	}
+	if !filepath.IsAbs(c.Target) {
+		return "", fmt.Errorf("volume target must be an absolute path, got %q", c.Target)
+	}
+
+	var fields []string
+	switch c.Type {
+	case types.VolumeTypeImage:
+		fields = append(fields, "type=image", "source="+c.Source)
+		if c.Image != nil && c.Image.SubPath != "" {
+			fields = append(fields, "image-subpath="+c.Image.SubPath)
+		}
+	case types.VolumeTypeVolume:
+		vol, ok := project.Volumes[c.Source]
+		if !ok {
+			return "", fmt.Errorf("invalid volume %q", c.Source)
+		}
+		if unknown := reflectutil.UnknownNonEmptyFields(c.Volume, "Subpath"); len(unknown) > 0 {
+			log.L.Warnf("Ignoring: volume: Volume: %+v", unknown)
+		}
+		fields = append(fields, "type=volume", "source="+vol.Name, "volume-subpath="+c.Volume.Subpath)
+	default:
+		return "", fmt.Errorf("unsupported volume type: %q", c.Type)
+	}
+
+	fields = append(fields, "target="+c.Target)
+	if c.ReadOnly {
+		fields = append(fields, "readonly")
+	}
+
+	return strings.Join(fields, ","), nil
+}
+
 func fileReferenceConfigToFlagV(c types.FileReferenceConfig, project *types.Project, secret bool) (string, error) {
 	objType := "config"
 	if secret {
@@ -480,6 +480,50 @@ services:
 	}
 }
 
+func TestParseNetworkAddresses(t *testing.T) {
+	t.Parallel()
+	const dockerComposeYAML = `
+services:
+  foo:
+    image: nginx:alpine
+    networks:
+      backend:
+        ipv4_address: 10.42.0.10
+        ipv6_address: fd00:42::10
+        aliases:
+        - web
+        - www
+networks:
+  backend:
+    enable_ipv6: true
+    ipam:
+      config:
+      - subnet: 10.42.0.0/24
+      - subnet: fd00:42::/64
+`
+	comp := testutil.NewComposeDir(t, dockerComposeYAML)
+	defer comp.CleanUp()
+
+	project, err := testutil.LoadProject(comp.YAMLFullPath(), comp.ProjectName(), nil)
+	assert.NilError(t, err)
+
+	fooSvc, err := project.GetService("foo")
+	assert.NilError(t, err)
+
+	foo, err := Parse(project, fooSvc)
+	assert.NilError(t, err)
+
+	t.Logf("foo: %+v", foo)
+	netName := comp.ProjectName() + "_backend"
+	for _, c := range foo.Containers {
+		assert.Assert(t, in(c.RunArgs, "--net="+netName))
+		assert.Assert(t, in(c.RunArgs, "--ip=10.42.0.10"))
+		assert.Assert(t, in(c.RunArgs, "--ip6=fd00:42::10"))
+		assert.Assert(t, in(c.RunArgs, "--network-alias="+netName+":web"))
+		assert.Assert(t, in(c.RunArgs, "--network-alias="+netName+":www"))
+	}
+}
+
 func TestParseConfigs(t *testing.T) {
 	t.Parallel()
 	if runtime.GOOS == "windows" {
@@ -599,3 +643,49 @@ services:
 	c = getContainersFromService("unless_stopped")[0]
 	assert.Assert(t, in(c.RunArgs, "--restart=unless-stopped"))
 }
+
+func TestParseVolumeImageAndSubpath(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("test is not compatible with windows")
+	}
+	const dockerComposeYAML = `
+services:
+  foo:
+    image: nginx:alpine
+    volumes:
+    - type: image
+      source: example.com/models:v1
+      target: /models
+      image:
+        subpath: weights
+    - type: volume
+      source: data
+      target: /data
+      read_only: true
+      volume:
+        subpath: foo
+volumes:
+  data: {}
+`
+	comp := testutil.NewComposeDir(t, dockerComposeYAML)
+	defer comp.CleanUp()
+
+	project, err := testutil.LoadProject(comp.YAMLFullPath(), comp.ProjectName(), nil)
+	assert.NilError(t, err)
+
+	fooSvc, err := project.GetService("foo")
+	assert.NilError(t, err)
+
+	foo, err := Parse(project, fooSvc)
+	assert.NilError(t, err)
+
+	t.Logf("foo: %+v", foo)
+	for _, c := range foo.Containers {
+		assert.Assert(t, in(c.RunArgs,
+			"--mount=type=image,source=example.com/models:v1,image-subpath=weights,target=/models"))
+		assert.Assert(t, in(c.RunArgs,
+			"--mount=type=volume,source="+comp.ProjectName()+"_data,volume-subpath=foo,target=/data,readonly"))
+	}
+}
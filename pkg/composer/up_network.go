@@ -36,7 +36,8 @@ func (c *Composer) upNetwork(ctx context.Context, shortName string) error {
 		return nil
 	}
 
-	if unknown := reflectutil.UnknownNonEmptyFields(&net, "Name", "Ipam", "Driver", "DriverOpts"); len(unknown) > 0 {
+	unknown := reflectutil.UnknownNonEmptyFields(&net, "Name", "Ipam", "Driver", "DriverOpts", "EnableIPv6")
+	if len(unknown) > 0 {
 		log.G(ctx).Warnf("Ignoring: network %s: %+v", shortName, unknown)
 	}
 
@@ -63,11 +64,11 @@ func (c *Composer) upNetwork(ctx context.Context, shortName string) error {
 			}
 		}
 
-		if net.Ipam.Config != nil {
-			if len(net.Ipam.Config) > 1 {
-				log.G(ctx).Warnf("Ignoring: network %s: imam.config %+v", shortName, net.Ipam.Config[1:])
-			}
+		if net.EnableIPv6 != nil && *net.EnableIPv6 {
+			createArgs = append(createArgs, "--ipv6")
+		}
 
+		if net.Ipam.Config != nil {
 			ipamConfig := net.Ipam.Config[0]
 			if unknown := reflectutil.UnknownNonEmptyFields(ipamConfig, "Subnet", "Gateway", "IPRange"); len(unknown) > 0 {
 				log.G(ctx).Warnf("Ignoring: network %s: ipam.config[0]: %+v", shortName, unknown)
@@ -81,6 +82,16 @@ func (c *Composer) upNetwork(ctx context.Context, shortName string) error {
 			if ipamConfig.IPRange != "" {
 				createArgs = append(createArgs, "--ip-range="+ipamConfig.IPRange)
 			}
+
+			// Only the subnet of the additional configs (e.g., the IPv6 one of a dual-stack network) is supported
+			for i, ipamConfig := range net.Ipam.Config[1:] {
+				if unknown := reflectutil.UnknownNonEmptyFields(ipamConfig, "Subnet"); len(unknown) > 0 {
+					log.G(ctx).Warnf("Ignoring: network %s: ipam.config[%d]: %+v", shortName, i+1, unknown)
+				}
+				if ipamConfig.Subnet != "" {
+					createArgs = append(createArgs, "--subnet="+ipamConfig.Subnet)
+				}
+			}
 		}
 
 		createArgs = append(createArgs, fullName)
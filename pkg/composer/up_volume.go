@@ -19,6 +19,8 @@ package composer
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/containerd/log"
 
@@ -36,10 +38,14 @@ func (c *Composer) upVolume(ctx context.Context, shortName string) error {
 		return nil
 	}
 
-	if unknown := reflectutil.UnknownNonEmptyFields(&vol, "Name"); len(unknown) > 0 {
+	if unknown := reflectutil.UnknownNonEmptyFields(&vol, "Name", "Driver", "DriverOpts"); len(unknown) > 0 {
 		log.G(ctx).Warnf("Ignoring: volume %s: %+v", shortName, unknown)
 	}
 
+	if vol.Driver != "" && vol.Driver != "local" {
+		return fmt.Errorf("volume %s: unsupported driver %q", shortName, vol.Driver)
+	}
+
 	// shortName is like "db_data", fullName is like "compose-wordpress_db_data"
 	fullName := vol.Name
 	// FIXME: this is racy. By the time we get below to creating the volume, there is no guarantee that things are still
@@ -55,8 +61,12 @@ func (c *Composer) upVolume(ctx context.Context, shortName string) error {
 		createArgs := []string{
 			fmt.Sprintf("--label=%s=%s", labels.ComposeProject, c.project.Name),
 			fmt.Sprintf("--label=%s=%s", labels.ComposeVolume, shortName),
-			fullName,
 		}
+		// driver_opts are the `local` driver options (type, device, o)
+		for _, k := range slices.Sorted(maps.Keys(vol.DriverOpts)) {
+			createArgs = append(createArgs, fmt.Sprintf("--opt=%s=%s", k, vol.DriverOpts[k]))
+		}
+		createArgs = append(createArgs, fullName)
 		if err := c.runCliCmd(ctx, append([]string{"volume", "create"}, createArgs...)...); err != nil {
 			return err
 		}
@@ -0,0 +1,82 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+)
+
+// FormatAddressPools returns the address pools as base=BASE,size=SIZE flag values.
+func FormatAddressPools(pools []AddressPool) []string {
+	res := make([]string, len(pools))
+	for i, pool := range pools {
+		res[i] = fmt.Sprintf("base=%s,size=%d", pool.Base, pool.Size)
+	}
+
+	return res
+}
+
+// ParseAddressPools parses base=BASE,size=SIZE flag values into address pools.
+// Pools must be IPv4, IPv6 subnets being allocated from the IPv6 pool instead.
+func ParseAddressPools(values []string) ([]AddressPool, error) {
+	res := make([]AddressPool, 0, len(values))
+	for _, value := range values {
+		pool := AddressPool{}
+		for _, field := range strings.Split(value, ",") {
+			key, val, _ := strings.Cut(field, "=")
+			switch key {
+			case "base":
+				pool.Base = val
+			case "size":
+				size, err := strconv.Atoi(val)
+				if err != nil {
+					return nil, fmt.Errorf("%w: invalid size in address pool %q", errs.ErrInvalidArgument, value)
+				}
+				pool.Size = size
+			default:
+				return nil, fmt.Errorf(
+					"%w: invalid address pool %q, expected base=BASE,size=SIZE",
+					errs.ErrInvalidArgument,
+					value,
+				)
+			}
+		}
+
+		_, base, err := net.ParseCIDR(pool.Base)
+		if err != nil || base.IP.To4() == nil {
+			return nil, fmt.Errorf("%w: invalid IPv4 base in address pool %q", errs.ErrInvalidArgument, value)
+		}
+		if ones, bits := base.Mask.Size(); pool.Size < ones || pool.Size > bits {
+			return nil, fmt.Errorf(
+				"%w: size of address pool %q must be between %d and %d",
+				errs.ErrInvalidArgument,
+				value,
+				ones,
+				bits,
+			)
+		}
+		pool.Base = base.String()
+		res = append(res, pool)
+	}
+
+	return res, nil
+}
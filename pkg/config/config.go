@@ -42,7 +42,34 @@ type Config struct {
 	Experimental     bool            `toml:"experimental"`
 	HostGatewayIP    string          `toml:"host_gateway_ip"`
 	BridgeIP         string          `toml:"bridge_ip, omitempty"`
+	BridgeSubnet     string          `toml:"bridge_subnet"`
+	BridgeIPv6       bool            `toml:"bridge_ipv6"`
+	IPv6Pool         string          `toml:"ipv6_pool"`
 	KubeHideDupe     bool            `toml:"kube_hide_dupe"`
+	EventsJournal    bool            `toml:"events_journal"`
+	QemuDir          string          `toml:"qemu_dir"`
+	HooksDir         string          `toml:"hooks_dir"`
+	// Formats maps command paths (e.g. "ps", "volume ls") to the default value of their `--format` flag
+	Formats map[string]string `toml:"formats,omitempty"`
+	// Registry maps a registry host (e.g. "docker.io") to its configuration
+	Registry map[string]RegistryConfig `toml:"registry,omitempty"`
+	// DefaultAddressPools are the pools subnets are allocated from, by order of preference, when creating a network
+	// without a subnet
+	DefaultAddressPools []AddressPool `toml:"default_address_pools,omitempty"`
+}
+
+// AddressPool is a pool of subnets.
+type AddressPool struct {
+	// Base is the prefix the subnets are allocated from, e.g. "10.200.0.0/16"
+	Base string `toml:"base"`
+	// Size is the prefix length of the allocated subnets, e.g. 24
+	Size int `toml:"size"`
+}
+
+// RegistryConfig is the configuration of a registry.
+type RegistryConfig struct {
+	// Mirrors are tried by order of preference, before falling back to the registry itself
+	Mirrors []string `toml:"mirrors,omitempty"`
 }
 
 // New creates a default Config object statically,
@@ -63,5 +90,8 @@ func New() *Config {
 		Experimental:     true,
 		HostGatewayIP:    ncdefaults.HostGatewayIP(),
 		KubeHideDupe:     false,
+		EventsJournal:    false,
+		QemuDir:          ncdefaults.QemuDir(),
+		HooksDir:         ncdefaults.HooksDir(),
 	}
 }
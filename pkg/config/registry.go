@@ -0,0 +1,78 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+)
+
+// RegistryMirrors returns the mirrors of each registry of the registry configuration.
+func RegistryMirrors(registries map[string]RegistryConfig) map[string][]string {
+	res := map[string][]string{}
+	for registry, registryConfig := range registries {
+		if len(registryConfig.Mirrors) > 0 {
+			res[registry] = registryConfig.Mirrors
+		}
+	}
+
+	return res
+}
+
+// FormatRegistryMirrors returns the mirrors of the registry configuration as REGISTRY=MIRROR flag values,
+// sorted by registry.
+func FormatRegistryMirrors(registries map[string]RegistryConfig) []string {
+	res := []string{}
+	for registry, registryConfig := range registries {
+		for _, mirror := range registryConfig.Mirrors {
+			res = append(res, registry+"="+mirror)
+		}
+	}
+
+	// Sorting is stable, preserving the order of mirrors of a registry
+	slices.SortStableFunc(res, func(a, b string) int {
+		regA, _, _ := strings.Cut(a, "=")
+		regB, _, _ := strings.Cut(b, "=")
+		return strings.Compare(regA, regB)
+	})
+
+	return res
+}
+
+// ParseRegistryMirrors parses REGISTRY=MIRROR flag values into a registry configuration.
+func ParseRegistryMirrors(values []string) (map[string]RegistryConfig, error) {
+	res := map[string]RegistryConfig{}
+	for _, value := range values {
+		registry, mirror, ok := strings.Cut(value, "=")
+		if !ok || registry == "" || mirror == "" {
+			return nil, fmt.Errorf(
+				"%w: invalid registry mirror %q, expected REGISTRY=MIRROR",
+				errs.ErrInvalidArgument,
+				value,
+			)
+		}
+
+		registryConfig := res[registry]
+		registryConfig.Mirrors = append(registryConfig.Mirrors, mirror)
+		res[registry] = registryConfig
+	}
+
+	return res, nil
+}
@@ -17,13 +17,19 @@
 package containerinspector
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"net"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/containernetworking/plugins/pkg/ns"
 
+	"go.farcloser.world/lepton/leptonic/errs"
 	"go.farcloser.world/lepton/pkg/inspecttypes/native"
 )
 
@@ -74,3 +80,132 @@ func determinePrimaryInterface(interfaces []native.NetInterface) int {
 	}
 	return 0
 }
+
+const cgroupMountPoint = "/sys/fs/cgroup"
+
+// cgroupV2Limits lists the cgroup v2 interface files reported as limits.
+var cgroupV2Limits = []string{
+	"cpu.max", "cpu.weight", "cpuset.cpus", "cpuset.mems",
+	"memory.min", "memory.low", "memory.high", "memory.max", "memory.swap.max",
+	"pids.max", "io.max", "io.weight",
+}
+
+// cgroupV1Limits lists, per controller, the cgroup v1 interface files reported as limits.
+var cgroupV1Limits = map[string][]string{
+	"cpu":    {"cpu.cfs_quota_us", "cpu.cfs_period_us", "cpu.shares"},
+	"cpuset": {"cpuset.cpus", "cpuset.mems"},
+	"memory": {
+		"memory.limit_in_bytes", "memory.soft_limit_in_bytes", "memory.memsw.limit_in_bytes", "memory.swappiness",
+	},
+	"pids":  {"pids.max"},
+	"blkio": {"blkio.weight"},
+}
+
+// InspectCgroup returns the cgroup of process `pid`, along with the current content of its limit files.
+// Files that do not exist (eg: controller not enabled) are not reported.
+func InspectCgroup(pid int) (*native.Cgroup, error) {
+	f, err := os.Open(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	res, controllers, err := parseCgroup(f)
+	if err != nil {
+		return nil, err
+	}
+	for controller, path := range controllers {
+		readCgroupFiles(res.Limits, filepath.Join(cgroupMountPoint, controller, path), cgroupV1Limits[controller])
+	}
+	if res.Version == 2 {
+		readCgroupFiles(res.Limits, filepath.Join(cgroupMountPoint, res.Path), cgroupV2Limits)
+	}
+
+	return res, nil
+}
+
+// parseCgroup parses the content of /proc/<pid>/cgroup into a cgroup without its limits, along with the path of each
+// v1 controller that has limits reported.
+func parseCgroup(r io.Reader) (*native.Cgroup, map[string]string, error) {
+	res := &native.Cgroup{
+		Limits: map[string]string{},
+	}
+	controllers := map[string]string{}
+	// Lines are "hierarchy-ID:controller-list:cgroup-path"
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[0] == "0" && parts[1] == "" {
+			// Unified hierarchy. On hybrid systems, v1 controllers win.
+			if res.Version == 0 {
+				res.Version = 2
+				res.Path = parts[2]
+			}
+			continue
+		}
+		if res.Version != 1 {
+			res.Version = 1
+			res.Path = ""
+		}
+		for _, controller := range strings.Split(parts[1], ",") {
+			if _, ok := cgroupV1Limits[controller]; !ok {
+				continue
+			}
+			if controller == "memory" || res.Path == "" {
+				res.Path = parts[2]
+			}
+			controllers[controller] = parts[2]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return res, controllers, nil
+}
+
+func readCgroupFiles(limits map[string]string, dir string, files []string) {
+	for _, file := range files {
+		if content, err := os.ReadFile(filepath.Join(dir, file)); err == nil {
+			limits[file] = strings.TrimSpace(string(content))
+		}
+	}
+}
+
+// OOMKills returns how many processes of cgroup `cg` were killed by the OOM killer, as counted by the memory
+// controller. The counter lives as long as the cgroup, that is as long as the task.
+func OOMKills(cg *native.Cgroup) (uint64, error) {
+	var file string
+	switch cg.Version {
+	case 2:
+		file = filepath.Join(cgroupMountPoint, cg.Path, "memory.events")
+	case 1:
+		file = filepath.Join(cgroupMountPoint, "memory", cg.Path, "memory.oom_control")
+	default:
+		return 0, fmt.Errorf("%w: unknown cgroup version %d", errs.ErrInvalidArgument, cg.Version)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return readOOMKills(f)
+}
+
+// readOOMKills reads the oom_kill counter of memory.events (v2) or memory.oom_control (v1): both are flat keyed files.
+func readOOMKills(r io.Reader) (uint64, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), " ")
+		if ok && key == "oom_kill" {
+			return strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		}
+	}
+
+	return 0, scanner.Err()
+}
@@ -0,0 +1,135 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package containerinspector
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/lepton/pkg/inspecttypes/native"
+)
+
+func TestParseCgroup(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		content         string
+		want            native.Cgroup
+		wantControllers map[string]string
+	}{
+		{
+			name:            "cgroup v2",
+			content:         "0::/system.slice/containerd.service/default-foo\n",
+			want:            native.Cgroup{Version: 2, Path: "/system.slice/containerd.service/default-foo"},
+			wantControllers: map[string]string{},
+		},
+		{
+			name: "cgroup v1",
+			content: "12:pids:/default/foo\n" +
+				"11:cpu,cpuacct:/default/foo\n" +
+				"10:memory:/default/foo-mem\n" +
+				"9:devices:/default/foo\n" +
+				"1:name=systemd:/default/foo\n",
+			want: native.Cgroup{Version: 1, Path: "/default/foo-mem"},
+			wantControllers: map[string]string{
+				"pids":   "/default/foo",
+				"cpu":    "/default/foo",
+				"memory": "/default/foo-mem",
+			},
+		},
+		{
+			name: "hybrid, v1 controllers win",
+			content: "0::/default/foo\n" +
+				"4:cpuset:/default/foo-cpuset\n",
+			want:            native.Cgroup{Version: 1, Path: "/default/foo-cpuset"},
+			wantControllers: map[string]string{"cpuset": "/default/foo-cpuset"},
+		},
+		{
+			name:            "malformed lines are skipped",
+			content:         "garbage\n\n0::/foo\n",
+			want:            native.Cgroup{Version: 2, Path: "/foo"},
+			wantControllers: map[string]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, controllers, err := parseCgroup(strings.NewReader(tc.content))
+			assert.NilError(t, err)
+			assert.Equal(t, got.Version, tc.want.Version)
+			assert.Equal(t, got.Path, tc.want.Path)
+			assert.Equal(t, len(got.Limits), 0)
+			assert.DeepEqual(t, controllers, tc.wantControllers)
+		})
+	}
+}
+
+func TestReadOOMKills(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		content string
+		want    uint64
+		wantErr bool
+	}{
+		{
+			name:    "cgroup v2 memory.events",
+			content: "low 0\nhigh 0\nmax 12\noom 3\noom_kill 2\noom_group_kill 0\n",
+			want:    2,
+		},
+		{
+			name:    "cgroup v1 memory.oom_control",
+			content: "oom_kill_disable 0\nunder_oom 0\noom_kill 1\n",
+			want:    1,
+		},
+		{
+			name:    "no kill",
+			content: "low 0\nhigh 0\nmax 0\noom 0\noom_kill 0\n",
+			want:    0,
+		},
+		{
+			name:    "kernel without the counter",
+			content: "oom_kill_disable 0\nunder_oom 0\n",
+			want:    0,
+		},
+		{
+			name:    "invalid counter",
+			content: "oom_kill many\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := readOOMKills(strings.NewReader(tc.content))
+			if tc.wantErr {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, got, tc.want)
+		})
+	}
+}
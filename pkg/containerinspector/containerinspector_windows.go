@@ -27,3 +27,11 @@ func InspectNetNS(ctx context.Context, pid int) (*native.NetNS, error) {
 
 	return r, nil
 }
+
+func InspectCgroup(pid int) (*native.Cgroup, error) {
+	return nil, nil
+}
+
+func OOMKills(cg *native.Cgroup) (uint64, error) {
+	return 0, nil
+}
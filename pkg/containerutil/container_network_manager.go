@@ -26,6 +26,8 @@ import (
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 
 	containerd "github.com/containerd/containerd/v2/client"
@@ -35,6 +37,7 @@ import (
 
 	"go.farcloser.world/containers/specs"
 
+	"go.farcloser.world/lepton/leptonic/errs"
 	"go.farcloser.world/lepton/pkg/api/options"
 	"go.farcloser.world/lepton/pkg/clientutil"
 	"go.farcloser.world/lepton/pkg/dnsutil/hostsstore"
@@ -903,6 +906,55 @@ func verifyNetworkTypes(
 	return res, nil
 }
 
+// ParseNetworkAliases returns the network-scoped aliases of a container, by network name.
+// Aliases are specified as "ALIAS", applying to all the networks of the container, or as "NETWORK:ALIAS".
+// Like with Docker, aliases are only supported on user-defined CNI networks.
+func ParseNetworkAliases(aliases, networks []string) (map[string][]string, error) {
+	if len(aliases) == 0 {
+		return nil, nil
+	}
+
+	netType, err := nettype.Detect(networks)
+	if err != nil {
+		return nil, err
+	}
+	userNetworks := []string{}
+	if netType == nettype.CNI {
+		for _, network := range networks {
+			if network != netutil.DefaultNetworkName {
+				userNetworks = append(userNetworks, network)
+			}
+		}
+	}
+	if len(userNetworks) == 0 {
+		return nil, fmt.Errorf("%w: network-scoped aliases are only supported for user-defined networks",
+			errs.ErrInvalidArgument)
+	}
+
+	res := map[string][]string{}
+	for _, alias := range aliases {
+		targets := userNetworks
+		if network, name, found := strings.Cut(alias, ":"); found {
+			if !slices.Contains(userNetworks, network) {
+				return nil, fmt.Errorf("%w: alias %q: the container is not attached to user-defined network %q",
+					errs.ErrInvalidArgument, alias, network)
+			}
+			targets = []string{network}
+			alias = name
+		}
+		if alias == "" {
+			return nil, fmt.Errorf("%w: network alias cannot be empty", errs.ErrInvalidArgument)
+		}
+		for _, network := range targets {
+			if !slices.Contains(res[network], alias) {
+				res[network] = append(res[network], alias)
+			}
+		}
+	}
+
+	return res, nil
+}
+
 // NetworkOptionsFromSpec Returns the ContainerNetwork used in a container's creation from its spec.Annotations.
 func NetworkOptionsFromSpec(spec *specs.Spec) (options.ContainerNetwork, error) {
 	opts := options.ContainerNetwork{}
@@ -924,6 +976,12 @@ func NetworkOptionsFromSpec(spec *specs.Spec) (options.ContainerNetwork, error)
 		opts.IPAddress = ipAddress
 	}
 
+	if ip6Address, ok := spec.Annotations[labels.IP6Address]; ok {
+		opts.IP6Address = ip6Address
+	}
+
+	opts.IPSticky, _ = strconv.ParseBool(spec.Annotations[labels.IPSticky])
+
 	var networks []string
 	networksJSON := spec.Annotations[labels.Networks]
 	if err := json.Unmarshal([]byte(networksJSON), &networks); err != nil {
@@ -39,11 +39,21 @@ type cniNetworkManagerPlatform struct{}
 
 // VerifyNetworkOptions checks that the internal network settings are correct.
 func (m *cniNetworkManager) VerifyNetworkOptions(_ context.Context) error {
+	dataStore, err := clientutil.DataStore(m.globalOptions.DataRoot, m.globalOptions.Address)
+	if err != nil {
+		return err
+	}
+
 	e, err := netutil.NewCNIEnv(
 		m.globalOptions.CNIPath,
 		m.globalOptions.CNINetConfPath,
 		netutil.WithNamespace(m.globalOptions.Namespace),
-		netutil.WithDefaultNetwork(m.globalOptions.BridgeIP),
+		netutil.WithIPv6Pool(m.globalOptions.IPv6Pool, dataStore),
+		netutil.WithDefaultNetwork(
+			m.globalOptions.BridgeIP,
+			m.globalOptions.BridgeSubnet,
+			m.globalOptions.BridgeIPv6,
+		),
 	)
 	if err != nil {
 		return err
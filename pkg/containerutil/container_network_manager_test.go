@@ -21,6 +21,8 @@ import (
 	"testing"
 
 	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/lepton/pkg/netutil"
 )
 
 func TestZeroMapValues(t *testing.T) {
@@ -115,3 +117,66 @@ func TestZeroMapValues(t *testing.T) {
 		})
 	}
 }
+
+func TestParseNetworkAliases(t *testing.T) {
+	testCases := []struct {
+		name     string
+		aliases  []string
+		networks []string
+		expected map[string][]string
+		err      string
+	}{
+		{
+			name:     "none",
+			networks: []string{"foo"},
+		},
+		{
+			name:     "all networks",
+			aliases:  []string{"db", "db"},
+			networks: []string{"foo", "bar", netutil.DefaultNetworkName},
+			expected: map[string][]string{"foo": {"db"}, "bar": {"db"}},
+		},
+		{
+			name:     "scoped",
+			aliases:  []string{"foo:db", "bar:cache", "web"},
+			networks: []string{"foo", "bar"},
+			expected: map[string][]string{"foo": {"db", "web"}, "bar": {"cache", "web"}},
+		},
+		{
+			name:     "default network",
+			aliases:  []string{"db"},
+			networks: []string{netutil.DefaultNetworkName},
+			err:      "only supported for user-defined networks",
+		},
+		{
+			name:     "host network",
+			aliases:  []string{"db"},
+			networks: []string{"host"},
+			err:      "only supported for user-defined networks",
+		},
+		{
+			name:     "unknown network",
+			aliases:  []string{"baz:db"},
+			networks: []string{"foo"},
+			err:      "not attached to user-defined network \"baz\"",
+		},
+		{
+			name:     "empty",
+			aliases:  []string{"foo:"},
+			networks: []string{"foo"},
+			err:      "cannot be empty",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			aliases, err := ParseNetworkAliases(tc.aliases, tc.networks)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, aliases, tc.expected)
+		})
+	}
+}
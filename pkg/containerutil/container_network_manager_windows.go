@@ -26,6 +26,7 @@ import (
 	"github.com/containerd/go-cni"
 
 	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/clientutil"
 	"go.farcloser.world/lepton/pkg/netutil"
 	"go.farcloser.world/lepton/pkg/ocihook"
 )
@@ -36,11 +37,21 @@ type cniNetworkManagerPlatform struct {
 
 // Verifies that the internal network settings are correct.
 func (m *cniNetworkManager) VerifyNetworkOptions(_ context.Context) error {
+	dataStore, err := clientutil.DataStore(m.globalOptions.DataRoot, m.globalOptions.Address)
+	if err != nil {
+		return err
+	}
+
 	e, err := netutil.NewCNIEnv(
 		m.globalOptions.CNIPath,
 		m.globalOptions.CNINetConfPath,
 		netutil.WithNamespace(m.globalOptions.Namespace),
-		netutil.WithDefaultNetwork(m.globalOptions.BridgeIP),
+		netutil.WithIPv6Pool(m.globalOptions.IPv6Pool, dataStore),
+		netutil.WithDefaultNetwork(
+			m.globalOptions.BridgeIP,
+			m.globalOptions.BridgeSubnet,
+			m.globalOptions.BridgeIPv6,
+		),
 	)
 	if err != nil {
 		return err
@@ -73,11 +84,21 @@ func (m *cniNetworkManager) VerifyNetworkOptions(_ context.Context) error {
 }
 
 func (m *cniNetworkManager) getCNI() (cni.CNI, error) {
+	dataStore, err := clientutil.DataStore(m.globalOptions.DataRoot, m.globalOptions.Address)
+	if err != nil {
+		return nil, err
+	}
+
 	e, err := netutil.NewCNIEnv(
 		m.globalOptions.CNIPath,
 		m.globalOptions.CNINetConfPath,
 		netutil.WithNamespace(m.globalOptions.Namespace),
-		netutil.WithDefaultNetwork(m.globalOptions.BridgeIP),
+		netutil.WithIPv6Pool(m.globalOptions.IPv6Pool, dataStore),
+		netutil.WithDefaultNetwork(
+			m.globalOptions.BridgeIP,
+			m.globalOptions.BridgeSubnet,
+			m.globalOptions.BridgeIPv6,
+		),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to instantiate CNI env: %w", err)
@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"path"
 	"path/filepath"
 	"strconv"
@@ -44,12 +45,15 @@ import (
 
 	"go.farcloser.world/containers/specs"
 
+	"go.farcloser.world/lepton/pkg/api/options"
 	"go.farcloser.world/lepton/pkg/consoleutil"
 	"go.farcloser.world/lepton/pkg/errutil"
+	"go.farcloser.world/lepton/pkg/eventutil"
 	"go.farcloser.world/lepton/pkg/formatter"
 	"go.farcloser.world/lepton/pkg/ipcutil"
 	"go.farcloser.world/lepton/pkg/labels"
 	"go.farcloser.world/lepton/pkg/labels/k8slabels"
+	"go.farcloser.world/lepton/pkg/mountutil/volumestore"
 	"go.farcloser.world/lepton/pkg/portutil"
 	"go.farcloser.world/lepton/pkg/rootlessutil"
 	"go.farcloser.world/lepton/pkg/signalutil"
@@ -77,14 +81,15 @@ func PrintHostPort(
 
 	if containerPort < 0 {
 		for _, p := range ports {
-			fmt.Fprintf(writer, "%d/%s -> %s:%d\n", p.ContainerPort, p.Protocol, p.HostIP, p.HostPort)
+			hostAddr := net.JoinHostPort(p.HostIP, strconv.Itoa(int(p.HostPort)))
+			fmt.Fprintf(writer, "%d/%s -> %s\n", p.ContainerPort, p.Protocol, hostAddr)
 		}
 		return nil
 	}
 
 	for _, p := range ports {
 		if int(p.ContainerPort) == containerPort && strings.ToLower(p.Protocol) == proto {
-			fmt.Fprintf(writer, "%s:%d\n", p.HostIP, p.HostPort)
+			fmt.Fprintln(writer, net.JoinHostPort(p.HostIP, strconv.Itoa(int(p.HostPort))))
 			return nil
 		}
 	}
@@ -222,12 +227,14 @@ func GenerateSharingPIDOpts(ctx context.Context, targetCon containerd.Container)
 }
 
 // Start starts `container` with `attach` flag. If `attach` is true, it will attach to the container's stdio.
+// The start event is recorded as soon as the task is started, before attaching.
 func Start(
 	ctx context.Context,
 	container containerd.Container,
 	flagA bool,
 	client *containerd.Client,
 	detachKeys string,
+	globalOptions *options.Global,
 ) (err error) {
 	// defer the storage of start error in the dedicated label
 	defer func() {
@@ -292,6 +299,10 @@ func Start(
 			log.G(ctx).WithError(err).Debug("failed to delete old task")
 		}
 	}
+	// Volumes with options must be mounted before the task is created
+	if err := volumestore.MountContainerVolumes(container.ID(), lab); err != nil {
+		return err
+	}
 	detachC := make(chan struct{})
 	attachStreamOpt := []string{}
 	if flagA {
@@ -314,12 +325,15 @@ func Start(
 		detachC,
 	)
 	if err != nil {
+		ReleaseContainerVolumes(ctx, container.ID(), lab)
 		return err
 	}
 
 	if err := task.Start(ctx); err != nil {
+		ReleaseContainerVolumes(ctx, container.ID(), lab)
 		return err
 	}
+	eventutil.Record(ctx, globalOptions, eventutil.ContainerEvent(ctx, container, eventutil.ActionStart))
 	if !flagA {
 		return nil
 	}
@@ -577,6 +591,13 @@ type ContainerVolume struct {
 	Propagation string
 }
 
+// ReleaseContainerVolumes releases the volumes mounted for a container whose task failed to be created or started.
+func ReleaseContainerVolumes(ctx context.Context, containerID string, containerLabels map[string]string) {
+	if err := volumestore.UnmountContainerVolumes(containerID, containerLabels); err != nil {
+		log.G(ctx).WithError(err).Error("failed releasing container volumes")
+	}
+}
+
 // GetContainerVolumes is a function that returns a slice of containerVolume pointers.
 // It accepts a map of container labels as input, where key is the label name and value is its associated value.
 // The function iterates over the predefined volume labels (AnonymousVolumes and Mounts)
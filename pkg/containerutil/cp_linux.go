@@ -21,15 +21,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"syscall"
 
 	containerd "github.com/containerd/containerd/v2/client"
 	"github.com/containerd/containerd/v2/core/containers"
 	"github.com/containerd/containerd/v2/core/mount"
+	"github.com/containerd/containerd/v2/pkg/oci"
 	"github.com/containerd/errdefs"
 	"github.com/containerd/log"
 
@@ -46,8 +49,8 @@ var (
 	ErrFilesystem        = errors.New("filesystem error") // lstat hard errors, etc
 	ErrContainerVanished = errors.New("the container you are trying to copy to/from has been deleted")
 	ErrRootlessCannotCp  = errors.New(
-		"cannot use cp with stopped containers in rootless mode",
-	) // rootless cp with a stopped container
+		"cannot copy out of stopped containers in rootless mode",
+	) // rootless cp from a stopped container
 	ErrFailedMountingSnapshot = errors.New(
 		"failed mounting snapshot",
 	) // failure to mount a stopped container snapshot
@@ -99,24 +102,14 @@ func getRoot(ctx context.Context, container containerd.Container) (string, int,
 // CopyFiles implements `cp`
 // It currently depends on the following assumptions:
 // - linux only
-// - tar binary exists on the system
-// - nsenter binary exists on the system
-// - if rootless, the container is running (aka: /proc/pid/root)
+// - if rootless and the container is running, the nsenter binary exists on the system
+// - if rootless and copying out of the container, the container is running (aka: /proc/pid/root)
 func CopyFiles(
 	ctx context.Context,
 	client *containerd.Client,
 	container containerd.Container,
 	options options.ContainerCp,
 ) (err error) {
-	// We do rely on the tar binary as a shortcut - could also be replaced by archive/tar, though that would mean
-	// we need to replace nsenter calls with re-exec
-	tarBinary, isGNUTar, err := tarutil.FindTarBinary()
-	if err != nil {
-		return err
-	}
-
-	log.G(ctx).Debugf("Detected tar binary %q (GNU=%v)", tarBinary, isGNUTar)
-
 	// This can happen if the container being passed has been deleted since in a racy way
 	conSpec, err := container.Spec(ctx)
 	if err != nil {
@@ -125,7 +118,7 @@ func CopyFiles(
 
 	// Try to get a running container root
 	root, pid, err := getRoot(ctx, container)
-	// If the task is "not found" (for example, if the container stopped), we will try to mount the snapshot
+	// If the task is "not found" (for example, if the container stopped), we will try to use the snapshot
 	// Any other type of error from Task() is fatal here.
 	if err != nil && !errdefs.IsNotFound(err) {
 		return errors.Join(ErrContainerVanished, err)
@@ -134,16 +127,10 @@ func CopyFiles(
 	log.G(ctx).Debugf("We have root %s and pid %d", root, pid)
 
 	// If we have no root:
-	// - bail out for rootless
-	// - mount the snapshot for rootful
+	// - when copying into the container, look up its snapshot layers, and let containerd apply the copy
+	// - otherwise, bail out for rootless, and mount the snapshot for rootful
+	var snapshot *snapshotView
 	if root == "" {
-		// FIXME: Rootless does not support copying into/out of stopped/created containers as we need to nsenter into
-		// the user namespace of the pid of the running container with --preserve-credentials to preserve uid/gid
-		// mapping and copy files into the container.
-		if rootlessutil.IsRootless() {
-			return ErrRootlessCannotCp
-		}
-
 		// See similar situation above. This may happen if we are racing against container deletion
 		var conInfo containers.Container
 		conInfo, err = container.Info(ctx)
@@ -151,29 +138,51 @@ func CopyFiles(
 			return errors.Join(ErrContainerVanished, err)
 		}
 
-		var cleanup func() error
-		root, cleanup, err = mountSnapshotForContainer(ctx, client, conInfo, options.GOptions.Snapshotter)
-		if cleanup != nil {
-			defer func() {
-				err = errors.Join(err, cleanup())
-			}()
+		if !options.Container2Host {
+			snapshot, err = newSnapshotView(ctx, client, conInfo)
+			if err != nil {
+				return errors.Join(ErrFilesystem, err)
+			}
 		}
 
-		if err != nil {
-			return errors.Join(ErrFailedMountingSnapshot, err)
+		if snapshot != nil {
+			root = snapshot.layers[0]
+		} else {
+			// FIXME: Rootless does not support copying out of stopped/created containers, as we would need to
+			// nsenter into the user namespace of a running container to be able to read all files.
+			if rootlessutil.IsRootless() {
+				return ErrRootlessCannotCp
+			}
+
+			var cleanup func() error
+			root, cleanup, err = mountSnapshotForContainer(ctx, client, conInfo, options.GOptions.Snapshotter)
+			if cleanup != nil {
+				defer func() {
+					err = errors.Join(err, cleanup())
+				}()
+			}
+
+			if err != nil {
+				return errors.Join(ErrFailedMountingSnapshot, err)
+			}
 		}
 
 		log.G(ctx).Debugf("Got new root %s", root)
 	}
 
+	// A "-" path is a tar archive streamed from stdin or to stdout, and has no specifier
 	var sourceSpec, destinationSpec *pathSpecifier
 	var sourceErr, destErr error
 	if options.Container2Host {
-		sourceSpec, sourceErr = getPathSpecFromContainer(options.SrcPath, conSpec, root)
-		destinationSpec, destErr = getPathSpecFromHost(options.DestPath)
+		sourceSpec, sourceErr = getPathSpecFromContainer(options.SrcPath, conSpec, root, nil)
+		if options.DestPath != "-" {
+			destinationSpec, destErr = getPathSpecFromHost(options.DestPath)
+		}
 	} else {
-		sourceSpec, sourceErr = getPathSpecFromHost(options.SrcPath)
-		destinationSpec, destErr = getPathSpecFromContainer(options.DestPath, conSpec, root)
+		if options.SrcPath != "-" {
+			sourceSpec, sourceErr = getPathSpecFromHost(options.SrcPath)
+		}
+		destinationSpec, destErr = getPathSpecFromContainer(options.DestPath, conSpec, root, snapshot)
 	}
 
 	if destErr != nil {
@@ -196,154 +205,258 @@ func CopyFiles(
 		return errors.Join(ErrFilesystem, sourceErr)
 	}
 
-	// Now, resolve cp shenanigans
-	// First, cannot copy a non-existent resource
-	if !sourceSpec.exists {
-		return ErrSourceDoesNotExist
+	archiveName, extractDir, err := resolveCopy(sourceSpec, destinationSpec)
+	if err != nil {
+		return err
 	}
 
-	// Second, cannot copy into a readonly destination
-	if destinationSpec.readOnly {
-		return ErrTargetIsReadOnly
+	// Files copied into the container are owned by the container root, unless in archive mode, where their ownership
+	// is preserved. Either way, ownership is translated when the container uses a user namespace.
+	// Files copied out of the container are owned by the current user.
+	unpackOptions := tarutil.UnpackOptions{NoChown: options.Container2Host}
+	if !options.Container2Host {
+		unpackOptions.IDMap = idMap(conSpec, true)
+		if !options.Archive {
+			rootOwner := tarutil.Owner{}
+			if unpackOptions.IDMap != nil {
+				rootOwner = unpackOptions.IDMap(rootOwner)
+			}
+			unpackOptions.Chown = &rootOwner
+		}
 	}
 
-	// Cannot copy a dir into a file
-	if sourceSpec.isADir && destinationSpec.exists && !destinationSpec.isADir {
-		return ErrCannotCopyDirToFile
-	}
+	// In rootless mode, the container side is accessed from within the user namespace of the container process
+	inUserNS := rootlessutil.IsRootless() && pid != 0
 
-	// A file cannot be copied inside a non-existent directory with a trailing slash, or slash+dot
-	if !sourceSpec.isADir && !destinationSpec.exists &&
-		(destinationSpec.endsWithSeparator || destinationSpec.endsWithSeparatorDot) {
-		return ErrDestinationDirMustExist
-	}
-
-	// XXX FIXME: this seems wrong. What about ownership? We could be doing that inside a container
-	if !destinationSpec.exists {
-		if err = os.Mkdir(destinationSpec.resolvedPath, 0o755); err != nil {
-			return errors.Join(ErrFilesystem, err)
+	var pack func(io.Writer) error
+	switch {
+	case sourceSpec == nil:
+		pack = func(w io.Writer) error {
+			_, err := io.Copy(w, options.Stdin)
+			return err
+		}
+	case options.Container2Host && inUserNS:
+		args := []string{"pack", "--name", archiveName}
+		if options.FollowSymLink {
+			args = append(args, "--follow-link")
+		}
+		pack = func(w io.Writer) error {
+			return runInUserNS(ctx, pid, nil, w, append(args, sourceSpec.resolvedPath)...)
+		}
+	default:
+		packOptions := tarutil.PackOptions{Name: archiveName, FollowSymlink: options.FollowSymLink}
+		if options.Container2Host {
+			packOptions.IDMap = idMap(conSpec, false)
+		}
+		pack = func(w io.Writer) error {
+			return tarutil.Pack(w, sourceSpec.resolvedPath, packOptions)
 		}
 	}
 
-	var tarCDir, tarCArg string
-	if sourceSpec.isADir {
-		if !destinationSpec.exists || sourceSpec.endsWithSeparatorDot {
-			// the content of the source directory is copied into this directory
-			tarCDir = sourceSpec.resolvedPath
-			tarCArg = "."
-		} else {
-			// the source directory is copied into this directory
-			tarCDir = filepath.Dir(sourceSpec.resolvedPath)
-			tarCArg = filepath.Base(sourceSpec.resolvedPath)
+	var unpack func(io.Reader) error
+	switch {
+	case destinationSpec == nil:
+		unpack = func(r io.Reader) error {
+			_, err := io.Copy(options.Stdout, r)
+			return err
 		}
-	} else {
-		// Prepare a single-file directory to create an archive of the source file
-		td, err := os.MkdirTemp("", "cp")
+	case destinationSpec.inSnapshot:
+		containerDir, err := filepath.Rel(root, extractDir)
 		if err != nil {
-			return err
+			return errors.Join(ErrFilesystem, err)
 		}
-		defer os.RemoveAll(td)
-		tarCDir = td
-		cp := []string{"cp", "-a"}
-		if options.FollowSymLink {
-			cp = append(cp, "-L")
+		unpack = func(r io.Reader) error {
+			return snapshot.apply(ctx, client, r, containerDir, unpackOptions)
 		}
-		if destinationSpec.endsWithSeparator || (destinationSpec.exists && destinationSpec.isADir) {
-			tarCArg = filepath.Base(sourceSpec.resolvedPath)
-		} else {
-			// Handle `cp /path/to/file some-container:/path/to/file-with-another-name`
-			tarCArg = filepath.Base(destinationSpec.resolvedPath)
+	case !options.Container2Host && inUserNS:
+		args := []string{"unpack"}
+		if unpackOptions.Chown != nil {
+			args = append(args, "--chown", fmt.Sprintf("%d:%d", unpackOptions.Chown.UID, unpackOptions.Chown.GID))
+		}
+		unpack = func(r io.Reader) error {
+			return runInUserNS(ctx, pid, r, nil, append(args, extractDir)...)
 		}
-		cp = append(cp, sourceSpec.resolvedPath, filepath.Join(td, tarCArg))
-		cpCmd := exec.CommandContext(ctx, cp[0], cp[1:]...)
-		log.G(ctx).Debugf("executing %v", cpCmd.Args)
-		if out, err := cpCmd.CombinedOutput(); err != nil {
-			return fmt.Errorf("failed to execute %v: %w (out=%q)", cpCmd.Args, err, string(out))
+	default:
+		// Volumes of a stopped container are written directly: in rootless mode, files are then owned by the
+		// current user, which is the root user of the container
+		if rootlessutil.IsRootless() && !options.Container2Host {
+			if options.Archive {
+				log.G(ctx).Warn("ownership cannot be preserved when copying into a volume of a stopped container " +
+					"in rootless mode")
+			}
+			unpackOptions.NoChown = true
+		}
+		unpack = func(r io.Reader) error {
+			return tarutil.Unpack(r, extractDir, unpackOptions)
 		}
 	}
 
-	tarC := []string{tarBinary}
-	if options.FollowSymLink {
-		tarC = append(tarC, "-h")
+	log.G(ctx).Debugf("copying %q to %q", options.SrcPath, extractDir)
+
+	if err = transfer(pack, unpack); err != nil {
+		if errors.Is(err, syscall.EROFS) || errors.Is(err, ErrTargetIsReadOnly) {
+			return ErrTargetIsReadOnly
+		}
+
+		return err
 	}
-	tarC = append(tarC, "-c", "-f", "-", tarCArg)
 
-	tarXDir := destinationSpec.resolvedPath
-	if !sourceSpec.isADir && !destinationSpec.endsWithSeparator && !(destinationSpec.exists && destinationSpec.isADir) {
-		tarXDir = filepath.Dir(destinationSpec.resolvedPath)
+	return nil
+}
+
+// resolveCopy applies the cp rules to the source and destination, and returns the name of the source in the archive,
+// and the directory to extract it into.
+// A nil source is an archive read from stdin, and a nil destination is an archive written to stdout.
+func resolveCopy(sourceSpec, destinationSpec *pathSpecifier) (string, string, error) {
+	// First, cannot copy a non-existent resource
+	if sourceSpec != nil && !sourceSpec.exists {
+		return "", "", ErrSourceDoesNotExist
 	}
-	tarX := []string{tarBinary, "-x"}
-	if options.Container2Host && isGNUTar {
-		tarX = append(tarX, "--no-same-owner")
+
+	// Second, cannot copy into a readonly destination
+	if destinationSpec != nil && destinationSpec.readOnly {
+		return "", "", ErrTargetIsReadOnly
 	}
-	tarX = append(tarX, "-f", "-")
 
-	if rootlessutil.IsRootless() {
-		nsenter := []string{"nsenter", "-t", strconv.Itoa(pid), "-U", "--preserve-credentials", "--"}
-		if options.Container2Host {
-			tarC = append(nsenter, tarC...)
-		} else {
-			tarX = append(nsenter, tarX...)
+	switch {
+	case sourceSpec == nil:
+		// An archive can only be extracted into an existing directory
+		if !destinationSpec.exists {
+			return "", "", ErrDestinationDirMustExist
 		}
+
+		if !destinationSpec.isADir {
+			return "", "", ErrDestinationIsNotADir
+		}
+
+		return "", destinationSpec.resolvedPath, nil
+	case destinationSpec == nil:
+		return filepath.Base(sourceSpec.resolvedPath), "", nil
 	}
 
-	// FIXME: moving to archive/tar should allow better error management than this
-	// WARNING: some of our testing on stderr might not be portable across different versions of tar
-	// In these cases (readonly target), we will just get the straight tar output instead
-	tarCCmd := exec.CommandContext(ctx, tarC[0], tarC[1:]...)
-	tarCCmd.Dir = tarCDir
-	tarCCmd.Stdin = nil
-	tarCCmd.Stderr = os.Stderr
+	// Cannot copy a dir into a file
+	if sourceSpec.isADir && destinationSpec.exists && !destinationSpec.isADir {
+		return "", "", ErrCannotCopyDirToFile
+	}
+
+	// A file cannot be copied inside a non-existent directory with a trailing slash, or slash+dot
+	if !sourceSpec.isADir && !destinationSpec.exists &&
+		(destinationSpec.endsWithSeparator || destinationSpec.endsWithSeparatorDot) {
+		return "", "", ErrDestinationDirMustExist
+	}
+
+	switch {
+	case sourceSpec.isADir && destinationSpec.exists && sourceSpec.endsWithSeparatorDot:
+		// the content of the source directory is copied into the destination directory
+		return ".", destinationSpec.resolvedPath, nil
+	case destinationSpec.exists && destinationSpec.isADir:
+		// the source is copied into the destination directory
+		return filepath.Base(sourceSpec.resolvedPath), destinationSpec.resolvedPath, nil
+	default:
+		// the source is copied as the destination
+		// Handle `cp /path/to/file some-container:/path/to/file-with-another-name`
+		return filepath.Base(destinationSpec.resolvedPath), filepath.Dir(destinationSpec.resolvedPath), nil
+	}
+}
 
-	tarXCmd := exec.CommandContext(ctx, tarX[0], tarX[1:]...)
-	tarXCmd.Dir = tarXDir
-	tarXCmd.Stdin, err = tarCCmd.StdoutPipe()
+// transfer streams the archive written by pack to unpack.
+func transfer(pack func(io.Writer) error, unpack func(io.Reader) error) error {
+	reader, writer := io.Pipe()
+	packed := make(chan error, 1)
+
+	go func() {
+		err := pack(writer)
+		_ = writer.CloseWithError(err)
+		packed <- err
+	}()
+
+	err := unpack(reader)
+	if err == nil {
+		// Drain the padding that may follow the end of the archive
+		_, err = io.Copy(io.Discard, reader)
+	}
+
+	// Unblock pack if unpack bailed out early
+	_ = reader.CloseWithError(err)
+
+	// A failure to pack is also seen by unpack, which has more context
+	if packErr := <-packed; err == nil {
+		err = packErr
+	}
+
+	return err
+}
+
+// runInUserNS runs `internal cp` in the user namespace of the process pid, so that the ownership of files in the
+// container is accessed as seen from the container.
+func runInUserNS(ctx context.Context, pid int, stdin io.Reader, stdout io.Writer, args ...string) error {
+	self, err := os.Executable()
 	if err != nil {
 		return err
 	}
-	tarXCmd.Stdout = os.Stderr
-	var tarErr bytes.Buffer
-	tarXCmd.Stderr = &tarErr
 
-	log.G(ctx).Debugf("executing %v in %q", tarCCmd.Args, tarCCmd.Dir)
-	if err := tarCCmd.Start(); err != nil {
-		return errors.Join(fmt.Errorf("failed to execute %v", tarCCmd.Args), err)
-	}
+	cmd := exec.CommandContext(ctx, "nsenter", append([]string{
+		"-t", strconv.Itoa(pid), "-U", "--preserve-credentials", "--", self, "internal", "cp",
+	}, args...)...)
+
+	var stderr bytes.Buffer
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
 
-	log.G(ctx).Debugf("executing %v in %q", tarXCmd.Args, tarXCmd.Dir)
-	if err := tarXCmd.Start(); err != nil {
-		if strings.Contains(err.Error(), "permission denied") {
+	log.G(ctx).Debugf("executing %v", cmd.Args)
+	if err = cmd.Run(); err != nil {
+		if strings.Contains(stderr.String(), syscall.EROFS.Error()) {
 			return ErrTargetIsReadOnly
 		}
 
-		// Other errors, just put them back on stderr
-		_, fpErr := fmt.Fprint(os.Stderr, tarErr.String())
-		if fpErr != nil {
-			return errors.Join(fpErr, err)
-		}
+		return fmt.Errorf("failed to execute %v: %w (stderr=%q)", cmd.Args, err, stderr.String())
+	}
 
-		return errors.Join(fmt.Errorf("failed to execute %v", tarXCmd.Args), err)
+	return nil
+}
+
+type idRange struct {
+	container int
+	host      int
+	size      int
+}
+
+// idMap returns a tarutil.IDMap translating container IDs to host IDs (or the reverse), according to the user
+// namespace mappings of the container. It returns nil when the container does not have mappings.
+func idMap(conSpec *oci.Spec, toHost bool) tarutil.IDMap {
+	if conSpec.Linux == nil || (len(conSpec.Linux.UIDMappings) == 0 && len(conSpec.Linux.GIDMappings) == 0) {
+		return nil
 	}
 
-	if err := tarCCmd.Wait(); err != nil {
-		return fmt.Errorf("failed to wait %v: %w", tarCCmd.Args, err)
+	var uids, gids []idRange
+	for _, mapping := range conSpec.Linux.UIDMappings {
+		uids = append(uids, idRange{int(mapping.ContainerID), int(mapping.HostID), int(mapping.Size)})
 	}
 
-	if err := tarXCmd.Wait(); err != nil {
-		if strings.Contains(tarErr.String(), "Read-only file system") {
-			return ErrTargetIsReadOnly
-		}
+	for _, mapping := range conSpec.Linux.GIDMappings {
+		gids = append(gids, idRange{int(mapping.ContainerID), int(mapping.HostID), int(mapping.Size)})
+	}
+
+	mapID := func(ranges []idRange, id int) int {
+		for _, rng := range ranges {
+			from, to := rng.container, rng.host
+			if !toHost {
+				from, to = to, from
+			}
 
-		// Other errors, just put them back on stderr
-		_, fpErr := fmt.Fprint(os.Stderr, tarErr.String())
-		if fpErr != nil {
-			return errors.Join(fpErr, err)
+			if id >= from && id < from+rng.size {
+				return to + id - from
+			}
 		}
 
-		return errors.Join(fmt.Errorf("failed to wait %v", tarXCmd.Args), err)
+		// Unmapped IDs are left as is
+		return id
 	}
 
-	return nil
+	return func(own tarutil.Owner) tarutil.Owner {
+		return tarutil.Owner{UID: mapID(uids, own.UID), GID: mapID(gids, own.GID)}
+	}
 }
 
 func mountSnapshotForContainer(
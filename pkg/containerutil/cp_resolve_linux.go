@@ -61,6 +61,10 @@ type pathSpecifier struct {
 	isADir               bool
 	readOnly             bool
 	resolvedPath         string
+	// inSnapshot is true when the resource is in the root filesystem of a stopped container, as seen through a
+	// snapshotView. containerPath is then the location of the resource in the container.
+	inSnapshot    bool
+	containerPath string
 }
 
 // getPathSpecFromHost builds a pathSpecifier from a host location
@@ -143,10 +147,13 @@ func getPathSpecFromHost(originalPath string) (*pathSpecifier, error) {
 }
 
 // getPathSpecFromHost builds a pathSpecifier from a container location
+// If snapshot is not nil, the container root filesystem is looked up through it, and containerHostRoot must be its
+// top layer.
 func getPathSpecFromContainer(
 	originalPath string,
 	conSpec *oci.Spec,
 	containerHostRoot string,
+	snapshot *snapshotView,
 ) (*pathSpecifier, error) {
 	pathSpec := &pathSpecifier{
 		originalPath:         originalPath,
@@ -165,7 +172,7 @@ func getPathSpecFromContainer(
 	}
 
 	// Now, fully resolve the path - resolving all symlinks and cleaning-up the end result, following across mounts
-	pathResolver := newResolver(conSpec, containerHostRoot)
+	pathResolver := newResolver(conSpec, containerHostRoot, snapshot)
 	resolvedContainerPath, err := pathResolver.resolvePath(path)
 
 	// Errors we get from that are from Lstat or Readlink
@@ -215,6 +222,8 @@ func getPathSpecFromContainer(
 	// Now, finally get the location of the fully resolved containerPath (in the root? in a volume?)
 	containerMount, relativePath := pathResolver.getMount(resolvedContainerPath)
 	pathSpec.resolvedPath = filepath.Join(containerMount.hostPath, relativePath)
+	pathSpec.inSnapshot = snapshot != nil && containerMount.containerPath == "/"
+	pathSpec.containerPath = resolvedContainerPath
 	// If the endpoint is readonly, flag it as such
 	if containerMount.readonly {
 		pathSpec.readOnly = true
@@ -223,7 +232,12 @@ func getPathSpecFromContainer(
 	// If it exists, we can check if it is a dir
 	if pathSpec.exists {
 		var st os.FileInfo
-		st, err = os.Stat(pathSpec.resolvedPath)
+		if pathSpec.inSnapshot {
+			// The path is fully resolved, so there is no symlink to follow
+			st, err = snapshot.lstat(resolvedContainerPath)
+		} else {
+			st, err = os.Stat(pathSpec.resolvedPath)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -240,6 +254,7 @@ type resolver struct {
 	root     *specs.Root
 	mounts   []specs.Mount
 	hostRoot string
+	snapshot *snapshotView
 }
 
 // locator represents a container mount
@@ -257,17 +272,40 @@ func isParent(child, candidate []string) (bool, []string) {
 }
 
 // newResolver returns a resolver struct
-func newResolver(conSpec *oci.Spec, hostRoot string) *resolver {
+// If snapshot is not nil, paths in the root filesystem are looked up through it instead of hostRoot.
+func newResolver(conSpec *oci.Spec, hostRoot string, snapshot *snapshotView) *resolver {
 	return &resolver{
 		root:     conSpec.Root,
 		mounts:   conSpec.Mounts,
 		hostRoot: hostRoot,
+		snapshot: snapshot,
+	}
+}
+
+// lstat is os.Lstat for a container path
+func (res *resolver) lstat(path string) (fs.FileInfo, error) {
+	hostPath, inRoot := res.pathOnHost(path)
+	if inRoot && res.snapshot != nil {
+		return res.snapshot.lstat(path)
 	}
+
+	return os.Lstat(hostPath)
+}
+
+// readlink is os.Readlink for a container path
+func (res *resolver) readlink(path string) (string, error) {
+	hostPath, inRoot := res.pathOnHost(path)
+	if inRoot && res.snapshot != nil {
+		return res.snapshot.readlink(path)
+	}
+
+	return os.Readlink(hostPath)
 }
 
-// pathOnHost will return the *host* location of a container path, accounting for volumes.
+// pathOnHost will return the *host* location of a container path, accounting for volumes, and whether the path is
+// in the container root filesystem (as opposed to a volume).
 // The provided path must be fully resolved, as returned by `resolvePath`.
-func (res *resolver) pathOnHost(path string) string {
+func (res *resolver) pathOnHost(path string) (string, bool) {
 	hostRoot := res.hostRoot
 	path = filepath.Clean(path)
 	itemized := strings.Split(path, string(os.PathSeparator))
@@ -285,7 +323,7 @@ func (res *resolver) pathOnHost(path string) string {
 		}
 	}
 
-	return filepath.Join(append([]string{hostRoot}, sub...)...)
+	return filepath.Join(append([]string{hostRoot}, sub...)...), containerRoot == "/"
 }
 
 // getMount returns the mount locator for a given fully-resolved path, along with the corresponding subpath of the path
@@ -321,8 +359,7 @@ func (res *resolver) getMount(path string) (*locator, string) {
 }
 
 // resolvePath is adapted from https://cs.opensource.google/go/go/+/go1.23.0:src/path/filepath/path.go;l=147
-// The (only) changes are on Lstat and ReadLink, which are fed the actual host path, that is computed by
-// `res.pathOnHost`
+// The (only) changes are on Lstat and ReadLink, which are looked up on the host by `res.lstat` and `res.readlink`
 func (res *resolver) resolvePath(path string) (string, error) {
 	volLen := volumeNameLen(path)
 	pathSeparator := string(os.PathSeparator)
@@ -393,8 +430,7 @@ func (res *resolver) resolvePath(path string) (string, error) {
 		dest += path[start:end]
 
 		// Resolve symlink.
-		hostPath := res.pathOnHost(dest)
-		fi, err := os.Lstat(hostPath)
+		fi, err := res.lstat(dest)
 		if err != nil {
 			return "", err
 		}
@@ -412,7 +448,7 @@ func (res *resolver) resolvePath(path string) (string, error) {
 			return "", errors.New("EvalSymlinks: too many links")
 		}
 
-		link, err := os.Readlink(hostPath)
+		link, err := res.readlink(dest)
 		if err != nil {
 			return "", err
 		}
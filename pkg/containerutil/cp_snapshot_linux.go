@@ -0,0 +1,281 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package containerutil
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/core/containers"
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/containerd/v2/core/leases"
+	"github.com/containerd/containerd/v2/core/mount"
+	"github.com/containerd/errdefs"
+	"golang.org/x/sys/unix"
+
+	"go.farcloser.world/containers/specs"
+
+	"go.farcloser.world/lepton/pkg/tarutil"
+)
+
+const (
+	overlayWhiteoutPrefix = ".wh."
+	overlayOpaqueMarker   = ".wh..wh..opq"
+)
+
+// snapshotView is a read-only view of the root filesystem of a stopped container, built from the layers of its
+// snapshot, without mounting it.
+// Only single bind and overlay mounts are supported (eg: native, overlayfs and fuse-overlayfs snapshotters).
+type snapshotView struct {
+	mounts []mount.Mount
+	// layers are ordered from the top (writable) one to the bottom one
+	layers []string
+}
+
+// newSnapshotView returns a view of the container snapshot, or nil if its mounts are not supported.
+func newSnapshotView(
+	ctx context.Context,
+	client *containerd.Client,
+	conInfo containers.Container,
+) (*snapshotView, error) {
+	mounts, err := client.SnapshotService(conInfo.Snapshotter).Mounts(ctx, conInfo.SnapshotKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(mounts) != 1 {
+		return nil, nil
+	}
+
+	view := &snapshotView{mounts: mounts}
+
+	switch mnt := mounts[0]; {
+	case mnt.Type == "bind" || mnt.Type == "rbind":
+		view.layers = []string{mnt.Source}
+	case mnt.Type == "overlay" || strings.HasSuffix(mnt.Type, "fuse-overlayfs"):
+		var upper string
+		var lowers []string
+		for _, option := range mnt.Options {
+			if value, found := strings.CutPrefix(option, "upperdir="); found {
+				upper = value
+			} else if value, found = strings.CutPrefix(option, "lowerdir="); found {
+				lowers = strings.Split(value, ":")
+			}
+		}
+
+		// Without an upper directory, the snapshot is read-only
+		if upper == "" {
+			return nil, nil
+		}
+
+		view.layers = append([]string{upper}, lowers...)
+	default:
+		return nil, nil
+	}
+
+	return view, nil
+}
+
+// locate returns the location on the host of a container path, in the topmost layer holding it.
+// Whiteouts and opaque directories hide the content of lower layers.
+func (view *snapshotView) locate(containerPath string) (string, fs.FileInfo, error) {
+	notFound := &fs.PathError{Op: "lstat", Path: containerPath, Err: syscall.ENOENT}
+	components := strings.Split(strings.Trim(filepath.Clean(containerPath), "/"), "/")
+	last := len(components) - 1
+
+	for _, layer := range view.layers {
+		hidden := false
+		current := layer
+		for i, component := range components {
+			if component == "" {
+				break
+			}
+
+			if isOverlayWhiteoutFile(filepath.Join(current, overlayWhiteoutPrefix+component)) {
+				return "", nil, notFound
+			}
+
+			current = filepath.Join(current, component)
+			if i == last {
+				break
+			}
+
+			info, err := os.Lstat(current)
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					break
+				}
+
+				return "", nil, err
+			}
+
+			// A parent replaced by a file or deleted in this layer hides the lower layers
+			if !info.IsDir() {
+				return "", nil, notFound
+			}
+
+			hidden = hidden || isOverlayOpaque(current)
+		}
+
+		info, err := os.Lstat(current)
+		if err == nil {
+			if isOverlayWhiteoutInfo(info) {
+				return "", nil, notFound
+			}
+
+			return current, info, nil
+		}
+
+		if !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, syscall.ENOTDIR) {
+			return "", nil, err
+		}
+
+		if hidden {
+			return "", nil, notFound
+		}
+	}
+
+	return "", nil, notFound
+}
+
+func (view *snapshotView) lstat(containerPath string) (fs.FileInfo, error) {
+	_, info, err := view.locate(containerPath)
+
+	return info, err
+}
+
+func (view *snapshotView) readlink(containerPath string) (string, error) {
+	location, _, err := view.locate(containerPath)
+	if err != nil {
+		return "", err
+	}
+
+	return os.Readlink(location)
+}
+
+// apply extracts the archive read from r into the container directory dir.
+// The archive is stored as a temporary layer in the content store, and applied by containerd onto the snapshot
+// mounts, so that the snapshot never gets mounted by us - which also works in rootless mode.
+func (view *snapshotView) apply(
+	ctx context.Context,
+	client *containerd.Client,
+	r io.Reader,
+	dir string,
+	opts tarutil.UnpackOptions,
+) error {
+	ctx, done, err := client.WithLease(ctx, leases.WithRandomID(), leases.WithExpiration(1*time.Hour))
+	if err != nil {
+		return fmt.Errorf("failed to create lease for cp: %w", err)
+	}
+	defer done(ctx)
+
+	writer, err := content.OpenWriter(
+		ctx,
+		client.ContentStore(),
+		content.WithRef(fmt.Sprintf("cp-%d", time.Now().UnixNano())),
+	)
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	prefix := strings.Trim(dir, "/")
+	err = tarutil.Rewrite(writer, r, func(hdr *tar.Header) bool {
+		name := path.Clean("/" + hdr.Name)
+		// The destination directory itself is left alone
+		if name == "/" {
+			return false
+		}
+
+		hdr.Name = path.Join(prefix, name)
+		if hdr.Typeflag == tar.TypeLink {
+			hdr.Linkname = path.Join(prefix, path.Clean("/"+hdr.Linkname))
+		}
+
+		switch {
+		case opts.Chown != nil:
+			hdr.Uid, hdr.Gid = opts.Chown.UID, opts.Chown.GID
+		case opts.IDMap != nil:
+			own := opts.IDMap(tarutil.Owner{UID: hdr.Uid, GID: hdr.Gid})
+			hdr.Uid, hdr.Gid = own.UID, own.GID
+		}
+
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	status, err := writer.Status()
+	if err != nil {
+		return err
+	}
+
+	desc := specs.Descriptor{
+		MediaType: images.MediaTypeDockerSchema2Layer,
+		Digest:    writer.Digest(),
+		Size:      status.Offset,
+	}
+
+	if err = writer.Commit(ctx, desc.Size, desc.Digest); err != nil && !errdefs.IsAlreadyExists(err) {
+		return err
+	}
+
+	_, err = client.DiffService().Apply(ctx, desc, view.mounts)
+
+	return err
+}
+
+// isOverlayWhiteoutFile returns true if location is a fuse-overlayfs style whiteout file
+func isOverlayWhiteoutFile(location string) bool {
+	_, err := os.Lstat(location)
+
+	return err == nil
+}
+
+// isOverlayWhiteoutInfo returns true if info is an overlayfs whiteout (a 0:0 character device)
+func isOverlayWhiteoutInfo(info fs.FileInfo) bool {
+	st, ok := info.Sys().(*syscall.Stat_t)
+
+	return ok && st.Mode&syscall.S_IFMT == syscall.S_IFCHR && st.Rdev == 0
+}
+
+// isOverlayOpaque returns true if the directory at location hides the content of the same directory in lower layers
+func isOverlayOpaque(location string) bool {
+	for _, attr := range []string{"trusted.overlay.opaque", "user.overlay.opaque"} {
+		value := make([]byte, 1)
+		if size, err := unix.Lgetxattr(location, attr, value); err == nil && size == 1 && value[0] == 'y' {
+			return true
+		}
+	}
+
+	_, err := os.Lstat(filepath.Join(location, overlayOpaqueMarker))
+
+	return err == nil
+}
@@ -39,3 +39,11 @@ func HostsDirs() []string {
 func HostGatewayIP() string {
 	return ""
 }
+
+func QemuDir() string {
+	return ""
+}
+
+func HooksDir() string {
+	return ""
+}
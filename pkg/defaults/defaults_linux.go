@@ -117,6 +117,18 @@ func CliTOML() string {
 	return filepath.Join(xch, version.RootName, version.RootName+".toml")
 }
 
+// HooksDir returns the directory of the user-provided OCI hooks (hooks.d format).
+func HooksDir() string {
+	if !rootlessutil.IsRootless() {
+		return fmt.Sprintf("/etc/%s/hooks.d", version.RootName)
+	}
+	xch, err := rootlesskit.XDGConfigHome()
+	if err != nil {
+		panic(err)
+	}
+	return filepath.Join(xch, version.RootName, "hooks.d")
+}
+
 func HostsDirs() []string {
 	if !rootlessutil.IsRootless() {
 		return []string{"/etc/containerd/certs.d", "/etc/docker/certs.d"}
@@ -147,3 +159,8 @@ func HostGatewayIP() string {
 	}
 	return ""
 }
+
+// QemuDir returns the directory of the static qemu binaries registered by `system emulation install`.
+func QemuDir() string {
+	return "/usr/bin"
+}
@@ -82,12 +82,14 @@ type Meta struct {
 	ExtraHosts map[string]string // host:ip
 	Name       string
 	Domainname string
+	Aliases    map[string][]string // network name: aliases
 }
 
 type Store interface {
 	Acquire(meta Meta) error
 	Release(id string) error
 	Update(id, newName string) error
+	Get(id string) (*Meta, error)
 	HostsPath(id string) (location string, err error)
 	DeallocHostsFile(id string) (err error)
 	AllocHostsFile(id string, content []byte) (location string, err error)
@@ -261,6 +263,28 @@ func (x *hostsStore) Update(id, newName string) (err error) {
 	})
 }
 
+// Get returns the metadata of a container, as set on Acquire.
+// It is only available until Release.
+func (x *hostsStore) Get(id string) (meta *Meta, err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(ErrHostsStore, err)
+		}
+	}()
+
+	err = x.safeStore.WithLock(func() error {
+		var content []byte
+		if content, err = x.safeStore.Get(id, metaJSON); err != nil {
+			return err
+		}
+
+		meta = &Meta{}
+		return json.Unmarshal(content, meta)
+	})
+
+	return meta, err
+}
+
 func (x *hostsStore) updateAllHosts() (err error) {
 	entries, err := x.safeStore.List()
 	if err != nil {
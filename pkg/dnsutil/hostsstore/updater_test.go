@@ -31,9 +31,10 @@ func TestCreateLine(t *testing.T) {
 	type testCase struct {
 		thatIP         string
 		thatNetwork    string
-		thatHostname   string // run --hostname
-		thatDomainname string // run --domainname
-		thatName       string // run --name
+		thatHostname   string              // run --hostname
+		thatDomainname string              // run --domainname
+		thatName       string              // run --name
+		thatAliases    map[string][]string // run --network-alias
 		myNetwork      string
 		expected       string
 	}
@@ -114,6 +115,15 @@ func TestCreateLine(t *testing.T) {
 			myNetwork:      netutil.DefaultNetworkName,
 			expected:       "bar.example.com.example.com bar.example.com",
 		},
+		{
+			thatIP:       "10.4.2.10",
+			thatNetwork:  "n1",
+			thatHostname: "bar",
+			thatName:     "foo",
+			thatAliases:  map[string][]string{"n1": {"db", "cache"}, "n2": {"web"}},
+			myNetwork:    "n1",
+			expected:     "bar bar.n1 foo foo.n1 db cache",
+		},
 	}
 	for _, tc := range testCases {
 		thatMeta := &Meta{
@@ -135,6 +145,7 @@ func TestCreateLine(t *testing.T) {
 			Hostname:   tc.thatHostname,
 			Domainname: tc.thatDomainname,
 			Name:       tc.thatName,
+			Aliases:    tc.thatAliases,
 		}
 
 		myNetworks := map[string]struct{}{
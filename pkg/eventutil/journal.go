@@ -0,0 +1,308 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package eventutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/log"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+	"go.farcloser.world/lepton/leptonic/store"
+	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/clientutil"
+	"go.farcloser.world/lepton/pkg/labels"
+)
+
+const (
+	eventsDirBasename = "events"
+	journalKey        = "journal.jsonl"
+	rotatedJournalKey = "journal.1.jsonl"
+
+	// DefaultJournalSize is the maximum number of events kept in a namespace journal.
+	// Older events are discarded first.
+	DefaultJournalSize = 1000
+)
+
+// Type is the type of object an event is about.
+type Type string
+
+const (
+	TypeContainer Type = "container"
+	TypeImage     Type = "image"
+	TypeNetwork   Type = "network"
+	TypeVolume    Type = "volume"
+)
+
+// Known actions.
+const (
+	ActionCreate  = "create"
+	ActionStart   = "start"
+	ActionDie     = "die"
+	ActionOOM     = "oom"
+	ActionDestroy = "destroy"
+	ActionPull    = "pull"
+	ActionPush    = "push"
+	ActionTag     = "tag"
+	ActionDelete  = "delete"
+	// ActionHealthStatus is followed by the new health status, as in "health_status: healthy"
+	ActionHealthStatus = "health_status"
+)
+
+// Known attributes.
+const (
+	AttributeName     = "name"
+	AttributeImage    = "image"
+	AttributeExitCode = "exitCode"
+)
+
+// Event is a lepton-level event, as persisted in the journal.
+type Event struct {
+	Time      time.Time `json:"time"`
+	Namespace string    `json:"namespace"`
+	Type      Type      `json:"type"`
+	Action    string    `json:"action"`
+	// ID is the container ID, the image reference, or the network or volume name
+	ID         string            `json:"id"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// ErrJournal will wrap all errors here
+var ErrJournal = errors.New("events journal error")
+
+// Journal persists events per namespace, so that they can be replayed later.
+// The journal is bounded: once full, the oldest events are discarded. Events are appended to a file, which is rotated
+// once it holds size events, so that at least the last size events are always kept.
+// All methods are safe to use concurrently.
+type Journal interface {
+	// Append adds events at the end of the journal.
+	Append(events ...Event) error
+	// Read returns all events in the journal, oldest first.
+	Read() ([]Event, error)
+}
+
+// NewJournal returns a Journal for a given namespace, holding at most size events.
+func NewJournal(dataStore, namespace string, size int) (Journal, error) {
+	if dataStore == "" || namespace == "" || size <= 0 {
+		return nil, errors.Join(ErrJournal, errs.ErrInvalidArgument)
+	}
+
+	st, err := store.New(filepath.Join(dataStore, eventsDirBasename, namespace), false, 0, 0)
+	if err != nil {
+		return nil, errors.Join(ErrJournal, err)
+	}
+
+	return &journal{
+		safeStore: st,
+		size:      size,
+	}, nil
+}
+
+type journal struct {
+	safeStore store.Store
+	size      int
+}
+
+func (jn *journal) Append(events ...Event) (err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(ErrJournal, err)
+		}
+	}()
+
+	var buf bytes.Buffer
+	for _, event := range events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	return jn.safeStore.WithLock(func() error {
+		location, err := jn.safeStore.Location(journalKey)
+		if err != nil {
+			return err
+		}
+
+		count, err := countLines(location)
+		if err != nil {
+			return err
+		}
+
+		// Once full, the journal becomes the rotated one, replacing the previous rotated journal
+		if count > 0 && count+len(events) > jn.size {
+			rotated, err := jn.safeStore.Location(rotatedJournalKey)
+			if err != nil {
+				return err
+			}
+
+			if err = os.Rename(location, rotated); err != nil {
+				return err
+			}
+		}
+
+		file, err := os.OpenFile(location, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+		if err != nil {
+			return err
+		}
+
+		_, err = file.Write(buf.Bytes())
+
+		return errors.Join(err, file.Close())
+	})
+}
+
+func (jn *journal) Read() (events []Event, err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(ErrJournal, err)
+		}
+	}()
+
+	err = jn.safeStore.WithLock(func() error {
+		var lines [][]byte
+		for _, key := range []string{rotatedJournalKey, journalKey} {
+			keyLines, err := jn.lines(key)
+			if err != nil {
+				return err
+			}
+			lines = append(lines, keyLines...)
+		}
+
+		if len(lines) > jn.size {
+			lines = lines[len(lines)-jn.size:]
+		}
+
+		events = make([]Event, 0, len(lines))
+		for _, line := range lines {
+			var event Event
+			// A line may have been cut short by a crash while it was appended
+			if err := json.Unmarshal(line, &event); err != nil {
+				log.L.WithError(err).Debug("skipping an invalid events journal entry")
+				continue
+			}
+			events = append(events, event)
+		}
+
+		return nil
+	})
+
+	return events, err
+}
+
+func (jn *journal) lines(key string) ([][]byte, error) {
+	content, err := jn.safeStore.Get(key)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var lines [][]byte
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, nil
+}
+
+// countLines returns the number of lines of a file, or 0 if it does not exist.
+func countLines(location string) (int, error) {
+	file, err := os.Open(location)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+
+		return 0, err
+	}
+	defer file.Close()
+
+	count := 0
+	buf := make([]byte, 32*1024)
+	for {
+		read, err := file.Read(buf)
+		count += bytes.Count(buf[:read], []byte("\n"))
+		if errors.Is(err, io.EOF) {
+			return count, nil
+		}
+
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Record appends an event to the journal of the namespace in globalOptions, if the journal is enabled.
+// Time and Namespace are filled in when left empty.
+// Recording is best-effort: failures are logged and never returned to the caller.
+func Record(ctx context.Context, globalOptions *options.Global, event Event) {
+	if globalOptions == nil || !globalOptions.EventsJournal {
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	if event.Namespace == "" {
+		event.Namespace = globalOptions.Namespace
+	}
+
+	dataStore, err := clientutil.DataStore(globalOptions.DataRoot, globalOptions.Address)
+	if err == nil {
+		var jn Journal
+		if jn, err = NewJournal(dataStore, event.Namespace, DefaultJournalSize); err == nil {
+			err = jn.Append(event)
+		}
+	}
+
+	if err != nil {
+		log.G(ctx).WithError(err).Debugf("failed to record %s %s event", event.Type, event.Action)
+	}
+}
+
+// ContainerEvent returns an event about container c, with its name and image as attributes.
+func ContainerEvent(ctx context.Context, c containerd.Container, action string) Event {
+	event := Event{
+		Type:       TypeContainer,
+		Action:     action,
+		ID:         c.ID(),
+		Attributes: map[string]string{},
+	}
+
+	if info, err := c.Info(ctx, containerd.WithoutRefreshedMetadata); err == nil {
+		event.Attributes[AttributeName] = info.Labels[labels.Name]
+		event.Attributes[AttributeImage] = info.Image
+	}
+
+	return event
+}
@@ -0,0 +1,78 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package eventutil_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/lepton/pkg/eventutil"
+)
+
+func TestJournal(t *testing.T) {
+	t.Parallel()
+
+	_, err := eventutil.NewJournal(t.TempDir(), "", eventutil.DefaultJournalSize)
+	assert.ErrorIs(t, err, eventutil.ErrJournal, "empty namespace should fail")
+
+	journal, err := eventutil.NewJournal(t.TempDir(), "default", 3)
+	assert.NilError(t, err)
+
+	events, err := journal.Read()
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 0, "a new journal should not have any event")
+
+	now := time.Now().UTC()
+	for i := range 5 {
+		assert.NilError(t, journal.Append(eventutil.Event{
+			Time:       now.Add(time.Duration(i) * time.Second),
+			Namespace:  "default",
+			Type:       eventutil.TypeVolume,
+			Action:     eventutil.ActionCreate,
+			ID:         "volume" + strconv.Itoa(i),
+			Attributes: map[string]string{eventutil.AttributeName: "volume" + strconv.Itoa(i)},
+		}))
+	}
+
+	events, err = journal.Read()
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 3, "the journal should be bounded")
+	assert.Equal(t, events[0].ID, "volume2", "the oldest events should have been discarded")
+	assert.Equal(t, events[2].ID, "volume4")
+	assert.Assert(t, events[2].Time.Equal(now.Add(4*time.Second)))
+	assert.Equal(t, events[2].Attributes[eventutil.AttributeName], "volume4")
+
+	// The journal keeps the last events across several rotations
+	for i := 5; i < 12; i++ {
+		assert.NilError(t, journal.Append(eventutil.Event{
+			Time:      now.Add(time.Duration(i) * time.Second),
+			Namespace: "default",
+			Type:      eventutil.TypeVolume,
+			Action:    eventutil.ActionCreate,
+			ID:        "volume" + strconv.Itoa(i),
+		}))
+	}
+
+	events, err = journal.Read()
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 3)
+	assert.Equal(t, events[0].ID, "volume9")
+	assert.Equal(t, events[2].ID, "volume11")
+}
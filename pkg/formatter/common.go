@@ -19,7 +19,6 @@ package formatter
 import (
 	"bytes"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"text/template"
@@ -34,48 +33,21 @@ type Flusher interface {
 
 // FormatSlice formats the slice with `--format` flag.
 //
-// --format="" (default): JSON
-// --format='{{json .}}': JSON lines
+// --format="" (default): indented JSON array
+// --format=json: JSON array
+// --format=jsonl: JSON lines
 //
 // FormatSlice is expected to be only used for `OBJECT inspect` commands.
+// See Render for the other supported formats.
 func FormatSlice(format string, writer io.Writer, x []interface{}) error {
-	var tmpl *template.Template
-	switch format {
-	case FormatNone:
-		// Avoid escaping "<", ">", "&"
-		// https://pkg.go.dev/encoding/json
-		encoder := json.NewEncoder(writer)
-		encoder.SetIndent("", "    ")
-		encoder.SetEscapeHTML(false)
-		err := encoder.Encode(x)
-		if err != nil {
+	if format == FormatNone {
+		if err := Render(writer, format, x, nil); err != nil {
 			return err
 		}
-		fmt.Fprint(writer, "\n")
-	case FormatTable, FormatWide:
-		return errors.New("unsupported format: \"table\", and \"wide\"")
-	default:
-		var err error
-		tmpl, err = ParseTemplate(format)
-		if err != nil {
-			return err
-		}
-		for _, f := range x {
-			var b bytes.Buffer
-			if err := tmpl.Execute(&b, f); err != nil {
-				if _, ok := err.(template.ExecError); ok { //nolint:errorlint
-					// FallBack to Raw Format
-					if err = tryRawFormat(&b, f, tmpl); err != nil {
-						return err
-					}
-				}
-			}
-			if _, err = fmt.Fprintln(writer, b.String()); err != nil {
-				return err
-			}
-		}
+		_, err := fmt.Fprint(writer, "\n")
+		return err
 	}
-	return nil
+	return Render(writer, format, x, nil)
 }
 
 // FIXME: is this really serving a purpose?
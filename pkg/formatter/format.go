@@ -22,4 +22,9 @@ const (
 	FormatJSON   = "json"
 	FormatWide   = "wide"
 	FormatTable  = "table"
+
+	// FormatOpenMetrics is only supported by commands exposing metrics, e.g. `stats`
+	FormatOpenMetrics = "openmetrics"
+	// FormatPrometheus is only supported by commands exposing metrics, e.g. `stats`
+	FormatPrometheus = "prometheus"
 )
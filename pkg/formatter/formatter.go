@@ -21,6 +21,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"strconv"
 	"strings"
 	"time"
@@ -126,7 +127,8 @@ func FormatPorts(labelMap map[string]string) string {
 	}
 	strs := make([]string, len(ports))
 	for i, p := range ports {
-		strs[i] = fmt.Sprintf("%s:%d->%d/%s", p.HostIP, p.HostPort, p.ContainerPort, p.Protocol)
+		hostAddr := net.JoinHostPort(p.HostIP, strconv.Itoa(int(p.HostPort)))
+		strs[i] = fmt.Sprintf("%s->%d/%s", hostAddr, p.ContainerPort, p.Protocol)
 	}
 	return strings.Join(strs, ", ")
 }
@@ -0,0 +1,323 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package formatter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// FormatJSONLines outputs one JSON object per line
+	FormatJSONLines = "jsonl"
+	FormatYAML      = "yaml"
+	FormatCSV       = "csv"
+
+	tablePrefix = FormatTable + " "
+)
+
+// ErrUnsupportedFormat is returned when a format cannot be rendered for the given items.
+var ErrUnsupportedFormat = errors.New("unsupported format")
+
+// Formats lists the values accepted by Render, for use in flag completion and help.
+var Formats = []string{FormatTable, FormatWide, FormatJSON, FormatJSONLines, FormatYAML, FormatCSV}
+
+// Column describes a column of a table rendered by Render.
+type Column struct {
+	// Header is the column title, e.g. "CONTAINER ID"
+	Header string
+	// Field is the name of the field (or method) of the item providing the value, e.g. "ID"
+	Field string
+	// Wide columns are only displayed with `--format=wide` (and in csv)
+	Wide bool
+}
+
+// Render writes items in the requested format:
+//
+// --format="" or "table": table of the non-wide columns
+// --format="wide": table of all columns
+// --format="table {{.ID}}\t{{.Name}}": table built from the template, with headers
+// --format="json": JSON array
+// --format="jsonl": one JSON object per line
+// --format="yaml": YAML sequence
+// --format="csv": all columns, with a header line
+// --format="{{.ID}}": the template, once per item
+//
+// When columns is nil (e.g. for `OBJECT inspect` commands), the default format is an indented JSON array,
+// and "table", "wide" and "csv" are not supported.
+func Render[T any](writer io.Writer, format string, items []T, columns []Column) error {
+	switch {
+	case format == FormatNone && columns == nil:
+		return writeJSON(writer, items, "    ")
+	case format == FormatNone, format == FormatTable, format == FormatWide:
+		if columns == nil {
+			return fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+		}
+		return renderColumns(writer, items, columns, format == FormatWide)
+	case strings.HasPrefix(format, tablePrefix):
+		return renderTableTemplate(writer, strings.TrimPrefix(format, tablePrefix), items, columns)
+	case format == FormatJSON:
+		return writeJSON(writer, items, "")
+	case format == FormatJSONLines:
+		for _, item := range items {
+			if err := writeJSON(writer, item, ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	case format == FormatYAML:
+		return writeYAML(writer, items)
+	case format == FormatCSV:
+		if columns == nil {
+			return fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+		}
+		return writeCSV(writer, items, columns)
+	default:
+		tmpl, err := ParseTemplate(format)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			var b bytes.Buffer
+			if err := tmpl.Execute(&b, item); err != nil {
+				if _, ok := err.(template.ExecError); !ok { //nolint:errorlint
+					return err
+				}
+				// FallBack to Raw Format
+				b.Reset()
+				if err = tryRawFormat(&b, item, tmpl); err != nil {
+					return err
+				}
+			}
+			if _, err = fmt.Fprintln(writer, b.String()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeJSON(writer io.Writer, x any, indent string) error {
+	// Avoid escaping "<", ">", "&"
+	// https://pkg.go.dev/encoding/json
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", indent)
+	encoder.SetEscapeHTML(false)
+	// Always output an array, even when there is nothing to show
+	if v := reflect.ValueOf(x); v.Kind() == reflect.Slice && v.IsNil() {
+		x = []any{}
+	}
+	return encoder.Encode(x)
+}
+
+func writeYAML(writer io.Writer, x any) error {
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, x, ""); err != nil {
+		return err
+	}
+	// Going through JSON preserves the json tags and field ordering of the items.
+	var node yaml.Node
+	if err := yaml.Unmarshal(buf.Bytes(), &node); err != nil {
+		return err
+	}
+	resetStyle(&node)
+	encoder := yaml.NewEncoder(writer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// resetStyle drops the flow style and quoting inherited from the JSON input.
+func resetStyle(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" {
+		// Keep quoting strings that would otherwise be read back as another type
+		node.Style = yaml.DoubleQuotedStyle
+		var v any
+		if yaml.Unmarshal([]byte(node.Value), &v) == nil {
+			if _, ok := v.(string); ok {
+				node.Style = 0
+			}
+		}
+	} else {
+		node.Style = 0
+	}
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
+
+func parseColumns(columns []Column, wide bool) ([]string, []*template.Template, error) {
+	var (
+		headers   []string
+		templates []*template.Template
+	)
+	for _, col := range columns {
+		if col.Wide && !wide {
+			continue
+		}
+		tmpl, err := ParseTemplate("{{." + col.Field + "}}")
+		if err != nil {
+			return nil, nil, err
+		}
+		headers = append(headers, col.Header)
+		templates = append(templates, tmpl)
+	}
+	return headers, templates, nil
+}
+
+func execColumns[T any](item T, templates []*template.Template) ([]string, error) {
+	values := make([]string, len(templates))
+	for i, tmpl := range templates {
+		var b strings.Builder
+		if err := tmpl.Execute(&b, item); err != nil {
+			return nil, err
+		}
+		values[i] = b.String()
+	}
+	return values, nil
+}
+
+func renderColumns[T any](writer io.Writer, items []T, columns []Column, wide bool) error {
+	headers, templates, err := parseColumns(columns, wide)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(writer, 4, 8, 4, ' ', 0)
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	for _, item := range items {
+		values, err := execColumns(item, templates)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, strings.Join(values, "\t"))
+	}
+	return w.Flush()
+}
+
+func writeCSV[T any](writer io.Writer, items []T, columns []Column) error {
+	headers, templates, err := parseColumns(columns, true)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(writer)
+	if err = w.Write(headers); err != nil {
+		return err
+	}
+	for _, item := range items {
+		values, err := execColumns(item, templates)
+		if err != nil {
+			return err
+		}
+		if err = w.Write(values); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func renderTableTemplate[T any](writer io.Writer, format string, items []T, columns []Column) error {
+	format = strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(strings.TrimSpace(format))
+	tmpl, err := ParseTemplate(format)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(writer, 4, 8, 4, ' ', 0)
+	// The header line is produced by running the template against the column titles.
+	// It is omitted if the template does more than referencing fields.
+	var header bytes.Buffer
+	if headerTmpl, err := tmpl.Clone(); err == nil &&
+		headerTmpl.Option("missingkey=error").Execute(&header, headerContext(items, columns)) == nil {
+		fmt.Fprintln(w, header.String())
+	}
+	for _, item := range items {
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, item); err != nil {
+			return err
+		}
+		fmt.Fprintln(w, b.String())
+	}
+	return w.Flush()
+}
+
+// headerContext maps field names to column titles.
+// Fields without a matching column are titled after their name, e.g. "CreatedAt" becomes "CREATED AT".
+func headerContext[T any](items []T, columns []Column) map[string]string {
+	ctx := map[string]string{}
+	if len(items) > 0 {
+		v := reflect.ValueOf(items[0])
+		for v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				break
+			}
+			v = v.Elem()
+		}
+		typ := v.Type()
+		switch typ.Kind() {
+		case reflect.Struct:
+			for i := range typ.NumField() {
+				if field := typ.Field(i); field.IsExported() {
+					ctx[field.Name] = headerName(field.Name)
+				}
+			}
+		case reflect.Map:
+			for _, key := range v.MapKeys() {
+				if key.Kind() == reflect.String {
+					ctx[key.String()] = headerName(key.String())
+				}
+			}
+		default:
+		}
+		// Methods may be defined on the pointer
+		ptr := reflect.PointerTo(typ)
+		for i := range ptr.NumMethod() {
+			if method := ptr.Method(i); method.Type.NumIn() == 1 && method.Type.NumOut() == 1 {
+				ctx[method.Name] = headerName(method.Name)
+			}
+		}
+	}
+	for _, col := range columns {
+		ctx[col.Field] = col.Header
+	}
+	return ctx
+}
+
+func headerName(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) &&
+			(unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			b.WriteRune(' ')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,123 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package formatter_test
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/lepton/pkg/formatter"
+)
+
+type renderItem struct {
+	ID        string
+	Name      string
+	CreatedAt string
+	Labels    map[string]string `json:",omitempty"`
+}
+
+func (r renderItem) Short() string {
+	return r.ID[:2]
+}
+
+func TestRender(t *testing.T) {
+	items := []renderItem{
+		{ID: "abcdef", Name: "one", CreatedAt: "yesterday"},
+		{ID: "123456", Name: "two", CreatedAt: "today", Labels: map[string]string{"foo": "true"}},
+	}
+	columns := []formatter.Column{
+		{Header: "ID", Field: "Short"},
+		{Header: "NAME", Field: "Name"},
+		{Header: "CREATED", Field: "CreatedAt", Wide: true},
+	}
+
+	testCases := []struct {
+		format   string
+		columns  []formatter.Column
+		expected string
+		err      string
+	}{
+		{
+			format:   "",
+			columns:  columns,
+			expected: "ID    NAME\nab    one\n12    two\n",
+		},
+		{
+			format:   "wide",
+			columns:  columns,
+			expected: "ID    NAME    CREATED\nab    one     yesterday\n12    two     today\n",
+		},
+		{
+			format:   `table {{.ID}}\t{{.CreatedAt}}`,
+			columns:  columns,
+			expected: "ID        CREATED\nabcdef    yesterday\n123456    today\n",
+		},
+		{
+			format: "json",
+			expected: `[{"ID":"abcdef","Name":"one","CreatedAt":"yesterday"},` +
+				`{"ID":"123456","Name":"two","CreatedAt":"today","Labels":{"foo":"true"}}]` + "\n",
+		},
+		{
+			format: "jsonl",
+			expected: `{"ID":"abcdef","Name":"one","CreatedAt":"yesterday"}` + "\n" +
+				`{"ID":"123456","Name":"two","CreatedAt":"today","Labels":{"foo":"true"}}` + "\n",
+		},
+		{
+			format: "yaml",
+			expected: "- ID: abcdef\n  Name: one\n  CreatedAt: yesterday\n" +
+				"- ID: \"123456\"\n  Name: two\n  CreatedAt: today\n  Labels:\n    foo: \"true\"\n",
+		},
+		{
+			format:   "csv",
+			columns:  columns,
+			expected: "ID,NAME,CREATED\nab,one,yesterday\n12,two,today\n",
+		},
+		{
+			format:   "{{.Name}}",
+			expected: "one\ntwo\n",
+		},
+		{
+			format: "table",
+			err:    "unsupported format",
+		},
+		{
+			format: "csv",
+			err:    "unsupported format",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := formatter.Render(&buf, tc.format, items, tc.columns)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, buf.String(), tc.expected)
+		})
+	}
+}
+
+func TestRenderEmptyJSON(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NilError(t, formatter.Render[renderItem](&buf, formatter.FormatJSON, nil, nil))
+	assert.Equal(t, buf.String(), "[]\n")
+}
@@ -0,0 +1,389 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package healthcheck runs the healthcheck of a container image, and keeps the health state of the container,
+// as Docker reports it.
+package healthcheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/pkg/cio"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+	"go.farcloser.world/lepton/leptonic/store"
+	"go.farcloser.world/lepton/leptonic/utils"
+)
+
+// Health statuses, as reported by Docker.
+const (
+	Starting  = "starting"
+	Healthy   = "healthy"
+	Unhealthy = "unhealthy"
+)
+
+const (
+	healthDirBasename = "health"
+	stateKey          = "state.json"
+
+	// Docker defaults
+	defaultTimeout = 30 * time.Second
+	defaultRetries = 3
+	// Docker keeps the last 5 results, with at most 4096 bytes of output each
+	maxLogEntries = 5
+	maxOutputLen  = 4096
+)
+
+// ErrNoHealthcheck is returned when running the healthcheck of a container whose image does not define any.
+var ErrNoHealthcheck = errors.New("container has no healthcheck")
+
+// Config is the healthcheck of an image configuration.
+type Config struct {
+	Test          []string
+	Interval      time.Duration
+	Timeout       time.Duration
+	StartPeriod   time.Duration
+	StartInterval time.Duration
+	Retries       int
+}
+
+// Result is the outcome of a single check.
+type Result struct {
+	Start    time.Time
+	End      time.Time
+	ExitCode int
+	Output   string
+}
+
+// Health is the health state of a container.
+type Health struct {
+	Status        string
+	FailingStreak int
+	Log           []*Result
+}
+
+// state is the persisted health of a task: it starts over with every new task.
+type state struct {
+	Pid    uint32    `json:"pid"`
+	Since  time.Time `json:"since"`
+	Health *Health   `json:"health"`
+}
+
+// ParseConfig returns the healthcheck of an image configuration (as a raw JSON blob), or nil if it does not have any.
+func ParseConfig(imageConfig []byte) (*Config, error) {
+	var img struct {
+		Config struct {
+			Healthcheck *Config
+		} `json:"config"`
+	}
+	if err := json.Unmarshal(imageConfig, &img); err != nil {
+		return nil, fmt.Errorf("failed to parse image config: %w", err)
+	}
+
+	config := img.Config.Healthcheck
+	if config == nil || len(config.Test) == 0 || config.Test[0] == "NONE" {
+		return nil, nil
+	}
+
+	return config, nil
+}
+
+// Load returns the health state of the task with the given pid, from the state directory of its container.
+// It returns nil if no check was run for that task. A pid of 0 returns the state of the last task.
+func Load(stateDir string, pid uint32) (*Health, error) {
+	if _, err := os.Stat(filepath.Join(stateDir, healthDirBasename)); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	st, err := newStore(stateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var current *state
+	err = st.WithLock(func() error {
+		current, err = read(st)
+		return err
+	})
+	if err != nil || current == nil || (pid != 0 && current.Pid != pid) {
+		return nil, err
+	}
+
+	return current.Health, nil
+}
+
+// Run runs the healthcheck of a running container once, and records its result in the state directory of the
+// container. It returns the new health state, and whether its status changed.
+func Run(ctx context.Context, container containerd.Container, stateDir string) (*Health, bool, error) {
+	config, err := readConfig(ctx, container)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if config == nil {
+		return nil, false, fmt.Errorf("%w: %w %s", errs.ErrFailedPrecondition, ErrNoHealthcheck, container.ID())
+	}
+
+	task, err := container.Task(ctx, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	status, err := task.Status(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if status.Status != containerd.Running {
+		return nil, false, fmt.Errorf(
+			"%w: container %s is %s",
+			errs.ErrFailedPrecondition,
+			container.ID(),
+			status.Status,
+		)
+	}
+
+	result, err := check(ctx, container, task, config)
+	if err != nil {
+		return nil, false, err
+	}
+
+	st, err := newStore(stateDir)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var (
+		health  *Health
+		changed bool
+	)
+	err = st.WithLock(func() error {
+		current, err := read(st)
+		if err != nil {
+			return err
+		}
+
+		// A new task starts over
+		if current == nil || current.Pid != task.Pid() {
+			current = &state{
+				Pid:    task.Pid(),
+				Since:  result.Start,
+				Health: &Health{Status: Starting, Log: []*Result{}},
+			}
+		}
+
+		previous := current.Health.Status
+		apply(current, config, result)
+		health, changed = current.Health, current.Health.Status != previous
+
+		data, err := json.Marshal(current)
+		if err != nil {
+			return err
+		}
+
+		return st.Set(data, stateKey)
+	})
+
+	return health, changed, err
+}
+
+// apply updates the health of a task with the result of a check, as Docker does.
+func apply(current *state, config *Config, result *Result) {
+	health := current.Health
+	health.Log = append(health.Log, result)
+	if len(health.Log) > maxLogEntries {
+		health.Log = health.Log[len(health.Log)-maxLogEntries:]
+	}
+
+	if result.ExitCode == 0 {
+		health.Status = Healthy
+		health.FailingStreak = 0
+		return
+	}
+
+	// Failures during the start period do not count, until the container is healthy once
+	if health.Status == Starting && result.Start.Before(current.Since.Add(config.StartPeriod)) {
+		return
+	}
+
+	retries := config.Retries
+	if retries <= 0 {
+		retries = defaultRetries
+	}
+
+	health.FailingStreak++
+	if health.FailingStreak >= retries {
+		health.Status = Unhealthy
+	}
+}
+
+// check execs the healthcheck command in the task.
+func check(ctx context.Context, container containerd.Container, task containerd.Task, config *Config) (*Result, error) {
+	spec, err := container.Spec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pspec := spec.Process
+	pspec.Terminal = false
+	switch config.Test[0] {
+	case "CMD":
+		pspec.Args = config.Test[1:]
+	case "CMD-SHELL":
+		if spec.Windows != nil {
+			pspec.Args = append([]string{"cmd", "/S", "/C"}, config.Test[1:]...)
+		} else {
+			pspec.Args = append([]string{"/bin/sh", "-c"}, config.Test[1:]...)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown healthcheck type %q", errs.ErrInvalidArgument, config.Test[0])
+	}
+
+	if len(pspec.Args) == 0 {
+		return nil, fmt.Errorf("%w: empty healthcheck command", errs.ErrInvalidArgument)
+	}
+
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	output := &limitedBuffer{limit: maxOutputLen}
+	result := &Result{Start: time.Now()}
+
+	process, err := task.Exec(ctx, "healthcheck-"+utils.GenerateID(utils.ID32), pspec,
+		cio.NewCreator(cio.WithStreams(nil, output, output)))
+	if err != nil {
+		return nil, err
+	}
+	defer process.Delete(context.WithoutCancel(ctx), containerd.WithProcessKill)
+
+	statusC, err := process.Wait(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = process.Start(ctx); err != nil {
+		return nil, err
+	}
+
+	var exitStatus containerd.ExitStatus
+	select {
+	case exitStatus = <-statusC:
+	case <-time.After(timeout):
+		if err = process.Kill(ctx, syscall.SIGKILL); err != nil {
+			return nil, err
+		}
+		<-statusC
+		result.End = time.Now()
+		result.ExitCode = -1
+		result.Output = fmt.Sprintf("Health check exceeded timeout (%s)", timeout)
+		return result, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	process.IO().Wait()
+	result.End = time.Now()
+
+	code, _, err := exitStatus.Result()
+	if err != nil {
+		return nil, err
+	}
+
+	result.ExitCode = int(code)
+	result.Output = output.String()
+
+	return result, nil
+}
+
+func readConfig(ctx context.Context, container containerd.Container) (*Config, error) {
+	img, err := container.Image(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	desc, err := img.Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	imageConfig, err := content.ReadBlob(ctx, img.ContentStore(), desc)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseConfig(imageConfig)
+}
+
+func newStore(stateDir string) (store.Store, error) {
+	return store.New(filepath.Join(stateDir, healthDirBasename), false, 0, 0)
+}
+
+func read(st store.Store) (*state, error) {
+	data, err := st.Get(stateKey)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var current state
+	if err = json.Unmarshal(data, &current); err != nil {
+		return nil, err
+	}
+
+	return &current, nil
+}
+
+// limitedBuffer keeps the first bytes written to it, from both the stdout and stderr of the check.
+type limitedBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+	limit int
+}
+
+func (lb *limitedBuffer) Write(p []byte) (int, error) {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	if remaining := lb.limit - lb.buf.Len(); remaining > 0 {
+		lb.buf.Write(p[:min(len(p), remaining)])
+	}
+
+	return len(p), nil
+}
+
+func (lb *limitedBuffer) String() string {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	return lb.buf.String()
+}
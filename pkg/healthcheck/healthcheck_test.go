@@ -0,0 +1,120 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package healthcheck
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseConfig(t *testing.T) {
+	t.Parallel()
+
+	config, err := ParseConfig(
+		[]byte(`{"config":{"Healthcheck":{"Test":["CMD-SHELL","true"],"Interval":5000000000,"Retries":2}}}`),
+	)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, config, &Config{Test: []string{"CMD-SHELL", "true"}, Interval: 5 * time.Second, Retries: 2})
+
+	config, err = ParseConfig([]byte(`{"config":{"Healthcheck":{"Test":["NONE"]}}}`))
+	assert.NilError(t, err)
+	assert.Assert(t, config == nil, "NONE disables the healthcheck")
+
+	config, err = ParseConfig([]byte(`{"config":{}}`))
+	assert.NilError(t, err)
+	assert.Assert(t, config == nil)
+
+	_, err = ParseConfig([]byte(`{`))
+	assert.ErrorContains(t, err, "failed to parse image config")
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	since := time.Now()
+	config := &Config{StartPeriod: time.Minute, Retries: 2}
+	current := &state{Since: since, Health: &Health{Status: Starting}}
+	result := func(offset time.Duration, code int) *Result {
+		return &Result{Start: since.Add(offset), End: since.Add(offset + time.Second), ExitCode: code}
+	}
+
+	// Failures during the start period do not count
+	apply(current, config, result(10*time.Second, 1))
+	assert.Equal(t, current.Health.Status, Starting)
+	assert.Equal(t, current.Health.FailingStreak, 0)
+
+	apply(current, config, result(20*time.Second, 0))
+	assert.Equal(t, current.Health.Status, Healthy)
+
+	// Once healthy, failures count even during the start period
+	apply(current, config, result(30*time.Second, 1))
+	assert.Equal(t, current.Health.Status, Healthy)
+	assert.Equal(t, current.Health.FailingStreak, 1)
+
+	apply(current, config, result(40*time.Second, 1))
+	assert.Equal(t, current.Health.Status, Unhealthy)
+	assert.Equal(t, current.Health.FailingStreak, 2)
+
+	apply(current, config, result(50*time.Second, 0))
+	assert.Equal(t, current.Health.Status, Healthy)
+	assert.Equal(t, current.Health.FailingStreak, 0)
+
+	// Only the last results are kept
+	for i := range 10 {
+		apply(current, config, result(time.Duration(60+i)*time.Second, 0))
+	}
+	assert.Equal(t, len(current.Health.Log), maxLogEntries)
+	assert.Assert(t, current.Health.Log[maxLogEntries-1].Start.Equal(since.Add(69*time.Second)))
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	t.Parallel()
+
+	buf := &limitedBuffer{limit: 4}
+	n, err := buf.Write([]byte("abc"))
+	assert.NilError(t, err)
+	assert.Equal(t, n, 3)
+	n, err = buf.Write([]byte("def"))
+	assert.NilError(t, err)
+	assert.Equal(t, n, 3, "writes must not fail once the limit is reached")
+	assert.Equal(t, buf.String(), "abcd")
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	stateDir := t.TempDir()
+	health, err := Load(stateDir, 0)
+	assert.NilError(t, err)
+	assert.Assert(t, health == nil)
+
+	st, err := newStore(stateDir)
+	assert.NilError(t, err)
+	assert.NilError(t, st.WithLock(func() error {
+		return st.Set([]byte(`{"pid":42,"health":{"Status":"healthy","FailingStreak":0,"Log":[]}}`), stateKey)
+	}))
+
+	health, err = Load(stateDir, 42)
+	assert.NilError(t, err)
+	assert.Equal(t, health.Status, Healthy)
+
+	health, err = Load(stateDir, 43)
+	assert.NilError(t, err)
+	assert.Assert(t, health == nil, "the state of a previous task must be ignored")
+}
@@ -35,6 +35,7 @@ type opts struct {
 	skipVerifyCerts bool
 	hostsDirs       []string
 	authCreds       AuthCreds
+	mirrors         map[string][]string
 }
 
 // Opt for New
@@ -148,9 +149,20 @@ func New(ctx context.Context, refHostname string, optFuncs ...Opt) (remotes.Reso
 		return nil, err
 	}
 
+	var o opts
+	for _, of := range optFuncs {
+		of(&o)
+	}
+
+	hosts := dockerconfig.ConfigureHosts(ctx, *ho)
+	if len(o.mirrors) > 0 {
+		hosts = withMirrorHosts(hosts, o.mirrors)
+	}
+	hosts = withMirrorTracing(hosts)
+
 	resolverOpts := docker.ResolverOptions{
 		Tracker: PushTracker,
-		Hosts:   dockerconfig.ConfigureHosts(ctx, *ho),
+		Hosts:   hosts,
 	}
 
 	resolver := docker.NewResolver(resolverOpts)
@@ -0,0 +1,173 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dockerconfigresolver
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	tomlu "github.com/pelletier/go-toml/v2/unstable"
+)
+
+const hostsFileName = "hosts.toml"
+
+// HostConfig is the configuration of a host in a hosts.toml file.
+// See https://github.com/containerd/containerd/blob/main/docs/hosts.md
+type HostConfig struct {
+	Capabilities []string       `toml:"capabilities,omitempty"`
+	CA           any            `toml:"ca,omitempty"`
+	Client       any            `toml:"client,omitempty"`
+	SkipVerify   *bool          `toml:"skip_verify,omitempty"`
+	Header       map[string]any `toml:"header,omitempty"`
+	OverridePath bool           `toml:"override_path,omitempty"`
+}
+
+// Host is a host of a hosts.toml file, tried before the server.
+type Host struct {
+	URL string
+	HostConfig
+}
+
+// HostsFile is a containerd hosts.toml file.
+type HostsFile struct {
+	// Server is the registry itself, tried after the hosts
+	Server string `toml:"server,omitempty"`
+	HostConfig
+	// Hosts, by order of preference
+	Hosts []*Host `toml:"-"`
+}
+
+// HostsFilePath returns the path of the hosts.toml file of a registry (eg: docker.io) in hostsDir.
+func HostsFilePath(hostsDir, registry string) (string, error) {
+	regURL, err := Parse(registry)
+	if err != nil {
+		return "", err
+	}
+
+	// The standard port is implied, see hostDirsFromRoot
+	host := regURL.Host
+	if regURL.Port() == StandardHTTPSPort {
+		host = regURL.Hostname()
+	}
+
+	// See the Docker inconsistencies handling in NewHostOptions
+	if regURL.Hostname() == "index.docker.io" {
+		host = "docker.io"
+	}
+
+	// Prefer an existing directory, otherwise use the canonical containerd form (eg: "host_port_")
+	dir := filepath.Join(hostsDir, host)
+	if idx := strings.LastIndex(host, ":"); idx > 0 {
+		if _, err = os.Stat(dir); err != nil {
+			dir = filepath.Join(hostsDir, host[:idx]+"_"+host[idx+1:]+"_")
+		}
+	}
+
+	return filepath.Join(dir, hostsFileName), nil
+}
+
+// LoadHostsFile reads a hosts.toml file. A missing file yields an empty HostsFile.
+func LoadHostsFile(path string) (*HostsFile, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &HostsFile{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	parsed := struct {
+		HostsFile
+		Hosts map[string]HostConfig `toml:"host"`
+	}{}
+	if err = toml.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	hostsFile := parsed.HostsFile
+	for _, url := range sortedHosts(data) {
+		hostsFile.Hosts = append(hostsFile.Hosts, &Host{URL: url, HostConfig: parsed.Hosts[url]})
+	}
+
+	return &hostsFile, nil
+}
+
+// Save writes the hosts.toml file, preserving the order of hosts.
+func (hf *HostsFile) Save(path string) error {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(hf); err != nil {
+		return err
+	}
+
+	// Hosts are encoded one at a time, since toml maps are not ordered
+	for _, host := range hf.Hosts {
+		var hostBuf bytes.Buffer
+		err := toml.NewEncoder(&hostBuf).Encode(struct {
+			Host map[string]HostConfig `toml:"host"`
+		}{Host: map[string]HostConfig{host.URL: host.HostConfig}})
+		if err != nil {
+			return err
+		}
+
+		// Drop the leading (implicit) [host] table, which may only be defined once
+		_, table, _ := strings.Cut(hostBuf.String(), "\n")
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString(strings.TrimLeft(table, "\n"))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// sortedHosts returns the hosts of a hosts.toml file, in order, the same way containerd does.
+func sortedHosts(data []byte) []string {
+	var res []string
+	var current string
+
+	parser := tomlu.Parser{}
+	parser.Reset(data)
+	for parser.NextExpression() {
+		expr := parser.Expression()
+		if expr.Kind != tomlu.Table {
+			continue
+		}
+
+		var parts []string
+		for it := expr.Key(); it.Next(); {
+			parts = append(parts, string(it.Node().Data))
+		}
+
+		// Only consider `host.XXX`, and skip sub-tables such as `host.XXX.header`
+		if len(parts) < 2 || parts[0] != "host" || parts[1] == current {
+			continue
+		}
+
+		current = parts[1]
+		res = append(res, current)
+	}
+
+	return res
+}
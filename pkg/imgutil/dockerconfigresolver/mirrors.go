@@ -0,0 +1,332 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dockerconfigresolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+	"github.com/containerd/log"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+)
+
+// WithMirrors specifies mirrors to pull from, by order of preference, before falling back to the registry itself.
+// mirrors maps a registry host (eg: docker.io) to mirror urls (eg: https://mirror.example.com).
+func WithMirrors(mirrors map[string][]string) Opt {
+	return func(o *opts) {
+		o.mirrors = mirrors
+	}
+}
+
+// withMirrorHosts prepends the mirrors of a registry to its hosts.
+// Mirrors share the client and authorizer of the registry, and may only be used to pull and resolve.
+func withMirrorHosts(hosts docker.RegistryHosts, mirrors map[string][]string) docker.RegistryHosts {
+	return func(host string) ([]docker.RegistryHost, error) {
+		registryHosts, err := hosts(host)
+		if err != nil {
+			return nil, err
+		}
+
+		mirrorURLs := mirrors[host]
+		if host == "index.docker.io" || host == "registry-1.docker.io" {
+			mirrorURLs = mirrors["docker.io"]
+		}
+
+		if len(mirrorURLs) == 0 || len(registryHosts) == 0 {
+			return registryHosts, nil
+		}
+
+		// The registry itself is always last
+		base := registryHosts[len(registryHosts)-1]
+		res := make([]docker.RegistryHost, 0, len(mirrorURLs)+len(registryHosts))
+		for _, mirrorURL := range mirrorURLs {
+			u, err := url.Parse(mirrorURL)
+			if err != nil || u.Host == "" {
+				return nil, fmt.Errorf("invalid mirror url %q for %q", mirrorURL, host)
+			}
+
+			mirror := base
+			mirror.Scheme = u.Scheme
+			mirror.Host = u.Host
+			mirror.Path = path.Join("/", u.Path)
+			if !strings.HasSuffix(mirror.Path, "/v2") {
+				mirror.Path = path.Join(mirror.Path, "v2")
+			}
+			mirror.Capabilities = docker.HostCapabilityPull | docker.HostCapabilityResolve
+			res = append(res, mirror)
+		}
+
+		return append(res, registryHosts...), nil
+	}
+}
+
+// Mirror is a mirror of a registry, as declared in a hosts.toml file.
+type Mirror struct {
+	Registry string
+	URL      string
+	// Priority is the position of the mirror, starting at 1 for the mirror tried first
+	Priority     int
+	Capabilities []string
+	SkipVerify   bool
+	CA           string
+	// Source is the hosts.toml file declaring the mirror
+	Source string
+}
+
+// AddMirror adds (or updates) a mirror of registry in the hosts.toml file of hostsDir.
+// priority is the position of the mirror, starting at 1 for the mirror tried first, 0 appending it after the others.
+func AddMirror(hostsDir, registry, mirrorURL string, priority int, skipVerify bool, ca string) (*Mirror, error) {
+	mirrorURL, err := normalizeMirrorURL(mirrorURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if priority < 0 {
+		return nil, fmt.Errorf("%w: priority must be positive", errs.ErrInvalidArgument)
+	}
+
+	if ca != "" {
+		if ca, err = filepath.Abs(ca); err != nil {
+			return nil, err
+		}
+
+		if _, err = os.Stat(ca); err != nil {
+			return nil, fmt.Errorf("%w: invalid ca file: %w", errs.ErrInvalidArgument, err)
+		}
+	}
+
+	path, err := HostsFilePath(hostsDir, registry)
+	if err != nil {
+		return nil, errors.Join(errs.ErrInvalidArgument, err)
+	}
+
+	hostsFile, err := LoadHostsFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	host := &Host{URL: mirrorURL}
+	for i, existing := range hostsFile.Hosts {
+		if existing.URL == mirrorURL {
+			host = existing
+			hostsFile.Hosts = append(hostsFile.Hosts[:i], hostsFile.Hosts[i+1:]...)
+			if priority == 0 {
+				priority = i + 1
+			}
+			break
+		}
+	}
+
+	if len(host.Capabilities) == 0 {
+		host.Capabilities = []string{"pull", "resolve"}
+	}
+	host.SkipVerify = nil
+	if skipVerify {
+		host.SkipVerify = &skipVerify
+	}
+	if ca != "" {
+		host.CA = ca
+	}
+
+	if priority == 0 || priority > len(hostsFile.Hosts) {
+		priority = len(hostsFile.Hosts) + 1
+	}
+	hostsFile.Hosts = slices.Insert(hostsFile.Hosts, priority-1, host)
+
+	if err = hostsFile.Save(path); err != nil {
+		return nil, err
+	}
+
+	return newMirror(registry, path, priority, host), nil
+}
+
+// RemoveMirror removes a mirror of registry from the hosts.toml file of hostsDir.
+func RemoveMirror(hostsDir, registry, mirrorURL string) error {
+	mirrorURL, err := normalizeMirrorURL(mirrorURL)
+	if err != nil {
+		return err
+	}
+
+	path, err := HostsFilePath(hostsDir, registry)
+	if err != nil {
+		return errors.Join(errs.ErrInvalidArgument, err)
+	}
+
+	hostsFile, err := LoadHostsFile(path)
+	if err != nil {
+		return err
+	}
+
+	index := slices.IndexFunc(hostsFile.Hosts, func(host *Host) bool {
+		return host.URL == mirrorURL
+	})
+	if index == -1 {
+		return fmt.Errorf("%w: no mirror %q for registry %q in %s", errs.ErrNotFound, mirrorURL, registry, path)
+	}
+
+	hostsFile.Hosts = slices.Delete(hostsFile.Hosts, index, index+1)
+
+	return hostsFile.Save(path)
+}
+
+// ListMirrors returns the mirrors declared in the hosts.toml files of hostsDirs.
+func ListMirrors(hostsDirs []string) ([]*Mirror, error) {
+	var res []*Mirror
+	for _, hostsDir := range validateDirectories(hostsDirs) {
+		entries, err := os.ReadDir(hostsDir)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+
+			path := filepath.Join(hostsDir, entry.Name(), hostsFileName)
+			hostsFile, err := LoadHostsFile(path)
+			if err != nil {
+				log.L.WithError(err).Warnf("Ignoring hosts file %q", path)
+				continue
+			}
+
+			registry := registryFromHostDirectory(entry.Name())
+			for i, host := range hostsFile.Hosts {
+				res = append(res, newMirror(registry, path, i+1, host))
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func newMirror(registry, path string, priority int, host *Host) *Mirror {
+	mirror := &Mirror{
+		Registry:     registry,
+		URL:          host.URL,
+		Priority:     priority,
+		Capabilities: host.Capabilities,
+		SkipVerify:   host.SkipVerify != nil && *host.SkipVerify,
+		Source:       path,
+	}
+	if ca, ok := host.CA.(string); ok {
+		mirror.CA = ca
+	}
+
+	return mirror
+}
+
+// normalizeMirrorURL defaults the scheme of a mirror url to https.
+func normalizeMirrorURL(mirrorURL string) (string, error) {
+	if !strings.Contains(mirrorURL, "://") {
+		mirrorURL = "https://" + mirrorURL
+	}
+
+	u, err := url.Parse(mirrorURL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("%w: invalid mirror url %q", errs.ErrInvalidArgument, mirrorURL)
+	}
+
+	return strings.TrimSuffix(u.String(), "/"), nil
+}
+
+// registryFromHostDirectory reverts the containerd "host_port_" directory naming.
+func registryFromHostDirectory(name string) string {
+	if trimmed, ok := strings.CutSuffix(name, "_"); ok {
+		if idx := strings.LastIndex(trimmed, "_"); idx > 0 {
+			return trimmed[:idx] + ":" + trimmed[idx+1:]
+		}
+	}
+
+	return name
+}
+
+type mirrorReporterKey struct{}
+
+// WithMirrorReporter returns a context reporting to fn the mirrors that successfully served requests of a registry.
+func WithMirrorReporter(ctx context.Context, fn func(registry, mirror string)) context.Context {
+	return context.WithValue(ctx, mirrorReporterKey{}, fn)
+}
+
+// withMirrorTracing logs the requests served by mirrors, that is, every host but the registry itself (last).
+func withMirrorTracing(hosts docker.RegistryHosts) docker.RegistryHosts {
+	return func(host string) ([]docker.RegistryHost, error) {
+		registryHosts, err := hosts(host)
+		if err != nil || len(registryHosts) < 2 {
+			return registryHosts, err
+		}
+
+		res := slices.Clone(registryHosts)
+		for i := range res[:len(res)-1] {
+			client := http.Client{}
+			if res[i].Client != nil {
+				client = *res[i].Client
+			}
+
+			transport := client.Transport
+			if transport == nil {
+				transport = http.DefaultTransport
+			}
+
+			client.Transport = &mirrorTransport{
+				registry: host,
+				mirror:   res[i].Host,
+				next:     transport,
+			}
+			res[i].Client = &client
+		}
+
+		return res, nil
+	}
+}
+
+type mirrorTransport struct {
+	registry string
+	mirror   string
+	next     http.RoundTripper
+}
+
+func (t *mirrorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	logger := log.G(ctx).WithField("registry", t.registry).WithField("mirror", t.mirror)
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		logger.WithError(err).Debugf("mirror request %s %s failed", req.Method, req.URL.Path)
+		return resp, err
+	}
+
+	logger.Debugf("mirror request %s %s: %s", req.Method, req.URL.Path, resp.Status)
+	// Authentication challenges are retried by the resolver, other errors fall back to the next host
+	if resp.StatusCode < http.StatusBadRequest {
+		if report, ok := ctx.Value(mirrorReporterKey{}).(func(registry, mirror string)); ok {
+			report(t.registry, t.mirror)
+		}
+	}
+
+	return resp, err
+}
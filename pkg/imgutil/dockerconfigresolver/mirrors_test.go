@@ -0,0 +1,101 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package dockerconfigresolver_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+	"go.farcloser.world/lepton/pkg/imgutil/dockerconfigresolver"
+)
+
+func TestHostsFilePreservesOrder(t *testing.T) {
+	hostsDir := t.TempDir()
+	path := filepath.Join(hostsDir, "docker.io", "hosts.toml")
+	assert.NilError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	assert.NilError(t, os.WriteFile(path, []byte(`server = "https://registry-1.docker.io"
+
+[host."https://zz.example.com"]
+  capabilities = ["pull", "resolve"]
+  [host."https://zz.example.com".header]
+    x-custom = "value"
+
+[host."https://aa.example.com"]
+  capabilities = ["pull"]
+  skip_verify = true
+`), 0o644))
+
+	hostsFile, err := dockerconfigresolver.LoadHostsFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, hostsFile.Server, "https://registry-1.docker.io")
+	assert.Equal(t, len(hostsFile.Hosts), 2)
+	assert.Equal(t, hostsFile.Hosts[0].URL, "https://zz.example.com")
+	assert.Equal(t, hostsFile.Hosts[1].URL, "https://aa.example.com")
+
+	assert.NilError(t, hostsFile.Save(path))
+
+	saved, err := dockerconfigresolver.LoadHostsFile(path)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, saved, hostsFile)
+}
+
+func TestMirrors(t *testing.T) {
+	hostsDir := t.TempDir()
+
+	_, err := dockerconfigresolver.AddMirror(hostsDir, "docker.io", "https://one.example.com", 0, false, "")
+	assert.NilError(t, err)
+
+	// The scheme defaults to https, and priority 1 is tried first
+	added, err := dockerconfigresolver.AddMirror(hostsDir, "index.docker.io", "two.example.com:5000", 1, true, "")
+	assert.NilError(t, err)
+	assert.Equal(t, added.URL, "https://two.example.com:5000")
+	assert.Equal(t, added.Priority, 1)
+
+	registry := "registry.example.com:5000"
+	_, err = dockerconfigresolver.AddMirror(hostsDir, registry, "http://three.example.com", 0, false, "")
+	assert.NilError(t, err)
+
+	_, err = dockerconfigresolver.AddMirror(hostsDir, "docker.io", "ftp://four.example.com", 0, false, "")
+	assert.Assert(t, errors.Is(err, errs.ErrInvalidArgument))
+
+	mirrors, err := dockerconfigresolver.ListMirrors([]string{hostsDir})
+	assert.NilError(t, err)
+	assert.Equal(t, len(mirrors), 3)
+	assert.Equal(t, mirrors[0].Registry, "docker.io")
+	assert.Equal(t, mirrors[0].URL, "https://two.example.com:5000")
+	assert.Equal(t, mirrors[0].SkipVerify, true)
+	assert.Equal(t, mirrors[1].URL, "https://one.example.com")
+	assert.Equal(t, mirrors[1].Priority, 2)
+	assert.DeepEqual(t, mirrors[1].Capabilities, []string{"pull", "resolve"})
+	assert.Equal(t, mirrors[2].Registry, "registry.example.com:5000")
+	assert.Equal(t, mirrors[2].Source, filepath.Join(hostsDir, "registry.example.com_5000_", "hosts.toml"))
+
+	assert.NilError(t, dockerconfigresolver.RemoveMirror(hostsDir, "docker.io", "two.example.com:5000"))
+	err = dockerconfigresolver.RemoveMirror(hostsDir, "docker.io", "https://two.example.com:5000")
+	assert.Assert(t, errors.Is(err, errs.ErrNotFound))
+
+	mirrors, err = dockerconfigresolver.ListMirrors([]string{hostsDir})
+	assert.NilError(t, err)
+	assert.Equal(t, len(mirrors), 2)
+	assert.Equal(t, mirrors[0].URL, "https://one.example.com")
+	assert.Equal(t, mirrors[0].Priority, 1)
+}
@@ -40,9 +40,11 @@ import (
 	"go.farcloser.world/lepton/leptonic/errs"
 	"go.farcloser.world/lepton/pkg/api/options"
 	"go.farcloser.world/lepton/pkg/errutil"
+	"go.farcloser.world/lepton/pkg/eventutil"
 	"go.farcloser.world/lepton/pkg/idutil/imagewalker"
 	"go.farcloser.world/lepton/pkg/imgutil/dockerconfigresolver"
 	"go.farcloser.world/lepton/pkg/imgutil/pull"
+	"go.farcloser.world/lepton/pkg/metricsutil"
 )
 
 // EnsuredImage contains the image existed in containerd and its metadata.
@@ -140,7 +142,15 @@ func EnsureImage(
 		return nil, err
 	}
 
-	var dOpts []dockerconfigresolver.Opt
+	if err = CheckContentQuota(ctx, client, options.GOptions.Namespace, 0); err != nil {
+		return nil, err
+	}
+
+	dOpts, err := RegistryMirrorOpts(ctx, client, options.GOptions)
+	if err != nil {
+		return nil, err
+	}
+
 	if options.GOptions.InsecureRegistry {
 		log.G(ctx).Warnf("skipping verifying HTTPS certs for %q", parsedReference.Domain)
 		dOpts = append(dOpts, dockerconfigresolver.WithSkipVerifyCerts(true))
@@ -225,6 +235,7 @@ func PullImage(
 		Platforms:  options.OCISpecPlatform, // empty for all-platforms
 	}
 	if !options.Quiet {
+		config.Progress = options.Progress
 		config.ProgressOutput = options.Stderr
 		if options.ProgressOutputToStdout {
 			config.ProgressOutput = options.Stdout
@@ -260,6 +271,12 @@ func PullImage(
 	if err != nil {
 		return nil, err
 	}
+	metricsutil.Increment(ctx, options.GOptions, metricsutil.ImagePulls)
+	eventutil.Record(ctx, options.GOptions, eventutil.Event{
+		Type:   eventutil.TypeImage,
+		Action: eventutil.ActionPull,
+		ID:     ref,
+	})
 	imgConfig, err := getImageConfig(ctx, containerdImage)
 	if err != nil {
 		return nil, err
@@ -21,7 +21,6 @@ import (
 	"fmt"
 	"io"
 	"sync"
-	"text/tabwriter"
 	"time"
 
 	"github.com/containerd/containerd/v2/core/content"
@@ -34,14 +33,13 @@ import (
 	"go.farcloser.world/containers/specs"
 )
 
-// ShowProgress continuously updates the output with job progress
+// ShowProgress continuously updates the printer with job progress
 // by checking status in the content store.
 //
 // From https://github.com/containerd/containerd/blob/v1.7.0-rc.2/cmd/ctr/commands/content/fetch.go#L219-L336
-func ShowProgress(ctx context.Context, ongoing *Jobs, cs content.Store, out io.Writer) {
+func ShowProgress(ctx context.Context, ongoing *Jobs, cs content.Store, printer Printer) {
 	var (
 		ticker   = time.NewTicker(100 * time.Millisecond)
-		fw       = progress.NewWriter(out)
 		start    = time.Now()
 		statuses = map[string]StatusInfo{}
 		done     bool
@@ -52,20 +50,22 @@ outer:
 	for {
 		select {
 		case <-ticker.C:
-			fw.Flush()
-
-			tw := tabwriter.NewWriter(fw, 1, 8, 1, ' ', 0)
-
 			resolved := StatusResolved
 			if !ongoing.IsResolved() {
 				resolved = StatusResolving
 			}
 			statuses[ongoing.name] = StatusInfo{
 				Ref:    ongoing.name,
+				Mirror: ongoing.Mirror(),
 				Status: resolved,
 			}
 			keys := []string{ongoing.name}
 
+			descs := map[string]specs.Descriptor{}
+			for _, j := range ongoing.Jobs() {
+				descs[remotes.MakeRefKey(ctx, j)] = j
+			}
+
 			activeSeen := map[string]struct{}{}
 			if !done {
 				active, err := cs.ListStatuses(ctx, "")
@@ -138,16 +138,19 @@ outer:
 
 			var ordered []StatusInfo
 			for _, key := range keys {
-				ordered = append(ordered, statuses[key])
+				status := statuses[key]
+				if desc, ok := descs[key]; ok {
+					status.Digest = desc.Digest
+					status.MediaType = desc.MediaType
+				}
+				ordered = append(ordered, status)
 			}
 
-			Display(tw, ordered, start)
-			tw.Flush()
-
 			if done {
-				fw.Flush()
+				printer.Done(ordered)
 				return
 			}
+			printer.Update(ordered)
 		case <-ctx.Done():
 			done = true // allow ui to update once more
 		}
@@ -167,6 +170,7 @@ type Jobs struct {
 	descs    []specs.Descriptor
 	mu       sync.Mutex
 	resolved bool
+	mirror   string
 }
 
 // New creates a new instance of the job status tracker.
@@ -210,6 +214,20 @@ func (j *Jobs) IsResolved() bool {
 	return j.resolved
 }
 
+// SetMirror records the registry mirror the content is being fetched from.
+func (j *Jobs) SetMirror(mirror string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.mirror = mirror
+}
+
+// Mirror returns the registry mirror the content is being fetched from, if any.
+func (j *Jobs) Mirror() string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.mirror
+}
+
 // StatusInfoStatus describes status info for an upload or download.
 // From https://github.com/containerd/containerd/blob/v1.7.0-rc.2/cmd/ctr/commands/content/fetch.go#L388-L400
 type StatusInfoStatus string
@@ -223,17 +241,31 @@ const (
 	StatusDownloading StatusInfoStatus = "downloading"
 	StatusUploading   StatusInfoStatus = "uploading"
 	StatusExists      StatusInfoStatus = "exists"
+	StatusUnpacking   StatusInfoStatus = "unpacking"
 )
 
 // StatusInfo holds the status info for an upload or download.
 // From https://github.com/containerd/containerd/blob/v1.7.0-rc.2/cmd/ctr/commands/content/fetch.go#L402-L410
 type StatusInfo struct {
 	Ref       string
+	Digest    digest.Digest
+	MediaType string
 	Status    StatusInfoStatus
 	Offset    int64
 	Total     int64
 	StartedAt time.Time
 	UpdatedAt time.Time
+	// Mirror is the registry mirror the image is fetched from, if any. Only set on the status of the image reference.
+	Mirror string
+}
+
+// displayRef returns the reference of status, along with the mirror it is fetched from, for humans.
+func displayRef(status StatusInfo) string {
+	if status.Mirror == "" {
+		return status.Ref
+	}
+
+	return status.Ref + " (via mirror " + status.Mirror + ")"
 }
 
 // Display pretty prints out the download or upload progress.
@@ -249,20 +281,20 @@ func Display(w io.Writer, statuses []StatusInfo, start time.Time) {
 				bar = progress.Bar(float64(status.Offset) / float64(status.Total))
 			}
 			fmt.Fprintf(w, "%s:\t%s\t%40r\t%8.8s/%s\t\n",
-				status.Ref,
+				displayRef(status),
 				status.Status,
 				bar,
 				progress.Bytes(status.Offset), progress.Bytes(status.Total))
 		case StatusResolving, StatusWaiting:
 			bar := progress.Bar(0.0)
 			fmt.Fprintf(w, "%s:\t%s\t%40r\t\n",
-				status.Ref,
+				displayRef(status),
 				status.Status,
 				bar)
 		default:
 			bar := progress.Bar(1.0)
 			fmt.Fprintf(w, "%s:\t%s\t%40r\t\n",
-				status.Ref,
+				displayRef(status),
 				status.Status,
 				bar)
 		}
@@ -0,0 +1,228 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/containerd/containerd/v2/pkg/progress"
+	"golang.org/x/term"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+)
+
+// Progress output modes, as accepted by --progress.
+const (
+	// ProgressAuto is ProgressTTY on a terminal, ProgressPlain otherwise
+	ProgressAuto = "auto"
+	// ProgressTTY redraws progress bars in place
+	ProgressTTY = "tty"
+	// ProgressPlain prints a line per status change, without cursor control codes
+	ProgressPlain = "plain"
+	// ProgressJSON prints a json event per status change
+	ProgressJSON = "json"
+)
+
+// progressInterval is the minimum delay between two events reporting only the progress of the same descriptor.
+const progressInterval = time.Second
+
+// ProgressModes lists the progress output modes.
+var ProgressModes = []string{ProgressAuto, ProgressTTY, ProgressPlain, ProgressJSON}
+
+// ValidateProgress returns an error if mode is not a progress output mode. An empty mode is ProgressAuto.
+func ValidateProgress(mode string) error {
+	if mode != "" && !slices.Contains(ProgressModes, mode) {
+		return fmt.Errorf(
+			"%w: invalid progress mode %q (must be one of %v)",
+			errs.ErrInvalidArgument,
+			mode,
+			ProgressModes,
+		)
+	}
+
+	return nil
+}
+
+// Printer renders the statuses of jobs.
+type Printer interface {
+	// Update renders the current statuses
+	Update(statuses []StatusInfo)
+	// Done renders the final statuses
+	Done(statuses []StatusInfo)
+}
+
+// NewPrinter returns a Printer writing to out in the given progress output mode.
+func NewPrinter(out io.Writer, mode string) Printer {
+	if mode == "" || mode == ProgressAuto {
+		mode = ProgressPlain
+		if f, ok := out.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+			mode = ProgressTTY
+		}
+	}
+
+	start := time.Now()
+	switch mode {
+	case ProgressJSON:
+		return &changePrinter{
+			last:     map[string]printedStatus{},
+			interval: progressInterval,
+			print:    jsonEvent(json.NewEncoder(out)),
+		}
+	case ProgressPlain:
+		return &changePrinter{
+			last:     map[string]printedStatus{},
+			interval: progressInterval,
+			print:    plainEvent(out),
+			done:     plainSummary(out, start),
+		}
+	default:
+		return &ttyPrinter{
+			fw:    progress.NewWriter(out),
+			start: start,
+		}
+	}
+}
+
+// ttyPrinter redraws the statuses in place.
+type ttyPrinter struct {
+	fw    *progress.Writer
+	start time.Time
+}
+
+func (p *ttyPrinter) Update(statuses []StatusInfo) {
+	p.fw.Flush()
+
+	tw := tabwriter.NewWriter(p.fw, 1, 8, 1, ' ', 0)
+	Display(tw, statuses, p.start)
+	tw.Flush()
+}
+
+func (p *ttyPrinter) Done(statuses []StatusInfo) {
+	p.Update(statuses)
+	p.fw.Flush()
+}
+
+// changePrinter prints the statuses that changed since the last update.
+// Changes of the progress alone are printed at most once per interval, save for the final statuses.
+type changePrinter struct {
+	last     map[string]printedStatus
+	interval time.Duration
+	print    func(status StatusInfo)
+	done     func(statuses []StatusInfo)
+}
+
+// printedStatus is the last status printed for a descriptor, and when it was.
+type printedStatus struct {
+	status StatusInfo
+	at     time.Time
+}
+
+func (p *changePrinter) Update(statuses []StatusInfo) {
+	p.update(statuses, false)
+}
+
+func (p *changePrinter) Done(statuses []StatusInfo) {
+	p.update(statuses, true)
+	if p.done != nil {
+		p.done(statuses)
+	}
+}
+
+func (p *changePrinter) update(statuses []StatusInfo, final bool) {
+	now := time.Now()
+	for _, status := range statuses {
+		if last, ok := p.last[status.Ref]; ok &&
+			last.status.Status == status.Status && last.status.Total == status.Total &&
+			last.status.Mirror == status.Mirror {
+			if last.status.Offset == status.Offset || (!final && now.Sub(last.at) < p.interval) {
+				continue
+			}
+		}
+
+		p.last[status.Ref] = printedStatus{status: status, at: now}
+		p.print(status)
+	}
+}
+
+func plainEvent(out io.Writer) func(status StatusInfo) {
+	return func(status StatusInfo) {
+		switch {
+		case status.Total > 0:
+			fmt.Fprintf(out, "%s: %s %s/%s\n",
+				displayRef(status), status.Status, progress.Bytes(status.Offset), progress.Bytes(status.Total))
+		default:
+			fmt.Fprintf(out, "%s: %s\n", displayRef(status), status.Status)
+		}
+	}
+}
+
+func plainSummary(out io.Writer, start time.Time) func(statuses []StatusInfo) {
+	return func(statuses []StatusInfo) {
+		var total int64
+		for _, status := range statuses {
+			total += status.Offset
+		}
+
+		fmt.Fprintf(out, "elapsed: %.1fs total: %v (%v)\n",
+			time.Since(start).Seconds(),
+			progress.Bytes(total),
+			progress.NewBytesPerSecond(total, time.Since(start)))
+	}
+}
+
+// Event is the json representation of a status change.
+type Event struct {
+	Time      time.Time        `json:"time"`
+	Ref       string           `json:"ref"`
+	Mirror    string           `json:"mirror,omitempty"`
+	Digest    string           `json:"digest,omitempty"`
+	MediaType string           `json:"mediaType,omitempty"`
+	Status    StatusInfoStatus `json:"status"`
+	Offset    int64            `json:"offset"`
+	Total     int64            `json:"total"`
+	StartedAt *time.Time       `json:"startedAt,omitempty"`
+	UpdatedAt *time.Time       `json:"updatedAt,omitempty"`
+}
+
+func jsonEvent(enc *json.Encoder) func(status StatusInfo) {
+	return func(status StatusInfo) {
+		event := Event{
+			Time:      time.Now().UTC(),
+			Ref:       status.Ref,
+			Mirror:    status.Mirror,
+			Digest:    status.Digest.String(),
+			MediaType: status.MediaType,
+			Status:    status.Status,
+			Offset:    status.Offset,
+			Total:     status.Total,
+		}
+		if !status.StartedAt.IsZero() {
+			event.StartedAt = &status.StartedAt
+		}
+		if !status.UpdatedAt.IsZero() {
+			event.UpdatedAt = &status.UpdatedAt
+		}
+
+		_ = enc.Encode(event)
+	}
+}
@@ -0,0 +1,144 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package jobs_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+	"go.farcloser.world/lepton/pkg/imgutil/jobs"
+)
+
+func TestValidateProgress(t *testing.T) {
+	for _, mode := range append(jobs.ProgressModes, "") {
+		assert.NilError(t, jobs.ValidateProgress(mode))
+	}
+
+	assert.Assert(t, errors.Is(jobs.ValidateProgress("fancy"), errs.ErrInvalidArgument))
+}
+
+func TestPrinterPrintsStatusChanges(t *testing.T) {
+	updates := [][]jobs.StatusInfo{
+		{
+			{Ref: "layer-sha256:aaa", Digest: "sha256:aaa", MediaType: "tar", Status: jobs.StatusWaiting},
+		},
+		{
+			{Ref: "layer-sha256:aaa", Digest: "sha256:aaa", Status: jobs.StatusDownloading, Offset: 1, Total: 10},
+		},
+		{
+			{Ref: "layer-sha256:aaa", Digest: "sha256:aaa", Status: jobs.StatusDownloading, Offset: 5, Total: 10},
+		},
+	}
+	final := []jobs.StatusInfo{
+		{Ref: "layer-sha256:aaa", Digest: "sha256:aaa", Status: jobs.StatusDone, Offset: 10, Total: 10},
+	}
+
+	var out bytes.Buffer
+	printer := jobs.NewPrinter(&out, jobs.ProgressJSON)
+	for _, statuses := range updates {
+		printer.Update(statuses)
+	}
+	printer.Done(final)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, len(lines), 3)
+
+	var events []jobs.Event
+	for _, line := range lines {
+		var event jobs.Event
+		assert.NilError(t, json.Unmarshal([]byte(line), &event))
+		events = append(events, event)
+	}
+	assert.Equal(t, events[0].Status, jobs.StatusWaiting)
+	assert.Equal(t, events[0].MediaType, "tar")
+	assert.Equal(t, events[1].Status, jobs.StatusDownloading)
+	assert.Equal(t, events[1].Total, int64(10))
+	assert.Equal(t, events[2].Status, jobs.StatusDone)
+	assert.Equal(t, events[2].Digest, "sha256:aaa")
+	assert.Equal(t, events[2].Offset, int64(10))
+
+	out.Reset()
+	printer = jobs.NewPrinter(&out, jobs.ProgressPlain)
+	for _, statuses := range updates {
+		printer.Update(statuses)
+	}
+	printer.Done(final)
+
+	assert.Assert(t, !strings.Contains(out.String(), "\x1b"))
+	lines = strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, len(lines), 4)
+	assert.Equal(t, lines[0], "layer-sha256:aaa: waiting")
+	assert.Assert(t, strings.HasPrefix(lines[3], "elapsed: "))
+}
+
+func TestPrinterPrintsProgress(t *testing.T) {
+	downloading := func(offset, total int64) []jobs.StatusInfo {
+		return []jobs.StatusInfo{
+			{Ref: "layer-sha256:aaa", Status: jobs.StatusDownloading, Offset: offset, Total: total},
+		}
+	}
+
+	var out bytes.Buffer
+	printer := jobs.NewPrinter(&out, jobs.ProgressPlain)
+	printer.Update(downloading(1, 0))
+	// A new total is printed right away
+	printer.Update(downloading(1, 10))
+	// Progress alone is throttled
+	printer.Update(downloading(2, 10))
+	printer.Update(downloading(2, 10))
+	// The final progress is always printed
+	printer.Done(downloading(5, 10))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, len(lines), 4)
+	assert.Equal(t, lines[0], "layer-sha256:aaa: downloading")
+	assert.Equal(t, lines[1], "layer-sha256:aaa: downloading 1.0 B/10.0 B")
+	assert.Equal(t, lines[2], "layer-sha256:aaa: downloading 5.0 B/10.0 B")
+	assert.Assert(t, strings.HasPrefix(lines[3], "elapsed: "))
+}
+
+func TestPrinterReportsMirror(t *testing.T) {
+	resolving := []jobs.StatusInfo{{Ref: "docker.io/library/alpine:latest", Status: jobs.StatusResolving}}
+	resolved := []jobs.StatusInfo{
+		{Ref: "docker.io/library/alpine:latest", Mirror: "mirror.example.com", Status: jobs.StatusResolved},
+	}
+
+	var out bytes.Buffer
+	printer := jobs.NewPrinter(&out, jobs.ProgressJSON)
+	printer.Update(resolving)
+	printer.Done(resolved)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, len(lines), 2)
+	var event jobs.Event
+	assert.NilError(t, json.Unmarshal([]byte(lines[1]), &event))
+	assert.Equal(t, event.Ref, "docker.io/library/alpine:latest")
+	assert.Equal(t, event.Mirror, "mirror.example.com")
+
+	out.Reset()
+	printer = jobs.NewPrinter(&out, jobs.ProgressPlain)
+	printer.Done(resolved)
+
+	lines = strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, lines[0], "docker.io/library/alpine:latest (via mirror mirror.example.com): resolved")
+}
@@ -23,6 +23,7 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	containerd "github.com/containerd/containerd/v2/client"
 	"github.com/containerd/containerd/v2/core/images"
@@ -32,6 +33,7 @@ import (
 
 	"go.farcloser.world/lepton/pkg/api/options"
 	"go.farcloser.world/lepton/pkg/imgutil"
+	"go.farcloser.world/lepton/pkg/imgutil/jobs"
 	"go.farcloser.world/lepton/pkg/platformutil"
 )
 
@@ -66,9 +68,13 @@ func FromArchive(ctx context.Context, client *containerd.Client, options options
 	if err != nil {
 		return nil, err
 	}
+	var printer jobs.Printer
+	if options.Progress == jobs.ProgressJSON && !options.Quiet {
+		printer = jobs.NewPrinter(options.Stdout, options.Progress)
+	}
 	unpackedImages := make([]images.Image, 0, len(imgs))
 	for _, img := range imgs {
-		err := unpackImage(ctx, client, img, platMC, options)
+		err := unpackImage(ctx, client, img, platMC, options, printer)
 		if err != nil {
 			return unpackedImages, fmt.Errorf("error unpacking image (%s): %w", img.Name, err)
 		}
@@ -149,10 +155,21 @@ func unpackImage(
 	model images.Image,
 	platform platforms.MatchComparer,
 	options options.ImageLoad,
+	printer jobs.Printer,
 ) error {
 	image := containerd.NewImageWithPlatform(client, model, platform)
 
-	if !options.Quiet {
+	// json progress replaces the human-oriented messages with events
+	status := jobs.StatusInfo{
+		Ref:       model.Name,
+		Digest:    model.Target.Digest,
+		MediaType: model.Target.MediaType,
+		Status:    jobs.StatusUnpacking,
+		StartedAt: time.Now(),
+	}
+	if printer != nil {
+		printer.Update([]jobs.StatusInfo{status})
+	} else if !options.Quiet {
 		fmt.Fprintf(options.Stdout, "unpacking %s (%s)...\n", model.Name, model.Target.Digest)
 	}
 
@@ -161,6 +178,13 @@ func unpackImage(
 		return err
 	}
 
+	if printer != nil {
+		status.Status = jobs.StatusDone
+		status.UpdatedAt = time.Now()
+		printer.Done([]jobs.StatusInfo{status})
+		return nil
+	}
+
 	// Loaded message is shown even when quiet.
 	repo, tag := imgutil.ParseRepoTag(model.Name)
 	fmt.Fprintf(options.Stdout, "Loaded image: %s:%s\n", repo, tag)
@@ -0,0 +1,107 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package imgutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	containerd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/docker/go-units"
+
+	"go.farcloser.world/lepton/leptonic/services/namespace"
+	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/config"
+	"go.farcloser.world/lepton/pkg/imgutil/dockerconfigresolver"
+)
+
+// RegistryMirrorOpts returns the resolver options implementing the registry mirrors defaults of the namespace,
+// followed by the registry mirrors of the cli configuration.
+func RegistryMirrorOpts(
+	ctx context.Context,
+	client *containerd.Client,
+	globalOptions *options.Global,
+) ([]dockerconfigresolver.Opt, error) {
+	defaults, err := namespace.GetDefaults(ctx, client, globalOptions.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	mirrors := map[string][]string{}
+	for registry, registryMirrors := range defaults.RegistryMirrors {
+		mirrors[registry] = slices.Clone(registryMirrors)
+	}
+
+	for registry, registryMirrors := range config.RegistryMirrors(globalOptions.Registry) {
+		for _, mirror := range registryMirrors {
+			if !slices.Contains(mirrors[registry], mirror) {
+				mirrors[registry] = append(mirrors[registry], mirror)
+			}
+		}
+	}
+
+	if len(mirrors) == 0 {
+		return nil, nil
+	}
+
+	return []dockerconfigresolver.Opt{dockerconfigresolver.WithMirrors(mirrors)}, nil
+}
+
+// CheckContentQuota returns an error if the content store of a namespace has reached its quota, or would exceed it
+// with `incoming` more bytes. The size of an image is not known before its manifests are fetched: pulls pass 0, and
+// are only refused once the quota is reached.
+func CheckContentQuota(ctx context.Context, client *containerd.Client, ns string, incoming int64) error {
+	quotas, err := namespace.GetQuotas(ctx, client, ns)
+	if err != nil {
+		return err
+	}
+
+	if quotas.Content == 0 {
+		return nil
+	}
+
+	var used int64
+	err = client.ContentStore().Walk(ctx, func(info content.Info) error {
+		used += info.Size
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return checkContentQuota(ns, quotas.Content, used, incoming)
+}
+
+func checkContentQuota(ns string, quota, used, incoming int64) error {
+	if quota == 0 || (used < quota && used+incoming <= quota) {
+		return nil
+	}
+
+	needed := ""
+	if incoming > 0 {
+		needed = fmt.Sprintf(" and needs %s more", units.BytesSize(float64(incoming)))
+	}
+
+	return errors.Join(namespace.ErrQuotaExceeded, fmt.Errorf(
+		"namespace %q has a content quota of %s (%s) and already uses %s%s, remove unused images first",
+		ns, units.BytesSize(float64(quota)), namespace.LabelQuotaContent, units.BytesSize(float64(used)), needed,
+	))
+}
@@ -0,0 +1,59 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package imgutil
+
+import (
+	"errors"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/lepton/leptonic/services/namespace"
+)
+
+func TestCheckContentQuota(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		quota    int64
+		used     int64
+		incoming int64
+		exceeded bool
+	}{
+		{name: "unlimited", quota: 0, used: 1 << 40, incoming: 1 << 40},
+		{name: "below quota, unknown size", quota: 1 << 30, used: 512 << 20},
+		{name: "quota reached, unknown size", quota: 1 << 30, used: 1 << 30, exceeded: true},
+		{name: "fits", quota: 1 << 30, used: 512 << 20, incoming: 256 << 20},
+		{name: "fills the quota", quota: 1 << 30, used: 512 << 20, incoming: 512 << 20},
+		{name: "exceeds the quota", quota: 1 << 30, used: 512 << 20, incoming: 513 << 20, exceeded: true},
+		{name: "quota lowered below usage", quota: 1 << 30, used: 2 << 30, incoming: 1, exceeded: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := checkContentQuota("test", tc.quota, tc.used, tc.incoming)
+			if tc.exceeded {
+				assert.Assert(t, errors.Is(err, namespace.ErrQuotaExceeded), "got %v", err)
+				return
+			}
+			assert.NilError(t, err)
+		})
+	}
+}
@@ -28,6 +28,7 @@ import (
 
 	"go.farcloser.world/containers/specs"
 
+	"go.farcloser.world/lepton/pkg/imgutil/dockerconfigresolver"
 	"go.farcloser.world/lepton/pkg/imgutil/jobs"
 	"go.farcloser.world/lepton/pkg/platformutil"
 )
@@ -38,6 +39,8 @@ type Config struct {
 	Resolver remotes.Resolver
 	// ProgressOutput to display progress
 	ProgressOutput io.Writer
+	// Progress output mode, one of jobs.ProgressModes
+	Progress string
 	// RemoteOpts, e.g. containerd.WithPullUnpack.
 	//
 	// Regardless to RemoteOpts, the following opts are always set:
@@ -52,13 +55,17 @@ type Config struct {
 func Pull(ctx context.Context, client *containerd.Client, ref string, config *Config) (containerd.Image, error) {
 	ongoing := jobs.New(ref)
 
+	ctx = dockerconfigresolver.WithMirrorReporter(ctx, func(_, mirror string) {
+		ongoing.SetMirror(mirror)
+	})
 	pctx, stopProgress := context.WithCancel(ctx)
 	progress := make(chan struct{})
 
 	go func() {
 		if config.ProgressOutput != nil {
 			// no progress bar, because it hides some debug logs
-			jobs.ShowProgress(pctx, ongoing, client.ContentStore(), config.ProgressOutput)
+			printer := jobs.NewPrinter(config.ProgressOutput, config.Progress)
+			jobs.ShowProgress(pctx, ongoing, client.ContentStore(), printer)
 		}
 		close(progress)
 	}()
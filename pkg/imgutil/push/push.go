@@ -22,14 +22,12 @@ import (
 	"fmt"
 	"io"
 	"sync"
-	"text/tabwriter"
 	"time"
 
 	containerd "github.com/containerd/containerd/v2/client"
 	"github.com/containerd/containerd/v2/core/images"
 	"github.com/containerd/containerd/v2/core/remotes"
 	"github.com/containerd/containerd/v2/core/remotes/docker"
-	"github.com/containerd/containerd/v2/pkg/progress"
 	"github.com/containerd/log"
 	"github.com/containerd/platforms"
 	"golang.org/x/sync/errgroup"
@@ -49,6 +47,7 @@ func Push(
 	localRef, remoteRef string,
 	platform platforms.MatchComparer,
 	allowNonDist, quiet bool,
+	progress string,
 ) error {
 	img, err := client.ImageService().Get(ctx, localRef)
 	if err != nil {
@@ -70,7 +69,7 @@ func Push(
 
 		jobHandler := images.HandlerFunc(func(ctx context.Context, desc specs.Descriptor) ([]specs.Descriptor, error) {
 			if allowNonDist || !images.IsNonDistributable(desc.MediaType) {
-				ongoing.add(remotes.MakeRefKey(ctx, desc))
+				ongoing.add(remotes.MakeRefKey(ctx, desc), desc)
 			}
 			return nil, nil
 		})
@@ -89,10 +88,9 @@ func Push(
 	if !quiet {
 		eg.Go(func() error {
 			var (
-				ticker = time.NewTicker(100 * time.Millisecond)
-				fw     = progress.NewWriter(stdout)
-				start  = time.Now()
-				done   bool
+				ticker  = time.NewTicker(100 * time.Millisecond)
+				printer = jobs.NewPrinter(stdout, progress)
+				done    bool
 			)
 
 			defer ticker.Stop()
@@ -100,17 +98,11 @@ func Push(
 			for {
 				select {
 				case <-ticker.C:
-					fw.Flush()
-
-					tw := tabwriter.NewWriter(fw, 1, 8, 1, ' ', 0)
-
-					jobs.Display(tw, ongoing.status(), start)
-					tw.Flush()
-
 					if done {
-						fw.Flush()
+						printer.Done(ongoing.status())
 						return nil
 					}
+					printer.Update(ongoing.status())
 				case <-doneCh:
 					done = true
 				case <-ctx.Done():
@@ -123,7 +115,7 @@ func Push(
 }
 
 type pushjobs struct {
-	jobs    map[string]struct{}
+	jobs    map[string]specs.Descriptor
 	ordered []string
 	tracker docker.StatusTracker
 	mu      sync.Mutex
@@ -131,12 +123,12 @@ type pushjobs struct {
 
 func newPushJobs(tracker docker.StatusTracker) *pushjobs {
 	return &pushjobs{
-		jobs:    make(map[string]struct{}),
+		jobs:    make(map[string]specs.Descriptor),
 		tracker: tracker,
 	}
 }
 
-func (j *pushjobs) add(ref string) {
+func (j *pushjobs) add(ref string, desc specs.Descriptor) {
 	j.mu.Lock()
 	defer j.mu.Unlock()
 
@@ -144,7 +136,7 @@ func (j *pushjobs) add(ref string) {
 		return
 	}
 	j.ordered = append(j.ordered, ref)
-	j.jobs[ref] = struct{}{}
+	j.jobs[ref] = desc
 }
 
 func (j *pushjobs) status() []jobs.StatusInfo {
@@ -154,7 +146,9 @@ func (j *pushjobs) status() []jobs.StatusInfo {
 	statuses := make([]jobs.StatusInfo, 0, len(j.jobs))
 	for _, name := range j.ordered {
 		si := jobs.StatusInfo{
-			Ref: name,
+			Ref:       name,
+			Digest:    j.jobs[name].Digest,
+			MediaType: j.jobs[name].MediaType,
 		}
 
 		status, err := j.tracker.GetStatus(name)
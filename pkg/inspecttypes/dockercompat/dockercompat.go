@@ -31,6 +31,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -39,11 +40,13 @@ import (
 	"github.com/containerd/containerd/v2/core/runtime/restart"
 	"github.com/containerd/go-cni"
 	"github.com/containerd/log"
+	types100 "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/docker/go-connections/nat"
 
 	"go.farcloser.world/containers/specs"
 	"go.farcloser.world/core/units"
 
+	"go.farcloser.world/lepton/pkg/healthcheck"
 	"go.farcloser.world/lepton/pkg/imgutil"
 	"go.farcloser.world/lepton/pkg/inspecttypes/native"
 	"go.farcloser.world/lepton/pkg/ipcutil"
@@ -102,6 +105,42 @@ type LoggerLogConfig struct {
 	Address string            `json:"address"`
 }
 
+// MarshalJSON renders the log configuration the way Docker does, as {"Type": ..., "Config": {...}}.
+func (lc LoggerLogConfig) MarshalJSON() ([]byte, error) {
+	config := lc.Opts
+	if config == nil {
+		config = map[string]string{}
+	}
+	return json.Marshal(struct {
+		Type    string
+		Config  map[string]string
+		Address string `json:",omitempty"`
+	}{
+		Type:    lc.Driver,
+		Config:  config,
+		Address: lc.Address,
+	})
+}
+
+// UnmarshalJSON accepts both the Docker form, and the form stored in the log-config label.
+func (lc *LoggerLogConfig) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Type    string
+		Config  map[string]string
+		Driver  string
+		Opts    map[string]string
+		Address string
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	lc.Driver, lc.Opts, lc.Address = raw.Driver, raw.Opts, raw.Address
+	if lc.Driver == "" {
+		lc.Driver, lc.Opts = raw.Type, raw.Config
+	}
+	return nil
+}
+
 // Container mimics a `docker container inspect` object.
 // From https://github.com/moby/moby/blob/v20.10.1/api/types/types.go#L340-L374
 type Container struct {
@@ -117,18 +156,18 @@ type Container struct {
 	LogPath        string
 	// Unimplemented: Node            *ContainerNode `json:",omitempty"` // Node is only propagated by Docker Swarm
 	// standalone API
-	Name         string
-	RestartCount int
-	Driver       string
-	Platform     string
-	// TODO: MountLabel      string
-	// TODO: ProcessLabel    string
+	Name            string
+	RestartCount    int
+	Driver          string
+	Platform        string
+	MountLabel      string
+	ProcessLabel    string
 	AppArmorProfile string
-	// TODO: ExecIDs         []string
-	HostConfig *HostConfig
-	// TODO: GraphDriver     GraphDriverData
-	SizeRw     *int64 `json:",omitempty"`
-	SizeRootFs *int64 `json:",omitempty"`
+	ExecIDs         []string
+	HostConfig      *HostConfig
+	GraphDriver     GraphDriverData
+	SizeRw          *int64 `json:",omitempty"`
+	SizeRootFs      *int64 `json:",omitempty"`
 
 	Mounts          []MountPoint
 	Config          *Config
@@ -139,17 +178,18 @@ type Container struct {
 // https://github.com/moby/moby/blob/8dbd90ec00daa26dc45d7da2431c965dec99e8b4/api/types/container/host_config.go#L391
 // HostConfig the non-portable Config structure of a container.
 type HostConfig struct {
-	// Binds           []string      // List of volume bindings for this container
+	Binds           []string        // List of volume bindings for this container
 	ContainerIDFile string          // File (path) where the containerId is written
 	LogConfig       LoggerLogConfig // Configuration of the logs for this container
-	// NetworkMode     // Network mode to use for the container
-	PortBindings nat.PortMap // Port mapping between the exposed port (container) and the host
-	// RestartPolicy   // Restart policy to be used for the container
-	// AutoRemove      bool          // Automatically remove container when it exits
-	// VolumeDriver    string        // Name of the volume driver used to mount volumes
-	// VolumesFrom     []string      // List of volumes to take from other container
-	// CapAdd          strslice.StrSlice // List of kernel capabilities to add to the container
-	// CapDrop         strslice.StrSlice // List of kernel capabilities to remove from the container
+	NetworkMode     string          // Network mode to use for the container
+	PortBindings    nat.PortMap     // Port mapping between the exposed port (container) and the host
+	RestartPolicy   RestartPolicy   // Restart policy to be used for the container
+	AutoRemove      bool            // Automatically remove container when it exits
+	VolumeDriver    string          // Name of the volume driver used to mount volumes
+	VolumesFrom     []string        // List of volumes to take from other container
+	ConsoleSize     [2]uint         // Initial console size (height,width)
+	CapAdd          []string        // List of kernel capabilities to add to the container
+	CapDrop         []string        // List of kernel capabilities to remove from the container
 
 	CgroupnsMode string   // Cgroup namespace mode to use for the container
 	DNS          []string `json:"Dns"`        // List of DNS server to lookup
@@ -158,29 +198,72 @@ type HostConfig struct {
 	ExtraHosts   []string // List of extra hosts
 	GroupAdd     []string // GroupAdd specifies additional groups to join
 	IpcMode      string   `json:"IpcMode"` // IPC namespace to use for the container
-	// Cgroup          CgroupSpec        // Cgroup to use for the container
+	// Unimplemented: Cgroup          CgroupSpec        // Cgroup to use for the container
+	// Unimplemented: Links           []string          // List of links (in the name:alias form)
 	OomScoreAdj int    // specifies the tune container’s OOM preferences (-1000 to 1000, rootless: 100 to 1000)
 	PidMode     string // PID namespace to use for the container
-	// Privileged      bool              // Is the container in privileged mode
-	// PublishAllPorts bool              // Should docker publish all exposed port for the container
-	ReadonlyRootfs bool // Is the container root filesystem in read-only
-	// SecurityOpt     []string          // List of string values to customize labels for MLS systems, such as SELinux.
-	Tmpfs   map[string]string `json:"Tmpfs,omitempty"` // List of tmpfs (mounts) used for the container
-	UTSMode string            // UTS namespace to use for the container
-	// UsernsMode      // The user namespace to use for the container
+	Privileged  bool   // Is the container in privileged mode
+	// Unimplemented: PublishAllPorts bool              // Should docker publish all exposed port for the container
+	ReadonlyRootfs bool              // Is the container root filesystem in read-only
+	SecurityOpt    []string          // List of string values to customize labels for MLS systems, such as SELinux.
+	Tmpfs          map[string]string `json:"Tmpfs,omitempty"` // List of tmpfs (mounts) used for the container
+	UTSMode        string            // UTS namespace to use for the container
+	// Unimplemented: UsernsMode      // The user namespace to use for the container
 	ShmSize int64             // Size of /dev/shm in bytes. The size must be greater than 0.
-	Sysctls map[string]string // List of Namespaced sysctls used for the container
+	Sysctls map[string]string `json:",omitempty"` // List of Namespaced sysctls used for the container
 	Runtime string            // Runtime to use with this container
 
-	BlkioWeight    uint16          // Block IO weight (relative weight vs. other containers)
-	CPUSetMems     string          `json:"CpusetMems"` // CpusetMems 0-2, 0,1
-	CPUSetCPUs     string          `json:"CpusetCpus"` // CpusetCpus 0-2, 0,1
-	CPUQuota       int64           `json:"CpuQuota"`   // CPU CFS (Completely Fair Scheduler) quota
-	CPUShares      uint64          `json:"CpuShares"`  // CPU shares (relative weight vs. other containers)
-	Memory         int64           // Memory limit (in bytes)
-	MemorySwap     int64           // Total memory usage (memory + swap); set `-1` to enable unlimited swap
-	OomKillDisable bool            // specifies whether to disable OOM Killer
-	Devices        []DeviceMapping // List of devices to map inside the container
+	BlkioWeight          uint16            // Block IO weight (relative weight vs. other containers)
+	BlkioWeightDevice    []*WeightDevice   // Block IO weight (relative device weight)
+	BlkioDeviceReadBps   []*ThrottleDevice // Limit read rate (bytes per second) from a device
+	BlkioDeviceWriteBps  []*ThrottleDevice // Limit write rate (bytes per second) to a device
+	BlkioDeviceReadIOps  []*ThrottleDevice // Limit read rate (IO per second) from a device
+	BlkioDeviceWriteIOps []*ThrottleDevice // Limit write rate (IO per second) to a device
+	CgroupParent         string            // Parent cgroup.
+	CPUPeriod            int64             `json:"CpuPeriod"`          // CPU CFS (Completely Fair Scheduler) period
+	CPUQuota             int64             `json:"CpuQuota"`           // CPU CFS (Completely Fair Scheduler) quota
+	CPURealtimePeriod    int64             `json:"CpuRealtimePeriod"`  // CPU real-time period
+	CPURealtimeRuntime   int64             `json:"CpuRealtimeRuntime"` // CPU real-time runtime
+	CPUSetCPUs           string            `json:"CpusetCpus"`         // CpusetCpus 0-2, 0,1
+	CPUSetMems           string            `json:"CpusetMems"`         // CpusetMems 0-2, 0,1
+	CPUShares            uint64            `json:"CpuShares"`          // CPU shares (relative weight)
+	Devices              []DeviceMapping   // List of devices to map inside the container
+	Memory               int64             // Memory limit (in bytes)
+	MemoryReservation    int64             // Memory soft limit (in bytes)
+	MemorySwap           int64             // Total memory usage (memory + swap); set `-1` to enable unlimited swap
+	MemorySwappiness     *int64            // Tuning container memory swappiness behaviour
+	OomKillDisable       bool              // specifies whether to disable OOM Killer
+	PidsLimit            *int64            // Setting PIDs limit for a container
+	Ulimits              []*Ulimit         // List of ulimits to be set in the container
+	Init                 *bool             `json:",omitempty"` // Run a custom init inside the container
+
+	MaskedPaths   []string // List of paths to be masked inside the container (this overrides the default set of paths)
+	ReadonlyPaths []string // List of paths to be set as read-only inside the container
+}
+
+// RestartPolicy is from https://github.com/moby/moby/blob/v26.1.2/api/types/container/hostconfig.go#L272-L276
+type RestartPolicy struct {
+	Name              string
+	MaximumRetryCount int
+}
+
+// WeightDevice is from https://github.com/moby/moby/blob/v26.1.2/api/types/blkiodev/blkio.go
+type WeightDevice struct {
+	Path   string
+	Weight uint16
+}
+
+// ThrottleDevice is from https://github.com/moby/moby/blob/v26.1.2/api/types/blkiodev/blkio.go
+type ThrottleDevice struct {
+	Path string
+	Rate uint64
+}
+
+// Ulimit is from https://github.com/docker/go-units/blob/v0.5.0/ulimit.go
+type Ulimit struct {
+	Name string
+	Hard int64
+	Soft int64
 }
 
 // MountPoint is from https://github.com/moby/moby/blob/v20.10.1/api/types/types.go#L416-L427
@@ -200,32 +283,42 @@ type MountPoint struct {
 // Config is from
 // https://github.com/moby/moby/blob/8dbd90ec00daa26dc45d7da2431c965dec99e8b4/api/types/container/config.go#L37-L69
 type Config struct {
-	Hostname    string `json:",omitempty"` // Hostname
-	Domainname  string `json:",omitempty"` // Domainname
-	User        string `json:",omitempty"` // User that will run the command(s) inside the container, also support user:group
-	AttachStdin bool   // Attach the standard input, makes possible user interaction
-	// TODO: AttachStdout bool        // Attach the standard output
-	// TODO: AttachStderr bool        // Attach the standard error
-	ExposedPorts nat.PortSet `json:",omitempty"` // List of exposed ports
-	// TODO: Tty          bool        // Attach standard streams to a tty, including stdin if it is not closed.
-	// TODO: OpenStdin    bool        // Open stdin
-	// TODO: StdinOnce    bool        // If true, close stdin after the 1 attached client disconnects.
-	Env []string `json:",omitempty"` // List of environment variable to set in the container
-	Cmd []string `json:",omitempty"` // Command to run when starting the container
-	// TODO Healthcheck     *HealthConfig       `json:",omitempty"` // Healthcheck describes how to check the container
-	// is healthy TODO: ArgsEscaped     bool                `json:",omitempty"` // True if command is already escaped
-	// (meaning treat as a command line) (Windows specific). TODO: Image           string              // Name of the
-	// image as it was passed by the operator (e.g. could be symbolic)
-	Volumes    map[string]struct{} `json:",omitempty"` // List of volumes (mounts) used for the container
-	WorkingDir string              `json:",omitempty"` // Current directory (PWD) in the command will be launched
-	Entrypoint []string            `json:",omitempty"` // Entrypoint to run when starting the container
-	// TODO: NetworkDisabled bool                `json:",omitempty"` // Is network disabled
-	// TODO: MacAddress      string              `json:",omitempty"` // Mac Address of the container
-	// TODO: OnBuild         []string            // ONBUILD metadata that were defined on the image Dockerfile
-	Labels map[string]string `json:",omitempty"` // List of labels set to this container
-	// TODO: StopSignal      string              `json:",omitempty"` // Signal to stop a container
-	// TODO: StopTimeout     *int                `json:",omitempty"` // Timeout (in seconds) to stop a container
-	// TODO: Shell           []string            `json:",omitempty"` // Shell for shell-form of RUN, CMD, ENTRYPOINT
+	Hostname     string        // Hostname
+	Domainname   string        // Domainname
+	User         string        // User that will run the command(s) inside the container, also support user:group
+	AttachStdin  bool          // Attach the standard input, makes possible user interaction
+	AttachStdout bool          // Attach the standard output
+	AttachStderr bool          // Attach the standard error
+	ExposedPorts nat.PortSet   `json:",omitempty"` // List of exposed ports
+	Tty          bool          // Attach standard streams to a tty, including stdin if it is not closed.
+	OpenStdin    bool          // Open stdin
+	StdinOnce    bool          // If true, close stdin after the 1 attached client disconnects.
+	Env          []string      // List of environment variable to set in the container
+	Cmd          []string      // Command to run when starting the container
+	Healthcheck  *HealthConfig `json:",omitempty"` // Healthcheck describes how to check the container is healthy
+	// Unimplemented: ArgsEscaped     bool                `json:",omitempty"` // (Windows specific)
+	Image      string              // Name of the image as it was passed by the operator
+	Volumes    map[string]struct{} // List of volumes (mounts) used for the container
+	WorkingDir string              // Current directory (PWD) in the command will be launched
+	Entrypoint []string            // Entrypoint to run when starting the container
+	// Unimplemented: NetworkDisabled bool                `json:",omitempty"` // Is network disabled
+	MacAddress  string            `json:",omitempty"` // Mac Address of the container
+	OnBuild     []string          // ONBUILD metadata that were defined on the image Dockerfile
+	Labels      map[string]string // List of labels set to this container
+	StopSignal  string            `json:",omitempty"` // Signal to stop a container
+	StopTimeout *int              `json:",omitempty"` // Timeout (in seconds) to stop a container
+	// Unimplemented: Shell           []string            `json:",omitempty"` // Shell for shell-form of RUN
+}
+
+// HealthConfig is from https://github.com/moby/moby/blob/v26.1.2/api/types/container/config.go#L44-L61
+// Healthchecks are read from the image configuration, and run by `container healthcheck`.
+type HealthConfig struct {
+	Test          []string      `json:",omitempty"`
+	Interval      time.Duration `json:",omitempty"`
+	Timeout       time.Duration `json:",omitempty"`
+	StartPeriod   time.Duration `json:",omitempty"`
+	StartInterval time.Duration `json:",omitempty"`
+	Retries       int           `json:",omitempty"`
 }
 
 // ContainerState is from https://github.com/moby/moby/blob/v20.10.1/api/types/types.go#L313-L326
@@ -234,18 +327,42 @@ type ContainerState struct {
 	Running    bool
 	Paused     bool
 	Restarting bool
-	// TODO: OOMKilled  bool
-	// TODO:	Dead       bool
+	OOMKilled  bool
+	Dead       bool
 	Pid        int
 	ExitCode   int
 	Error      string
 	StartedAt  string
 	FinishedAt string
-	// TODO: Health     *Health `json:",omitempty"`
+	Health     *Health `json:",omitempty"`
+}
+
+// Health is from https://github.com/moby/moby/blob/v26.1.2/api/types/container/health.go#L16-L21
+type Health struct {
+	Status        string               // Status is one of Starting, Healthy or Unhealthy
+	FailingStreak int                  // FailingStreak is the number of consecutive failures
+	Log           []*HealthcheckResult // Log contains the last few results (oldest first)
+}
+
+// HealthcheckResult is from https://github.com/moby/moby/blob/v26.1.2/api/types/container/health.go#L23-L29
+type HealthcheckResult struct {
+	Start    time.Time // Start is the time this check started
+	End      time.Time // End is the time this check ended
+	ExitCode int       // ExitCode is 0 when healthy, 1 when unhealthy, and -1 when the check could not run
+	Output   string    // Output from last check
 }
 
 type NetworkSettings struct {
-	Ports *nat.PortMap
+	Bridge     string // Bridge is the Bridge name the network uses(e.g. `docker0`)
+	SandboxID  string // SandboxID uniquely represents a container's network stack
+	SandboxKey string // SandboxKey identifies the sandbox
+	Ports      *nat.PortMap
+	// Deprecated fields, kept as Docker still reports them
+	HairpinMode            bool
+	LinkLocalIPv6Address   string
+	LinkLocalIPv6PrefixLen int
+	SecondaryIPAddresses   []string
+	SecondaryIPv6Addresses []string
 	DefaultNetworkSettings
 	Networks map[string]*NetworkEndpointSettings
 }
@@ -257,9 +374,17 @@ type DNSSettings struct {
 }
 
 type HostConfigLabel struct {
-	BlkioWeight uint16
-	CidFile     string
-	Devices     []DeviceMapping
+	BlkioWeight  uint16
+	CidFile      string
+	Devices      []DeviceMapping
+	Binds        []string `json:",omitempty"`
+	VolumesFrom  []string `json:",omitempty"`
+	CapAdd       []string `json:",omitempty"`
+	CapDrop      []string `json:",omitempty"`
+	Privileged   bool     `json:",omitempty"`
+	SecurityOpt  []string `json:",omitempty"`
+	CgroupParent string   `json:",omitempty"`
+	Init         *bool    `json:",omitempty"`
 }
 
 type DeviceMapping struct {
@@ -269,41 +394,60 @@ type DeviceMapping struct {
 }
 
 type cpuSettings struct {
-	cpuSetCpus string
-	cpuSetMems string
-	cpuShares  uint64
-	cpuQuota   int64
+	cpuSetCpus         string
+	cpuSetMems         string
+	cpuShares          uint64
+	cpuQuota           int64
+	cpuPeriod          int64
+	cpuRealtimePeriod  int64
+	cpuRealtimeRuntime int64
+}
+
+type blkioSettings struct {
+	weightDevice    []*WeightDevice
+	deviceReadBps   []*ThrottleDevice
+	deviceWriteBps  []*ThrottleDevice
+	deviceReadIOps  []*ThrottleDevice
+	deviceWriteIOps []*ThrottleDevice
 }
 
 // DefaultNetworkSettings is from https://github.com/moby/moby/blob/v20.10.1/api/types/types.go#L405-L414
 type DefaultNetworkSettings struct {
-	// TODO EndpointID          string // EndpointID uniquely represents a service endpoint in a Sandbox
-	// TODO Gateway             string // Gateway holds the gateway address for the network
+	EndpointID          string // EndpointID uniquely represents a service endpoint in a Sandbox
+	Gateway             string // Gateway holds the gateway address for the network
 	GlobalIPv6Address   string // GlobalIPv6Address holds network's global IPv6 address
 	GlobalIPv6PrefixLen int    // GlobalIPv6PrefixLen represents mask length of network's global IPv6 address
 	IPAddress           string // IPAddress holds the IPv4 address for the network
 	IPPrefixLen         int    // IPPrefixLen represents mask length of network's IPv4 address
-	// TODO IPv6Gateway         string // IPv6Gateway holds gateway address specific for IPv6
-	MacAddress string // MacAddress holds the MAC address for the network
+	IPv6Gateway         string // IPv6Gateway holds gateway address specific for IPv6
+	MacAddress          string // MacAddress holds the MAC address for the network
 }
 
-// NetworkEndpointSettings is from https://github.com/moby/moby/blob/v20.10.1/api/types/network/network.go#L49-L65
+// NetworkEndpointSettings is from https://github.com/moby/moby/blob/v26.1.2/api/types/network/endpoint.go#L11-L37
 type NetworkEndpointSettings struct {
 	// Configurations
-	// TODO IPAMConfig *EndpointIPAMConfig
-	// TODO Links      []string
-	// TODO Aliases    []string
+	IPAMConfig *EndpointIPAMConfig
+	Links      []string
+	Aliases    []string
+	MacAddress string
+	DriverOpts map[string]string
 	// Operational data
-	// TODO NetworkID           string
-	// TODO EndpointID          string
-	// TODO Gateway             string
-	IPAddress   string
-	IPPrefixLen int
-	// TODO IPv6Gateway         string
+	NetworkID           string
+	EndpointID          string
+	Gateway             string
+	IPAddress           string
+	IPPrefixLen         int
+	IPv6Gateway         string
 	GlobalIPv6Address   string
 	GlobalIPv6PrefixLen int
-	MacAddress          string
-	// TODO DriverOpts          map[string]string
+	DNSNames            []string
+}
+
+// EndpointIPAMConfig is from https://github.com/moby/moby/blob/v26.1.2/api/types/network/endpoint.go#L58-L63
+type EndpointIPAMConfig struct {
+	IPv4Address  string   `json:",omitempty"`
+	IPv6Address  string   `json:",omitempty"`
+	LinkLocalIPs []string `json:",omitempty"`
 }
 
 // ContainerFromNative instantiates a Docker-compatible Container from containerd-native Container.
@@ -315,6 +459,9 @@ func ContainerFromNative(n *native.Container) (*Container, error) {
 		Image:   n.Image,
 		Name:    n.Labels[labels.Name],
 		Driver:  n.Snapshotter,
+		GraphDriver: GraphDriverData{
+			Name: n.Snapshotter,
+		},
 		// XXX is this always right? what if the container OS is NOT the same as the host OS?
 		Platform: runtime.GOOS, // for Docker compatibility, this Platform string does NOT contain arch like "/amd64"
 	}
@@ -333,6 +480,13 @@ func ContainerFromNative(n *native.Container) (*Container, error) {
 				}
 			}
 			c.AppArmorProfile = p.ApparmorProfile
+			c.ProcessLabel = p.SelinuxLabel
+			if p.ConsoleSize != nil {
+				c.HostConfig.ConsoleSize = [2]uint{p.ConsoleSize.Height, p.ConsoleSize.Width}
+			}
+		}
+		if sp.Linux != nil {
+			c.MountLabel = sp.Linux.MountLabel
 		}
 		c.Mounts = mountsFromNative(sp.Mounts)
 		for _, mount := range c.Mounts {
@@ -410,6 +564,41 @@ func ContainerFromNative(n *native.Container) (*Container, error) {
 
 	c.HostConfig.BlkioWeight = hostConfigLabel.BlkioWeight
 	c.HostConfig.ContainerIDFile = hostConfigLabel.CidFile
+	c.HostConfig.Binds = hostConfigLabel.Binds
+	c.HostConfig.VolumesFrom = hostConfigLabel.VolumesFrom
+	c.HostConfig.CapAdd = hostConfigLabel.CapAdd
+	c.HostConfig.CapDrop = hostConfigLabel.CapDrop
+	c.HostConfig.Privileged = hostConfigLabel.Privileged
+	c.HostConfig.SecurityOpt = hostConfigLabel.SecurityOpt
+	c.HostConfig.CgroupParent = hostConfigLabel.CgroupParent
+	c.HostConfig.Init = hostConfigLabel.Init
+
+	var networks []string
+	if networksJSON := n.Labels[labels.Networks]; networksJSON != "" {
+		if err := json.Unmarshal([]byte(networksJSON), &networks); err != nil {
+			return nil, fmt.Errorf("failed to parse networks: %w", err)
+		}
+		if len(networks) > 0 {
+			c.HostConfig.NetworkMode = networks[0]
+		}
+	}
+
+	c.HostConfig.PortBindings, err = getPortBindingsFromNative(n.Spec.(*specs.Spec))
+	if err != nil {
+		return nil, err
+	}
+
+	c.HostConfig.RestartPolicy, err = getRestartPolicyFromNative(n.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	if autoRemove := n.Labels[labels.ContainerAutoRemove]; autoRemove != "" {
+		c.HostConfig.AutoRemove, err = strconv.ParseBool(autoRemove)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse auto-remove: %w", err)
+		}
+	}
 
 	groupAdd := groupAddFromNative(n.Spec.(*specs.Spec))
 
@@ -431,11 +620,16 @@ func ContainerFromNative(n *native.Container) (*Container, error) {
 		}
 	}
 
+	var (
+		netNS      *native.NetNS
+		sandboxKey string
+	)
 	cs := new(ContainerState)
 	cs.Restarting = n.Labels[restart.StatusLabel] == string(containerd.Running)
 	cs.Error = n.Labels[labels.Error]
 	if n.Process != nil {
 		cs.Status = statusFromNative(n.Process.Status, n.Labels)
+		cs.Restarting = cs.Status == "restarting"
 		cs.Running = n.Process.Status.Status == containerd.Running
 		cs.Paused = n.Process.Status.Status == containerd.Paused
 		cs.Pid = n.Process.Pid
@@ -452,19 +646,36 @@ func ContainerFromNative(n *native.Container) (*Container, error) {
 		if !n.Process.Status.ExitTime.IsZero() {
 			cs.FinishedAt = n.Process.Status.ExitTime.Format(time.RFC3339Nano)
 		}
-		nSettings, err := networkSettingsFromNative(n.Process.NetNS, n.Spec.(*specs.Spec))
-		if err != nil {
-			return nil, err
+		netNS = n.Process.NetNS
+		if n.Process.Pid > 0 && c.HostConfig.NetworkMode != "host" {
+			sandboxKey = fmt.Sprintf("/proc/%d/ns/net", n.Process.Pid)
+		}
+	}
+
+	nSettings, err := networkSettingsFromNative(netNS, n.Spec.(*specs.Spec))
+	if err != nil {
+		return nil, err
+	}
+	nSettings.SandboxKey = sandboxKey
+	if len(nSettings.Networks) == 0 {
+		// Without interfaces to inspect (e.g., the container is not running), report the networks as configured
+		aliases := networkAliases(n.ID, n.Labels)
+		for _, netName := range networks {
+			if !strings.HasPrefix(netName, "container:") {
+				nSettings.Networks[netName] = &NetworkEndpointSettings{Aliases: aliases[netName]}
+			}
 		}
-		c.NetworkSettings = nSettings
-		c.HostConfig.PortBindings = *nSettings.Ports
 	}
+	c.NetworkSettings = nSettings
 
 	cpuSetting := cpuSettingsFromNative(n.Spec.(*specs.Spec))
 	c.HostConfig.CPUSetCPUs = cpuSetting.cpuSetCpus
 	c.HostConfig.CPUSetMems = cpuSetting.cpuSetMems
 	c.HostConfig.CPUQuota = cpuSetting.cpuQuota
 	c.HostConfig.CPUShares = cpuSetting.cpuShares
+	c.HostConfig.CPUPeriod = cpuSetting.cpuPeriod
+	c.HostConfig.CPURealtimePeriod = cpuSetting.cpuRealtimePeriod
+	c.HostConfig.CPURealtimeRuntime = cpuSetting.cpuRealtimeRuntime
 
 	cgroupNamespace := getCgroupnsFromNative(n.Spec.(*specs.Spec))
 	c.HostConfig.CgroupnsMode = cgroupNamespace
@@ -474,6 +685,18 @@ func ContainerFromNative(n *native.Container) (*Container, error) {
 	c.HostConfig.OomKillDisable = memorySettings.DisableOOMKiller
 	c.HostConfig.Memory = memorySettings.Limit
 	c.HostConfig.MemorySwap = memorySettings.Swap
+	c.HostConfig.MemoryReservation = memorySettings.Reservation
+	c.HostConfig.MemorySwappiness = memorySettings.Swappiness
+
+	c.HostConfig.PidsLimit = getPidsLimitFromNative(n.Spec.(*specs.Spec))
+	c.HostConfig.Ulimits = getUlimitsFromNative(n.Spec.(*specs.Spec))
+
+	blkioSetting := getBlkioSettingsFromNative(n.Spec.(*specs.Spec))
+	c.HostConfig.BlkioWeightDevice = blkioSetting.weightDevice
+	c.HostConfig.BlkioDeviceReadBps = blkioSetting.deviceReadBps
+	c.HostConfig.BlkioDeviceWriteBps = blkioSetting.deviceWriteBps
+	c.HostConfig.BlkioDeviceReadIOps = blkioSetting.deviceReadIOps
+	c.HostConfig.BlkioDeviceWriteIOps = blkioSetting.deviceWriteIOps
 
 	dnsSettings, err := getDNSFromNative(n.Labels)
 	if err != nil {
@@ -502,9 +725,35 @@ func ContainerFromNative(n *native.Container) (*Container, error) {
 		c.HostConfig.Runtime = n.Runtime.Name
 	}
 
+	if sp := n.Spec.(*specs.Spec); sp.Linux != nil {
+		c.HostConfig.MaskedPaths = sp.Linux.MaskedPaths
+		c.HostConfig.ReadonlyPaths = sp.Linux.ReadonlyPaths
+	}
+
 	c.State = cs
 	c.Config = &Config{
-		Labels: n.Labels,
+		Image:      n.Image,
+		Labels:     n.Labels,
+		MacAddress: n.Labels[labels.MACAddress],
+		StopSignal: n.Labels[containerd.StopSignalLabel],
+	}
+	if p := n.Spec.(*specs.Spec).Process; p != nil {
+		c.Config.Tty = p.Terminal
+		c.Config.User = getUserFromNative(p.User)
+		c.Config.Env = p.Env
+		c.Config.Cmd = p.Args
+		if c.HostConfig.Init != nil && *c.HostConfig.Init && len(p.Args) >= 2 && p.Args[1] == "--" {
+			// Leave out the init process, as run with "<init> -- <command>"
+			c.Config.Cmd = p.Args[2:]
+		}
+		c.Config.WorkingDir = p.Cwd
+	}
+	if stopTimeout := n.Labels[labels.StopTimeout]; stopTimeout != "" {
+		timeout, err := strconv.Atoi(stopTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse stop timeout: %w", err)
+		}
+		c.Config.StopTimeout = &timeout
 	}
 	if n.Labels[labels.Hostname] != "" {
 		hostname = n.Labels[labels.Hostname]
@@ -525,6 +774,180 @@ func ContainerFromNative(n *native.Container) (*Container, error) {
 	return c, nil
 }
 
+// ApplyImageConfig completes the Config of a container with what only the image configuration (as a raw JSON blob)
+// knows: the entrypoint, as split from the command, exposed ports, volumes, ONBUILD triggers and the healthcheck.
+func ApplyImageConfig(c *Container, imageConfig []byte) error {
+	var img struct {
+		Config struct {
+			Entrypoint   []string
+			ExposedPorts map[string]struct{}
+			Volumes      map[string]struct{}
+			OnBuild      []string
+			Healthcheck  *HealthConfig
+		} `json:"config"`
+	}
+	if err := json.Unmarshal(imageConfig, &img); err != nil {
+		return fmt.Errorf("failed to parse image config: %w", err)
+	}
+	if c.Config == nil {
+		c.Config = &Config{}
+	}
+
+	entrypoint := img.Config.Entrypoint
+	if len(entrypoint) > 0 && len(c.Config.Cmd) >= len(entrypoint) &&
+		slices.Equal(c.Config.Cmd[:len(entrypoint)], entrypoint) {
+		c.Config.Entrypoint = entrypoint
+		c.Config.Cmd = c.Config.Cmd[len(entrypoint):]
+	}
+
+	if len(img.Config.ExposedPorts) > 0 || (c.HostConfig != nil && len(c.HostConfig.PortBindings) > 0) {
+		c.Config.ExposedPorts = make(nat.PortSet)
+		for port := range img.Config.ExposedPorts {
+			c.Config.ExposedPorts[nat.Port(port)] = struct{}{}
+		}
+		if c.HostConfig != nil {
+			for port := range c.HostConfig.PortBindings {
+				c.Config.ExposedPorts[port] = struct{}{}
+			}
+		}
+	}
+
+	c.Config.Volumes = img.Config.Volumes
+	c.Config.OnBuild = img.Config.OnBuild
+	c.Config.Healthcheck = img.Config.Healthcheck
+	return nil
+}
+
+// ApplyHealth reports the health of a container, as last recorded by its healthcheck (nil if it never ran).
+// It must be called after ApplyImageConfig: like Docker, a running container whose image has a healthcheck is starting
+// until its first check.
+func ApplyHealth(c *Container, health *healthcheck.Health) {
+	if c.State == nil || c.Config == nil || c.Config.Healthcheck == nil ||
+		len(c.Config.Healthcheck.Test) == 0 || c.Config.Healthcheck.Test[0] == "NONE" {
+		return
+	}
+
+	if health == nil {
+		if c.State.Running || c.State.Paused {
+			c.State.Health = &Health{Status: healthcheck.Starting, Log: []*HealthcheckResult{}}
+		}
+		return
+	}
+
+	c.State.Health = &Health{
+		Status:        health.Status,
+		FailingStreak: health.FailingStreak,
+		Log:           make([]*HealthcheckResult, 0, len(health.Log)),
+	}
+	for _, result := range health.Log {
+		c.State.Health.Log = append(c.State.Health.Log, &HealthcheckResult{
+			Start:    result.Start,
+			End:      result.End,
+			ExitCode: result.ExitCode,
+			Output:   result.Output,
+		})
+	}
+}
+
+// networkAliases returns the network-scoped aliases of a container from its labels, by network name.
+func networkAliases(id string, containerLabels map[string]string) map[string][]string {
+	var aliases map[string][]string
+	if containerLabels[labels.NetworkAliases] == "" {
+		return aliases
+	}
+	if err := json.Unmarshal([]byte(containerLabels[labels.NetworkAliases]), &aliases); err != nil {
+		log.L.WithError(err).Warnf("failed to unmarshal the network aliases of container %q", id)
+	}
+	return aliases
+}
+
+// ApplyNetworkResults names the endpoints of a running container after the networks it was attached to, given the
+// CNI results of its setup, keyed by network name. Endpoints that cannot be matched with a CNI result are left as found
+// when inspecting the network namespace.
+// Endpoints are then given the ID of their network, when known from networkIDs.
+func ApplyNetworkResults(c *Container, results map[string]*types100.Result, networkIDs map[string]string) {
+	if c.NetworkSettings == nil || c.HostConfig == nil || c.Config == nil {
+		return
+	}
+
+	var dnsNames []string
+	for _, name := range []string{c.Name, c.Config.Hostname} {
+		if name != "" && !slices.Contains(dnsNames, name) {
+			dnsNames = append(dnsNames, name)
+		}
+	}
+
+	aliases := networkAliases(c.ID, c.Config.Labels)
+	for netName, result := range results {
+		if result == nil {
+			continue
+		}
+		nes := &NetworkEndpointSettings{
+			Aliases:    aliases[netName],
+			DriverOpts: map[string]string{},
+			DNSNames:   dnsNames,
+		}
+		for _, alias := range aliases[netName] {
+			if !slices.Contains(nes.DNSNames, alias) {
+				nes.DNSNames = append(slices.Clone(nes.DNSNames), alias)
+			}
+		}
+
+		for i, iface := range result.Interfaces {
+			// The interface with a sandbox is the one inside the container
+			if iface.Sandbox == "" {
+				continue
+			}
+			nes.MacAddress = iface.Mac
+			delete(c.NetworkSettings.Networks, "unknown-"+iface.Name)
+			for _, ipc := range result.IPs {
+				if ipc.Interface != nil && *ipc.Interface != i {
+					continue
+				}
+				ones, _ := ipc.Address.Mask.Size()
+				gateway := ""
+				if ipc.Gateway != nil {
+					gateway = ipc.Gateway.String()
+				}
+				if ip4 := ipc.Address.IP.To4(); ip4 != nil {
+					nes.IPAddress = ip4.String()
+					nes.IPPrefixLen = ones
+					nes.Gateway = gateway
+				} else {
+					nes.GlobalIPv6Address = ipc.Address.IP.String()
+					nes.GlobalIPv6PrefixLen = ones
+					nes.IPv6Gateway = gateway
+				}
+			}
+		}
+
+		if netName == c.HostConfig.NetworkMode {
+			ipv4, ipv6 := c.Config.Labels[labels.IPAddress], c.Config.Labels[labels.IP6Address]
+			if ipv4 != "" || ipv6 != "" {
+				nes.IPAMConfig = &EndpointIPAMConfig{
+					IPv4Address: ipv4,
+					IPv6Address: ipv6,
+				}
+			}
+		}
+
+		if nes.IPAddress != "" && nes.IPAddress == c.NetworkSettings.IPAddress {
+			c.NetworkSettings.Gateway = nes.Gateway
+		}
+		if nes.GlobalIPv6Address != "" && nes.GlobalIPv6Address == c.NetworkSettings.GlobalIPv6Address {
+			c.NetworkSettings.IPv6Gateway = nes.IPv6Gateway
+		}
+
+		c.NetworkSettings.Networks[netName] = nes
+	}
+
+	for netName, nes := range c.NetworkSettings.Networks {
+		if id, ok := networkIDs[netName]; ok {
+			nes.NetworkID = id
+		}
+	}
+}
+
 func ImageFromNative(nativeImage *native.Image) (*Image, error) {
 	imgOCI := nativeImage.ImageConfig
 	repository, tag := imgutil.ParseRepoTag(nativeImage.Image.Name)
@@ -680,6 +1103,22 @@ func networkSettingsFromNative(n *native.NetNS, sp *specs.Spec) (*NetworkSetting
 	return res, nil
 }
 
+func getPortBindingsFromNative(sp *specs.Spec) (nat.PortMap, error) {
+	portsLabel, ok := sp.Annotations[labels.Ports]
+	if !ok {
+		return nat.PortMap{}, nil
+	}
+	var ports []cni.PortMapping
+	if err := json.Unmarshal([]byte(portsLabel), &ports); err != nil {
+		return nil, fmt.Errorf("failed to parse ports: %w", err)
+	}
+	portMap, err := convertToNatPort(ports)
+	if err != nil {
+		return nil, err
+	}
+	return *portMap, nil
+}
+
 func cpuSettingsFromNative(sp *specs.Spec) *cpuSettings {
 	res := &cpuSettings{}
 	if sp.Linux != nil && sp.Linux.Resources != nil && sp.Linux.Resources.CPU != nil {
@@ -698,6 +1137,18 @@ func cpuSettingsFromNative(sp *specs.Spec) *cpuSettings {
 		if sp.Linux.Resources.CPU.Quota != nil && *sp.Linux.Resources.CPU.Quota > 0 {
 			res.cpuQuota = *sp.Linux.Resources.CPU.Quota
 		}
+
+		if sp.Linux.Resources.CPU.Period != nil {
+			res.cpuPeriod = int64(*sp.Linux.Resources.CPU.Period)
+		}
+
+		if sp.Linux.Resources.CPU.RealtimePeriod != nil {
+			res.cpuRealtimePeriod = int64(*sp.Linux.Resources.CPU.RealtimePeriod)
+		}
+
+		if sp.Linux.Resources.CPU.RealtimeRuntime != nil {
+			res.cpuRealtimeRuntime = *sp.Linux.Resources.CPU.RealtimeRuntime
+		}
 	}
 
 	return res
@@ -768,6 +1219,15 @@ func getMemorySettingsFromNative(sp *specs.Spec) *MemorySetting {
 		if sp.Linux.Resources.Memory.Swap != nil {
 			res.Swap = *sp.Linux.Resources.Memory.Swap
 		}
+
+		if sp.Linux.Resources.Memory.Reservation != nil {
+			res.Reservation = *sp.Linux.Resources.Memory.Reservation
+		}
+
+		if sp.Linux.Resources.Memory.Swappiness != nil {
+			swappiness := int64(*sp.Linux.Resources.Memory.Swappiness)
+			res.Swappiness = &swappiness
+		}
 	}
 	return res
 }
@@ -822,6 +1282,98 @@ func getSysctlFromNative(sp *specs.Spec) map[string]string {
 	return res
 }
 
+func getRestartPolicyFromNative(lbls map[string]string) (RestartPolicy, error) {
+	policyLabel, ok := lbls[restart.PolicyLabel]
+	if !ok {
+		return RestartPolicy{Name: "no"}, nil
+	}
+	policy, err := restart.NewPolicy(policyLabel)
+	if err != nil {
+		return RestartPolicy{}, fmt.Errorf("failed to parse restart policy: %w", err)
+	}
+	return RestartPolicy{
+		Name:              policy.Name(),
+		MaximumRetryCount: policy.MaximumRetryCount(),
+	}, nil
+}
+
+func getPidsLimitFromNative(sp *specs.Spec) *int64 {
+	if sp.Linux != nil && sp.Linux.Resources != nil && sp.Linux.Resources.Pids != nil {
+		limit := sp.Linux.Resources.Pids.Limit
+		return &limit
+	}
+	return nil
+}
+
+func getUlimitsFromNative(sp *specs.Spec) []*Ulimit {
+	res := []*Ulimit{}
+	if sp.Process != nil {
+		for _, rlimit := range sp.Process.Rlimits {
+			res = append(res, &Ulimit{
+				Name: strings.ToLower(strings.TrimPrefix(rlimit.Type, "RLIMIT_")),
+				Hard: int64(rlimit.Hard),
+				Soft: int64(rlimit.Soft),
+			})
+		}
+	}
+	return res
+}
+
+// getBlkioSettingsFromNative reports devices by their /dev/block/<major>:<minor> path, as the spec does not retain the
+// path given by the user.
+func getBlkioSettingsFromNative(sp *specs.Spec) *blkioSettings {
+	res := &blkioSettings{
+		weightDevice:    []*WeightDevice{},
+		deviceReadBps:   []*ThrottleDevice{},
+		deviceWriteBps:  []*ThrottleDevice{},
+		deviceReadIOps:  []*ThrottleDevice{},
+		deviceWriteIOps: []*ThrottleDevice{},
+	}
+	if sp.Linux == nil || sp.Linux.Resources == nil || sp.Linux.Resources.BlockIO == nil {
+		return res
+	}
+	blockIO := sp.Linux.Resources.BlockIO
+	for _, dev := range blockIO.WeightDevice {
+		if dev.Weight != nil {
+			res.weightDevice = append(res.weightDevice, &WeightDevice{
+				Path:   blockDevicePath(dev.Major, dev.Minor),
+				Weight: *dev.Weight,
+			})
+		}
+	}
+	throttle := func(devs []specs.LinuxThrottleDevice) []*ThrottleDevice {
+		res := []*ThrottleDevice{}
+		for _, dev := range devs {
+			res = append(res, &ThrottleDevice{
+				Path: blockDevicePath(dev.Major, dev.Minor),
+				Rate: dev.Rate,
+			})
+		}
+		return res
+	}
+	res.deviceReadBps = throttle(blockIO.ThrottleReadBpsDevice)
+	res.deviceWriteBps = throttle(blockIO.ThrottleWriteBpsDevice)
+	res.deviceReadIOps = throttle(blockIO.ThrottleReadIOPSDevice)
+	res.deviceWriteIOps = throttle(blockIO.ThrottleWriteIOPSDevice)
+	return res
+}
+
+func blockDevicePath(major, minor int64) string {
+	return fmt.Sprintf("/dev/block/%d:%d", major, minor)
+}
+
+// getUserFromNative returns the user the process runs as, in the uid:gid form.
+// Like Docker, it is empty for root.
+func getUserFromNative(user specs.User) string {
+	if user.Username != "" {
+		return user.Username
+	}
+	if user.UID == 0 && user.GID == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d:%d", user.UID, user.GID)
+}
+
 type IPAMConfig struct {
 	Subnet  string `json:"Subnet,omitempty"`
 	Gateway string `json:"Gateway,omitempty"`
@@ -853,9 +1405,11 @@ type structuredCNI struct {
 }
 
 type MemorySetting struct {
-	Limit            int64 `json:"limit"`
-	Swap             int64 `json:"swap"`
-	DisableOOMKiller bool  `json:"disableOOMKiller"`
+	Limit            int64  `json:"limit"`
+	Swap             int64  `json:"swap"`
+	DisableOOMKiller bool   `json:"disableOOMKiller"`
+	Reservation      int64  `json:"reservation"`
+	Swappiness       *int64 `json:"swappiness"`
 }
 
 func NetworkFromNative(n *native.Network) (*Network, error) {
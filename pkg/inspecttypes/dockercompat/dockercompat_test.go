@@ -17,18 +17,27 @@
 package dockercompat_test
 
 import (
+	"encoding/json"
+	"net"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	containerd "github.com/containerd/containerd/v2/client"
 	"github.com/containerd/containerd/v2/core/containers"
+	"github.com/containerd/containerd/v2/core/runtime/restart"
+	types100 "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/docker/go-connections/nat"
 	"gotest.tools/v3/assert"
 
 	"go.farcloser.world/containers/specs"
 
+	"go.farcloser.world/lepton/pkg/healthcheck"
 	"go.farcloser.world/lepton/pkg/inspecttypes/dockercompat"
 	"go.farcloser.world/lepton/pkg/inspecttypes/native"
 	"go.farcloser.world/lepton/pkg/labels"
@@ -81,6 +90,15 @@ func TestContainerFromNative(t *testing.T) {
 					},
 					UTSMode: "host",
 					Tmpfs:   map[string]string{},
+					RestartPolicy: dockercompat.RestartPolicy{
+						Name: "no",
+					},
+					BlkioWeightDevice:    []*dockercompat.WeightDevice{},
+					BlkioDeviceReadBps:   []*dockercompat.ThrottleDevice{},
+					BlkioDeviceWriteBps:  []*dockercompat.ThrottleDevice{},
+					BlkioDeviceReadIOps:  []*dockercompat.ThrottleDevice{},
+					BlkioDeviceWriteIOps: []*dockercompat.ThrottleDevice{},
+					Ulimits:              []*dockercompat.Ulimit{},
 				},
 				Mounts: []dockercompat.MountPoint{
 					{
@@ -101,8 +119,9 @@ func TestContainerFromNative(t *testing.T) {
 					Hostname: "host1",
 				},
 				NetworkSettings: &dockercompat.NetworkSettings{
-					Ports:    &nat.PortMap{},
-					Networks: map[string]*dockercompat.NetworkEndpointSettings{},
+					SandboxKey: "/proc/10000/ns/net",
+					Ports:      &nat.PortMap{},
+					Networks:   map[string]*dockercompat.NetworkEndpointSettings{},
 				},
 			},
 		},
@@ -173,6 +192,15 @@ func TestContainerFromNative(t *testing.T) {
 					},
 					UTSMode: "host",
 					Tmpfs:   map[string]string{},
+					RestartPolicy: dockercompat.RestartPolicy{
+						Name: "no",
+					},
+					BlkioWeightDevice:    []*dockercompat.WeightDevice{},
+					BlkioDeviceReadBps:   []*dockercompat.ThrottleDevice{},
+					BlkioDeviceWriteBps:  []*dockercompat.ThrottleDevice{},
+					BlkioDeviceReadIOps:  []*dockercompat.ThrottleDevice{},
+					BlkioDeviceWriteIOps: []*dockercompat.ThrottleDevice{},
+					Ulimits:              []*dockercompat.Ulimit{},
 				},
 				Mounts: []dockercompat.MountPoint{
 					{
@@ -211,8 +239,9 @@ func TestContainerFromNative(t *testing.T) {
 				},
 				Config: &dockercompat.Config{},
 				NetworkSettings: &dockercompat.NetworkSettings{
-					Ports:    &nat.PortMap{},
-					Networks: map[string]*dockercompat.NetworkEndpointSettings{},
+					SandboxKey: "/proc/10000/ns/net",
+					Ports:      &nat.PortMap{},
+					Networks:   map[string]*dockercompat.NetworkEndpointSettings{},
 				},
 			},
 		},
@@ -263,6 +292,15 @@ func TestContainerFromNative(t *testing.T) {
 					},
 					UTSMode: "host",
 					Tmpfs:   map[string]string{},
+					RestartPolicy: dockercompat.RestartPolicy{
+						Name: "no",
+					},
+					BlkioWeightDevice:    []*dockercompat.WeightDevice{},
+					BlkioDeviceReadBps:   []*dockercompat.ThrottleDevice{},
+					BlkioDeviceWriteBps:  []*dockercompat.ThrottleDevice{},
+					BlkioDeviceReadIOps:  []*dockercompat.ThrottleDevice{},
+					BlkioDeviceWriteIOps: []*dockercompat.ThrottleDevice{},
+					Ulimits:              []*dockercompat.Ulimit{},
 				},
 				Mounts: []dockercompat.MountPoint{
 					{
@@ -279,8 +317,9 @@ func TestContainerFromNative(t *testing.T) {
 					Hostname: "host1",
 				},
 				NetworkSettings: &dockercompat.NetworkSettings{
-					Ports:    &nat.PortMap{},
-					Networks: map[string]*dockercompat.NetworkEndpointSettings{},
+					SandboxKey: "/proc/10000/ns/net",
+					Ports:      &nat.PortMap{},
+					Networks:   map[string]*dockercompat.NetworkEndpointSettings{},
 				},
 			},
 		},
@@ -404,3 +443,508 @@ func TestNetworkSettingsFromNative(t *testing.T) {
 }
 
 */
+
+// unsupportedKeys are the Docker fields that have no equivalent, and are thus not expected in the output.
+var unsupportedKeys = []string{
+	"HostConfig.Cgroup",
+	"HostConfig.Links",
+	"HostConfig.PublishAllPorts",
+	"HostConfig.UsernsMode",
+	"HostConfig.NanoCpus",
+	"HostConfig.DeviceCgroupRules",
+	"HostConfig.DeviceRequests",
+	// Windows only
+	"HostConfig.Isolation",
+	"HostConfig.CpuCount",
+	"HostConfig.CpuPercent",
+	"HostConfig.IOMaximumIOps",
+	"HostConfig.IOMaximumBandwidth",
+}
+
+// dataKeys are the Docker fields holding maps whose keys are data, and not field names.
+var dataKeys = []string{
+	"GraphDriver.Data",
+	"HostConfig.LogConfig.Config",
+	"HostConfig.PortBindings",
+	"HostConfig.Tmpfs",
+	"Config.ExposedPorts",
+	"Config.Volumes",
+	"Config.Labels",
+	"NetworkSettings.Ports",
+}
+
+// missingKeys lists the fields of the Docker output want that are absent from got.
+func missingKeys(want, got any, path string) []string {
+	var missing []string
+	switch w := want.(type) {
+	case map[string]any:
+		g, _ := got.(map[string]any)
+		if slices.Contains(dataKeys, path) {
+			return nil
+		}
+		for key, value := range w {
+			keyPath := strings.TrimPrefix(path+"."+key, ".")
+			if slices.Contains(unsupportedKeys, keyPath) {
+				continue
+			}
+			if _, ok := g[key]; !ok {
+				missing = append(missing, keyPath)
+				continue
+			}
+			missing = append(missing, missingKeys(value, g[key], keyPath)...)
+		}
+	case []any:
+		g, _ := got.([]any)
+		for i := 0; i < len(w) && i < len(g); i++ {
+			missing = append(missing, missingKeys(w[i], g[i], path+"."+strconv.Itoa(i))...)
+		}
+	}
+	return missing
+}
+
+// lookup returns the value at path in v, and whether it exists.
+func lookup(v any, path string) (any, bool) {
+	for _, key := range strings.Split(path, ".") {
+		switch x := v.(type) {
+		case map[string]any:
+			var ok bool
+			if v, ok = x[key]; !ok {
+				return nil, false
+			}
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i >= len(x) {
+				return nil, false
+			}
+			v = x[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// TestContainerFromNativeFields compares the output with the hand-written expectations found in testdata/docker, which
+// follow the layout of `docker container inspect` (see testdata/docker/README.md).
+// All expected fields must be present, save unsupportedKeys, and the listed fields must have the same values.
+func TestContainerFromNativeFields(t *testing.T) {
+	memoryLimit, memoryReservation, memorySwap := int64(64<<20), int64(32<<20), int64(128<<20)
+	smallMemoryLimit, smallMemorySwap := int64(32<<20), int64(64<<20)
+	cpuShares, cpuPeriod, cpuQuota := uint64(512), uint64(100000), int64(50000)
+	ethIndex := 2
+	volumeName := "7d3e1a9c5b2f4e6d8a0c1b3e5f7a9c2d4e6b8a0c1d3f5e7a9b2c4d6e8f0a1b3c"
+	maskedPaths := []string{
+		"/proc/asound", "/proc/acpi", "/proc/kcore", "/proc/keys", "/proc/latency_stats", "/proc/timer_list",
+		"/proc/timer_stats", "/proc/sched_debug", "/proc/scsi", "/sys/firmware", "/sys/devices/virtual/powercap",
+	}
+	readonlyPaths := []string{"/proc/bus", "/proc/fs", "/proc/irq", "/proc/sys", "/proc/sysrq-trigger"}
+
+	testCases := []struct {
+		fixture     string
+		n           *native.Container
+		results     map[string]*types100.Result
+		networkIDs  map[string]string
+		imageConfig string
+		health      *healthcheck.Health
+		compare     []string
+	}{
+		{
+			// docker run -d --name web --hostname web --init --read-only --tmpfs /run -v /srv/data:/data:ro
+			//   --restart on-failure:3 -m 64m --memory-reservation 32m --memory-swap 128m --cpu-shares 512
+			//   --cpu-period 100000 --cpu-quota 50000 --cpuset-cpus 0 --pids-limit 100 --ulimit nofile=1024:2048
+			//   --cap-add NET_ADMIN --cap-drop MKNOD --security-opt no-new-privileges -p 8080:80 -e FOO=bar
+			//   -w /srv -u 1000:1000 --stop-signal SIGQUIT --stop-timeout 20 alpine:3.20 sleep infinity
+			fixture: "running.json",
+			n: &native.Container{
+				Container: containers.Container{
+					ID:    "5a1e0b8d2f4c3e7a9b6d8f0e1c2a3b4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b",
+					Image: "alpine:3.20",
+					Labels: map[string]string{
+						labels.Name:     "web",
+						labels.Hostname: "web",
+						labels.Networks: `["bridge"]`,
+						labels.Mounts: `[` +
+							`{"Type":"bind","Source":"/srv/data","Destination":"/data",` +
+							`"Mode":"ro,rprivate","RW":false,"Propagation":"rprivate"},` +
+							`{"Type":"tmpfs","Source":"tmpfs","Destination":"/run",` +
+							`"Mode":"","RW":true,"Propagation":""}]`,
+						labels.StopTimeout: "20",
+						labels.HostConfigLabel: `{"BlkioWeight":0,"CidFile":"","Devices":[],` +
+							`"Binds":["/srv/data:/data:ro"],"CapAdd":["NET_ADMIN"],"CapDrop":["MKNOD"],` +
+							`"SecurityOpt":["no-new-privileges"],"Init":true}`,
+						labels.ContainerAutoRemove: "false",
+						restart.PolicyLabel:        "on-failure:3",
+						restart.StatusLabel:        "running",
+						containerd.StopSignalLabel: "SIGQUIT",
+					},
+					Snapshotter: "overlayfs",
+				},
+				Spec: &specs.Spec{
+					Annotations: map[string]string{
+						labels.Ports: `[{"HostPort":8080,"ContainerPort":80,"Protocol":"tcp","HostIP":"0.0.0.0"}]`,
+					},
+					Process: &specs.Process{
+						User: specs.User{UID: 1000, GID: 1000},
+						Args: []string{"/sbin/tini", "--", "sleep", "infinity"},
+						Env: []string{
+							"FOO=bar",
+							"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+						},
+						Cwd:             "/srv",
+						Rlimits:         []specs.POSIXRlimit{{Type: "RLIMIT_NOFILE", Hard: 2048, Soft: 1024}},
+						NoNewPrivileges: true,
+					},
+					Root: &specs.Root{Path: "rootfs", Readonly: true},
+					Linux: &specs.Linux{
+						Resources: &specs.LinuxResources{
+							Memory: &specs.LinuxMemory{
+								Limit:       &memoryLimit,
+								Reservation: &memoryReservation,
+								Swap:        &memorySwap,
+							},
+							CPU: &specs.LinuxCPU{
+								Shares: &cpuShares,
+								Quota:  &cpuQuota,
+								Period: &cpuPeriod,
+								Cpus:   "0",
+							},
+							Pids: &specs.LinuxPids{Limit: 100},
+						},
+						Namespaces: []specs.LinuxNamespace{
+							{Type: "pid"}, {Type: "ipc"}, {Type: "uts"},
+							{Type: "mount"}, {Type: "network"}, {Type: "cgroup"},
+						},
+						MaskedPaths:   maskedPaths,
+						ReadonlyPaths: readonlyPaths,
+					},
+				},
+				Process: &native.Process{
+					Pid: 4242,
+					Status: containerd.Status{
+						Status: "running",
+					},
+					NetNS: &native.NetNS{
+						PrimaryInterface: ethIndex,
+						Interfaces: []native.NetInterface{
+							{
+								Interface: net.Interface{Index: 1, Name: "lo", Flags: net.FlagUp | net.FlagLoopback},
+								Addrs:     []string{"127.0.0.1/8"},
+							},
+							{
+								Interface:    net.Interface{Index: ethIndex, Name: "eth0", Flags: net.FlagUp},
+								HardwareAddr: "02:42:ac:11:00:02",
+								Addrs:        []string{"172.17.0.2/16"},
+							},
+						},
+					},
+				},
+			},
+			results: map[string]*types100.Result{
+				"bridge": {
+					Interfaces: []*types100.Interface{
+						{Name: "lepton0", Mac: "02:42:1e:8a:4c:01"},
+						{Name: "veth3c2b1a09", Mac: "6e:1f:53:8a:0b:7c"},
+						{Name: "eth0", Mac: "02:42:ac:11:00:02", Sandbox: "/proc/4242/ns/net"},
+					},
+					IPs: []*types100.IPConfig{
+						{
+							Interface: &ethIndex,
+							Address:   net.IPNet{IP: net.ParseIP("172.17.0.2"), Mask: net.CIDRMask(16, 32)},
+							Gateway:   net.ParseIP("172.17.0.1"),
+						},
+					},
+				},
+			},
+			networkIDs: map[string]string{
+				"bridge": "d3c4b5a6978877665544332211000fedcba9876543210fedcba9876543210fed",
+			},
+			imageConfig: `{"architecture":"amd64","os":"linux","config":{"Env":["PATH=/usr/local/sbin:/usr/local/bin:` +
+				`/usr/sbin:/usr/bin:/sbin:/bin"],"Cmd":["/bin/sh"]}}`,
+			compare: []string{
+				"State.Status",
+				"State.Running",
+				"State.Pid",
+				"State.ExitCode",
+				"HostConfig.Binds",
+				"HostConfig.LogConfig",
+				"HostConfig.NetworkMode",
+				"HostConfig.RestartPolicy",
+				"HostConfig.AutoRemove",
+				"HostConfig.CapAdd",
+				"HostConfig.CapDrop",
+				"HostConfig.ReadonlyRootfs",
+				"HostConfig.SecurityOpt",
+				"HostConfig.CpuShares",
+				"HostConfig.CpuPeriod",
+				"HostConfig.CpuQuota",
+				"HostConfig.CpusetCpus",
+				"HostConfig.Memory",
+				"HostConfig.MemoryReservation",
+				"HostConfig.MemorySwap",
+				"HostConfig.MemorySwappiness",
+				"HostConfig.BlkioWeightDevice",
+				"HostConfig.PidsLimit",
+				"HostConfig.Ulimits",
+				"HostConfig.Init",
+				"HostConfig.MaskedPaths",
+				"HostConfig.ReadonlyPaths",
+				"Mounts.0.Type",
+				"Mounts.0.Source",
+				"Mounts.0.Destination",
+				"Mounts.0.RW",
+				"Config.Hostname",
+				"Config.User",
+				"Config.Tty",
+				"Config.Env",
+				"Config.Cmd",
+				"Config.Entrypoint",
+				"Config.ExposedPorts",
+				"Config.Image",
+				"Config.WorkingDir",
+				"Config.StopSignal",
+				"Config.StopTimeout",
+				"NetworkSettings.Gateway",
+				"NetworkSettings.IPAddress",
+				"NetworkSettings.IPPrefixLen",
+				"NetworkSettings.MacAddress",
+				"NetworkSettings.Networks.bridge.NetworkID",
+				"NetworkSettings.Networks.bridge.Gateway",
+				"NetworkSettings.Networks.bridge.IPAddress",
+				"NetworkSettings.Networks.bridge.IPPrefixLen",
+				"NetworkSettings.Networks.bridge.MacAddress",
+				"NetworkSettings.Networks.bridge.IPAMConfig",
+			},
+		},
+		{
+			// docker run -t --name app --network mynet -m 32m --log-opt max-size=10m registry.example.com/app:1.4
+			// Then killed by the OOM killer.
+			fixture: "exited.json",
+			n: &native.Container{
+				Container: containers.Container{
+					ID:    "c0ffee5e1f2a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4",
+					Image: "registry.example.com/app:1.4",
+					Labels: map[string]string{
+						labels.Name:      "app",
+						labels.Hostname:  "c0ffee5e1f2a",
+						labels.Networks:  `["mynet"]`,
+						labels.LogConfig: `{"driver":"json-file","opts":{"max-size":"10m"},"address":""}`,
+						labels.Mounts: `[{"Type":"volume",` +
+							`"Name":"` + volumeName + `",` +
+							`"Source":"/var/lib/lepton/volumes/default/` + volumeName + `/_data",` +
+							`"Destination":"/var/lib/app","Driver":"local","Mode":"","RW":true,"Propagation":""}]`,
+						labels.ContainerAutoRemove: "false",
+					},
+					Snapshotter: "overlayfs",
+				},
+				Spec: &specs.Spec{
+					Process: &specs.Process{
+						Terminal:    true,
+						ConsoleSize: &specs.Box{Height: 48, Width: 160},
+						Args:        []string{"/entrypoint.sh", "serve", "--port", "8080"},
+						Env: []string{
+							"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+							"APP_HOME=/app",
+						},
+						Cwd: "/app",
+					},
+					Linux: &specs.Linux{
+						Resources: &specs.LinuxResources{
+							Memory: &specs.LinuxMemory{
+								Limit: &smallMemoryLimit,
+								Swap:  &smallMemorySwap,
+							},
+						},
+						MaskedPaths:   maskedPaths,
+						ReadonlyPaths: readonlyPaths,
+					},
+				},
+				Process: &native.Process{
+					Status: containerd.Status{
+						Status:     "stopped",
+						ExitStatus: 137,
+					},
+				},
+			},
+			networkIDs: map[string]string{
+				"mynet": "9a8b7c6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d1e0f9a8b",
+			},
+			imageConfig: `{"architecture":"amd64","os":"linux","config":{"ExposedPorts":{"8080/tcp":{}},` +
+				`"Env":["PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin","APP_HOME=/app"],` +
+				`"Entrypoint":["/entrypoint.sh"],"Cmd":["serve","--port","8080"],"Volumes":{"/var/lib/app":{}},` +
+				`"WorkingDir":"/app","Labels":{"org.opencontainers.image.title":"app"},` +
+				`"Healthcheck":{"Test":["CMD-SHELL","wget -qO- http://localhost:8080/healthz || exit 1"],` +
+				`"Interval":30000000000,"Timeout":5000000000,"StartPeriod":10000000000,"Retries":3}}}`,
+			health: &healthcheck.Health{Status: healthcheck.Unhealthy},
+			compare: []string{
+				"State.Status",
+				"State.Running",
+				"State.Restarting",
+				"State.Pid",
+				"State.ExitCode",
+				"State.Health.Status",
+				"State.Health.FailingStreak",
+				"HostConfig.Binds",
+				"HostConfig.LogConfig",
+				"HostConfig.NetworkMode",
+				"HostConfig.PortBindings",
+				"HostConfig.RestartPolicy",
+				"HostConfig.ConsoleSize",
+				"HostConfig.CapAdd",
+				"HostConfig.Memory",
+				"HostConfig.MemorySwap",
+				"HostConfig.PidsLimit",
+				"HostConfig.Ulimits",
+				"Mounts.0.Type",
+				"Mounts.0.Name",
+				"Mounts.0.Destination",
+				"Mounts.0.Driver",
+				"Mounts.0.RW",
+				"Config.Hostname",
+				"Config.User",
+				"Config.Tty",
+				"Config.Env",
+				"Config.Cmd",
+				"Config.Entrypoint",
+				"Config.Healthcheck",
+				"Config.ExposedPorts",
+				"Config.Volumes",
+				"Config.Image",
+				"Config.WorkingDir",
+				"NetworkSettings.SandboxKey",
+				"NetworkSettings.IPAddress",
+				"NetworkSettings.Networks.mynet.NetworkID",
+				"NetworkSettings.Networks.mynet.IPAddress",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.fixture, func(t *testing.T) {
+			fixture, err := os.ReadFile(filepath.Join("testdata", "docker", tc.fixture))
+			assert.NilError(t, err)
+			var want map[string]any
+			assert.NilError(t, json.Unmarshal(fixture, &want))
+
+			d, err := dockercompat.ContainerFromNative(tc.n)
+			assert.NilError(t, err)
+			assert.NilError(t, dockercompat.ApplyImageConfig(d, []byte(tc.imageConfig)))
+			dockercompat.ApplyHealth(d, tc.health)
+			dockercompat.ApplyNetworkResults(d, tc.results, tc.networkIDs)
+
+			output, err := json.Marshal(d)
+			assert.NilError(t, err)
+			var got map[string]any
+			assert.NilError(t, json.Unmarshal(output, &got))
+
+			assert.DeepEqual(t, missingKeys(want, got, ""), []string(nil))
+			for _, path := range tc.compare {
+				wantValue, ok := lookup(want, path)
+				assert.Assert(t, ok, "%s is not in the fixture", path)
+				gotValue, _ := lookup(got, path)
+				assert.DeepEqual(t, gotValue, wantValue)
+			}
+		})
+	}
+}
+
+func TestApplyNetworkResultsAliases(t *testing.T) {
+	t.Parallel()
+
+	n := &native.Container{
+		Container: containers.Container{
+			ID: "1f2e3d4c5b6a79881f2e3d4c5b6a79881f2e3d4c5b6a79881f2e3d4c5b6a7988",
+			Labels: map[string]string{
+				labels.Name:           "api",
+				labels.Hostname:       "api",
+				labels.Networks:       `["backend","frontend"]`,
+				labels.NetworkAliases: `{"backend":["db-client","api"]}`,
+			},
+		},
+		Spec: &specs.Spec{},
+	}
+
+	// Stopped: the endpoints are the configured networks
+	d, err := dockercompat.ContainerFromNative(n)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, d.NetworkSettings.Networks["backend"].Aliases, []string{"db-client", "api"})
+	assert.Assert(t, d.NetworkSettings.Networks["frontend"].Aliases == nil)
+
+	// Running: the endpoints are built from the CNI results
+	dockercompat.ApplyNetworkResults(d, map[string]*types100.Result{
+		"backend":  {},
+		"frontend": {},
+	}, nil)
+	assert.DeepEqual(t, d.NetworkSettings.Networks["backend"].Aliases, []string{"db-client", "api"})
+	assert.DeepEqual(t, d.NetworkSettings.Networks["backend"].DNSNames, []string{"api", "db-client"})
+	assert.Assert(t, d.NetworkSettings.Networks["frontend"].Aliases == nil)
+	assert.DeepEqual(t, d.NetworkSettings.Networks["frontend"].DNSNames, []string{"api"})
+}
+
+func TestApplyHealth(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 10, 14, 10, 2, 41, 0, time.UTC)
+	check := &dockercompat.HealthConfig{Test: []string{"CMD", "true"}}
+	recorded := &healthcheck.Health{
+		Status:        healthcheck.Unhealthy,
+		FailingStreak: 3,
+		Log: []*healthcheck.Result{
+			{Start: start, End: start.Add(time.Second), ExitCode: 1, Output: "connection refused\n"},
+		},
+	}
+
+	testCases := []struct {
+		name        string
+		healthcheck *dockercompat.HealthConfig
+		running     bool
+		health      *healthcheck.Health
+		want        *dockercompat.Health
+	}{
+		{
+			name:    "no healthcheck",
+			running: true,
+			health:  recorded,
+		},
+		{
+			name:        "disabled healthcheck",
+			healthcheck: &dockercompat.HealthConfig{Test: []string{"NONE"}},
+			running:     true,
+			health:      recorded,
+		},
+		{
+			name:        "running, never checked",
+			healthcheck: check,
+			running:     true,
+			want:        &dockercompat.Health{Status: healthcheck.Starting, Log: []*dockercompat.HealthcheckResult{}},
+		},
+		{
+			name:        "exited, never checked",
+			healthcheck: check,
+		},
+		{
+			name:        "checked",
+			healthcheck: check,
+			health:      recorded,
+			want: &dockercompat.Health{
+				Status:        healthcheck.Unhealthy,
+				FailingStreak: 3,
+				Log: []*dockercompat.HealthcheckResult{
+					{Start: start, End: start.Add(time.Second), ExitCode: 1, Output: "connection refused\n"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			d := &dockercompat.Container{
+				Config: &dockercompat.Config{Healthcheck: tc.healthcheck},
+				State:  &dockercompat.ContainerState{Running: tc.running},
+			}
+			dockercompat.ApplyHealth(d, tc.health)
+			assert.DeepEqual(t, d.State.Health, tc.want)
+		})
+	}
+}
@@ -18,9 +18,13 @@ package native
 
 import (
 	"net"
+	"time"
 
 	containerd "github.com/containerd/containerd/v2/client"
 	"github.com/containerd/containerd/v2/core/containers"
+	"github.com/containerd/containerd/v2/core/mount"
+	"github.com/containerd/containerd/v2/core/snapshots"
+	"github.com/containerd/go-cni"
 )
 
 // Container corresponds to a containerd-native container object.
@@ -29,6 +33,67 @@ type Container struct {
 	containers.Container
 	Spec    interface{} `json:"Spec,omitempty"`
 	Process *Process    `json:"Process,omitempty"`
+	// The fields below are only set by `inspect --mode=native`, on a best-effort basis.
+	Cgroup          *Cgroup    `json:"Cgroup,omitempty"`
+	ResolvedRuntime *Runtime   `json:"ResolvedRuntime,omitempty"`
+	Snapshot        *Snapshot  `json:"Snapshot,omitempty"`
+	State           *State     `json:"State,omitempty"`
+	LogConfig       *LogConfig `json:"LogConfig,omitempty"`
+	NameOwnership   *NameOwner `json:"NameOwnership,omitempty"`
+}
+
+// Cgroup describes the cgroup of a running container.
+type Cgroup struct {
+	// Version is 1 or 2.
+	Version int `json:"Version,omitempty"`
+	// Path is the cgroup path, relative to the cgroup mount point.
+	// For a stopped container, this is the path requested in the spec, which may use the systemd "slice:prefix:name"
+	// notation.
+	Path string `json:"Path,omitempty"`
+	// Limits maps cgroup interface files (eg: "memory.max") to their current content.
+	Limits map[string]string `json:"Limits,omitempty"`
+}
+
+// Runtime is the runtime of the container, as resolved by containerd.
+type Runtime struct {
+	Name string `json:"Name,omitempty"`
+	// Shim is the name of the shim binary, and ShimPath its location in PATH, if found.
+	Shim     string `json:"Shim,omitempty"`
+	ShimPath string `json:"ShimPath,omitempty"`
+	// Options are the decoded runtime options (eg: runc BinaryName, SystemdCgroup).
+	Options interface{} `json:"Options,omitempty"`
+}
+
+// Snapshot is the rootfs snapshot of the container.
+type Snapshot struct {
+	Snapshotter string           `json:"Snapshotter,omitempty"`
+	Key         string           `json:"Key,omitempty"`
+	Mounts      []mount.Mount    `json:"Mounts,omitempty"`
+	Usage       *snapshots.Usage `json:"Usage,omitempty"`
+}
+
+// State is what the OCI hooks recorded about the container.
+type State struct {
+	StartedAt   time.Time `json:"StartedAt"`
+	CreateError bool      `json:"CreateError,omitempty"`
+	// IPs maps network names to the addresses allocated on them.
+	IPs       map[string][]string `json:"IPs,omitempty"`
+	Ports     []cni.PortMapping   `json:"Ports,omitempty"`
+	HostsPath string              `json:"HostsPath,omitempty"`
+}
+
+// LogConfig is the logging configuration of the container.
+type LogConfig struct {
+	Driver  string            `json:"Driver,omitempty"`
+	Opts    map[string]string `json:"Opts,omitempty"`
+	Address string            `json:"Address,omitempty"`
+}
+
+// NameOwner tells which container the name store grants the container name to.
+// Owner differing from the container ID denotes a corrupted name store.
+type NameOwner struct {
+	Name  string `json:"Name,omitempty"`
+	Owner string `json:"Owner,omitempty"`
 }
 
 type Process struct {
@@ -69,6 +69,14 @@ var (
 	// IP6Address is the static IP6 address of the container assigned by the user
 	IP6Address = Prefix + "ip6"
 
+	// NetworkAliases is a JSON-marshalled map[string][]string of the `run --network-alias` aliases, by network name.
+	NetworkAliases = Prefix + "network-aliases"
+
+	// IPSticky indicates whether the addresses first allocated to a container are requested again on restart.
+	// Set on containers (`run --ip-sticky`) or on networks (`network create --opt ip-sticky=true`).
+	// Boolean value which can be parsed with strconv.ParseBool() is required.
+	IPSticky = Prefix + "ip-sticky"
+
 	// LogURI is the log URI
 	LogURI = Prefix + "log-uri"
 
@@ -85,6 +93,10 @@ var (
 	// Mounts is the mount points for the container.
 	Mounts = Prefix + "mounts"
 
+	// VolumeSubpaths is a JSON-marshalled string of the volume directories mounted instead of the whole volume
+	// ([]volumestore.Subpath).
+	VolumeSubpaths = Prefix + "volume-subpaths"
+
 	// StopTimeout is seconds to wait for stop a container.
 	StopTimeout = Prefix + "stop-timeout"
 
@@ -0,0 +1,111 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package metricsutil
+
+import (
+	"go.farcloser.world/containers/stats"
+
+	"go.farcloser.world/lepton/pkg/labels"
+)
+
+// ContainerSample is a single stats reading for a container, along with the container labels used to
+// label the resulting metrics.
+type ContainerSample struct {
+	Namespace string
+	Labels    map[string]string
+	Entry     stats.Entry
+}
+
+func (cs *ContainerSample) metricLabels() map[string]string {
+	return map[string]string{
+		"namespace":       cs.Namespace,
+		"id":              cs.Entry.ID,
+		"name":            cs.Entry.Name,
+		"compose_project": cs.Labels[labels.ComposeProject],
+		"compose_service": cs.Labels[labels.ComposeService],
+	}
+}
+
+// ContainerFamilies turns stats readings into per-container metric families.
+// Invalid readings (eg: stopped containers) are skipped.
+func ContainerFamilies(samples []ContainerSample) []*Family {
+	cpu := &Family{
+		Name: "container_cpu_usage_ratio",
+		Help: "CPU usage of the container, as a ratio of one CPU (1 = one full CPU).",
+		Type: Gauge,
+		Unit: "ratio",
+	}
+	memUsage := &Family{
+		Name: "container_memory_usage_bytes",
+		Help: "Memory usage of the container, excluding the inactive file cache.",
+		Type: Gauge,
+		Unit: "bytes",
+	}
+	memLimit := &Family{
+		Name: "container_memory_limit_bytes",
+		Help: "Memory limit of the container (host memory if unlimited).",
+		Type: Gauge,
+		Unit: "bytes",
+	}
+	netRx := &Family{
+		Name: "container_network_receive_bytes",
+		Help: "Bytes received on all interfaces of the container.",
+		Type: Counter,
+		Unit: "bytes",
+	}
+	netTx := &Family{
+		Name: "container_network_transmit_bytes",
+		Help: "Bytes transmitted on all interfaces of the container.",
+		Type: Counter,
+		Unit: "bytes",
+	}
+	blkRead := &Family{
+		Name: "container_blkio_read_bytes",
+		Help: "Bytes read from block devices by the container.",
+		Type: Counter,
+		Unit: "bytes",
+	}
+	blkWrite := &Family{
+		Name: "container_blkio_write_bytes",
+		Help: "Bytes written to block devices by the container.",
+		Type: Counter,
+		Unit: "bytes",
+	}
+	pids := &Family{
+		Name: "container_pids",
+		Help: "Number of processes in the container.",
+		Type: Gauge,
+	}
+
+	for _, sample := range samples {
+		if sample.Entry.IsInvalid || sample.Entry.ID == "" {
+			continue
+		}
+
+		lbls := sample.metricLabels()
+		cpu.Samples = append(cpu.Samples, Sample{lbls, sample.Entry.CPUPercentage / 100})
+		memUsage.Samples = append(memUsage.Samples, Sample{lbls, sample.Entry.Memory})
+		memLimit.Samples = append(memLimit.Samples, Sample{lbls, sample.Entry.MemoryLimit})
+		netRx.Samples = append(netRx.Samples, Sample{lbls, sample.Entry.NetworkRx})
+		netTx.Samples = append(netTx.Samples, Sample{lbls, sample.Entry.NetworkTx})
+		blkRead.Samples = append(blkRead.Samples, Sample{lbls, sample.Entry.BlockRead})
+		blkWrite.Samples = append(blkWrite.Samples, Sample{lbls, sample.Entry.BlockWrite})
+		pids.Samples = append(pids.Samples, Sample{lbls, float64(sample.Entry.PidsCurrent)})
+	}
+
+	return []*Family{cpu, memUsage, memLimit, netRx, netTx, blkRead, blkWrite, pids}
+}
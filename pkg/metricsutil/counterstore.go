@@ -0,0 +1,144 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package metricsutil
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/log"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+	"go.farcloser.world/lepton/leptonic/store"
+	"go.farcloser.world/lepton/pkg/api/options"
+	"go.farcloser.world/lepton/pkg/clientutil"
+)
+
+const metricsDirBasename = "metrics"
+
+// Known counters.
+const (
+	ImagePulls  = "image_pulls"
+	ImagePushes = "image_pushes"
+)
+
+// ErrCounterStore will wrap all errors here
+var ErrCounterStore = errors.New("counter-store error")
+
+// CounterStore persists monotonic counters per namespace, so that they survive across cli invocations.
+// All methods are safe to use concurrently.
+type CounterStore interface {
+	// Increment adds one to the named counter, creating it if needed.
+	Increment(name string) error
+	// List returns the current value of all counters.
+	List() (map[string]uint64, error)
+}
+
+// NewCounterStore returns a CounterStore for a given namespace.
+func NewCounterStore(dataStore, namespace string) (CounterStore, error) {
+	if dataStore == "" || namespace == "" {
+		return nil, errors.Join(ErrCounterStore, errs.ErrInvalidArgument)
+	}
+
+	st, err := store.New(filepath.Join(dataStore, metricsDirBasename, namespace), false, 0, 0)
+	if err != nil {
+		return nil, errors.Join(ErrCounterStore, err)
+	}
+
+	return &counterStore{
+		safeStore: st,
+	}, nil
+}
+
+type counterStore struct {
+	safeStore store.Store
+}
+
+func (cs *counterStore) Increment(name string) (err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(ErrCounterStore, err)
+		}
+	}()
+
+	return cs.safeStore.WithLock(func() error {
+		value, err := cs.get(name)
+		if err != nil {
+			return err
+		}
+
+		return cs.safeStore.Set([]byte(strconv.FormatUint(value+1, 10)), name)
+	})
+}
+
+func (cs *counterStore) List() (counters map[string]uint64, err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(ErrCounterStore, err)
+		}
+	}()
+
+	counters = map[string]uint64{}
+	err = cs.safeStore.WithLock(func() error {
+		names, err := cs.safeStore.List()
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			if counters[name], err = cs.get(name); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return counters, err
+}
+
+func (cs *counterStore) get(name string) (uint64, error) {
+	content, err := cs.safeStore.Get(name)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return 0, nil
+		}
+
+		return 0, err
+	}
+
+	return strconv.ParseUint(strings.TrimSpace(string(content)), 10, 64)
+}
+
+// Increment bumps a counter for the namespace in globalOptions.
+// Metrics are best-effort: failures are logged and never returned to the caller.
+func Increment(ctx context.Context, globalOptions *options.Global, name string) {
+	dataStore, err := clientutil.DataStore(globalOptions.DataRoot, globalOptions.Address)
+	if err == nil {
+		var counters CounterStore
+		if counters, err = NewCounterStore(dataStore, globalOptions.Namespace); err == nil {
+			err = counters.Increment(name)
+		}
+	}
+
+	if err != nil {
+		log.G(ctx).WithError(err).Debugf("failed to increment counter %q", name)
+	}
+}
@@ -0,0 +1,59 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package metricsutil_test
+
+import (
+	"sync"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/lepton/pkg/metricsutil"
+)
+
+func TestCounterStore(t *testing.T) {
+	t.Parallel()
+
+	_, err := metricsutil.NewCounterStore(t.TempDir(), "")
+	assert.ErrorIs(t, err, metricsutil.ErrCounterStore, "empty namespace should fail")
+
+	counters, err := metricsutil.NewCounterStore(t.TempDir(), "default")
+	assert.NilError(t, err)
+
+	list, err := counters.List()
+	assert.NilError(t, err)
+	assert.Equal(t, len(list), 0, "a new store should not have any counter")
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Check(t, counters.Increment(metricsutil.ImagePulls))
+		}()
+	}
+	wg.Wait()
+
+	assert.NilError(t, counters.Increment(metricsutil.ImagePushes))
+
+	list, err = counters.List()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, list, map[string]uint64{
+		metricsutil.ImagePulls:  10,
+		metricsutil.ImagePushes: 1,
+	})
+}
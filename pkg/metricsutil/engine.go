@@ -0,0 +1,64 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package metricsutil
+
+// EngineState holds the namespace-wide values exported alongside containers metrics.
+type EngineState struct {
+	Namespace  string
+	Containers int
+	Images     int
+	Volumes    int
+	Counters   map[string]uint64
+}
+
+// EngineFamilies turns the namespace-wide state into metric families.
+func EngineFamilies(state EngineState) []*Family {
+	lbls := map[string]string{"namespace": state.Namespace}
+
+	return []*Family{
+		{
+			Name:    "containers",
+			Help:    "Number of containers.",
+			Type:    Gauge,
+			Samples: []Sample{{lbls, float64(state.Containers)}},
+		},
+		{
+			Name:    "images",
+			Help:    "Number of images.",
+			Type:    Gauge,
+			Samples: []Sample{{lbls, float64(state.Images)}},
+		},
+		{
+			Name:    "volumes",
+			Help:    "Number of volumes.",
+			Type:    Gauge,
+			Samples: []Sample{{lbls, float64(state.Volumes)}},
+		},
+		{
+			Name:    ImagePulls,
+			Help:    "Number of successful image pulls.",
+			Type:    Counter,
+			Samples: []Sample{{lbls, float64(state.Counters[ImagePulls])}},
+		},
+		{
+			Name:    ImagePushes,
+			Help:    "Number of successful image pushes.",
+			Type:    Counter,
+			Samples: []Sample{{lbls, float64(state.Counters[ImagePushes])}},
+		},
+	}
+}
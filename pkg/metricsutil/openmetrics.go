@@ -0,0 +1,158 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package metricsutil renders container and engine metrics in the OpenMetrics text format
+// (https://github.com/OpenObservability/OpenMetrics/blob/main/specification/OpenMetrics.md) or in the Prometheus
+// text format (https://prometheus.io/docs/instrumenting/exposition_formats/#text-based-format), and keeps the
+// persistent counters (pulls, pushes) that cannot be derived from the current state of containerd.
+package metricsutil
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.farcloser.world/lepton/pkg/version"
+)
+
+const (
+	// ContentType is the http Content-Type for the OpenMetrics text format.
+	ContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+	// TextContentType is the http Content-Type for the Prometheus text format.
+	TextContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// Type is the OpenMetrics type of a metric family.
+type Type string
+
+const (
+	Gauge   Type = "gauge"
+	Counter Type = "counter"
+)
+
+// Sample is a single value of a family, identified by its labels.
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// Family is a named set of samples sharing the same type and help.
+// Name must not carry the RootName prefix, nor the "_total" suffix for counters: they are added by Write and WriteText.
+type Family struct {
+	Name    string
+	Help    string
+	Type    Type
+	Unit    string
+	Samples []Sample
+}
+
+// Write outputs the given families in the OpenMetrics text format, followed by the mandatory "# EOF" marker.
+// Counter families are named without the "_total" suffix, which only their samples carry.
+// Families without samples are omitted.
+func Write(w io.Writer, families []*Family) error {
+	return write(w, families, true)
+}
+
+// WriteText outputs the given families in the Prometheus text format, as read by Prometheus without content
+// negotiation, or by the node-exporter textfile collector.
+// Counter families are named after their samples, with the "_total" suffix. Units are not reported.
+// Families without samples are omitted.
+func WriteText(w io.Writer, families []*Family) error {
+	return write(w, families, false)
+}
+
+func write(w io.Writer, families []*Family, openMetrics bool) error {
+	bw := bufio.NewWriter(w)
+
+	for _, family := range families {
+		if len(family.Samples) == 0 {
+			continue
+		}
+
+		name := version.RootName + "_" + family.Name
+		sampleName := name
+		if family.Type == Counter {
+			sampleName += "_total"
+		}
+
+		if openMetrics {
+			fmt.Fprintf(bw, "# TYPE %s %s\n", name, family.Type)
+			if family.Unit != "" {
+				fmt.Fprintf(bw, "# UNIT %s %s\n", name, family.Unit)
+			}
+			if family.Help != "" {
+				fmt.Fprintf(bw, "# HELP %s %s\n", name, escapeHelp(family.Help))
+			}
+		} else {
+			if family.Help != "" {
+				fmt.Fprintf(bw, "# HELP %s %s\n", sampleName, escapeHelp(family.Help))
+			}
+			fmt.Fprintf(bw, "# TYPE %s %s\n", sampleName, family.Type)
+		}
+
+		for _, sample := range family.Samples {
+			fmt.Fprintf(
+				bw,
+				"%s%s %s\n",
+				sampleName,
+				formatLabels(sample.Labels),
+				strconv.FormatFloat(sample.Value, 'g', -1, 64),
+			)
+		}
+	}
+
+	if openMetrics {
+		fmt.Fprintln(bw, "# EOF")
+	}
+
+	return bw.Flush()
+}
+
+// formatLabels returns the labels set, sorted by name, or the empty string if there are no labels.
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + escapeLabelValue(labels[name]) + `"`
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var (
+	labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpReplacer       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabelValue(value string) string {
+	return labelValueReplacer.Replace(value)
+}
+
+func escapeHelp(help string) string {
+	return helpReplacer.Replace(help)
+}
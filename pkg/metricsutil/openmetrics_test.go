@@ -0,0 +1,95 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package metricsutil_test
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/lepton/pkg/metricsutil"
+)
+
+func testFamilies() []*metricsutil.Family {
+	return []*metricsutil.Family{
+		{
+			Name: "container_memory_usage_bytes",
+			Help: "Memory usage.",
+			Type: metricsutil.Gauge,
+			Unit: "bytes",
+			Samples: []metricsutil.Sample{
+				{Labels: map[string]string{"name": "foo", "namespace": "default"}, Value: 1024},
+				{Labels: map[string]string{"name": "b\"a\\r\nbaz", "namespace": "default"}, Value: 0.5},
+			},
+		},
+		{
+			Name: "empty",
+			Type: metricsutil.Gauge,
+		},
+		{
+			Name:    "image_pulls",
+			Help:    "Pulls.\nMultiline",
+			Type:    metricsutil.Counter,
+			Samples: []metricsutil.Sample{{Value: 3}},
+		},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	assert.NilError(t, metricsutil.Write(&buf, testFamilies()))
+	assert.Equal(t, buf.String(), `# TYPE lepton_container_memory_usage_bytes gauge
+# UNIT lepton_container_memory_usage_bytes bytes
+# HELP lepton_container_memory_usage_bytes Memory usage.
+lepton_container_memory_usage_bytes{name="foo",namespace="default"} 1024
+lepton_container_memory_usage_bytes{name="b\"a\\r\nbaz",namespace="default"} 0.5
+# TYPE lepton_image_pulls counter
+# HELP lepton_image_pulls Pulls.\nMultiline
+lepton_image_pulls_total 3
+# EOF
+`)
+}
+
+func TestWriteText(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	assert.NilError(t, metricsutil.WriteText(&buf, testFamilies()))
+	assert.Equal(t, buf.String(), `# HELP lepton_container_memory_usage_bytes Memory usage.
+# TYPE lepton_container_memory_usage_bytes gauge
+lepton_container_memory_usage_bytes{name="foo",namespace="default"} 1024
+lepton_container_memory_usage_bytes{name="b\"a\\r\nbaz",namespace="default"} 0.5
+# HELP lepton_image_pulls_total Pulls.\nMultiline
+# TYPE lepton_image_pulls_total counter
+lepton_image_pulls_total 3
+`)
+}
+
+func TestWriteEmpty(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	assert.NilError(t, metricsutil.Write(&buf, nil))
+	assert.Equal(t, buf.String(), "# EOF\n")
+
+	buf.Reset()
+	assert.NilError(t, metricsutil.WriteText(&buf, nil))
+	assert.Equal(t, buf.String(), "")
+}
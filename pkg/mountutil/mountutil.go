@@ -39,6 +39,7 @@ const (
 	Volume        = "volume"
 	Tmpfs         = "tmpfs"
 	Npipe         = "npipe"
+	Image         = "image"
 	pathSeparator = string(os.PathSeparator)
 )
 
@@ -49,6 +50,8 @@ type Processed struct {
 	AnonymousVolume string // anonymous volume name
 	Mode            string
 	Opts            []oci.SpecOpts
+	// Subpath is the directory of the volume or image to mount, instead of its root
+	Subpath string
 }
 
 type volumeSpec struct {
@@ -18,6 +18,7 @@ package mountutil
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
@@ -308,6 +309,8 @@ func ProcessFlagMount(s string, volStore volumestore.VolumeService) (*Processed,
 		rwOption         string
 		tmpfsSize        int64
 		tmpfsMode        os.FileMode
+		volumeSubpath    string
+		imageSubpath     string
 		err              error
 	)
 
@@ -318,7 +321,8 @@ func ProcessFlagMount(s string, volStore volumestore.VolumeService) (*Processed,
 	// three types of mount(and examples):
 	// --mount type=bind,source="$(pwd)"/target,target=/app2,readonly,bind-propagation=shared
 	// --mount type=tmpfs,destination=/app,tmpfs-mode=1770,tmpfs-size=1MB
-	// --mount type=volume,src=vol-1,dst=/app,readonly
+	// --mount type=volume,src=vol-1,dst=/app,readonly,volume-subpath=dir
+	// --mount type=image,src=alpine,dst=/app,image-subpath=etc
 	// if type not specified, default will be set to volume
 	// --mount src=`pwd`/tmp,target=/app
 
@@ -349,9 +353,11 @@ func ProcessFlagMount(s string, volStore volumestore.VolumeService) (*Processed,
 				mountType = Tmpfs
 			case "bind":
 				mountType = Bind
+			case "image":
+				mountType = Image
 			case "volume":
 			default:
-				return nil, fmt.Errorf("invalid mount type '%s' must be a volume/bind/tmpfs", value)
+				return nil, fmt.Errorf("invalid mount type '%s' must be a volume/bind/tmpfs/image", value)
 			}
 		case "source", "src":
 			src = value
@@ -374,6 +380,10 @@ func ProcessFlagMount(s string, volStore volumestore.VolumeService) (*Processed,
 			if err != nil {
 				return nil, fmt.Errorf("invalid value for %s: %s", key, value)
 			}
+		case "volume-subpath":
+			volumeSubpath = value
+		case "image-subpath", "subpath":
+			imageSubpath = value
 		case "tmpfs-size":
 			tmpfsSize, err = units.RAMInBytes(value)
 			if err != nil {
@@ -390,6 +400,16 @@ func ProcessFlagMount(s string, volStore volumestore.VolumeService) (*Processed,
 		}
 	}
 
+	if volumeSubpath != "" && mountType != Volume {
+		return nil, errors.New("volume-subpath is only supported for volume mounts")
+	}
+	if imageSubpath != "" && mountType != Image {
+		return nil, errors.New("image-subpath is only supported for image mounts")
+	}
+	if mountType == Image {
+		return processImageMount(src, dst, imageSubpath, rwOption)
+	}
+
 	// compose new fileds and join into a string
 	// to call legacy ProcessFlagTmpfs or ProcessFlagV function
 	fields = []string{}
@@ -437,9 +457,68 @@ func ProcessFlagMount(s string, volStore volumestore.VolumeService) (*Processed,
 		return ProcessFlagTmpfs(fieldsStr)
 	case Volume, Bind:
 		// createDir=false for --mount option to disallow creating directories on host if not found
-		return ProcessFlagV(fieldsStr, volStore, false)
+		res, err := ProcessFlagV(fieldsStr, volStore, false)
+		if err != nil || volumeSubpath == "" {
+			return res, err
+		}
+		return withVolumeSubpath(res, volumeSubpath)
+	}
+	return nil, fmt.Errorf("invalid mount type '%s' must be a volume/bind/tmpfs/image", mountType)
+}
+
+// processImageMount returns a read-only mount of an image, that is resolved to a snapshot when the container is created.
+func processImageMount(src, dst, subpath, rwOption string) (*Processed, error) {
+	if src == "" {
+		return nil, errors.New("image mount requires a source image")
+	}
+	if _, err := isValidPath(dst); err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("invalid mount type '%s' must be a volume/bind/tmpfs", mountType)
+	if rwOption == "rw" {
+		return nil, errors.New("image mounts are always read-only")
+	}
+
+	res := &Processed{
+		Type: Image,
+		Name: src,
+		Mode: "ro",
+		Mount: specs.Mount{
+			Destination: cleanMount(dst),
+		},
+	}
+
+	if subpath != "" {
+		var err error
+		if res.Subpath, err = cleanSubpath(subpath); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// withVolumeSubpath restricts a named volume mount to a directory of the volume.
+// The mount source is left to the volume: the directory is only resolved within the volume when the container starts,
+// and must exist by then (see volumestore.Subpath).
+func withVolumeSubpath(res *Processed, subpath string) (*Processed, error) {
+	if res.Type != Volume || res.Name == "" {
+		return nil, errors.New("volume-subpath requires a named volume")
+	}
+
+	var err error
+	if res.Subpath, err = cleanSubpath(subpath); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func cleanSubpath(subpath string) (string, error) {
+	if !filepath.IsLocal(subpath) {
+		return "", fmt.Errorf("subpath %q must be a relative path, within the mount source", subpath)
+	}
+
+	return filepath.Clean(subpath), nil
 }
 
 // copy from
@@ -361,3 +361,74 @@ func TestProcessFlagVAnonymousVolumes(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessFlagMountSubpath(t *testing.T) {
+	tests := []struct {
+		rawSpec string
+		wants   *mountutil.Processed
+		err     string
+	}{
+		{
+			rawSpec: "type=volume,src=TestVolume,dst=/mnt/foo,volume-subpath=sub/dir",
+			wants: &mountutil.Processed{
+				Type:    "volume",
+				Name:    "TestVolume",
+				Subpath: "sub/dir",
+				Mount: specs.Mount{
+					Source:      "/test/volume",
+					Destination: "/mnt/foo",
+				},
+			},
+		},
+		{
+			rawSpec: "type=image,src=alpine,dst=/mnt/foo,image-subpath=/etc/",
+			err:     "must be a relative path",
+		},
+		{
+			rawSpec: "type=image,src=alpine,dst=/mnt/foo,subpath=etc/",
+			wants: &mountutil.Processed{
+				Type:    "image",
+				Name:    "alpine",
+				Mode:    "ro",
+				Subpath: "etc",
+				Mount: specs.Mount{
+					Destination: "/mnt/foo",
+				},
+			},
+		},
+		{
+			rawSpec: "type=image,src=alpine,dst=/mnt/foo,rw",
+			err:     "image mounts are always read-only",
+		},
+		{
+			rawSpec: "type=volume,src=TestVolume,dst=/mnt/foo,volume-subpath=../escape",
+			err:     "must be a relative path",
+		},
+		{
+			rawSpec: "type=bind,src=/mnt/foo,dst=/mnt/foo,volume-subpath=dir",
+			err:     "volume-subpath is only supported for volume mounts",
+		},
+		{
+			rawSpec: "type=volume,dst=/mnt/foo,volume-subpath=dir",
+			err:     "volume-subpath requires a named volume",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rawSpec, func(t *testing.T) {
+			processed, err := mountutil.ProcessFlagMount(tt.rawSpec, MckVolStore)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.Equal(t, processed.Type, tt.wants.Type)
+			assert.Equal(t, processed.Name, tt.wants.Name)
+			assert.Equal(t, processed.Mode, tt.wants.Mode)
+			assert.Equal(t, processed.Subpath, tt.wants.Subpath)
+			assert.Equal(t, processed.Mount.Destination, tt.wants.Mount.Destination)
+			assert.Equal(t, processed.Mount.Source, tt.wants.Mount.Source)
+		})
+	}
+}
@@ -0,0 +1,266 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package volumestore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/containerd/log"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+	"go.farcloser.world/lepton/pkg/labels"
+)
+
+// Subpath is a directory of a volume mounted in a container instead of the whole volume.
+// The container spec mounts Source, a directory of the container state directory on which MountContainerVolumes pins
+// the volume directory when the task starts. Resolving the directory any earlier would let a symlink swapped in the
+// volume in the meantime point the mount anywhere on the host.
+type Subpath struct {
+	Name    string `json:"name"`
+	Subpath string `json:"subpath"`
+	Source  string `json:"source"`
+}
+
+// SubpathSource returns the directory of the container state directory where the subpath with the given index is
+// pinned.
+func SubpathSource(stateDir string, index int) string {
+	return filepath.Join(stateDir, "subpaths", strconv.Itoa(index))
+}
+
+// MountContainerVolumes mounts the volumes with options used by a container, and pins its volume subpaths.
+// It is meant to be called right before creating the container task, as mounts performed afterward would not be
+// visible inside the container. UnmountContainerVolumes undoes it once the task stops.
+// containerLabels may be either the container labels, or the OCI annotations propagated from them.
+func MountContainerVolumes(containerID string, containerLabels map[string]string) error {
+	names := containerVolumeNames(containerLabels)
+	if len(names) == 0 {
+		return nil
+	}
+
+	volStore, err := containerVolumeStore(containerLabels)
+	if err != nil {
+		return err
+	}
+
+	var mounted []string
+	for _, name := range names {
+		if err = volStore.Mount(name, containerID); err != nil {
+			// A volume gone from under us has nothing to mount - let the runtime report the missing source
+			if errors.Is(err, errs.ErrNotFound) {
+				log.L.WithError(err).Warnf("volume %q not found", name)
+				continue
+			}
+
+			for _, prev := range mounted {
+				if unmountErr := volStore.Unmount(prev, containerID); unmountErr != nil {
+					log.L.WithError(unmountErr).Errorf("failed releasing volume %q", prev)
+				}
+			}
+
+			return err
+		}
+
+		mounted = append(mounted, name)
+	}
+
+	subpaths := containerVolumeSubpaths(containerLabels)
+	for i, subpath := range subpaths {
+		if err = pinSubpath(volStore, subpath); err != nil {
+			for _, prev := range subpaths[:i] {
+				if unpinErr := unpinDirectory(prev.Source); unpinErr != nil {
+					log.L.WithError(unpinErr).Errorf("failed releasing volume subpath %q", prev.Source)
+				}
+			}
+
+			for _, prev := range mounted {
+				if unmountErr := volStore.Unmount(prev, containerID); unmountErr != nil {
+					log.L.WithError(unmountErr).Errorf("failed releasing volume %q", prev)
+				}
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UnmountContainerVolumes releases the volumes used by a container, unmounting the ones it was the last user of.
+func UnmountContainerVolumes(containerID string, containerLabels map[string]string) error {
+	names := containerVolumeNames(containerLabels)
+	if len(names) == 0 {
+		return nil
+	}
+
+	volStore, err := containerVolumeStore(containerLabels)
+	if err != nil {
+		return err
+	}
+
+	// Subpaths are unpinned first, as they may keep the volume mounts busy
+	errList := []error{UnpinContainerSubpaths(containerLabels)}
+	for _, name := range names {
+		if err = volStore.Unmount(name, containerID); err != nil && !errors.Is(err, errs.ErrNotFound) {
+			errList = append(errList, err)
+		}
+	}
+
+	return errors.Join(errList...)
+}
+
+// UnpinContainerSubpaths unmounts the volume subpaths pinned for a container, and removes their directory, so that a
+// restart not going through MountContainerVolumes fails instead of mounting an empty directory.
+// It must be called before removing the state directory of a container, lest it removes the content of the volumes.
+func UnpinContainerSubpaths(containerLabels map[string]string) error {
+	var errList []error
+	for _, subpath := range containerVolumeSubpaths(containerLabels) {
+		if err := unpinDirectory(subpath.Source); err != nil {
+			errList = append(errList, err)
+		}
+	}
+
+	return errors.Join(errList...)
+}
+
+// CheckRestartPolicy returns an error if any of the named volumes is mounted on use, or if any volume subpath is used,
+// and the restart policy is not "no".
+// These are mounted by run and start right before the task is created, which the containerd restart monitor
+// does not do when it restarts a container on its own, including after a reboot. They cannot be mounted by the OCI
+// hooks either: by the time createRuntime hooks run, the mounts of the container are already set up.
+// volStore must be locked.
+func CheckRestartPolicy(volStore VolumeService, names []string, subpaths []Subpath, policy string) error {
+	if policy == "" || policy == "no" {
+		return nil
+	}
+
+	if len(subpaths) > 0 {
+		return fmt.Errorf("%w: subpath %q of volume %q is only mounted when the container is started explicitly, "+
+			"restart policy %q is not supported",
+			errs.ErrInvalidArgument, subpaths[0].Subpath, subpaths[0].Name, policy)
+	}
+
+	for _, name := range names {
+		mounted, err := volStore.MountedOnUseWithoutLock(name)
+		if err != nil {
+			if errors.Is(err, errs.ErrNotFound) {
+				continue
+			}
+
+			return err
+		}
+
+		if mounted {
+			return fmt.Errorf("%w: volume %q is only mounted when the container is started explicitly, "+
+				"restart policy %q is not supported", errs.ErrInvalidArgument, name, policy)
+		}
+	}
+
+	return nil
+}
+
+// CheckContainerRestartPolicy is CheckRestartPolicy for the volumes used by an existing container.
+func CheckContainerRestartPolicy(containerLabels map[string]string, policy string) error {
+	names := containerVolumeNames(containerLabels)
+	if len(names) == 0 || policy == "" || policy == "no" {
+		return nil
+	}
+
+	volStore, err := containerVolumeStore(containerLabels)
+	if err != nil {
+		return err
+	}
+
+	if err = volStore.Lock(); err != nil {
+		return err
+	}
+	defer volStore.Release()
+
+	return CheckRestartPolicy(volStore, names, containerVolumeSubpaths(containerLabels), policy)
+}
+
+// pinSubpath resolves a volume subpath within its volume, and bind mounts it on its source.
+func pinSubpath(volStore VolumeService, subpath Subpath) error {
+	vol, err := volStore.Get(subpath.Name, false)
+	if err != nil {
+		return err
+	}
+
+	if err = pinDirectory(vol.Mountpoint, subpath.Subpath, subpath.Source); err != nil {
+		return fmt.Errorf("failed mounting subpath %q of volume %q: %w", subpath.Subpath, subpath.Name, err)
+	}
+
+	return nil
+}
+
+// containerVolumeSubpaths returns the volume subpaths listed in the volume subpaths label of a container.
+func containerVolumeSubpaths(containerLabels map[string]string) []Subpath {
+	subpathsJSON := containerLabels[labels.VolumeSubpaths]
+	if subpathsJSON == "" {
+		return nil
+	}
+
+	var subpaths []Subpath
+	if err := json.Unmarshal([]byte(subpathsJSON), &subpaths); err != nil {
+		log.L.WithError(err).Warn("failed parsing container volume subpaths")
+		return nil
+	}
+
+	return subpaths
+}
+
+// containerVolumeNames returns the named volumes listed in the mounts label of a container.
+func containerVolumeNames(containerLabels map[string]string) []string {
+	mountsJSON := containerLabels[labels.Mounts]
+	if mountsJSON == "" {
+		return nil
+	}
+
+	var mounts []struct {
+		Type string `json:",omitempty"`
+		Name string `json:",omitempty"`
+	}
+	if err := json.Unmarshal([]byte(mountsJSON), &mounts); err != nil {
+		log.L.WithError(err).Warn("failed parsing container mounts")
+		return nil
+	}
+
+	var names []string
+	for _, m := range mounts {
+		if m.Type == "volume" && m.Name != "" && !slices.Contains(names, m.Name) {
+			names = append(names, m.Name)
+		}
+	}
+
+	return names
+}
+
+// containerVolumeStore opens the volume store of the namespace of a container.
+func containerVolumeStore(containerLabels map[string]string) (VolumeService, error) {
+	stateDir := containerLabels[labels.StateDir]
+	namespace := containerLabels[labels.Namespace]
+	if stateDir == "" || namespace == "" {
+		return nil, fmt.Errorf("%w: container is missing its state directory or namespace", errs.ErrInvalidArgument)
+	}
+
+	// StateDir is "<DATASTORE>/containers/<NAMESPACE>/<ID>"
+	return New(filepath.Dir(filepath.Dir(filepath.Dir(stateDir))), namespace)
+}
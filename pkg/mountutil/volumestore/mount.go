@@ -0,0 +1,228 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package volumestore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/containerd/log"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+	"go.farcloser.world/lepton/leptonic/identifiers"
+)
+
+const (
+	// OptionType is the filesystem type to mount on the volume (eg: nfs, cifs, tmpfs, ext4)
+	OptionType = "type"
+	// OptionDevice is the device, remote share, or pseudo device to mount (eg: "host:/export", "/dev/sdb1", "tmpfs")
+	OptionDevice = "device"
+	// OptionMount is the comma separated list of mount options (eg: "addr=10.0.0.1,rw,nfsvers=4")
+	OptionMount = "o"
+	// OptionSize is the maximum size of the volume (eg: "10G"), enforced with a project quota or a loopback image
+	OptionSize = "size"
+)
+
+// ValidateOptions checks volume options against the semantics of the docker `local` driver:
+// only type, device and o are known, and type and device must be set together.
+// Alternatively, size alone limits the size of the volume.
+func ValidateOptions(options map[string]string) error {
+	if len(options) == 0 {
+		return nil
+	}
+
+	if size, ok := options[OptionSize]; ok {
+		if len(options) > 1 {
+			return fmt.Errorf("%w: volume option %q cannot be combined with other options (for tmpfs, use %q)",
+				errs.ErrInvalidArgument, OptionSize, "o=size=...")
+		}
+
+		_, err := parseSize(size)
+
+		return err
+	}
+
+	for key := range options {
+		switch key {
+		case OptionType, OptionDevice, OptionMount:
+		default:
+			return fmt.Errorf("%w: unknown volume option %q", errs.ErrInvalidArgument, key)
+		}
+	}
+
+	for _, key := range []string{OptionType, OptionDevice} {
+		if options[key] == "" {
+			return fmt.Errorf("%w: missing required volume option %q", errs.ErrInvalidArgument, key)
+		}
+	}
+
+	return nil
+}
+
+func (vs *volumeStore) Mount(name, containerID string) (err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(ErrServiceVolume, err)
+		}
+	}()
+
+	if err = identifiers.Validate(name); err != nil {
+		return err
+	}
+
+	return vs.Locker.WithLock(func() error {
+		content, err := vs.manager.Get(name, volumeJSONFileName)
+		if err != nil {
+			return err
+		}
+
+		options, err := vs.rawMountOptions(name, parseVolumeJSON(content))
+		if err != nil || len(options) == 0 {
+			return err
+		}
+
+		target, err := vs.manager.Location(name, dataDirName)
+		if err != nil {
+			return err
+		}
+
+		users, err := vs.rawUsers(name)
+		if err != nil {
+			return err
+		}
+
+		// Users may be stale if the host rebooted while the volume was mounted, so, trust the mount table instead
+		if !isMountpoint(target) {
+			log.L.Debugf("mounting volume %q (type %s) for %s", name, options[OptionType], containerID)
+			if err = mountVolume(options, target); err != nil {
+				return fmt.Errorf("failed mounting volume %q: %w", name, err)
+			}
+			users = nil
+		}
+
+		if !slices.Contains(users, containerID) {
+			users = append(users, containerID)
+		}
+
+		return vs.rawSetUsers(name, users)
+	})
+}
+
+func (vs *volumeStore) Unmount(name, containerID string) (err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(ErrServiceVolume, err)
+		}
+	}()
+
+	if err = identifiers.Validate(name); err != nil {
+		return err
+	}
+
+	return vs.Locker.WithLock(func() error {
+		if doesExist, err := vs.manager.Exists(name, usersJSONFileName); err != nil || !doesExist {
+			return err
+		}
+
+		users, err := vs.rawUsers(name)
+		if err != nil {
+			return err
+		}
+
+		users = slices.DeleteFunc(users, func(user string) bool {
+			return user == containerID
+		})
+
+		if len(users) > 0 {
+			return vs.rawSetUsers(name, users)
+		}
+
+		target, err := vs.manager.Location(name, dataDirName)
+		if err != nil {
+			return err
+		}
+
+		if isMountpoint(target) {
+			log.L.Debugf("unmounting volume %q, last used by %s", name, containerID)
+			if err = unmountVolume(target); err != nil {
+				return fmt.Errorf("failed unmounting volume %q: %w", name, err)
+			}
+		}
+
+		return vs.manager.Delete(name, usersJSONFileName)
+	})
+}
+
+func (vs *volumeStore) MountedOnUseWithoutLock(name string) (mounted bool, err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(ErrServiceVolume, err)
+		}
+	}()
+
+	if err = identifiers.Validate(name); err != nil {
+		return false, err
+	}
+
+	content, err := vs.manager.Get(name, volumeJSONFileName)
+	if err != nil {
+		return false, err
+	}
+
+	options, err := vs.rawMountOptions(name, parseVolumeJSON(content))
+
+	return len(options) > 0, err
+}
+
+func (vs *volumeStore) rawIsMounted(name string) bool {
+	target, err := vs.manager.Location(name, dataDirName)
+	if err != nil {
+		return false
+	}
+
+	return isMountpoint(target)
+}
+
+func (vs *volumeStore) rawUsers(name string) ([]string, error) {
+	var users []string
+
+	if doesExist, err := vs.manager.Exists(name, usersJSONFileName); err != nil || !doesExist {
+		return nil, err
+	}
+
+	content, err := vs.manager.Get(name, usersJSONFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(content, &users); err != nil {
+		return nil, errors.Join(errs.ErrSystemFailure, err)
+	}
+
+	return users, nil
+}
+
+func (vs *volumeStore) rawSetUsers(name string, users []string) error {
+	content, err := json.Marshal(users)
+	if err != nil {
+		return err
+	}
+
+	return vs.manager.Set(content, name, usersJSONFileName)
+}
@@ -0,0 +1,122 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package volumestore
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/containerd/v2/core/mount"
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/moby/sys/mountinfo"
+	"golang.org/x/sys/unix"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+)
+
+func mountVolume(options map[string]string, target string) error {
+	var mountOptions []string
+	for _, opt := range strings.Split(options[OptionMount], ",") {
+		if opt == "" {
+			continue
+		}
+
+		// The kernel does not resolve names for network filesystems - this is usually done by mount helpers.
+		if addr, ok := strings.CutPrefix(opt, "addr="); ok {
+			switch options[OptionType] {
+			case "nfs", "nfs4", "cifs", "smb3":
+				ipAddr, err := net.ResolveIPAddr("ip", addr)
+				if err != nil {
+					return err
+				}
+				opt = "addr=" + ipAddr.String()
+			}
+		}
+
+		mountOptions = append(mountOptions, opt)
+	}
+
+	m := mount.Mount{
+		Type:    options[OptionType],
+		Source:  options[OptionDevice],
+		Options: mountOptions,
+	}
+
+	return m.Mount(target)
+}
+
+func unmountVolume(target string) error {
+	return mount.UnmountAll(target, 0)
+}
+
+func isMountpoint(target string) bool {
+	mounted, err := mountinfo.Mounted(target)
+	return err == nil && mounted
+}
+
+// pinDirectory bind mounts the directory subpath of root on target.
+// The directory is opened without following symlinks out of root, and mounted through its file descriptor, so that
+// what gets mounted is what was resolved, even if a component of subpath is swapped in the meantime.
+func pinDirectory(root, subpath, target string) error {
+	dir, err := securejoin.OpenInRoot(root, subpath)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	var st unix.Stat_t
+	if err = unix.Fstat(int(dir.Fd()), &st); err != nil {
+		return err
+	}
+
+	if st.Mode&unix.S_IFMT != unix.S_IFDIR {
+		return fmt.Errorf("%w: %q is not a directory", errs.ErrInvalidArgument, subpath)
+	}
+
+	if err = os.MkdirAll(target, 0o700); err != nil {
+		return err
+	}
+
+	// Left over by a task that did not go through UnmountContainerVolumes
+	if isMountpoint(target) {
+		if err = unix.Unmount(target, unix.MNT_DETACH); err != nil {
+			return err
+		}
+	}
+
+	return unix.Mount("/proc/self/fd/"+strconv.Itoa(int(dir.Fd())), target, "", unix.MS_BIND|unix.MS_REC, "")
+}
+
+// unpinDirectory undoes pinDirectory, and removes target.
+func unpinDirectory(target string) error {
+	if isMountpoint(target) {
+		if err := unix.Unmount(target, unix.MNT_DETACH); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package volumestore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+)
+
+func TestPinDirectory(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("must be superuser to bind mount")
+	}
+
+	root := t.TempDir()
+	volume := filepath.Join(root, "volume")
+	outside := filepath.Join(root, "outside")
+	assert.NilError(t, os.MkdirAll(filepath.Join(volume, "sub", "dir"), 0o755))
+	assert.NilError(t, os.WriteFile(filepath.Join(volume, "sub", "dir", "file"), []byte("inside"), 0o644))
+	assert.NilError(t, os.WriteFile(filepath.Join(volume, "regular"), nil, 0o644))
+	assert.NilError(t, os.MkdirAll(outside, 0o755))
+	assert.NilError(t, os.WriteFile(filepath.Join(outside, "file"), []byte("outside"), 0o644))
+	assert.NilError(t, os.Symlink(outside, filepath.Join(volume, "escape")))
+	assert.NilError(t, os.Symlink("../..", filepath.Join(volume, "sub", "up")))
+
+	target := filepath.Join(root, "state", "subpaths", "0")
+
+	assert.NilError(t, pinDirectory(volume, "sub/dir", target))
+	content, err := os.ReadFile(filepath.Join(target, "file"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "inside")
+
+	// Swapping the directory for a symlink once pinned does not change what is mounted
+	assert.NilError(t, os.Rename(filepath.Join(volume, "sub", "dir"), filepath.Join(volume, "sub", "moved")))
+	assert.NilError(t, os.Symlink(outside, filepath.Join(volume, "sub", "dir")))
+	content, err = os.ReadFile(filepath.Join(target, "file"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "inside")
+
+	assert.NilError(t, unpinDirectory(target))
+	_, err = os.Stat(target)
+	assert.Assert(t, errors.Is(err, os.ErrNotExist))
+	assert.NilError(t, unpinDirectory(target))
+
+	// Symlinks are resolved within the volume
+	assert.Assert(t, pinDirectory(volume, "escape", target) != nil)
+	assert.Assert(t, pinDirectory(volume, "sub/dir", target) != nil)
+	assert.NilError(t, pinDirectory(volume, "sub/up", target))
+	_, err = os.Stat(filepath.Join(target, "regular"))
+	assert.NilError(t, err)
+	assert.NilError(t, unpinDirectory(target))
+
+	err = pinDirectory(volume, "regular", target)
+	assert.Assert(t, errors.Is(err, errs.ErrInvalidArgument))
+}
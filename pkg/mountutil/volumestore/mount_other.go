@@ -0,0 +1,45 @@
+//go:build !linux
+
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package volumestore
+
+import (
+	"fmt"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+)
+
+func mountVolume(_ map[string]string, _ string) error {
+	return fmt.Errorf("%w: volume options are only supported on linux", errs.ErrFailedPrecondition)
+}
+
+func unmountVolume(_ string) error {
+	return nil
+}
+
+func isMountpoint(_ string) bool {
+	return false
+}
+
+func pinDirectory(_, _, _ string) error {
+	return fmt.Errorf("%w: volume subpaths are only supported on linux", errs.ErrFailedPrecondition)
+}
+
+func unpinDirectory(_ string) error {
+	return nil
+}
@@ -0,0 +1,114 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package volumestore_test
+
+import (
+	"errors"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+	"go.farcloser.world/lepton/pkg/mountutil/volumestore"
+)
+
+func TestValidateOptions(t *testing.T) {
+	t.Parallel()
+
+	valid := []map[string]string{
+		nil,
+		{"type": "tmpfs", "device": "tmpfs", "o": "size=100m"},
+		{"type": "nfs", "device": ":/export", "o": "addr=10.0.0.1,rw"},
+		{"type": "ext4", "device": "/dev/sdb1"},
+		{"size": "10G"},
+		{"size": "512m"},
+	}
+	for _, opts := range valid {
+		assert.NilError(t, volumestore.ValidateOptions(opts))
+	}
+
+	invalid := []map[string]string{
+		{"type": "tmpfs"},
+		{"device": "/dev/sdb1"},
+		{"o": "rw"},
+		{"type": "tmpfs", "device": "tmpfs", "size": "100m"},
+		{"size": "0"},
+		{"size": "lots"},
+		{"size": ""},
+	}
+	for _, opts := range invalid {
+		err := volumestore.ValidateOptions(opts)
+		assert.Assert(t, errors.Is(err, errs.ErrInvalidArgument), "%v", opts)
+	}
+}
+
+func TestCreateWithOptions(t *testing.T) {
+	t.Parallel()
+
+	volStore, err := volumestore.New(t.TempDir(), "test")
+	assert.NilError(t, err)
+
+	opts := map[string]string{"type": "tmpfs", "device": "tmpfs", "o": "size=10m"}
+	_, err = volStore.Create("withopts", map[string]string{"foo": "bar"}, opts)
+	assert.NilError(t, err)
+
+	vol, err := volStore.Get("withopts", false)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, vol.Options, opts)
+	assert.DeepEqual(t, vol.Labels, map[string]string{"foo": "bar"})
+
+	_, err = volStore.Create("invalid", nil, map[string]string{"type": "tmpfs"})
+	assert.Assert(t, errors.Is(err, errs.ErrInvalidArgument))
+
+	// Plain volumes are never mounted
+	_, err = volStore.Create("plain", map[string]string{}, nil)
+	assert.NilError(t, err)
+	assert.NilError(t, volStore.Mount("plain", "container"))
+	assert.NilError(t, volStore.Unmount("plain", "container"))
+}
+
+func TestCheckRestartPolicy(t *testing.T) {
+	t.Parallel()
+
+	volStore, err := volumestore.New(t.TempDir(), "test")
+	assert.NilError(t, err)
+
+	_, err = volStore.Create("withopts", nil, map[string]string{"type": "tmpfs", "device": "tmpfs"})
+	assert.NilError(t, err)
+	_, err = volStore.Create("plain", nil, nil)
+	assert.NilError(t, err)
+
+	assert.NilError(t, volStore.Lock())
+	defer volStore.Release()
+
+	for _, policy := range []string{"", "no"} {
+		assert.NilError(t, volumestore.CheckRestartPolicy(volStore, []string{"plain", "withopts"}, nil, policy))
+		assert.NilError(t, volumestore.CheckRestartPolicy(volStore, []string{"plain"},
+			[]volumestore.Subpath{{Name: "plain", Subpath: "dir"}}, policy))
+	}
+
+	for _, policy := range []string{"always", "unless-stopped", "on-failure:3"} {
+		assert.NilError(t, volumestore.CheckRestartPolicy(volStore, []string{"plain", "missing"}, nil, policy))
+
+		err = volumestore.CheckRestartPolicy(volStore, []string{"plain", "withopts"}, nil, policy)
+		assert.Assert(t, errors.Is(err, errs.ErrInvalidArgument), policy)
+
+		err = volumestore.CheckRestartPolicy(volStore, []string{"plain"},
+			[]volumestore.Subpath{{Name: "plain", Subpath: "dir"}}, policy)
+		assert.Assert(t, errors.Is(err, errs.ErrInvalidArgument), policy)
+	}
+}
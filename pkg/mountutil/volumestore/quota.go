@@ -0,0 +1,144 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package volumestore
+
+import (
+	"fmt"
+
+	"github.com/containerd/log"
+	"github.com/docker/go-units"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+)
+
+const (
+	quotaProject  = "project"
+	quotaLoopback = "loopback"
+
+	// loopbackImageName is the ext4 image backing a volume whose size cannot be limited with a project quota
+	loopbackImageName = "disk.img"
+)
+
+// quotaJSON describes how the size of a volume is limited
+type quotaJSON struct {
+	// Type is either a project quota on the data directory, or a loopback image mounted on it
+	Type    string `json:"type"`
+	Project uint32 `json:"project,omitempty"`
+	Limit   int64  `json:"limit"`
+}
+
+func parseSize(size string) (int64, error) {
+	limit, err := units.RAMInBytes(size)
+	if err != nil || limit <= 0 {
+		return 0, fmt.Errorf("%w: invalid volume size %q", errs.ErrInvalidArgument, size)
+	}
+
+	return limit, nil
+}
+
+func loopbackOptions(image string) map[string]string {
+	return map[string]string{
+		OptionType:   "ext4",
+		OptionDevice: image,
+		OptionMount:  "loop",
+	}
+}
+
+// rawSetupQuota creates the data directory of a new volume and limits its size, with a project quota if the data root
+// supports it (XFS, or ext4 with the project quota feature), or with a loopback image otherwise.
+// On failure, the volume is removed.
+func (vs *volumeStore) rawSetupQuota(name, size string) (quota *quotaJSON, err error) {
+	limit, err := parseSize(size)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if deleteErr := vs.manager.Delete(name); deleteErr != nil {
+				log.L.WithError(deleteErr).Errorf("failed cleaning up volume %q", name)
+			}
+		}
+	}()
+
+	if err = vs.manager.GroupEnsure(name, dataDirName); err != nil {
+		return nil, err
+	}
+
+	dataDir, err := vs.manager.Location(name, dataDirName)
+	if err != nil {
+		return nil, err
+	}
+
+	project, err := setProjectQuota(vs.root, dataDir, limit)
+	if err == nil {
+		return &quotaJSON{Type: quotaProject, Project: project, Limit: limit}, nil
+	}
+
+	log.L.WithError(err).Debugf("cannot use a project quota for volume %q, using a loopback image instead", name)
+
+	image, err := vs.manager.Location(name, loopbackImageName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = createLoopbackImage(image, limit); err != nil {
+		return nil, fmt.Errorf("failed limiting the size of volume %q: %w", name, err)
+	}
+
+	return &quotaJSON{Type: quotaLoopback, Limit: limit}, nil
+}
+
+// rawUsage returns the number of bytes used in a volume with a size limit.
+func (vs *volumeStore) rawUsage(name string, quota *quotaJSON) (int64, error) {
+	switch quota.Type {
+	case quotaProject:
+		return projectUsage(vs.root, quota.Project)
+	case quotaLoopback:
+		image, err := vs.manager.Location(name, loopbackImageName)
+		if err != nil {
+			return 0, err
+		}
+
+		dataDir, err := vs.manager.Location(name, dataDirName)
+		if err != nil {
+			return 0, err
+		}
+
+		return loopbackUsage(image, dataDir)
+	default:
+		return 0, fmt.Errorf("%w: unknown quota type %q", errs.ErrSystemFailure, quota.Type)
+	}
+}
+
+// rawMountOptions returns what to mount on the data directory of a volume, if anything.
+func (vs *volumeStore) rawMountOptions(name string, vo *volumeJSON) (map[string]string, error) {
+	if vo.Quota != nil && vo.Quota.Type == quotaLoopback {
+		image, err := vs.manager.Location(name, loopbackImageName)
+		if err != nil {
+			return nil, err
+		}
+
+		return loopbackOptions(image), nil
+	}
+
+	if vo.Options[OptionType] == "" {
+		return nil, nil
+	}
+
+	return vo.Options, nil
+}
@@ -0,0 +1,340 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package volumestore
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"go.farcloser.world/core/filesystem"
+)
+
+// Project quotas are managed through the XFS quota interface, which the kernel also implements for ext4.
+// See linux/fs.h and linux/dqblk_xfs.h.
+const (
+	iocNRBits   = 8
+	iocTypeBits = 8
+	iocSizeMask = 0x1fff
+
+	fsXflagProjinherit = 0x00000200
+
+	qXGetQuota = ('X' << 8) + 3
+	qXSetQLim  = ('X' << 8) + 4
+	prjQuota   = 2
+
+	fsDquotVersion = 1
+	fsProjQuota    = 2
+	fsDqBSoft      = 1 << 2
+	fsDqBHard      = 1 << 3
+
+	// Quotas are expressed in basic blocks of 512 bytes
+	basicBlockSize = 512
+
+	// backingFsBlockDev is the block device node created for quotactl, next to the namespaces of the volume store
+	backingFsBlockDev = ".backingFsBlockDev"
+)
+
+// fsxattr is struct fsxattr from linux/fs.h
+type fsxattr struct {
+	xflags     uint32
+	extsize    uint32
+	nextents   uint32
+	projid     uint32
+	cowextsize uint32
+	pad        [8]byte
+}
+
+// fsDiskQuota is struct fs_disk_quota from linux/dqblk_xfs.h
+type fsDiskQuota struct {
+	version      int8
+	flags        int8
+	fieldmask    uint16
+	id           uint32
+	blkHardlimit uint64
+	blkSoftlimit uint64
+	inoHardlimit uint64
+	inoSoftlimit uint64
+	bcount       uint64
+	icount       uint64
+	itimer       int32
+	btimer       int32
+	iwarns       uint16
+	bwarns       uint16
+	itimerHi     int8
+	btimerHi     int8
+	rtbtimerHi   int8
+	padding2     int8
+	rtbHardlimit uint64
+	rtbSoftlimit uint64
+	rtbcount     uint64
+	rtbtimer     int32
+	rtbwarns     uint16
+	padding3     int16
+	padding4     [8]byte
+}
+
+var (
+	// The direction bits of ioctl requests are architecture specific: derive them from FS_IOC_GETFLAGS and
+	// FS_IOC_SETFLAGS, which are respectively _IOR('f', 1, long) and _IOW('f', 2, long).
+	iocRead  = uint(unix.FS_IOC_GETFLAGS) &^ (iocSizeMask<<(iocNRBits+iocTypeBits) | 'f'<<iocNRBits | 1)
+	iocWrite = uint(unix.FS_IOC_SETFLAGS) &^ (iocSizeMask<<(iocNRBits+iocTypeBits) | 'f'<<iocNRBits | 2)
+
+	// FS_IOC_FSGETXATTR is _IOR('X', 31, struct fsxattr), FS_IOC_FSSETXATTR is _IOW('X', 32, struct fsxattr)
+	fsIocFsgetxattr = iocRead | uint(unsafe.Sizeof(fsxattr{}))<<(iocNRBits+iocTypeBits) | 'X'<<iocNRBits | 31
+	fsIocFssetxattr = iocWrite | uint(unsafe.Sizeof(fsxattr{}))<<(iocNRBits+iocTypeBits) | 'X'<<iocNRBits | 32
+)
+
+// setProjectQuota assigns a new project ID to dir, and limits the project to limit bytes.
+// root is the directory holding all volume namespaces, used to allocate a project ID not used by another volume.
+func setProjectQuota(root, dir string, limit int64) (uint32, error) {
+	// Project IDs are unique across namespaces: hold the lock of root until dir has its own
+	var project uint32
+	err := filesystem.WithLock(root, func() error {
+		var err error
+		if project, err = nextProjectID(root); err != nil {
+			return err
+		}
+
+		attr, err := getFsxattr(dir)
+		if err != nil {
+			return err
+		}
+
+		attr.projid = project
+		attr.xflags |= fsXflagProjinherit
+
+		return setFsxattr(dir, attr)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	blocks := uint64(limit) / basicBlockSize //nolint:gosec // limit is validated to be positive
+	quota := fsDiskQuota{
+		version:      fsDquotVersion,
+		flags:        fsProjQuota,
+		fieldmask:    fsDqBSoft | fsDqBHard,
+		id:           project,
+		blkHardlimit: blocks,
+		blkSoftlimit: blocks,
+	}
+
+	if err = quotactl(root, qXSetQLim, project, &quota); err != nil {
+		return 0, err
+	}
+
+	return project, nil
+}
+
+// projectUsage returns the number of bytes used by a project.
+func projectUsage(root string, project uint32) (int64, error) {
+	var quota fsDiskQuota
+	if err := quotactl(root, qXGetQuota, project, &quota); err != nil {
+		return 0, err
+	}
+
+	return int64(quota.bcount * basicBlockSize), nil //nolint:gosec // block counts cannot overflow
+}
+
+// nextProjectID returns a project ID higher than the ones of all existing volumes, and of root itself, so that
+// administrators can reserve a range of project IDs by setting the project ID of root.
+// The lock of root must be held.
+func nextProjectID(root string) (uint32, error) {
+	attr, err := getFsxattr(root)
+	if err != nil {
+		return 0, err
+	}
+
+	highest := attr.projid
+	dirs, err := filepath.Glob(filepath.Join(root, "*", "*", dataDirName))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, dir := range dirs {
+		if attr, err = getFsxattr(dir); err == nil && attr.projid > highest {
+			highest = attr.projid
+		}
+	}
+
+	return highest + 1, nil
+}
+
+func getFsxattr(dir string) (*fsxattr, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var attr fsxattr
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, f.Fd(), uintptr(fsIocFsgetxattr), uintptr(unsafe.Pointer(&attr)))
+	if errno != 0 {
+		return nil, fmt.Errorf("failed getting project ID of %q: %w", dir, errno)
+	}
+
+	return &attr, nil
+}
+
+func setFsxattr(dir string, attr *fsxattr) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, f.Fd(), uintptr(fsIocFssetxattr), uintptr(unsafe.Pointer(attr)))
+	if errno != 0 {
+		return fmt.Errorf("failed setting project ID of %q: %w", dir, errno)
+	}
+
+	return nil
+}
+
+// quotactl runs a project quota command against the filesystem holding root.
+func quotactl(root string, cmd int, project uint32, quota *fsDiskQuota) error {
+	device, err := backingFsBlockDevice(root)
+	if err != nil {
+		return err
+	}
+
+	special, err := unix.BytePtrFromString(device)
+	if err != nil {
+		return err
+	}
+
+	_, _, errno := unix.Syscall6(unix.SYS_QUOTACTL, uintptr(cmd<<8|prjQuota), uintptr(unsafe.Pointer(special)),
+		uintptr(project), uintptr(unsafe.Pointer(quota)), 0, 0)
+	if errno != 0 {
+		return fmt.Errorf("quotactl failed (project quotas may not be enabled on %q): %w", root, errno)
+	}
+
+	return nil
+}
+
+// backingFsBlockDevice returns a block device node with the device number of the filesystem holding root, as quotactl
+// requires one. Like docker does, the node is created once, next to the namespaces of the volume store.
+func backingFsBlockDevice(root string) (string, error) {
+	var rootStat unix.Stat_t
+	if err := unix.Stat(root, &rootStat); err != nil {
+		return "", err
+	}
+
+	device := filepath.Join(root, backingFsBlockDev)
+	if isBlockDevice(device, &rootStat) {
+		return device, nil
+	}
+
+	// Missing, or stale (eg: the data root was moved to another filesystem)
+	err := filesystem.WithLock(root, func() error {
+		if isBlockDevice(device, &rootStat) {
+			return nil
+		}
+
+		_ = os.Remove(device)
+		//nolint:gosec // dev_t fits
+		if err := unix.Mknod(device, unix.S_IFBLK|0o600, int(rootStat.Dev)); err != nil {
+			return fmt.Errorf("failed creating the block device for quotas: %w", err)
+		}
+
+		return nil
+	})
+
+	return device, err
+}
+
+// isBlockDevice tells whether device is a block device node for the filesystem of rootStat.
+func isBlockDevice(device string, rootStat *unix.Stat_t) bool {
+	var st unix.Stat_t
+	if err := unix.Lstat(device, &st); err != nil {
+		return false
+	}
+
+	return st.Mode&unix.S_IFMT == unix.S_IFBLK && st.Rdev == rootStat.Dev
+}
+
+// createLoopbackImage creates a sparse ext4 image of limit bytes, mounted on volumes instead of using project quotas.
+func createLoopbackImage(image string, limit int64) (err error) {
+	mkfs, err := exec.LookPath("mkfs.ext4")
+	if err != nil {
+		return errors.Join(errors.New("mkfs.ext4 is required for size limits without project quotas"), err)
+	}
+
+	f, err := os.OpenFile(image, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	err = f.Truncate(limit)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// No blocks reserved for root: the whole size is available to the volume
+	if out, err := exec.Command(mkfs, "-q", "-F", "-m", "0", image).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed formatting %q: %w (%s)", image, err, strings.TrimSpace(string(out)))
+	}
+
+	// Volumes start empty: get rid of lost+found
+	dir, err := os.MkdirTemp(filepath.Dir(image), ".format-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(dir)
+
+	if err = mountVolume(loopbackOptions(image), dir); err != nil {
+		return err
+	}
+
+	err = os.Remove(filepath.Join(dir, "lost+found"))
+	if unmountErr := unmountVolume(dir); err == nil {
+		err = unmountErr
+	}
+
+	return err
+}
+
+// loopbackUsage returns the number of bytes used in a loopback image, mounted on dir.
+// If the image is not mounted, this is the space allocated on disk for the sparse image.
+func loopbackUsage(image, dir string) (int64, error) {
+	if isMountpoint(dir) {
+		var st unix.Statfs_t
+		if err := unix.Statfs(dir, &st); err != nil {
+			return 0, err
+		}
+
+		return int64(st.Blocks-st.Bfree) * st.Bsize, nil //nolint:gosec // block counts cannot overflow
+	}
+
+	var st unix.Stat_t
+	if err := unix.Stat(image, &st); err != nil {
+		return 0, err
+	}
+
+	return st.Blocks * basicBlockSize, nil
+}
@@ -0,0 +1,57 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package volumestore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+	"gotest.tools/v3/assert"
+)
+
+func TestBackingFsBlockDevice(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("must be superuser to create block device nodes")
+	}
+
+	root := t.TempDir()
+	device, err := backingFsBlockDevice(root)
+	assert.NilError(t, err)
+	assert.Equal(t, device, filepath.Join(root, backingFsBlockDev))
+
+	var rootStat, st unix.Stat_t
+	assert.NilError(t, unix.Stat(root, &rootStat))
+	assert.NilError(t, unix.Lstat(device, &st))
+	assert.Assert(t, isBlockDevice(device, &rootStat))
+
+	// The node is created once
+	_, err = backingFsBlockDevice(root)
+	assert.NilError(t, err)
+	var again unix.Stat_t
+	assert.NilError(t, unix.Lstat(device, &again))
+	assert.Equal(t, again.Ino, st.Ino)
+
+	// A stale node is replaced
+	assert.NilError(t, os.Remove(device))
+	assert.NilError(t, os.WriteFile(device, nil, 0o600))
+	assert.Assert(t, !isBlockDevice(device, &rootStat))
+	_, err = backingFsBlockDevice(root)
+	assert.NilError(t, err)
+	assert.Assert(t, isBlockDevice(device, &rootStat))
+}
@@ -0,0 +1,41 @@
+//go:build !linux
+
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package volumestore
+
+import (
+	"fmt"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+)
+
+func setProjectQuota(_, _ string, _ int64) (uint32, error) {
+	return 0, fmt.Errorf("%w: project quotas are only supported on linux", errs.ErrFailedPrecondition)
+}
+
+func projectUsage(_ string, _ uint32) (int64, error) {
+	return 0, fmt.Errorf("%w: project quotas are only supported on linux", errs.ErrFailedPrecondition)
+}
+
+func createLoopbackImage(_ string, _ int64) error {
+	return fmt.Errorf("%w: volume size limits are only supported on linux", errs.ErrFailedPrecondition)
+}
+
+func loopbackUsage(_, _ string) (int64, error) {
+	return 0, fmt.Errorf("%w: volume size limits are only supported on linux", errs.ErrFailedPrecondition)
+}
@@ -39,6 +39,7 @@ const (
 	volumeDirBasename  = "volumes"
 	dataDirName        = "_data"
 	volumeJSONFileName = "volume.json"
+	usersJSONFileName  = "users.json"
 )
 
 // ErrServiceVolume will wrap all errors here
@@ -48,7 +49,8 @@ type VolumeService interface {
 	// Create will either return an existing volume, or create a new one
 	// NOTE that different labels will NOT create a new volume if there is one by that name already,
 	// but instead return the existing one with the (possibly different) labels
-	Create(name string, labels map[string]string) (vol *api.Volume, err error)
+	// Options (see ValidateOptions) describe a filesystem to mount on the volume data directory when in use
+	Create(name string, labels, options map[string]string) (vol *api.Volume, err error)
 	// Remove one of more volumes
 	Remove(generator func() ([]string, []error, error)) (removed []string, warns []error, err error)
 	// Exists checks if a given volume exists
@@ -63,6 +65,14 @@ type VolumeService interface {
 	Prune(filter func(volumes []*api.Volume) ([]string, error)) (err error)
 	// Count returns the number of volumes
 	Count() (count int, err error)
+	// Mount records the container as a user of the volume, and mounts the volume if it has options and is not mounted
+	// already. This is a no-op for plain volumes.
+	Mount(name, containerID string) error
+	// Unmount removes the container from the volume users, and unmounts the volume if this was the last one.
+	Unmount(name, containerID string) error
+	// MountedOnUseWithoutLock tells whether the volume has a filesystem mounted on it while in use (see Mount).
+	// This method does NOT lock, and is meant to be used between `Lock` and `Release`.
+	MountedOnUseWithoutLock(name string) (bool, error)
 
 	// Lock: see store implementation
 	Lock() error
@@ -95,6 +105,7 @@ func New(dataStore, namespace string) (volStore VolumeService, err error) {
 	return &volumeStore{
 		Locker:  st,
 		manager: st,
+		root:    filepath.Join(dataStore, volumeDirBasename),
 	}, nil
 }
 
@@ -103,6 +114,8 @@ type volumeStore struct {
 	store.Locker
 
 	manager store.Manager
+	// root is the directory holding the volumes of all namespaces
+	root string
 }
 
 // Exists checks if a volume exists in the store
@@ -157,18 +170,22 @@ func (vs *volumeStore) CreateWithoutLock(name string, labels map[string]string)
 		}
 	}()
 
-	return vs.rawCreate(name, labels)
+	return vs.rawCreate(name, labels, nil)
 }
 
-func (vs *volumeStore) Create(name string, labels map[string]string) (vol *api.Volume, err error) {
+func (vs *volumeStore) Create(name string, labels, options map[string]string) (vol *api.Volume, err error) {
 	defer func() {
 		if err != nil {
 			err = errors.Join(ErrServiceVolume, err)
 		}
 	}()
 
+	if err = ValidateOptions(options); err != nil {
+		return nil, err
+	}
+
 	err = vs.Locker.WithLock(func() error {
-		vol, err = vs.rawCreate(name, labels)
+		vol, err = vs.rawCreate(name, labels, options)
 		return err
 	})
 
@@ -253,6 +270,7 @@ func (vs *volumeStore) Remove(
 
 			// Erroring on exists is a hard error
 			// !doesExist is a soft error
+			// Being mounted is a soft error
 			// Inability to delete is a hard error
 			if doesExist, err := vs.manager.Exists(name); err != nil {
 				return err
@@ -260,6 +278,9 @@ func (vs *volumeStore) Remove(
 				// TODO: see above
 				warns = append(warns, fmt.Errorf("volume %q: %w", name, errs.ErrNotFound))
 				continue
+			} else if vs.rawIsMounted(name) {
+				warns = append(warns, fmt.Errorf("volume %q is mounted: %w", name, errs.ErrFailedPrecondition))
+				continue
 			} else if err = vs.manager.Delete(name); err != nil {
 				return err
 			}
@@ -303,6 +324,12 @@ func (vs *volumeStore) Prune(filter func(vol []*api.Volume) ([]string, error)) (
 		}
 
 		for _, name := range toDelete {
+			// Never recursively delete the content of a filesystem mounted on a volume
+			if vs.rawIsMounted(name) {
+				log.L.Warnf("volume %q is mounted and will not be pruned", name)
+				continue
+			}
+
 			err = vs.manager.Delete(name)
 			if err != nil {
 				return err
@@ -319,9 +346,11 @@ func (vs *volumeStore) rawGet(name string, size bool) (vol *api.Volume, err erro
 		return nil, err
 	}
 
+	volOpts := parseVolumeJSON(content)
 	vol = &api.Volume{
-		Name:   name,
-		Labels: parseLabels(content),
+		Name:    name,
+		Labels:  volOpts.Labels,
+		Options: volOpts.Options,
 	}
 
 	vol.Mountpoint, err = vs.manager.Location(name, dataDirName)
@@ -329,7 +358,14 @@ func (vs *volumeStore) rawGet(name string, size bool) (vol *api.Volume, err erro
 		return nil, err
 	}
 
-	if size {
+	// The usage of volumes with a size limit is cheap to get, so, it is always reported
+	if volOpts.Quota != nil {
+		vol.Limit = volOpts.Quota.Limit
+		vol.Size, err = vs.rawUsage(name, volOpts.Quota)
+		if err != nil {
+			log.L.WithError(err).Warnf("failed reading volume usage for %q", name)
+		}
+	} else if size {
 		vol.Size, err = vs.manager.GroupSize(name, dataDirName)
 		if err != nil {
 			return nil, errors.Join(fmt.Errorf("failed reading volume size for %q", name), err)
@@ -339,11 +375,14 @@ func (vs *volumeStore) rawGet(name string, size bool) (vol *api.Volume, err erro
 	return vol, nil
 }
 
-func (vs *volumeStore) rawCreate(name string, lbls map[string]string) (vol *api.Volume, err error) {
+func (vs *volumeStore) rawCreate(name string, lbls, options map[string]string) (vol *api.Volume, err error) {
 	volOpts := struct {
-		Labels map[string]string `json:"labels"`
+		Labels  map[string]string `json:"labels"`
+		Options map[string]string `json:"options,omitempty"`
+		Quota   *quotaJSON        `json:"quota,omitempty"`
 	}{
-		Labels: lbls,
+		Labels:  lbls,
+		Options: options,
 	}
 
 	if name == "" {
@@ -355,15 +394,21 @@ func (vs *volumeStore) rawCreate(name string, lbls map[string]string) (vol *api.
 		return nil, err
 	}
 
-	// Failure here must exit, no need to clean-up
-	labelsJSON, err := json.MarshalIndent(volOpts, "", "    ")
-	if err != nil {
-		return nil, err
-	}
-
 	if doesExist, err := vs.manager.Exists(name, volumeJSONFileName); err != nil {
 		return nil, err
 	} else if !doesExist {
+		// The size limit is set on the data directory, before the volume is recorded
+		if limit := options[OptionSize]; limit != "" {
+			if volOpts.Quota, err = vs.rawSetupQuota(name, limit); err != nil {
+				return nil, err
+			}
+		}
+
+		labelsJSON, err := json.MarshalIndent(volOpts, "", "    ")
+		if err != nil {
+			return nil, err
+		}
+
 		if err = vs.manager.Set(labelsJSON, name, volumeJSONFileName); err != nil {
 			return nil, err
 		}
@@ -374,8 +419,13 @@ func (vs *volumeStore) rawCreate(name string, lbls map[string]string) (vol *api.
 
 	// At this point, we either have an existing volume, or created a new one successfully
 	vol = &api.Volume{
-		Name:   name,
-		Labels: lbls,
+		Name:    name,
+		Labels:  lbls,
+		Options: options,
+	}
+
+	if volOpts.Quota != nil {
+		vol.Limit = volOpts.Quota.Limit
 	}
 
 	if err = vs.manager.GroupEnsure(name, dataDirName); err != nil {
@@ -390,13 +440,16 @@ func (vs *volumeStore) rawCreate(name string, lbls map[string]string) (vol *api.
 }
 
 // Private helpers
-func parseLabels(b []byte) map[string]string {
-	type volumeOpts struct {
-		Labels map[string]string `json:"labels,omitempty"`
-	}
-	var vo volumeOpts
+type volumeJSON struct {
+	Labels  map[string]string `json:"labels,omitempty"`
+	Options map[string]string `json:"options,omitempty"`
+	Quota   *quotaJSON        `json:"quota,omitempty"`
+}
+
+func parseVolumeJSON(b []byte) *volumeJSON {
+	var vo volumeJSON
 	if err := json.Unmarshal(b, &vo); err != nil {
-		return nil
+		return &volumeJSON{}
 	}
-	return vo.Labels
+	return &vo
 }
@@ -66,6 +66,8 @@ type NameStore interface {
 	Release(name, id string) error
 	// Rename allows the container owning a specific name to change it to newName (if available)
 	Rename(oldName, id, newName string) error
+	// Owner returns the id of the container owning `name`
+	Owner(name string) (string, error)
 }
 
 type nameStore struct {
@@ -179,3 +181,24 @@ func (x *nameStore) Rename(oldName, id, newName string) (err error) {
 		return x.safeStore.Delete(oldName)
 	})
 }
+
+func (x *nameStore) Owner(name string) (id string, err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(ErrNameStore, err)
+		}
+	}()
+
+	if err = identifiers.Validate(name); err != nil {
+		return "", err
+	}
+
+	err = x.safeStore.WithLock(func() error {
+		var content []byte
+		content, err = x.safeStore.Get(name)
+		id = string(content)
+		return err
+	})
+
+	return id, err
+}
@@ -0,0 +1,82 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package netutil
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+	"go.farcloser.world/lepton/leptonic/store"
+)
+
+const (
+	// ipv6PoolDirBasename is the base name of /var/lib/nerdctl/<ADDRHASH>/ipv6-pool
+	ipv6PoolDirBasename = "ipv6-pool"
+	ipv6PoolKey         = "pool"
+	// ulaPrefixSize is the prefix length of a ULA prefix: fd00::/8, followed by a 40 bits random global ID
+	ulaPrefixSize = 48
+)
+
+// DefaultIPv6Pool returns the ULA prefix (RFC 4193) IPv6 subnets are allocated from, when no pool is configured.
+// The prefix is randomly generated the first time it is needed, so that it is unique to the host, and then persisted
+// in dataStore. The first subnet of the pool is used by the default network, when dual-stack.
+func DefaultIPv6Pool(dataStore string) (string, error) {
+	if dataStore == "" {
+		return "", fmt.Errorf("%w: no data store to persist the IPv6 pool in", errs.ErrInvalidArgument)
+	}
+
+	st, err := store.New(filepath.Join(dataStore, ipv6PoolDirBasename), false, 0, 0)
+	if err != nil {
+		return "", err
+	}
+
+	var pool string
+	err = st.WithLock(func() error {
+		data, err := st.Get(ipv6PoolKey)
+		if err == nil {
+			pool = string(data)
+			return nil
+		}
+		if !errors.Is(err, errs.ErrNotFound) {
+			return err
+		}
+
+		if pool, err = generateULAPrefix(rand.Reader); err != nil {
+			return err
+		}
+
+		return st.Set([]byte(pool), ipv6PoolKey)
+	})
+
+	return pool, err
+}
+
+// generateULAPrefix returns a /48 ULA prefix, with a global ID read from r.
+func generateULAPrefix(r io.Reader) (string, error) {
+	ip := make(net.IP, net.IPv6len)
+	ip[0] = 0xfd
+	if _, err := io.ReadFull(r, ip[1:ulaPrefixSize/8]); err != nil {
+		return "", errors.Join(errs.ErrSystemFailure, err)
+	}
+
+	return (&net.IPNet{IP: ip, Mask: net.CIDRMask(ulaPrefixSize, 128)}).String(), nil
+}
@@ -0,0 +1,67 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package netutil
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+)
+
+func TestGenerateULAPrefix(t *testing.T) {
+	t.Parallel()
+
+	pool, err := generateULAPrefix(bytes.NewReader([]byte{0x12, 0x34, 0x56, 0x78, 0x9a}))
+	assert.NilError(t, err)
+	assert.Equal(t, pool, "fd12:3456:789a::/48")
+
+	_, err = generateULAPrefix(bytes.NewReader([]byte{0x12}))
+	assert.ErrorContains(t, err, "EOF")
+}
+
+func TestDefaultIPv6Pool(t *testing.T) {
+	t.Parallel()
+
+	dataStore := t.TempDir()
+	pool, err := DefaultIPv6Pool(dataStore)
+	assert.NilError(t, err)
+
+	_, ipNet, err := net.ParseCIDR(pool)
+	assert.NilError(t, err)
+	assert.Equal(t, ipNet.String(), pool)
+	assert.Equal(t, ipNet.IP[0], byte(0xfd))
+	ones, _ := ipNet.Mask.Size()
+	assert.Equal(t, ones, ulaPrefixSize)
+
+	// The pool is persisted
+	again, err := DefaultIPv6Pool(dataStore)
+	assert.NilError(t, err)
+	assert.Equal(t, again, pool)
+
+	// and unique to the data store
+	other, err := DefaultIPv6Pool(t.TempDir())
+	assert.NilError(t, err)
+	assert.Assert(t, other != pool)
+
+	_, err = DefaultIPv6Pool("")
+	assert.Assert(t, errors.Is(err, errs.ErrInvalidArgument))
+}
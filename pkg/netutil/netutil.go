@@ -21,12 +21,15 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 
 	containerd "github.com/containerd/containerd/v2/client"
@@ -47,10 +50,29 @@ import (
 	"go.farcloser.world/lepton/pkg/version"
 )
 
+// IPv6SubnetSize is the prefix length of the IPv6 subnets allocated from the pool, when creating an IPv6 network
+// without an IPv6 `--subnet` option
+const IPv6SubnetSize = 64
+
 type CNIEnv struct {
 	Path        string
 	NetconfPath string
 	Namespace   string
+	// IPv6Pool is the prefix IPv6 subnets are allocated from, DefaultIPv6Pool of the data store if empty
+	IPv6Pool string
+	// AddressPools are the pools IPv4 subnets are allocated from, from StartingCIDR onwards if empty
+	AddressPools []AddressPool
+
+	// dataStore is where DefaultIPv6Pool is persisted
+	dataStore string
+}
+
+// AddressPool is a pool IPv4 subnets are allocated from.
+type AddressPool struct {
+	// Base is the prefix the subnets are allocated from, e.g. "10.200.0.0/16"
+	Base string
+	// Size is the prefix length of the allocated subnets, e.g. 24
+	Size int
 }
 
 type CNIEnvOpt func(e *CNIEnv) error
@@ -186,9 +208,41 @@ func namespaceUsedNetworks(ctx context.Context, containers []containerd.Containe
 	return used, nil
 }
 
-func WithDefaultNetwork(bridgeIP string) CNIEnvOpt {
+// WithDefaultNetwork ensures the default network exists, creating it dual-stack if ipv6 is set.
+// Its subnet is bridgeSubnet, or the one of bridgeIP, or DefaultCIDR.
+// WithIPv6Pool must be passed before it, for the default network to use the configured pool.
+func WithDefaultNetwork(bridgeIP, bridgeSubnet string, ipv6 bool) CNIEnvOpt {
+	return func(e *CNIEnv) error {
+		return e.ensureDefaultNetworkConfig(bridgeIP, bridgeSubnet, ipv6)
+	}
+}
+
+// WithAddressPools sets the pools IPv4 subnets are allocated from, by order of preference, when creating a network
+// without a subnet.
+func WithAddressPools(pools []AddressPool) CNIEnvOpt {
+	return func(e *CNIEnv) error {
+		e.AddressPools = pools
+		return nil
+	}
+}
+
+// WithIPv6Pool sets the prefix IPv6 subnets are allocated from. With an empty pool, the DefaultIPv6Pool of dataStore
+// is used, and generated when first needed.
+func WithIPv6Pool(pool, dataStore string) CNIEnvOpt {
 	return func(e *CNIEnv) error {
-		return e.ensureDefaultNetworkConfig(bridgeIP)
+		e.dataStore = dataStore
+		if pool == "" {
+			return nil
+		}
+		_, ipNet, err := net.ParseCIDR(pool)
+		if err != nil || ipNet.IP.To4() != nil {
+			return fmt.Errorf("%w: invalid IPv6 pool %q", errs.ErrInvalidArgument, pool)
+		}
+		if ones, _ := ipNet.Mask.Size(); ones > IPv6SubnetSize {
+			return fmt.Errorf("%w: IPv6 pool %q is smaller than a /%d", errs.ErrInvalidArgument, pool, IPv6SubnetSize)
+		}
+		e.IPv6Pool = ipNet.String()
+		return nil
 	}
 }
 
@@ -340,11 +394,27 @@ func (e *CNIEnv) CreateNetwork(opts *options.NetworkCreate) (*NetworkConfig, err
 		if err != nil {
 			return err
 		}
-		plugins, err := e.generateCNIPlugins(opts.Driver, opts.Name, ipam, opts.Options, opts.IPv6)
+		// "ip-sticky" is not a driver option, and is recorded as a label instead
+		driverOpts := make(map[string]string, len(opts.Options))
+		netLabels := slices.Clone(opts.Labels)
+		for k, v := range opts.Options {
+			if k != "ip-sticky" {
+				driverOpts[k] = v
+				continue
+			}
+			sticky, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("%w: invalid value for network option %q: %q", errs.ErrInvalidArgument, k, v)
+			}
+			if sticky {
+				netLabels = append(netLabels, labels.IPSticky+"=true")
+			}
+		}
+		plugins, err := e.generateCNIPlugins(opts.Driver, opts.Name, ipam, driverOpts, opts.IPv6)
 		if err != nil {
 			return err
 		}
-		netConf, err = e.generateNetworkConfig(opts.Name, opts.Labels, plugins)
+		netConf, err = e.generateNetworkConfig(opts.Name, netLabels, plugins)
 		if err != nil {
 			return err
 		}
@@ -429,20 +499,20 @@ func (e *CNIEnv) GetDefaultNetworkConfig() (*NetworkConfig, error) {
 	return nil, nil
 }
 
-func (e *CNIEnv) ensureDefaultNetworkConfig(bridgeIP string) error {
+func (e *CNIEnv) ensureDefaultNetworkConfig(bridgeIP, bridgeSubnet string, ipv6 bool) error {
 	defaultNet, err := e.GetDefaultNetworkConfig()
 	if err != nil {
 		return fmt.Errorf("failed to check for default network: %w", err)
 	}
 	if defaultNet == nil {
-		if err := e.createDefaultNetworkConfig(bridgeIP); err != nil {
+		if err := e.createDefaultNetworkConfig(bridgeIP, bridgeSubnet, ipv6); err != nil {
 			return fmt.Errorf("failed to create default network: %w", err)
 		}
 	}
 	return nil
 }
 
-func (e *CNIEnv) createDefaultNetworkConfig(bridgeIP string) error {
+func (e *CNIEnv) createDefaultNetworkConfig(bridgeIP, bridgeSubnet string, ipv6 bool) error {
 	filename := e.getConfigPathForNetworkName(DefaultNetworkName)
 	if _, err := os.Stat(filename); err == nil {
 		return fmt.Errorf(
@@ -452,15 +522,9 @@ func (e *CNIEnv) createDefaultNetworkConfig(bridgeIP string) error {
 		)
 	}
 
-	bridgeCIDR := DefaultCIDR
-	bridgeGatewayIP := ""
-	if bridgeIP != "" {
-		bIP, bCIDR, err := net.ParseCIDR(bridgeIP)
-		if err != nil {
-			return fmt.Errorf("invalid bridge ip %s: %w", bridgeIP, err)
-		}
-		bridgeGatewayIP = bIP.String()
-		bridgeCIDR = bCIDR.String()
+	bridgeCIDR, bridgeGatewayIP, err := defaultNetworkSubnet(bridgeIP, bridgeSubnet)
+	if err != nil {
+		return err
 	}
 	opts := &options.NetworkCreate{
 		Name:       DefaultNetworkName,
@@ -470,14 +534,55 @@ func (e *CNIEnv) createDefaultNetworkConfig(bridgeIP string) error {
 		IPAMDriver: "default",
 		Labels:     []string{labels.DefaultNetwork + "=true"},
 	}
+	if ipv6 {
+		// The default network gets the first subnet of the pool
+		bridgeCIDR6, err := e.ipv6Pool()
+		if err != nil {
+			return err
+		}
+		bridgeCIDR6.Mask = net.CIDRMask(IPv6SubnetSize, 128)
+		opts.Subnets = append(opts.Subnets, bridgeCIDR6.String())
+		opts.IPv6 = true
+	}
 
-	_, err := e.CreateNetwork(opts)
+	_, err = e.CreateNetwork(opts)
 	if err != nil && !errdefs.IsAlreadyExists(err) {
 		return err
 	}
 	return nil
 }
 
+// defaultNetworkSubnet returns the subnet and gateway of the default network: the subnet is bridgeSubnet, or the one
+// of bridgeIP, or DefaultCIDR, and the gateway is the address of bridgeIP, if any.
+func defaultNetworkSubnet(bridgeIP, bridgeSubnet string) (subnet, gateway string, err error) {
+	subnet = DefaultCIDR
+	var bSubnet *net.IPNet
+	if bridgeSubnet != "" {
+		var bSubnetIP net.IP
+		bSubnetIP, bSubnet, err = net.ParseCIDR(bridgeSubnet)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid bridge subnet %s: %w", bridgeSubnet, err)
+		}
+		if !bSubnet.IP.Equal(bSubnetIP) {
+			return "", "", fmt.Errorf("unexpected bridge subnet %q, maybe you meant %q?", bridgeSubnet, bSubnet)
+		}
+		subnet = bSubnet.String()
+	}
+	if bridgeIP != "" {
+		bIP, bCIDR, err := net.ParseCIDR(bridgeIP)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid bridge ip %s: %w", bridgeIP, err)
+		}
+		if bSubnet == nil {
+			subnet = bCIDR.String()
+		} else if !bSubnet.Contains(bIP) {
+			return "", "", fmt.Errorf("bridge ip %s is not in the bridge subnet %s", bridgeIP, bridgeSubnet)
+		}
+		gateway = bIP.String()
+	}
+	return subnet, gateway, nil
+}
+
 // generateNetworkConfig creates NetworkConfig.
 // generateNetworkConfig does not fill "File" field.
 func (e *CNIEnv) generateNetworkConfig(name string, labels []string, plugins []CNIPlugin) (*NetworkConfig, error) {
@@ -614,11 +719,69 @@ func networkID(name string) string {
 	return hex.EncodeToString(hash[:])
 }
 
+func (e *CNIEnv) ipv6Pool() (*net.IPNet, error) {
+	if e.IPv6Pool == "" {
+		pool, err := DefaultIPv6Pool(e.dataStore)
+		if err != nil {
+			return nil, err
+		}
+		e.IPv6Pool = pool
+	}
+	pool := e.IPv6Pool
+	_, ipNet, err := net.ParseCIDR(pool)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid IPv6 pool %q", errs.ErrInvalidArgument, pool)
+	}
+	return ipNet, nil
+}
+
+// parseSubnet6 returns a free IPv6 subnet from the pool.
+func (e *CNIEnv) parseSubnet6() (*net.IPNet, error) {
+	usedSubnets, err := e.usedSubnets()
+	if err != nil {
+		return nil, err
+	}
+	pool, err := e.ipv6Pool()
+	if err != nil {
+		return nil, err
+	}
+	// Like `StartingCIDR` for IPv4, skip the first subnet of the pool, left to the default network
+	reserved := &net.IPNet{IP: pool.IP, Mask: net.CIDRMask(IPv6SubnetSize, 128)}
+	return subnetutil.GetFreeSubnetInPool(pool, IPv6SubnetSize, append(usedSubnets, reserved))
+}
+
+// subnetFromAddressPools returns the first free subnet of the address pools.
+func (e *CNIEnv) subnetFromAddressPools(usedSubnets []*net.IPNet) (*net.IPNet, error) {
+	pools := make([]string, len(e.AddressPools))
+	for i, pool := range e.AddressPools {
+		pools[i] = fmt.Sprintf("%s (/%d)", pool.Base, pool.Size)
+		_, base, err := net.ParseCIDR(pool.Base)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid address pool %q", errs.ErrInvalidArgument, pool.Base)
+		}
+		subnet, err := subnetutil.GetFreeSubnetInPool(base, pool.Size, usedSubnets)
+		if err == nil {
+			return subnet, nil
+		}
+		if !errors.Is(err, subnetutil.ErrPoolExhausted) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf(
+		"%w: all the default address pools are in use (%s), add pools to `default_address_pools`, or specify a subnet",
+		subnetutil.ErrPoolExhausted,
+		strings.Join(pools, ", "),
+	)
+}
+
 func (e *CNIEnv) parseSubnet(subnetStr string) (*net.IPNet, error) {
 	usedSubnets, err := e.usedSubnets()
 	if err != nil {
 		return nil, err
 	}
+	if subnetStr == "" && len(e.AddressPools) > 0 {
+		return e.subnetFromAddressPools(usedSubnets)
+	}
 	if subnetStr == "" {
 		_, defaultSubnet, _ := net.ParseCIDR(StartingCIDR)
 		subnet, err := subnetutil.GetFreeSubnet(defaultSubnet, usedSubnets)
@@ -61,7 +61,7 @@ func testDefaultNetworkCreationWithBridgeIP(t *testing.T) {
 	assert.Assert(t, defaultNetConf == nil)
 
 	// Attempt to create the default network with a test bridgeIP
-	err = netutil.WithDefaultNetwork(testBridgeIP)(&cniEnv)
+	err = netutil.WithDefaultNetwork(testBridgeIP, "", false)(&cniEnv)
 	assert.NilError(t, err)
 
 	// Ensure default network config is present now.
@@ -115,7 +115,7 @@ func testDefaultNetworkCreationWithBridgeIP(t *testing.T) {
 	assert.Equal(t, "host-local", bridgeConfig.IPAM.Type)
 
 	// Ensure network isn't created twice or accidentally re-created.
-	err = netutil.WithDefaultNetwork(testBridgeIP)(&cniEnv)
+	err = netutil.WithDefaultNetwork(testBridgeIP, "", false)(&cniEnv)
 	assert.NilError(t, err)
 
 	// Check for any other network config files.
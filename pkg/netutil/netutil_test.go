@@ -159,7 +159,7 @@ func testDefaultNetworkCreation(t *testing.T) {
 	assert.Assert(t, defaultNetConf == nil)
 
 	// Attempt to create the default network.
-	err = netutil.WithDefaultNetwork("")(&cniEnv)
+	err = netutil.WithDefaultNetwork("", "", false)(&cniEnv)
 	assert.NilError(t, err)
 
 	// Ensure default network config is present now.
@@ -181,7 +181,7 @@ func testDefaultNetworkCreation(t *testing.T) {
 	assert.Assert(t, boolv)
 
 	// Ensure network isn't created twice or accidentally re-created.
-	err = netutil.WithDefaultNetwork("")(&cniEnv)
+	err = netutil.WithDefaultNetwork("", "", false)(&cniEnv)
 	assert.NilError(t, err)
 
 	// Check for any other network config files.
@@ -248,7 +248,7 @@ func TestNetworkWithDefaultNameAlreadyExists(t *testing.T) {
 	assert.Assert(t, defaultNetConf != nil)
 	assert.Assert(t, defaultNetConf.File == testConfFile)
 
-	err = netutil.WithDefaultNetwork("")(&cniEnv)
+	err = netutil.WithDefaultNetwork("", "", false)(&cniEnv)
 	assert.NilError(t, err)
 
 	netConfs, err = cniEnv.NetworkList()
@@ -222,15 +222,30 @@ func (e *CNIEnv) generateIPAM(
 		ipamConf.Routes = []IPAMRoute{
 			{Dst: "0.0.0.0/0"},
 		}
-		ranges, findIPv4, err := e.parseIPAMRanges(subnets, gatewayStr, ipRangeStr, ipv6)
+		ranges, findIPv4, findIPv6, err := e.parseIPAMRanges(subnets, gatewayStr, ipRangeStr, ipv6)
 		if err != nil {
 			return nil, err
 		}
 		ipamConf.Ranges = append(ipamConf.Ranges, ranges...)
 		if !findIPv4 {
-			ranges, _, _ = e.parseIPAMRanges([]string{""}, gatewayStr, ipRangeStr, ipv6)
+			ranges, _, _, _ = e.parseIPAMRanges([]string{""}, gatewayStr, ipRangeStr, ipv6)
 			ipamConf.Ranges = append(ipamConf.Ranges, ranges...)
 		}
+		if ipv6 {
+			// Without an IPv6 subnet, allocate one from the IPv6 pool
+			if !findIPv6 {
+				subnet, err := e.parseSubnet6()
+				if err != nil {
+					return nil, err
+				}
+				ipamRange, err := ParseIPAMRange(subnet, "", "")
+				if err != nil {
+					return nil, err
+				}
+				ipamConf.Ranges = append(ipamConf.Ranges, []IPAMRange{*ipamRange})
+			}
+			ipamConf.Routes = append(ipamConf.Routes, IPAMRoute{Dst: "::/0"})
+		}
 		ipamConfig = ipamConf
 	case "dhcp":
 		ipamConf := newDHCPIPAMConfig()
@@ -289,28 +304,33 @@ func (e *CNIEnv) generateIPAM(
 	return ipam, nil
 }
 
-func (e *CNIEnv) parseIPAMRanges(subnets []string, gateway, ipRange string, ipv6 bool) ([][]IPAMRange, bool, error) {
-	findIPv4 := false
-	ranges := make([][]IPAMRange, 0, len(subnets))
+func (e *CNIEnv) parseIPAMRanges(
+	subnets []string,
+	gateway, ipRange string,
+	ipv6 bool,
+) (ranges [][]IPAMRange, findIPv4, findIPv6 bool, err error) {
+	ranges = make([][]IPAMRange, 0, len(subnets))
 	for i := range subnets {
 		subnet, err := e.parseSubnet(subnets[i])
 		if err != nil {
-			return nil, findIPv4, err
+			return nil, findIPv4, findIPv6, err
 		}
 		// if ipv6 flag is not set, subnets of ipv6 should be excluded
 		if !ipv6 && subnet.IP.To4() == nil {
 			continue
 		}
-		if !findIPv4 && subnet.IP.To4() != nil {
+		if subnet.IP.To4() != nil {
 			findIPv4 = true
+		} else {
+			findIPv6 = true
 		}
 		ipamRange, err := ParseIPAMRange(subnet, gateway, ipRange)
 		if err != nil {
-			return nil, findIPv4, err
+			return nil, findIPv4, findIPv6, err
 		}
 		ranges = append(ranges, []IPAMRange{*ipamRange})
 	}
-	return ranges, findIPv4, nil
+	return ranges, findIPv4, findIPv6, nil
 }
 
 func enforceFirewallPluginVersion(firewallPath string) (bool, error) {
@@ -19,11 +19,16 @@
 package netutil
 
 import (
+	"encoding/json"
+	"errors"
+	"net"
 	"testing"
 
 	"gotest.tools/v3/assert"
 
 	"go.farcloser.world/core/version/semver"
+
+	subnetutil "go.farcloser.world/lepton/pkg/netutil/subnet"
 )
 
 func TestGuessFirewallPluginVersion(t *testing.T) {
@@ -69,3 +74,113 @@ func TestGuessFirewallPluginVersion(t *testing.T) {
 		}
 	}
 }
+
+func TestGenerateIPAMIPv6(t *testing.T) {
+	e := &CNIEnv{NetconfPath: t.TempDir(), dataStore: t.TempDir()}
+	defaultPool, err := DefaultIPv6Pool(e.dataStore)
+	assert.NilError(t, err)
+	_, pool, err := net.ParseCIDR(defaultPool)
+	assert.NilError(t, err)
+
+	decode := func(t *testing.T, ipam map[string]interface{}) *hostLocalIPAMConfig {
+		t.Helper()
+		b, err := json.Marshal(ipam)
+		assert.NilError(t, err)
+		conf := &hostLocalIPAMConfig{}
+		assert.NilError(t, json.Unmarshal(b, conf))
+		return conf
+	}
+	families := func(conf *hostLocalIPAMConfig) (v4, v6 []string) {
+		for _, r := range conf.Ranges {
+			_, subnet, _ := net.ParseCIDR(r[0].Subnet)
+			if subnet.IP.To4() != nil {
+				v4 = append(v4, r[0].Subnet)
+			} else {
+				v6 = append(v6, r[0].Subnet)
+			}
+		}
+		return v4, v6
+	}
+
+	// Without any subnet, both are allocated, the IPv6 one from the pool, after the subnet of the default network
+	ipam, err := e.generateIPAM("default", []string{""}, "", "", nil, true)
+	assert.NilError(t, err)
+	conf := decode(t, ipam)
+	v4, v6 := families(conf)
+	assert.Equal(t, len(v4), 1)
+	second := &net.IPNet{IP: append(net.IP{}, pool.IP...), Mask: net.CIDRMask(IPv6SubnetSize, 128)}
+	second.IP[7] = 1
+	assert.DeepEqual(t, v6, []string{second.String()})
+	assert.DeepEqual(t, conf.Routes, []IPAMRoute{{Dst: "0.0.0.0/0"}, {Dst: "::/0"}})
+
+	// An explicit IPv6 subnet is used as is
+	ipam, err = e.generateIPAM("default", []string{"fd00:dead:beef::/64"}, "", "", nil, true)
+	assert.NilError(t, err)
+	v4, v6 = families(decode(t, ipam))
+	assert.Equal(t, len(v4), 1)
+	assert.DeepEqual(t, v6, []string{"fd00:dead:beef::/64"})
+
+	// From a custom pool
+	e.IPv6Pool = "fd12:3456:789a::/56"
+	ipam, err = e.generateIPAM("default", []string{"10.42.0.0/24"}, "", "", nil, true)
+	assert.NilError(t, err)
+	v4, v6 = families(decode(t, ipam))
+	assert.DeepEqual(t, v4, []string{"10.42.0.0/24"})
+	assert.DeepEqual(t, v6, []string{"fd12:3456:789a:1::/64"})
+
+	// Without IPv6, no IPv6 subnet nor route
+	ipam, err = e.generateIPAM("default", []string{""}, "", "", nil, false)
+	assert.NilError(t, err)
+	conf = decode(t, ipam)
+	_, v6 = families(conf)
+	assert.Equal(t, len(v6), 0)
+	assert.DeepEqual(t, conf.Routes, []IPAMRoute{{Dst: "0.0.0.0/0"}})
+}
+
+func TestSubnetFromAddressPools(t *testing.T) {
+	e := &CNIEnv{
+		AddressPools: []AddressPool{
+			{Base: "10.200.0.0/23", Size: 24},
+			{Base: "10.201.0.0/16", Size: 25},
+		},
+	}
+	var used []*net.IPNet
+	for _, expected := range []string{"10.200.0.0/24", "10.200.1.0/24", "10.201.0.0/25", "10.201.0.128/25"} {
+		subnet, err := e.subnetFromAddressPools(used)
+		assert.NilError(t, err)
+		assert.Equal(t, subnet.String(), expected)
+		used = append(used, subnet)
+	}
+
+	// Once every pool is in use, the error says so
+	_, all, _ := net.ParseCIDR("10.0.0.0/8")
+	_, err := e.subnetFromAddressPools([]*net.IPNet{all})
+	assert.Assert(t, errors.Is(err, subnetutil.ErrPoolExhausted))
+	assert.ErrorContains(t, err, "10.200.0.0/23 (/24), 10.201.0.0/16 (/25)")
+}
+
+func TestDefaultNetworkSubnet(t *testing.T) {
+	testCases := []struct {
+		bridgeIP, bridgeSubnet string
+		subnet, gateway        string
+		err                    string
+	}{
+		{subnet: DefaultCIDR},
+		{bridgeIP: "10.1.100.1/24", subnet: "10.1.100.0/24", gateway: "10.1.100.1"},
+		{bridgeSubnet: "10.50.0.0/16", subnet: "10.50.0.0/16"},
+		{bridgeIP: "10.50.3.1/24", bridgeSubnet: "10.50.0.0/16", subnet: "10.50.0.0/16", gateway: "10.50.3.1"},
+		{bridgeIP: "10.1.100.1/24", bridgeSubnet: "10.50.0.0/16", err: "is not in the bridge subnet"},
+		{bridgeSubnet: "10.50.0.1/16", err: "maybe you meant \"10.50.0.0/16\""},
+		{bridgeSubnet: "10.50.0.0", err: "invalid bridge subnet"},
+	}
+	for _, tc := range testCases {
+		subnet, gateway, err := defaultNetworkSubnet(tc.bridgeIP, tc.bridgeSubnet)
+		if tc.err != "" {
+			assert.ErrorContains(t, err, tc.err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, subnet, tc.subnet)
+		assert.Equal(t, gateway, tc.gateway)
+	}
+}
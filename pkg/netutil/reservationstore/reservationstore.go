@@ -0,0 +1,133 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package reservationstore records the addresses first allocated to containers with sticky IPs, so that they can be
+// requested again when these containers are restarted.
+// Reservations are recorded by the createRuntime OCI hook, and released on container removal.
+// All methods are safe to use concurrently.
+// Note that locking of the store is done at the namespace level.
+package reservationstore
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+	"go.farcloser.world/lepton/leptonic/store"
+)
+
+// reservationsDirBasename is the base name of /var/lib/nerdctl/<ADDRHASH>/reservations
+const reservationsDirBasename = "reservations"
+
+// ErrReservationStore will wrap all errors here
+var ErrReservationStore = errors.New("reservation-store error")
+
+// Reservation is what a container was first allocated on a network.
+type Reservation struct {
+	// IPs are the addresses, without prefix length
+	IPs []string `json:"ips,omitempty"`
+	MAC string   `json:"mac,omitempty"`
+}
+
+// Store allows reserving and releasing the addresses of containers.
+// Reservations are keyed by network name.
+type Store interface {
+	// Get returns the reservations of the container with `id`, or ErrNotFound
+	Get(id string) (map[string]*Reservation, error)
+	// Reserve records the reservations of the container with `id`, unless it already has some
+	Reserve(id string, reservations map[string]*Reservation) error
+	// Release removes the reservations of the container with `id`. Releasing a container with no reservations is a
+	// no-op.
+	Release(id string) error
+}
+
+// New will return a Store for a given namespace.
+func New(dataStore, namespace string) (Store, error) {
+	if dataStore == "" || namespace == "" {
+		return nil, errors.Join(ErrReservationStore, errs.ErrInvalidArgument)
+	}
+
+	st, err := store.New(filepath.Join(dataStore, reservationsDirBasename, namespace), false, 0, 0)
+	if err != nil {
+		return nil, errors.Join(ErrReservationStore, err)
+	}
+
+	return &reservationStore{
+		safeStore: st,
+	}, nil
+}
+
+type reservationStore struct {
+	safeStore store.Store
+}
+
+func (x *reservationStore) Get(id string) (reservations map[string]*Reservation, err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(ErrReservationStore, err)
+		}
+	}()
+
+	err = x.safeStore.WithLock(func() error {
+		var content []byte
+		if content, err = x.safeStore.Get(id); err != nil {
+			return err
+		}
+
+		return json.Unmarshal(content, &reservations)
+	})
+
+	return reservations, err
+}
+
+func (x *reservationStore) Reserve(id string, reservations map[string]*Reservation) (err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(ErrReservationStore, err)
+		}
+	}()
+
+	content, err := json.Marshal(reservations)
+	if err != nil {
+		return err
+	}
+
+	return x.safeStore.WithLock(func() error {
+		var doesExist bool
+		if doesExist, err = x.safeStore.Exists(id); err != nil || doesExist {
+			return err
+		}
+
+		return x.safeStore.Set(content, id)
+	})
+}
+
+func (x *reservationStore) Release(id string) (err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Join(ErrReservationStore, err)
+		}
+	}()
+
+	return x.safeStore.WithLock(func() error {
+		err = x.safeStore.Delete(id)
+		if errors.Is(err, errs.ErrNotFound) {
+			err = nil
+		}
+		return err
+	})
+}
@@ -0,0 +1,57 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package reservationstore_test
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+	"go.farcloser.world/lepton/pkg/netutil/reservationstore"
+)
+
+func TestReservationStore(t *testing.T) {
+	const id = "c4ed811cc361d26faffdee8d696ddbc45a9d93c571b5b3c54d3da01cb29caeb1"
+
+	st, err := reservationstore.New(t.TempDir(), "default")
+	assert.NilError(t, err)
+
+	_, err = st.Get(id)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+
+	first := map[string]*reservationstore.Reservation{
+		"foo": {IPs: []string{"10.4.2.2", "fd00::2"}, MAC: "92:d0:c6:0a:29:33"},
+	}
+	assert.NilError(t, st.Reserve(id, first))
+
+	// The first reservation is kept
+	assert.NilError(t, st.Reserve(id, map[string]*reservationstore.Reservation{
+		"foo": {IPs: []string{"10.4.2.3"}},
+	}))
+	reservations, err := st.Get(id)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, reservations, first)
+
+	assert.NilError(t, st.Release(id))
+	_, err = st.Get(id)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+	assert.NilError(t, st.Release(id))
+
+	_, err = reservationstore.New("", "default")
+	assert.ErrorIs(t, err, errs.ErrInvalidArgument)
+}
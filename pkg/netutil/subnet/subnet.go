@@ -20,10 +20,14 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"slices"
 
 	"go.farcloser.world/lepton/pkg/rootlessutil"
 )
 
+// ErrPoolExhausted is returned when all the subnets of an address pool are in use
+var ErrPoolExhausted = errors.New("address pool exhausted")
+
 func GetLiveNetworkSubnets() ([]*net.IPNet, error) {
 	var addrs []net.Addr
 	if err := rootlessutil.WithDetachedNetNSIfAny(func() error {
@@ -59,6 +63,30 @@ func GetFreeSubnet(n *net.IPNet, usedNetworks []*net.IPNet) (*net.IPNet, error)
 	return nil, errors.New("could not find free subnet")
 }
 
+// GetFreeSubnetInPool try to find a free subnet of the given prefix length in the pool
+func GetFreeSubnetInPool(pool *net.IPNet, size int, usedNetworks []*net.IPNet) (*net.IPNet, error) {
+	ones, bits := pool.Mask.Size()
+	if size < ones || size > bits {
+		return nil, fmt.Errorf("cannot allocate /%d subnets from %s", size, pool.String())
+	}
+	// Copy the base address, as nextSubnet increments it in place
+	n := &net.IPNet{
+		IP:   slices.Clone(pool.IP.Mask(pool.Mask)),
+		Mask: net.CIDRMask(size, bits),
+	}
+	for pool.Contains(n.IP) {
+		if !IntersectsWithNetworks(n, usedNetworks) {
+			return n, nil
+		}
+		next, err := nextSubnet(n)
+		if err != nil {
+			break
+		}
+		n = next
+	}
+	return nil, fmt.Errorf("%w: no free /%d subnet left in %s", ErrPoolExhausted, size, pool.String())
+}
+
 func nextSubnet(subnet *net.IPNet) (*net.IPNet, error) {
 	newSubnet := &net.IPNet{
 		IP:   subnet.IP,
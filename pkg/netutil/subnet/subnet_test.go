@@ -48,3 +48,62 @@ func TestNextSubnet(t *testing.T) {
 		assert.Equal(t, nextSubnet.String(), tc.expect)
 	}
 }
+
+func TestGetFreeSubnetInPool(t *testing.T) {
+	parse := func(cidrs ...string) []*net.IPNet {
+		nets := make([]*net.IPNet, len(cidrs))
+		for i, cidr := range cidrs {
+			_, nets[i], _ = net.ParseCIDR(cidr)
+		}
+		return nets
+	}
+
+	testCases := []struct {
+		pool   string
+		size   int
+		used   []string
+		expect string
+		err    string
+	}{
+		{
+			pool:   "fd4c:6570:746f::/48",
+			size:   64,
+			expect: "fd4c:6570:746f::/64",
+		},
+		{
+			pool:   "fd4c:6570:746f::/48",
+			size:   64,
+			used:   []string{"fd4c:6570:746f::/64", "fe80::/64", "fd4c:6570:746f:1::/64"},
+			expect: "fd4c:6570:746f:2::/64",
+		},
+		{
+			pool: "fd4c:6570:746f:ff00::/56",
+			size: 64,
+			used: []string{"fd4c:6570:746f:ff00::/56"},
+			err:  "address pool exhausted: no free /64 subnet left in fd4c:6570:746f:ff00::/56",
+		},
+		{
+			pool:   "10.200.0.0/16",
+			size:   24,
+			used:   []string{"10.200.0.0/24"},
+			expect: "10.200.1.0/24",
+		},
+		{
+			pool: "fd4c:6570:746f::/48",
+			size: 32,
+			err:  "cannot allocate /32 subnets from fd4c:6570:746f::/48",
+		},
+	}
+	for _, tc := range testCases {
+		pool := parse(tc.pool)[0]
+		subnet, err := GetFreeSubnetInPool(pool, tc.size, parse(tc.used...))
+		if tc.err != "" {
+			assert.Error(t, err, tc.err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, subnet.String(), tc.expect)
+		// The pool must be left untouched
+		assert.Equal(t, pool.String(), tc.pool)
+	}
+}
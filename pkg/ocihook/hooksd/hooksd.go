@@ -0,0 +1,252 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package hooksd loads user-provided OCI hooks from a directory, in the format of OCI hooks.d (version 1.0.0), and
+// injects the ones matching a container into its spec.
+// Hooks are then run by the OCI runtime, which passes them the container state on stdin.
+// See https://github.com/containers/common/blob/main/pkg/hooks/docs/oci-hooks.5.md
+package hooksd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/containerd/log"
+
+	"go.farcloser.world/containers/specs"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+)
+
+// Version is the only supported version of the hook files format.
+const Version = "1.0.0"
+
+// DefaultTimeout is the timeout in seconds of hooks that do not set one.
+const DefaultTimeout = 30
+
+// Stages a hook can be injected in.
+const (
+	StagePrestart        = "prestart"
+	StageCreateRuntime   = "createRuntime"
+	StageCreateContainer = "createContainer"
+	StageStartContainer  = "startContainer"
+	StagePoststart       = "poststart"
+	StagePoststop        = "poststop"
+)
+
+var stages = []string{
+	StagePrestart, StageCreateRuntime, StageCreateContainer, StageStartContainer, StagePoststart, StagePoststop,
+}
+
+// Hook is the content of a hook file.
+type Hook struct {
+	Version string     `json:"version"`
+	Hook    specs.Hook `json:"hook"`
+	When    When       `json:"when"`
+	Stages  []string   `json:"stages"`
+
+	// name is the name of the file the hook was loaded from
+	name string
+}
+
+// When are the conditions under which a hook is injected. All the conditions that are set must match.
+type When struct {
+	// Always, when true, matches any container.
+	Always *bool `json:"always,omitempty"`
+	// Annotations maps regular expressions of annotation keys to regular expressions of their values.
+	// Each entry must be matched by at least one annotation of the container.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Commands are regular expressions, one of which must match the first argument of the container process.
+	Commands []string `json:"commands,omitempty"`
+	// HasBindMounts, when true, matches containers with at least one bind mount.
+	HasBindMounts *bool `json:"hasBindMounts,omitempty"`
+}
+
+// Load reads and validates the hook files (*.json) of dir, in lexical order.
+// A dir that is unset or does not exist has no hooks.
+func Load(dir string) ([]*Hook, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var hooks []*Hook
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		hook := &Hook{name: entry.Name()}
+		if err = json.Unmarshal(content, hook); err != nil {
+			return nil, fmt.Errorf("%w: hook %q: %w", errs.ErrInvalidArgument, entry.Name(), err)
+		}
+		if err = hook.validate(); err != nil {
+			return nil, fmt.Errorf("%w: hook %q: %w", errs.ErrInvalidArgument, entry.Name(), err)
+		}
+		hooks = append(hooks, hook)
+	}
+
+	return hooks, nil
+}
+
+func (hook *Hook) validate() error {
+	if hook.Version != Version {
+		return fmt.Errorf("unsupported version %q (only %q is supported)", hook.Version, Version)
+	}
+	if !filepath.IsAbs(hook.Hook.Path) {
+		return fmt.Errorf("hook path %q must be absolute", hook.Hook.Path)
+	}
+	if hook.Hook.Timeout != nil && *hook.Hook.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive, got %d", *hook.Hook.Timeout)
+	}
+	if len(hook.Stages) == 0 {
+		return errors.New("at least one stage must be set")
+	}
+	for _, stage := range hook.Stages {
+		if !slices.Contains(stages, stage) {
+			return fmt.Errorf("unknown stage %q (must be one of %s)", stage, strings.Join(stages, ", "))
+		}
+	}
+	when := hook.When
+	if when.Always == nil && when.HasBindMounts == nil && len(when.Annotations) == 0 && len(when.Commands) == 0 {
+		return errors.New("at least one of always, annotations, commands or hasBindMounts must be set")
+	}
+	for key, value := range when.Annotations {
+		if _, err := regexp.Compile(key); err != nil {
+			return fmt.Errorf("invalid annotation key pattern: %w", err)
+		}
+		if _, err := regexp.Compile(value); err != nil {
+			return fmt.Errorf("invalid annotation value pattern: %w", err)
+		}
+	}
+	for _, command := range when.Commands {
+		if _, err := regexp.Compile(command); err != nil {
+			return fmt.Errorf("invalid command pattern: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Match tells whether the hook applies to the container with spec.
+func (hook *Hook) Match(spec *specs.Spec) bool {
+	when := hook.When
+	matches := 0
+
+	if when.Always != nil {
+		if !*when.Always {
+			return false
+		}
+		matches++
+	}
+
+	if when.HasBindMounts != nil {
+		if !*when.HasBindMounts || !hasBindMounts(spec) {
+			return false
+		}
+		matches++
+	}
+
+	// Patterns were validated on load
+	for keyPattern, valuePattern := range when.Annotations {
+		matched := false
+		for key, value := range spec.Annotations {
+			if regexp.MustCompile(keyPattern).MatchString(key) && regexp.MustCompile(valuePattern).MatchString(value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+		matches++
+	}
+
+	if len(when.Commands) > 0 {
+		if spec.Process == nil || len(spec.Process.Args) == 0 {
+			return false
+		}
+		for _, commandPattern := range when.Commands {
+			if regexp.MustCompile(commandPattern).MatchString(spec.Process.Args[0]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return matches > 0
+}
+
+func hasBindMounts(spec *specs.Spec) bool {
+	for _, mount := range spec.Mounts {
+		if mount.Type == "bind" || slices.Contains(mount.Options, "bind") || slices.Contains(mount.Options, "rbind") {
+			return true
+		}
+	}
+	return false
+}
+
+// Inject appends the hooks matching spec to its hooks, for each of their stages, setting DefaultTimeout on hooks that
+// have no timeout.
+func Inject(spec *specs.Spec, hooks []*Hook) {
+	for _, hook := range hooks {
+		if !hook.Match(spec) {
+			continue
+		}
+		log.L.Debugf("injecting hook %q in stages %v", hook.name, hook.Stages)
+		if spec.Hooks == nil {
+			spec.Hooks = &specs.Hooks{}
+		}
+		ociHook := hook.Hook
+		if ociHook.Timeout == nil {
+			timeout := DefaultTimeout
+			ociHook.Timeout = &timeout
+		}
+		for _, stage := range hook.Stages {
+			switch stage {
+			case StagePrestart:
+				//nolint:staticcheck // prestart is deprecated by the OCI spec, but still honored by runtimes
+				spec.Hooks.Prestart = append(spec.Hooks.Prestart, ociHook)
+			case StageCreateRuntime:
+				spec.Hooks.CreateRuntime = append(spec.Hooks.CreateRuntime, ociHook)
+			case StageCreateContainer:
+				spec.Hooks.CreateContainer = append(spec.Hooks.CreateContainer, ociHook)
+			case StageStartContainer:
+				spec.Hooks.StartContainer = append(spec.Hooks.StartContainer, ociHook)
+			case StagePoststart:
+				spec.Hooks.Poststart = append(spec.Hooks.Poststart, ociHook)
+			case StagePoststop:
+				spec.Hooks.Poststop = append(spec.Hooks.Poststop, ociHook)
+			}
+		}
+	}
+}
@@ -0,0 +1,133 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hooksd_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/containers/specs"
+
+	"go.farcloser.world/lepton/leptonic/errs"
+	"go.farcloser.world/lepton/pkg/ocihook/hooksd"
+)
+
+func writeHooks(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		assert.NilError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	hooks, err := hooksd.Load(filepath.Join(t.TempDir(), "missing"))
+	assert.NilError(t, err)
+	assert.Equal(t, len(hooks), 0)
+
+	dir := writeHooks(t, map[string]string{
+		"20-audit.json": `{"version": "1.0.0", "hook": {"path": "/usr/bin/audit"},
+			"when": {"always": true}, "stages": ["poststop"]}`,
+		"10-devices.json": `{"version": "1.0.0", "hook": {"path": "/usr/bin/devices", "timeout": 5},
+			"when": {"annotations": {"^com\\.example\\.devices$": ".+"}}, "stages": ["createContainer"]}`,
+		"README": "not a hook",
+	})
+	hooks, err = hooksd.Load(dir)
+	assert.NilError(t, err)
+	assert.Equal(t, len(hooks), 2)
+	assert.Equal(t, hooks[0].Hook.Path, "/usr/bin/devices")
+	assert.Equal(t, hooks[1].Hook.Path, "/usr/bin/audit")
+
+	for name, content := range map[string]string{
+		"version": `{"version": "0.1.0", "hook": {"path": "/bin/true"}, "when": {"always": true},
+			"stages": ["poststop"]}`,
+		"relative": `{"version": "1.0.0", "hook": {"path": "true"}, "when": {"always": true}, "stages": ["poststop"]}`,
+		"stage": `{"version": "1.0.0", "hook": {"path": "/bin/true"}, "when": {"always": true},
+			"stages": ["prestop"]}`,
+		"when": `{"version": "1.0.0", "hook": {"path": "/bin/true"}, "when": {}, "stages": ["poststop"]}`,
+		"pattern": `{"version": "1.0.0", "hook": {"path": "/bin/true"}, "when": {"commands": ["("]},
+			"stages": ["poststop"]}`,
+	} {
+		_, err = hooksd.Load(writeHooks(t, map[string]string{name + ".json": content}))
+		assert.ErrorIs(t, err, errs.ErrInvalidArgument, name)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	yes, no := true, false
+	spec := &specs.Spec{
+		Process:     &specs.Process{Args: []string{"/usr/bin/nginx", "-g", "daemon off;"}},
+		Annotations: map[string]string{"com.example.devices": "fuse"},
+		Mounts:      []specs.Mount{{Destination: "/data", Type: "bind", Source: "/srv/data"}},
+	}
+
+	testCases := []struct {
+		name     string
+		when     hooksd.When
+		expected bool
+	}{
+		{"always", hooksd.When{Always: &yes}, true},
+		{"never", hooksd.When{Always: &no, Commands: []string{".*"}}, false},
+		{"annotation", hooksd.When{Annotations: map[string]string{"^com\\.example\\.": "^fuse$"}}, true},
+		{"annotation value", hooksd.When{Annotations: map[string]string{"^com\\.example\\.": "^gpu$"}}, false},
+		{"command", hooksd.When{Commands: []string{"/sh$", "/nginx$"}}, true},
+		{"command mismatch", hooksd.When{Commands: []string{"/sh$"}}, false},
+		{"bind mounts", hooksd.When{HasBindMounts: &yes}, true},
+		{"all must match", hooksd.When{Always: &yes, Commands: []string{"/sh$"}}, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hook := &hooksd.Hook{When: tc.when}
+			assert.Equal(t, hook.Match(spec), tc.expected)
+		})
+	}
+
+	assert.Equal(t, (&hooksd.Hook{When: hooksd.When{HasBindMounts: &yes}}).Match(&specs.Spec{}), false)
+}
+
+func TestInject(t *testing.T) {
+	timeout := 5
+	hooks := []*hooksd.Hook{
+		{
+			Hook:   specs.Hook{Path: "/usr/bin/devices", Timeout: &timeout},
+			When:   hooksd.When{Commands: []string{"nginx"}},
+			Stages: []string{hooksd.StageCreateContainer, hooksd.StageStartContainer},
+		},
+		{
+			Hook:   specs.Hook{Path: "/usr/bin/audit"},
+			When:   hooksd.When{Annotations: map[string]string{"audit": "true"}},
+			Stages: []string{hooksd.StagePoststart, hooksd.StagePoststop},
+		},
+	}
+	spec := &specs.Spec{Process: &specs.Process{Args: []string{"nginx"}}}
+	hooksd.Inject(spec, hooks)
+
+	assert.Equal(t, len(spec.Hooks.CreateContainer), 1)
+	assert.Equal(t, len(spec.Hooks.StartContainer), 1)
+	assert.Equal(t, *spec.Hooks.StartContainer[0].Timeout, 5)
+	assert.Equal(t, len(spec.Hooks.Poststart), 0)
+
+	spec = &specs.Spec{Annotations: map[string]string{"audit": "true"}}
+	hooksd.Inject(spec, hooks)
+	assert.Equal(t, len(spec.Hooks.CreateContainer), 0)
+	assert.Equal(t, len(spec.Hooks.Poststop), 1)
+	assert.Equal(t, *spec.Hooks.Poststop[0].Timeout, hooksd.DefaultTimeout)
+}
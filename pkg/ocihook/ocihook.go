@@ -25,6 +25,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -41,9 +42,11 @@ import (
 	"go.farcloser.world/lepton/pkg/bypass4netnsutil"
 	"go.farcloser.world/lepton/pkg/dnsutil/hostsstore"
 	"go.farcloser.world/lepton/pkg/labels"
+	"go.farcloser.world/lepton/pkg/mountutil/volumestore"
 	"go.farcloser.world/lepton/pkg/namestore"
 	"go.farcloser.world/lepton/pkg/netutil"
 	"go.farcloser.world/lepton/pkg/netutil/nettype"
+	"go.farcloser.world/lepton/pkg/netutil/reservationstore"
 	"go.farcloser.world/lepton/pkg/ocihook/state"
 	"go.farcloser.world/lepton/pkg/rootlessutil"
 	"go.farcloser.world/lepton/pkg/version"
@@ -60,7 +63,13 @@ import (
 // the Host Compute Network Service (HCN) API.
 var NetworkNamespace = labels.Prefix + "network-namespace"
 
-func Run(stdin io.Reader, stderr io.Writer, event, dataStore, cniPath, cniNetconfPath, bridgeIP string) error {
+func Run(
+	stdin io.Reader,
+	stderr io.Writer,
+	event, dataStore, cniPath, cniNetconfPath, bridgeIP, bridgeSubnet string,
+	bridgeIPv6 bool,
+	ipv6Pool string,
+) error {
 	if stdin == nil || event == "" || dataStore == "" || cniPath == "" || cniNetconfPath == "" {
 		return errors.New("got insufficient args")
 	}
@@ -112,7 +121,16 @@ func Run(stdin io.Reader, stderr io.Writer, event, dataStore, cniPath, cniNetcon
 	}
 	defer filesystem.Unlock(lock)
 
-	opts, err := newHandlerOpts(&state, dataStore, cniPath, cniNetconfPath, bridgeIP)
+	opts, err := newHandlerOpts(
+		&state,
+		dataStore,
+		cniPath,
+		cniNetconfPath,
+		bridgeIP,
+		bridgeSubnet,
+		bridgeIPv6,
+		ipv6Pool,
+	)
 	if err != nil {
 		return err
 	}
@@ -127,7 +145,12 @@ func Run(stdin io.Reader, stderr io.Writer, event, dataStore, cniPath, cniNetcon
 	}
 }
 
-func newHandlerOpts(state *specs.State, dataStore, cniPath, cniNetconfPath, bridgeIP string) (*handlerOpts, error) {
+func newHandlerOpts(
+	state *specs.State,
+	dataStore, cniPath, cniNetconfPath, bridgeIP, bridgeSubnet string,
+	bridgeIPv6 bool,
+	ipv6Pool string,
+) (*handlerOpts, error) {
 	o := &handlerOpts{
 		state:     state,
 		dataStore: dataStore,
@@ -176,7 +199,8 @@ func newHandlerOpts(state *specs.State, dataStore, cniPath, cniNetconfPath, brid
 			cniPath,
 			cniNetconfPath,
 			netutil.WithNamespace(namespace),
-			netutil.WithDefaultNetwork(bridgeIP),
+			netutil.WithIPv6Pool(ipv6Pool, dataStore),
+			netutil.WithDefaultNetwork(bridgeIP, bridgeSubnet, bridgeIPv6),
 		)
 		if err != nil {
 			return nil, err
@@ -191,6 +215,11 @@ func newHandlerOpts(state *specs.State, dataStore, cniPath, cniNetconfPath, brid
 			}
 			cniOpts = append(cniOpts, cni.WithConfListBytes(netw.Bytes))
 			o.cniNames = append(o.cniNames, netstr)
+			if netw.CliLabels != nil {
+				if sticky, _ := strconv.ParseBool((*netw.CliLabels)[labels.IPSticky]); sticky {
+					o.ipSticky = true
+				}
+			}
 		}
 		o.cni, err = cni.New(cniOpts...)
 		if err != nil {
@@ -227,6 +256,16 @@ func newHandlerOpts(state *specs.State, dataStore, cniPath, cniNetconfPath, brid
 		o.containerIP6 = ip6Address
 	}
 
+	if sticky, _ := strconv.ParseBool(o.state.Annotations[labels.IPSticky]); sticky {
+		o.ipSticky = true
+	}
+
+	if aliasesJSON := o.state.Annotations[labels.NetworkAliases]; aliasesJSON != "" {
+		if err := json.Unmarshal([]byte(aliasesJSON), &o.networkAliases); err != nil {
+			return nil, err
+		}
+	}
+
 	if rootlessutil.IsRootlessChild() {
 		o.rootlessKitClient, err = rootlessutil.NewRootlessKitClient()
 		if err != nil {
@@ -267,6 +306,8 @@ type handlerOpts struct {
 	containerIP       string
 	containerMAC      string
 	containerIP6      string
+	ipSticky          bool
+	networkAliases    map[string][]string
 }
 
 // hookSpec is from https://github.com/containerd/containerd/blob/v1.4.3/cmd/containerd/command/oci-hook.go#L59-L64
@@ -361,6 +402,8 @@ func getPortMapOpts(opts *handlerOpts) []cni.NamespaceOpts {
 				if !(childIP != nil && childIP.Equal(hostIP)) {
 					if portDriverDisallowsLoopbackChildIP {
 						p.HostIP = childIP.String()
+					} else if hostIP.To4() == nil {
+						p.HostIP = "::1"
 					} else {
 						p.HostIP = "127.0.0.1"
 					}
@@ -432,31 +475,94 @@ func getIP6AddressOpts(opts *handlerOpts) []cni.NamespaceOpts {
 	}
 }
 
+func getNamespaceOpts(opts *handlerOpts) []cni.NamespaceOpts {
+	var namespaceOpts []cni.NamespaceOpts
+	namespaceOpts = append(namespaceOpts, getPortMapOpts(opts)...)
+	namespaceOpts = append(namespaceOpts, getIPAddressOpts(opts)...)
+	namespaceOpts = append(namespaceOpts, getMACAddressOpts(opts)...)
+	namespaceOpts = append(namespaceOpts, getIP6AddressOpts(opts)...)
+	return namespaceOpts
+}
+
+// applyReservation requests again the addresses reserved for the container on its first start, unless they were
+// explicitly set. It returns whether any reserved address was applied.
+func applyReservation(rs reservationstore.Store, opts *handlerOpts) bool {
+	reservations, err := rs.Get(opts.state.ID)
+	if err != nil {
+		if !errors.Is(err, errs.ErrNotFound) {
+			log.L.WithError(err).Warnf("failed to read the addresses reserved for container %q", opts.state.ID)
+		}
+		return false
+	}
+	reservation, ok := reservations[opts.cniNames[0]]
+	if !ok {
+		return false
+	}
+	applied := false
+	for _, addr := range reservation.IPs {
+		ip := net.ParseIP(addr)
+		switch {
+		case ip == nil:
+			continue
+		case ip.To4() != nil && opts.containerIP == "":
+			opts.containerIP = addr
+			applied = true
+		case ip.To4() == nil && opts.containerIP6 == "":
+			opts.containerIP6 = addr
+			applied = true
+		}
+	}
+	if reservation.MAC != "" && opts.containerMAC == "" {
+		opts.containerMAC = reservation.MAC
+		applied = true
+	}
+	return applied
+}
+
+// reservationsFromResults returns the addresses and MAC allocated in the container namespace, by network name.
+func reservationsFromResults(results map[string]*types100.Result) map[string]*reservationstore.Reservation {
+	reservations := make(map[string]*reservationstore.Reservation, len(results))
+	for name, result := range results {
+		reservation := &reservationstore.Reservation{}
+		for _, ipc := range result.IPs {
+			reservation.IPs = append(reservation.IPs, ipc.Address.IP.String())
+		}
+		for _, iface := range result.Interfaces {
+			if iface.Sandbox != "" {
+				reservation.MAC = iface.Mac
+				break
+			}
+		}
+		reservations[name] = reservation
+	}
+	return reservations
+}
+
 func applyNetworkSettings(opts *handlerOpts) error {
-	portMapOpts := getPortMapOpts(opts)
 	nsPath, err := getNetNSPath(opts.state)
 	if err != nil {
 		return err
 	}
 	ctx := context.Background()
-	hs, err := hostsstore.New(opts.dataStore, opts.state.Annotations[labels.Namespace])
+	ns := opts.state.Annotations[labels.Namespace]
+	hs, err := hostsstore.New(opts.dataStore, ns)
 	if err != nil {
 		return err
 	}
-	ipAddressOpts := getIPAddressOpts(opts)
-	macAddressOpts := getMACAddressOpts(opts)
-	ip6AddressOpts := getIP6AddressOpts(opts)
-	var namespaceOpts []cni.NamespaceOpts
-	namespaceOpts = append(namespaceOpts, portMapOpts...)
-	namespaceOpts = append(namespaceOpts, ipAddressOpts...)
-	namespaceOpts = append(namespaceOpts, macAddressOpts...)
-	namespaceOpts = append(namespaceOpts, ip6AddressOpts...)
-	namespaceOpts = append(namespaceOpts,
-		cni.WithLabels(map[string]string{
-			"IgnoreUnknown": "1",
-		}),
-		cni.WithArgs(version.EnvPrefix+"_CNI_DHCP_HOSTNAME", opts.state.Annotations[labels.Hostname]),
-	)
+
+	// Sticky addresses are only supported on a single network, as the CNI "ips" capability applies to all of them
+	var rs reservationstore.Store
+	reserved := false
+	explicitIP, explicitIP6, explicitMAC := opts.containerIP, opts.containerIP6, opts.containerMAC
+	if opts.ipSticky && len(opts.cniNames) > 1 {
+		log.L.Warnf("container %q is connected to multiple networks, its addresses will not be sticky", opts.state.ID)
+	} else if opts.ipSticky && len(opts.cniNames) == 1 {
+		if rs, err = reservationstore.New(opts.dataStore, ns); err != nil {
+			return err
+		}
+		reserved = applyReservation(rs, opts)
+	}
+
 	hsMeta := hostsstore.Meta{
 		ID:         opts.state.ID,
 		Networks:   make(map[string]*types100.Result, len(opts.cniNames)),
@@ -464,6 +570,7 @@ func applyNetworkSettings(opts *handlerOpts) error {
 		Domainname: opts.state.Annotations[labels.Domainname],
 		ExtraHosts: opts.extraHosts,
 		Name:       opts.state.Annotations[labels.Name],
+		Aliases:    opts.networkAliases,
 	}
 
 	// When containerd gets bounced, containers that were previously running and that are restarted will go again
@@ -475,9 +582,26 @@ func applyNetworkSettings(opts *handlerOpts) error {
 	// Thus, we do pre-emptively clean things up - error is not checked, as in the majority of cases, that would
 	// legitimately error (and that does not matter)
 	// See https://github.com/containerd/nerdctl/issues/3355
-	_ = opts.cni.Remove(ctx, opts.fullID, "", namespaceOpts...)
+	setup := func() (*cni.Result, error) {
+		namespaceOpts := append(getNamespaceOpts(opts),
+			cni.WithLabels(map[string]string{
+				"IgnoreUnknown": "1",
+			}),
+			cni.WithArgs(version.EnvPrefix+"_CNI_DHCP_HOSTNAME", opts.state.Annotations[labels.Hostname]),
+		)
+		_ = opts.cni.Remove(ctx, opts.fullID, "", namespaceOpts...)
+		return opts.cni.Setup(ctx, opts.fullID, nsPath, namespaceOpts...)
+	}
 
-	cniRes, err := opts.cni.Setup(ctx, opts.fullID, nsPath, namespaceOpts...)
+	cniRes, err := setup()
+	if err != nil && reserved {
+		// The reserved addresses may have been allocated to another container in the meantime
+		log.L.WithError(err).Warnf("failed to reuse the addresses reserved for container %q, allocating new ones",
+			opts.state.ID)
+		opts.containerIP, opts.containerIP6, opts.containerMAC = explicitIP, explicitIP6, explicitMAC
+		reserved = false
+		cniRes, err = setup()
+	}
 	if err != nil {
 		return fmt.Errorf("failed to call cni.Setup: %w", err)
 	}
@@ -486,6 +610,15 @@ func applyNetworkSettings(opts *handlerOpts) error {
 		hsMeta.Networks[cniName] = cniResRaw[i]
 	}
 
+	if rs != nil && !reserved {
+		if err := rs.Release(opts.state.ID); err != nil {
+			log.L.WithError(err).Warnf("failed to release the stale addresses of container %q", opts.state.ID)
+		}
+		if err := rs.Reserve(opts.state.ID, reservationsFromResults(hsMeta.Networks)); err != nil {
+			log.L.WithError(err).Warnf("failed to reserve the addresses of container %q", opts.state.ID)
+		}
+	}
+
 	b4nnEnabled, b4nnBindEnabled, err := bypass4netnsutil.IsBypass4netnsEnabled(opts.state.Annotations)
 	if err != nil {
 		return err
@@ -523,7 +656,7 @@ func applyNetworkSettings(opts *handlerOpts) error {
 }
 
 func onCreateRuntime(opts *handlerOpts) error {
-	loadAppArmor()
+	loadAppArmor(opts.state)
 
 	name := opts.state.Annotations[labels.Name]
 	ns := opts.state.Annotations[labels.Namespace]
@@ -582,6 +715,11 @@ func onPostStop(opts *handlerOpts) error {
 		return nil
 	}
 
+	// Unmount volumes with options this container was the last user of
+	if err := volumestore.UnmountContainerVolumes(opts.state.ID, opts.state.Annotations); err != nil {
+		log.L.WithError(err).Error("failed releasing volumes in onPostStop")
+	}
+
 	ctx := context.Background()
 	ns := opts.state.Annotations[labels.Namespace]
 	if opts.cni != nil {
@@ -611,16 +749,7 @@ func onPostStop(opts *handlerOpts) error {
 				}
 			}
 		}
-		portMapOpts := getPortMapOpts(opts)
-		ipAddressOpts := getIPAddressOpts(opts)
-		macAddressOpts := getMACAddressOpts(opts)
-		ip6AddressOpts := getIP6AddressOpts(opts)
-		var namespaceOpts []cni.NamespaceOpts
-		namespaceOpts = append(namespaceOpts, portMapOpts...)
-		namespaceOpts = append(namespaceOpts, ipAddressOpts...)
-		namespaceOpts = append(namespaceOpts, macAddressOpts...)
-		namespaceOpts = append(namespaceOpts, ip6AddressOpts...)
-		if err := opts.cni.Remove(ctx, opts.fullID, "", namespaceOpts...); err != nil {
+		if err := opts.cni.Remove(ctx, opts.fullID, "", getNamespaceOpts(opts)...); err != nil {
 			log.L.WithError(err).Errorf("failed to call cni.Remove")
 			return err
 		}
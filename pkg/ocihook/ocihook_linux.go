@@ -17,13 +17,19 @@
 package ocihook
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
 	"github.com/containerd/log"
 
+	"go.farcloser.world/containers/specs"
+
 	"go.farcloser.world/lepton/leptonic/services/apparmor"
 	"go.farcloser.world/lepton/pkg/defaults"
 )
 
-func loadAppArmor() {
+func loadAppArmor(state *specs.State) {
 	if err := apparmor.Load(defaults.AppArmorProfileName); err != nil {
 		log.L.WithError(err).Errorf("failed to load AppArmor profile %q", defaults.AppArmorProfileName)
 		// We do not abort here. This is by design, and not a security issue.
@@ -31,4 +37,23 @@ func loadAppArmor() {
 		// If the container is configured to use the default AppArmor profile
 		// but the profile was not actually loaded, runc will fail.
 	}
+
+	// Generated profiles do not survive a host reboot: regenerate them from the spec
+	data, err := os.ReadFile(filepath.Join(state.Bundle, "config.json"))
+	if err != nil {
+		log.L.WithError(err).Errorf("failed to read the spec of container %q", state.ID)
+		return
+	}
+
+	var spec specs.Spec
+	if err = json.Unmarshal(data, &spec); err != nil {
+		log.L.WithError(err).Errorf("failed to parse the spec of container %q", state.ID)
+		return
+	}
+
+	if apparmor.IsGenerated(state.ID, &spec) {
+		if err = apparmor.LoadGenerated(state.ID, &spec); err != nil {
+			log.L.WithError(err).Errorf("failed to load the generated AppArmor profile of container %q", state.ID)
+		}
+	}
 }
@@ -16,6 +16,10 @@
 
 package ocihook
 
-func loadAppArmor() {
+import (
+	"go.farcloser.world/containers/specs"
+)
+
+func loadAppArmor(_ *specs.State) {
 	// noop
 }
@@ -18,23 +18,41 @@ package statsutil
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 
 	"go.farcloser.world/containers/stats"
 	"go.farcloser.world/core/units"
+
+	"go.farcloser.world/lepton/leptonic/container"
 )
 
-// Rendering a FormattedStatsEntry from StatsEntry
-func RenderEntry(in *stats.Entry) FormattedStatsEntry {
-	return FormattedStatsEntry{
+// Rendering a FormattedStatsEntry from StatsEntry, and the detailed stats of the container (that may be nil)
+func RenderEntry(in *stats.Entry, detailed *container.Stats) FormattedStatsEntry {
+	res := FormattedStatsEntry{
 		Entry: *in,
 	}
+
+	if detailed != nil {
+		res.PSI = detailed.PSI
+		res.MemoryStats = detailed.Memory
+		res.Networks = detailed.Networks
+	}
+
+	return res
 }
 
 // FormattedStatsEntry represents a formatted StatsEntry
+// PSI, MemoryStats and Networks are available as-is to templates, eg: `{{.MemoryStats.Anon}}`,
+// or `{{(index .Networks "eth0").RxBytes}}`.
 type FormattedStatsEntry struct {
 	stats.Entry
+
+	// PSI is nil on cgroup v1, or when the kernel does not support pressure stall information
+	PSI         *container.PSI
+	MemoryStats container.MemoryBreakdown
+	Networks    map[string]container.NetworkInterface
 }
 
 func (s *FormattedStatsEntry) Name(noTrunc bool) string {
@@ -113,3 +131,93 @@ func (s *FormattedStatsEntry) PIDs() string {
 	}
 	return strconv.FormatUint(s.Entry.PidsCurrent, 10)
 }
+
+// CPUPressure returns the cpu "some" and "full" pressure over the last 10 seconds
+func (s *FormattedStatsEntry) CPUPressure() string {
+	if s.Entry.IsInvalid || s.PSI == nil {
+		return "--"
+	}
+	return formatPressure(s.PSI.CPU)
+}
+
+// MemPressure returns the memory "some" and "full" pressure over the last 10 seconds
+func (s *FormattedStatsEntry) MemPressure() string {
+	if s.Entry.IsInvalid || s.PSI == nil {
+		return "--"
+	}
+	return formatPressure(s.PSI.Memory)
+}
+
+// IOPressure returns the io "some" and "full" pressure over the last 10 seconds
+func (s *FormattedStatsEntry) IOPressure() string {
+	if s.Entry.IsInvalid || s.PSI == nil {
+		return "--"
+	}
+	return formatPressure(s.PSI.IO)
+}
+
+func (s *FormattedStatsEntry) MemAnon() string {
+	if s.Entry.IsInvalid {
+		return "--"
+	}
+	return units.BytesSize(float64(s.MemoryStats.Anon))
+}
+
+func (s *FormattedStatsEntry) MemFile() string {
+	if s.Entry.IsInvalid {
+		return "--"
+	}
+	return units.BytesSize(float64(s.MemoryStats.File))
+}
+
+func (s *FormattedStatsEntry) MemKernel() string {
+	if s.Entry.IsInvalid {
+		return "--"
+	}
+	return units.BytesSize(float64(s.MemoryStats.Kernel))
+}
+
+// OOMEvents returns the number of OOM events and the number of processes killed by the OOM killer
+func (s *FormattedStatsEntry) OOMEvents() string {
+	if s.Entry.IsInvalid {
+		return "--"
+	}
+	return fmt.Sprintf("%d / %d", s.MemoryStats.OOMEvents, s.MemoryStats.OOMKills)
+}
+
+// NetIOPerInterface returns the received and transmitted bytes for each network interface, sorted by name
+func (s *FormattedStatsEntry) NetIOPerInterface() string {
+	if s.Entry.IsInvalid || len(s.Networks) == 0 {
+		return "--"
+	}
+
+	names := make([]string, 0, len(s.Networks))
+	for name := range s.Networks {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	res := make([]string, 0, len(names))
+	for _, name := range names {
+		netif := s.Networks[name]
+		res = append(res, fmt.Sprintf(
+			"%s: %s / %s",
+			name,
+			units.HumanSizeWithPrecision(float64(netif.RxBytes), 3),
+			units.HumanSizeWithPrecision(float64(netif.TxBytes), 3),
+		))
+	}
+
+	return strings.Join(res, ", ")
+}
+
+func formatPressure(pressure container.ResourcePressure) string {
+	some, full := "--", "--"
+	if pressure.Some != nil {
+		some = fmt.Sprintf("%.2f%%", pressure.Some.Avg10)
+	}
+	if pressure.Full != nil {
+		full = fmt.Sprintf("%.2f%%", pressure.Full.Avg10)
+	}
+	return some + " / " + full
+}
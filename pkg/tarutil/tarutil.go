@@ -14,44 +14,96 @@
    limitations under the License.
 */
 
+// Package tarutil is an in-process tar engine, used by cp.
+// Archives preserve ownership, permissions, timestamps, extended attributes, hardlinks and device files.
 package tarutil
 
 import (
+	"archive/tar"
 	"errors"
-	"os"
-	"os/exec"
-	"strings"
-
-	"github.com/containerd/log"
+	"fmt"
+	"io"
 )
 
-// FindTarBinary returns a path to the tar binary and whether it is GNU tar.
-func FindTarBinary() (string, bool, error) {
-	isGNU := func(exe string) bool {
-		v, err := exec.Command(exe, "--version").Output()
+// ErrInvalidArchive is returned when an archive contains entries that cannot be safely extracted.
+var ErrInvalidArchive = errors.New("invalid archive")
+
+// xattrPrefix is the PAX record prefix used by GNU tar and bsdtar to store extended attributes
+const xattrPrefix = "SCHILY.xattr."
+
+// Owner is a pair of numeric user and group IDs.
+type Owner struct {
+	UID int
+	GID int
+}
+
+// IDMap translates an owner from one user namespace to another.
+type IDMap func(Owner) Owner
+
+// PackOptions controls how Pack archives a source.
+type PackOptions struct {
+	// Name is the name of the source in the archive.
+	// If ".", the source must be a directory and only its content is archived.
+	Name string
+	// FollowSymlink dereferences symlinks, archiving their target instead.
+	FollowSymlink bool
+	// Chown, if set, overrides the ownership of all entries.
+	Chown *Owner
+	// IDMap, if set, translates the ownership of all entries. It is ignored if Chown is set.
+	IDMap IDMap
+}
+
+// UnpackOptions controls how Unpack extracts an archive.
+type UnpackOptions struct {
+	// NoChown leaves extracted entries owned by the current user.
+	NoChown bool
+	// Chown, if set, overrides the ownership recorded in the archive.
+	Chown *Owner
+	// IDMap, if set, translates the ownership recorded in the archive. It is ignored if Chown is set.
+	IDMap IDMap
+}
+
+// Rewrite copies the archive read from r into w, calling fn on each header.
+// Entries for which fn returns false are dropped.
+func Rewrite(w io.Writer, r io.Reader, fn func(*tar.Header) bool) error {
+	tr := tar.NewReader(r)
+	tw := tar.NewWriter(w)
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
 		if err != nil {
-			log.L.Warnf("Failed to detect whether %q is GNU tar or not", exe)
-			return false
+			return errors.Join(ErrInvalidArchive, err)
 		}
-		if !strings.Contains(string(v), "GNU tar") {
-			log.L.Warnf("%q does not seem GNU tar", exe)
-			return false
+
+		if !fn(hdr) {
+			continue
 		}
-		return true
-	}
-	if v := os.Getenv("TAR"); v != "" {
-		if exe, err := exec.LookPath(v); err == nil {
-			return exe, isGNU(exe), nil
+
+		if err = tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if _, err = io.Copy(tw, tr); err != nil {
+			return fmt.Errorf("failed to copy %q: %w", hdr.Name, err)
 		}
 	}
-	if exe, err := exec.LookPath("gnutar"); err == nil {
-		return exe, true, nil
-	}
-	if exe, err := exec.LookPath("gtar"); err == nil {
-		return exe, true, nil
+
+	return tw.Close()
+}
+
+func owner(hdr *tar.Header, chown *Owner, idMap IDMap) Owner {
+	if chown != nil {
+		return *chown
 	}
-	if exe, err := exec.LookPath("tar"); err == nil {
-		return exe, isGNU(exe), nil
+
+	res := Owner{UID: hdr.Uid, GID: hdr.Gid}
+	if idMap != nil {
+		res = idMap(res)
 	}
-	return "", false, errors.New("failed to find `tar` binary")
+
+	return res
 }
@@ -0,0 +1,510 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package tarutil
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/containerd/log"
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"golang.org/x/sys/unix"
+)
+
+// sparseBlockSize is the granularity at which zeroed blocks are turned into holes on extraction
+const sparseBlockSize = 4096
+
+type inode struct {
+	dev uint64
+	ino uint64
+}
+
+// Pack writes a tar archive of src to w.
+// Sparse files are archived in full, as archive/tar cannot write sparse headers: holes are only restored by Unpack.
+func Pack(w io.Writer, src string, opts PackOptions) error {
+	pck := &packer{
+		tw:        tar.NewWriter(w),
+		opts:      opts,
+		links:     map[inode]string{},
+		ancestors: map[inode]bool{},
+	}
+
+	info, err := pck.stat(src)
+	if err != nil {
+		return err
+	}
+
+	if opts.Name == "" || opts.Name == "." {
+		if !info.IsDir() {
+			return fmt.Errorf("%q is not a directory", src)
+		}
+
+		err = pck.children(src, "", info)
+	} else {
+		err = pck.walk(src, path.Clean(opts.Name), info)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return pck.tw.Close()
+}
+
+type packer struct {
+	tw   *tar.Writer
+	opts PackOptions
+	// links maps inodes to the name of their first occurrence in the archive
+	links map[inode]string
+	// ancestors holds the directories being walked, to detect loops when following symlinks
+	ancestors map[inode]bool
+}
+
+func (pck *packer) stat(location string) (fs.FileInfo, error) {
+	if pck.opts.FollowSymlink {
+		info, err := os.Stat(location)
+		// Dangling symlinks are archived as is
+		if err == nil || !errors.Is(err, fs.ErrNotExist) {
+			return info, err
+		}
+	}
+
+	return os.Lstat(location)
+}
+
+func (pck *packer) walk(location, name string, info fs.FileInfo) error {
+	if err := pck.add(location, name, info); err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return nil
+	}
+
+	return pck.children(location, name, info)
+}
+
+func (pck *packer) children(location, name string, info fs.FileInfo) error {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		key := inode{dev: uint64(st.Dev), ino: st.Ino} //nolint:unconvert // Dev is uint32 on some architectures
+		if pck.ancestors[key] {
+			return fmt.Errorf("filesystem loop detected at %q", location)
+		}
+
+		pck.ancestors[key] = true
+		defer delete(pck.ancestors, key)
+	}
+
+	entries, err := os.ReadDir(location)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		childLocation := filepath.Join(location, entry.Name())
+		childName := entry.Name()
+		if name != "" {
+			childName = name + "/" + entry.Name()
+		}
+
+		childInfo, err := pck.stat(childLocation)
+		if err != nil {
+			return err
+		}
+
+		if err = pck.walk(childLocation, childName, childInfo); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (pck *packer) add(location, name string, info fs.FileInfo) error {
+	var link string
+	if info.Mode()&fs.ModeSymlink != 0 {
+		var err error
+		if link, err = os.Readlink(location); err != nil {
+			return err
+		}
+	}
+
+	hdr, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+
+	hdr.Name = name
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+
+	// Names are meaningless across user namespaces: only numeric IDs are kept
+	hdr.Uname = ""
+	hdr.Gname = ""
+	hdr.ChangeTime = time.Time{}
+	hdr.Format = tar.FormatPAX
+
+	if st, ok := info.Sys().(*syscall.Stat_t); ok && info.Mode().IsRegular() && st.Nlink > 1 {
+		key := inode{dev: uint64(st.Dev), ino: st.Ino} //nolint:unconvert // Dev is uint32 on some architectures
+		if first, found := pck.links[key]; found {
+			hdr.Typeflag = tar.TypeLink
+			hdr.Linkname = first
+			hdr.Size = 0
+		} else {
+			pck.links[key] = name
+		}
+	}
+
+	own := owner(hdr, pck.opts.Chown, pck.opts.IDMap)
+	hdr.Uid = own.UID
+	hdr.Gid = own.GID
+
+	xattrs, err := readXattrs(location)
+	if err != nil {
+		return fmt.Errorf("failed to read extended attributes of %q: %w", location, err)
+	}
+
+	for key, value := range xattrs {
+		if hdr.PAXRecords == nil {
+			hdr.PAXRecords = map[string]string{}
+		}
+		hdr.PAXRecords[xattrPrefix+key] = value
+	}
+
+	if err = pck.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	if hdr.Typeflag != tar.TypeReg {
+		return nil
+	}
+
+	file, err := os.Open(location)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(pck.tw, file)
+
+	return err
+}
+
+func readXattrs(location string) (map[string]string, error) {
+	size, err := unix.Llistxattr(location, nil)
+	if err != nil {
+		if errors.Is(err, unix.ENOTSUP) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	if size == 0 {
+		return nil, nil
+	}
+
+	buf := make([]byte, size)
+	if size, err = unix.Llistxattr(location, buf); err != nil {
+		return nil, err
+	}
+
+	res := map[string]string{}
+	for _, key := range strings.Split(string(buf[:size]), "\x00") {
+		// SELinux labels belong to the host policy, and are not carried over
+		if key == "" || key == "security.selinux" {
+			continue
+		}
+
+		size, err = unix.Lgetxattr(location, key, nil)
+		if err != nil {
+			if errors.Is(err, unix.ENODATA) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		value := make([]byte, size)
+		if size, err = unix.Lgetxattr(location, key, value); err != nil {
+			return nil, err
+		}
+
+		res[key] = string(value[:size])
+	}
+
+	return res, nil
+}
+
+// Unpack extracts the tar archive read from r into the directory dest.
+// Entries cannot escape dest, even through symlinks already present in dest.
+func Unpack(r io.Reader, dest string, opts UnpackOptions) error {
+	unp := &unpacker{
+		dest: dest,
+		opts: opts,
+	}
+
+	type deferredDir struct {
+		target string
+		hdr    *tar.Header
+	}
+
+	var dirs []deferredDir
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return errors.Join(ErrInvalidArchive, err)
+		}
+
+		if hdr.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+
+		target, err := unp.resolve(hdr.Name)
+		if err != nil {
+			return err
+		}
+
+		// The archive root is dest itself
+		if target == "" {
+			continue
+		}
+
+		created, err := unp.create(tr, hdr, target)
+		if err != nil {
+			return fmt.Errorf("failed to extract %q: %w", hdr.Name, err)
+		}
+
+		if !created {
+			continue
+		}
+
+		// Directories metadata is applied last, as extracting their content would change their modification time,
+		// and could be prevented by their permissions
+		if hdr.Typeflag == tar.TypeDir {
+			dirs = append(dirs, deferredDir{target: target, hdr: hdr})
+			continue
+		}
+
+		if err = unp.metadata(hdr, target); err != nil {
+			return fmt.Errorf("failed to extract %q: %w", hdr.Name, err)
+		}
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := unp.metadata(dirs[i].hdr, dirs[i].target); err != nil {
+			return fmt.Errorf("failed to extract %q: %w", dirs[i].hdr.Name, err)
+		}
+	}
+
+	return nil
+}
+
+type unpacker struct {
+	dest string
+	opts UnpackOptions
+}
+
+// resolve returns the location of an archive entry inside dest.
+// Symlinks in the parent directories are resolved (scoped to dest), but the entry itself is never followed.
+func (unp *unpacker) resolve(name string) (string, error) {
+	name = path.Clean("/" + name)
+	if name == "/" {
+		return "", nil
+	}
+
+	parent, err := securejoin.SecureJoin(unp.dest, path.Dir(name))
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(parent, path.Base(name)), nil
+}
+
+func (unp *unpacker) create(tr *tar.Reader, hdr *tar.Header, target string) (bool, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return false, err
+	}
+
+	// Existing entries are replaced, unless both are directories
+	if info, err := os.Lstat(target); err == nil {
+		if hdr.Typeflag == tar.TypeDir && info.IsDir() {
+			return true, nil
+		}
+
+		if err = os.RemoveAll(target); err != nil {
+			return false, err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return false, err
+	}
+
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		return true, os.Mkdir(target, 0o700)
+	case tar.TypeReg:
+		file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return false, err
+		}
+
+		err = copySparse(file, tr, hdr.Size)
+
+		return true, errors.Join(err, file.Close())
+	case tar.TypeLink:
+		source, err := unp.resolve(hdr.Linkname)
+		if err != nil {
+			return false, err
+		}
+
+		if source == "" {
+			return false, errors.Join(ErrInvalidArchive, fmt.Errorf("invalid hardlink target %q", hdr.Linkname))
+		}
+
+		return true, os.Link(source, target)
+	case tar.TypeSymlink:
+		return true, os.Symlink(hdr.Linkname, target)
+	case tar.TypeChar, tar.TypeBlock, tar.TypeFifo:
+		mode := uint32(hdr.Mode & 0o7777) //nolint:gosec // permission bits
+		switch hdr.Typeflag {
+		case tar.TypeChar:
+			mode |= unix.S_IFCHR
+		case tar.TypeBlock:
+			mode |= unix.S_IFBLK
+		default:
+			mode |= unix.S_IFIFO
+		}
+
+		//nolint:gosec // device numbers
+		dev := unix.Mkdev(uint32(hdr.Devmajor), uint32(hdr.Devminor))
+
+		return true, unix.Mknod(target, mode, int(dev)) //nolint:gosec // device numbers
+	default:
+		log.L.Warnf("skipping %q: unsupported tar entry type %q", hdr.Name, hdr.Typeflag)
+
+		return false, nil
+	}
+}
+
+func (unp *unpacker) metadata(hdr *tar.Header, target string) error {
+	// Hardlinks share the inode of their target, which is already set up
+	if hdr.Typeflag == tar.TypeLink {
+		return nil
+	}
+
+	// Ownership comes first, as changing it clears the setuid bits and file capabilities
+	if !unp.opts.NoChown {
+		own := owner(hdr, unp.opts.Chown, unp.opts.IDMap)
+		if err := os.Lchown(target, own.UID, own.GID); err != nil {
+			return err
+		}
+	}
+
+	if hdr.Typeflag != tar.TypeSymlink {
+		if err := os.Chmod(target, hdr.FileInfo().Mode()); err != nil {
+			return err
+		}
+	}
+
+	for key, value := range hdr.PAXRecords {
+		name, isXattr := strings.CutPrefix(key, xattrPrefix)
+		if !isXattr {
+			continue
+		}
+
+		if err := unix.Lsetxattr(target, name, []byte(value), 0); err != nil {
+			// Unsupported by the destination filesystem, or reserved to a privileged user
+			if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EPERM) || errors.Is(err, unix.EACCES) {
+				log.L.WithError(err).Debugf("ignoring extended attribute %q on %q", name, target)
+				continue
+			}
+
+			return err
+		}
+	}
+
+	accessTime := hdr.AccessTime
+	if accessTime.IsZero() {
+		accessTime = hdr.ModTime
+	}
+
+	times := []unix.Timespec{
+		unix.NsecToTimespec(accessTime.UnixNano()),
+		unix.NsecToTimespec(hdr.ModTime.UnixNano()),
+	}
+
+	return unix.UtimesNanoAt(unix.AT_FDCWD, target, times, unix.AT_SYMLINK_NOFOLLOW)
+}
+
+// copySparse copies size bytes from r to file, leaving holes in place of zeroed blocks.
+func copySparse(file *os.File, r io.Reader, size int64) error {
+	buf := make([]byte, 16*sparseBlockSize)
+	for {
+		read, readErr := io.ReadFull(r, buf)
+		for offset := 0; offset < read; offset += sparseBlockSize {
+			block := buf[offset:min(offset+sparseBlockSize, read)]
+
+			var err error
+			if isZero(block) {
+				_, err = file.Seek(int64(len(block)), io.SeekCurrent)
+			} else {
+				_, err = file.Write(block)
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+
+		if errors.Is(readErr, io.EOF) || errors.Is(readErr, io.ErrUnexpectedEOF) {
+			break
+		}
+
+		if readErr != nil {
+			return readErr
+		}
+	}
+
+	// A trailing hole is only materialized by setting the size
+	return file.Truncate(size)
+}
+
+func isZero(block []byte) bool {
+	for _, b := range block {
+		if b != 0 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,158 @@
+/*
+   Copyright Farcloser.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package tarutil_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"golang.org/x/sys/unix"
+	"gotest.tools/v3/assert"
+
+	"go.farcloser.world/lepton/pkg/tarutil"
+)
+
+func TestPackUnpack(t *testing.T) {
+	t.Parallel()
+
+	src := t.TempDir()
+	assert.NilError(t, os.MkdirAll(filepath.Join(src, "dir", "sub"), 0o750))
+	assert.NilError(t, os.WriteFile(filepath.Join(src, "dir", "file"), []byte("content"), 0o640))
+	assert.NilError(t, os.Link(filepath.Join(src, "dir", "file"), filepath.Join(src, "dir", "sub", "link")))
+	assert.NilError(t, os.Symlink("../file", filepath.Join(src, "dir", "sub", "symlink")))
+
+	// A 1MiB file with a single non-zero byte in the middle
+	sparse, err := os.Create(filepath.Join(src, "dir", "sparse"))
+	assert.NilError(t, err)
+	_, err = sparse.WriteAt([]byte{1}, 512*1024)
+	assert.NilError(t, err)
+	assert.NilError(t, sparse.Truncate(1024*1024))
+	assert.NilError(t, sparse.Close())
+
+	hasXattrs := unix.Lsetxattr(filepath.Join(src, "dir", "file"), "user.test", []byte("value"), 0) == nil
+
+	var archive bytes.Buffer
+	assert.NilError(t, tarutil.Pack(&archive, filepath.Join(src, "dir"), tarutil.PackOptions{Name: "copy"}))
+
+	dest := t.TempDir()
+	assert.NilError(t, tarutil.Unpack(&archive, dest, tarutil.UnpackOptions{NoChown: true}))
+
+	content, err := os.ReadFile(filepath.Join(dest, "copy", "file"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "content")
+
+	info, err := os.Stat(filepath.Join(dest, "copy", "file"))
+	assert.NilError(t, err)
+	assert.Equal(t, info.Mode().Perm(), os.FileMode(0o640))
+
+	info, err = os.Stat(filepath.Join(dest, "copy", "sub"))
+	assert.NilError(t, err)
+	assert.Equal(t, info.Mode().Perm(), os.FileMode(0o750))
+
+	link, err := os.Readlink(filepath.Join(dest, "copy", "sub", "symlink"))
+	assert.NilError(t, err)
+	assert.Equal(t, link, "../file")
+
+	var fileStat, linkStat syscall.Stat_t
+	assert.NilError(t, syscall.Stat(filepath.Join(dest, "copy", "file"), &fileStat))
+	assert.NilError(t, syscall.Stat(filepath.Join(dest, "copy", "sub", "link"), &linkStat))
+	assert.Equal(t, fileStat.Ino, linkStat.Ino)
+
+	var sparseStat syscall.Stat_t
+	assert.NilError(t, syscall.Stat(filepath.Join(dest, "copy", "sparse"), &sparseStat))
+	assert.Equal(t, sparseStat.Size, int64(1024*1024))
+	assert.Assert(t, sparseStat.Blocks*512 < sparseStat.Size, "sparse file was not extracted with holes")
+
+	if hasXattrs {
+		value := make([]byte, 16)
+		size, err := unix.Lgetxattr(filepath.Join(dest, "copy", "file"), "user.test", value)
+		assert.NilError(t, err)
+		assert.Equal(t, string(value[:size]), "value")
+	}
+}
+
+func TestPackContent(t *testing.T) {
+	t.Parallel()
+
+	src := t.TempDir()
+	assert.NilError(t, os.WriteFile(filepath.Join(src, "file"), []byte("content"), 0o600))
+
+	var archive bytes.Buffer
+	assert.NilError(t, tarutil.Pack(&archive, src, tarutil.PackOptions{
+		Name:  ".",
+		Chown: &tarutil.Owner{UID: 1234, GID: 5678},
+	}))
+
+	tr := tar.NewReader(&archive)
+	hdr, err := tr.Next()
+	assert.NilError(t, err)
+	assert.Equal(t, hdr.Name, "file")
+	assert.Equal(t, hdr.Uid, 1234)
+	assert.Equal(t, hdr.Gid, 5678)
+	assert.Equal(t, hdr.Uname, "")
+}
+
+func TestUnpackCannotEscape(t *testing.T) {
+	t.Parallel()
+
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	assert.NilError(t, tw.WriteHeader(&tar.Header{Name: "escape", Typeflag: tar.TypeSymlink, Linkname: "/"}))
+	assert.NilError(t, tw.WriteHeader(&tar.Header{Name: "escape/file", Typeflag: tar.TypeReg, Mode: 0o600}))
+	assert.NilError(t, tw.WriteHeader(&tar.Header{Name: "../../parent", Typeflag: tar.TypeReg, Mode: 0o600}))
+	assert.NilError(t, tw.Close())
+
+	root := t.TempDir()
+	dest := filepath.Join(root, "dest")
+	assert.NilError(t, os.Mkdir(dest, 0o700))
+	assert.NilError(t, tarutil.Unpack(&archive, dest, tarutil.UnpackOptions{NoChown: true}))
+
+	_, err := os.Lstat(filepath.Join(dest, "file"))
+	assert.NilError(t, err)
+	_, err = os.Lstat(filepath.Join(dest, "parent"))
+	assert.NilError(t, err)
+	_, err = os.Lstat(filepath.Join(root, "parent"))
+	assert.Assert(t, os.IsNotExist(err))
+}
+
+func TestRewrite(t *testing.T) {
+	t.Parallel()
+
+	src := t.TempDir()
+	assert.NilError(t, os.WriteFile(filepath.Join(src, "file"), []byte("content"), 0o600))
+	assert.NilError(t, os.WriteFile(filepath.Join(src, "dropped"), []byte("content"), 0o600))
+
+	var archive, rewritten bytes.Buffer
+	assert.NilError(t, tarutil.Pack(&archive, src, tarutil.PackOptions{Name: "."}))
+	assert.NilError(t, tarutil.Rewrite(&rewritten, &archive, func(hdr *tar.Header) bool {
+		hdr.Name = "prefix/" + hdr.Name
+
+		return hdr.Name != "prefix/dropped"
+	}))
+
+	tr := tar.NewReader(&rewritten)
+	hdr, err := tr.Next()
+	assert.NilError(t, err)
+	assert.Equal(t, hdr.Name, "prefix/file")
+	_, err = tr.Next()
+	assert.Equal(t, err, io.EOF)
+}